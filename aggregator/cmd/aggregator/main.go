@@ -1,34 +1,209 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"math/big"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/quiver/aggregator/internal/config"
+	"github.com/quiver/aggregator/pkg/anchor"
 	"github.com/quiver/aggregator/pkg/api"
+	"github.com/quiver/aggregator/pkg/audit"
+	"github.com/quiver/aggregator/pkg/blockchain"
 	"github.com/quiver/aggregator/pkg/epoch"
+	"github.com/quiver/aggregator/pkg/payout"
+	"github.com/quiver/aggregator/pkg/pricing"
+	"github.com/quiver/aggregator/pkg/registry"
+	"github.com/quiver/aggregator/pkg/sealer"
+	"github.com/quiver/aggregator/pkg/settlement"
+	"github.com/quiver/aggregator/pkg/slashing"
 	"github.com/quiver/aggregator/pkg/storage"
+	"github.com/quiver/aggregator/pkg/verify"
 )
 
 func main() {
 	cfg := config.DefaultConfig()
 
-	store := storage.NewStore()
-	epochManager := epoch.NewManager()
+	store, err := openStore(cfg)
+	if err != nil {
+		log.Fatal("Failed to open storage:", err)
+	}
+	defer store.Close()
+
+	epochManager, err := epoch.NewManagerWithPersister(store)
+	if err != nil {
+		log.Fatal("Failed to load epochs:", err)
+	}
+	if cfg.EpochSchedulePath != "" {
+		schedule, err := epoch.LoadSchedule(cfg.EpochSchedulePath)
+		if err != nil {
+			log.Fatal("Failed to load epoch schedule:", err)
+		}
+		if err := epochManager.SetSchedule(schedule); err != nil {
+			log.Fatal("Epoch schedule conflicts with stored epochs:", err)
+		}
+	}
 	handler := api.NewHandler(store, epochManager)
 
+	// Chains are rebuilt from the store, and the auditor keeps the receipts
+	// of the latest sealed epoch and later for its link checks
+	var sealed uint64
+	for _, info := range epochManager.ListEpochs("") {
+		if info.Finalized {
+			sealed = info.Epoch
+		}
+	}
+	auditor, err := audit.NewAuditorWithStore(audit.DefaultConfig(), store, sealed)
+	if err != nil {
+		log.Fatal("Failed to rebuild chain audit:", err)
+	}
+	handler.SetAuditor(auditor)
+
+	epochSealer := sealer.New(store, epochManager)
+	epochSealer.SetGrace(cfg.SealGrace)
+	epochSealer.OnSealed(func(e sealer.Event) { auditor.Forget(e.Epoch) })
+	if cfg.PayoutAddressesPath != "" {
+		addresses, err := payout.LoadAddresses(cfg.PayoutAddressesPath)
+		if err != nil {
+			log.Fatal("Failed to load payout addresses:", err)
+		}
+		epochSealer.SetAddresses(addresses)
+	}
+	price := pricing.DefaultBook().Price
+	if cfg.PricingPath != "" {
+		book, err := pricing.LoadBook(cfg.PricingPath)
+		if err != nil {
+			log.Fatal("Failed to load price tables:", err)
+		}
+		price = book.Price
+	}
+	var ledger *slashing.Ledger
+	if cfg.GatewayKeysPath != "" {
+		gateways, err := verify.LoadAllowlist(cfg.GatewayKeysPath)
+		if err != nil {
+			log.Fatal("Failed to load gateway keys:", err)
+		}
+		ledger, err = slashing.New(store, gateways, epochSealer)
+		if err != nil {
+			log.Fatal("Failed to load canary evidence:", err)
+		}
+		price = ledger.Price(price)
+		handler.SetLedger(ledger)
+		fmt.Printf("Accepting canary evidence from %d gateway keys\n", len(gateways))
+	}
+	epochSealer.SetPrice(price)
+	handler.SetSealer(epochSealer)
+
+	if err := handler.SetSignaturePolicy(cfg.SignaturePolicy); err != nil {
+		log.Fatal(err)
+	}
+	if cfg.RequireStake && cfg.SettlementRPC == "" {
+		log.Fatal("QUIVER_REQUIRE_STAKE needs QUIVER_SETTLEMENT_RPC")
+	}
+	if cfg.SlashAmount != "" && (ledger == nil || cfg.SettlementRPC == "") {
+		log.Fatal("QUIVER_SLASH_AMOUNT needs QUIVER_GATEWAY_KEYS and QUIVER_SETTLEMENT_RPC")
+	}
+	verifier := verify.NewVerifier(nil)
+	if cfg.ProviderAllowlistPath != "" && !cfg.RequireStake {
+		allowlist, err := verify.LoadAllowlist(cfg.ProviderAllowlistPath)
+		if err != nil {
+			log.Fatal("Failed to load provider allowlist:", err)
+		}
+		verifier = verify.NewVerifier(allowlist)
+		fmt.Printf("Accepting receipts from %d registered provider keys\n", len(allowlist))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if cfg.SettlementRPC != "" {
+		chainConfig := blockchain.DefaultPolygonConfig()
+		chainConfig.RPCEndpoint = cfg.SettlementRPC
+		chainConfig.ContractAddress = cfg.SettlementContract
+		chainConfig.PrivateKey = cfg.SettlementKey
+		chainConfig.ChainID = cfg.ChainID
+		client, err := blockchain.NewClient(chainConfig)
+		if err != nil {
+			log.Fatal("Failed to connect to settlement chain:", err)
+		}
+		defer client.Close()
+
+		settler := settlement.New(store, client)
+		handler.SetSettler(settler, cfg.ClaimWait)
+		go settler.Run(ctx, settlement.DefaultPollInterval)
+
+		if cfg.RequireStake {
+			// A receipt can be disputed until its epoch is anchored: up to
+			// an epoch, the seal grace and the anchor delay after it is made
+			start, end, err := epochManager.Schedule().Bounds(epochManager.CurrentEpoch())
+			if err != nil {
+				log.Fatal("Failed to read the epoch length:", err)
+			}
+			providers := registry.New(client)
+			if err := providers.CheckUnbonding(ctx, end.Sub(start)+cfg.SealGrace+cfg.AnchorDelay); err != nil {
+				log.Fatal("Settlement contract cannot hold stakes through disputes:", err)
+			}
+			verifier = verify.NewVerifier(providers)
+			handler.SetRegistry(providers)
+			fmt.Println("Accepting receipts from staked providers only")
+		}
+
+		if cfg.SlashAmount != "" {
+			amount, ok := new(big.Int).SetString(cfg.SlashAmount, 10)
+			if !ok || amount.Sign() <= 0 {
+				log.Fatal("Invalid QUIVER_SLASH_AMOUNT:", cfg.SlashAmount)
+			}
+			ledger.SetBackend(client, amount)
+			go ledger.Run(ctx, settlement.DefaultPollInterval)
+			fmt.Printf("Slashing %s from a provider's stake per failed canary\n", amount)
+		}
+
+		anchorer := anchor.New(client, epochManager)
+		anchorer.SetDelay(cfg.AnchorDelay)
+		anchorer.SetPayouts(epochSealer.Payouts)
+		epochSealer.OnSealed(anchorer.Notify)
+		go anchorer.Run(ctx, cfg.SealInterval)
+		fmt.Printf("Paying claims and anchoring epochs through %s\n", cfg.SettlementContract)
+	}
+	// Evidence follows the key rotations and revocations ingestion learns
+	handler.SetVerifier(verifier)
+	if ledger != nil {
+		ledger.SetKeyHistory(verifier.KeyHistory())
+	}
+
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
 	router.Use(gin.Recovery())
 
 	router.POST("/commit", handler.Commit)
+	router.POST("/receipts", handler.Ingest)
+	router.GET("/receipts", handler.ListReceipts)
+	router.GET("/receipts/:id/proof", handler.ReceiptProof)
+	router.GET("/providers/:pk", handler.ProviderRegistration)
+	router.GET("/providers/:pk/summary", handler.ProviderSummary)
 	router.POST("/claim", handler.Claim)
-	router.GET("/state", handler.GetState)
+	router.GET("/claims/:id", handler.GetClaim)
+	router.GET("/epochs", handler.ListEpochs)
+	router.GET("/epochs/:epoch", handler.GetEpoch)
+	router.POST("/epochs/:epoch/multiproof", handler.Multiproof)
+	router.GET("/epochs/:epoch/payouts", handler.Payouts)
+	router.GET("/epochs/:epoch/payouts/:address", handler.PayoutClaim)
 	router.GET("/health", handler.Health)
+	router.GET("/audit/findings", handler.AuditFindings)
+	router.GET("/audit/chains", handler.AuditChains)
+	router.GET("/quarantine", handler.Quarantine)
+	router.POST("/evidence", handler.SubmitEvidence)
+	router.GET("/evidence", handler.ListEvidence)
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
+	go epochSealer.Run(ctx, cfg.SealInterval)
 
 	fmt.Printf("Aggregator started on port %s\n", cfg.Port)
 
@@ -44,3 +219,19 @@ func main() {
 
 	fmt.Println("Shutting down...")
 }
+
+func openStore(cfg *config.Config) (storage.Store, error) {
+	switch cfg.StorageBackend {
+	case "memory":
+		return storage.NewStore(), nil
+	case "bolt":
+		if err := os.MkdirAll(cfg.StoragePath, 0700); err != nil {
+			return nil, err
+		}
+		path := filepath.Join(cfg.StoragePath, "aggregator.db")
+		fmt.Printf("Storing receipts in %s\n", path)
+		return storage.OpenBoltStore(path)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.StorageBackend)
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/quiver/aggregator/pkg/audit"
+	"github.com/quiver/aggregator/pkg/jcs"
+	"github.com/quiver/aggregator/pkg/merkle"
+	"github.com/quiver/aggregator/pkg/storage"
+	"github.com/quiver/aggregator/pkg/verify"
+)
+
+// Check results.
+const (
+	StatusPass = "pass"
+	StatusFail = "fail"
+	StatusSkip = "skip"
+)
+
+// Check is the outcome of one rule applied to one receipt.
+type Check struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// ReceiptReport lists the checks run on one receipt.
+type ReceiptReport struct {
+	Source     string  `json:"source"`
+	ReceiptID  string  `json:"receipt_id"`
+	ProviderPK string  `json:"provider_pk"`
+	Seq        int64   `json:"seq"`
+	Checks     []Check `json:"checks"`
+}
+
+// Report is the result of a verification run. Valid is false if any check
+// failed or the receipts break a chain rule.
+type Report struct {
+	Valid    bool            `json:"valid"`
+	Receipts []ReceiptReport `json:"receipts"`
+	Chain    []audit.Finding `json:"chain_findings"`
+}
+
+// input is a receipt and where it was read from.
+type input struct {
+	source string
+	signed storage.SignedReceipt
+}
+
+// options are the optional facts receipts are checked against.
+type options struct {
+	// identity, if set, is the provider key every receipt must name
+	identity string
+	// proofs maps receipt IDs to Merkle inclusion proofs under root
+	proofs map[string]*merkle.Proof
+	root   string
+	// prompt and output, if set, are the texts the hashes must match
+	prompt *string
+	output *string
+}
+
+func check(inputs []input, opts options) *Report {
+	report := &Report{Valid: true, Receipts: make([]ReceiptReport, 0, len(inputs))}
+
+	// Learn every succession chain first so revocations apply to receipts
+	// that do not carry them
+	history := verify.NewKeyHistory()
+	historyErrs := make(map[string]error)
+	for _, in := range inputs {
+		pk := in.signed.Receipt.ProviderPK
+		if err := history.Learn(pk, in.signed.Succession); err != nil && historyErrs[pk] == nil {
+			historyErrs[pk] = err
+		}
+	}
+
+	for _, in := range inputs {
+		r := &in.signed.Receipt
+		rr := ReceiptReport{
+			Source:     in.source,
+			ReceiptID:  r.ReceiptID,
+			ProviderPK: r.ProviderPK,
+			Seq:        r.Seq,
+		}
+
+		rr.Checks = append(rr.Checks, checkSignature(&in.signed, opts.identity, history, historyErrs[r.ProviderPK]))
+		if len(in.signed.CoSignatures) > 0 {
+			rr.Checks = append(rr.Checks, checkCoSignatures(&in.signed))
+		}
+		if opts.root != "" || len(opts.proofs) > 0 {
+			rr.Checks = append(rr.Checks, checkInclusion(r, opts.proofs[r.ReceiptID], opts.root))
+		}
+		if opts.prompt != nil {
+			rr.Checks = append(rr.Checks, checkHash("prompt_hash", r.PromptHash, *opts.prompt))
+		}
+		if opts.output != nil {
+			if r.Stream != nil {
+				rr.Checks = append(rr.Checks, Check{
+					Name:   "output_hash",
+					Status: StatusSkip,
+					Detail: "streamed receipts hash the output chunk by chunk",
+				})
+			} else {
+				rr.Checks = append(rr.Checks, checkHash("output_hash", r.OutputHash, *opts.output))
+			}
+		}
+
+		for _, c := range rr.Checks {
+			if c.Status == StatusFail {
+				report.Valid = false
+			}
+		}
+		report.Receipts = append(report.Receipts, rr)
+	}
+
+	report.Chain = checkChain(inputs)
+	if len(report.Chain) > 0 {
+		report.Valid = false
+	}
+	return report
+}
+
+func checkSignature(signed *storage.SignedReceipt, identity string, history *verify.KeyHistory, historyErr error) Check {
+	c := Check{Name: "signature", Status: StatusFail}
+	pk := signed.Receipt.ProviderPK
+
+	if identity != "" && pk != identity {
+		c.Detail = fmt.Sprintf("provider_pk %s is not the expected provider", pk)
+		return c
+	}
+	if historyErr != nil {
+		c.Detail = fmt.Sprintf("key history: %v", historyErr)
+		return c
+	}
+
+	valid, err := verify.VerifySignatureChain(signed, pk, history.Chain(pk))
+	switch {
+	case err != nil:
+		c.Detail = err.Error()
+	case !valid:
+		c.Detail = verify.ErrInvalidSignature.Error()
+	default:
+		c.Status = StatusPass
+	}
+	return c
+}
+
+func checkCoSignatures(signed *storage.SignedReceipt) Check {
+	c := Check{Name: "cosignatures", Status: StatusFail}
+	valid, err := verify.VerifyCoSignatures(signed)
+	switch {
+	case err != nil:
+		c.Detail = err.Error()
+	case !valid:
+		c.Detail = verify.ErrInvalidCoSignature.Error()
+	default:
+		c.Status = StatusPass
+		c.Detail = fmt.Sprintf("%d co-signatures", len(signed.CoSignatures))
+	}
+	return c
+}
+
+// checkInclusion verifies a Merkle proof for r under root. The leaf is the
+// receipt's canonical JSON, as the aggregator commits it.
+func checkInclusion(r *storage.Receipt, proof *merkle.Proof, root string) Check {
+	c := Check{Name: "inclusion", Status: StatusFail}
+	switch {
+	case proof == nil:
+		c.Detail = "no proof for this receipt"
+		return c
+	case root == "":
+		c.Detail = "no epoch root given"
+		return c
+	}
+
+	canonical, err := jcs.Canonicalize(r)
+	if err != nil {
+		c.Detail = err.Error()
+		return c
+	}
+	if !merkle.Verify(canonical, proof, root) {
+		c.Detail = "proof does not lead to the epoch root"
+		return c
+	}
+	c.Status = StatusPass
+	c.Detail = fmt.Sprintf("leaf %d of %d", proof.LeafIndex, proof.TreeSize)
+	return c
+}
+
+func checkHash(name, want, text string) Check {
+	sum := sha256.Sum256([]byte(text))
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return Check{Name: name, Status: StatusFail, Detail: fmt.Sprintf("text hashes to %s", got)}
+	}
+	return Check{Name: name, Status: StatusPass}
+}
+
+// checkChain runs the aggregator's chain audit over the receipts in
+// sequence order. Receipts outside the hash chain (seq 0, stream
+// checkpoints) are left out.
+func checkChain(inputs []input) []audit.Finding {
+	chained := make([]*storage.SignedReceipt, 0, len(inputs))
+	for i := range inputs {
+		if inputs[i].signed.Receipt.Seq > 0 {
+			chained = append(chained, &inputs[i].signed)
+		}
+	}
+	sort.SliceStable(chained, func(i, j int) bool {
+		a, b := &chained[i].Receipt, &chained[j].Receipt
+		if a.ProviderPK != b.ProviderPK {
+			return a.ProviderPK < b.ProviderPK
+		}
+		return a.Seq < b.Seq
+	})
+
+	auditor := audit.NewAuditor(audit.DefaultConfig())
+	for _, signed := range chained {
+		auditor.Observe(signed)
+	}
+
+	// Findings come newest first
+	found := auditor.Findings(audit.Filter{Unresolved: true})
+	for i, j := 0, len(found)-1; i < j; i, j = i+1, j-1 {
+		found[i], found[j] = found[j], found[i]
+	}
+	return found
+}
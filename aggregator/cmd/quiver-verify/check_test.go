@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/quiver/aggregator/pkg/audit"
+	"github.com/quiver/aggregator/pkg/jcs"
+	"github.com/quiver/aggregator/pkg/merkle"
+	"github.com/quiver/aggregator/pkg/storage"
+	"github.com/quiver/aggregator/pkg/verify"
+)
+
+var testProviderKey = ed25519.NewKeyFromSeed(bytes.Repeat([]byte{7}, ed25519.SeedSize))
+
+func hashText(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// signedChain returns n linked v2 receipts from seq 1, signed by
+// testProviderKey.
+func signedChain(t *testing.T, n int) []*storage.SignedReceipt {
+	t.Helper()
+	pub := testProviderKey.Public().(ed25519.PublicKey)
+	start := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	var chain []*storage.SignedReceipt
+	prevHash := ""
+	for i := 1; i <= n; i++ {
+		end := start.Add(time.Duration(i) * time.Second)
+		r := storage.Receipt{
+			Version:    verify.VersionV2,
+			ProviderPK: base64.StdEncoding.EncodeToString(pub),
+			Model:      "llama3.2:3b",
+			PromptHash: hashText("prompt"),
+			OutputHash: hashText("output"),
+			TokensIn:   3,
+			TokensOut:  7,
+			StartISO:   start.Format(time.RFC3339),
+			EndISO:     end.Format(time.RFC3339),
+			Seq:        int64(i),
+			PrevHash:   prevHash,
+			ReceiptID:  "receipt-" + string(rune('0'+i)),
+		}
+		signed := &storage.SignedReceipt{Receipt: r, Algorithm: verify.AlgEd25519, KeyID: verify.KeyID(pub)}
+		input, err := verify.SigningInput(&signed.Receipt, signed.Algorithm, signed.KeyID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signed.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(testProviderKey, input))
+
+		prevHash, _ = audit.ChainHash(&signed.Receipt)
+		chain = append(chain, signed)
+	}
+	return chain
+}
+
+func inputsOf(chain []*storage.SignedReceipt) []input {
+	inputs := make([]input, len(chain))
+	for i, signed := range chain {
+		inputs[i] = input{source: signed.Receipt.ReceiptID, signed: *signed}
+	}
+	return inputs
+}
+
+func status(rr ReceiptReport, name string) string {
+	for _, c := range rr.Checks {
+		if c.Name == name {
+			return c.Status
+		}
+	}
+	return ""
+}
+
+func TestCheckReceiptVectors(t *testing.T) {
+	for _, file := range []string{"receipts.json", "rotation.json"} {
+		data, err := os.ReadFile("../../../testvectors/" + file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var vectors struct {
+			Cases []struct {
+				Name   string                `json:"name"`
+				Signed storage.SignedReceipt `json:"signed"`
+				Valid  bool                  `json:"valid"`
+			} `json:"cases"`
+		}
+		if err := json.Unmarshal(data, &vectors); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, tc := range vectors.Cases {
+			t.Run(file+"/"+tc.Name, func(t *testing.T) {
+				report := check([]input{{source: tc.Name, signed: tc.Signed}}, options{})
+				got := status(report.Receipts[0], "signature") == StatusPass
+				if got != tc.Valid {
+					t.Errorf("signature check %v, want %v: %+v", got, tc.Valid, report.Receipts[0].Checks)
+				}
+			})
+		}
+	}
+}
+
+func TestCheckChainContinuity(t *testing.T) {
+	chain := signedChain(t, 3)
+
+	report := check(inputsOf(chain), options{})
+	if !report.Valid || len(report.Chain) != 0 {
+		t.Fatalf("intact chain rejected: %+v", report.Chain)
+	}
+
+	// Drop seq 2
+	report = check(inputsOf([]*storage.SignedReceipt{chain[2], chain[0]}), options{})
+	if report.Valid || len(report.Chain) != 1 || report.Chain[0].Kind != audit.KindGap {
+		t.Fatalf("gap not reported: %+v", report.Chain)
+	}
+}
+
+func TestCheckInclusionAndText(t *testing.T) {
+	chain := signedChain(t, 2)
+	tree := merkle.NewTree()
+	for _, signed := range chain {
+		canonical, err := jcs.Canonicalize(&signed.Receipt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tree.AddLeaf(canonical)
+	}
+	if err := tree.Build(); err != nil {
+		t.Fatal(err)
+	}
+	proof, _ := tree.Proof(1)
+
+	prompt, output := "prompt", "not the output"
+	opts := options{
+		proofs: map[string]*merkle.Proof{chain[1].Receipt.ReceiptID: proof},
+		root:   tree.Root(),
+		prompt: &prompt,
+		output: &output,
+	}
+	rr := check(inputsOf(chain[1:2]), opts).Receipts[0]
+	if status(rr, "inclusion") != StatusPass || status(rr, "prompt_hash") != StatusPass {
+		t.Errorf("checks: %+v", rr.Checks)
+	}
+	if status(rr, "output_hash") != StatusFail {
+		t.Errorf("wrong output accepted: %+v", rr.Checks)
+	}
+
+	opts.root = hashText("another root")
+	rr = check(inputsOf(chain[1:2]), opts).Receipts[0]
+	if status(rr, "inclusion") != StatusFail {
+		t.Errorf("proof accepted under the wrong root: %+v", rr.Checks)
+	}
+}
+
+func TestRunJSONOutput(t *testing.T) {
+	chain := signedChain(t, 2)
+	dir := t.TempDir()
+
+	// A journal file: one entry per line with the receipt wrapped
+	var journal bytes.Buffer
+	for _, signed := range chain {
+		line, _ := json.Marshal(map[string]interface{}{"seq": signed.Receipt.Seq, "receipt": signed})
+		journal.Write(append(line, '\n'))
+	}
+	path := filepath.Join(dir, "receipts.jsonl")
+	if err := os.WriteFile(path, journal.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-json", dir}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d: %s%s", code, stdout.String(), stderr.String())
+	}
+	var report Report
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if !report.Valid || len(report.Receipts) != 2 || report.Receipts[1].Source != path+":2" {
+		t.Errorf("unexpected report: %+v", report)
+	}
+
+	// Tampering fails the run
+	chain[1].Receipt.TokensOut++
+	tampered, _ := json.Marshal(chain[1])
+	if err := os.WriteFile(path, tampered, 0600); err != nil {
+		t.Fatal(err)
+	}
+	stdout.Reset()
+	if code := run([]string{path}, &stdout, &stderr); code != 1 {
+		t.Errorf("exit %d for a tampered receipt:\n%s", code, stdout.String())
+	}
+
+	if code := run(nil, &stdout, &stderr); code != 2 {
+		t.Errorf("exit %d without arguments", code)
+	}
+}
+
+func TestRunWithProofFile(t *testing.T) {
+	chain := signedChain(t, 3)
+	tree := merkle.NewTree()
+	for _, signed := range chain {
+		canonical, _ := jcs.Canonicalize(&signed.Receipt)
+		tree.AddLeaf(canonical)
+	}
+	if err := tree.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	receiptPath := filepath.Join(dir, "receipt.json")
+	data, _ := json.Marshal(chain[2])
+	os.WriteFile(receiptPath, data, 0600)
+
+	// A single proof, as the aggregator stores it
+	proof, _ := tree.Proof(2)
+	proofPath := filepath.Join(dir, "proof.json")
+	data, _ = json.Marshal(proof)
+	os.WriteFile(proofPath, data, 0600)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-proof", proofPath, "-root", tree.Root(), receiptPath}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d: %s%s", code, stdout.String(), stderr.String())
+	}
+
+	// Proofs keyed by receipt ID
+	byID := map[string]*merkle.Proof{chain[2].Receipt.ReceiptID: proof}
+	data, _ = json.Marshal(byID)
+	os.WriteFile(proofPath, data, 0600)
+	stdout.Reset()
+	if code := run([]string{"-proof", proofPath, "-root", tree.Root(), receiptPath}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d: %s%s", code, stdout.String(), stderr.String())
+	}
+
+	// The proof of another leaf fails inclusion
+	other, _ := tree.Proof(1)
+	data, _ = json.Marshal(other)
+	os.WriteFile(proofPath, data, 0600)
+	stdout.Reset()
+	if code := run([]string{"-proof", proofPath, "-root", tree.Root(), receiptPath}, &stdout, &stderr); code != 1 {
+		t.Errorf("exit %d for another leaf's proof:\n%s", code, stdout.String())
+	}
+}
@@ -0,0 +1,255 @@
+// Command quiver-verify checks provider receipts offline: signatures and key
+// succession, hash chain continuity, Merkle inclusion in an epoch root and,
+// given the original texts, the prompt and output hashes.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/quiver/aggregator/pkg/merkle"
+	"github.com/quiver/aggregator/pkg/storage"
+)
+
+const usage = `usage: quiver-verify [flags] <receipt.json | receipts.jsonl | dir>...
+
+Receipts may be signed receipt envelopes, provider journal entries or
+provider responses, one per .json file or one per line of a .jsonl file.
+Directories are searched for both.
+
+Exit status is 0 if every check passes, 1 if any fails and 2 on bad input.
+
+flags:
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("quiver-verify", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+	jsonOut := fs.Bool("json", false, "write the report as JSON")
+	identity := fs.String("provider", "", "base64 provider key every receipt must name")
+	proofPath := fs.String("proof", "", "Merkle proof file: a proof object as the aggregator stores it, or an object mapping receipt IDs to proofs")
+	root := fs.String("root", "", "hex epoch Merkle root the proofs lead to")
+	promptPath := fs.String("prompt", "", "file holding the prompt text")
+	outputPath := fs.String("output", "", "file holding the output text")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	inputs, err := loadReceipts(fs.Args())
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	if len(inputs) == 0 {
+		fmt.Fprintln(stderr, "no receipts found")
+		return 2
+	}
+
+	opts := options{identity: *identity, root: *root}
+	if *proofPath != "" {
+		if opts.proofs, err = loadProofs(*proofPath, inputs); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+	}
+	if *promptPath != "" || *outputPath != "" {
+		if len(inputs) != 1 {
+			fmt.Fprintln(stderr, "-prompt and -output need exactly one receipt")
+			return 2
+		}
+		if opts.prompt, err = readText(*promptPath); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+		if opts.output, err = readText(*outputPath); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+	}
+
+	report := check(inputs, opts)
+	if *jsonOut {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	} else {
+		printReport(stdout, report)
+	}
+
+	if !report.Valid {
+		return 1
+	}
+	return 0
+}
+
+// loadReceipts reads receipts from files and directories in the order given.
+func loadReceipts(paths []string) ([]input, error) {
+	var inputs []input
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		files := []string{path}
+		if info.IsDir() {
+			files = nil
+			for _, pattern := range []string{"*.json", "*.jsonl"} {
+				matches, err := filepath.Glob(filepath.Join(path, pattern))
+				if err != nil {
+					return nil, err
+				}
+				files = append(files, matches...)
+			}
+			sort.Strings(files)
+		}
+
+		for _, file := range files {
+			found, err := loadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			inputs = append(inputs, found...)
+		}
+	}
+	return inputs, nil
+}
+
+func loadFile(path string) ([]input, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if filepath.Ext(path) != ".jsonl" {
+		signed, err := decodeReceipt(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return []input{{source: path, signed: *signed}}, nil
+	}
+
+	var inputs []input
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		signed, err := decodeReceipt(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		inputs = append(inputs, input{source: fmt.Sprintf("%s:%d", path, line), signed: *signed})
+	}
+	return inputs, scanner.Err()
+}
+
+// decodeReceipt accepts a signed receipt, or any object that wraps one in a
+// "receipt" field such as a journal entry or a provider response.
+func decodeReceipt(data []byte) (*storage.SignedReceipt, error) {
+	var probe struct {
+		Signature string          `json:"signature"`
+		Receipt   json.RawMessage `json:"receipt"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, err
+	}
+	if probe.Signature == "" && len(probe.Receipt) > 0 {
+		return decodeReceipt(probe.Receipt)
+	}
+	if probe.Signature == "" {
+		return nil, errors.New("no signed receipt found")
+	}
+
+	var signed storage.SignedReceipt
+	if err := json.Unmarshal(data, &signed); err != nil {
+		return nil, err
+	}
+	return &signed, nil
+}
+
+// loadProofs reads a proof file. A bare array is the proof of the only
+// receipt given.
+func loadProofs(path string, inputs []input) (map[string]*merkle.Proof, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var single merkle.Proof
+	if err := json.Unmarshal(data, &single); err == nil && single.Hashes != nil {
+		if len(inputs) != 1 {
+			return nil, errors.New("a single proof needs exactly one receipt; map receipt IDs to proofs instead")
+		}
+		return map[string]*merkle.Proof{inputs[0].signed.Receipt.ReceiptID: &single}, nil
+	}
+
+	var byID map[string]*merkle.Proof
+	if err := json.Unmarshal(data, &byID); err != nil {
+		return nil, fmt.Errorf("%s: expected a proof or an object of proofs", path)
+	}
+	return byID, nil
+}
+
+func readText(path string) (*string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	text := string(data)
+	return &text, nil
+}
+
+func printReport(w io.Writer, report *Report) {
+	for _, rr := range report.Receipts {
+		fmt.Fprintf(w, "%s: receipt %s seq %d\n", rr.Source, rr.ReceiptID, rr.Seq)
+		for _, c := range rr.Checks {
+			line := fmt.Sprintf("  %-13s %s", c.Name, strings.ToUpper(c.Status))
+			if c.Detail != "" {
+				line += "  " + c.Detail
+			}
+			fmt.Fprintln(w, line)
+		}
+	}
+
+	if len(report.Chain) == 0 {
+		fmt.Fprintln(w, "chain: ok")
+	} else {
+		fmt.Fprintf(w, "chain: %d findings\n", len(report.Chain))
+		for _, f := range report.Chain {
+			fmt.Fprintf(w, "  %-15s %s seq %d  %s\n", f.Kind, f.ProviderPK, f.Seq, f.Detail)
+		}
+	}
+
+	if report.Valid {
+		fmt.Fprintln(w, "result: valid")
+	} else {
+		fmt.Fprintln(w, "result: INVALID")
+	}
+}
@@ -1,13 +1,158 @@
 package config
 
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
 type Config struct {
 	Port        string
 	StoragePath string
+	// StorageBackend is "bolt", which keeps a database under StoragePath,
+	// or "memory", which loses everything on exit
+	StorageBackend string
+	// SignaturePolicy is "reject" or "quarantine"
+	SignaturePolicy string
+	// SealGrace is how long after an epoch ends it still accepts receipts;
+	// SealInterval is how often the sealer looks for epochs past it
+	SealGrace    time.Duration
+	SealInterval time.Duration
+	// ProviderAllowlistPath, if set, names a file of base64 provider keys
+	// allowed to submit receipts, one per line
+	ProviderAllowlistPath string
+	// EpochSchedulePath, if set, names a JSON epoch schedule; without one
+	// epochs are whole UTC days. Providers must use the same schedule.
+	EpochSchedulePath string
+	// PayoutAddressesPath, if set, names a file pairing provider keys with
+	// the addresses their payouts go to; see payout.LoadAddresses
+	PayoutAddressesPath string
+	// PricingPath, if set, names a JSON book of epoch-versioned price
+	// tables; see pricing.LoadBook. Without one every token earns 100
+	// base units.
+	PricingPath string
+	// SettlementRPC, if set, is the node claims are paid through, by the
+	// SimpleSettlement contract at SettlementContract on chain ChainID,
+	// signed with the hex key SettlementKey. Without it claims are refused.
+	SettlementRPC      string
+	SettlementContract string
+	SettlementKey      string
+	ChainID            int64
+	// AnchorDelay is how long after it is finalized or amended an epoch's
+	// root waits before it is published through SettlementRPC. Anchored
+	// epochs can no longer be amended.
+	AnchorDelay time.Duration
+	// ClaimWait is how long a claim request waits for its payment to
+	// confirm before answering that it is pending
+	ClaimWait time.Duration
+	// RequireStake accepts receipts only from provider keys staked on the
+	// settlement contract, for models they declared; see package registry.
+	// It needs SettlementRPC and replaces ProviderAllowlistPath.
+	RequireStake bool
+	// GatewayKeysPath, if set, names a file of base64 gateway keys whose
+	// canary evidence is accepted, one per line. Without it evidence is
+	// refused.
+	GatewayKeysPath string
+	// SlashAmount is how many base units each piece of evidence slashes
+	// from the provider's stake, as a decimal string. It needs
+	// SettlementRPC; without it evidence only forfeits rewards.
+	SlashAmount string
 }
 
 func DefaultConfig() *Config {
-	return &Config{
-		Port:        "8081",
-		StoragePath: "./data",
+	cfg := &Config{
+		Port:            "8081",
+		StoragePath:     "./data",
+		StorageBackend:  "bolt",
+		SignaturePolicy: "reject",
+		SealGrace:       10 * time.Minute,
+		SealInterval:    time.Minute,
+		ChainID:         137,
+		AnchorDelay:     time.Hour,
+		ClaimWait:       30 * time.Second,
+	}
+
+	if path := os.Getenv("QUIVER_STORAGE_PATH"); path != "" {
+		cfg.StoragePath = path
+	}
+
+	if backend := os.Getenv("QUIVER_STORAGE_BACKEND"); backend != "" {
+		cfg.StorageBackend = backend
+	}
+
+	if policy := os.Getenv("QUIVER_SIGNATURE_POLICY"); policy != "" {
+		cfg.SignaturePolicy = policy
+	}
+
+	if grace := os.Getenv("QUIVER_SEAL_GRACE"); grace != "" {
+		if d, err := time.ParseDuration(grace); err == nil && d >= 0 {
+			cfg.SealGrace = d
+		}
+	}
+
+	if interval := os.Getenv("QUIVER_SEAL_INTERVAL"); interval != "" {
+		if d, err := time.ParseDuration(interval); err == nil && d > 0 {
+			cfg.SealInterval = d
+		}
+	}
+
+	if allowlist := os.Getenv("QUIVER_PROVIDER_ALLOWLIST"); allowlist != "" {
+		cfg.ProviderAllowlistPath = allowlist
+	}
+
+	if schedule := os.Getenv("QUIVER_EPOCH_SCHEDULE"); schedule != "" {
+		cfg.EpochSchedulePath = schedule
+	}
+
+	if addresses := os.Getenv("QUIVER_PAYOUT_ADDRESSES"); addresses != "" {
+		cfg.PayoutAddressesPath = addresses
+	}
+
+	if pricing := os.Getenv("QUIVER_PRICING"); pricing != "" {
+		cfg.PricingPath = pricing
 	}
+
+	if rpc := os.Getenv("QUIVER_SETTLEMENT_RPC"); rpc != "" {
+		cfg.SettlementRPC = rpc
+	}
+
+	if contract := os.Getenv("QUIVER_SETTLEMENT_CONTRACT"); contract != "" {
+		cfg.SettlementContract = contract
+	}
+
+	if key := os.Getenv("QUIVER_SETTLEMENT_KEY"); key != "" {
+		cfg.SettlementKey = key
+	}
+
+	if chainID := os.Getenv("QUIVER_CHAIN_ID"); chainID != "" {
+		if id, err := strconv.ParseInt(chainID, 10, 64); err == nil && id > 0 {
+			cfg.ChainID = id
+		}
+	}
+
+	if delay := os.Getenv("QUIVER_ANCHOR_DELAY"); delay != "" {
+		if d, err := time.ParseDuration(delay); err == nil && d >= 0 {
+			cfg.AnchorDelay = d
+		}
+	}
+
+	if wait := os.Getenv("QUIVER_CLAIM_WAIT"); wait != "" {
+		if d, err := time.ParseDuration(wait); err == nil && d >= 0 {
+			cfg.ClaimWait = d
+		}
+	}
+
+	if stake := os.Getenv("QUIVER_REQUIRE_STAKE"); stake != "" {
+		cfg.RequireStake = stake == "true"
+	}
+
+	if gateways := os.Getenv("QUIVER_GATEWAY_KEYS"); gateways != "" {
+		cfg.GatewayKeysPath = gateways
+	}
+
+	if amount := os.Getenv("QUIVER_SLASH_AMOUNT"); amount != "" {
+		cfg.SlashAmount = amount
+	}
+
+	return cfg
 }
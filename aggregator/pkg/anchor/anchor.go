@@ -0,0 +1,229 @@
+// Package anchor publishes finalized epoch roots, and their payout roots,
+// to the settlement contract and records the anchoring transaction against
+// each epoch. An anchored epoch can no longer be amended, so epochs can be
+// given time to collect amendments first.
+package anchor
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/quiver/aggregator/pkg/blockchain"
+	"github.com/quiver/aggregator/pkg/epoch"
+	"github.com/quiver/aggregator/pkg/payout"
+	"github.com/quiver/aggregator/pkg/sealer"
+	"github.com/sirupsen/logrus"
+)
+
+// Anchorer anchors finalized epochs through a blockchain client.
+type Anchorer struct {
+	client  *blockchain.Client
+	epochs  *epoch.Manager
+	payouts func(uint64) (*payout.Payouts, error)
+	delay   time.Duration
+	wake    chan struct{}
+	logger  *logrus.Logger
+
+	// mu keeps one epoch anchoring at a time
+	mu sync.Mutex
+}
+
+func New(client *blockchain.Client, epochs *epoch.Manager) *Anchorer {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	return &Anchorer{
+		client: client,
+		epochs: epochs,
+		wake:   make(chan struct{}, 1),
+		logger: logger,
+	}
+}
+
+// SetDelay sets how long after it is finalized or last amended an epoch
+// waits before it is anchored.
+func (a *Anchorer) SetDelay(d time.Duration) {
+	a.delay = d
+}
+
+// SetPayouts sets where an epoch's payout tree comes from, such as
+// Sealer.Payouts. Epochs are then anchored with their payout roots.
+func (a *Anchorer) SetPayouts(payouts func(uint64) (*payout.Payouts, error)) {
+	a.payouts = payouts
+}
+
+// Notify wakes Run after an epoch is sealed. Register it with
+// Sealer.OnSealed.
+func (a *Anchorer) Notify(sealer.Event) {
+	select {
+	case a.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Anchor publishes an epoch's root, then its payout root, and marks the
+// epoch anchored, returning the transaction that anchored the root. The
+// epoch is moved to anchoring before anything is sent, so it cannot be
+// amended under a root that may already be on chain. If an earlier attempt
+// committed either root but did not record it, it is not committed again.
+func (a *Anchorer) Anchor(ctx context.Context, n uint64) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	info, exists := a.epochs.GetEpochInfo(n)
+	if !exists {
+		return "", fmt.Errorf("epoch %d not found", n)
+	}
+	if info.State == epoch.StateAnchored {
+		return info.AnchorTx, nil
+	}
+	if info.State != epoch.StateFinalized && info.State != epoch.StateAnchoring {
+		return "", fmt.Errorf("%w: epoch %d is %s", epoch.ErrInvalidTransition, n, info.State)
+	}
+	root := info.Root
+	rootBytes, err := hex.DecodeString(root)
+	if err != nil || len(rootBytes) != 32 {
+		return "", fmt.Errorf("epoch %d has invalid root %q", n, root)
+	}
+	if err := a.epochs.BeginAnchoring(n, root); err != nil {
+		return "", err
+	}
+	batch := blockchain.ReceiptBatch{
+		BatchID:      fmt.Sprintf("epoch-%d-v%d", n, info.Version),
+		Epoch:        n,
+		ReceiptCount: uint64(info.ReceiptCount),
+		Timestamp:    time.Now().UTC(),
+	}
+	copy(batch.MerkleRoot[:], rootBytes)
+
+	txHash, onChain, err := a.client.FindAnchor(ctx, n)
+	if err != nil {
+		return "", err
+	}
+	if txHash == "" {
+		receipt, err := a.client.AnchorBatch(ctx, batch)
+		if err != nil {
+			return "", err
+		}
+		txHash, onChain = receipt.TxHash.Hex(), batch.MerkleRoot
+	}
+	if onChain != batch.MerkleRoot {
+		return "", fmt.Errorf("epoch %d is anchored with root %x in %s, not %s", n, onChain, txHash, root)
+	}
+	payoutTx, err := a.anchorPayouts(ctx, n, root)
+	if err != nil {
+		return "", err
+	}
+
+	if err := a.epochs.MarkAnchored(n, root, txHash); err != nil {
+		return "", fmt.Errorf("epoch %d anchored in %s: %w", n, txHash, err)
+	}
+	a.logger.WithFields(logrus.Fields{
+		"epoch":     n,
+		"root":      root,
+		"tx_hash":   txHash,
+		"payout_tx": payoutTx,
+	}).Info("Epoch anchored")
+	return txHash, nil
+}
+
+// anchorPayouts publishes the payout root built from an epoch's root and
+// returns the transaction that did, now or earlier. It does nothing for
+// epochs without a payout root.
+func (a *Anchorer) anchorPayouts(ctx context.Context, n uint64, root string) (string, error) {
+	if a.payouts == nil {
+		return "", nil
+	}
+	payouts, err := a.payouts(n)
+	if err != nil {
+		return "", fmt.Errorf("epoch %d payouts: %w", n, err)
+	}
+	if payouts.Root == "" {
+		return "", nil
+	}
+	if payouts.EpochRoot != root {
+		return "", fmt.Errorf("epoch %d payouts were built from root %s, not %s", n, payouts.EpochRoot, root)
+	}
+	var payoutRoot [32]byte
+	rootBytes, err := hex.DecodeString(payouts.Root)
+	if err != nil || len(rootBytes) != 32 {
+		return "", fmt.Errorf("epoch %d has invalid payout root %q", n, payouts.Root)
+	}
+	copy(payoutRoot[:], rootBytes)
+
+	txHash, onChain, err := a.client.FindPayoutAnchor(ctx, n)
+	if err != nil {
+		return "", err
+	}
+	if txHash == "" {
+		receipt, err := a.client.AnchorPayouts(ctx, n, payoutRoot, uint64(len(payouts.Leaves)))
+		if err != nil {
+			return "", err
+		}
+		txHash, onChain = receipt.TxHash.Hex(), payoutRoot
+	}
+	if onChain != payoutRoot {
+		return "", fmt.Errorf("epoch %d is anchored with payout root %x in %s, not %s", n, onChain, txHash, payouts.Root)
+	}
+	return txHash, nil
+}
+
+// AnchorDue anchors every finalized epoch whose delay has passed by now,
+// and retries every epoch left anchoring, and returns the epochs it
+// anchored. An epoch that fails to anchor does not hold up the others.
+func (a *Anchorer) AnchorDue(ctx context.Context, now time.Time) ([]uint64, error) {
+	var anchored []uint64
+	var errs []error
+	due := append(a.epochs.ListEpochs(epoch.StateAnchoring), a.epochs.ListEpochs(epoch.StateFinalized)...)
+	sort.Slice(due, func(i, j int) bool { return due[i].Epoch < due[j].Epoch })
+	for _, info := range due {
+		if info.State == epoch.StateFinalized && now.Before(lastChange(info).Add(a.delay)) {
+			continue
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		if _, err := a.Anchor(ctx, info.Epoch); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		anchored = append(anchored, info.Epoch)
+	}
+	return anchored, errors.Join(errs...)
+}
+
+// lastChange is when an epoch's root was last set.
+func lastChange(info *epoch.Info) time.Time {
+	if n := len(info.Amendments); n > 0 {
+		return info.Amendments[n-1].AmendedAt
+	}
+	if info.FinalizedAt != nil {
+		return *info.FinalizedAt
+	}
+	return time.Time{}
+}
+
+// Run anchors due epochs every interval, and whenever Notify is called,
+// until ctx is done.
+func (a *Anchorer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := a.AnchorDue(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			a.logger.WithError(err).Error("Failed to anchor epoch")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-a.wake:
+		}
+	}
+}
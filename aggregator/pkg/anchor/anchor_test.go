@@ -0,0 +1,374 @@
+package anchor
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quiver/aggregator/pkg/blockchain"
+	"github.com/quiver/aggregator/pkg/blockchain/blockchaintest"
+	"github.com/quiver/aggregator/pkg/epoch"
+	"github.com/quiver/aggregator/pkg/payout"
+	"github.com/quiver/aggregator/pkg/sealer"
+)
+
+var settlement = common.HexToAddress("0x0000000000000000000000000000000000005e77")
+
+// newChain returns a client of a simulated chain that mines a block every
+// millisecond until the test ends, and the client's address.
+func newChain(t *testing.T) (*blockchain.Client, *chain, common.Address) {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	sim := mine(t, core.GenesisAlloc{
+		crypto.PubkeyToAddress(key.PublicKey): {Balance: big.NewInt(1e18)},
+		settlement:                            {Code: blockchaintest.RootLogger(), Balance: new(big.Int)},
+	})
+	return newClient(t, sim, key, settlement), sim, crypto.PubkeyToAddress(key.PublicKey)
+}
+
+// newContractChain is newChain with the token and settlement contracts
+// deployed by the client's key, which owns them. It returns the settlement
+// contract in place of the client's address.
+func newContractChain(t *testing.T) (*blockchain.Client, *chain, common.Address) {
+	t.Helper()
+	blockchaintest.RequireBytecode(t, blockchain.SimpleSettlementMetaData.Bin, blockchain.SimpleQUIVTokenMetaData.Bin)
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	sim := mine(t, core.GenesisAlloc{
+		crypto.PubkeyToAddress(key.PublicKey): {Balance: big.NewInt(1e18)},
+	})
+
+	deployer, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1337))
+	require.NoError(t, err)
+	token := blockchaintest.Deploy(t, deployer, blockchain.SimpleQUIVTokenMetaData, sim)
+	contract := blockchaintest.Deploy(t, deployer, blockchain.SimpleSettlementMetaData, sim, token)
+	return newClient(t, sim, key, contract), sim, contract
+}
+
+// chain is a simulated backend mined in the background. The backend
+// filters logs without holding its own lock, so filtering and mining take
+// mu in turn.
+type chain struct {
+	*backends.SimulatedBackend
+	mu sync.Mutex
+}
+
+func (c *chain) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.SimulatedBackend.FilterLogs(ctx, query)
+}
+
+func (c *chain) commit() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Commit()
+}
+
+// mine starts a simulated chain with alloc that mines a block every
+// millisecond until the test ends.
+func mine(t *testing.T, alloc core.GenesisAlloc) *chain {
+	t.Helper()
+	sim := &chain{SimulatedBackend: backends.NewSimulatedBackend(alloc, 10_000_000)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				sim.commit()
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+		sim.Close()
+	})
+	return sim
+}
+
+// newClient returns a client of sim that sends with key to the settlement
+// contract at contract.
+func newClient(t *testing.T, sim *chain, key *ecdsa.PrivateKey, contract common.Address) *blockchain.Client {
+	t.Helper()
+	config := blockchain.DefaultPolygonConfig()
+	config.ChainID = 1337
+	config.GasLimit = 1_000_000
+	config.ContractAddress = contract.Hex()
+	config.PrivateKey = hex.EncodeToString(crypto.FromECDSA(key))
+	config.PollInterval = time.Millisecond
+	config.ConfirmationWait = 5 * time.Second
+	config.Confirmations = 2
+
+	client, err := blockchain.NewClientWithBackend(config, sim)
+	require.NoError(t, err)
+	return client
+}
+
+func testRoot(b byte) string {
+	return strings.Repeat(hex.EncodeToString([]byte{b}), 32)
+}
+
+func TestAnchorDue(t *testing.T) {
+	client, _, _ := newChain(t)
+	epochs := epoch.NewManager()
+	a := New(client, epochs)
+	a.SetDelay(time.Hour)
+	ctx := context.Background()
+
+	require.NoError(t, epochs.FinalizeEpoch(1, testRoot(1), 3))
+	require.NoError(t, epochs.FinalizeEpoch(2, testRoot(2), 5))
+	epochs.GetOrCreateEpoch(3)
+	// A run that stopped after epoch 4 began anchoring
+	require.NoError(t, epochs.FinalizeEpoch(4, testRoot(4), 1))
+	require.NoError(t, epochs.BeginAnchoring(4, testRoot(4)))
+
+	anchored, err := a.AnchorDue(ctx, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, []uint64{4}, anchored, "anchored a finalized epoch inside the delay")
+
+	anchored, err = a.AnchorDue(ctx, time.Now().Add(2*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, []uint64{1, 2}, anchored)
+
+	for _, n := range anchored {
+		info, _ := epochs.GetEpochInfo(n)
+		require.Equal(t, epoch.StateAnchored, info.State)
+		txHash, root, err := client.FindAnchor(ctx, n)
+		require.NoError(t, err)
+		require.Equal(t, txHash, info.AnchorTx)
+		require.Equal(t, info.Root, hex.EncodeToString(root[:]))
+	}
+
+	// Anchoring again changes nothing
+	info, _ := epochs.GetEpochInfo(1)
+	txHash, err := a.Anchor(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, info.AnchorTx, txHash)
+
+	_, err = a.Anchor(ctx, 3)
+	require.ErrorIs(t, err, epoch.ErrInvalidTransition)
+}
+
+func TestAnchorRecordsEarlierTransaction(t *testing.T) {
+	client, sim, sender := newChain(t)
+	epochs := epoch.NewManager()
+	a := New(client, epochs)
+	ctx := context.Background()
+
+	require.NoError(t, epochs.FinalizeEpoch(4, testRoot(4), 2))
+	require.NoError(t, epochs.FinalizeEpoch(5, testRoot(5), 2))
+
+	// A run that committed epoch 4 and stopped before recording it, and
+	// a root for epoch 5 that the epoch does not have
+	batch := blockchain.ReceiptBatch{Epoch: 4, ReceiptCount: 2}
+	copy(batch.MerkleRoot[:], common.FromHex(testRoot(4)))
+	earlier, err := client.AnchorBatch(ctx, batch)
+	require.NoError(t, err)
+	_, err = client.AnchorBatch(ctx, blockchain.ReceiptBatch{Epoch: 5, ReceiptCount: 2, MerkleRoot: [32]byte{5}})
+	require.NoError(t, err)
+
+	nonce, err := sim.PendingNonceAt(ctx, sender)
+	require.NoError(t, err)
+
+	txHash, err := a.Anchor(ctx, 4)
+	require.NoError(t, err)
+	require.Equal(t, earlier.TxHash.Hex(), txHash)
+
+	// Epoch 5 is on chain with a root it does not have, which does not
+	// hold up epoch 6
+	require.NoError(t, epochs.FinalizeEpoch(6, testRoot(6), 2))
+	nonce++
+	anchored, err := a.AnchorDue(ctx, time.Now())
+	require.Error(t, err)
+	require.Equal(t, []uint64{6}, anchored)
+	info, _ := epochs.GetEpochInfo(5)
+	require.Equal(t, epoch.StateAnchoring, info.State)
+
+	after, err := sim.PendingNonceAt(ctx, sender)
+	require.NoError(t, err)
+	require.Equal(t, nonce, after, "sent a second transaction for an epoch already on chain")
+}
+
+func TestAnchorWaitsForAmendment(t *testing.T) {
+	client, _, _ := newChain(t)
+	epochs := epoch.NewManager()
+	a := New(client, epochs)
+	ctx := context.Background()
+
+	require.NoError(t, epochs.FinalizeEpoch(11, testRoot(11), 2))
+	require.NoError(t, epochs.BeginAmending(11))
+	_, err := a.Anchor(ctx, 11)
+	require.ErrorIs(t, err, epoch.ErrAmending)
+	txHash, _, err := client.FindAnchor(ctx, 11)
+	require.NoError(t, err)
+	require.Empty(t, txHash, "sent a root that was being amended")
+
+	_, err = epochs.Amend(11, testRoot(0x1b), 3, "late receipts", nil)
+	require.NoError(t, err)
+	epochs.EndAmending(11)
+
+	_, err = a.Anchor(ctx, 11)
+	require.NoError(t, err)
+	info, _ := epochs.GetEpochInfo(11)
+	require.Equal(t, epoch.StateAnchored, info.State)
+	_, root, err := client.FindAnchor(ctx, 11)
+	require.NoError(t, err)
+	require.Equal(t, testRoot(0x1b), hex.EncodeToString(root[:]))
+}
+
+func TestAnchorPayouts(t *testing.T) {
+	client, sim, sender := newChain(t)
+	epochs := epoch.NewManager()
+	a := New(client, epochs)
+	ctx := context.Background()
+
+	payouts := map[uint64]*payout.Payouts{
+		7: {EpochRoot: testRoot(7), Root: testRoot(0x77), Leaves: make([]*payout.Leaf, 3)},
+		8: {EpochRoot: testRoot(8), Root: testRoot(0x88), Leaves: make([]*payout.Leaf, 2)},
+		// built before an amendment changed the epoch's root
+		9: {EpochRoot: testRoot(0x99), Root: testRoot(0x98), Leaves: make([]*payout.Leaf, 1)},
+		// no provider has a payout address
+		10: {EpochRoot: testRoot(10)},
+	}
+	a.SetPayouts(func(n uint64) (*payout.Payouts, error) {
+		return payouts[n], nil
+	})
+	for n := uint64(7); n <= 10; n++ {
+		require.NoError(t, epochs.FinalizeEpoch(n, testRoot(byte(n)), 2))
+	}
+
+	_, err := a.Anchor(ctx, 7)
+	require.NoError(t, err)
+	txHash, root, err := client.FindPayoutAnchor(ctx, 7)
+	require.NoError(t, err)
+	require.NotEmpty(t, txHash)
+	require.Equal(t, testRoot(0x77), hex.EncodeToString(root[:]))
+
+	// A run that committed both of epoch 8's roots and stopped before
+	// recording them
+	batch := blockchain.ReceiptBatch{Epoch: 8, ReceiptCount: 2}
+	copy(batch.MerkleRoot[:], common.FromHex(testRoot(8)))
+	_, err = client.AnchorBatch(ctx, batch)
+	require.NoError(t, err)
+	var payoutRoot [32]byte
+	copy(payoutRoot[:], common.FromHex(testRoot(0x88)))
+	_, err = client.AnchorPayouts(ctx, 8, payoutRoot, 2)
+	require.NoError(t, err)
+	nonce, err := sim.PendingNonceAt(ctx, sender)
+	require.NoError(t, err)
+	_, err = a.Anchor(ctx, 8)
+	require.NoError(t, err)
+	after, err := sim.PendingNonceAt(ctx, sender)
+	require.NoError(t, err)
+	require.Equal(t, nonce, after, "committed a root already on chain")
+
+	_, err = a.Anchor(ctx, 9)
+	require.Error(t, err)
+	// Its root is on chain, so it stays anchoring rather than amendable
+	info, _ := epochs.GetEpochInfo(9)
+	require.Equal(t, epoch.StateAnchoring, info.State)
+	txHash, _, err = client.FindPayoutAnchor(ctx, 9)
+	require.NoError(t, err)
+	require.Empty(t, txHash, "anchored stale payouts")
+
+	_, err = a.Anchor(ctx, 10)
+	require.NoError(t, err)
+	txHash, _, err = client.FindPayoutAnchor(ctx, 10)
+	require.NoError(t, err)
+	require.Empty(t, txHash)
+}
+
+// TestAnchorOnContract anchors epochs and their payout roots with the
+// settlement contract itself, including one a stopped run left anchoring
+// after committing its root.
+func TestAnchorOnContract(t *testing.T) {
+	client, sim, contract := newContractChain(t)
+	epochs := epoch.NewManager()
+	a := New(client, epochs)
+	ctx := context.Background()
+
+	payouts := map[uint64]*payout.Payouts{
+		12: {EpochRoot: testRoot(12), Root: testRoot(0x12), Leaves: make([]*payout.Leaf, 2)},
+		13: {EpochRoot: testRoot(13), Root: testRoot(0x13), Leaves: make([]*payout.Leaf, 1)},
+	}
+	a.SetPayouts(func(n uint64) (*payout.Payouts, error) {
+		return payouts[n], nil
+	})
+	require.NoError(t, epochs.FinalizeEpoch(12, testRoot(12), 2))
+	require.NoError(t, epochs.FinalizeEpoch(13, testRoot(13), 3))
+
+	require.NoError(t, epochs.BeginAnchoring(13, testRoot(13)))
+	batch := blockchain.ReceiptBatch{Epoch: 13, ReceiptCount: 3}
+	copy(batch.MerkleRoot[:], common.FromHex(testRoot(13)))
+	_, err := client.AnchorBatch(ctx, batch)
+	require.NoError(t, err)
+
+	anchored, err := a.AnchorDue(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, []uint64{12, 13}, anchored)
+
+	settlement, err := blockchain.NewSimpleSettlement(contract, sim)
+	require.NoError(t, err)
+	for _, n := range anchored {
+		info, _ := epochs.GetEpochInfo(n)
+		require.Equal(t, epoch.StateAnchored, info.State)
+
+		root, err := settlement.EpochRoots(nil, new(big.Int).SetUint64(n))
+		require.NoError(t, err)
+		require.Equal(t, info.Root, hex.EncodeToString(root[:]))
+		size, err := settlement.EpochTreeSizes(nil, new(big.Int).SetUint64(n))
+		require.NoError(t, err)
+		require.EqualValues(t, info.ReceiptCount, size.Uint64())
+
+		payoutRoot, err := settlement.PayoutRoots(nil, new(big.Int).SetUint64(n))
+		require.NoError(t, err)
+		require.Equal(t, payouts[n].Root, hex.EncodeToString(payoutRoot[:]))
+	}
+}
+
+func TestRunAnchorsSealedEpochs(t *testing.T) {
+	client, _, _ := newChain(t)
+	epochs := epoch.NewManager()
+	a := New(client, epochs)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		a.Run(ctx, time.Hour)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	require.NoError(t, epochs.FinalizeEpoch(9, testRoot(9), 1))
+	a.Notify(sealer.Event{Epoch: 9})
+	require.Eventually(t, func() bool {
+		info, _ := epochs.GetEpochInfo(9)
+		return info.State == epoch.StateAnchored
+	}, 5*time.Second, time.Millisecond)
+}
@@ -1,118 +1,872 @@
 package api
 
 import (
-	"crypto/sha256"
+	"bytes"
+	"context"
+	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"math/big"
 	"net/http"
 	"sort"
+	"strconv"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/gin-gonic/gin"
+	"github.com/quiver/aggregator/pkg/audit"
 	"github.com/quiver/aggregator/pkg/epoch"
+	"github.com/quiver/aggregator/pkg/jcs"
 	"github.com/quiver/aggregator/pkg/merkle"
+	"github.com/quiver/aggregator/pkg/metrics"
+	"github.com/quiver/aggregator/pkg/payout"
+	"github.com/quiver/aggregator/pkg/registry"
+	"github.com/quiver/aggregator/pkg/sealer"
+	"github.com/quiver/aggregator/pkg/settlement"
+	"github.com/quiver/aggregator/pkg/slashing"
 	"github.com/quiver/aggregator/pkg/storage"
+	"github.com/quiver/aggregator/pkg/verify"
 	"github.com/sirupsen/logrus"
 )
 
 const (
 	// MaxReceiptSize is the maximum allowed size for a single receipt (10KB)
 	MaxReceiptSize = 10 * 1024
+
+	// SignaturePolicyReject drops receipts that fail verification.
+	SignaturePolicyReject = "reject"
+	// SignaturePolicyQuarantine keeps receipts that fail verification in a
+	// quarantine area for inspection. They are never settled.
+	SignaturePolicyQuarantine = "quarantine"
 )
 
 type Handler struct {
-	store        *storage.Store
-	epochManager *epoch.Manager
-	logger       *logrus.Logger
+	store           storage.Store
+	epochManager    *epoch.Manager
+	auditor         *audit.Auditor
+	verifier        *verify.Verifier
+	sealer          *sealer.Sealer
+	settler         *settlement.Settler
+	registry        *registry.Registry
+	ledger          *slashing.Ledger
+	claimWait       time.Duration
+	signaturePolicy string
+	logger          *logrus.Logger
 }
 
-func NewHandler(store *storage.Store, epochManager *epoch.Manager) *Handler {
+func NewHandler(store storage.Store, epochManager *epoch.Manager) *Handler {
 	logger := logrus.New()
 	logger.SetFormatter(&logrus.JSONFormatter{})
 
 	return &Handler{
-		store:        store,
-		epochManager: epochManager,
-		logger:       logger,
+		store:           store,
+		epochManager:    epochManager,
+		auditor:         audit.NewAuditor(audit.DefaultConfig()),
+		verifier:        verify.NewVerifier(nil),
+		sealer:          sealer.New(store, epochManager),
+		signaturePolicy: SignaturePolicyReject,
+		logger:          logger,
 	}
 }
 
+// SetVerifier replaces the receipt verifier, for example with one that
+// checks an allowlist of registered provider keys.
+func (h *Handler) SetVerifier(v *verify.Verifier) {
+	h.verifier = v
+}
+
+// SetAuditor replaces the chain auditor, for example with one that keeps
+// its findings in the store.
+func (h *Handler) SetAuditor(a *audit.Auditor) {
+	h.auditor = a
+}
+
+// SetSealer replaces the sealer that stores and seals committed receipts.
+// It must use the handler's store and epoch manager.
+func (h *Handler) SetSealer(s *sealer.Sealer) {
+	h.sealer = s
+}
+
+// SetSettler sets the settler that pays claims. Claim waits up to wait for
+// the payment to confirm before answering with it still pending. Without a
+// settler, claims are refused.
+func (h *Handler) SetSettler(s *settlement.Settler, wait time.Duration) {
+	h.settler = s
+	h.claimWait = wait
+}
+
+// SetRegistry sets the provider registry GET /providers/:pk reads. To
+// accept receipts only from registered providers, also verify with it; see
+// SetVerifier.
+func (h *Handler) SetRegistry(r *registry.Registry) {
+	h.registry = r
+}
+
+// SetLedger sets the ledger POST /evidence records canary evidence in.
+// Without one, evidence is refused.
+func (h *Handler) SetLedger(l *slashing.Ledger) {
+	h.ledger = l
+}
+
+// SetSignaturePolicy selects what happens to receipts that fail
+// verification: SignaturePolicyReject or SignaturePolicyQuarantine.
+func (h *Handler) SetSignaturePolicy(policy string) error {
+	switch policy {
+	case SignaturePolicyReject, SignaturePolicyQuarantine:
+		h.signaturePolicy = policy
+		return nil
+	default:
+		return fmt.Errorf("unknown signature policy %q", policy)
+	}
+}
+
+// Commit accepts a batch of receipts for one epoch. Receipts are admitted
+// while the epoch is open and answered with 202; the sealer seals the epoch
+// once its grace window has passed, and a commit after that seals it if the
+// sealer has not yet. Repeating a commit that is already sealed changes
+// nothing; changing a finalized epoch needs amend and a reason.
 func (h *Handler) Commit(c *gin.Context) {
 	var req CommitRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request"})
 		return
 	}
+	if msg := checkSizes(req.Receipts); msg != "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: msg})
+		return
+	}
+
+	// Verify signatures before anything reaches the auditor or the store
+	index := receiptIndex(req.Receipts)
+	accepted, rejected := h.verifyReceipts(req.Receipts)
+
+	// Receipts are settled in the epoch they name
+	inEpoch := accepted[:0]
+	for _, receipt := range accepted {
+		if uint64(receipt.Receipt.Epoch) != req.Epoch {
+			rejected = append(rejected, ReceiptError{
+				Index:     index[receipt],
+				ReceiptID: receipt.Receipt.ReceiptID,
+				Error:     fmt.Sprintf("receipt is for epoch %d", receipt.Receipt.Epoch),
+			})
+			continue
+		}
+		inEpoch = append(inEpoch, receipt)
+	}
+	accepted = inEpoch
+
+	if len(accepted) == 0 {
+		sortErrors(rejected)
+		c.JSON(http.StatusBadRequest, CommitResponse{
+			Epoch:    req.Epoch,
+			Rejected: rejected,
+		})
+		return
+	}
 
-	// Validate and store receipts
-	for _, receipt := range req.Receipts {
-		// Validate receipt size
+	// Sort receipts by sequence number
+	sort.Slice(accepted, func(i, j int) bool {
+		return accepted[i].Receipt.Seq < accepted[j].Receipt.Seq
+	})
+
+	if info, exists := h.epochManager.GetEpochInfo(req.Epoch); exists && info.Finalized {
+		if h.alreadyStored(accepted) {
+			sortErrors(rejected)
+			c.JSON(http.StatusOK, commitResponse(info, rejected, nil))
+			return
+		}
+		if !req.Amend {
+			c.JSON(http.StatusConflict, ErrorResponse{Error: fmt.Sprintf("Epoch %d already finalized with root %s", req.Epoch, info.Root)})
+			return
+		}
+		if info.State != epoch.StateFinalized {
+			c.JSON(http.StatusConflict, ErrorResponse{Error: fmt.Sprintf("Epoch %d is %s and cannot be amended", req.Epoch, info.State)})
+			return
+		}
+		if req.Reason == "" {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Amendments need a reason"})
+			return
+		}
+	} else if req.Amend {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("Epoch %d is not finalized", req.Epoch)})
+		return
+	}
+
+	var findings []audit.Finding
+	var info *epoch.Info
+	var err error
+	if req.Amend {
+		if info, err = h.sealer.Amend(req.Epoch, accepted, req.Reason); err == nil {
+			findings = h.audit(accepted)
+		}
+	} else {
+		stored, turnedAway := h.admit(accepted, index)
+		rejected = append(rejected, turnedAway...)
+		findings = h.audit(stored)
+		if len(turnedAway) == len(accepted) && h.store.Count(req.Epoch) == 0 {
+			sortErrors(rejected)
+			c.JSON(http.StatusBadRequest, CommitResponse{
+				Epoch:    req.Epoch,
+				Rejected: rejected,
+			})
+			return
+		}
+		info, err = h.sealer.SealIfDue(req.Epoch)
+	}
+	if err != nil {
+		h.logger.WithError(err).WithField("epoch", req.Epoch).Error("Failed to finalize epoch")
+		status := http.StatusInternalServerError
+		if errors.Is(err, epoch.ErrAlreadyFinalized) || errors.Is(err, epoch.ErrInvalidTransition) || errors.Is(err, epoch.ErrAmending) || errors.Is(err, sealer.ErrReceiptChanged) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, ErrorResponse{Error: "Failed to finalize epoch"})
+		return
+	}
+	if req.Amend {
+		h.logger.WithFields(logrus.Fields{
+			"epoch":   req.Epoch,
+			"version": info.Version,
+			"reason":  req.Reason,
+		}).Warn("Epoch amended")
+	}
+
+	sortErrors(rejected)
+	if info.State == epoch.StateOpen {
+		resp := commitResponse(info, rejected, findings)
+		resp.ReceiptCount = h.store.Count(req.Epoch)
+		c.JSON(http.StatusAccepted, resp)
+		return
+	}
+	c.JSON(http.StatusOK, commitResponse(info, rejected, findings))
+}
+
+func commitResponse(info *epoch.Info, rejected []ReceiptError, findings []audit.Finding) CommitResponse {
+	return CommitResponse{
+		MerkleRoot:    info.Root,
+		Epoch:         info.Epoch,
+		ReceiptCount:  info.ReceiptCount,
+		Rejected:      rejected,
+		ChainFindings: findings,
+		State:         info.State,
+		Version:       info.Version,
+	}
+}
+
+// Ingest accepts receipts for open epochs without sealing anything. The
+// sealer finalizes each epoch once its grace window has passed.
+func (h *Handler) Ingest(c *gin.Context) {
+	var req IngestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request"})
+		return
+	}
+	if msg := checkSizes(req.Receipts); msg != "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: msg})
+		return
+	}
+
+	index := receiptIndex(req.Receipts)
+	accepted, rejected := h.verifyReceipts(req.Receipts)
+	sort.Slice(accepted, func(i, j int) bool {
+		return accepted[i].Receipt.Seq < accepted[j].Receipt.Seq
+	})
+
+	stored, closed := h.admit(accepted, index)
+	rejected = append(rejected, closed...)
+	findings := h.audit(stored)
+	sortErrors(rejected)
+
+	resp := IngestResponse{
+		Accepted:      len(accepted) - len(closed),
+		Rejected:      rejected,
+		ChainFindings: findings,
+	}
+	if resp.Accepted == 0 {
+		c.JSON(http.StatusBadRequest, resp)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// checkSizes returns an error message if any receipt is too large.
+func checkSizes(receipts []*storage.SignedReceipt) string {
+	for _, receipt := range receipts {
 		receiptJSON, err := json.Marshal(receipt)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid receipt format"})
-			return
+			return "Invalid receipt format"
 		}
 		if len(receiptJSON) > MaxReceiptSize {
-			c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("Receipt exceeds maximum size of %d bytes", MaxReceiptSize)})
-			return
+			return fmt.Sprintf("Receipt exceeds maximum size of %d bytes", MaxReceiptSize)
+		}
+	}
+	return ""
+}
+
+// receiptIndex maps each receipt to its position in the request.
+func receiptIndex(receipts []*storage.SignedReceipt) map[*storage.SignedReceipt]int {
+	index := make(map[*storage.SignedReceipt]int, len(receipts))
+	for i, receipt := range receipts {
+		index[receipt] = i
+	}
+	return index
+}
+
+func sortErrors(errs []ReceiptError) {
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Index < errs[j].Index })
+}
+
+// audit runs each provider's chain audit over receipts in the order given.
+// Only stored receipts are audited, so a receipt turned away or sent again
+// after a timeout is not taken for part of the provider's chain.
+func (h *Handler) audit(receipts []*storage.SignedReceipt) []audit.Finding {
+	var findings []audit.Finding
+	for _, receipt := range receipts {
+		found, err := h.auditor.Observe(receipt)
+		if err != nil {
+			h.logger.WithError(err).Error("Failed to audit receipt")
 		}
-		
-		if err := h.store.Store(receipt); err != nil {
-			h.logger.WithError(err).Error("Failed to store receipt")
+		for _, f := range found {
+			metrics.ChainFindingsTotal.WithLabelValues(string(f.Kind)).Inc()
+			h.logger.WithFields(logrus.Fields{
+				"kind":        f.Kind,
+				"provider_pk": f.ProviderPK,
+				"seq":         f.Seq,
+				"receipt_id":  f.ReceiptID,
+			}).Warn(f.Detail)
 		}
+		findings = append(findings, found...)
 	}
+	metrics.ChainMissingReceipts.Set(float64(h.auditor.MissingTotal()))
+	return findings
+}
 
-	// Sort receipts by sequence number
-	sort.Slice(req.Receipts, func(i, j int) bool {
-		return req.Receipts[i].Receipt.Seq < req.Receipts[j].Receipt.Seq
+// admit stores receipts through the sealer. It returns the receipts it
+// stored and errors for those whose epoch is no longer open, whose ID
+// another receipt holds or that could not be stored. Receipts already
+// stored as given are skipped.
+func (h *Handler) admit(receipts []*storage.SignedReceipt, index map[*storage.SignedReceipt]int) ([]*storage.SignedReceipt, []ReceiptError) {
+	var stored []*storage.SignedReceipt
+	var rejected []ReceiptError
+	for _, receipt := range receipts {
+		if h.alreadyStored([]*storage.SignedReceipt{receipt}) {
+			continue
+		}
+		if err := h.sealer.Admit(receipt); err != nil {
+			if !errors.Is(err, sealer.ErrEpochClosed) && !errors.Is(err, storage.ErrReceiptExists) {
+				h.logger.WithError(err).Error("Failed to store receipt")
+			}
+			rejected = append(rejected, ReceiptError{
+				Index:     index[receipt],
+				ReceiptID: receipt.Receipt.ReceiptID,
+				Error:     err.Error(),
+			})
+			continue
+		}
+		stored = append(stored, receipt)
+	}
+	return stored, rejected
+}
+
+// alreadyStored reports whether every receipt is stored exactly as given.
+func (h *Handler) alreadyStored(receipts []*storage.SignedReceipt) bool {
+	for _, receipt := range receipts {
+		stored, err := h.store.GetByID(receipt.Receipt.ReceiptID)
+		if err != nil || stored == nil {
+			return false
+		}
+		a, err := jcs.Canonicalize(stored.Receipt)
+		if err != nil {
+			return false
+		}
+		b, err := jcs.Canonicalize(receipt.Receipt)
+		if err != nil || !bytes.Equal(a, b) {
+			return false
+		}
+	}
+	return true
+}
+
+// ListEpochs lists known epochs in ascending order. Optional query
+// parameter: state.
+func (h *Handler) ListEpochs(c *gin.Context) {
+	state := epoch.State(c.Query("state"))
+	switch state {
+	case "", epoch.StateOpen, epoch.StateSealing, epoch.StateFinalized, epoch.StateAnchoring, epoch.StateAnchored:
+	default:
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid state"})
+		return
+	}
+
+	c.JSON(http.StatusOK, EpochsResponse{
+		Epochs: h.epochManager.ListEpochs(state),
 	})
+}
 
-	// Build Merkle tree
-	tree := merkle.NewTree()
-	for _, receipt := range req.Receipts {
-		canonical, err := canonicalizeJSON(receipt.Receipt)
+// GetEpoch returns one epoch with its root, receipt counts, state, anchor
+// and amendment history.
+func (h *Handler) GetEpoch(c *gin.Context) {
+	n, err := strconv.ParseUint(c.Param("epoch"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid epoch"})
+		return
+	}
+
+	info, exists := h.epochManager.GetEpochInfo(n)
+	if !exists {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Epoch not found"})
+		return
+	}
+	c.JSON(http.StatusOK, EpochResponse{
+		Info:           info,
+		StoredReceipts: h.store.Count(n),
+	})
+}
+
+// ListReceipts pages through stored receipts in epoch then receipt ID
+// order. Optional query parameters: provider, model, epoch, from, to,
+// cursor and limit. from and to are RFC 3339 times and select the epochs
+// overlapping [from, to). A page's next value, passed as cursor, returns
+// the page after it; cursors stay valid as receipts are added.
+func (h *Handler) ListReceipts(c *gin.Context) {
+	q, ok := h.receiptQuery(c)
+	if !ok {
+		return
+	}
+	q.Model = c.Query("model")
+	q.After = c.Query("cursor")
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid limit"})
+			return
+		}
+		q.Limit = n
+	}
+
+	page, err := h.store.Scan(q)
+	if errors.Is(err, storage.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid cursor"})
+		return
+	}
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to scan receipts")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to list receipts"})
+		return
+	}
+	if page.Receipts == nil {
+		page.Receipts = []*storage.SignedReceipt{}
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// ReceiptProof returns a receipt's inclusion proof against its epoch's
+// current root, once the epoch is sealed.
+func (h *Handler) ReceiptProof(c *gin.Context) {
+	id := c.Param("id")
+	receipt, err := h.store.GetByID(id)
+	if err != nil {
+		h.logger.WithError(err).WithField("receipt_id", id).Error("Failed to load receipt")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to load receipt"})
+		return
+	}
+	if receipt == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Receipt not found"})
+		return
+	}
+
+	n := uint64(receipt.Receipt.Epoch)
+	info, exists := h.epochManager.GetEpochInfo(n)
+	proof, ok := h.store.GetProof(id)
+	if !exists || !info.Finalized || !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Epoch not finalized"})
+		return
+	}
+	// Proofs are stored before an amendment takes effect, so they can
+	// briefly be newer than the root
+	if proof.TreeSize != info.ReceiptCount {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Epoch was amended, retry"})
+		return
+	}
+
+	canonical, err := jcs.Canonicalize(receipt.Receipt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to canonicalize receipt"})
+		return
+	}
+	c.JSON(http.StatusOK, ReceiptProofResponse{
+		ReceiptID: id,
+		Epoch:     n,
+		Root:      info.Root,
+		State:     info.State,
+		AnchorTx:  info.AnchorTx,
+		LeafHash:  hex.EncodeToString(merkle.LeafHash(canonical)),
+		Proof:     proof,
+	})
+}
+
+// ProviderSummary totals a provider's receipts, tokens and earnings, per
+// epoch and overall. Optional query parameters: epoch, from and to, as for
+// ListReceipts. Earnings are what claims for the receipts pay.
+func (h *Handler) ProviderSummary(c *gin.Context) {
+	pk, ok := providerKey(c.Param("pk"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid provider key"})
+		return
+	}
+	q, ok := h.receiptQuery(c)
+	if !ok {
+		return
+	}
+	q.ProviderPK = pk
+	q.Limit = storage.MaxPageSize
+
+	summary := ProviderSummaryResponse{ProviderPK: pk, Epochs: []*EpochSummary{}}
+	total := new(big.Int)
+	var current *EpochSummary
+	var earned *big.Int
+	flush := func() {
+		if current != nil {
+			current.Earnings = earned.String()
+			summary.Epochs = append(summary.Epochs, current)
+		}
+	}
+	for {
+		page, err := h.store.Scan(q)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to canonicalize receipt"})
+			h.logger.WithError(err).WithField("provider", pk).Error("Failed to scan receipts")
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to summarize provider"})
 			return
 		}
-		tree.AddLeaf(canonical)
+		for _, receipt := range page.Receipts {
+			r := &receipt.Receipt
+			if n := uint64(r.Epoch); current == nil || current.Epoch != n {
+				flush()
+				current = &EpochSummary{Epoch: n, State: epoch.StateOpen}
+				if info, exists := h.epochManager.GetEpochInfo(n); exists {
+					current.State = info.State
+				}
+				earned = new(big.Int)
+			}
+			current.Receipts++
+			current.TokensIn += int64(r.TokensIn)
+			current.TokensOut += int64(r.TokensOut)
+			summary.Receipts++
+			summary.TokensIn += int64(r.TokensIn)
+			summary.TokensOut += int64(r.TokensOut)
+			if payout.Paid(r) {
+				price := h.sealer.Price(r)
+				earned.Add(earned, price)
+				total.Add(total, price)
+			}
+		}
+		if page.Next == "" {
+			break
+		}
+		q.After = page.Next
 	}
+	flush()
+	summary.Earnings = total.String()
+	c.JSON(http.StatusOK, summary)
+}
 
-	if err := tree.Build(); err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to build merkle tree"})
+// ProviderRegistration returns a provider key's stake and declared models
+// as the registry last read them.
+func (h *Handler) ProviderRegistration(c *gin.Context) {
+	if h.registry == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "Provider registry is not configured"})
+		return
+	}
+	pk, ok := providerKey(c.Param("pk"))
+	if key, _ := base64.StdEncoding.DecodeString(pk); !ok || len(key) != 32 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid provider key"})
 		return
 	}
+	provider, err := h.registry.Lookup(c.Request.Context(), pk)
+	if err != nil {
+		h.logger.WithError(err).WithField("provider", pk).Error("Failed to read provider registration")
+		c.JSON(http.StatusBadGateway, ErrorResponse{Error: "Failed to read provider registration"})
+		return
+	}
+	c.JSON(http.StatusOK, provider)
+}
 
-	root := tree.Root()
+// receiptQuery reads the provider, epoch, from and to query parameters
+// into a storage query. It answers 400 and returns false if one is
+// malformed.
+func (h *Handler) receiptQuery(c *gin.Context) (storage.Query, bool) {
+	var q storage.Query
+	if provider := c.Query("provider"); provider != "" {
+		pk, ok := providerKey(provider)
+		if !ok {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid provider key"})
+			return q, false
+		}
+		q.ProviderPK = pk
+	}
 
-	// Store proofs
-	for i, receipt := range req.Receipts {
-		proof, err := tree.Proof(i)
-		if err == nil {
-			h.store.StoreProof(receipt.Receipt.ReceiptID, proof)
+	if e := c.Query("epoch"); e != "" {
+		if c.Query("from") != "" || c.Query("to") != "" {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Use epoch or from and to, not both"})
+			return q, false
+		}
+		n, err := strconv.ParseUint(e, 10, 64)
+		if err != nil || n == math.MaxUint64 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid epoch"})
+			return q, false
+		}
+		q.FromEpoch, q.ToEpoch = n, n+1
+		return q, true
+	}
+
+	schedule := h.epochManager.Schedule()
+	if from := c.Query("from"); from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid from time"})
+			return q, false
+		}
+		q.FromEpoch = schedule.EpochAt(t)
+	}
+	if to := c.Query("to"); to != "" {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid to time"})
+			return q, false
+		}
+		if !t.After(schedule.Genesis()) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "to is not after the first epoch began"})
+			return q, false
+		}
+		// The epoch containing to overlaps the range unless it starts at to
+		n := schedule.EpochAt(t)
+		if start, _, err := schedule.Bounds(n); err == nil && start.Before(t) {
+			n++
+		}
+		q.ToEpoch = n
+	}
+	return q, true
+}
+
+// providerKey returns a provider key in the standard base64 receipts use.
+// URL-safe base64 is accepted too, since a standard key can contain '/'
+// and so cannot always be a path segment.
+func providerKey(s string) (string, bool) {
+	for _, encoding := range []*base64.Encoding{
+		base64.StdEncoding,
+		base64.URLEncoding,
+		base64.RawURLEncoding,
+	} {
+		if key, err := encoding.DecodeString(s); err == nil && len(key) > 0 {
+			return base64.StdEncoding.EncodeToString(key), true
 		}
 	}
+	return "", false
+}
 
-	// Finalize epoch
-	if err := h.epochManager.FinalizeEpoch(req.Epoch, root, len(req.Receipts)); err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to finalize epoch"})
+// Multiproof proves a set of receipts from a sealed epoch in one proof, so
+// a provider can claim them together.
+func (h *Handler) Multiproof(c *gin.Context) {
+	n, err := strconv.ParseUint(c.Param("epoch"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid epoch"})
 		return
 	}
+	var req MultiproofRequest
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.ReceiptIDs) == 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request"})
+		return
+	}
+	seen := make(map[string]bool, len(req.ReceiptIDs))
+	for _, id := range req.ReceiptIDs {
+		if seen[id] {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("Receipt %s listed twice", id)})
+			return
+		}
+		seen[id] = true
+	}
 
-	h.logger.WithFields(logrus.Fields{
-		"epoch":         req.Epoch,
-		"receipt_count": len(req.Receipts),
-		"merkle_root":   root,
-	}).Info("Epoch committed")
-
-	c.JSON(http.StatusOK, CommitResponse{
-		MerkleRoot:   root,
-		Epoch:        req.Epoch,
-		ReceiptCount: len(req.Receipts),
+	proof, ids, err := h.sealer.Multiproof(n, req.ReceiptIDs)
+	switch {
+	case errors.Is(err, sealer.ErrNotSealed):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Epoch not finalized"})
+		return
+	case errors.Is(err, sealer.ErrNotInEpoch):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return
+	case err != nil:
+		h.logger.WithError(err).WithField("epoch", n).Error("Failed to build multiproof")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to build proof"})
+		return
+	}
+
+	// Amendments only grow an epoch, so a matching size means the root
+	// is the one the proof was built against
+	info, _ := h.epochManager.GetEpochInfo(n)
+	if info.ReceiptCount != proof.TreeSize {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Epoch was amended, retry"})
+		return
+	}
+	c.JSON(http.StatusOK, MultiproofResponse{
+		Epoch:      n,
+		Root:       info.Root,
+		ReceiptIDs: ids,
+		Proof:      proof,
 	})
 }
 
+// Payouts returns a sealed epoch's payout tree.
+func (h *Handler) Payouts(c *gin.Context) {
+	n, err := strconv.ParseUint(c.Param("epoch"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid epoch"})
+		return
+	}
+
+	payouts, err := h.sealer.Payouts(n)
+	if errors.Is(err, sealer.ErrNotSealed) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Epoch not finalized"})
+		return
+	}
+	if err != nil {
+		h.logger.WithError(err).WithField("epoch", n).Error("Failed to load payouts")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to load payouts"})
+		return
+	}
+	c.JSON(http.StatusOK, payouts)
+}
+
+// PayoutClaim returns what an address needs to claim its payout for an
+// epoch: its leaf, the leaf's proof and the receipts the leaf covers.
+func (h *Handler) PayoutClaim(c *gin.Context) {
+	n, err := strconv.ParseUint(c.Param("epoch"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid epoch"})
+		return
+	}
+	address := c.Param("address")
+	if !common.IsHexAddress(address) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid address"})
+		return
+	}
+
+	claim, err := h.sealer.PayoutClaim(n, address)
+	switch {
+	case errors.Is(err, sealer.ErrNotSealed):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Epoch not finalized"})
+		return
+	case errors.Is(err, payout.ErrNoPayout):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return
+	case err != nil:
+		h.logger.WithError(err).WithField("epoch", n).Error("Failed to build payout claim")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to build claim"})
+		return
+	}
+	c.JSON(http.StatusOK, claim)
+}
+
+// verifyReceipts verifies receipts in parallel and splits them into those that may
+// be stored and per-receipt errors for the rest. Indexes in the errors refer
+// to the request order.
+func (h *Handler) verifyReceipts(receipts []*storage.SignedReceipt) ([]*storage.SignedReceipt, []ReceiptError) {
+	errs := h.verifier.VerifyParallel(receipts)
+
+	accepted := make([]*storage.SignedReceipt, 0, len(receipts))
+	var rejected []ReceiptError
+	for i, err := range errs {
+		if err == nil {
+			metrics.SignatureVerificationsTotal.WithLabelValues("valid").Inc()
+			accepted = append(accepted, receipts[i])
+			continue
+		}
+
+		result := "invalid"
+		switch {
+		case errors.Is(err, verify.ErrUnregisteredKey):
+			result = "unregistered"
+		case errors.Is(err, verify.ErrCheckpointReceipt):
+			result = "checkpoint"
+		case errors.Is(err, verify.ErrRevokedKey):
+			result = "revoked"
+		}
+		metrics.SignatureVerificationsTotal.WithLabelValues(result).Inc()
+
+		// A null entry has nothing to quarantine or identify it by
+		var id, pk string
+		if receipts[i] != nil {
+			id, pk = receipts[i].Receipt.ReceiptID, receipts[i].Receipt.ProviderPK
+		}
+		quarantined := h.signaturePolicy == SignaturePolicyQuarantine && receipts[i] != nil
+		if quarantined {
+			if qerr := h.store.Quarantine(receipts[i], err.Error()); qerr != nil {
+				h.logger.WithError(qerr).Error("Failed to quarantine receipt")
+				quarantined = false
+			}
+		}
+
+		h.logger.WithFields(logrus.Fields{
+			"receipt_id":  id,
+			"provider_pk": pk,
+			"quarantined": quarantined,
+		}).WithError(err).Warn("Receipt failed verification")
+
+		rejected = append(rejected, ReceiptError{
+			Index:       i,
+			ReceiptID:   id,
+			Error:       err.Error(),
+			Quarantined: quarantined,
+		})
+	}
+
+	return accepted, rejected
+}
+
+// Quarantine lists receipts held back because they failed verification.
+func (h *Handler) Quarantine(c *gin.Context) {
+	c.JSON(http.StatusOK, QuarantineResponse{
+		Receipts: h.store.GetQuarantined(),
+	})
+}
+
+// AuditFindings lists chain findings, newest first. Optional query
+// parameters: provider, kind, unresolved=true and limit.
+func (h *Handler) AuditFindings(c *gin.Context) {
+	filter := audit.Filter{
+		ProviderPK: c.Query("provider"),
+		Kind:       audit.Kind(c.Query("kind")),
+		Unresolved: c.Query("unresolved") == "true",
+		Limit:      100,
+	}
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid limit"})
+			return
+		}
+		filter.Limit = n
+	}
+
+	c.JSON(http.StatusOK, AuditFindingsResponse{
+		Findings: h.auditor.Findings(filter),
+	})
+}
+
+// AuditChains summarizes every provider chain the auditor has seen.
+func (h *Handler) AuditChains(c *gin.Context) {
+	c.JSON(http.StatusOK, AuditChainsResponse{
+		Chains: h.auditor.Chains(),
+	})
+}
+
+// Claim pays one receipt through the settler, once its inclusion proof
+// checks out against the epoch's root and that root is anchored. The
+// settlement contract checks the proof again before paying the receipt
+// provider's payout address. A receipt is paid at most once: claiming it
+// again while the first claim is pending or confirmed answers 409 with that
+// claim.
 func (h *Handler) Claim(c *gin.Context) {
 	var req ClaimRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -126,6 +880,12 @@ func (h *Handler) Claim(c *gin.Context) {
 		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Receipt not found"})
 		return
 	}
+	// A receipt is paid from its own epoch only; another epoch may have
+	// the same root
+	if receipt.Receipt.Epoch < 0 || uint64(receipt.Receipt.Epoch) != req.Epoch {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("Receipt belongs to epoch %d", receipt.Receipt.Epoch)})
+		return
+	}
 
 	// Get epoch info
 	epochInfo, exists := h.epochManager.GetEpochInfo(req.Epoch)
@@ -135,83 +895,172 @@ func (h *Handler) Claim(c *gin.Context) {
 	}
 
 	// Verify proof
-	canonical, err := canonicalizeJSON(receipt.Receipt)
+	canonical, err := jcs.Canonicalize(receipt.Receipt)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to canonicalize receipt"})
 		return
 	}
-	valid := merkle.Verify(canonical, req.MerkleProof, epochInfo.Root)
+	if !merkle.Verify(canonical, req.MerkleProof, epochInfo.Root) {
+		c.JSON(http.StatusOK, ClaimResponse{Valid: false, Amount: "0"})
+		return
+	}
+	if !payout.Paid(&receipt.Receipt) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Stream checkpoints are not paid; claim the final receipt"})
+		return
+	}
+	if h.settler == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "Settlement is not configured"})
+		return
+	}
+	address, ok := h.sealer.Address(receipt.Receipt.ProviderPK)
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Provider has no payout address"})
+		return
+	}
+
+	// The contract checks the proof against the root committed on chain,
+	// which an epoch only has once it is anchored
+	if epochInfo.State != epoch.StateAnchored {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Epoch root is not anchored yet"})
+		return
+	}
+
+	claim, err := h.settler.Submit(c.Request.Context(), &settlement.Claim{
+		ID:        req.ReceiptID,
+		Epoch:     req.Epoch,
+		LeafIndex: uint64(req.MerkleProof.LeafIndex),
+		LeafHash:  hex.EncodeToString(merkle.LeafHash(canonical)),
+		Proof:     req.MerkleProof.Hashes,
+		Provider:  address.Hex(),
+		Amount:    h.sealer.Price(&receipt.Receipt).String(),
+	})
+	if errors.Is(err, settlement.ErrAlreadyClaimed) {
+		resp := claimResponse(claim)
+		resp.Error = "Receipt already claimed"
+		c.JSON(http.StatusConflict, resp)
+		return
+	}
+	if err != nil {
+		h.logger.WithError(err).WithField("receipt_id", req.ReceiptID).Error("Failed to record claim")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to record claim"})
+		return
+	}
 
-	amount := "0"
-	if valid {
-		// Calculate amount based on tokens
-		totalTokens := receipt.Receipt.TokensIn + receipt.Receipt.TokensOut
-		amount = calculateAmount(totalTokens)
+	if claim.Status == settlement.StatusPending {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), h.claimWait)
+		waited, err := h.settler.Wait(ctx, claim.ID)
+		cancel()
+		if err != nil {
+			h.logger.WithError(err).WithField("receipt_id", req.ReceiptID).Warn("Failed to check claim")
+		}
+		if waited != nil {
+			claim = waited
+		}
 	}
 
 	h.logger.WithFields(logrus.Fields{
 		"receipt_id": req.ReceiptID,
 		"epoch":      req.Epoch,
-		"valid":      valid,
-		"amount":     amount,
+		"amount":     claim.Amount,
+		"tx_hash":    claim.TxHash,
+		"status":     claim.Status,
 	}).Info("Claim processed")
 
-	c.JSON(http.StatusOK, ClaimResponse{
-		Valid:  valid,
-		Amount: amount,
-		TxHash: "0x" + hashString(req.ReceiptID+epochInfo.Root),
-	})
+	c.JSON(http.StatusOK, claimResponse(claim))
 }
 
-func (h *Handler) GetState(c *gin.Context) {
-	state, err := h.store.ExportState()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to export state"})
-		return
+func claimResponse(claim *settlement.Claim) ClaimResponse {
+	return ClaimResponse{
+		Valid:  true,
+		Amount: claim.Amount,
+		TxHash: claim.TxHash,
+		Status: string(claim.Status),
+		Error:  claim.Error,
 	}
-
-	c.Data(http.StatusOK, "application/json", state)
 }
 
-func canonicalizeJSON(v interface{}) ([]byte, error) {
-	data, err := json.Marshal(v)
+// GetClaim returns a claim's settlement record by receipt ID.
+func (h *Handler) GetClaim(c *gin.Context) {
+	if h.settler == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "Settlement is not configured"})
+		return
+	}
+	claim, err := h.settler.Get(c.Param("id"))
 	if err != nil {
-		return nil, err
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to load claim"})
+		return
 	}
-
-	var m map[string]interface{}
-	if err := json.Unmarshal(data, &m); err != nil {
-		return nil, err
+	if claim == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Claim not found"})
+		return
 	}
-
-	return json.Marshal(sortKeys(m))
+	c.JSON(http.StatusOK, claim)
 }
 
-func sortKeys(m map[string]interface{}) map[string]interface{} {
-	result := make(map[string]interface{})
-
-	keys := make([]string, 0, len(m))
-	for k := range m {
-		keys = append(keys, k)
+// SubmitEvidence records a gateway's signed evidence that a provider failed
+// a canary and penalizes the provider. Evidence for a receipt already
+// recorded answers 200 with the existing record.
+func (h *Handler) SubmitEvidence(c *gin.Context) {
+	if h.ledger == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "Canary evidence is not accepted"})
+		return
 	}
-	sort.Strings(keys)
-
-	for _, k := range keys {
-		v := m[k]
-		switch val := v.(type) {
-		case map[string]interface{}:
-			result[k] = sortKeys(val)
-		default:
-			result[k] = v
-		}
+	var signed slashing.SignedEvidence
+	if err := c.ShouldBindJSON(&signed); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request"})
+		return
 	}
 
-	return result
+	record, created, err := h.ledger.Record(c.Request.Context(), &signed)
+	switch {
+	case errors.Is(err, slashing.ErrUntrustedGateway):
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, slashing.ErrInvalidEvidence):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	case err != nil:
+		h.logger.WithError(err).Error("Failed to record evidence")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to record evidence"})
+	case created:
+		c.JSON(http.StatusCreated, record)
+	default:
+		c.JSON(http.StatusOK, record)
+	}
 }
 
-func hashString(s string) string {
-	h := sha256.Sum256([]byte(s))
-	return hex.EncodeToString(h[:])
+// ListEvidence returns recorded canary evidence. Optional query
+// parameters: provider and epoch.
+func (h *Handler) ListEvidence(c *gin.Context) {
+	if h.ledger == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "Canary evidence is not accepted"})
+		return
+	}
+	var pk string
+	if provider := c.Query("provider"); provider != "" {
+		var ok bool
+		if pk, ok = providerKey(provider); !ok {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid provider key"})
+			return
+		}
+	}
+	records := h.ledger.List(pk)
+	if e := c.Query("epoch"); e != "" {
+		n, err := strconv.ParseUint(e, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid epoch"})
+			return
+		}
+		var inEpoch []*slashing.Record
+		for _, r := range records {
+			if r.Epoch == n {
+				inEpoch = append(inEpoch, r)
+			}
+		}
+		records = inEpoch
+	}
+	if records == nil {
+		records = []*slashing.Record{}
+	}
+	c.JSON(http.StatusOK, EvidenceResponse{Evidence: records})
 }
 
 func (h *Handler) Health(c *gin.Context) {
@@ -224,8 +1073,3 @@ func (h *Handler) Health(c *gin.Context) {
 	})
 }
 
-func calculateAmount(tokens int) string {
-	// Simple calculation: 1 token = 0.0001 units
-	amount := tokens * 100
-	return fmt.Sprintf("%d", amount)
-}
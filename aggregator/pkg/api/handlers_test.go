@@ -2,82 +2,166 @@ package api
 
 import (
 	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
 	"testing"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/gin-gonic/gin"
+	"github.com/quiver/aggregator/pkg/audit"
 	"github.com/quiver/aggregator/pkg/epoch"
+	"github.com/quiver/aggregator/pkg/jcs"
 	"github.com/quiver/aggregator/pkg/merkle"
+	"github.com/quiver/aggregator/pkg/payout"
+	"github.com/quiver/aggregator/pkg/registry"
+	"github.com/quiver/aggregator/pkg/sealer"
+	"github.com/quiver/aggregator/pkg/settlement"
+	"github.com/quiver/aggregator/pkg/slashing"
 	"github.com/quiver/aggregator/pkg/storage"
+	"github.com/quiver/aggregator/pkg/verify"
 )
 
+var testProviderKey = ed25519.NewKeyFromSeed(bytes.Repeat([]byte{7}, ed25519.SeedSize))
+
+// signReceipt returns r signed as a v2 receipt by testProviderKey.
+func signReceipt(t *testing.T, r storage.Receipt) *storage.SignedReceipt {
+	t.Helper()
+
+	pub := testProviderKey.Public().(ed25519.PublicKey)
+	r.Version = verify.VersionV2
+	r.ProviderPK = base64.StdEncoding.EncodeToString(pub)
+
+	signed := &storage.SignedReceipt{
+		Receipt:   r,
+		Algorithm: verify.AlgEd25519,
+		KeyID:     verify.KeyID(pub),
+	}
+	input, err := verify.SigningInput(&signed.Receipt, signed.Algorithm, signed.KeyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(testProviderKey, input))
+	return signed
+}
+
+// setClock makes h's sealer read the time from the returned pointer, which
+// starts inside epoch 19723 while it still admits receipts.
+func setClock(s *sealer.Sealer) *time.Time {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	s.SetClock(func() time.Time { return now })
+	return &now
+}
+
+// after19723 is when epoch 19723's default grace window has passed.
+var after19723 = time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC).Add(sealer.DefaultGrace)
+
 func TestCommitEndpoint(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	store := storage.NewStore()
 	epochManager := epoch.NewManager()
 	handler := NewHandler(store, epochManager)
+	now := setClock(handler.sealer)
 
 	router := gin.New()
 	router.POST("/commit", handler.Commit)
 
-	receipts := []*storage.SignedReceipt{
-		{
-			Receipt: storage.Receipt{
-				Version:   "1.0.0",
-				ReceiptID: "test1",
-				Epoch:     19723,
-				Seq:       1,
-				TokensIn:  5,
-				TokensOut: 10,
-			},
-			Signature: "sig1",
-		},
-		{
-			Receipt: storage.Receipt{
-				Version:   "1.0.0",
-				ReceiptID: "test2",
-				Epoch:     19723,
-				Seq:       2,
-				TokensIn:  3,
-				TokensOut: 7,
-			},
-			Signature: "sig2",
-		},
-	}
-
-	req := CommitRequest{
-		Receipts: receipts,
-		Epoch:    19723,
-	}
-
-	body, _ := json.Marshal(req)
-	request := httptest.NewRequest("POST", "/commit", bytes.NewReader(body))
-	request.Header.Set("Content-Type", "application/json")
+	first := signReceipt(t, storage.Receipt{
+		ReceiptID: "test1",
+		Epoch:     19723,
+		Seq:       1,
+		TokensIn:  5,
+		TokensOut: 10,
+	})
+	prevHash, _ := audit.ChainHash(&first.Receipt)
+	second := signReceipt(t, storage.Receipt{
+		ReceiptID: "test2",
+		Epoch:     19723,
+		Seq:       2,
+		PrevHash:  prevHash,
+		TokensIn:  3,
+		TokensOut: 7,
+	})
+	receipts := []*storage.SignedReceipt{first, second}
 
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, request)
+	commit := func(req CommitRequest) (int, CommitResponse) {
+		body, _ := json.Marshal(req)
+		request := httptest.NewRequest("POST", "/commit", bytes.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, request)
 
-	if w.Code != http.StatusOK {
-		t.Errorf("Expected status 200, got %d", w.Code)
+		var resp CommitResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
 	}
 
-	var resp CommitResponse
-	json.Unmarshal(w.Body.Bytes(), &resp)
-
-	if resp.Epoch != 19723 {
-		t.Errorf("Expected epoch 19723, got %d", resp.Epoch)
+	// Inside its window the epoch takes the receipts and stays open
+	code, resp := commit(CommitRequest{Receipts: receipts, Epoch: 19723})
+	if code != http.StatusAccepted || resp.State != epoch.StateOpen || resp.MerkleRoot != "" {
+		t.Fatalf("commit inside the window: %d %+v", code, resp)
+	}
+	if resp.Epoch != 19723 || resp.ReceiptCount != 2 {
+		t.Errorf("Expected 2 receipts in epoch 19723, got %+v", resp)
 	}
 
+	// Once the grace window has passed the same commit seals the epoch
+	*now = after19723
+	code, resp = commit(CommitRequest{Receipts: receipts, Epoch: 19723})
+	if code != http.StatusOK || resp.State != epoch.StateFinalized || len(resp.Rejected) != 0 {
+		t.Fatalf("commit after the window: %d %+v", code, resp)
+	}
 	if resp.ReceiptCount != 2 {
 		t.Errorf("Expected 2 receipts, got %d", resp.ReceiptCount)
 	}
-
 	if resp.MerkleRoot == "" {
 		t.Error("Expected non-empty merkle root")
 	}
+
+	// A past epoch that never took receipts is closed
+	late := signReceipt(t, storage.Receipt{ReceiptID: "test3", Epoch: 19722, Seq: 3})
+	code, resp = commit(CommitRequest{Receipts: []*storage.SignedReceipt{late}, Epoch: 19722})
+	if code != http.StatusBadRequest || len(resp.Rejected) != 1 {
+		t.Errorf("commit to a past epoch: %d %+v", code, resp)
+	}
+	if _, exists := epochManager.GetEpochInfo(19722); exists {
+		t.Error("commit to a past epoch created it")
+	}
+}
+
+// chainBackend confirms every claim for a leaf it has not paid before, like
+// the settlement contract.
+type chainBackend struct {
+	paid map[[2]uint64]bool
+	txs  map[string]string
+}
+
+func (b *chainBackend) SubmitClaim(ctx context.Context, epoch, leafIndex uint64, leafHash [32]byte, proof [][32]byte, provider string, amount *big.Int) (string, error) {
+	if b.txs == nil {
+		b.paid, b.txs = make(map[[2]uint64]bool), make(map[string]string)
+	}
+	txHash := fmt.Sprintf("0x%064x", len(b.txs)+1)
+	b.txs[txHash] = "confirmed"
+	leaf := [2]uint64{epoch, leafIndex}
+	if b.paid[leaf] {
+		b.txs[txHash] = "failed"
+	}
+	b.paid[leaf] = true
+	return txHash, nil
+}
+
+func (b *chainBackend) TransactionStatus(ctx context.Context, txHash string) (string, error) {
+	return b.txs[txHash], nil
 }
 
 func TestClaimEndpoint(t *testing.T) {
@@ -89,13 +173,16 @@ func TestClaimEndpoint(t *testing.T) {
 
 	router := gin.New()
 	router.POST("/claim", handler.Claim)
+	router.GET("/claims/:id", handler.GetClaim)
 
 	// Setup test data
 	receipt := &storage.SignedReceipt{
 		Receipt: storage.Receipt{
-			ReceiptID: "claim-test",
-			TokensIn:  10,
-			TokensOut: 20,
+			ReceiptID:  "claim-test",
+			ProviderPK: "pk-a",
+			Epoch:      19723,
+			TokensIn:   10,
+			TokensOut:  20,
 		},
 		Signature: "sig",
 	}
@@ -103,7 +190,7 @@ func TestClaimEndpoint(t *testing.T) {
 
 	// Create a merkle tree with the receipt
 	tree := merkle.NewTree()
-	canonical, _ := canonicalizeJSON(receipt.Receipt)
+	canonical, _ := jcs.Canonicalize(receipt.Receipt)
 	tree.AddLeaf(canonical)
 	tree.Build()
 
@@ -113,31 +200,862 @@ func TestClaimEndpoint(t *testing.T) {
 	// Finalize epoch with actual root
 	epochManager.FinalizeEpoch(19723, tree.Root(), 1)
 
-	req := ClaimRequest{
-		ReceiptID:   "claim-test",
-		MerkleProof: proof,
-		Epoch:       19723,
+	claimIn := func(n uint64, proof *merkle.Proof) (int, ClaimResponse) {
+		body, _ := json.Marshal(ClaimRequest{ReceiptID: "claim-test", MerkleProof: proof, Epoch: n})
+		request := httptest.NewRequest("POST", "/claim", bytes.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, request)
+
+		var resp ClaimResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+	claim := func(proof *merkle.Proof) (int, ClaimResponse) {
+		return claimIn(19723, proof)
+	}
+
+	if code, _ := claim(proof); code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status 503 without settlement, got %d", code)
+	}
+
+	handler.SetSettler(settlement.New(store, &chainBackend{}), time.Second)
+	if code, _ := claim(proof); code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400 without a payout address, got %d", code)
+	}
+
+	address := "0x00000000000000000000000000000000000000aa"
+	handler.sealer.SetAddresses(payout.Addresses{"pk-a": common.HexToAddress(address)})
+
+	bad := *proof
+	bad.Hashes = []string{tree.Root()}
+	if code, resp := claim(&bad); code != http.StatusOK || resp.Valid || resp.TxHash != "" {
+		t.Fatalf("Invalid proof: status %d, response %+v", code, resp)
+	}
+
+	if code, _ := claim(proof); code != http.StatusConflict {
+		t.Fatalf("Expected status 409 before the epoch is anchored, got %d", code)
+	}
+	if err := epochManager.MarkAnchored(19723, tree.Root(), "0xanchor"); err != nil {
+		t.Fatal(err)
+	}
+
+	// An anchored epoch with the same root does not pay the receipt
+	epochManager.FinalizeEpoch(19724, tree.Root(), 1)
+	if err := epochManager.MarkAnchored(19724, tree.Root(), "0xanchor2"); err != nil {
+		t.Fatal(err)
+	}
+	if code, _ := claimIn(19724, proof); code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400 for another epoch, got %d", code)
+	}
+
+	code, resp := claim(proof)
+	if code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if !resp.Valid || resp.Amount != "3000" || resp.Status != "confirmed" || len(resp.TxHash) != 66 {
+		t.Fatalf("Unexpected claim response %+v", resp)
+	}
+
+	code, again := claim(proof)
+	if code != http.StatusConflict || again.TxHash != resp.TxHash || again.Status != "confirmed" {
+		t.Fatalf("Second claim: status %d, response %+v", code, again)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/claims/claim-test", nil))
+	var stored settlement.Claim
+	json.Unmarshal(w.Body.Bytes(), &stored)
+	if w.Code != http.StatusOK || stored.TxHash != resp.TxHash || stored.Provider != common.HexToAddress(address).Hex() || stored.Epoch != 19723 ||
+		stored.LeafHash != hex.EncodeToString(merkle.LeafHash(canonical)) {
+		t.Fatalf("GET /claims: status %d, claim %+v", w.Code, stored)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/claims/nope", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("Expected 404 for unknown claim, got %d", w.Code)
 	}
+}
+
+func TestCommitReportsChainFindings(t *testing.T) {
+	gin.SetMode(gin.TestMode)
 
+	store := storage.NewStore()
+	epochManager := epoch.NewManager()
+	handler := NewHandler(store, epochManager)
+	setClock(handler.sealer)
+
+	router := gin.New()
+	router.POST("/commit", handler.Commit)
+	router.GET("/audit/findings", handler.AuditFindings)
+	router.GET("/audit/chains", handler.AuditChains)
+
+	// seq 1 and 3 from the same provider: seq 2 is missing
+	req := CommitRequest{
+		Epoch: 19723,
+		Receipts: []*storage.SignedReceipt{
+			signReceipt(t, storage.Receipt{ReceiptID: "r1", Epoch: 19723, Seq: 1}),
+			signReceipt(t, storage.Receipt{ReceiptID: "r3", Epoch: 19723, Seq: 3, PrevHash: "h2"}),
+		},
+	}
 	body, _ := json.Marshal(req)
-	request := httptest.NewRequest("POST", "/claim", bytes.NewReader(body))
+	request := httptest.NewRequest("POST", "/commit", bytes.NewReader(body))
 	request.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, request)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d", w.Code)
+	}
+
+	var resp CommitResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.ChainFindings) != 1 || resp.ChainFindings[0].Kind != audit.KindGap {
+		t.Fatalf("Expected one gap finding, got %+v", resp.ChainFindings)
+	}
+
+	// A client retrying the same batch after a timeout is not replaying it
+	request = httptest.NewRequest("POST", "/commit", bytes.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, request)
+	resp = CommitResponse{}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusAccepted || len(resp.ChainFindings) != 0 {
+		t.Fatalf("retried commit: %d %+v", w.Code, resp.ChainFindings)
+	}
+
+	w = httptest.NewRecorder()
+	provider := req.Receipts[0].Receipt.ProviderPK
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/audit/findings?provider="+url.QueryEscape(provider), nil))
+	var findings AuditFindingsResponse
+	json.Unmarshal(w.Body.Bytes(), &findings)
+	if len(findings.Findings) != 1 || findings.Findings[0].Kind != audit.KindGap {
+		t.Errorf("Expected 1 finding from API, got %d", len(findings.Findings))
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/audit/chains", nil))
+	var chains AuditChainsResponse
+	json.Unmarshal(w.Body.Bytes(), &chains)
+	if len(chains.Chains) != 1 || chains.Chains[0].Missing != 1 {
+		t.Errorf("Expected one chain with one missing receipt, got %+v", chains.Chains)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/audit/findings?limit=0", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid limit, got %d", w.Code)
+	}
+}
+
+func TestCommitSignaturePolicy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	good := signReceipt(t, storage.Receipt{ReceiptID: "good", Epoch: 19723, Seq: 1, TokensOut: 10})
+	tampered := signReceipt(t, storage.Receipt{ReceiptID: "tampered", Epoch: 19723, Seq: 2, TokensOut: 10})
+	tampered.Receipt.TokensOut = 10000
+
+	commit := func(router *gin.Engine, receipts ...*storage.SignedReceipt) (int, CommitResponse) {
+		body, _ := json.Marshal(CommitRequest{Epoch: 19723, Receipts: receipts})
+		request := httptest.NewRequest("POST", "/commit", bytes.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, request)
+
+		var resp CommitResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+
+	t.Run("reject", func(t *testing.T) {
+		store := storage.NewStore()
+		handler := NewHandler(store, epoch.NewManager())
+		setClock(handler.sealer)
+		router := gin.New()
+		router.POST("/commit", handler.Commit)
+
+		code, resp := commit(router, good, tampered)
+		if code != http.StatusAccepted {
+			t.Fatalf("Expected status 202, got %d", code)
+		}
+		if resp.ReceiptCount != 1 {
+			t.Errorf("Expected 1 accepted receipt, got %d", resp.ReceiptCount)
+		}
+		if len(resp.Rejected) != 1 || resp.Rejected[0].Index != 1 || resp.Rejected[0].ReceiptID != "tampered" {
+			t.Fatalf("Expected tampered receipt rejected at index 1, got %+v", resp.Rejected)
+		}
+		if resp.Rejected[0].Quarantined {
+			t.Error("Rejected receipt should not be quarantined")
+		}
+		if stored, _ := store.GetByID("tampered"); stored != nil {
+			t.Error("Tampered receipt was stored")
+		}
+		if len(store.GetQuarantined()) != 0 {
+			t.Error("Expected empty quarantine")
+		}
+
+		code, resp = commit(router, tampered)
+		if code != http.StatusBadRequest {
+			t.Errorf("Expected 400 when every receipt fails, got %d", code)
+		}
+		if len(resp.Rejected) != 1 {
+			t.Errorf("Expected rejection detail, got %+v", resp.Rejected)
+		}
+	})
+
+	t.Run("quarantine", func(t *testing.T) {
+		store := storage.NewStore()
+		handler := NewHandler(store, epoch.NewManager())
+		setClock(handler.sealer)
+		if err := handler.SetSignaturePolicy(SignaturePolicyQuarantine); err != nil {
+			t.Fatal(err)
+		}
+		router := gin.New()
+		router.POST("/commit", handler.Commit)
+		router.GET("/quarantine", handler.Quarantine)
+
+		// A null entry is rejected on its own, with nothing to quarantine
+		code, resp := commit(router, good, tampered, nil)
+		if code != http.StatusAccepted {
+			t.Fatalf("Expected status 202, got %d", code)
+		}
+		if len(resp.Rejected) != 2 || !resp.Rejected[0].Quarantined {
+			t.Fatalf("Expected tampered receipt quarantined, got %+v", resp.Rejected)
+		}
+		if null := resp.Rejected[1]; null.Index != 2 || null.Quarantined {
+			t.Errorf("Expected null receipt rejected at index 2, got %+v", null)
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/quarantine", nil))
+		var quarantine QuarantineResponse
+		json.Unmarshal(w.Body.Bytes(), &quarantine)
+		if len(quarantine.Receipts) != 1 || quarantine.Receipts[0].Receipt.Receipt.ReceiptID != "tampered" {
+			t.Errorf("Expected tampered receipt in quarantine, got %+v", quarantine.Receipts)
+		}
+	})
+
+	t.Run("allowlist", func(t *testing.T) {
+		handler := NewHandler(storage.NewStore(), epoch.NewManager())
+		handler.SetVerifier(verify.NewVerifier(verify.NewAllowlist([]string{"other"})))
+		router := gin.New()
+		router.POST("/commit", handler.Commit)
+
+		code, resp := commit(router, good)
+		if code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for unregistered provider, got %d", code)
+		}
+		if len(resp.Rejected) != 1 {
+			t.Errorf("Expected rejection detail, got %+v", resp.Rejected)
+		}
+	})
+
+	if err := NewHandler(storage.NewStore(), epoch.NewManager()).SetSignaturePolicy("ignore"); err == nil {
+		t.Error("Expected error for unknown policy")
+	}
+}
+
+func TestCommitFinalizedEpoch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	epochManager := epoch.NewManager()
+	handler := NewHandler(storage.NewStore(), epochManager)
+	now := setClock(handler.sealer)
+	router := gin.New()
+	router.POST("/commit", handler.Commit)
+	router.GET("/epochs", handler.ListEpochs)
+	router.GET("/epochs/:epoch", handler.GetEpoch)
+
+	commit := func(req CommitRequest) (int, CommitResponse) {
+		body, _ := json.Marshal(req)
+		request := httptest.NewRequest("POST", "/commit", bytes.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, request)
+
+		var resp CommitResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+
+	first := signReceipt(t, storage.Receipt{ReceiptID: "f1", Epoch: 19723, Seq: 1})
+	prevHash, _ := audit.ChainHash(&first.Receipt)
+	late := signReceipt(t, storage.Receipt{ReceiptID: "f2", Epoch: 19723, Seq: 2, PrevHash: prevHash})
+
+	if code, resp := commit(CommitRequest{Epoch: 19723, Receipts: []*storage.SignedReceipt{first}}); code != http.StatusAccepted {
+		t.Fatalf("first commit: %d %+v", code, resp)
+	}
+	*now = after19723
+	code, original := commit(CommitRequest{Epoch: 19723, Receipts: []*storage.SignedReceipt{first}})
+	if code != http.StatusOK || original.State != epoch.StateFinalized || original.Version != 1 {
+		t.Fatalf("commit after the window: %d %+v", code, original)
+	}
+
+	// The same commit again is accepted without change
+	code, resp := commit(CommitRequest{Epoch: 19723, Receipts: []*storage.SignedReceipt{first}})
+	if code != http.StatusOK || resp.MerkleRoot != original.MerkleRoot || resp.Version != 1 {
+		t.Errorf("repeated commit: %d %+v", code, resp)
+	}
+
+	// A different commit cannot rewrite the root
+	both := []*storage.SignedReceipt{first, late}
+	if code, _ := commit(CommitRequest{Epoch: 19723, Receipts: both}); code != http.StatusConflict {
+		t.Errorf("Expected 409 for a conflicting commit, got %d", code)
+	}
+	if info, _ := epochManager.GetEpochInfo(19723); info.Root != original.MerkleRoot {
+		t.Fatal("conflicting commit changed the root")
+	}
+
+	if code, _ := commit(CommitRequest{Epoch: 19723, Receipts: both, Amend: true}); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an amendment without a reason, got %d", code)
+	}
+	if code, _ := commit(CommitRequest{Epoch: 19724, Receipts: both, Amend: true, Reason: "x"}); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for amending an open epoch, got %d", code)
+	}
+
+	code, resp = commit(CommitRequest{Epoch: 19723, Receipts: both, Amend: true, Reason: "late receipt"})
+	if code != http.StatusOK || resp.Version != 2 || resp.MerkleRoot == original.MerkleRoot {
+		t.Fatalf("amendment: %d %+v", code, resp)
+	}
 
 	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/epochs/19723", nil))
+	var info epoch.Info
+	json.Unmarshal(w.Body.Bytes(), &info)
+	if w.Code != http.StatusOK || len(info.Amendments) != 1 || info.Amendments[0].PrevRoot != original.MerkleRoot {
+		t.Errorf("epoch after amendment: %d %+v", w.Code, info)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/epochs?state=finalized", nil))
+	var list EpochsResponse
+	json.Unmarshal(w.Body.Bytes(), &list)
+	if len(list.Epochs) != 1 || list.Epochs[0].Epoch != 19723 {
+		t.Errorf("finalized epochs: %+v", list.Epochs)
+	}
+
+	for path, want := range map[string]int{
+		"/epochs/1":         http.StatusNotFound,
+		"/epochs/x":         http.StatusBadRequest,
+		"/epochs?state=bad": http.StatusBadRequest,
+	} {
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != want {
+			t.Errorf("GET %s: got %d, want %d", path, w.Code, want)
+		}
+	}
+}
+
+func TestIngestThenSeal(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := storage.NewStore()
+	epochManager := epoch.NewManager()
+	handler := NewHandler(store, epochManager)
+	epochSealer := sealer.New(store, epochManager)
+	now := setClock(epochSealer)
+	handler.SetSealer(epochSealer)
+
+	router := gin.New()
+	router.POST("/receipts", handler.Ingest)
+	router.POST("/commit", handler.Commit)
+
+	post := func(path string, v interface{}) (int, []byte) {
+		body, _ := json.Marshal(v)
+		request := httptest.NewRequest("POST", path, bytes.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, request)
+		return w.Code, w.Body.Bytes()
+	}
+
+	first := signReceipt(t, storage.Receipt{ReceiptID: "i1", Epoch: 19723, Seq: 1})
+	prevHash, _ := audit.ChainHash(&first.Receipt)
+	second := signReceipt(t, storage.Receipt{ReceiptID: "i2", Epoch: 19723, Seq: 2, PrevHash: prevHash})
+
+	// Receipts arrive one request at a time without sealing the epoch
+	for _, receipt := range []*storage.SignedReceipt{first, second} {
+		code, body := post("/receipts", IngestRequest{Receipts: []*storage.SignedReceipt{receipt}})
+		var resp IngestResponse
+		json.Unmarshal(body, &resp)
+		if code != http.StatusOK || resp.Accepted != 1 {
+			t.Fatalf("ingest: %d %s", code, body)
+		}
+	}
+	if info, _ := epochManager.GetEpochInfo(19723); info.State != epoch.StateOpen {
+		t.Fatalf("ingest changed the epoch to %s", info.State)
+	}
+
+	*now = after19723
+	sealed, err := epochSealer.SealDue(*now)
+	if err != nil || len(sealed) != 1 {
+		t.Fatalf("sealed %v: %v", sealed, err)
+	}
+	info, _ := epochManager.GetEpochInfo(19723)
+	if info.ReceiptCount != 2 {
+		t.Errorf("sealed %d receipts, want 2", info.ReceiptCount)
+	}
+
+	// A late receipt is turned away
+	late := signReceipt(t, storage.Receipt{ReceiptID: "i3", Epoch: 19723, Seq: 3})
+	code, body := post("/receipts", IngestRequest{Receipts: []*storage.SignedReceipt{late}})
+	var resp IngestResponse
+	json.Unmarshal(body, &resp)
+	if code != http.StatusBadRequest || len(resp.Rejected) != 1 {
+		t.Errorf("late receipt: %d %s", code, body)
+	}
+
+	// Committing what the sealer already sealed is a no-op
+	code, body = post("/commit", CommitRequest{Epoch: 19723, Receipts: []*storage.SignedReceipt{second}})
+	var commit CommitResponse
+	json.Unmarshal(body, &commit)
+	if code != http.StatusOK || commit.MerkleRoot != info.Root || commit.ReceiptCount != 2 {
+		t.Errorf("repeated commit: %d %s", code, body)
+	}
+
+	// A receipt in the next epoch cannot take a sealed receipt's ID
+	hijack := signReceipt(t, storage.Receipt{ReceiptID: "i1", Epoch: 19724, Seq: 3, PrevHash: prevHash})
+	code, body = post("/receipts", IngestRequest{Receipts: []*storage.SignedReceipt{hijack}})
+	json.Unmarshal(body, &resp)
+	if code != http.StatusBadRequest || len(resp.Rejected) != 1 {
+		t.Errorf("receipt reusing a sealed ID: %d %s", code, body)
+	}
+	if n := store.Count(19723); n != 2 {
+		t.Errorf("sealed epoch stores %d receipts, want 2", n)
+	}
+
+	// A commit only takes receipts for its own epoch
+	other := signReceipt(t, storage.Receipt{ReceiptID: "o1", Epoch: 19800, Seq: 4})
+	code, body = post("/commit", CommitRequest{Epoch: 19801, Receipts: []*storage.SignedReceipt{other}})
+	json.Unmarshal(body, &commit)
+	if code != http.StatusBadRequest || len(commit.Rejected) != 1 {
+		t.Errorf("commit for another epoch: %d %s", code, body)
+	}
+}
+
+func TestMultiproofEndpoint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := storage.NewStore()
+	epochManager := epoch.NewManager()
+	handler := NewHandler(store, epochManager)
+
+	router := gin.New()
+	router.POST("/epochs/:epoch/multiproof", handler.Multiproof)
+
+	post := func(path string, v interface{}) (int, []byte) {
+		body, _ := json.Marshal(v)
+		request := httptest.NewRequest("POST", path, bytes.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, request)
+		return w.Code, w.Body.Bytes()
+	}
+
+	if code, body := post("/epochs/19723/multiproof", MultiproofRequest{ReceiptIDs: []string{"m1"}}); code != http.StatusBadRequest {
+		t.Errorf("open epoch: %d %s", code, body)
+	}
+
+	for _, id := range []string{"m1", "m2", "m3", "m4"} {
+		store.Store(&storage.SignedReceipt{Receipt: storage.Receipt{ReceiptID: id, Epoch: 19723, TokensOut: 5}, Signature: "sig"})
+	}
+	if _, err := handler.sealer.Seal(19723); err != nil {
+		t.Fatal(err)
+	}
+
+	code, body := post("/epochs/19723/multiproof", MultiproofRequest{ReceiptIDs: []string{"m4", "m2"}})
+	if code != http.StatusOK {
+		t.Fatalf("multiproof: %d %s", code, body)
+	}
+	var resp MultiproofResponse
+	json.Unmarshal(body, &resp)
+	info, _ := epochManager.GetEpochInfo(19723)
+	if resp.Root != info.Root || fmt.Sprint(resp.ReceiptIDs) != "[m2 m4]" {
+		t.Fatalf("response: %s", body)
+	}
+	leaves := make([][]byte, len(resp.ReceiptIDs))
+	for i, id := range resp.ReceiptIDs {
+		receipt, _ := store.GetByID(id)
+		leaves[i], _ = jcs.Canonicalize(receipt.Receipt)
+	}
+	if !merkle.VerifyMultiproof(leaves, resp.Proof, resp.Root) {
+		t.Error("multiproof does not verify")
+	}
+
+	for _, tc := range []struct {
+		ids  []string
+		code int
+	}{
+		{[]string{"m1", "m1"}, http.StatusBadRequest},
+		{[]string{}, http.StatusBadRequest},
+		{[]string{"m1", "nope"}, http.StatusNotFound},
+	} {
+		if code, body := post("/epochs/19723/multiproof", MultiproofRequest{ReceiptIDs: tc.ids}); code != tc.code {
+			t.Errorf("%v: %d %s, want %d", tc.ids, code, body, tc.code)
+		}
+	}
+}
+
+func TestPayoutEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := storage.NewStore()
+	epochManager := epoch.NewManager()
+	handler := NewHandler(store, epochManager)
+	address := "0x00000000000000000000000000000000000000A1"
+	handler.sealer.SetAddresses(payout.Addresses{"pk-a": common.HexToAddress(address)})
+
+	router := gin.New()
+	router.GET("/epochs/:epoch/payouts", handler.Payouts)
+	router.GET("/epochs/:epoch/payouts/:address", handler.PayoutClaim)
+
+	get := func(path string) (int, []byte) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w.Code, w.Body.Bytes()
+	}
+
+	if code, body := get("/epochs/19723/payouts"); code != http.StatusBadRequest {
+		t.Errorf("payouts of an open epoch: %d %s", code, body)
+	}
+
+	for _, id := range []string{"p1", "p2"} {
+		store.Store(&storage.SignedReceipt{Receipt: storage.Receipt{ReceiptID: id, ProviderPK: "pk-a", Epoch: 19723, TokensOut: 5}, Signature: "sig"})
+	}
+	if _, err := handler.sealer.Seal(19723); err != nil {
+		t.Fatal(err)
+	}
+
+	code, body := get("/epochs/19723/payouts")
+	var payouts payout.Payouts
+	json.Unmarshal(body, &payouts)
+	if code != http.StatusOK || len(payouts.Leaves) != 1 || payouts.Leaves[0].Amount.Int64() != 1000 {
+		t.Fatalf("payouts: %d %s", code, body)
+	}
+
+	code, body = get("/epochs/19723/payouts/" + address)
+	var claim payout.Claim
+	json.Unmarshal(body, &claim)
+	if code != http.StatusOK || !payout.Verify(claim.Leaf, claim.Proof, payouts.Root) || len(claim.ReceiptIDs) != 2 {
+		t.Errorf("claim: %d %s", code, body)
+	}
+
+	if code, body := get("/epochs/19723/payouts/0x00000000000000000000000000000000000000b0"); code != http.StatusNotFound {
+		t.Errorf("claim for an address with no payout: %d %s", code, body)
+	}
+	if code, body := get("/epochs/19723/payouts/not-an-address"); code != http.StatusBadRequest {
+		t.Errorf("claim for an invalid address: %d %s", code, body)
+	}
+}
+
+func TestQueryEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := storage.NewStore()
+	epochManager := epoch.NewManager()
+	handler := NewHandler(store, epochManager)
+	setClock(handler.sealer)
+
+	router := gin.New()
+	router.GET("/receipts", handler.ListReceipts)
+	router.GET("/receipts/:id/proof", handler.ReceiptProof)
+	router.GET("/providers/:pk/summary", handler.ProviderSummary)
+	router.GET("/epochs/:epoch", handler.GetEpoch)
+
+	get := func(path string, v interface{}) int {
+		t.Helper()
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if v != nil && w.Code == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+				t.Fatalf("GET %s: %v", path, err)
+			}
+		}
+		return w.Code
+	}
+
+	// pkA's standard base64 has slashes, so its path form is URL-safe
+	keyA := bytes.Repeat([]byte{0xff}, 32)
+	pkA := base64.StdEncoding.EncodeToString(keyA)
+	pkB := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+	checkpoint := &storage.StreamInfo{Index: 0, Chunks: 1}
+	for _, r := range []storage.Receipt{
+		{ReceiptID: "a1", ProviderPK: pkA, Model: "m1", TokensIn: 1, TokensOut: 2, Epoch: 19723},
+		{ReceiptID: "a2", ProviderPK: pkA, Model: "m1", TokensIn: 3, TokensOut: 4, Epoch: 19723, Stream: checkpoint},
+		{ReceiptID: "a3", ProviderPK: pkA, Model: "m2", TokensIn: 5, TokensOut: 6, Epoch: 19723},
+		{ReceiptID: "a4", ProviderPK: pkA, Model: "m1", TokensIn: 7, TokensOut: 8, Epoch: 19724},
+		{ReceiptID: "b1", ProviderPK: pkB, Model: "m1", TokensIn: 9, TokensOut: 10, Epoch: 19723},
+	} {
+		if err := handler.sealer.Admit(&storage.SignedReceipt{Receipt: r, Signature: "sig"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	info, err := handler.sealer.Seal(19723)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Pages follow each other through their cursors
+	var ids []string
+	cursor := ""
+	for pages := 0; ; pages++ {
+		var page storage.Page
+		if code := get("/receipts?limit=2&provider="+url.QueryEscape(pkA)+"&cursor="+cursor, &page); code != http.StatusOK {
+			t.Fatalf("page %d: %d", pages, code)
+		}
+		for _, r := range page.Receipts {
+			ids = append(ids, r.Receipt.ReceiptID)
+		}
+		if page.Next == "" {
+			break
+		}
+		cursor = page.Next
+	}
+	if fmt.Sprint(ids) != "[a1 a2 a3 a4]" {
+		t.Errorf("provider receipts: %v", ids)
+	}
+
+	start, _, _ := epochManager.Schedule().Bounds(19724)
+	for query, want := range map[string]int{
+		"model=m2":                           1,
+		"epoch=19724":                        1,
+		"epoch=1":                            0,
+		"from=" + start.Format(time.RFC3339): 1,
+		"to=" + start.Format(time.RFC3339):   4,
+		"to=" + start.Add(time.Second).Format(time.RFC3339): 5,
+	} {
+		var page storage.Page
+		if code := get("/receipts?"+query, &page); code != http.StatusOK || len(page.Receipts) != want {
+			t.Errorf("GET /receipts?%s: %d, %d receipts, want %d", query, code, len(page.Receipts), want)
+		}
+	}
+
+	for path, want := range map[string]int{
+		"/receipts?cursor=bad": http.StatusBadRequest,
+		"/receipts?limit=0":    http.StatusBadRequest,
+		"/receipts?epoch=19723&from=" + start.Format(time.RFC3339): http.StatusBadRequest,
+		"/receipts?from=yesterday":                                 http.StatusBadRequest,
+		"/receipts?to=1970-01-01T00:00:00Z":                        http.StatusBadRequest,
+		"/receipts?provider=!":                                     http.StatusBadRequest,
+		"/receipts/a4/proof":                                       http.StatusBadRequest,
+		"/receipts/nope/proof":                                     http.StatusNotFound,
+		"/providers/!/summary":                                     http.StatusBadRequest,
+	} {
+		if code := get(path, nil); code != want {
+			t.Errorf("GET %s: got %d, want %d", path, code, want)
+		}
+	}
+
+	var proof ReceiptProofResponse
+	if code := get("/receipts/a3/proof", &proof); code != http.StatusOK {
+		t.Fatalf("proof: %d", code)
+	}
+	receipt, _ := store.GetByID("a3")
+	canonical, _ := jcs.Canonicalize(receipt.Receipt)
+	if proof.Root != info.Root || proof.State != epoch.StateFinalized || !merkle.Verify(canonical, proof.Proof, proof.Root) ||
+		proof.LeafHash != hex.EncodeToString(merkle.LeafHash(canonical)) {
+		t.Errorf("proof: %+v", proof)
+	}
+
+	var summary ProviderSummaryResponse
+	if code := get("/providers/"+base64.URLEncoding.EncodeToString(keyA)+"/summary", &summary); code != http.StatusOK {
+		t.Fatalf("summary: %d", code)
+	}
+	// a2 is a stream checkpoint and earns nothing
+	if summary.ProviderPK != pkA || summary.Receipts != 4 || summary.TokensIn != 16 || summary.TokensOut != 20 || summary.Earnings != "2900" {
+		t.Errorf("summary: %+v", summary)
+	}
+	if len(summary.Epochs) != 2 {
+		t.Fatalf("summary epochs: %+v", summary.Epochs)
+	}
+	if e := summary.Epochs[0]; e.Epoch != 19723 || e.State != epoch.StateFinalized || e.Receipts != 3 || e.Earnings != "1400" {
+		t.Errorf("epoch 19723: %+v", e)
+	}
+	if e := summary.Epochs[1]; e.Epoch != 19724 || e.State != epoch.StateOpen || e.Receipts != 1 || e.Earnings != "1500" {
+		t.Errorf("epoch 19724: %+v", e)
+	}
+
+	var open EpochResponse
+	if code := get("/epochs/19724", &open); code != http.StatusOK || open.State != epoch.StateOpen || open.StoredReceipts != 1 || open.Root != "" {
+		t.Errorf("open epoch: %d %+v", code, open)
+	}
+}
+
+// stakeBackend stakes testProviderKey for the models it lists.
+type stakeBackend struct {
+	models []string
+}
+
+func (b *stakeBackend) StakeOf(ctx context.Context, key [32]byte) (string, *big.Int, time.Time, error) {
+	if !bytes.Equal(key[:], testProviderKey.Public().(ed25519.PublicKey)) {
+		return "", new(big.Int), time.Time{}, nil
+	}
+	return "0x00000000000000000000000000000000000000aa", big.NewInt(1000), time.Time{}, nil
+}
+
+func (b *stakeBackend) ProviderModels(ctx context.Context, key [32]byte) ([]string, error) {
+	return b.models, nil
+}
+
+func (b *stakeBackend) StakeParams(ctx context.Context) (*big.Int, time.Duration, error) {
+	return big.NewInt(1000), 24 * time.Hour, nil
+}
+
+func TestStakedProviders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewHandler(storage.NewStore(), epoch.NewManager())
+	setClock(handler.sealer)
+	router := gin.New()
+	router.POST("/receipts", handler.Ingest)
+	router.GET("/providers/:pk", handler.ProviderRegistration)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/providers/x", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("registration without a registry: %d", w.Code)
+	}
+
+	providers := registry.New(&stakeBackend{models: []string{"llama3.2:3b"}})
+	handler.SetRegistry(providers)
+	handler.SetVerifier(verify.NewVerifier(providers))
+
+	stranger := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{8}, ed25519.SeedSize))
+	unstaked := &storage.SignedReceipt{Receipt: storage.Receipt{
+		ReceiptID:  "s3",
+		Epoch:      19723,
+		Model:      "llama3.2:3b",
+		ProviderPK: base64.StdEncoding.EncodeToString(stranger.Public().(ed25519.PublicKey)),
+	}}
+	body, _ := json.Marshal(IngestRequest{Receipts: []*storage.SignedReceipt{
+		signReceipt(t, storage.Receipt{ReceiptID: "s1", Epoch: 19723, Seq: 1, Model: "llama3.2:3b"}),
+		signReceipt(t, storage.Receipt{ReceiptID: "s2", Epoch: 19723, Seq: 2, Model: "mistral:7b"}),
+		unstaked,
+	}})
+	request := httptest.NewRequest("POST", "/receipts", bytes.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
 	router.ServeHTTP(w, request)
 
-	if w.Code != http.StatusOK {
-		t.Errorf("Expected status 200, got %d", w.Code)
+	var resp IngestResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Accepted != 1 || len(resp.Rejected) != 2 {
+		t.Fatalf("ingest: %d %s", w.Code, w.Body)
+	}
+	for _, rejected := range resp.Rejected {
+		if rejected.ReceiptID == "s1" {
+			t.Errorf("staked provider's receipt for a declared model rejected: %+v", rejected)
+		}
 	}
 
-	var resp ClaimResponse
+	pk := base64.URLEncoding.EncodeToString(testProviderKey.Public().(ed25519.PublicKey))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/providers/"+pk, nil))
+	var provider registry.Provider
+	json.Unmarshal(w.Body.Bytes(), &provider)
+	if w.Code != http.StatusOK || !provider.Registered || provider.Stake != "1000" || len(provider.Models) != 1 {
+		t.Errorf("registration: %d %s", w.Code, w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/providers/AAAA", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("registration of a short key: %d", w.Code)
+	}
+}
+
+func TestEvidenceEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	data, err := os.ReadFile("../../../testvectors/canary.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors struct {
+		Gateway struct {
+			PublicKey string `json:"public_key"`
+		} `json:"gateway"`
+		Cases []struct {
+			Signed json.RawMessage `json:"signed"`
+		} `json:"cases"`
+	}
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+
+	store := storage.NewStore()
+	epochManager := epoch.NewManager()
+	handler := NewHandler(store, epochManager)
+	s := sealer.New(store, epochManager)
+	setClock(s)
+	handler.SetSealer(s)
+
+	router := gin.New()
+	router.POST("/evidence", handler.SubmitEvidence)
+	router.GET("/evidence", handler.ListEvidence)
+
+	submit := func(body []byte) *httptest.ResponseRecorder {
+		request := httptest.NewRequest("POST", "/evidence", bytes.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, request)
+		return w
+	}
+	failed := vectors.Cases[0].Signed
+
+	if w := submit(failed); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("without a ledger: %d", w.Code)
+	}
+
+	ledger, err := slashing.New(store, verify.NewAllowlist([]string{vectors.Gateway.PublicKey}), s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.SetLedger(ledger)
+	s.SetPrice(ledger.Price(s.Price))
+
+	for _, tc := range []struct {
+		name string
+		body []byte
+		code int
+	}{
+		{"failed canary", failed, http.StatusCreated},
+		{"same evidence again", failed, http.StatusOK},
+		{"passing completion", vectors.Cases[1].Signed, http.StatusBadRequest},
+		{"malformed", []byte(`{`), http.StatusBadRequest},
+	} {
+		if w := submit(tc.body); w.Code != tc.code {
+			t.Errorf("%s: %d %s", tc.name, w.Code, w.Body)
+		}
+	}
+
+	untrusted, _ := slashing.New(storage.NewStore(), verify.NewAllowlist(nil), s)
+	handler.SetLedger(untrusted)
+	if w := submit(failed); w.Code != http.StatusForbidden {
+		t.Errorf("untrusted gateway: %d %s", w.Code, w.Body)
+	}
+	handler.SetLedger(ledger)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/evidence?epoch=19723", nil))
+	var resp EvidenceResponse
 	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusOK || len(resp.Evidence) != 1 || !resp.Evidence[0].Forfeit {
+		t.Fatalf("list: %d %s", w.Code, w.Body)
+	}
+	provider := resp.Evidence[0].ProviderPK
 
-	if resp.Amount == "0" {
-		t.Error("Expected non-zero amount")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/evidence?epoch=19724", nil))
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusOK || len(resp.Evidence) != 0 {
+		t.Errorf("list for another epoch: %d %s", w.Code, w.Body)
 	}
 
-	if resp.TxHash == "" {
-		t.Error("Expected non-empty tx hash")
+	if price := s.Price(&storage.Receipt{ProviderPK: provider, Epoch: 19723, TokensOut: 100}); price.Sign() != 0 {
+		t.Errorf("forfeited receipt priced at %v", price)
 	}
 }
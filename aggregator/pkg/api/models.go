@@ -1,30 +1,140 @@
 package api
 
-import "github.com/quiver/aggregator/pkg/storage"
+import (
+	"github.com/quiver/aggregator/pkg/audit"
+	"github.com/quiver/aggregator/pkg/epoch"
+	"github.com/quiver/aggregator/pkg/merkle"
+	"github.com/quiver/aggregator/pkg/slashing"
+	"github.com/quiver/aggregator/pkg/storage"
+)
 
 type CommitRequest struct {
 	Receipts []*storage.SignedReceipt `json:"receipts" binding:"required"`
 	Epoch    uint64                   `json:"epoch" binding:"required"`
+	// Amend replaces the root of an already finalized epoch. The old root
+	// is kept in the epoch's amendment history along with Reason.
+	Amend  bool   `json:"amend,omitempty"`
+	Reason string `json:"reason,omitempty"`
 }
 
 type CommitResponse struct {
-	MerkleRoot   string `json:"merkle_root"`
-	Epoch        uint64 `json:"epoch"`
-	ReceiptCount int    `json:"receipt_count"`
+	MerkleRoot    string          `json:"merkle_root"`
+	Epoch         uint64          `json:"epoch"`
+	ReceiptCount  int             `json:"receipt_count"`
+	Rejected      []ReceiptError  `json:"rejected,omitempty"`
+	ChainFindings []audit.Finding `json:"chain_findings,omitempty"`
+	State         epoch.State     `json:"state,omitempty"`
+	Version       int             `json:"version,omitempty"`
+}
+
+type IngestRequest struct {
+	Receipts []*storage.SignedReceipt `json:"receipts" binding:"required"`
+}
+
+type IngestResponse struct {
+	Accepted      int             `json:"accepted"`
+	Rejected      []ReceiptError  `json:"rejected,omitempty"`
+	ChainFindings []audit.Finding `json:"chain_findings,omitempty"`
+}
+
+// ReceiptError explains why one receipt in a commit was not accepted.
+type ReceiptError struct {
+	Index       int    `json:"index"`
+	ReceiptID   string `json:"receipt_id"`
+	Error       string `json:"error"`
+	Quarantined bool   `json:"quarantined"`
+}
+
+type QuarantineResponse struct {
+	Receipts []*storage.QuarantinedReceipt `json:"receipts"`
 }
 
 type ClaimRequest struct {
-	ReceiptID   string   `json:"receipt_id" binding:"required"`
-	MerkleProof []string `json:"merkle_proof" binding:"required"`
-	Epoch       uint64   `json:"epoch" binding:"required"`
+	ReceiptID   string        `json:"receipt_id" binding:"required"`
+	MerkleProof *merkle.Proof `json:"merkle_proof" binding:"required"`
+	Epoch       uint64        `json:"epoch" binding:"required"`
 }
 
 type ClaimResponse struct {
 	Valid  bool   `json:"valid"`
 	Amount string `json:"amount"`
-	TxHash string `json:"tx_hash"`
+	// TxHash and Status, one of pending, confirmed or failed, describe the
+	// payment of a valid claim. Error says why a failed payment failed.
+	TxHash string `json:"tx_hash,omitempty"`
+	Status string `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type MultiproofRequest struct {
+	ReceiptIDs []string `json:"receipt_ids" binding:"required"`
+}
+
+// MultiproofResponse proves the receipts it lists, in proof.leaf_indices
+// order, against an epoch's current root.
+type MultiproofResponse struct {
+	Epoch      uint64             `json:"epoch"`
+	Root       string             `json:"root"`
+	ReceiptIDs []string           `json:"receipt_ids"`
+	Proof      *merkle.Multiproof `json:"proof"`
+}
+
+type AuditFindingsResponse struct {
+	Findings []audit.Finding `json:"findings"`
+}
+
+type AuditChainsResponse struct {
+	Chains []audit.ChainStatus `json:"chains"`
+}
+
+type EpochsResponse struct {
+	Epochs []*epoch.Info `json:"epochs"`
 }
 
 type ErrorResponse struct {
 	Error string `json:"error"`
 }
+
+// EpochResponse is an epoch with the number of receipts stored for it so
+// far. An open epoch has stored receipts but no root or receipt_count
+// until it is sealed.
+type EpochResponse struct {
+	*epoch.Info
+	StoredReceipts int `json:"stored_receipts"`
+}
+
+// ReceiptProofResponse proves one receipt against its epoch's current
+// root. LeafHash is the leaf the settlement contract checks the proof for.
+type ReceiptProofResponse struct {
+	ReceiptID string        `json:"receipt_id"`
+	Epoch     uint64        `json:"epoch"`
+	Root      string        `json:"root"`
+	State     epoch.State   `json:"state"`
+	AnchorTx  string        `json:"anchor_tx,omitempty"`
+	LeafHash  string        `json:"leaf_hash"`
+	Proof     *merkle.Proof `json:"proof"`
+}
+
+type ProviderSummaryResponse struct {
+	ProviderPK string          `json:"provider_pk"`
+	Receipts   int             `json:"receipts"`
+	TokensIn   int64           `json:"tokens_in"`
+	TokensOut  int64           `json:"tokens_out"`
+	Earnings   string          `json:"earnings"`
+	Epochs     []*EpochSummary `json:"epochs"`
+}
+
+// EpochSummary totals one provider's receipts in one epoch. Earnings is a
+// decimal string and leaves out stream checkpoints, which are not paid.
+type EpochSummary struct {
+	Epoch     uint64      `json:"epoch"`
+	State     epoch.State `json:"state"`
+	Receipts  int         `json:"receipts"`
+	TokensIn  int64       `json:"tokens_in"`
+	TokensOut int64       `json:"tokens_out"`
+	Earnings  string      `json:"earnings"`
+}
+
+// EvidenceResponse lists recorded canary evidence, oldest first.
+type EvidenceResponse struct {
+	Evidence []*slashing.Record `json:"evidence"`
+}
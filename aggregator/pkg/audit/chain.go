@@ -0,0 +1,559 @@
+// Package audit verifies each provider's receipt hash chain as receipts are
+// ingested. Providers number receipts with a per-key Seq and link each one to
+// its predecessor through PrevHash; the auditor reports where a provider's
+// submissions break those rules.
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/quiver/aggregator/pkg/jcs"
+	"github.com/quiver/aggregator/pkg/storage"
+)
+
+// Kind classifies a finding.
+type Kind string
+
+const (
+	// KindGap means sequence numbers are missing between two receipts.
+	KindGap Kind = "gap"
+	// KindFork means two different receipts claim the same chain position,
+	// either the same Seq or the same PrevHash, or a receipt does not link
+	// to the receipt before it.
+	KindFork Kind = "fork"
+	// KindReplay means a different receipt was submitted under a ReceiptID
+	// already seen. The same receipt sent again is not a finding.
+	KindReplay Kind = "replay"
+	// KindTimeRegression means a later receipt finished before an earlier one.
+	KindTimeRegression Kind = "time_regression"
+)
+
+// Finding is one chain violation.
+type Finding struct {
+	ID         int64     `json:"id"`
+	Kind       Kind      `json:"kind"`
+	ProviderPK string    `json:"provider_pk"`
+	Seq        int64     `json:"seq"`
+	ReceiptID  string    `json:"receipt_id"`
+	Detail     string    `json:"detail"`
+	DetectedAt time.Time `json:"detected_at"`
+	// For gaps: the missing range, inclusive. Resolved is set once late
+	// receipts fill the whole range.
+	GapFrom  int64 `json:"gap_from,omitempty"`
+	GapTo    int64 `json:"gap_to,omitempty"`
+	Resolved bool  `json:"resolved"`
+}
+
+// Filter selects findings. Zero values match everything.
+type Filter struct {
+	ProviderPK string
+	Kind       Kind
+	Unresolved bool
+	Limit      int
+}
+
+// ChainStatus summarizes what the auditor knows about one provider's chain.
+type ChainStatus struct {
+	ProviderPK string `json:"provider_pk"`
+	Receipts   int    `json:"receipts"`
+	MinSeq     int64  `json:"min_seq"`
+	MaxSeq     int64  `json:"max_seq"`
+	Missing    int64  `json:"missing"`
+	Findings   int    `json:"findings"`
+}
+
+// Config tunes the auditor.
+type Config struct {
+	// ClockSkew is how far a later receipt's end time may precede an
+	// earlier one before it is flagged. Receipt timestamps have one second
+	// resolution and concurrent requests finish in arbitrary order.
+	ClockSkew time.Duration
+}
+
+// DefaultConfig returns the auditor defaults.
+func DefaultConfig() Config {
+	return Config{
+		ClockSkew: 2 * time.Second,
+	}
+}
+
+type link struct {
+	receiptID string
+	hash      string
+	prevHash  string
+	end       time.Time
+}
+
+// span is an inclusive range of sequence numbers.
+type span struct {
+	from, to int64
+}
+
+type chain struct {
+	bySeq  map[int64]link
+	byPrev map[string]string // PrevHash -> ReceiptID
+	minSeq int64
+	maxSeq int64
+	// missing holds the sequence numbers between minSeq and maxSeq no
+	// receipt has claimed, in ascending order.
+	missing  []span
+	findings int
+	gaps     []*Finding
+}
+
+// position is where an observed receipt sits, kept by epoch for Forget.
+type position struct {
+	providerPK string
+	seq        int64
+	receiptID  string
+}
+
+// Store persists findings and holds the receipts chains are rebuilt from.
+// storage.Store implements it.
+type Store interface {
+	SaveFinding(id int64, record []byte) error
+	LoadFindings() (map[int64][]byte, error)
+	Epochs() ([]uint64, error)
+	GetByEpoch(epoch uint64) ([]*storage.SignedReceipt, error)
+}
+
+// Auditor tracks every provider's chain. It is safe for concurrent use.
+type Auditor struct {
+	config   Config
+	store    Store
+	chains   map[string]*chain
+	seenIDs  map[string]string // ReceiptID -> ChainHash
+	byEpoch  map[uint64][]position
+	findings []*Finding
+	nextID   int64
+	mu       sync.RWMutex
+}
+
+// NewAuditor creates an auditor with the given configuration. It keeps
+// findings in memory only.
+func NewAuditor(config Config) *Auditor {
+	return &Auditor{
+		config:  config,
+		chains:  make(map[string]*chain),
+		seenIDs: make(map[string]string),
+		byEpoch: make(map[uint64][]position),
+	}
+}
+
+// NewAuditorWithStore creates an auditor that saves its findings to store.
+// It loads the findings already saved and rebuilds every chain from the
+// stored receipts, forgetting those of epochs before keep as it goes; see
+// Forget.
+func NewAuditorWithStore(config Config, store Store, keep uint64) (*Auditor, error) {
+	a := NewAuditor(config)
+
+	epochs, err := store.Epochs()
+	if err != nil {
+		return nil, err
+	}
+	for _, epoch := range epochs {
+		receipts, err := store.GetByEpoch(epoch)
+		if err != nil {
+			return nil, err
+		}
+		for _, signed := range receipts {
+			r := &signed.Receipt
+			hash, err := ChainHash(r)
+			if err != nil {
+				return nil, fmt.Errorf("receipt %s: %w", r.ReceiptID, err)
+			}
+			end, _ := time.Parse(time.RFC3339, r.EndISO)
+			a.observe(r, hash, end, false)
+		}
+		a.forget(keep)
+	}
+
+	stored, err := store.LoadFindings()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, 0, len(stored))
+	for id := range stored {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		f := &Finding{}
+		if err := json.Unmarshal(stored[id], f); err != nil {
+			return nil, fmt.Errorf("finding %d: %w", id, err)
+		}
+		a.findings = append(a.findings, f)
+		if f.ID > a.nextID {
+			a.nextID = f.ID
+		}
+		c, ok := a.chains[f.ProviderPK]
+		if !ok || f.Kind == KindReplay {
+			continue
+		}
+		c.findings++
+		if f.Kind == KindGap && !f.Resolved {
+			c.gaps = append(c.gaps, f)
+		}
+	}
+
+	// Receipts stored after a gap's last save may have filled it
+	a.store = store
+	for _, c := range a.chains {
+		for _, f := range c.resolveGaps() {
+			if err := a.save(f); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return a, nil
+}
+
+// ChainHash is the value the next receipt in a chain carries as PrevHash:
+// the hex SHA-256 of the receipt's canonical JSON.
+func ChainHash(r *storage.Receipt) (string, error) {
+	canonical, err := jcs.Canonicalize(r)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(canonical)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// Observe checks a receipt against the chain seen so far and records it.
+// It returns the findings the receipt triggered. A receipt already observed
+// as given is ignored, so retried submissions are not replays. An error
+// saving findings is returned along with them.
+func (a *Auditor) Observe(signed *storage.SignedReceipt) ([]Finding, error) {
+	r := &signed.Receipt
+
+	hash, err := ChainHash(r)
+	if err != nil {
+		return nil, err
+	}
+	end, _ := time.Parse(time.RFC3339, r.EndISO)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	found, resolved := a.observe(r, hash, end, true)
+
+	var result []Finding
+	for _, f := range found {
+		result = append(result, *f)
+	}
+	for _, f := range append(found, resolved...) {
+		if saveErr := a.save(f); saveErr != nil && err == nil {
+			err = saveErr
+		}
+	}
+	return result, err
+}
+
+// observe adds a receipt to its chain. It records findings only if live;
+// rebuilding from the store, the findings were saved the first time.
+func (a *Auditor) observe(r *storage.Receipt, hash string, end time.Time, live bool) (found, resolved []*Finding) {
+	if seen, dup := a.seenIDs[r.ReceiptID]; dup {
+		if seen != hash && live {
+			found = append(found, a.record(&Finding{
+				Kind:       KindReplay,
+				ProviderPK: r.ProviderPK,
+				Seq:        r.Seq,
+				ReceiptID:  r.ReceiptID,
+				Detail:     fmt.Sprintf("receipt %s already ingested with different contents", r.ReceiptID),
+			}))
+		}
+		return found, nil
+	}
+	a.seenIDs[r.ReceiptID] = hash
+	epoch := uint64(r.Epoch)
+	a.byEpoch[epoch] = append(a.byEpoch[epoch], position{providerPK: r.ProviderPK, seq: r.Seq, receiptID: r.ReceiptID})
+
+	c, exists := a.chains[r.ProviderPK]
+	if !exists {
+		c = &chain{
+			bySeq:  make(map[int64]link),
+			byPrev: make(map[string]string),
+			minSeq: r.Seq,
+			maxSeq: r.Seq,
+		}
+		a.chains[r.ProviderPK] = c
+	}
+
+	report := func(f *Finding) *Finding {
+		if !live {
+			return nil
+		}
+		f.ProviderPK = r.ProviderPK
+		f.ReceiptID = r.ReceiptID
+		if f.Seq == 0 {
+			f.Seq = r.Seq
+		}
+		c.findings++
+		found = append(found, a.record(f))
+		return f
+	}
+
+	if existing, ok := c.bySeq[r.Seq]; ok {
+		report(&Finding{Kind: KindFork, Detail: fmt.Sprintf("seq %d already used by receipt %s", r.Seq, existing.receiptID)})
+		return found, nil
+	}
+	if exists && r.Seq >= c.minSeq && r.Seq <= c.maxSeq && !c.isMissing(r.Seq) {
+		// The receipt holding this position was forgotten with its epoch
+		report(&Finding{Kind: KindFork, Detail: fmt.Sprintf("seq %d already used in an earlier epoch", r.Seq)})
+		return found, nil
+	}
+	if other, ok := c.byPrev[r.PrevHash]; ok {
+		report(&Finding{Kind: KindFork, Detail: fmt.Sprintf("prev_hash shared with receipt %s", other)})
+	} else {
+		c.byPrev[r.PrevHash] = r.ReceiptID
+	}
+
+	// Link and time ordering against both neighbours, since receipts can
+	// arrive out of order
+	if prev, ok := c.bySeq[r.Seq-1]; ok {
+		if r.PrevHash != prev.hash {
+			report(&Finding{Kind: KindFork, Detail: fmt.Sprintf("prev_hash does not match seq %d", r.Seq-1)})
+		}
+		if a.regressed(prev.end, end) {
+			report(&Finding{Kind: KindTimeRegression, Detail: fmt.Sprintf("ends before seq %d", r.Seq-1)})
+		}
+	}
+	if next, ok := c.bySeq[r.Seq+1]; ok {
+		if a.regressed(end, next.end) {
+			report(&Finding{Kind: KindTimeRegression, Seq: r.Seq + 1, Detail: fmt.Sprintf("ends before seq %d", r.Seq)})
+		}
+	}
+
+	c.bySeq[r.Seq] = link{receiptID: r.ReceiptID, hash: hash, prevHash: r.PrevHash, end: end}
+
+	switch {
+	case r.Seq > c.maxSeq+1:
+		c.missing = append(c.missing, span{c.maxSeq + 1, r.Seq - 1})
+		gap := report(&Finding{
+			Kind:    KindGap,
+			Detail:  missingDetail(c.maxSeq+1, r.Seq-1),
+			GapFrom: c.maxSeq + 1,
+			GapTo:   r.Seq - 1,
+		})
+		if gap != nil {
+			c.gaps = append(c.gaps, gap)
+		}
+		c.maxSeq = r.Seq
+	case r.Seq > c.maxSeq:
+		c.maxSeq = r.Seq
+	case r.Seq < c.minSeq-1:
+		c.missing = append([]span{{r.Seq + 1, c.minSeq - 1}}, c.missing...)
+		gap := report(&Finding{
+			Kind:    KindGap,
+			Detail:  missingDetail(r.Seq+1, c.minSeq-1),
+			GapFrom: r.Seq + 1,
+			GapTo:   c.minSeq - 1,
+		})
+		if gap != nil {
+			c.gaps = append(c.gaps, gap)
+		}
+		c.minSeq = r.Seq
+	case r.Seq < c.minSeq:
+		c.minSeq = r.Seq
+	default:
+		c.fill(r.Seq)
+		resolved = c.resolveGaps()
+	}
+
+	return found, resolved
+}
+
+// Forget drops what the auditor holds for receipts of epochs before n, so
+// its memory does not grow with every receipt ever ingested. Gaps are still
+// tracked and a later receipt reusing a forgotten receipt's Seq is still a
+// fork, but nothing is checked against the forgotten receipt's link, end
+// time or ID.
+func (a *Auditor) Forget(n uint64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.forget(n)
+}
+
+func (a *Auditor) forget(n uint64) {
+	for epoch, positions := range a.byEpoch {
+		if epoch >= n {
+			continue
+		}
+		for _, p := range positions {
+			delete(a.seenIDs, p.receiptID)
+			c := a.chains[p.providerPK]
+			if l, ok := c.bySeq[p.seq]; ok && l.receiptID == p.receiptID {
+				delete(c.bySeq, p.seq)
+				if c.byPrev[l.prevHash] == p.receiptID {
+					delete(c.byPrev, l.prevHash)
+				}
+			}
+		}
+		delete(a.byEpoch, epoch)
+	}
+}
+
+func missingDetail(from, to int64) string {
+	if from == to {
+		return fmt.Sprintf("missing seq %d", from)
+	}
+	return fmt.Sprintf("missing seq %d-%d", from, to)
+}
+
+func (a *Auditor) regressed(earlier, later time.Time) bool {
+	if earlier.IsZero() || later.IsZero() {
+		return false
+	}
+	return earlier.Sub(later) > a.config.ClockSkew
+}
+
+func (c *chain) isMissing(seq int64) bool {
+	for _, s := range c.missing {
+		if seq >= s.from && seq <= s.to {
+			return true
+		}
+	}
+	return false
+}
+
+// fill removes seq from the missing ranges.
+func (c *chain) fill(seq int64) {
+	for i, s := range c.missing {
+		if seq < s.from || seq > s.to {
+			continue
+		}
+		switch {
+		case s.from == s.to:
+			c.missing = append(c.missing[:i], c.missing[i+1:]...)
+		case seq == s.from:
+			c.missing[i].from++
+		case seq == s.to:
+			c.missing[i].to--
+		default:
+			c.missing = append(c.missing[:i+1], c.missing[i:]...)
+			c.missing[i].to = seq - 1
+			c.missing[i+1].from = seq + 1
+		}
+		return
+	}
+}
+
+func (c *chain) missingCount() int64 {
+	var total int64
+	for _, s := range c.missing {
+		total += s.to - s.from + 1
+	}
+	return total
+}
+
+// resolveGaps marks the gaps no longer missing any receipt resolved and
+// returns them.
+func (c *chain) resolveGaps() []*Finding {
+	var resolved []*Finding
+	open := c.gaps[:0]
+	for _, gap := range c.gaps {
+		filled := true
+		for _, s := range c.missing {
+			if s.from <= gap.GapTo && gap.GapFrom <= s.to {
+				filled = false
+				break
+			}
+		}
+		if filled {
+			gap.Resolved = true
+			resolved = append(resolved, gap)
+		} else {
+			open = append(open, gap)
+		}
+	}
+	c.gaps = open
+	return resolved
+}
+
+func (a *Auditor) record(f *Finding) *Finding {
+	a.nextID++
+	f.ID = a.nextID
+	f.DetectedAt = time.Now().UTC()
+	a.findings = append(a.findings, f)
+	return f
+}
+
+func (a *Auditor) save(f *Finding) error {
+	if a.store == nil {
+		return nil
+	}
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	if err := a.store.SaveFinding(f.ID, data); err != nil {
+		return fmt.Errorf("save finding %d: %w", f.ID, err)
+	}
+	return nil
+}
+
+// Findings returns findings matching filter, newest first.
+func (a *Auditor) Findings(filter Filter) []Finding {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	result := make([]Finding, 0)
+	for i := len(a.findings) - 1; i >= 0; i-- {
+		f := a.findings[i]
+		if filter.ProviderPK != "" && f.ProviderPK != filter.ProviderPK {
+			continue
+		}
+		if filter.Kind != "" && f.Kind != filter.Kind {
+			continue
+		}
+		if filter.Unresolved && f.Resolved {
+			continue
+		}
+		result = append(result, *f)
+		if filter.Limit > 0 && len(result) >= filter.Limit {
+			break
+		}
+	}
+	return result
+}
+
+// Chains returns the status of every tracked chain, ordered by provider key.
+func (a *Auditor) Chains() []ChainStatus {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	result := make([]ChainStatus, 0, len(a.chains))
+	for pk, c := range a.chains {
+		missing := c.missingCount()
+		result = append(result, ChainStatus{
+			ProviderPK: pk,
+			Receipts:   int(c.maxSeq - c.minSeq + 1 - missing),
+			MinSeq:     c.minSeq,
+			MaxSeq:     c.maxSeq,
+			Missing:    missing,
+			Findings:   c.findings,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ProviderPK < result[j].ProviderPK
+	})
+	return result
+}
+
+// MissingTotal returns the number of sequence numbers missing across all
+// chains.
+func (a *Auditor) MissingTotal() int64 {
+	var total int64
+	for _, status := range a.Chains() {
+		total += status.Missing
+	}
+	return total
+}
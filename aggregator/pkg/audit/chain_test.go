@@ -0,0 +1,278 @@
+package audit
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quiver/aggregator/pkg/storage"
+)
+
+// buildChain returns n correctly linked receipts for provider, seq 1..n.
+func buildChain(t *testing.T, provider string, n int) []*storage.SignedReceipt {
+	t.Helper()
+	return buildEpochChain(t, provider, make([]int64, n)...)
+}
+
+// buildEpochChain returns correctly linked receipts for provider, seq i in
+// epochs[i-1].
+func buildEpochChain(t *testing.T, provider string, epochs ...int64) []*storage.SignedReceipt {
+	t.Helper()
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	receipts := make([]*storage.SignedReceipt, 0, len(epochs))
+	prevHash := ""
+	for i := 1; i <= len(epochs); i++ {
+		r := &storage.SignedReceipt{
+			Receipt: storage.Receipt{
+				Version:    "2.0.0",
+				Epoch:      epochs[i-1],
+				ProviderPK: provider,
+				ReceiptID:  fmt.Sprintf("%s-%d", provider, i),
+				EndISO:     base.Add(time.Duration(i) * time.Minute).Format(time.RFC3339),
+				Seq:        int64(i),
+				PrevHash:   prevHash,
+			},
+		}
+		hash, err := ChainHash(&r.Receipt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		prevHash = hash
+		receipts = append(receipts, r)
+	}
+	return receipts
+}
+
+func observeAll(t *testing.T, a *Auditor, receipts ...*storage.SignedReceipt) []Finding {
+	t.Helper()
+
+	var all []Finding
+	for _, r := range receipts {
+		found, err := a.Observe(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		all = append(all, found...)
+	}
+	return all
+}
+
+func TestCleanChain(t *testing.T) {
+	a := NewAuditor(DefaultConfig())
+
+	if found := observeAll(t, a, buildChain(t, "pk1", 10)...); len(found) != 0 {
+		t.Fatalf("expected no findings, got %+v", found)
+	}
+
+	chains := a.Chains()
+	if len(chains) != 1 || chains[0].Receipts != 10 || chains[0].Missing != 0 {
+		t.Errorf("unexpected chain status %+v", chains)
+	}
+}
+
+func TestGapDetectedAndResolved(t *testing.T) {
+	a := NewAuditor(DefaultConfig())
+	chain := buildChain(t, "pk1", 6)
+
+	found := observeAll(t, a, chain[0], chain[1], chain[5])
+	if len(found) != 1 || found[0].Kind != KindGap || found[0].GapFrom != 3 || found[0].GapTo != 5 {
+		t.Fatalf("expected gap 3-5, got %+v", found)
+	}
+	if a.MissingTotal() != 3 {
+		t.Errorf("expected 3 missing, got %d", a.MissingTotal())
+	}
+
+	// Late delivery fills the gap
+	if found := observeAll(t, a, chain[2], chain[3], chain[4]); len(found) != 0 {
+		t.Fatalf("expected no findings for late receipts, got %+v", found)
+	}
+	if open := a.Findings(Filter{Unresolved: true}); len(open) != 0 {
+		t.Errorf("gap should be resolved, still open: %+v", open)
+	}
+	if a.MissingTotal() != 0 {
+		t.Errorf("expected 0 missing, got %d", a.MissingTotal())
+	}
+}
+
+func TestForkSharedPrevHash(t *testing.T) {
+	a := NewAuditor(DefaultConfig())
+	chain := buildChain(t, "pk1", 3)
+	observeAll(t, a, chain...)
+
+	// A second receipt built on seq 2, claiming seq 4
+	fork := *chain[2]
+	fork.Receipt.ReceiptID = "pk1-fork"
+	fork.Receipt.Seq = 4
+
+	found := observeAll(t, a, &fork)
+	if len(found) == 0 || found[0].Kind != KindFork {
+		t.Fatalf("expected fork, got %+v", found)
+	}
+}
+
+func TestForkDuplicateSeq(t *testing.T) {
+	a := NewAuditor(DefaultConfig())
+	chain := buildChain(t, "pk1", 2)
+	observeAll(t, a, chain...)
+
+	dup := *chain[1]
+	dup.Receipt.ReceiptID = "pk1-other"
+	dup.Receipt.PrevHash = "something-else"
+
+	found := observeAll(t, a, &dup)
+	if len(found) != 1 || found[0].Kind != KindFork {
+		t.Fatalf("expected fork for reused seq, got %+v", found)
+	}
+}
+
+func TestBrokenLink(t *testing.T) {
+	a := NewAuditor(DefaultConfig())
+	chain := buildChain(t, "pk1", 3)
+	chain[2].Receipt.PrevHash = "not-the-hash-of-seq-2"
+
+	found := observeAll(t, a, chain...)
+	if len(found) != 1 || found[0].Kind != KindFork || found[0].Seq != 3 {
+		t.Fatalf("expected broken link at seq 3, got %+v", found)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	a := NewAuditor(DefaultConfig())
+	chain := buildChain(t, "pk1", 2)
+	observeAll(t, a, chain...)
+
+	// The same receipt again, as a retried submission sends it, is fine
+	if found := observeAll(t, a, chain[1]); len(found) != 0 {
+		t.Fatalf("expected no findings for a resubmission, got %+v", found)
+	}
+
+	found := observeAll(t, a, replayOf(chain[1]))
+	if len(found) != 1 || found[0].Kind != KindReplay {
+		t.Fatalf("expected replay, got %+v", found)
+	}
+}
+
+// replayOf returns a receipt with r's ID and different contents.
+func replayOf(r *storage.SignedReceipt) *storage.SignedReceipt {
+	replay := *r
+	replay.Receipt.TokensOut++
+	return &replay
+}
+
+func TestTimeRegression(t *testing.T) {
+	a := NewAuditor(DefaultConfig())
+	chain := buildChain(t, "pk1", 3)
+
+	// seq 3 ends an hour before seq 2; relink so only the time is wrong
+	early := time.Date(2023, 12, 31, 23, 0, 0, 0, time.UTC)
+	chain[2].Receipt.EndISO = early.Format(time.RFC3339)
+
+	found := observeAll(t, a, chain...)
+	if len(found) != 1 || found[0].Kind != KindTimeRegression {
+		t.Fatalf("expected time regression, got %+v", found)
+	}
+}
+
+func TestFindingsFilter(t *testing.T) {
+	a := NewAuditor(DefaultConfig())
+	c1 := buildChain(t, "pk1", 3)
+	c2 := buildChain(t, "pk2", 3)
+	observeAll(t, a, c1[0], c1[2], c2[0], replayOf(c2[0]))
+
+	if got := a.Findings(Filter{ProviderPK: "pk1"}); len(got) != 1 || got[0].Kind != KindGap {
+		t.Errorf("expected one gap for pk1, got %+v", got)
+	}
+	if got := a.Findings(Filter{Kind: KindReplay}); len(got) != 1 || got[0].ProviderPK != "pk2" {
+		t.Errorf("expected one replay for pk2, got %+v", got)
+	}
+	if got := a.Findings(Filter{Limit: 1}); len(got) != 1 || got[0].Kind != KindReplay {
+		t.Errorf("expected newest finding first, got %+v", got)
+	}
+}
+
+func TestForget(t *testing.T) {
+	a := NewAuditor(DefaultConfig())
+	chain := buildEpochChain(t, "pk1", 1, 1, 2, 2, 1)
+	observeAll(t, a, chain[0], chain[1], chain[4])
+	a.Forget(2)
+
+	// The gap is still open and late receipts still fill it
+	if a.MissingTotal() != 2 {
+		t.Errorf("expected 2 missing after forgetting, got %d", a.MissingTotal())
+	}
+	if found := observeAll(t, a, chain[2], chain[3]); len(found) != 0 {
+		t.Fatalf("expected no findings for late receipts, got %+v", found)
+	}
+	if got := a.Findings(Filter{Unresolved: true}); len(got) != 0 {
+		t.Errorf("expected the gap resolved, got %+v", got)
+	}
+
+	// A forgotten receipt's position still cannot be taken again
+	dup := *chain[1]
+	dup.Receipt.ReceiptID = "pk1-other"
+	dup.Receipt.Epoch = 2
+	found := observeAll(t, a, &dup)
+	if len(found) != 1 || found[0].Kind != KindFork {
+		t.Fatalf("expected fork for a forgotten seq, got %+v", found)
+	}
+	if chains := a.Chains(); chains[0].Receipts != 5 || chains[0].Missing != 0 {
+		t.Errorf("unexpected chain status %+v", chains)
+	}
+}
+
+func TestAuditorStore(t *testing.T) {
+	store := storage.NewStore()
+	chain := buildEpochChain(t, "pk1", 1, 1, 2, 2, 2, 1)
+	a, err := NewAuditorWithStore(DefaultConfig(), store, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range []*storage.SignedReceipt{chain[0], chain[1], chain[5]} {
+		if err := store.Store(r); err != nil {
+			t.Fatal(err)
+		}
+		observeAll(t, a, r)
+	}
+
+	// Findings and chains survive a restart
+	a, err = NewAuditorWithStore(DefaultConfig(), store, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := a.Findings(Filter{Unresolved: true}); len(got) != 1 || got[0].Kind != KindGap || got[0].GapFrom != 3 || got[0].GapTo != 5 {
+		t.Fatalf("expected the gap after reload, got %+v", got)
+	}
+	if chains := a.Chains(); len(chains) != 1 || chains[0].Receipts != 3 || chains[0].Missing != 3 || chains[0].Findings != 1 {
+		t.Errorf("unexpected chain status after reload %+v", chains)
+	}
+	if found := observeAll(t, a, chain[1]); len(found) != 0 {
+		t.Errorf("expected a stored receipt to be known after reload, got %+v", found)
+	}
+
+	// New findings continue the numbering, and resolving a gap is saved
+	found := observeAll(t, a, replayOf(chain[0]))
+	if len(found) != 1 || found[0].ID != 2 {
+		t.Fatalf("expected replay finding 2, got %+v", found)
+	}
+	for _, r := range chain[2:5] {
+		if err := store.Store(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	observeAll(t, a, chain[2], chain[3])
+
+	// The last receipt was stored but not observed before the restart
+	a, err = NewAuditorWithStore(DefaultConfig(), store, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := a.Findings(Filter{}); len(got) != 2 || got[1].Kind != KindGap || !got[1].Resolved {
+		t.Fatalf("expected the gap resolved after reload, got %+v", got)
+	}
+	saved, _ := store.LoadFindings()
+	if !strings.Contains(string(saved[1]), `"resolved":true`) {
+		t.Errorf("resolved gap not saved: %s", saved[1])
+	}
+}
@@ -0,0 +1,180 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var (
+	// ErrConfirmationTimeout means no transaction was confirmed within
+	// Config.ConfirmationWait.
+	ErrConfirmationTimeout = errors.New("transaction confirmation timeout")
+	// ErrReverted means a transaction was mined but failed.
+	ErrReverted = errors.New("transaction reverted")
+)
+
+// AnchorBatch commits a batch's root and waits until it is confirmed. A
+// transaction that is not mined within ConfirmationWait is replaced by one
+// with the same nonce and a higher gas price, up to MaxFeeBumps times.
+// AnchorBatch returns the receipt of whichever of them was mined.
+func (c *Client) AnchorBatch(ctx context.Context, batch ReceiptBatch) (*types.Receipt, error) {
+	tx, err := c.SubmitBatch(ctx, batch)
+	if err != nil {
+		return nil, err
+	}
+	return c.confirm(ctx, batch.Epoch, tx)
+}
+
+// AnchorPayouts commits an epoch's payout root, the root of a tree of
+// treeSize leaves, with the settlement contract's commitPayoutRoot, and
+// waits until it is confirmed like AnchorBatch.
+func (c *Client) AnchorPayouts(ctx context.Context, epoch uint64, root [32]byte, treeSize uint64) (*types.Receipt, error) {
+	if root == ([32]byte{}) || treeSize == 0 {
+		return nil, fmt.Errorf("payout tree for epoch %d is empty", epoch)
+	}
+	tx, err := c.transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return c.settlement.CommitPayoutRoot(opts,
+			new(big.Int).SetUint64(epoch), root, new(big.Int).SetUint64(treeSize))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.confirm(ctx, epoch, tx)
+}
+
+// confirm waits until tx or one of its replacements is confirmed, bumping
+// fees as AnchorBatch describes.
+func (c *Client) confirm(ctx context.Context, epoch uint64, tx *types.Transaction) (*types.Receipt, error) {
+	sent := []*types.Transaction{tx}
+	for bumps := 0; ; bumps++ {
+		receipt, err := c.waitMined(ctx, sent)
+		if errors.Is(err, ErrConfirmationTimeout) && bumps < c.config.MaxFeeBumps {
+			// If the replacement is refused, one of the sent transactions
+			// may have been mined meanwhile; keep waiting for them
+			if replacement, err := c.replace(ctx, sent[len(sent)-1]); err == nil {
+				sent = append(sent, replacement)
+			}
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("epoch %d: %w", epoch, err)
+		}
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			return receipt, fmt.Errorf("epoch %d: %w: %s", epoch, ErrReverted, receipt.TxHash.Hex())
+		}
+		return receipt, nil
+	}
+}
+
+// FindAnchor looks for the RootCommitted event of an epoch. It returns the
+// transaction that committed the epoch and its root, or an empty hash if
+// the epoch was never committed.
+func (c *Client) FindAnchor(ctx context.Context, epoch uint64) (string, [32]byte, error) {
+	return c.findCommit(ctx, "RootCommitted", epoch)
+}
+
+// FindPayoutAnchor is FindAnchor for the epoch's payout root, looking for
+// its PayoutRootCommitted event.
+func (c *Client) FindPayoutAnchor(ctx context.Context, epoch uint64) (string, [32]byte, error) {
+	return c.findCommit(ctx, "PayoutRootCommitted", epoch)
+}
+
+// findCommit returns the transaction and root of the named event, which
+// has an indexed epoch followed by the root.
+func (c *Client) findCommit(ctx context.Context, name string, epoch uint64) (string, [32]byte, error) {
+	event := settlementABI.Events[name]
+	logs, err := c.ethClient.FilterLogs(ctx, ethereum.FilterQuery{
+		Addresses: []common.Address{c.settlementAddr},
+		Topics:    [][]common.Hash{{event.ID}, {common.BigToHash(new(big.Int).SetUint64(epoch))}},
+	})
+	if err != nil {
+		return "", [32]byte{}, err
+	}
+	for _, l := range logs {
+		if l.Removed {
+			continue
+		}
+		values, err := event.Inputs.NonIndexed().Unpack(l.Data)
+		if err != nil {
+			return "", [32]byte{}, err
+		}
+		return l.TxHash.Hex(), values[0].([32]byte), nil
+	}
+	return "", [32]byte{}, nil
+}
+
+// replace resends tx with the same nonce at a gas price FeeBumpPercent
+// higher, or the suggested price if that is higher still.
+func (c *Client) replace(ctx context.Context, tx *types.Transaction) (*types.Transaction, error) {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	gasPrice := new(big.Int).Mul(tx.GasPrice(), big.NewInt(int64(100+c.config.FeeBumpPercent)))
+	gasPrice.Div(gasPrice, big.NewInt(100))
+	if suggested, err := c.ethClient.SuggestGasPrice(ctx); err == nil && suggested.Cmp(gasPrice) > 0 {
+		gasPrice = suggested
+	}
+	if max := c.config.MaxGasPrice; max != nil && gasPrice.Cmp(max) > 0 {
+		if tx.GasPrice().Cmp(max) >= 0 {
+			return nil, fmt.Errorf("gas price is already at the maximum of %s", max)
+		}
+		gasPrice = max
+	}
+
+	return c.signAndSend(ctx, types.NewTransaction(tx.Nonce(), *tx.To(), tx.Value(), tx.Gas(), gasPrice, tx.Data()))
+}
+
+// waitMined waits until one of txs, which share a nonce, has
+// Config.Confirmations confirmations, and returns its receipt.
+func (c *Client) waitMined(ctx context.Context, txs []*types.Transaction) (*types.Receipt, error) {
+	interval := c.config.PollInterval
+	if interval <= 0 {
+		interval = 3 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	timeout := time.After(c.config.ConfirmationWait)
+
+	for {
+		for _, tx := range txs {
+			receipt, err := c.ethClient.TransactionReceipt(ctx, tx.Hash())
+			if err != nil {
+				continue
+			}
+			if ok, err := c.confirmed(ctx, receipt); err == nil && ok {
+				return receipt, nil
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-timeout:
+			return nil, ErrConfirmationTimeout
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// confirmed reports whether a mined transaction is deep enough.
+func (c *Client) confirmed(ctx context.Context, receipt *types.Receipt) (bool, error) {
+	want := c.config.Confirmations
+	if want <= 1 {
+		return true, nil
+	}
+	head, err := c.ethClient.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	depth := new(big.Int).Sub(head.Number, receipt.BlockNumber)
+	return depth.Sign() >= 0 && depth.Uint64()+1 >= want, nil
+}
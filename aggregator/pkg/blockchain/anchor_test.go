@@ -0,0 +1,222 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quiver/aggregator/pkg/blockchain/blockchaintest"
+)
+
+var settlementContract = common.HexToAddress("0x0000000000000000000000000000000000005e77")
+
+// mempool holds sent transactions until mine is called, so they can be
+// left pending and replaced, which the simulated backend does not allow.
+type mempool struct {
+	*backends.SimulatedBackend
+
+	mu   sync.Mutex
+	held map[uint64]*types.Transaction
+	sent []*types.Transaction
+}
+
+func (m *mempool) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if old, ok := m.held[tx.Nonce()]; ok {
+		min := new(big.Int).Mul(old.GasPrice(), big.NewInt(110))
+		if new(big.Int).Mul(tx.GasPrice(), big.NewInt(100)).Cmp(min) < 0 {
+			return errors.New("replacement transaction underpriced")
+		}
+	}
+	m.held[tx.Nonce()] = tx
+	m.sent = append(m.sent, tx)
+	return nil
+}
+
+func (m *mempool) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	nonce, err := m.SimulatedBackend.PendingNonceAt(ctx, account)
+	for n := range m.held {
+		if n >= nonce {
+			nonce = n + 1
+		}
+	}
+	return nonce, err
+}
+
+func (m *mempool) sentCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.sent)
+}
+
+// mine includes the held transactions in a block.
+func (m *mempool) mine(t *testing.T) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for nonce, tx := range m.held {
+		require.NoError(t, m.SimulatedBackend.SendTransaction(context.Background(), tx))
+		delete(m.held, nonce)
+	}
+	m.SimulatedBackend.Commit()
+}
+
+func anchorConfig(config *Config) {
+	config.PollInterval = time.Millisecond
+	config.ConfirmationWait = 5 * time.Second
+	config.Confirmations = 1
+}
+
+func testBatch(epoch uint64) ReceiptBatch {
+	return ReceiptBatch{
+		Epoch:        epoch,
+		MerkleRoot:   crypto.Keccak256Hash([]byte(fmt.Sprintf("root-%d", epoch))),
+		ReceiptCount: 12,
+	}
+}
+
+func TestAnchorBatch(t *testing.T) {
+	client, sim := newSimulatedClient(t, settlementContract, blockchaintest.RootLogger(), func(config *Config) {
+		anchorConfig(config)
+		config.Confirmations = 3
+	})
+	ctx := context.Background()
+
+	type result struct {
+		receipt *types.Receipt
+		err     error
+	}
+	done := make(chan result, 1)
+	go func() {
+		receipt, err := client.AnchorBatch(ctx, testBatch(42))
+		done <- result{receipt, err}
+	}()
+
+	// Keep producing blocks until the root has three confirmations
+	var res result
+	for waiting := true; waiting; {
+		select {
+		case res = <-done:
+			waiting = false
+		case <-time.After(time.Millisecond):
+			sim.Commit()
+		}
+	}
+	require.NoError(t, res.err)
+	require.Equal(t, types.ReceiptStatusSuccessful, res.receipt.Status)
+	head, err := sim.HeaderByNumber(ctx, nil)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, head.Number.Uint64()-res.receipt.BlockNumber.Uint64()+1, uint64(3))
+
+	tx, _, err := sim.TransactionByHash(ctx, res.receipt.TxHash)
+	require.NoError(t, err)
+	args, err := settlementABI.Methods["commitRoot"].Inputs.Unpack(tx.Data()[4:])
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(42), args[0])
+	require.Equal(t, [32]byte(testBatch(42).MerkleRoot), args[1])
+	require.Equal(t, big.NewInt(12), args[2])
+
+	txHash, root, err := client.FindAnchor(ctx, 42)
+	require.NoError(t, err)
+	require.Equal(t, res.receipt.TxHash.Hex(), txHash)
+	require.Equal(t, testBatch(42).MerkleRoot, root)
+
+	txHash, _, err = client.FindAnchor(ctx, 43)
+	require.NoError(t, err)
+	require.Empty(t, txHash)
+
+	_, err = client.SubmitBatch(ctx, ReceiptBatch{Epoch: 44})
+	require.Error(t, err)
+}
+
+func TestAnchorBatchBumpsFees(t *testing.T) {
+	var pool *mempool
+	client, _ := newSimulatedClient(t, settlementContract, blockchaintest.RootLogger(), func(config *Config) {
+		anchorConfig(config)
+		config.ConfirmationWait = 20 * time.Millisecond
+		config.FeeBumpPercent = 20
+		config.MaxFeeBumps = 5
+	}, func(sim *backends.SimulatedBackend) EthBackend {
+		pool = &mempool{SimulatedBackend: sim, held: make(map[uint64]*types.Transaction)}
+		return pool
+	})
+
+	var receipt *types.Receipt
+	done := make(chan error, 1)
+	go func() {
+		var err error
+		receipt, err = client.AnchorBatch(context.Background(), testBatch(7))
+		done <- err
+	}()
+
+	// Let two fee bumps go by before the transaction is mined
+	require.Eventually(t, func() bool { return pool.sentCount() == 3 }, 5*time.Second, time.Millisecond)
+	pool.mine(t)
+	require.NoError(t, <-done)
+
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	last := pool.sent[len(pool.sent)-1]
+	require.Equal(t, last.Hash(), receipt.TxHash)
+	for i := 1; i < len(pool.sent); i++ {
+		prev, tx := pool.sent[i-1], pool.sent[i]
+		require.Equal(t, prev.Nonce(), tx.Nonce())
+		require.Equal(t, prev.Data(), tx.Data())
+		bumped := new(big.Int).Mul(prev.GasPrice(), big.NewInt(120))
+		require.True(t, new(big.Int).Mul(tx.GasPrice(), big.NewInt(100)).Cmp(bumped) >= 0,
+			"gas price %s after %s", tx.GasPrice(), prev.GasPrice())
+	}
+}
+
+func TestAnchorBatchGivesUp(t *testing.T) {
+	var pool *mempool
+	client, _ := newSimulatedClient(t, settlementContract, blockchaintest.RootLogger(), func(config *Config) {
+		anchorConfig(config)
+		config.ConfirmationWait = 10 * time.Millisecond
+		config.FeeBumpPercent = 20
+		config.MaxFeeBumps = 2
+	}, func(sim *backends.SimulatedBackend) EthBackend {
+		pool = &mempool{SimulatedBackend: sim, held: make(map[uint64]*types.Transaction)}
+		return pool
+	})
+
+	_, err := client.AnchorBatch(context.Background(), testBatch(7))
+	require.ErrorIs(t, err, ErrConfirmationTimeout)
+	require.Equal(t, 3, pool.sentCount())
+}
+
+func TestAnchorBatchReverted(t *testing.T) {
+	client, sim := newSimulatedClient(t, reverter, nil, anchorConfig)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.AnchorBatch(context.Background(), testBatch(7))
+		done <- err
+	}()
+
+	var err error
+	for waiting := true; waiting; {
+		select {
+		case err = <-done:
+			waiting = false
+		case <-time.After(time.Millisecond):
+			sim.Commit()
+		}
+	}
+	require.ErrorIs(t, err, ErrReverted)
+}
@@ -0,0 +1,4068 @@
+// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package blockchain
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = errors.New
+	_ = big.NewInt
+	_ = strings.NewReader
+	_ = ethereum.NotFound
+	_ = bind.Bind
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = event.NewSubscription
+	_ = abi.ConvertType
+)
+
+// SimpleQUIVTokenMetaData contains all meta data concerning the SimpleQUIVToken contract.
+var SimpleQUIVTokenMetaData = &bind.MetaData{
+	ABI: "[{\"inputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"constructor\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"spender\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"allowance\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"needed\",\"type\":\"uint256\"}],\"name\":\"ERC20InsufficientAllowance\",\"type\":\"error\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"sender\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"balance\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"needed\",\"type\":\"uint256\"}],\"name\":\"ERC20InsufficientBalance\",\"type\":\"error\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"approver\",\"type\":\"address\"}],\"name\":\"ERC20InvalidApprover\",\"type\":\"error\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"receiver\",\"type\":\"address\"}],\"name\":\"ERC20InvalidReceiver\",\"type\":\"error\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"sender\",\"type\":\"address\"}],\"name\":\"ERC20InvalidSender\",\"type\":\"error\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"spender\",\"type\":\"address\"}],\"name\":\"ERC20InvalidSpender\",\"type\":\"error\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"}],\"name\":\"OwnableInvalidOwner\",\"type\":\"error\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"account\",\"type\":\"address\"}],\"name\":\"OwnableUnauthorizedAccount\",\"type\":\"error\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"spender\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"Approval\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"previousOwner\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"newOwner\",\"type\":\"address\"}],\"name\":\"OwnershipTransferred\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"from\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"Transfer\",\"type\":\"event\"},{\"inputs\":[],\"name\":\"MAX_SUPPLY\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"spender\",\"type\":\"address\"}],\"name\":\"allowance\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"spender\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"approve\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"account\",\"type\":\"address\"}],\"name\":\"balanceOf\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"decimals\",\"outputs\":[{\"internalType\":\"uint8\",\"name\":\"\",\"type\":\"uint8\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"}],\"name\":\"mint\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"name\",\"outputs\":[{\"internalType\":\"string\",\"name\":\"\",\"type\":\"string\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"owner\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"renounceOwnership\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"symbol\",\"outputs\":[{\"internalType\":\"string\",\"name\":\"\",\"type\":\"string\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"totalSupply\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"transfer\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"from\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"transferFrom\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"newOwner\",\"type\":\"address\"}],\"name\":\"transferOwnership\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"}]",
+}
+
+// SimpleQUIVTokenABI is the input ABI used to generate the binding from.
+// Deprecated: Use SimpleQUIVTokenMetaData.ABI instead.
+var SimpleQUIVTokenABI = SimpleQUIVTokenMetaData.ABI
+
+// SimpleQUIVToken is an auto generated Go binding around an Ethereum contract.
+type SimpleQUIVToken struct {
+	SimpleQUIVTokenCaller     // Read-only binding to the contract
+	SimpleQUIVTokenTransactor // Write-only binding to the contract
+	SimpleQUIVTokenFilterer   // Log filterer for contract events
+}
+
+// SimpleQUIVTokenCaller is an auto generated read-only Go binding around an Ethereum contract.
+type SimpleQUIVTokenCaller struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// SimpleQUIVTokenTransactor is an auto generated write-only Go binding around an Ethereum contract.
+type SimpleQUIVTokenTransactor struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// SimpleQUIVTokenFilterer is an auto generated log filtering Go binding around an Ethereum contract events.
+type SimpleQUIVTokenFilterer struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// SimpleQUIVTokenSession is an auto generated Go binding around an Ethereum contract,
+// with pre-set call and transact options.
+type SimpleQUIVTokenSession struct {
+	Contract     *SimpleQUIVToken  // Generic contract binding to set the session for
+	CallOpts     bind.CallOpts     // Call options to use throughout this session
+	TransactOpts bind.TransactOpts // Transaction auth options to use throughout this session
+}
+
+// SimpleQUIVTokenCallerSession is an auto generated read-only Go binding around an Ethereum contract,
+// with pre-set call options.
+type SimpleQUIVTokenCallerSession struct {
+	Contract *SimpleQUIVTokenCaller // Generic contract caller binding to set the session for
+	CallOpts bind.CallOpts          // Call options to use throughout this session
+}
+
+// SimpleQUIVTokenTransactorSession is an auto generated write-only Go binding around an Ethereum contract,
+// with pre-set transact options.
+type SimpleQUIVTokenTransactorSession struct {
+	Contract     *SimpleQUIVTokenTransactor // Generic contract transactor binding to set the session for
+	TransactOpts bind.TransactOpts          // Transaction auth options to use throughout this session
+}
+
+// SimpleQUIVTokenRaw is an auto generated low-level Go binding around an Ethereum contract.
+type SimpleQUIVTokenRaw struct {
+	Contract *SimpleQUIVToken // Generic contract binding to access the raw methods on
+}
+
+// SimpleQUIVTokenCallerRaw is an auto generated low-level read-only Go binding around an Ethereum contract.
+type SimpleQUIVTokenCallerRaw struct {
+	Contract *SimpleQUIVTokenCaller // Generic read-only contract binding to access the raw methods on
+}
+
+// SimpleQUIVTokenTransactorRaw is an auto generated low-level write-only Go binding around an Ethereum contract.
+type SimpleQUIVTokenTransactorRaw struct {
+	Contract *SimpleQUIVTokenTransactor // Generic write-only contract binding to access the raw methods on
+}
+
+// NewSimpleQUIVToken creates a new instance of SimpleQUIVToken, bound to a specific deployed contract.
+func NewSimpleQUIVToken(address common.Address, backend bind.ContractBackend) (*SimpleQUIVToken, error) {
+	contract, err := bindSimpleQUIVToken(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &SimpleQUIVToken{SimpleQUIVTokenCaller: SimpleQUIVTokenCaller{contract: contract}, SimpleQUIVTokenTransactor: SimpleQUIVTokenTransactor{contract: contract}, SimpleQUIVTokenFilterer: SimpleQUIVTokenFilterer{contract: contract}}, nil
+}
+
+// NewSimpleQUIVTokenCaller creates a new read-only instance of SimpleQUIVToken, bound to a specific deployed contract.
+func NewSimpleQUIVTokenCaller(address common.Address, caller bind.ContractCaller) (*SimpleQUIVTokenCaller, error) {
+	contract, err := bindSimpleQUIVToken(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &SimpleQUIVTokenCaller{contract: contract}, nil
+}
+
+// NewSimpleQUIVTokenTransactor creates a new write-only instance of SimpleQUIVToken, bound to a specific deployed contract.
+func NewSimpleQUIVTokenTransactor(address common.Address, transactor bind.ContractTransactor) (*SimpleQUIVTokenTransactor, error) {
+	contract, err := bindSimpleQUIVToken(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &SimpleQUIVTokenTransactor{contract: contract}, nil
+}
+
+// NewSimpleQUIVTokenFilterer creates a new log filterer instance of SimpleQUIVToken, bound to a specific deployed contract.
+func NewSimpleQUIVTokenFilterer(address common.Address, filterer bind.ContractFilterer) (*SimpleQUIVTokenFilterer, error) {
+	contract, err := bindSimpleQUIVToken(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &SimpleQUIVTokenFilterer{contract: contract}, nil
+}
+
+// bindSimpleQUIVToken binds a generic wrapper to an already deployed contract.
+func bindSimpleQUIVToken(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := SimpleQUIVTokenMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, *parsed, caller, transactor, filterer), nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_SimpleQUIVToken *SimpleQUIVTokenRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _SimpleQUIVToken.Contract.SimpleQUIVTokenCaller.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_SimpleQUIVToken *SimpleQUIVTokenRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _SimpleQUIVToken.Contract.SimpleQUIVTokenTransactor.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_SimpleQUIVToken *SimpleQUIVTokenRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _SimpleQUIVToken.Contract.SimpleQUIVTokenTransactor.contract.Transact(opts, method, params...)
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_SimpleQUIVToken *SimpleQUIVTokenCallerRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _SimpleQUIVToken.Contract.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_SimpleQUIVToken *SimpleQUIVTokenTransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _SimpleQUIVToken.Contract.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_SimpleQUIVToken *SimpleQUIVTokenTransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _SimpleQUIVToken.Contract.contract.Transact(opts, method, params...)
+}
+
+// MAXSUPPLY is a free data retrieval call binding the contract method 0x32cb6b0c.
+//
+// Solidity: function MAX_SUPPLY() view returns(uint256)
+func (_SimpleQUIVToken *SimpleQUIVTokenCaller) MAXSUPPLY(opts *bind.CallOpts) (*big.Int, error) {
+	var out []interface{}
+	err := _SimpleQUIVToken.contract.Call(opts, &out, "MAX_SUPPLY")
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// MAXSUPPLY is a free data retrieval call binding the contract method 0x32cb6b0c.
+//
+// Solidity: function MAX_SUPPLY() view returns(uint256)
+func (_SimpleQUIVToken *SimpleQUIVTokenSession) MAXSUPPLY() (*big.Int, error) {
+	return _SimpleQUIVToken.Contract.MAXSUPPLY(&_SimpleQUIVToken.CallOpts)
+}
+
+// MAXSUPPLY is a free data retrieval call binding the contract method 0x32cb6b0c.
+//
+// Solidity: function MAX_SUPPLY() view returns(uint256)
+func (_SimpleQUIVToken *SimpleQUIVTokenCallerSession) MAXSUPPLY() (*big.Int, error) {
+	return _SimpleQUIVToken.Contract.MAXSUPPLY(&_SimpleQUIVToken.CallOpts)
+}
+
+// Allowance is a free data retrieval call binding the contract method 0xdd62ed3e.
+//
+// Solidity: function allowance(address owner, address spender) view returns(uint256)
+func (_SimpleQUIVToken *SimpleQUIVTokenCaller) Allowance(opts *bind.CallOpts, owner common.Address, spender common.Address) (*big.Int, error) {
+	var out []interface{}
+	err := _SimpleQUIVToken.contract.Call(opts, &out, "allowance", owner, spender)
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// Allowance is a free data retrieval call binding the contract method 0xdd62ed3e.
+//
+// Solidity: function allowance(address owner, address spender) view returns(uint256)
+func (_SimpleQUIVToken *SimpleQUIVTokenSession) Allowance(owner common.Address, spender common.Address) (*big.Int, error) {
+	return _SimpleQUIVToken.Contract.Allowance(&_SimpleQUIVToken.CallOpts, owner, spender)
+}
+
+// Allowance is a free data retrieval call binding the contract method 0xdd62ed3e.
+//
+// Solidity: function allowance(address owner, address spender) view returns(uint256)
+func (_SimpleQUIVToken *SimpleQUIVTokenCallerSession) Allowance(owner common.Address, spender common.Address) (*big.Int, error) {
+	return _SimpleQUIVToken.Contract.Allowance(&_SimpleQUIVToken.CallOpts, owner, spender)
+}
+
+// BalanceOf is a free data retrieval call binding the contract method 0x70a08231.
+//
+// Solidity: function balanceOf(address account) view returns(uint256)
+func (_SimpleQUIVToken *SimpleQUIVTokenCaller) BalanceOf(opts *bind.CallOpts, account common.Address) (*big.Int, error) {
+	var out []interface{}
+	err := _SimpleQUIVToken.contract.Call(opts, &out, "balanceOf", account)
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// BalanceOf is a free data retrieval call binding the contract method 0x70a08231.
+//
+// Solidity: function balanceOf(address account) view returns(uint256)
+func (_SimpleQUIVToken *SimpleQUIVTokenSession) BalanceOf(account common.Address) (*big.Int, error) {
+	return _SimpleQUIVToken.Contract.BalanceOf(&_SimpleQUIVToken.CallOpts, account)
+}
+
+// BalanceOf is a free data retrieval call binding the contract method 0x70a08231.
+//
+// Solidity: function balanceOf(address account) view returns(uint256)
+func (_SimpleQUIVToken *SimpleQUIVTokenCallerSession) BalanceOf(account common.Address) (*big.Int, error) {
+	return _SimpleQUIVToken.Contract.BalanceOf(&_SimpleQUIVToken.CallOpts, account)
+}
+
+// Decimals is a free data retrieval call binding the contract method 0x313ce567.
+//
+// Solidity: function decimals() view returns(uint8)
+func (_SimpleQUIVToken *SimpleQUIVTokenCaller) Decimals(opts *bind.CallOpts) (uint8, error) {
+	var out []interface{}
+	err := _SimpleQUIVToken.contract.Call(opts, &out, "decimals")
+
+	if err != nil {
+		return *new(uint8), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(uint8)).(*uint8)
+
+	return out0, err
+
+}
+
+// Decimals is a free data retrieval call binding the contract method 0x313ce567.
+//
+// Solidity: function decimals() view returns(uint8)
+func (_SimpleQUIVToken *SimpleQUIVTokenSession) Decimals() (uint8, error) {
+	return _SimpleQUIVToken.Contract.Decimals(&_SimpleQUIVToken.CallOpts)
+}
+
+// Decimals is a free data retrieval call binding the contract method 0x313ce567.
+//
+// Solidity: function decimals() view returns(uint8)
+func (_SimpleQUIVToken *SimpleQUIVTokenCallerSession) Decimals() (uint8, error) {
+	return _SimpleQUIVToken.Contract.Decimals(&_SimpleQUIVToken.CallOpts)
+}
+
+// Name is a free data retrieval call binding the contract method 0x06fdde03.
+//
+// Solidity: function name() view returns(string)
+func (_SimpleQUIVToken *SimpleQUIVTokenCaller) Name(opts *bind.CallOpts) (string, error) {
+	var out []interface{}
+	err := _SimpleQUIVToken.contract.Call(opts, &out, "name")
+
+	if err != nil {
+		return *new(string), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(string)).(*string)
+
+	return out0, err
+
+}
+
+// Name is a free data retrieval call binding the contract method 0x06fdde03.
+//
+// Solidity: function name() view returns(string)
+func (_SimpleQUIVToken *SimpleQUIVTokenSession) Name() (string, error) {
+	return _SimpleQUIVToken.Contract.Name(&_SimpleQUIVToken.CallOpts)
+}
+
+// Name is a free data retrieval call binding the contract method 0x06fdde03.
+//
+// Solidity: function name() view returns(string)
+func (_SimpleQUIVToken *SimpleQUIVTokenCallerSession) Name() (string, error) {
+	return _SimpleQUIVToken.Contract.Name(&_SimpleQUIVToken.CallOpts)
+}
+
+// Owner is a free data retrieval call binding the contract method 0x8da5cb5b.
+//
+// Solidity: function owner() view returns(address)
+func (_SimpleQUIVToken *SimpleQUIVTokenCaller) Owner(opts *bind.CallOpts) (common.Address, error) {
+	var out []interface{}
+	err := _SimpleQUIVToken.contract.Call(opts, &out, "owner")
+
+	if err != nil {
+		return *new(common.Address), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+
+	return out0, err
+
+}
+
+// Owner is a free data retrieval call binding the contract method 0x8da5cb5b.
+//
+// Solidity: function owner() view returns(address)
+func (_SimpleQUIVToken *SimpleQUIVTokenSession) Owner() (common.Address, error) {
+	return _SimpleQUIVToken.Contract.Owner(&_SimpleQUIVToken.CallOpts)
+}
+
+// Owner is a free data retrieval call binding the contract method 0x8da5cb5b.
+//
+// Solidity: function owner() view returns(address)
+func (_SimpleQUIVToken *SimpleQUIVTokenCallerSession) Owner() (common.Address, error) {
+	return _SimpleQUIVToken.Contract.Owner(&_SimpleQUIVToken.CallOpts)
+}
+
+// Symbol is a free data retrieval call binding the contract method 0x95d89b41.
+//
+// Solidity: function symbol() view returns(string)
+func (_SimpleQUIVToken *SimpleQUIVTokenCaller) Symbol(opts *bind.CallOpts) (string, error) {
+	var out []interface{}
+	err := _SimpleQUIVToken.contract.Call(opts, &out, "symbol")
+
+	if err != nil {
+		return *new(string), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(string)).(*string)
+
+	return out0, err
+
+}
+
+// Symbol is a free data retrieval call binding the contract method 0x95d89b41.
+//
+// Solidity: function symbol() view returns(string)
+func (_SimpleQUIVToken *SimpleQUIVTokenSession) Symbol() (string, error) {
+	return _SimpleQUIVToken.Contract.Symbol(&_SimpleQUIVToken.CallOpts)
+}
+
+// Symbol is a free data retrieval call binding the contract method 0x95d89b41.
+//
+// Solidity: function symbol() view returns(string)
+func (_SimpleQUIVToken *SimpleQUIVTokenCallerSession) Symbol() (string, error) {
+	return _SimpleQUIVToken.Contract.Symbol(&_SimpleQUIVToken.CallOpts)
+}
+
+// TotalSupply is a free data retrieval call binding the contract method 0x18160ddd.
+//
+// Solidity: function totalSupply() view returns(uint256)
+func (_SimpleQUIVToken *SimpleQUIVTokenCaller) TotalSupply(opts *bind.CallOpts) (*big.Int, error) {
+	var out []interface{}
+	err := _SimpleQUIVToken.contract.Call(opts, &out, "totalSupply")
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// TotalSupply is a free data retrieval call binding the contract method 0x18160ddd.
+//
+// Solidity: function totalSupply() view returns(uint256)
+func (_SimpleQUIVToken *SimpleQUIVTokenSession) TotalSupply() (*big.Int, error) {
+	return _SimpleQUIVToken.Contract.TotalSupply(&_SimpleQUIVToken.CallOpts)
+}
+
+// TotalSupply is a free data retrieval call binding the contract method 0x18160ddd.
+//
+// Solidity: function totalSupply() view returns(uint256)
+func (_SimpleQUIVToken *SimpleQUIVTokenCallerSession) TotalSupply() (*big.Int, error) {
+	return _SimpleQUIVToken.Contract.TotalSupply(&_SimpleQUIVToken.CallOpts)
+}
+
+// Approve is a paid mutator transaction binding the contract method 0x095ea7b3.
+//
+// Solidity: function approve(address spender, uint256 value) returns(bool)
+func (_SimpleQUIVToken *SimpleQUIVTokenTransactor) Approve(opts *bind.TransactOpts, spender common.Address, value *big.Int) (*types.Transaction, error) {
+	return _SimpleQUIVToken.contract.Transact(opts, "approve", spender, value)
+}
+
+// Approve is a paid mutator transaction binding the contract method 0x095ea7b3.
+//
+// Solidity: function approve(address spender, uint256 value) returns(bool)
+func (_SimpleQUIVToken *SimpleQUIVTokenSession) Approve(spender common.Address, value *big.Int) (*types.Transaction, error) {
+	return _SimpleQUIVToken.Contract.Approve(&_SimpleQUIVToken.TransactOpts, spender, value)
+}
+
+// Approve is a paid mutator transaction binding the contract method 0x095ea7b3.
+//
+// Solidity: function approve(address spender, uint256 value) returns(bool)
+func (_SimpleQUIVToken *SimpleQUIVTokenTransactorSession) Approve(spender common.Address, value *big.Int) (*types.Transaction, error) {
+	return _SimpleQUIVToken.Contract.Approve(&_SimpleQUIVToken.TransactOpts, spender, value)
+}
+
+// Mint is a paid mutator transaction binding the contract method 0x40c10f19.
+//
+// Solidity: function mint(address to, uint256 amount) returns()
+func (_SimpleQUIVToken *SimpleQUIVTokenTransactor) Mint(opts *bind.TransactOpts, to common.Address, amount *big.Int) (*types.Transaction, error) {
+	return _SimpleQUIVToken.contract.Transact(opts, "mint", to, amount)
+}
+
+// Mint is a paid mutator transaction binding the contract method 0x40c10f19.
+//
+// Solidity: function mint(address to, uint256 amount) returns()
+func (_SimpleQUIVToken *SimpleQUIVTokenSession) Mint(to common.Address, amount *big.Int) (*types.Transaction, error) {
+	return _SimpleQUIVToken.Contract.Mint(&_SimpleQUIVToken.TransactOpts, to, amount)
+}
+
+// Mint is a paid mutator transaction binding the contract method 0x40c10f19.
+//
+// Solidity: function mint(address to, uint256 amount) returns()
+func (_SimpleQUIVToken *SimpleQUIVTokenTransactorSession) Mint(to common.Address, amount *big.Int) (*types.Transaction, error) {
+	return _SimpleQUIVToken.Contract.Mint(&_SimpleQUIVToken.TransactOpts, to, amount)
+}
+
+// RenounceOwnership is a paid mutator transaction binding the contract method 0x715018a6.
+//
+// Solidity: function renounceOwnership() returns()
+func (_SimpleQUIVToken *SimpleQUIVTokenTransactor) RenounceOwnership(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _SimpleQUIVToken.contract.Transact(opts, "renounceOwnership")
+}
+
+// RenounceOwnership is a paid mutator transaction binding the contract method 0x715018a6.
+//
+// Solidity: function renounceOwnership() returns()
+func (_SimpleQUIVToken *SimpleQUIVTokenSession) RenounceOwnership() (*types.Transaction, error) {
+	return _SimpleQUIVToken.Contract.RenounceOwnership(&_SimpleQUIVToken.TransactOpts)
+}
+
+// RenounceOwnership is a paid mutator transaction binding the contract method 0x715018a6.
+//
+// Solidity: function renounceOwnership() returns()
+func (_SimpleQUIVToken *SimpleQUIVTokenTransactorSession) RenounceOwnership() (*types.Transaction, error) {
+	return _SimpleQUIVToken.Contract.RenounceOwnership(&_SimpleQUIVToken.TransactOpts)
+}
+
+// Transfer is a paid mutator transaction binding the contract method 0xa9059cbb.
+//
+// Solidity: function transfer(address to, uint256 value) returns(bool)
+func (_SimpleQUIVToken *SimpleQUIVTokenTransactor) Transfer(opts *bind.TransactOpts, to common.Address, value *big.Int) (*types.Transaction, error) {
+	return _SimpleQUIVToken.contract.Transact(opts, "transfer", to, value)
+}
+
+// Transfer is a paid mutator transaction binding the contract method 0xa9059cbb.
+//
+// Solidity: function transfer(address to, uint256 value) returns(bool)
+func (_SimpleQUIVToken *SimpleQUIVTokenSession) Transfer(to common.Address, value *big.Int) (*types.Transaction, error) {
+	return _SimpleQUIVToken.Contract.Transfer(&_SimpleQUIVToken.TransactOpts, to, value)
+}
+
+// Transfer is a paid mutator transaction binding the contract method 0xa9059cbb.
+//
+// Solidity: function transfer(address to, uint256 value) returns(bool)
+func (_SimpleQUIVToken *SimpleQUIVTokenTransactorSession) Transfer(to common.Address, value *big.Int) (*types.Transaction, error) {
+	return _SimpleQUIVToken.Contract.Transfer(&_SimpleQUIVToken.TransactOpts, to, value)
+}
+
+// TransferFrom is a paid mutator transaction binding the contract method 0x23b872dd.
+//
+// Solidity: function transferFrom(address from, address to, uint256 value) returns(bool)
+func (_SimpleQUIVToken *SimpleQUIVTokenTransactor) TransferFrom(opts *bind.TransactOpts, from common.Address, to common.Address, value *big.Int) (*types.Transaction, error) {
+	return _SimpleQUIVToken.contract.Transact(opts, "transferFrom", from, to, value)
+}
+
+// TransferFrom is a paid mutator transaction binding the contract method 0x23b872dd.
+//
+// Solidity: function transferFrom(address from, address to, uint256 value) returns(bool)
+func (_SimpleQUIVToken *SimpleQUIVTokenSession) TransferFrom(from common.Address, to common.Address, value *big.Int) (*types.Transaction, error) {
+	return _SimpleQUIVToken.Contract.TransferFrom(&_SimpleQUIVToken.TransactOpts, from, to, value)
+}
+
+// TransferFrom is a paid mutator transaction binding the contract method 0x23b872dd.
+//
+// Solidity: function transferFrom(address from, address to, uint256 value) returns(bool)
+func (_SimpleQUIVToken *SimpleQUIVTokenTransactorSession) TransferFrom(from common.Address, to common.Address, value *big.Int) (*types.Transaction, error) {
+	return _SimpleQUIVToken.Contract.TransferFrom(&_SimpleQUIVToken.TransactOpts, from, to, value)
+}
+
+// TransferOwnership is a paid mutator transaction binding the contract method 0xf2fde38b.
+//
+// Solidity: function transferOwnership(address newOwner) returns()
+func (_SimpleQUIVToken *SimpleQUIVTokenTransactor) TransferOwnership(opts *bind.TransactOpts, newOwner common.Address) (*types.Transaction, error) {
+	return _SimpleQUIVToken.contract.Transact(opts, "transferOwnership", newOwner)
+}
+
+// TransferOwnership is a paid mutator transaction binding the contract method 0xf2fde38b.
+//
+// Solidity: function transferOwnership(address newOwner) returns()
+func (_SimpleQUIVToken *SimpleQUIVTokenSession) TransferOwnership(newOwner common.Address) (*types.Transaction, error) {
+	return _SimpleQUIVToken.Contract.TransferOwnership(&_SimpleQUIVToken.TransactOpts, newOwner)
+}
+
+// TransferOwnership is a paid mutator transaction binding the contract method 0xf2fde38b.
+//
+// Solidity: function transferOwnership(address newOwner) returns()
+func (_SimpleQUIVToken *SimpleQUIVTokenTransactorSession) TransferOwnership(newOwner common.Address) (*types.Transaction, error) {
+	return _SimpleQUIVToken.Contract.TransferOwnership(&_SimpleQUIVToken.TransactOpts, newOwner)
+}
+
+// SimpleQUIVTokenApprovalIterator is returned from FilterApproval and is used to iterate over the raw logs and unpacked data for Approval events raised by the SimpleQUIVToken contract.
+type SimpleQUIVTokenApprovalIterator struct {
+	Event *SimpleQUIVTokenApproval // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *SimpleQUIVTokenApprovalIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(SimpleQUIVTokenApproval)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(SimpleQUIVTokenApproval)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *SimpleQUIVTokenApprovalIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *SimpleQUIVTokenApprovalIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// SimpleQUIVTokenApproval represents a Approval event raised by the SimpleQUIVToken contract.
+type SimpleQUIVTokenApproval struct {
+	Owner   common.Address
+	Spender common.Address
+	Value   *big.Int
+	Raw     types.Log // Blockchain specific contextual infos
+}
+
+// FilterApproval is a free log retrieval operation binding the contract event 0x8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925.
+//
+// Solidity: event Approval(address indexed owner, address indexed spender, uint256 value)
+func (_SimpleQUIVToken *SimpleQUIVTokenFilterer) FilterApproval(opts *bind.FilterOpts, owner []common.Address, spender []common.Address) (*SimpleQUIVTokenApprovalIterator, error) {
+
+	var ownerRule []interface{}
+	for _, ownerItem := range owner {
+		ownerRule = append(ownerRule, ownerItem)
+	}
+	var spenderRule []interface{}
+	for _, spenderItem := range spender {
+		spenderRule = append(spenderRule, spenderItem)
+	}
+
+	logs, sub, err := _SimpleQUIVToken.contract.FilterLogs(opts, "Approval", ownerRule, spenderRule)
+	if err != nil {
+		return nil, err
+	}
+	return &SimpleQUIVTokenApprovalIterator{contract: _SimpleQUIVToken.contract, event: "Approval", logs: logs, sub: sub}, nil
+}
+
+// WatchApproval is a free log subscription operation binding the contract event 0x8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925.
+//
+// Solidity: event Approval(address indexed owner, address indexed spender, uint256 value)
+func (_SimpleQUIVToken *SimpleQUIVTokenFilterer) WatchApproval(opts *bind.WatchOpts, sink chan<- *SimpleQUIVTokenApproval, owner []common.Address, spender []common.Address) (event.Subscription, error) {
+
+	var ownerRule []interface{}
+	for _, ownerItem := range owner {
+		ownerRule = append(ownerRule, ownerItem)
+	}
+	var spenderRule []interface{}
+	for _, spenderItem := range spender {
+		spenderRule = append(spenderRule, spenderItem)
+	}
+
+	logs, sub, err := _SimpleQUIVToken.contract.WatchLogs(opts, "Approval", ownerRule, spenderRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(SimpleQUIVTokenApproval)
+				if err := _SimpleQUIVToken.contract.UnpackLog(event, "Approval", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseApproval is a log parse operation binding the contract event 0x8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925.
+//
+// Solidity: event Approval(address indexed owner, address indexed spender, uint256 value)
+func (_SimpleQUIVToken *SimpleQUIVTokenFilterer) ParseApproval(log types.Log) (*SimpleQUIVTokenApproval, error) {
+	event := new(SimpleQUIVTokenApproval)
+	if err := _SimpleQUIVToken.contract.UnpackLog(event, "Approval", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// SimpleQUIVTokenOwnershipTransferredIterator is returned from FilterOwnershipTransferred and is used to iterate over the raw logs and unpacked data for OwnershipTransferred events raised by the SimpleQUIVToken contract.
+type SimpleQUIVTokenOwnershipTransferredIterator struct {
+	Event *SimpleQUIVTokenOwnershipTransferred // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *SimpleQUIVTokenOwnershipTransferredIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(SimpleQUIVTokenOwnershipTransferred)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(SimpleQUIVTokenOwnershipTransferred)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *SimpleQUIVTokenOwnershipTransferredIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *SimpleQUIVTokenOwnershipTransferredIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// SimpleQUIVTokenOwnershipTransferred represents a OwnershipTransferred event raised by the SimpleQUIVToken contract.
+type SimpleQUIVTokenOwnershipTransferred struct {
+	PreviousOwner common.Address
+	NewOwner      common.Address
+	Raw           types.Log // Blockchain specific contextual infos
+}
+
+// FilterOwnershipTransferred is a free log retrieval operation binding the contract event 0x8be0079c531659141344cd1fd0a4f28419497f9722a3daafe3b4186f6b6457e0.
+//
+// Solidity: event OwnershipTransferred(address indexed previousOwner, address indexed newOwner)
+func (_SimpleQUIVToken *SimpleQUIVTokenFilterer) FilterOwnershipTransferred(opts *bind.FilterOpts, previousOwner []common.Address, newOwner []common.Address) (*SimpleQUIVTokenOwnershipTransferredIterator, error) {
+
+	var previousOwnerRule []interface{}
+	for _, previousOwnerItem := range previousOwner {
+		previousOwnerRule = append(previousOwnerRule, previousOwnerItem)
+	}
+	var newOwnerRule []interface{}
+	for _, newOwnerItem := range newOwner {
+		newOwnerRule = append(newOwnerRule, newOwnerItem)
+	}
+
+	logs, sub, err := _SimpleQUIVToken.contract.FilterLogs(opts, "OwnershipTransferred", previousOwnerRule, newOwnerRule)
+	if err != nil {
+		return nil, err
+	}
+	return &SimpleQUIVTokenOwnershipTransferredIterator{contract: _SimpleQUIVToken.contract, event: "OwnershipTransferred", logs: logs, sub: sub}, nil
+}
+
+// WatchOwnershipTransferred is a free log subscription operation binding the contract event 0x8be0079c531659141344cd1fd0a4f28419497f9722a3daafe3b4186f6b6457e0.
+//
+// Solidity: event OwnershipTransferred(address indexed previousOwner, address indexed newOwner)
+func (_SimpleQUIVToken *SimpleQUIVTokenFilterer) WatchOwnershipTransferred(opts *bind.WatchOpts, sink chan<- *SimpleQUIVTokenOwnershipTransferred, previousOwner []common.Address, newOwner []common.Address) (event.Subscription, error) {
+
+	var previousOwnerRule []interface{}
+	for _, previousOwnerItem := range previousOwner {
+		previousOwnerRule = append(previousOwnerRule, previousOwnerItem)
+	}
+	var newOwnerRule []interface{}
+	for _, newOwnerItem := range newOwner {
+		newOwnerRule = append(newOwnerRule, newOwnerItem)
+	}
+
+	logs, sub, err := _SimpleQUIVToken.contract.WatchLogs(opts, "OwnershipTransferred", previousOwnerRule, newOwnerRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(SimpleQUIVTokenOwnershipTransferred)
+				if err := _SimpleQUIVToken.contract.UnpackLog(event, "OwnershipTransferred", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseOwnershipTransferred is a log parse operation binding the contract event 0x8be0079c531659141344cd1fd0a4f28419497f9722a3daafe3b4186f6b6457e0.
+//
+// Solidity: event OwnershipTransferred(address indexed previousOwner, address indexed newOwner)
+func (_SimpleQUIVToken *SimpleQUIVTokenFilterer) ParseOwnershipTransferred(log types.Log) (*SimpleQUIVTokenOwnershipTransferred, error) {
+	event := new(SimpleQUIVTokenOwnershipTransferred)
+	if err := _SimpleQUIVToken.contract.UnpackLog(event, "OwnershipTransferred", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// SimpleQUIVTokenTransferIterator is returned from FilterTransfer and is used to iterate over the raw logs and unpacked data for Transfer events raised by the SimpleQUIVToken contract.
+type SimpleQUIVTokenTransferIterator struct {
+	Event *SimpleQUIVTokenTransfer // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *SimpleQUIVTokenTransferIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(SimpleQUIVTokenTransfer)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(SimpleQUIVTokenTransfer)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *SimpleQUIVTokenTransferIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *SimpleQUIVTokenTransferIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// SimpleQUIVTokenTransfer represents a Transfer event raised by the SimpleQUIVToken contract.
+type SimpleQUIVTokenTransfer struct {
+	From  common.Address
+	To    common.Address
+	Value *big.Int
+	Raw   types.Log // Blockchain specific contextual infos
+}
+
+// FilterTransfer is a free log retrieval operation binding the contract event 0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef.
+//
+// Solidity: event Transfer(address indexed from, address indexed to, uint256 value)
+func (_SimpleQUIVToken *SimpleQUIVTokenFilterer) FilterTransfer(opts *bind.FilterOpts, from []common.Address, to []common.Address) (*SimpleQUIVTokenTransferIterator, error) {
+
+	var fromRule []interface{}
+	for _, fromItem := range from {
+		fromRule = append(fromRule, fromItem)
+	}
+	var toRule []interface{}
+	for _, toItem := range to {
+		toRule = append(toRule, toItem)
+	}
+
+	logs, sub, err := _SimpleQUIVToken.contract.FilterLogs(opts, "Transfer", fromRule, toRule)
+	if err != nil {
+		return nil, err
+	}
+	return &SimpleQUIVTokenTransferIterator{contract: _SimpleQUIVToken.contract, event: "Transfer", logs: logs, sub: sub}, nil
+}
+
+// WatchTransfer is a free log subscription operation binding the contract event 0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef.
+//
+// Solidity: event Transfer(address indexed from, address indexed to, uint256 value)
+func (_SimpleQUIVToken *SimpleQUIVTokenFilterer) WatchTransfer(opts *bind.WatchOpts, sink chan<- *SimpleQUIVTokenTransfer, from []common.Address, to []common.Address) (event.Subscription, error) {
+
+	var fromRule []interface{}
+	for _, fromItem := range from {
+		fromRule = append(fromRule, fromItem)
+	}
+	var toRule []interface{}
+	for _, toItem := range to {
+		toRule = append(toRule, toItem)
+	}
+
+	logs, sub, err := _SimpleQUIVToken.contract.WatchLogs(opts, "Transfer", fromRule, toRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(SimpleQUIVTokenTransfer)
+				if err := _SimpleQUIVToken.contract.UnpackLog(event, "Transfer", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseTransfer is a log parse operation binding the contract event 0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef.
+//
+// Solidity: event Transfer(address indexed from, address indexed to, uint256 value)
+func (_SimpleQUIVToken *SimpleQUIVTokenFilterer) ParseTransfer(log types.Log) (*SimpleQUIVTokenTransfer, error) {
+	event := new(SimpleQUIVTokenTransfer)
+	if err := _SimpleQUIVToken.contract.UnpackLog(event, "Transfer", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// SimpleSettlementMetaData contains all meta data concerning the SimpleSettlement contract.
+var SimpleSettlementMetaData = &bind.MetaData{
+	ABI: "[{\"inputs\":[{\"internalType\":\"address\",\"name\":\"_token\",\"type\":\"address\"}],\"stateMutability\":\"nonpayable\",\"type\":\"constructor\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"}],\"name\":\"OwnableInvalidOwner\",\"type\":\"error\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"account\",\"type\":\"address\"}],\"name\":\"OwnableUnauthorizedAccount\",\"type\":\"error\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"epoch\",\"type\":\"uint256\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"provider\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"leafCount\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"}],\"name\":\"ClaimProcessed\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"bytes32\",\"name\":\"providerKey\",\"type\":\"bytes32\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"openDisputes\",\"type\":\"uint256\"}],\"name\":\"DisputeClosed\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"bytes32\",\"name\":\"providerKey\",\"type\":\"bytes32\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"openDisputes\",\"type\":\"uint256\"}],\"name\":\"DisputeOpened\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"previousOwner\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"newOwner\",\"type\":\"address\"}],\"name\":\"OwnershipTransferred\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"epoch\",\"type\":\"uint256\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"provider\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"receiptCount\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"bytes32\",\"name\":\"receiptsRoot\",\"type\":\"bytes32\"}],\"name\":\"PayoutClaimed\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"epoch\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"bytes32\",\"name\":\"root\",\"type\":\"bytes32\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"treeSize\",\"type\":\"uint256\"}],\"name\":\"PayoutRootCommitted\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"epoch\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"leafIndex\",\"type\":\"uint256\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"provider\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"}],\"name\":\"ReceiptProcessed\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"epoch\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"bytes32\",\"name\":\"root\",\"type\":\"bytes32\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"treeSize\",\"type\":\"uint256\"}],\"name\":\"RootCommitted\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"bytes32\",\"name\":\"providerKey\",\"type\":\"bytes32\"},{\"indexed\":true,\"internalType\":\"bytes32\",\"name\":\"evidenceHash\",\"type\":\"bytes32\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"remaining\",\"type\":\"uint256\"}],\"name\":\"Slashed\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"minStake\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"unbondingPeriod\",\"type\":\"uint256\"}],\"name\":\"StakeParamsSet\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"bytes32\",\"name\":\"providerKey\",\"type\":\"bytes32\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"}],\"name\":\"StakeWithdrawn\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"bytes32\",\"name\":\"providerKey\",\"type\":\"bytes32\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"string[]\",\"name\":\"models\",\"type\":\"string[]\"}],\"name\":\"Staked\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"bytes32\",\"name\":\"providerKey\",\"type\":\"bytes32\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"unbondingAt\",\"type\":\"uint256\"}],\"name\":\"UnbondRequested\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"provider\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"}],\"name\":\"Withdrawn\",\"type\":\"event\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"epoch\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"receiptCount\",\"type\":\"uint256\"},{\"internalType\":\"bytes32\",\"name\":\"receiptsRoot\",\"type\":\"bytes32\"},{\"internalType\":\"uint256\",\"name\":\"leafIndex\",\"type\":\"uint256\"},{\"internalType\":\"bytes32[]\",\"name\":\"proof\",\"type\":\"bytes32[]\"}],\"name\":\"claimPayout\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"name\":\"claimedLeaves\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"providerKey\",\"type\":\"bytes32\"}],\"name\":\"closeDispute\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"epoch\",\"type\":\"uint256\"},{\"internalType\":\"bytes32\",\"name\":\"root\",\"type\":\"bytes32\"},{\"internalType\":\"uint256\",\"name\":\"treeSize\",\"type\":\"uint256\"}],\"name\":\"commitPayoutRoot\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"epoch\",\"type\":\"uint256\"},{\"internalType\":\"bytes32\",\"name\":\"root\",\"type\":\"bytes32\"},{\"internalType\":\"uint256\",\"name\":\"treeSize\",\"type\":\"uint256\"}],\"name\":\"commitRoot\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"name\":\"epochRoots\",\"outputs\":[{\"internalType\":\"bytes32\",\"name\":\"\",\"type\":\"bytes32\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"name\":\"epochTreeSizes\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"providerKey\",\"type\":\"bytes32\"}],\"name\":\"getStake\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"stakeOwner\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"unbondingAt\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"openDisputes\",\"type\":\"uint256\"},{\"internalType\":\"string[]\",\"name\":\"models\",\"type\":\"string[]\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"minStake\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"providerKey\",\"type\":\"bytes32\"}],\"name\":\"openDispute\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"owner\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"},{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"name\":\"payoutClaimed\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"name\":\"payoutRoots\",\"outputs\":[{\"internalType\":\"bytes32\",\"name\":\"\",\"type\":\"bytes32\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"name\":\"payoutTreeSizes\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"epoch\",\"type\":\"uint256\"},{\"internalType\":\"uint256[]\",\"name\":\"leafIndices\",\"type\":\"uint256[]\"},{\"internalType\":\"bytes32[]\",\"name\":\"leafHashes\",\"type\":\"bytes32[]\"},{\"internalType\":\"bytes32[]\",\"name\":\"proof\",\"type\":\"bytes32[]\"},{\"internalType\":\"address\",\"name\":\"provider\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"}],\"name\":\"processClaim\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"epoch\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"leafIndex\",\"type\":\"uint256\"},{\"internalType\":\"bytes32\",\"name\":\"leafHash\",\"type\":\"bytes32\"},{\"internalType\":\"bytes32[]\",\"name\":\"proof\",\"type\":\"bytes32[]\"},{\"internalType\":\"address\",\"name\":\"provider\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"}],\"name\":\"processReceipt\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"name\":\"providerBalances\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"},{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"name\":\"receiptsPaid\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"renounceOwnership\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"providerKey\",\"type\":\"bytes32\"}],\"name\":\"requestUnbond\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"_minStake\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"_unbondingPeriod\",\"type\":\"uint256\"}],\"name\":\"setStakeParams\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"providerKey\",\"type\":\"bytes32\"},{\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"},{\"internalType\":\"bytes32\",\"name\":\"evidenceHash\",\"type\":\"bytes32\"}],\"name\":\"slash\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"\",\"type\":\"bytes32\"}],\"name\":\"slashedEvidence\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"providerKey\",\"type\":\"bytes32\"},{\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"},{\"internalType\":\"string[]\",\"name\":\"models\",\"type\":\"string[]\"}],\"name\":\"stake\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"token\",\"outputs\":[{\"internalType\":\"contractIERC20\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"newOwner\",\"type\":\"address\"}],\"name\":\"transferOwnership\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"unbondingPeriod\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"epoch\",\"type\":\"uint256\"},{\"internalType\":\"uint256[]\",\"name\":\"leafIndices\",\"type\":\"uint256[]\"},{\"internalType\":\"bytes32[]\",\"name\":\"leafHashes\",\"type\":\"bytes32[]\"},{\"internalType\":\"bytes32[]\",\"name\":\"proof\",\"type\":\"bytes32[]\"}],\"name\":\"verifyClaim\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"withdraw\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"providerKey\",\"type\":\"bytes32\"}],\"name\":\"withdrawStake\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"}]",
+}
+
+// SimpleSettlementABI is the input ABI used to generate the binding from.
+// Deprecated: Use SimpleSettlementMetaData.ABI instead.
+var SimpleSettlementABI = SimpleSettlementMetaData.ABI
+
+// SimpleSettlement is an auto generated Go binding around an Ethereum contract.
+type SimpleSettlement struct {
+	SimpleSettlementCaller     // Read-only binding to the contract
+	SimpleSettlementTransactor // Write-only binding to the contract
+	SimpleSettlementFilterer   // Log filterer for contract events
+}
+
+// SimpleSettlementCaller is an auto generated read-only Go binding around an Ethereum contract.
+type SimpleSettlementCaller struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// SimpleSettlementTransactor is an auto generated write-only Go binding around an Ethereum contract.
+type SimpleSettlementTransactor struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// SimpleSettlementFilterer is an auto generated log filtering Go binding around an Ethereum contract events.
+type SimpleSettlementFilterer struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// SimpleSettlementSession is an auto generated Go binding around an Ethereum contract,
+// with pre-set call and transact options.
+type SimpleSettlementSession struct {
+	Contract     *SimpleSettlement // Generic contract binding to set the session for
+	CallOpts     bind.CallOpts     // Call options to use throughout this session
+	TransactOpts bind.TransactOpts // Transaction auth options to use throughout this session
+}
+
+// SimpleSettlementCallerSession is an auto generated read-only Go binding around an Ethereum contract,
+// with pre-set call options.
+type SimpleSettlementCallerSession struct {
+	Contract *SimpleSettlementCaller // Generic contract caller binding to set the session for
+	CallOpts bind.CallOpts           // Call options to use throughout this session
+}
+
+// SimpleSettlementTransactorSession is an auto generated write-only Go binding around an Ethereum contract,
+// with pre-set transact options.
+type SimpleSettlementTransactorSession struct {
+	Contract     *SimpleSettlementTransactor // Generic contract transactor binding to set the session for
+	TransactOpts bind.TransactOpts           // Transaction auth options to use throughout this session
+}
+
+// SimpleSettlementRaw is an auto generated low-level Go binding around an Ethereum contract.
+type SimpleSettlementRaw struct {
+	Contract *SimpleSettlement // Generic contract binding to access the raw methods on
+}
+
+// SimpleSettlementCallerRaw is an auto generated low-level read-only Go binding around an Ethereum contract.
+type SimpleSettlementCallerRaw struct {
+	Contract *SimpleSettlementCaller // Generic read-only contract binding to access the raw methods on
+}
+
+// SimpleSettlementTransactorRaw is an auto generated low-level write-only Go binding around an Ethereum contract.
+type SimpleSettlementTransactorRaw struct {
+	Contract *SimpleSettlementTransactor // Generic write-only contract binding to access the raw methods on
+}
+
+// NewSimpleSettlement creates a new instance of SimpleSettlement, bound to a specific deployed contract.
+func NewSimpleSettlement(address common.Address, backend bind.ContractBackend) (*SimpleSettlement, error) {
+	contract, err := bindSimpleSettlement(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &SimpleSettlement{SimpleSettlementCaller: SimpleSettlementCaller{contract: contract}, SimpleSettlementTransactor: SimpleSettlementTransactor{contract: contract}, SimpleSettlementFilterer: SimpleSettlementFilterer{contract: contract}}, nil
+}
+
+// NewSimpleSettlementCaller creates a new read-only instance of SimpleSettlement, bound to a specific deployed contract.
+func NewSimpleSettlementCaller(address common.Address, caller bind.ContractCaller) (*SimpleSettlementCaller, error) {
+	contract, err := bindSimpleSettlement(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &SimpleSettlementCaller{contract: contract}, nil
+}
+
+// NewSimpleSettlementTransactor creates a new write-only instance of SimpleSettlement, bound to a specific deployed contract.
+func NewSimpleSettlementTransactor(address common.Address, transactor bind.ContractTransactor) (*SimpleSettlementTransactor, error) {
+	contract, err := bindSimpleSettlement(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &SimpleSettlementTransactor{contract: contract}, nil
+}
+
+// NewSimpleSettlementFilterer creates a new log filterer instance of SimpleSettlement, bound to a specific deployed contract.
+func NewSimpleSettlementFilterer(address common.Address, filterer bind.ContractFilterer) (*SimpleSettlementFilterer, error) {
+	contract, err := bindSimpleSettlement(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &SimpleSettlementFilterer{contract: contract}, nil
+}
+
+// bindSimpleSettlement binds a generic wrapper to an already deployed contract.
+func bindSimpleSettlement(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := SimpleSettlementMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, *parsed, caller, transactor, filterer), nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_SimpleSettlement *SimpleSettlementRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _SimpleSettlement.Contract.SimpleSettlementCaller.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_SimpleSettlement *SimpleSettlementRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _SimpleSettlement.Contract.SimpleSettlementTransactor.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_SimpleSettlement *SimpleSettlementRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _SimpleSettlement.Contract.SimpleSettlementTransactor.contract.Transact(opts, method, params...)
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_SimpleSettlement *SimpleSettlementCallerRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _SimpleSettlement.Contract.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_SimpleSettlement *SimpleSettlementTransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _SimpleSettlement.Contract.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_SimpleSettlement *SimpleSettlementTransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _SimpleSettlement.Contract.contract.Transact(opts, method, params...)
+}
+
+// ClaimedLeaves is a free data retrieval call binding the contract method 0x2f759c0b.
+//
+// Solidity: function claimedLeaves(uint256 , uint256 ) view returns(bool)
+func (_SimpleSettlement *SimpleSettlementCaller) ClaimedLeaves(opts *bind.CallOpts, arg0 *big.Int, arg1 *big.Int) (bool, error) {
+	var out []interface{}
+	err := _SimpleSettlement.contract.Call(opts, &out, "claimedLeaves", arg0, arg1)
+
+	if err != nil {
+		return *new(bool), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(bool)).(*bool)
+
+	return out0, err
+
+}
+
+// ClaimedLeaves is a free data retrieval call binding the contract method 0x2f759c0b.
+//
+// Solidity: function claimedLeaves(uint256 , uint256 ) view returns(bool)
+func (_SimpleSettlement *SimpleSettlementSession) ClaimedLeaves(arg0 *big.Int, arg1 *big.Int) (bool, error) {
+	return _SimpleSettlement.Contract.ClaimedLeaves(&_SimpleSettlement.CallOpts, arg0, arg1)
+}
+
+// ClaimedLeaves is a free data retrieval call binding the contract method 0x2f759c0b.
+//
+// Solidity: function claimedLeaves(uint256 , uint256 ) view returns(bool)
+func (_SimpleSettlement *SimpleSettlementCallerSession) ClaimedLeaves(arg0 *big.Int, arg1 *big.Int) (bool, error) {
+	return _SimpleSettlement.Contract.ClaimedLeaves(&_SimpleSettlement.CallOpts, arg0, arg1)
+}
+
+// EpochRoots is a free data retrieval call binding the contract method 0x55deccc1.
+//
+// Solidity: function epochRoots(uint256 ) view returns(bytes32)
+func (_SimpleSettlement *SimpleSettlementCaller) EpochRoots(opts *bind.CallOpts, arg0 *big.Int) ([32]byte, error) {
+	var out []interface{}
+	err := _SimpleSettlement.contract.Call(opts, &out, "epochRoots", arg0)
+
+	if err != nil {
+		return *new([32]byte), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new([32]byte)).(*[32]byte)
+
+	return out0, err
+
+}
+
+// EpochRoots is a free data retrieval call binding the contract method 0x55deccc1.
+//
+// Solidity: function epochRoots(uint256 ) view returns(bytes32)
+func (_SimpleSettlement *SimpleSettlementSession) EpochRoots(arg0 *big.Int) ([32]byte, error) {
+	return _SimpleSettlement.Contract.EpochRoots(&_SimpleSettlement.CallOpts, arg0)
+}
+
+// EpochRoots is a free data retrieval call binding the contract method 0x55deccc1.
+//
+// Solidity: function epochRoots(uint256 ) view returns(bytes32)
+func (_SimpleSettlement *SimpleSettlementCallerSession) EpochRoots(arg0 *big.Int) ([32]byte, error) {
+	return _SimpleSettlement.Contract.EpochRoots(&_SimpleSettlement.CallOpts, arg0)
+}
+
+// EpochTreeSizes is a free data retrieval call binding the contract method 0xbaeead13.
+//
+// Solidity: function epochTreeSizes(uint256 ) view returns(uint256)
+func (_SimpleSettlement *SimpleSettlementCaller) EpochTreeSizes(opts *bind.CallOpts, arg0 *big.Int) (*big.Int, error) {
+	var out []interface{}
+	err := _SimpleSettlement.contract.Call(opts, &out, "epochTreeSizes", arg0)
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// EpochTreeSizes is a free data retrieval call binding the contract method 0xbaeead13.
+//
+// Solidity: function epochTreeSizes(uint256 ) view returns(uint256)
+func (_SimpleSettlement *SimpleSettlementSession) EpochTreeSizes(arg0 *big.Int) (*big.Int, error) {
+	return _SimpleSettlement.Contract.EpochTreeSizes(&_SimpleSettlement.CallOpts, arg0)
+}
+
+// EpochTreeSizes is a free data retrieval call binding the contract method 0xbaeead13.
+//
+// Solidity: function epochTreeSizes(uint256 ) view returns(uint256)
+func (_SimpleSettlement *SimpleSettlementCallerSession) EpochTreeSizes(arg0 *big.Int) (*big.Int, error) {
+	return _SimpleSettlement.Contract.EpochTreeSizes(&_SimpleSettlement.CallOpts, arg0)
+}
+
+// GetStake is a free data retrieval call binding the contract method 0xe765c122.
+//
+// Solidity: function getStake(bytes32 providerKey) view returns(address stakeOwner, uint256 amount, uint256 unbondingAt, uint256 openDisputes, string[] models)
+func (_SimpleSettlement *SimpleSettlementCaller) GetStake(opts *bind.CallOpts, providerKey [32]byte) (struct {
+	StakeOwner   common.Address
+	Amount       *big.Int
+	UnbondingAt  *big.Int
+	OpenDisputes *big.Int
+	Models       []string
+}, error) {
+	var out []interface{}
+	err := _SimpleSettlement.contract.Call(opts, &out, "getStake", providerKey)
+
+	outstruct := new(struct {
+		StakeOwner   common.Address
+		Amount       *big.Int
+		UnbondingAt  *big.Int
+		OpenDisputes *big.Int
+		Models       []string
+	})
+	if err != nil {
+		return *outstruct, err
+	}
+
+	outstruct.StakeOwner = *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+	outstruct.Amount = *abi.ConvertType(out[1], new(*big.Int)).(**big.Int)
+	outstruct.UnbondingAt = *abi.ConvertType(out[2], new(*big.Int)).(**big.Int)
+	outstruct.OpenDisputes = *abi.ConvertType(out[3], new(*big.Int)).(**big.Int)
+	outstruct.Models = *abi.ConvertType(out[4], new([]string)).(*[]string)
+
+	return *outstruct, err
+
+}
+
+// GetStake is a free data retrieval call binding the contract method 0xe765c122.
+//
+// Solidity: function getStake(bytes32 providerKey) view returns(address stakeOwner, uint256 amount, uint256 unbondingAt, uint256 openDisputes, string[] models)
+func (_SimpleSettlement *SimpleSettlementSession) GetStake(providerKey [32]byte) (struct {
+	StakeOwner   common.Address
+	Amount       *big.Int
+	UnbondingAt  *big.Int
+	OpenDisputes *big.Int
+	Models       []string
+}, error) {
+	return _SimpleSettlement.Contract.GetStake(&_SimpleSettlement.CallOpts, providerKey)
+}
+
+// GetStake is a free data retrieval call binding the contract method 0xe765c122.
+//
+// Solidity: function getStake(bytes32 providerKey) view returns(address stakeOwner, uint256 amount, uint256 unbondingAt, uint256 openDisputes, string[] models)
+func (_SimpleSettlement *SimpleSettlementCallerSession) GetStake(providerKey [32]byte) (struct {
+	StakeOwner   common.Address
+	Amount       *big.Int
+	UnbondingAt  *big.Int
+	OpenDisputes *big.Int
+	Models       []string
+}, error) {
+	return _SimpleSettlement.Contract.GetStake(&_SimpleSettlement.CallOpts, providerKey)
+}
+
+// MinStake is a free data retrieval call binding the contract method 0x375b3c0a.
+//
+// Solidity: function minStake() view returns(uint256)
+func (_SimpleSettlement *SimpleSettlementCaller) MinStake(opts *bind.CallOpts) (*big.Int, error) {
+	var out []interface{}
+	err := _SimpleSettlement.contract.Call(opts, &out, "minStake")
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// MinStake is a free data retrieval call binding the contract method 0x375b3c0a.
+//
+// Solidity: function minStake() view returns(uint256)
+func (_SimpleSettlement *SimpleSettlementSession) MinStake() (*big.Int, error) {
+	return _SimpleSettlement.Contract.MinStake(&_SimpleSettlement.CallOpts)
+}
+
+// MinStake is a free data retrieval call binding the contract method 0x375b3c0a.
+//
+// Solidity: function minStake() view returns(uint256)
+func (_SimpleSettlement *SimpleSettlementCallerSession) MinStake() (*big.Int, error) {
+	return _SimpleSettlement.Contract.MinStake(&_SimpleSettlement.CallOpts)
+}
+
+// Owner is a free data retrieval call binding the contract method 0x8da5cb5b.
+//
+// Solidity: function owner() view returns(address)
+func (_SimpleSettlement *SimpleSettlementCaller) Owner(opts *bind.CallOpts) (common.Address, error) {
+	var out []interface{}
+	err := _SimpleSettlement.contract.Call(opts, &out, "owner")
+
+	if err != nil {
+		return *new(common.Address), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+
+	return out0, err
+
+}
+
+// Owner is a free data retrieval call binding the contract method 0x8da5cb5b.
+//
+// Solidity: function owner() view returns(address)
+func (_SimpleSettlement *SimpleSettlementSession) Owner() (common.Address, error) {
+	return _SimpleSettlement.Contract.Owner(&_SimpleSettlement.CallOpts)
+}
+
+// Owner is a free data retrieval call binding the contract method 0x8da5cb5b.
+//
+// Solidity: function owner() view returns(address)
+func (_SimpleSettlement *SimpleSettlementCallerSession) Owner() (common.Address, error) {
+	return _SimpleSettlement.Contract.Owner(&_SimpleSettlement.CallOpts)
+}
+
+// PayoutClaimed is a free data retrieval call binding the contract method 0xdda8744d.
+//
+// Solidity: function payoutClaimed(uint256 , address ) view returns(bool)
+func (_SimpleSettlement *SimpleSettlementCaller) PayoutClaimed(opts *bind.CallOpts, arg0 *big.Int, arg1 common.Address) (bool, error) {
+	var out []interface{}
+	err := _SimpleSettlement.contract.Call(opts, &out, "payoutClaimed", arg0, arg1)
+
+	if err != nil {
+		return *new(bool), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(bool)).(*bool)
+
+	return out0, err
+
+}
+
+// PayoutClaimed is a free data retrieval call binding the contract method 0xdda8744d.
+//
+// Solidity: function payoutClaimed(uint256 , address ) view returns(bool)
+func (_SimpleSettlement *SimpleSettlementSession) PayoutClaimed(arg0 *big.Int, arg1 common.Address) (bool, error) {
+	return _SimpleSettlement.Contract.PayoutClaimed(&_SimpleSettlement.CallOpts, arg0, arg1)
+}
+
+// PayoutClaimed is a free data retrieval call binding the contract method 0xdda8744d.
+//
+// Solidity: function payoutClaimed(uint256 , address ) view returns(bool)
+func (_SimpleSettlement *SimpleSettlementCallerSession) PayoutClaimed(arg0 *big.Int, arg1 common.Address) (bool, error) {
+	return _SimpleSettlement.Contract.PayoutClaimed(&_SimpleSettlement.CallOpts, arg0, arg1)
+}
+
+// PayoutRoots is a free data retrieval call binding the contract method 0x70baab6c.
+//
+// Solidity: function payoutRoots(uint256 ) view returns(bytes32)
+func (_SimpleSettlement *SimpleSettlementCaller) PayoutRoots(opts *bind.CallOpts, arg0 *big.Int) ([32]byte, error) {
+	var out []interface{}
+	err := _SimpleSettlement.contract.Call(opts, &out, "payoutRoots", arg0)
+
+	if err != nil {
+		return *new([32]byte), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new([32]byte)).(*[32]byte)
+
+	return out0, err
+
+}
+
+// PayoutRoots is a free data retrieval call binding the contract method 0x70baab6c.
+//
+// Solidity: function payoutRoots(uint256 ) view returns(bytes32)
+func (_SimpleSettlement *SimpleSettlementSession) PayoutRoots(arg0 *big.Int) ([32]byte, error) {
+	return _SimpleSettlement.Contract.PayoutRoots(&_SimpleSettlement.CallOpts, arg0)
+}
+
+// PayoutRoots is a free data retrieval call binding the contract method 0x70baab6c.
+//
+// Solidity: function payoutRoots(uint256 ) view returns(bytes32)
+func (_SimpleSettlement *SimpleSettlementCallerSession) PayoutRoots(arg0 *big.Int) ([32]byte, error) {
+	return _SimpleSettlement.Contract.PayoutRoots(&_SimpleSettlement.CallOpts, arg0)
+}
+
+// PayoutTreeSizes is a free data retrieval call binding the contract method 0x9644e360.
+//
+// Solidity: function payoutTreeSizes(uint256 ) view returns(uint256)
+func (_SimpleSettlement *SimpleSettlementCaller) PayoutTreeSizes(opts *bind.CallOpts, arg0 *big.Int) (*big.Int, error) {
+	var out []interface{}
+	err := _SimpleSettlement.contract.Call(opts, &out, "payoutTreeSizes", arg0)
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// PayoutTreeSizes is a free data retrieval call binding the contract method 0x9644e360.
+//
+// Solidity: function payoutTreeSizes(uint256 ) view returns(uint256)
+func (_SimpleSettlement *SimpleSettlementSession) PayoutTreeSizes(arg0 *big.Int) (*big.Int, error) {
+	return _SimpleSettlement.Contract.PayoutTreeSizes(&_SimpleSettlement.CallOpts, arg0)
+}
+
+// PayoutTreeSizes is a free data retrieval call binding the contract method 0x9644e360.
+//
+// Solidity: function payoutTreeSizes(uint256 ) view returns(uint256)
+func (_SimpleSettlement *SimpleSettlementCallerSession) PayoutTreeSizes(arg0 *big.Int) (*big.Int, error) {
+	return _SimpleSettlement.Contract.PayoutTreeSizes(&_SimpleSettlement.CallOpts, arg0)
+}
+
+// ProviderBalances is a free data retrieval call binding the contract method 0x28ac7f2c.
+//
+// Solidity: function providerBalances(address ) view returns(uint256)
+func (_SimpleSettlement *SimpleSettlementCaller) ProviderBalances(opts *bind.CallOpts, arg0 common.Address) (*big.Int, error) {
+	var out []interface{}
+	err := _SimpleSettlement.contract.Call(opts, &out, "providerBalances", arg0)
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// ProviderBalances is a free data retrieval call binding the contract method 0x28ac7f2c.
+//
+// Solidity: function providerBalances(address ) view returns(uint256)
+func (_SimpleSettlement *SimpleSettlementSession) ProviderBalances(arg0 common.Address) (*big.Int, error) {
+	return _SimpleSettlement.Contract.ProviderBalances(&_SimpleSettlement.CallOpts, arg0)
+}
+
+// ProviderBalances is a free data retrieval call binding the contract method 0x28ac7f2c.
+//
+// Solidity: function providerBalances(address ) view returns(uint256)
+func (_SimpleSettlement *SimpleSettlementCallerSession) ProviderBalances(arg0 common.Address) (*big.Int, error) {
+	return _SimpleSettlement.Contract.ProviderBalances(&_SimpleSettlement.CallOpts, arg0)
+}
+
+// ReceiptsPaid is a free data retrieval call binding the contract method 0x4d74adb4.
+//
+// Solidity: function receiptsPaid(uint256 , address ) view returns(bool)
+func (_SimpleSettlement *SimpleSettlementCaller) ReceiptsPaid(opts *bind.CallOpts, arg0 *big.Int, arg1 common.Address) (bool, error) {
+	var out []interface{}
+	err := _SimpleSettlement.contract.Call(opts, &out, "receiptsPaid", arg0, arg1)
+
+	if err != nil {
+		return *new(bool), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(bool)).(*bool)
+
+	return out0, err
+
+}
+
+// ReceiptsPaid is a free data retrieval call binding the contract method 0x4d74adb4.
+//
+// Solidity: function receiptsPaid(uint256 , address ) view returns(bool)
+func (_SimpleSettlement *SimpleSettlementSession) ReceiptsPaid(arg0 *big.Int, arg1 common.Address) (bool, error) {
+	return _SimpleSettlement.Contract.ReceiptsPaid(&_SimpleSettlement.CallOpts, arg0, arg1)
+}
+
+// ReceiptsPaid is a free data retrieval call binding the contract method 0x4d74adb4.
+//
+// Solidity: function receiptsPaid(uint256 , address ) view returns(bool)
+func (_SimpleSettlement *SimpleSettlementCallerSession) ReceiptsPaid(arg0 *big.Int, arg1 common.Address) (bool, error) {
+	return _SimpleSettlement.Contract.ReceiptsPaid(&_SimpleSettlement.CallOpts, arg0, arg1)
+}
+
+// SlashedEvidence is a free data retrieval call binding the contract method 0xf6fb1202.
+//
+// Solidity: function slashedEvidence(bytes32 ) view returns(bool)
+func (_SimpleSettlement *SimpleSettlementCaller) SlashedEvidence(opts *bind.CallOpts, arg0 [32]byte) (bool, error) {
+	var out []interface{}
+	err := _SimpleSettlement.contract.Call(opts, &out, "slashedEvidence", arg0)
+
+	if err != nil {
+		return *new(bool), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(bool)).(*bool)
+
+	return out0, err
+
+}
+
+// SlashedEvidence is a free data retrieval call binding the contract method 0xf6fb1202.
+//
+// Solidity: function slashedEvidence(bytes32 ) view returns(bool)
+func (_SimpleSettlement *SimpleSettlementSession) SlashedEvidence(arg0 [32]byte) (bool, error) {
+	return _SimpleSettlement.Contract.SlashedEvidence(&_SimpleSettlement.CallOpts, arg0)
+}
+
+// SlashedEvidence is a free data retrieval call binding the contract method 0xf6fb1202.
+//
+// Solidity: function slashedEvidence(bytes32 ) view returns(bool)
+func (_SimpleSettlement *SimpleSettlementCallerSession) SlashedEvidence(arg0 [32]byte) (bool, error) {
+	return _SimpleSettlement.Contract.SlashedEvidence(&_SimpleSettlement.CallOpts, arg0)
+}
+
+// Token is a free data retrieval call binding the contract method 0xfc0c546a.
+//
+// Solidity: function token() view returns(address)
+func (_SimpleSettlement *SimpleSettlementCaller) Token(opts *bind.CallOpts) (common.Address, error) {
+	var out []interface{}
+	err := _SimpleSettlement.contract.Call(opts, &out, "token")
+
+	if err != nil {
+		return *new(common.Address), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+
+	return out0, err
+
+}
+
+// Token is a free data retrieval call binding the contract method 0xfc0c546a.
+//
+// Solidity: function token() view returns(address)
+func (_SimpleSettlement *SimpleSettlementSession) Token() (common.Address, error) {
+	return _SimpleSettlement.Contract.Token(&_SimpleSettlement.CallOpts)
+}
+
+// Token is a free data retrieval call binding the contract method 0xfc0c546a.
+//
+// Solidity: function token() view returns(address)
+func (_SimpleSettlement *SimpleSettlementCallerSession) Token() (common.Address, error) {
+	return _SimpleSettlement.Contract.Token(&_SimpleSettlement.CallOpts)
+}
+
+// UnbondingPeriod is a free data retrieval call binding the contract method 0x6cf6d675.
+//
+// Solidity: function unbondingPeriod() view returns(uint256)
+func (_SimpleSettlement *SimpleSettlementCaller) UnbondingPeriod(opts *bind.CallOpts) (*big.Int, error) {
+	var out []interface{}
+	err := _SimpleSettlement.contract.Call(opts, &out, "unbondingPeriod")
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// UnbondingPeriod is a free data retrieval call binding the contract method 0x6cf6d675.
+//
+// Solidity: function unbondingPeriod() view returns(uint256)
+func (_SimpleSettlement *SimpleSettlementSession) UnbondingPeriod() (*big.Int, error) {
+	return _SimpleSettlement.Contract.UnbondingPeriod(&_SimpleSettlement.CallOpts)
+}
+
+// UnbondingPeriod is a free data retrieval call binding the contract method 0x6cf6d675.
+//
+// Solidity: function unbondingPeriod() view returns(uint256)
+func (_SimpleSettlement *SimpleSettlementCallerSession) UnbondingPeriod() (*big.Int, error) {
+	return _SimpleSettlement.Contract.UnbondingPeriod(&_SimpleSettlement.CallOpts)
+}
+
+// VerifyClaim is a free data retrieval call binding the contract method 0xcbd02861.
+//
+// Solidity: function verifyClaim(uint256 epoch, uint256[] leafIndices, bytes32[] leafHashes, bytes32[] proof) view returns(bool)
+func (_SimpleSettlement *SimpleSettlementCaller) VerifyClaim(opts *bind.CallOpts, epoch *big.Int, leafIndices []*big.Int, leafHashes [][32]byte, proof [][32]byte) (bool, error) {
+	var out []interface{}
+	err := _SimpleSettlement.contract.Call(opts, &out, "verifyClaim", epoch, leafIndices, leafHashes, proof)
+
+	if err != nil {
+		return *new(bool), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(bool)).(*bool)
+
+	return out0, err
+
+}
+
+// VerifyClaim is a free data retrieval call binding the contract method 0xcbd02861.
+//
+// Solidity: function verifyClaim(uint256 epoch, uint256[] leafIndices, bytes32[] leafHashes, bytes32[] proof) view returns(bool)
+func (_SimpleSettlement *SimpleSettlementSession) VerifyClaim(epoch *big.Int, leafIndices []*big.Int, leafHashes [][32]byte, proof [][32]byte) (bool, error) {
+	return _SimpleSettlement.Contract.VerifyClaim(&_SimpleSettlement.CallOpts, epoch, leafIndices, leafHashes, proof)
+}
+
+// VerifyClaim is a free data retrieval call binding the contract method 0xcbd02861.
+//
+// Solidity: function verifyClaim(uint256 epoch, uint256[] leafIndices, bytes32[] leafHashes, bytes32[] proof) view returns(bool)
+func (_SimpleSettlement *SimpleSettlementCallerSession) VerifyClaim(epoch *big.Int, leafIndices []*big.Int, leafHashes [][32]byte, proof [][32]byte) (bool, error) {
+	return _SimpleSettlement.Contract.VerifyClaim(&_SimpleSettlement.CallOpts, epoch, leafIndices, leafHashes, proof)
+}
+
+// ClaimPayout is a paid mutator transaction binding the contract method 0x22ee8135.
+//
+// Solidity: function claimPayout(uint256 epoch, uint256 amount, uint256 receiptCount, bytes32 receiptsRoot, uint256 leafIndex, bytes32[] proof) returns()
+func (_SimpleSettlement *SimpleSettlementTransactor) ClaimPayout(opts *bind.TransactOpts, epoch *big.Int, amount *big.Int, receiptCount *big.Int, receiptsRoot [32]byte, leafIndex *big.Int, proof [][32]byte) (*types.Transaction, error) {
+	return _SimpleSettlement.contract.Transact(opts, "claimPayout", epoch, amount, receiptCount, receiptsRoot, leafIndex, proof)
+}
+
+// ClaimPayout is a paid mutator transaction binding the contract method 0x22ee8135.
+//
+// Solidity: function claimPayout(uint256 epoch, uint256 amount, uint256 receiptCount, bytes32 receiptsRoot, uint256 leafIndex, bytes32[] proof) returns()
+func (_SimpleSettlement *SimpleSettlementSession) ClaimPayout(epoch *big.Int, amount *big.Int, receiptCount *big.Int, receiptsRoot [32]byte, leafIndex *big.Int, proof [][32]byte) (*types.Transaction, error) {
+	return _SimpleSettlement.Contract.ClaimPayout(&_SimpleSettlement.TransactOpts, epoch, amount, receiptCount, receiptsRoot, leafIndex, proof)
+}
+
+// ClaimPayout is a paid mutator transaction binding the contract method 0x22ee8135.
+//
+// Solidity: function claimPayout(uint256 epoch, uint256 amount, uint256 receiptCount, bytes32 receiptsRoot, uint256 leafIndex, bytes32[] proof) returns()
+func (_SimpleSettlement *SimpleSettlementTransactorSession) ClaimPayout(epoch *big.Int, amount *big.Int, receiptCount *big.Int, receiptsRoot [32]byte, leafIndex *big.Int, proof [][32]byte) (*types.Transaction, error) {
+	return _SimpleSettlement.Contract.ClaimPayout(&_SimpleSettlement.TransactOpts, epoch, amount, receiptCount, receiptsRoot, leafIndex, proof)
+}
+
+// CloseDispute is a paid mutator transaction binding the contract method 0x32b90520.
+//
+// Solidity: function closeDispute(bytes32 providerKey) returns()
+func (_SimpleSettlement *SimpleSettlementTransactor) CloseDispute(opts *bind.TransactOpts, providerKey [32]byte) (*types.Transaction, error) {
+	return _SimpleSettlement.contract.Transact(opts, "closeDispute", providerKey)
+}
+
+// CloseDispute is a paid mutator transaction binding the contract method 0x32b90520.
+//
+// Solidity: function closeDispute(bytes32 providerKey) returns()
+func (_SimpleSettlement *SimpleSettlementSession) CloseDispute(providerKey [32]byte) (*types.Transaction, error) {
+	return _SimpleSettlement.Contract.CloseDispute(&_SimpleSettlement.TransactOpts, providerKey)
+}
+
+// CloseDispute is a paid mutator transaction binding the contract method 0x32b90520.
+//
+// Solidity: function closeDispute(bytes32 providerKey) returns()
+func (_SimpleSettlement *SimpleSettlementTransactorSession) CloseDispute(providerKey [32]byte) (*types.Transaction, error) {
+	return _SimpleSettlement.Contract.CloseDispute(&_SimpleSettlement.TransactOpts, providerKey)
+}
+
+// CommitPayoutRoot is a paid mutator transaction binding the contract method 0x7fb4b795.
+//
+// Solidity: function commitPayoutRoot(uint256 epoch, bytes32 root, uint256 treeSize) returns()
+func (_SimpleSettlement *SimpleSettlementTransactor) CommitPayoutRoot(opts *bind.TransactOpts, epoch *big.Int, root [32]byte, treeSize *big.Int) (*types.Transaction, error) {
+	return _SimpleSettlement.contract.Transact(opts, "commitPayoutRoot", epoch, root, treeSize)
+}
+
+// CommitPayoutRoot is a paid mutator transaction binding the contract method 0x7fb4b795.
+//
+// Solidity: function commitPayoutRoot(uint256 epoch, bytes32 root, uint256 treeSize) returns()
+func (_SimpleSettlement *SimpleSettlementSession) CommitPayoutRoot(epoch *big.Int, root [32]byte, treeSize *big.Int) (*types.Transaction, error) {
+	return _SimpleSettlement.Contract.CommitPayoutRoot(&_SimpleSettlement.TransactOpts, epoch, root, treeSize)
+}
+
+// CommitPayoutRoot is a paid mutator transaction binding the contract method 0x7fb4b795.
+//
+// Solidity: function commitPayoutRoot(uint256 epoch, bytes32 root, uint256 treeSize) returns()
+func (_SimpleSettlement *SimpleSettlementTransactorSession) CommitPayoutRoot(epoch *big.Int, root [32]byte, treeSize *big.Int) (*types.Transaction, error) {
+	return _SimpleSettlement.Contract.CommitPayoutRoot(&_SimpleSettlement.TransactOpts, epoch, root, treeSize)
+}
+
+// CommitRoot is a paid mutator transaction binding the contract method 0x8cc719ff.
+//
+// Solidity: function commitRoot(uint256 epoch, bytes32 root, uint256 treeSize) returns()
+func (_SimpleSettlement *SimpleSettlementTransactor) CommitRoot(opts *bind.TransactOpts, epoch *big.Int, root [32]byte, treeSize *big.Int) (*types.Transaction, error) {
+	return _SimpleSettlement.contract.Transact(opts, "commitRoot", epoch, root, treeSize)
+}
+
+// CommitRoot is a paid mutator transaction binding the contract method 0x8cc719ff.
+//
+// Solidity: function commitRoot(uint256 epoch, bytes32 root, uint256 treeSize) returns()
+func (_SimpleSettlement *SimpleSettlementSession) CommitRoot(epoch *big.Int, root [32]byte, treeSize *big.Int) (*types.Transaction, error) {
+	return _SimpleSettlement.Contract.CommitRoot(&_SimpleSettlement.TransactOpts, epoch, root, treeSize)
+}
+
+// CommitRoot is a paid mutator transaction binding the contract method 0x8cc719ff.
+//
+// Solidity: function commitRoot(uint256 epoch, bytes32 root, uint256 treeSize) returns()
+func (_SimpleSettlement *SimpleSettlementTransactorSession) CommitRoot(epoch *big.Int, root [32]byte, treeSize *big.Int) (*types.Transaction, error) {
+	return _SimpleSettlement.Contract.CommitRoot(&_SimpleSettlement.TransactOpts, epoch, root, treeSize)
+}
+
+// OpenDispute is a paid mutator transaction binding the contract method 0xf08ef6cb.
+//
+// Solidity: function openDispute(bytes32 providerKey) returns()
+func (_SimpleSettlement *SimpleSettlementTransactor) OpenDispute(opts *bind.TransactOpts, providerKey [32]byte) (*types.Transaction, error) {
+	return _SimpleSettlement.contract.Transact(opts, "openDispute", providerKey)
+}
+
+// OpenDispute is a paid mutator transaction binding the contract method 0xf08ef6cb.
+//
+// Solidity: function openDispute(bytes32 providerKey) returns()
+func (_SimpleSettlement *SimpleSettlementSession) OpenDispute(providerKey [32]byte) (*types.Transaction, error) {
+	return _SimpleSettlement.Contract.OpenDispute(&_SimpleSettlement.TransactOpts, providerKey)
+}
+
+// OpenDispute is a paid mutator transaction binding the contract method 0xf08ef6cb.
+//
+// Solidity: function openDispute(bytes32 providerKey) returns()
+func (_SimpleSettlement *SimpleSettlementTransactorSession) OpenDispute(providerKey [32]byte) (*types.Transaction, error) {
+	return _SimpleSettlement.Contract.OpenDispute(&_SimpleSettlement.TransactOpts, providerKey)
+}
+
+// ProcessClaim is a paid mutator transaction binding the contract method 0xc118cda1.
+//
+// Solidity: function processClaim(uint256 epoch, uint256[] leafIndices, bytes32[] leafHashes, bytes32[] proof, address provider, uint256 amount) returns()
+func (_SimpleSettlement *SimpleSettlementTransactor) ProcessClaim(opts *bind.TransactOpts, epoch *big.Int, leafIndices []*big.Int, leafHashes [][32]byte, proof [][32]byte, provider common.Address, amount *big.Int) (*types.Transaction, error) {
+	return _SimpleSettlement.contract.Transact(opts, "processClaim", epoch, leafIndices, leafHashes, proof, provider, amount)
+}
+
+// ProcessClaim is a paid mutator transaction binding the contract method 0xc118cda1.
+//
+// Solidity: function processClaim(uint256 epoch, uint256[] leafIndices, bytes32[] leafHashes, bytes32[] proof, address provider, uint256 amount) returns()
+func (_SimpleSettlement *SimpleSettlementSession) ProcessClaim(epoch *big.Int, leafIndices []*big.Int, leafHashes [][32]byte, proof [][32]byte, provider common.Address, amount *big.Int) (*types.Transaction, error) {
+	return _SimpleSettlement.Contract.ProcessClaim(&_SimpleSettlement.TransactOpts, epoch, leafIndices, leafHashes, proof, provider, amount)
+}
+
+// ProcessClaim is a paid mutator transaction binding the contract method 0xc118cda1.
+//
+// Solidity: function processClaim(uint256 epoch, uint256[] leafIndices, bytes32[] leafHashes, bytes32[] proof, address provider, uint256 amount) returns()
+func (_SimpleSettlement *SimpleSettlementTransactorSession) ProcessClaim(epoch *big.Int, leafIndices []*big.Int, leafHashes [][32]byte, proof [][32]byte, provider common.Address, amount *big.Int) (*types.Transaction, error) {
+	return _SimpleSettlement.Contract.ProcessClaim(&_SimpleSettlement.TransactOpts, epoch, leafIndices, leafHashes, proof, provider, amount)
+}
+
+// ProcessReceipt is a paid mutator transaction binding the contract method 0x29cf9c9e.
+//
+// Solidity: function processReceipt(uint256 epoch, uint256 leafIndex, bytes32 leafHash, bytes32[] proof, address provider, uint256 amount) returns()
+func (_SimpleSettlement *SimpleSettlementTransactor) ProcessReceipt(opts *bind.TransactOpts, epoch *big.Int, leafIndex *big.Int, leafHash [32]byte, proof [][32]byte, provider common.Address, amount *big.Int) (*types.Transaction, error) {
+	return _SimpleSettlement.contract.Transact(opts, "processReceipt", epoch, leafIndex, leafHash, proof, provider, amount)
+}
+
+// ProcessReceipt is a paid mutator transaction binding the contract method 0x29cf9c9e.
+//
+// Solidity: function processReceipt(uint256 epoch, uint256 leafIndex, bytes32 leafHash, bytes32[] proof, address provider, uint256 amount) returns()
+func (_SimpleSettlement *SimpleSettlementSession) ProcessReceipt(epoch *big.Int, leafIndex *big.Int, leafHash [32]byte, proof [][32]byte, provider common.Address, amount *big.Int) (*types.Transaction, error) {
+	return _SimpleSettlement.Contract.ProcessReceipt(&_SimpleSettlement.TransactOpts, epoch, leafIndex, leafHash, proof, provider, amount)
+}
+
+// ProcessReceipt is a paid mutator transaction binding the contract method 0x29cf9c9e.
+//
+// Solidity: function processReceipt(uint256 epoch, uint256 leafIndex, bytes32 leafHash, bytes32[] proof, address provider, uint256 amount) returns()
+func (_SimpleSettlement *SimpleSettlementTransactorSession) ProcessReceipt(epoch *big.Int, leafIndex *big.Int, leafHash [32]byte, proof [][32]byte, provider common.Address, amount *big.Int) (*types.Transaction, error) {
+	return _SimpleSettlement.Contract.ProcessReceipt(&_SimpleSettlement.TransactOpts, epoch, leafIndex, leafHash, proof, provider, amount)
+}
+
+// RenounceOwnership is a paid mutator transaction binding the contract method 0x715018a6.
+//
+// Solidity: function renounceOwnership() returns()
+func (_SimpleSettlement *SimpleSettlementTransactor) RenounceOwnership(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _SimpleSettlement.contract.Transact(opts, "renounceOwnership")
+}
+
+// RenounceOwnership is a paid mutator transaction binding the contract method 0x715018a6.
+//
+// Solidity: function renounceOwnership() returns()
+func (_SimpleSettlement *SimpleSettlementSession) RenounceOwnership() (*types.Transaction, error) {
+	return _SimpleSettlement.Contract.RenounceOwnership(&_SimpleSettlement.TransactOpts)
+}
+
+// RenounceOwnership is a paid mutator transaction binding the contract method 0x715018a6.
+//
+// Solidity: function renounceOwnership() returns()
+func (_SimpleSettlement *SimpleSettlementTransactorSession) RenounceOwnership() (*types.Transaction, error) {
+	return _SimpleSettlement.Contract.RenounceOwnership(&_SimpleSettlement.TransactOpts)
+}
+
+// RequestUnbond is a paid mutator transaction binding the contract method 0x977d178f.
+//
+// Solidity: function requestUnbond(bytes32 providerKey) returns()
+func (_SimpleSettlement *SimpleSettlementTransactor) RequestUnbond(opts *bind.TransactOpts, providerKey [32]byte) (*types.Transaction, error) {
+	return _SimpleSettlement.contract.Transact(opts, "requestUnbond", providerKey)
+}
+
+// RequestUnbond is a paid mutator transaction binding the contract method 0x977d178f.
+//
+// Solidity: function requestUnbond(bytes32 providerKey) returns()
+func (_SimpleSettlement *SimpleSettlementSession) RequestUnbond(providerKey [32]byte) (*types.Transaction, error) {
+	return _SimpleSettlement.Contract.RequestUnbond(&_SimpleSettlement.TransactOpts, providerKey)
+}
+
+// RequestUnbond is a paid mutator transaction binding the contract method 0x977d178f.
+//
+// Solidity: function requestUnbond(bytes32 providerKey) returns()
+func (_SimpleSettlement *SimpleSettlementTransactorSession) RequestUnbond(providerKey [32]byte) (*types.Transaction, error) {
+	return _SimpleSettlement.Contract.RequestUnbond(&_SimpleSettlement.TransactOpts, providerKey)
+}
+
+// SetStakeParams is a paid mutator transaction binding the contract method 0xbefbe220.
+//
+// Solidity: function setStakeParams(uint256 _minStake, uint256 _unbondingPeriod) returns()
+func (_SimpleSettlement *SimpleSettlementTransactor) SetStakeParams(opts *bind.TransactOpts, _minStake *big.Int, _unbondingPeriod *big.Int) (*types.Transaction, error) {
+	return _SimpleSettlement.contract.Transact(opts, "setStakeParams", _minStake, _unbondingPeriod)
+}
+
+// SetStakeParams is a paid mutator transaction binding the contract method 0xbefbe220.
+//
+// Solidity: function setStakeParams(uint256 _minStake, uint256 _unbondingPeriod) returns()
+func (_SimpleSettlement *SimpleSettlementSession) SetStakeParams(_minStake *big.Int, _unbondingPeriod *big.Int) (*types.Transaction, error) {
+	return _SimpleSettlement.Contract.SetStakeParams(&_SimpleSettlement.TransactOpts, _minStake, _unbondingPeriod)
+}
+
+// SetStakeParams is a paid mutator transaction binding the contract method 0xbefbe220.
+//
+// Solidity: function setStakeParams(uint256 _minStake, uint256 _unbondingPeriod) returns()
+func (_SimpleSettlement *SimpleSettlementTransactorSession) SetStakeParams(_minStake *big.Int, _unbondingPeriod *big.Int) (*types.Transaction, error) {
+	return _SimpleSettlement.Contract.SetStakeParams(&_SimpleSettlement.TransactOpts, _minStake, _unbondingPeriod)
+}
+
+// Slash is a paid mutator transaction binding the contract method 0x706ee615.
+//
+// Solidity: function slash(bytes32 providerKey, uint256 amount, bytes32 evidenceHash) returns()
+func (_SimpleSettlement *SimpleSettlementTransactor) Slash(opts *bind.TransactOpts, providerKey [32]byte, amount *big.Int, evidenceHash [32]byte) (*types.Transaction, error) {
+	return _SimpleSettlement.contract.Transact(opts, "slash", providerKey, amount, evidenceHash)
+}
+
+// Slash is a paid mutator transaction binding the contract method 0x706ee615.
+//
+// Solidity: function slash(bytes32 providerKey, uint256 amount, bytes32 evidenceHash) returns()
+func (_SimpleSettlement *SimpleSettlementSession) Slash(providerKey [32]byte, amount *big.Int, evidenceHash [32]byte) (*types.Transaction, error) {
+	return _SimpleSettlement.Contract.Slash(&_SimpleSettlement.TransactOpts, providerKey, amount, evidenceHash)
+}
+
+// Slash is a paid mutator transaction binding the contract method 0x706ee615.
+//
+// Solidity: function slash(bytes32 providerKey, uint256 amount, bytes32 evidenceHash) returns()
+func (_SimpleSettlement *SimpleSettlementTransactorSession) Slash(providerKey [32]byte, amount *big.Int, evidenceHash [32]byte) (*types.Transaction, error) {
+	return _SimpleSettlement.Contract.Slash(&_SimpleSettlement.TransactOpts, providerKey, amount, evidenceHash)
+}
+
+// Stake is a paid mutator transaction binding the contract method 0x8f771472.
+//
+// Solidity: function stake(bytes32 providerKey, uint256 amount, string[] models) returns()
+func (_SimpleSettlement *SimpleSettlementTransactor) Stake(opts *bind.TransactOpts, providerKey [32]byte, amount *big.Int, models []string) (*types.Transaction, error) {
+	return _SimpleSettlement.contract.Transact(opts, "stake", providerKey, amount, models)
+}
+
+// Stake is a paid mutator transaction binding the contract method 0x8f771472.
+//
+// Solidity: function stake(bytes32 providerKey, uint256 amount, string[] models) returns()
+func (_SimpleSettlement *SimpleSettlementSession) Stake(providerKey [32]byte, amount *big.Int, models []string) (*types.Transaction, error) {
+	return _SimpleSettlement.Contract.Stake(&_SimpleSettlement.TransactOpts, providerKey, amount, models)
+}
+
+// Stake is a paid mutator transaction binding the contract method 0x8f771472.
+//
+// Solidity: function stake(bytes32 providerKey, uint256 amount, string[] models) returns()
+func (_SimpleSettlement *SimpleSettlementTransactorSession) Stake(providerKey [32]byte, amount *big.Int, models []string) (*types.Transaction, error) {
+	return _SimpleSettlement.Contract.Stake(&_SimpleSettlement.TransactOpts, providerKey, amount, models)
+}
+
+// TransferOwnership is a paid mutator transaction binding the contract method 0xf2fde38b.
+//
+// Solidity: function transferOwnership(address newOwner) returns()
+func (_SimpleSettlement *SimpleSettlementTransactor) TransferOwnership(opts *bind.TransactOpts, newOwner common.Address) (*types.Transaction, error) {
+	return _SimpleSettlement.contract.Transact(opts, "transferOwnership", newOwner)
+}
+
+// TransferOwnership is a paid mutator transaction binding the contract method 0xf2fde38b.
+//
+// Solidity: function transferOwnership(address newOwner) returns()
+func (_SimpleSettlement *SimpleSettlementSession) TransferOwnership(newOwner common.Address) (*types.Transaction, error) {
+	return _SimpleSettlement.Contract.TransferOwnership(&_SimpleSettlement.TransactOpts, newOwner)
+}
+
+// TransferOwnership is a paid mutator transaction binding the contract method 0xf2fde38b.
+//
+// Solidity: function transferOwnership(address newOwner) returns()
+func (_SimpleSettlement *SimpleSettlementTransactorSession) TransferOwnership(newOwner common.Address) (*types.Transaction, error) {
+	return _SimpleSettlement.Contract.TransferOwnership(&_SimpleSettlement.TransactOpts, newOwner)
+}
+
+// Withdraw is a paid mutator transaction binding the contract method 0x3ccfd60b.
+//
+// Solidity: function withdraw() returns()
+func (_SimpleSettlement *SimpleSettlementTransactor) Withdraw(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _SimpleSettlement.contract.Transact(opts, "withdraw")
+}
+
+// Withdraw is a paid mutator transaction binding the contract method 0x3ccfd60b.
+//
+// Solidity: function withdraw() returns()
+func (_SimpleSettlement *SimpleSettlementSession) Withdraw() (*types.Transaction, error) {
+	return _SimpleSettlement.Contract.Withdraw(&_SimpleSettlement.TransactOpts)
+}
+
+// Withdraw is a paid mutator transaction binding the contract method 0x3ccfd60b.
+//
+// Solidity: function withdraw() returns()
+func (_SimpleSettlement *SimpleSettlementTransactorSession) Withdraw() (*types.Transaction, error) {
+	return _SimpleSettlement.Contract.Withdraw(&_SimpleSettlement.TransactOpts)
+}
+
+// WithdrawStake is a paid mutator transaction binding the contract method 0xb95ff689.
+//
+// Solidity: function withdrawStake(bytes32 providerKey) returns()
+func (_SimpleSettlement *SimpleSettlementTransactor) WithdrawStake(opts *bind.TransactOpts, providerKey [32]byte) (*types.Transaction, error) {
+	return _SimpleSettlement.contract.Transact(opts, "withdrawStake", providerKey)
+}
+
+// WithdrawStake is a paid mutator transaction binding the contract method 0xb95ff689.
+//
+// Solidity: function withdrawStake(bytes32 providerKey) returns()
+func (_SimpleSettlement *SimpleSettlementSession) WithdrawStake(providerKey [32]byte) (*types.Transaction, error) {
+	return _SimpleSettlement.Contract.WithdrawStake(&_SimpleSettlement.TransactOpts, providerKey)
+}
+
+// WithdrawStake is a paid mutator transaction binding the contract method 0xb95ff689.
+//
+// Solidity: function withdrawStake(bytes32 providerKey) returns()
+func (_SimpleSettlement *SimpleSettlementTransactorSession) WithdrawStake(providerKey [32]byte) (*types.Transaction, error) {
+	return _SimpleSettlement.Contract.WithdrawStake(&_SimpleSettlement.TransactOpts, providerKey)
+}
+
+// SimpleSettlementClaimProcessedIterator is returned from FilterClaimProcessed and is used to iterate over the raw logs and unpacked data for ClaimProcessed events raised by the SimpleSettlement contract.
+type SimpleSettlementClaimProcessedIterator struct {
+	Event *SimpleSettlementClaimProcessed // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *SimpleSettlementClaimProcessedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(SimpleSettlementClaimProcessed)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(SimpleSettlementClaimProcessed)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *SimpleSettlementClaimProcessedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *SimpleSettlementClaimProcessedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// SimpleSettlementClaimProcessed represents a ClaimProcessed event raised by the SimpleSettlement contract.
+type SimpleSettlementClaimProcessed struct {
+	Epoch     *big.Int
+	Provider  common.Address
+	LeafCount *big.Int
+	Amount    *big.Int
+	Raw       types.Log // Blockchain specific contextual infos
+}
+
+// FilterClaimProcessed is a free log retrieval operation binding the contract event 0xe7341d3ada07d539ddfc0995d3c26c60000920fe12039ac4373255a9da15b72e.
+//
+// Solidity: event ClaimProcessed(uint256 indexed epoch, address indexed provider, uint256 leafCount, uint256 amount)
+func (_SimpleSettlement *SimpleSettlementFilterer) FilterClaimProcessed(opts *bind.FilterOpts, epoch []*big.Int, provider []common.Address) (*SimpleSettlementClaimProcessedIterator, error) {
+
+	var epochRule []interface{}
+	for _, epochItem := range epoch {
+		epochRule = append(epochRule, epochItem)
+	}
+	var providerRule []interface{}
+	for _, providerItem := range provider {
+		providerRule = append(providerRule, providerItem)
+	}
+
+	logs, sub, err := _SimpleSettlement.contract.FilterLogs(opts, "ClaimProcessed", epochRule, providerRule)
+	if err != nil {
+		return nil, err
+	}
+	return &SimpleSettlementClaimProcessedIterator{contract: _SimpleSettlement.contract, event: "ClaimProcessed", logs: logs, sub: sub}, nil
+}
+
+// WatchClaimProcessed is a free log subscription operation binding the contract event 0xe7341d3ada07d539ddfc0995d3c26c60000920fe12039ac4373255a9da15b72e.
+//
+// Solidity: event ClaimProcessed(uint256 indexed epoch, address indexed provider, uint256 leafCount, uint256 amount)
+func (_SimpleSettlement *SimpleSettlementFilterer) WatchClaimProcessed(opts *bind.WatchOpts, sink chan<- *SimpleSettlementClaimProcessed, epoch []*big.Int, provider []common.Address) (event.Subscription, error) {
+
+	var epochRule []interface{}
+	for _, epochItem := range epoch {
+		epochRule = append(epochRule, epochItem)
+	}
+	var providerRule []interface{}
+	for _, providerItem := range provider {
+		providerRule = append(providerRule, providerItem)
+	}
+
+	logs, sub, err := _SimpleSettlement.contract.WatchLogs(opts, "ClaimProcessed", epochRule, providerRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(SimpleSettlementClaimProcessed)
+				if err := _SimpleSettlement.contract.UnpackLog(event, "ClaimProcessed", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseClaimProcessed is a log parse operation binding the contract event 0xe7341d3ada07d539ddfc0995d3c26c60000920fe12039ac4373255a9da15b72e.
+//
+// Solidity: event ClaimProcessed(uint256 indexed epoch, address indexed provider, uint256 leafCount, uint256 amount)
+func (_SimpleSettlement *SimpleSettlementFilterer) ParseClaimProcessed(log types.Log) (*SimpleSettlementClaimProcessed, error) {
+	event := new(SimpleSettlementClaimProcessed)
+	if err := _SimpleSettlement.contract.UnpackLog(event, "ClaimProcessed", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// SimpleSettlementDisputeClosedIterator is returned from FilterDisputeClosed and is used to iterate over the raw logs and unpacked data for DisputeClosed events raised by the SimpleSettlement contract.
+type SimpleSettlementDisputeClosedIterator struct {
+	Event *SimpleSettlementDisputeClosed // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *SimpleSettlementDisputeClosedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(SimpleSettlementDisputeClosed)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(SimpleSettlementDisputeClosed)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *SimpleSettlementDisputeClosedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *SimpleSettlementDisputeClosedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// SimpleSettlementDisputeClosed represents a DisputeClosed event raised by the SimpleSettlement contract.
+type SimpleSettlementDisputeClosed struct {
+	ProviderKey  [32]byte
+	OpenDisputes *big.Int
+	Raw          types.Log // Blockchain specific contextual infos
+}
+
+// FilterDisputeClosed is a free log retrieval operation binding the contract event 0x9a64e2b3114f3c0f6880040251749a44b60a5dced266077c767082869204c73b.
+//
+// Solidity: event DisputeClosed(bytes32 indexed providerKey, uint256 openDisputes)
+func (_SimpleSettlement *SimpleSettlementFilterer) FilterDisputeClosed(opts *bind.FilterOpts, providerKey [][32]byte) (*SimpleSettlementDisputeClosedIterator, error) {
+
+	var providerKeyRule []interface{}
+	for _, providerKeyItem := range providerKey {
+		providerKeyRule = append(providerKeyRule, providerKeyItem)
+	}
+
+	logs, sub, err := _SimpleSettlement.contract.FilterLogs(opts, "DisputeClosed", providerKeyRule)
+	if err != nil {
+		return nil, err
+	}
+	return &SimpleSettlementDisputeClosedIterator{contract: _SimpleSettlement.contract, event: "DisputeClosed", logs: logs, sub: sub}, nil
+}
+
+// WatchDisputeClosed is a free log subscription operation binding the contract event 0x9a64e2b3114f3c0f6880040251749a44b60a5dced266077c767082869204c73b.
+//
+// Solidity: event DisputeClosed(bytes32 indexed providerKey, uint256 openDisputes)
+func (_SimpleSettlement *SimpleSettlementFilterer) WatchDisputeClosed(opts *bind.WatchOpts, sink chan<- *SimpleSettlementDisputeClosed, providerKey [][32]byte) (event.Subscription, error) {
+
+	var providerKeyRule []interface{}
+	for _, providerKeyItem := range providerKey {
+		providerKeyRule = append(providerKeyRule, providerKeyItem)
+	}
+
+	logs, sub, err := _SimpleSettlement.contract.WatchLogs(opts, "DisputeClosed", providerKeyRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(SimpleSettlementDisputeClosed)
+				if err := _SimpleSettlement.contract.UnpackLog(event, "DisputeClosed", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseDisputeClosed is a log parse operation binding the contract event 0x9a64e2b3114f3c0f6880040251749a44b60a5dced266077c767082869204c73b.
+//
+// Solidity: event DisputeClosed(bytes32 indexed providerKey, uint256 openDisputes)
+func (_SimpleSettlement *SimpleSettlementFilterer) ParseDisputeClosed(log types.Log) (*SimpleSettlementDisputeClosed, error) {
+	event := new(SimpleSettlementDisputeClosed)
+	if err := _SimpleSettlement.contract.UnpackLog(event, "DisputeClosed", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// SimpleSettlementDisputeOpenedIterator is returned from FilterDisputeOpened and is used to iterate over the raw logs and unpacked data for DisputeOpened events raised by the SimpleSettlement contract.
+type SimpleSettlementDisputeOpenedIterator struct {
+	Event *SimpleSettlementDisputeOpened // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *SimpleSettlementDisputeOpenedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(SimpleSettlementDisputeOpened)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(SimpleSettlementDisputeOpened)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *SimpleSettlementDisputeOpenedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *SimpleSettlementDisputeOpenedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// SimpleSettlementDisputeOpened represents a DisputeOpened event raised by the SimpleSettlement contract.
+type SimpleSettlementDisputeOpened struct {
+	ProviderKey  [32]byte
+	OpenDisputes *big.Int
+	Raw          types.Log // Blockchain specific contextual infos
+}
+
+// FilterDisputeOpened is a free log retrieval operation binding the contract event 0xbad66769c2139f3f8a64794a4308e97ef948a8e6cd0553d93d4b530672c88b71.
+//
+// Solidity: event DisputeOpened(bytes32 indexed providerKey, uint256 openDisputes)
+func (_SimpleSettlement *SimpleSettlementFilterer) FilterDisputeOpened(opts *bind.FilterOpts, providerKey [][32]byte) (*SimpleSettlementDisputeOpenedIterator, error) {
+
+	var providerKeyRule []interface{}
+	for _, providerKeyItem := range providerKey {
+		providerKeyRule = append(providerKeyRule, providerKeyItem)
+	}
+
+	logs, sub, err := _SimpleSettlement.contract.FilterLogs(opts, "DisputeOpened", providerKeyRule)
+	if err != nil {
+		return nil, err
+	}
+	return &SimpleSettlementDisputeOpenedIterator{contract: _SimpleSettlement.contract, event: "DisputeOpened", logs: logs, sub: sub}, nil
+}
+
+// WatchDisputeOpened is a free log subscription operation binding the contract event 0xbad66769c2139f3f8a64794a4308e97ef948a8e6cd0553d93d4b530672c88b71.
+//
+// Solidity: event DisputeOpened(bytes32 indexed providerKey, uint256 openDisputes)
+func (_SimpleSettlement *SimpleSettlementFilterer) WatchDisputeOpened(opts *bind.WatchOpts, sink chan<- *SimpleSettlementDisputeOpened, providerKey [][32]byte) (event.Subscription, error) {
+
+	var providerKeyRule []interface{}
+	for _, providerKeyItem := range providerKey {
+		providerKeyRule = append(providerKeyRule, providerKeyItem)
+	}
+
+	logs, sub, err := _SimpleSettlement.contract.WatchLogs(opts, "DisputeOpened", providerKeyRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(SimpleSettlementDisputeOpened)
+				if err := _SimpleSettlement.contract.UnpackLog(event, "DisputeOpened", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseDisputeOpened is a log parse operation binding the contract event 0xbad66769c2139f3f8a64794a4308e97ef948a8e6cd0553d93d4b530672c88b71.
+//
+// Solidity: event DisputeOpened(bytes32 indexed providerKey, uint256 openDisputes)
+func (_SimpleSettlement *SimpleSettlementFilterer) ParseDisputeOpened(log types.Log) (*SimpleSettlementDisputeOpened, error) {
+	event := new(SimpleSettlementDisputeOpened)
+	if err := _SimpleSettlement.contract.UnpackLog(event, "DisputeOpened", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// SimpleSettlementOwnershipTransferredIterator is returned from FilterOwnershipTransferred and is used to iterate over the raw logs and unpacked data for OwnershipTransferred events raised by the SimpleSettlement contract.
+type SimpleSettlementOwnershipTransferredIterator struct {
+	Event *SimpleSettlementOwnershipTransferred // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *SimpleSettlementOwnershipTransferredIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(SimpleSettlementOwnershipTransferred)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(SimpleSettlementOwnershipTransferred)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *SimpleSettlementOwnershipTransferredIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *SimpleSettlementOwnershipTransferredIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// SimpleSettlementOwnershipTransferred represents a OwnershipTransferred event raised by the SimpleSettlement contract.
+type SimpleSettlementOwnershipTransferred struct {
+	PreviousOwner common.Address
+	NewOwner      common.Address
+	Raw           types.Log // Blockchain specific contextual infos
+}
+
+// FilterOwnershipTransferred is a free log retrieval operation binding the contract event 0x8be0079c531659141344cd1fd0a4f28419497f9722a3daafe3b4186f6b6457e0.
+//
+// Solidity: event OwnershipTransferred(address indexed previousOwner, address indexed newOwner)
+func (_SimpleSettlement *SimpleSettlementFilterer) FilterOwnershipTransferred(opts *bind.FilterOpts, previousOwner []common.Address, newOwner []common.Address) (*SimpleSettlementOwnershipTransferredIterator, error) {
+
+	var previousOwnerRule []interface{}
+	for _, previousOwnerItem := range previousOwner {
+		previousOwnerRule = append(previousOwnerRule, previousOwnerItem)
+	}
+	var newOwnerRule []interface{}
+	for _, newOwnerItem := range newOwner {
+		newOwnerRule = append(newOwnerRule, newOwnerItem)
+	}
+
+	logs, sub, err := _SimpleSettlement.contract.FilterLogs(opts, "OwnershipTransferred", previousOwnerRule, newOwnerRule)
+	if err != nil {
+		return nil, err
+	}
+	return &SimpleSettlementOwnershipTransferredIterator{contract: _SimpleSettlement.contract, event: "OwnershipTransferred", logs: logs, sub: sub}, nil
+}
+
+// WatchOwnershipTransferred is a free log subscription operation binding the contract event 0x8be0079c531659141344cd1fd0a4f28419497f9722a3daafe3b4186f6b6457e0.
+//
+// Solidity: event OwnershipTransferred(address indexed previousOwner, address indexed newOwner)
+func (_SimpleSettlement *SimpleSettlementFilterer) WatchOwnershipTransferred(opts *bind.WatchOpts, sink chan<- *SimpleSettlementOwnershipTransferred, previousOwner []common.Address, newOwner []common.Address) (event.Subscription, error) {
+
+	var previousOwnerRule []interface{}
+	for _, previousOwnerItem := range previousOwner {
+		previousOwnerRule = append(previousOwnerRule, previousOwnerItem)
+	}
+	var newOwnerRule []interface{}
+	for _, newOwnerItem := range newOwner {
+		newOwnerRule = append(newOwnerRule, newOwnerItem)
+	}
+
+	logs, sub, err := _SimpleSettlement.contract.WatchLogs(opts, "OwnershipTransferred", previousOwnerRule, newOwnerRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(SimpleSettlementOwnershipTransferred)
+				if err := _SimpleSettlement.contract.UnpackLog(event, "OwnershipTransferred", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseOwnershipTransferred is a log parse operation binding the contract event 0x8be0079c531659141344cd1fd0a4f28419497f9722a3daafe3b4186f6b6457e0.
+//
+// Solidity: event OwnershipTransferred(address indexed previousOwner, address indexed newOwner)
+func (_SimpleSettlement *SimpleSettlementFilterer) ParseOwnershipTransferred(log types.Log) (*SimpleSettlementOwnershipTransferred, error) {
+	event := new(SimpleSettlementOwnershipTransferred)
+	if err := _SimpleSettlement.contract.UnpackLog(event, "OwnershipTransferred", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// SimpleSettlementPayoutClaimedIterator is returned from FilterPayoutClaimed and is used to iterate over the raw logs and unpacked data for PayoutClaimed events raised by the SimpleSettlement contract.
+type SimpleSettlementPayoutClaimedIterator struct {
+	Event *SimpleSettlementPayoutClaimed // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *SimpleSettlementPayoutClaimedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(SimpleSettlementPayoutClaimed)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(SimpleSettlementPayoutClaimed)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *SimpleSettlementPayoutClaimedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *SimpleSettlementPayoutClaimedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// SimpleSettlementPayoutClaimed represents a PayoutClaimed event raised by the SimpleSettlement contract.
+type SimpleSettlementPayoutClaimed struct {
+	Epoch        *big.Int
+	Provider     common.Address
+	Amount       *big.Int
+	ReceiptCount *big.Int
+	ReceiptsRoot [32]byte
+	Raw          types.Log // Blockchain specific contextual infos
+}
+
+// FilterPayoutClaimed is a free log retrieval operation binding the contract event 0x0134a891d8f694984f4369ccf91a33f6d992226f6d9a12c37c12463997c073bd.
+//
+// Solidity: event PayoutClaimed(uint256 indexed epoch, address indexed provider, uint256 amount, uint256 receiptCount, bytes32 receiptsRoot)
+func (_SimpleSettlement *SimpleSettlementFilterer) FilterPayoutClaimed(opts *bind.FilterOpts, epoch []*big.Int, provider []common.Address) (*SimpleSettlementPayoutClaimedIterator, error) {
+
+	var epochRule []interface{}
+	for _, epochItem := range epoch {
+		epochRule = append(epochRule, epochItem)
+	}
+	var providerRule []interface{}
+	for _, providerItem := range provider {
+		providerRule = append(providerRule, providerItem)
+	}
+
+	logs, sub, err := _SimpleSettlement.contract.FilterLogs(opts, "PayoutClaimed", epochRule, providerRule)
+	if err != nil {
+		return nil, err
+	}
+	return &SimpleSettlementPayoutClaimedIterator{contract: _SimpleSettlement.contract, event: "PayoutClaimed", logs: logs, sub: sub}, nil
+}
+
+// WatchPayoutClaimed is a free log subscription operation binding the contract event 0x0134a891d8f694984f4369ccf91a33f6d992226f6d9a12c37c12463997c073bd.
+//
+// Solidity: event PayoutClaimed(uint256 indexed epoch, address indexed provider, uint256 amount, uint256 receiptCount, bytes32 receiptsRoot)
+func (_SimpleSettlement *SimpleSettlementFilterer) WatchPayoutClaimed(opts *bind.WatchOpts, sink chan<- *SimpleSettlementPayoutClaimed, epoch []*big.Int, provider []common.Address) (event.Subscription, error) {
+
+	var epochRule []interface{}
+	for _, epochItem := range epoch {
+		epochRule = append(epochRule, epochItem)
+	}
+	var providerRule []interface{}
+	for _, providerItem := range provider {
+		providerRule = append(providerRule, providerItem)
+	}
+
+	logs, sub, err := _SimpleSettlement.contract.WatchLogs(opts, "PayoutClaimed", epochRule, providerRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(SimpleSettlementPayoutClaimed)
+				if err := _SimpleSettlement.contract.UnpackLog(event, "PayoutClaimed", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParsePayoutClaimed is a log parse operation binding the contract event 0x0134a891d8f694984f4369ccf91a33f6d992226f6d9a12c37c12463997c073bd.
+//
+// Solidity: event PayoutClaimed(uint256 indexed epoch, address indexed provider, uint256 amount, uint256 receiptCount, bytes32 receiptsRoot)
+func (_SimpleSettlement *SimpleSettlementFilterer) ParsePayoutClaimed(log types.Log) (*SimpleSettlementPayoutClaimed, error) {
+	event := new(SimpleSettlementPayoutClaimed)
+	if err := _SimpleSettlement.contract.UnpackLog(event, "PayoutClaimed", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// SimpleSettlementPayoutRootCommittedIterator is returned from FilterPayoutRootCommitted and is used to iterate over the raw logs and unpacked data for PayoutRootCommitted events raised by the SimpleSettlement contract.
+type SimpleSettlementPayoutRootCommittedIterator struct {
+	Event *SimpleSettlementPayoutRootCommitted // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *SimpleSettlementPayoutRootCommittedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(SimpleSettlementPayoutRootCommitted)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(SimpleSettlementPayoutRootCommitted)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *SimpleSettlementPayoutRootCommittedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *SimpleSettlementPayoutRootCommittedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// SimpleSettlementPayoutRootCommitted represents a PayoutRootCommitted event raised by the SimpleSettlement contract.
+type SimpleSettlementPayoutRootCommitted struct {
+	Epoch    *big.Int
+	Root     [32]byte
+	TreeSize *big.Int
+	Raw      types.Log // Blockchain specific contextual infos
+}
+
+// FilterPayoutRootCommitted is a free log retrieval operation binding the contract event 0x60e464240cf21438e2b90e1205954c50b390598f10664313367d39ef1a423595.
+//
+// Solidity: event PayoutRootCommitted(uint256 indexed epoch, bytes32 root, uint256 treeSize)
+func (_SimpleSettlement *SimpleSettlementFilterer) FilterPayoutRootCommitted(opts *bind.FilterOpts, epoch []*big.Int) (*SimpleSettlementPayoutRootCommittedIterator, error) {
+
+	var epochRule []interface{}
+	for _, epochItem := range epoch {
+		epochRule = append(epochRule, epochItem)
+	}
+
+	logs, sub, err := _SimpleSettlement.contract.FilterLogs(opts, "PayoutRootCommitted", epochRule)
+	if err != nil {
+		return nil, err
+	}
+	return &SimpleSettlementPayoutRootCommittedIterator{contract: _SimpleSettlement.contract, event: "PayoutRootCommitted", logs: logs, sub: sub}, nil
+}
+
+// WatchPayoutRootCommitted is a free log subscription operation binding the contract event 0x60e464240cf21438e2b90e1205954c50b390598f10664313367d39ef1a423595.
+//
+// Solidity: event PayoutRootCommitted(uint256 indexed epoch, bytes32 root, uint256 treeSize)
+func (_SimpleSettlement *SimpleSettlementFilterer) WatchPayoutRootCommitted(opts *bind.WatchOpts, sink chan<- *SimpleSettlementPayoutRootCommitted, epoch []*big.Int) (event.Subscription, error) {
+
+	var epochRule []interface{}
+	for _, epochItem := range epoch {
+		epochRule = append(epochRule, epochItem)
+	}
+
+	logs, sub, err := _SimpleSettlement.contract.WatchLogs(opts, "PayoutRootCommitted", epochRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(SimpleSettlementPayoutRootCommitted)
+				if err := _SimpleSettlement.contract.UnpackLog(event, "PayoutRootCommitted", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParsePayoutRootCommitted is a log parse operation binding the contract event 0x60e464240cf21438e2b90e1205954c50b390598f10664313367d39ef1a423595.
+//
+// Solidity: event PayoutRootCommitted(uint256 indexed epoch, bytes32 root, uint256 treeSize)
+func (_SimpleSettlement *SimpleSettlementFilterer) ParsePayoutRootCommitted(log types.Log) (*SimpleSettlementPayoutRootCommitted, error) {
+	event := new(SimpleSettlementPayoutRootCommitted)
+	if err := _SimpleSettlement.contract.UnpackLog(event, "PayoutRootCommitted", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// SimpleSettlementReceiptProcessedIterator is returned from FilterReceiptProcessed and is used to iterate over the raw logs and unpacked data for ReceiptProcessed events raised by the SimpleSettlement contract.
+type SimpleSettlementReceiptProcessedIterator struct {
+	Event *SimpleSettlementReceiptProcessed // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *SimpleSettlementReceiptProcessedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(SimpleSettlementReceiptProcessed)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(SimpleSettlementReceiptProcessed)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *SimpleSettlementReceiptProcessedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *SimpleSettlementReceiptProcessedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// SimpleSettlementReceiptProcessed represents a ReceiptProcessed event raised by the SimpleSettlement contract.
+type SimpleSettlementReceiptProcessed struct {
+	Epoch     *big.Int
+	LeafIndex *big.Int
+	Provider  common.Address
+	Amount    *big.Int
+	Raw       types.Log // Blockchain specific contextual infos
+}
+
+// FilterReceiptProcessed is a free log retrieval operation binding the contract event 0xc38233d87aebd4b983883048f8f112213a7ae97de5aef8c8ebc2ab4135ea16a5.
+//
+// Solidity: event ReceiptProcessed(uint256 indexed epoch, uint256 leafIndex, address indexed provider, uint256 amount)
+func (_SimpleSettlement *SimpleSettlementFilterer) FilterReceiptProcessed(opts *bind.FilterOpts, epoch []*big.Int, provider []common.Address) (*SimpleSettlementReceiptProcessedIterator, error) {
+
+	var epochRule []interface{}
+	for _, epochItem := range epoch {
+		epochRule = append(epochRule, epochItem)
+	}
+
+	var providerRule []interface{}
+	for _, providerItem := range provider {
+		providerRule = append(providerRule, providerItem)
+	}
+
+	logs, sub, err := _SimpleSettlement.contract.FilterLogs(opts, "ReceiptProcessed", epochRule, providerRule)
+	if err != nil {
+		return nil, err
+	}
+	return &SimpleSettlementReceiptProcessedIterator{contract: _SimpleSettlement.contract, event: "ReceiptProcessed", logs: logs, sub: sub}, nil
+}
+
+// WatchReceiptProcessed is a free log subscription operation binding the contract event 0xc38233d87aebd4b983883048f8f112213a7ae97de5aef8c8ebc2ab4135ea16a5.
+//
+// Solidity: event ReceiptProcessed(uint256 indexed epoch, uint256 leafIndex, address indexed provider, uint256 amount)
+func (_SimpleSettlement *SimpleSettlementFilterer) WatchReceiptProcessed(opts *bind.WatchOpts, sink chan<- *SimpleSettlementReceiptProcessed, epoch []*big.Int, provider []common.Address) (event.Subscription, error) {
+
+	var epochRule []interface{}
+	for _, epochItem := range epoch {
+		epochRule = append(epochRule, epochItem)
+	}
+
+	var providerRule []interface{}
+	for _, providerItem := range provider {
+		providerRule = append(providerRule, providerItem)
+	}
+
+	logs, sub, err := _SimpleSettlement.contract.WatchLogs(opts, "ReceiptProcessed", epochRule, providerRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(SimpleSettlementReceiptProcessed)
+				if err := _SimpleSettlement.contract.UnpackLog(event, "ReceiptProcessed", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseReceiptProcessed is a log parse operation binding the contract event 0xc38233d87aebd4b983883048f8f112213a7ae97de5aef8c8ebc2ab4135ea16a5.
+//
+// Solidity: event ReceiptProcessed(uint256 indexed epoch, uint256 leafIndex, address indexed provider, uint256 amount)
+func (_SimpleSettlement *SimpleSettlementFilterer) ParseReceiptProcessed(log types.Log) (*SimpleSettlementReceiptProcessed, error) {
+	event := new(SimpleSettlementReceiptProcessed)
+	if err := _SimpleSettlement.contract.UnpackLog(event, "ReceiptProcessed", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// SimpleSettlementRootCommittedIterator is returned from FilterRootCommitted and is used to iterate over the raw logs and unpacked data for RootCommitted events raised by the SimpleSettlement contract.
+type SimpleSettlementRootCommittedIterator struct {
+	Event *SimpleSettlementRootCommitted // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *SimpleSettlementRootCommittedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(SimpleSettlementRootCommitted)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(SimpleSettlementRootCommitted)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *SimpleSettlementRootCommittedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *SimpleSettlementRootCommittedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// SimpleSettlementRootCommitted represents a RootCommitted event raised by the SimpleSettlement contract.
+type SimpleSettlementRootCommitted struct {
+	Epoch    *big.Int
+	Root     [32]byte
+	TreeSize *big.Int
+	Raw      types.Log // Blockchain specific contextual infos
+}
+
+// FilterRootCommitted is a free log retrieval operation binding the contract event 0x6837c572e0fc6954e1be90b35b785809d4da4881782169aab2638c9b72d728ec.
+//
+// Solidity: event RootCommitted(uint256 indexed epoch, bytes32 root, uint256 treeSize)
+func (_SimpleSettlement *SimpleSettlementFilterer) FilterRootCommitted(opts *bind.FilterOpts, epoch []*big.Int) (*SimpleSettlementRootCommittedIterator, error) {
+
+	var epochRule []interface{}
+	for _, epochItem := range epoch {
+		epochRule = append(epochRule, epochItem)
+	}
+
+	logs, sub, err := _SimpleSettlement.contract.FilterLogs(opts, "RootCommitted", epochRule)
+	if err != nil {
+		return nil, err
+	}
+	return &SimpleSettlementRootCommittedIterator{contract: _SimpleSettlement.contract, event: "RootCommitted", logs: logs, sub: sub}, nil
+}
+
+// WatchRootCommitted is a free log subscription operation binding the contract event 0x6837c572e0fc6954e1be90b35b785809d4da4881782169aab2638c9b72d728ec.
+//
+// Solidity: event RootCommitted(uint256 indexed epoch, bytes32 root, uint256 treeSize)
+func (_SimpleSettlement *SimpleSettlementFilterer) WatchRootCommitted(opts *bind.WatchOpts, sink chan<- *SimpleSettlementRootCommitted, epoch []*big.Int) (event.Subscription, error) {
+
+	var epochRule []interface{}
+	for _, epochItem := range epoch {
+		epochRule = append(epochRule, epochItem)
+	}
+
+	logs, sub, err := _SimpleSettlement.contract.WatchLogs(opts, "RootCommitted", epochRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(SimpleSettlementRootCommitted)
+				if err := _SimpleSettlement.contract.UnpackLog(event, "RootCommitted", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseRootCommitted is a log parse operation binding the contract event 0x6837c572e0fc6954e1be90b35b785809d4da4881782169aab2638c9b72d728ec.
+//
+// Solidity: event RootCommitted(uint256 indexed epoch, bytes32 root, uint256 treeSize)
+func (_SimpleSettlement *SimpleSettlementFilterer) ParseRootCommitted(log types.Log) (*SimpleSettlementRootCommitted, error) {
+	event := new(SimpleSettlementRootCommitted)
+	if err := _SimpleSettlement.contract.UnpackLog(event, "RootCommitted", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// SimpleSettlementSlashedIterator is returned from FilterSlashed and is used to iterate over the raw logs and unpacked data for Slashed events raised by the SimpleSettlement contract.
+type SimpleSettlementSlashedIterator struct {
+	Event *SimpleSettlementSlashed // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *SimpleSettlementSlashedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(SimpleSettlementSlashed)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(SimpleSettlementSlashed)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *SimpleSettlementSlashedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *SimpleSettlementSlashedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// SimpleSettlementSlashed represents a Slashed event raised by the SimpleSettlement contract.
+type SimpleSettlementSlashed struct {
+	ProviderKey  [32]byte
+	EvidenceHash [32]byte
+	Amount       *big.Int
+	Remaining    *big.Int
+	Raw          types.Log // Blockchain specific contextual infos
+}
+
+// FilterSlashed is a free log retrieval operation binding the contract event 0x8d8992761ed3d336e0dcd3e8871189e6dfa1ff30066981254000588e0e815c3c.
+//
+// Solidity: event Slashed(bytes32 indexed providerKey, bytes32 indexed evidenceHash, uint256 amount, uint256 remaining)
+func (_SimpleSettlement *SimpleSettlementFilterer) FilterSlashed(opts *bind.FilterOpts, providerKey [][32]byte, evidenceHash [][32]byte) (*SimpleSettlementSlashedIterator, error) {
+
+	var providerKeyRule []interface{}
+	for _, providerKeyItem := range providerKey {
+		providerKeyRule = append(providerKeyRule, providerKeyItem)
+	}
+	var evidenceHashRule []interface{}
+	for _, evidenceHashItem := range evidenceHash {
+		evidenceHashRule = append(evidenceHashRule, evidenceHashItem)
+	}
+
+	logs, sub, err := _SimpleSettlement.contract.FilterLogs(opts, "Slashed", providerKeyRule, evidenceHashRule)
+	if err != nil {
+		return nil, err
+	}
+	return &SimpleSettlementSlashedIterator{contract: _SimpleSettlement.contract, event: "Slashed", logs: logs, sub: sub}, nil
+}
+
+// WatchSlashed is a free log subscription operation binding the contract event 0x8d8992761ed3d336e0dcd3e8871189e6dfa1ff30066981254000588e0e815c3c.
+//
+// Solidity: event Slashed(bytes32 indexed providerKey, bytes32 indexed evidenceHash, uint256 amount, uint256 remaining)
+func (_SimpleSettlement *SimpleSettlementFilterer) WatchSlashed(opts *bind.WatchOpts, sink chan<- *SimpleSettlementSlashed, providerKey [][32]byte, evidenceHash [][32]byte) (event.Subscription, error) {
+
+	var providerKeyRule []interface{}
+	for _, providerKeyItem := range providerKey {
+		providerKeyRule = append(providerKeyRule, providerKeyItem)
+	}
+	var evidenceHashRule []interface{}
+	for _, evidenceHashItem := range evidenceHash {
+		evidenceHashRule = append(evidenceHashRule, evidenceHashItem)
+	}
+
+	logs, sub, err := _SimpleSettlement.contract.WatchLogs(opts, "Slashed", providerKeyRule, evidenceHashRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(SimpleSettlementSlashed)
+				if err := _SimpleSettlement.contract.UnpackLog(event, "Slashed", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseSlashed is a log parse operation binding the contract event 0x8d8992761ed3d336e0dcd3e8871189e6dfa1ff30066981254000588e0e815c3c.
+//
+// Solidity: event Slashed(bytes32 indexed providerKey, bytes32 indexed evidenceHash, uint256 amount, uint256 remaining)
+func (_SimpleSettlement *SimpleSettlementFilterer) ParseSlashed(log types.Log) (*SimpleSettlementSlashed, error) {
+	event := new(SimpleSettlementSlashed)
+	if err := _SimpleSettlement.contract.UnpackLog(event, "Slashed", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// SimpleSettlementStakeParamsSetIterator is returned from FilterStakeParamsSet and is used to iterate over the raw logs and unpacked data for StakeParamsSet events raised by the SimpleSettlement contract.
+type SimpleSettlementStakeParamsSetIterator struct {
+	Event *SimpleSettlementStakeParamsSet // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *SimpleSettlementStakeParamsSetIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(SimpleSettlementStakeParamsSet)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(SimpleSettlementStakeParamsSet)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *SimpleSettlementStakeParamsSetIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *SimpleSettlementStakeParamsSetIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// SimpleSettlementStakeParamsSet represents a StakeParamsSet event raised by the SimpleSettlement contract.
+type SimpleSettlementStakeParamsSet struct {
+	MinStake        *big.Int
+	UnbondingPeriod *big.Int
+	Raw             types.Log // Blockchain specific contextual infos
+}
+
+// FilterStakeParamsSet is a free log retrieval operation binding the contract event 0xd92cec12199949c9f2093cf901bb3c2b0ee110617b326d815b571dd28f8fbf46.
+//
+// Solidity: event StakeParamsSet(uint256 minStake, uint256 unbondingPeriod)
+func (_SimpleSettlement *SimpleSettlementFilterer) FilterStakeParamsSet(opts *bind.FilterOpts) (*SimpleSettlementStakeParamsSetIterator, error) {
+
+	logs, sub, err := _SimpleSettlement.contract.FilterLogs(opts, "StakeParamsSet")
+	if err != nil {
+		return nil, err
+	}
+	return &SimpleSettlementStakeParamsSetIterator{contract: _SimpleSettlement.contract, event: "StakeParamsSet", logs: logs, sub: sub}, nil
+}
+
+// WatchStakeParamsSet is a free log subscription operation binding the contract event 0xd92cec12199949c9f2093cf901bb3c2b0ee110617b326d815b571dd28f8fbf46.
+//
+// Solidity: event StakeParamsSet(uint256 minStake, uint256 unbondingPeriod)
+func (_SimpleSettlement *SimpleSettlementFilterer) WatchStakeParamsSet(opts *bind.WatchOpts, sink chan<- *SimpleSettlementStakeParamsSet) (event.Subscription, error) {
+
+	logs, sub, err := _SimpleSettlement.contract.WatchLogs(opts, "StakeParamsSet")
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(SimpleSettlementStakeParamsSet)
+				if err := _SimpleSettlement.contract.UnpackLog(event, "StakeParamsSet", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseStakeParamsSet is a log parse operation binding the contract event 0xd92cec12199949c9f2093cf901bb3c2b0ee110617b326d815b571dd28f8fbf46.
+//
+// Solidity: event StakeParamsSet(uint256 minStake, uint256 unbondingPeriod)
+func (_SimpleSettlement *SimpleSettlementFilterer) ParseStakeParamsSet(log types.Log) (*SimpleSettlementStakeParamsSet, error) {
+	event := new(SimpleSettlementStakeParamsSet)
+	if err := _SimpleSettlement.contract.UnpackLog(event, "StakeParamsSet", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// SimpleSettlementStakeWithdrawnIterator is returned from FilterStakeWithdrawn and is used to iterate over the raw logs and unpacked data for StakeWithdrawn events raised by the SimpleSettlement contract.
+type SimpleSettlementStakeWithdrawnIterator struct {
+	Event *SimpleSettlementStakeWithdrawn // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *SimpleSettlementStakeWithdrawnIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(SimpleSettlementStakeWithdrawn)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(SimpleSettlementStakeWithdrawn)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *SimpleSettlementStakeWithdrawnIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *SimpleSettlementStakeWithdrawnIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// SimpleSettlementStakeWithdrawn represents a StakeWithdrawn event raised by the SimpleSettlement contract.
+type SimpleSettlementStakeWithdrawn struct {
+	ProviderKey [32]byte
+	Owner       common.Address
+	Amount      *big.Int
+	Raw         types.Log // Blockchain specific contextual infos
+}
+
+// FilterStakeWithdrawn is a free log retrieval operation binding the contract event 0x11269c9885f555c7abc6754b6e48ba590f3653354fc22e80d9f1a3da407d139d.
+//
+// Solidity: event StakeWithdrawn(bytes32 indexed providerKey, address indexed owner, uint256 amount)
+func (_SimpleSettlement *SimpleSettlementFilterer) FilterStakeWithdrawn(opts *bind.FilterOpts, providerKey [][32]byte, owner []common.Address) (*SimpleSettlementStakeWithdrawnIterator, error) {
+
+	var providerKeyRule []interface{}
+	for _, providerKeyItem := range providerKey {
+		providerKeyRule = append(providerKeyRule, providerKeyItem)
+	}
+	var ownerRule []interface{}
+	for _, ownerItem := range owner {
+		ownerRule = append(ownerRule, ownerItem)
+	}
+
+	logs, sub, err := _SimpleSettlement.contract.FilterLogs(opts, "StakeWithdrawn", providerKeyRule, ownerRule)
+	if err != nil {
+		return nil, err
+	}
+	return &SimpleSettlementStakeWithdrawnIterator{contract: _SimpleSettlement.contract, event: "StakeWithdrawn", logs: logs, sub: sub}, nil
+}
+
+// WatchStakeWithdrawn is a free log subscription operation binding the contract event 0x11269c9885f555c7abc6754b6e48ba590f3653354fc22e80d9f1a3da407d139d.
+//
+// Solidity: event StakeWithdrawn(bytes32 indexed providerKey, address indexed owner, uint256 amount)
+func (_SimpleSettlement *SimpleSettlementFilterer) WatchStakeWithdrawn(opts *bind.WatchOpts, sink chan<- *SimpleSettlementStakeWithdrawn, providerKey [][32]byte, owner []common.Address) (event.Subscription, error) {
+
+	var providerKeyRule []interface{}
+	for _, providerKeyItem := range providerKey {
+		providerKeyRule = append(providerKeyRule, providerKeyItem)
+	}
+	var ownerRule []interface{}
+	for _, ownerItem := range owner {
+		ownerRule = append(ownerRule, ownerItem)
+	}
+
+	logs, sub, err := _SimpleSettlement.contract.WatchLogs(opts, "StakeWithdrawn", providerKeyRule, ownerRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(SimpleSettlementStakeWithdrawn)
+				if err := _SimpleSettlement.contract.UnpackLog(event, "StakeWithdrawn", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseStakeWithdrawn is a log parse operation binding the contract event 0x11269c9885f555c7abc6754b6e48ba590f3653354fc22e80d9f1a3da407d139d.
+//
+// Solidity: event StakeWithdrawn(bytes32 indexed providerKey, address indexed owner, uint256 amount)
+func (_SimpleSettlement *SimpleSettlementFilterer) ParseStakeWithdrawn(log types.Log) (*SimpleSettlementStakeWithdrawn, error) {
+	event := new(SimpleSettlementStakeWithdrawn)
+	if err := _SimpleSettlement.contract.UnpackLog(event, "StakeWithdrawn", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// SimpleSettlementStakedIterator is returned from FilterStaked and is used to iterate over the raw logs and unpacked data for Staked events raised by the SimpleSettlement contract.
+type SimpleSettlementStakedIterator struct {
+	Event *SimpleSettlementStaked // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *SimpleSettlementStakedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(SimpleSettlementStaked)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(SimpleSettlementStaked)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *SimpleSettlementStakedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *SimpleSettlementStakedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// SimpleSettlementStaked represents a Staked event raised by the SimpleSettlement contract.
+type SimpleSettlementStaked struct {
+	ProviderKey [32]byte
+	Owner       common.Address
+	Amount      *big.Int
+	Models      []string
+	Raw         types.Log // Blockchain specific contextual infos
+}
+
+// FilterStaked is a free log retrieval operation binding the contract event 0xb706263adac0dec5fa28765dcd69a7cde82d6b1607f2aad1c8dde1fd8e7c30f7.
+//
+// Solidity: event Staked(bytes32 indexed providerKey, address indexed owner, uint256 amount, string[] models)
+func (_SimpleSettlement *SimpleSettlementFilterer) FilterStaked(opts *bind.FilterOpts, providerKey [][32]byte, owner []common.Address) (*SimpleSettlementStakedIterator, error) {
+
+	var providerKeyRule []interface{}
+	for _, providerKeyItem := range providerKey {
+		providerKeyRule = append(providerKeyRule, providerKeyItem)
+	}
+	var ownerRule []interface{}
+	for _, ownerItem := range owner {
+		ownerRule = append(ownerRule, ownerItem)
+	}
+
+	logs, sub, err := _SimpleSettlement.contract.FilterLogs(opts, "Staked", providerKeyRule, ownerRule)
+	if err != nil {
+		return nil, err
+	}
+	return &SimpleSettlementStakedIterator{contract: _SimpleSettlement.contract, event: "Staked", logs: logs, sub: sub}, nil
+}
+
+// WatchStaked is a free log subscription operation binding the contract event 0xb706263adac0dec5fa28765dcd69a7cde82d6b1607f2aad1c8dde1fd8e7c30f7.
+//
+// Solidity: event Staked(bytes32 indexed providerKey, address indexed owner, uint256 amount, string[] models)
+func (_SimpleSettlement *SimpleSettlementFilterer) WatchStaked(opts *bind.WatchOpts, sink chan<- *SimpleSettlementStaked, providerKey [][32]byte, owner []common.Address) (event.Subscription, error) {
+
+	var providerKeyRule []interface{}
+	for _, providerKeyItem := range providerKey {
+		providerKeyRule = append(providerKeyRule, providerKeyItem)
+	}
+	var ownerRule []interface{}
+	for _, ownerItem := range owner {
+		ownerRule = append(ownerRule, ownerItem)
+	}
+
+	logs, sub, err := _SimpleSettlement.contract.WatchLogs(opts, "Staked", providerKeyRule, ownerRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(SimpleSettlementStaked)
+				if err := _SimpleSettlement.contract.UnpackLog(event, "Staked", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseStaked is a log parse operation binding the contract event 0xb706263adac0dec5fa28765dcd69a7cde82d6b1607f2aad1c8dde1fd8e7c30f7.
+//
+// Solidity: event Staked(bytes32 indexed providerKey, address indexed owner, uint256 amount, string[] models)
+func (_SimpleSettlement *SimpleSettlementFilterer) ParseStaked(log types.Log) (*SimpleSettlementStaked, error) {
+	event := new(SimpleSettlementStaked)
+	if err := _SimpleSettlement.contract.UnpackLog(event, "Staked", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// SimpleSettlementUnbondRequestedIterator is returned from FilterUnbondRequested and is used to iterate over the raw logs and unpacked data for UnbondRequested events raised by the SimpleSettlement contract.
+type SimpleSettlementUnbondRequestedIterator struct {
+	Event *SimpleSettlementUnbondRequested // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *SimpleSettlementUnbondRequestedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(SimpleSettlementUnbondRequested)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(SimpleSettlementUnbondRequested)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *SimpleSettlementUnbondRequestedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *SimpleSettlementUnbondRequestedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// SimpleSettlementUnbondRequested represents a UnbondRequested event raised by the SimpleSettlement contract.
+type SimpleSettlementUnbondRequested struct {
+	ProviderKey [32]byte
+	UnbondingAt *big.Int
+	Raw         types.Log // Blockchain specific contextual infos
+}
+
+// FilterUnbondRequested is a free log retrieval operation binding the contract event 0x72e23ace8891ee7addc6cb17bf889c592ff7cbe5dbc02a1dc4ddba8a3f11920a.
+//
+// Solidity: event UnbondRequested(bytes32 indexed providerKey, uint256 unbondingAt)
+func (_SimpleSettlement *SimpleSettlementFilterer) FilterUnbondRequested(opts *bind.FilterOpts, providerKey [][32]byte) (*SimpleSettlementUnbondRequestedIterator, error) {
+
+	var providerKeyRule []interface{}
+	for _, providerKeyItem := range providerKey {
+		providerKeyRule = append(providerKeyRule, providerKeyItem)
+	}
+
+	logs, sub, err := _SimpleSettlement.contract.FilterLogs(opts, "UnbondRequested", providerKeyRule)
+	if err != nil {
+		return nil, err
+	}
+	return &SimpleSettlementUnbondRequestedIterator{contract: _SimpleSettlement.contract, event: "UnbondRequested", logs: logs, sub: sub}, nil
+}
+
+// WatchUnbondRequested is a free log subscription operation binding the contract event 0x72e23ace8891ee7addc6cb17bf889c592ff7cbe5dbc02a1dc4ddba8a3f11920a.
+//
+// Solidity: event UnbondRequested(bytes32 indexed providerKey, uint256 unbondingAt)
+func (_SimpleSettlement *SimpleSettlementFilterer) WatchUnbondRequested(opts *bind.WatchOpts, sink chan<- *SimpleSettlementUnbondRequested, providerKey [][32]byte) (event.Subscription, error) {
+
+	var providerKeyRule []interface{}
+	for _, providerKeyItem := range providerKey {
+		providerKeyRule = append(providerKeyRule, providerKeyItem)
+	}
+
+	logs, sub, err := _SimpleSettlement.contract.WatchLogs(opts, "UnbondRequested", providerKeyRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(SimpleSettlementUnbondRequested)
+				if err := _SimpleSettlement.contract.UnpackLog(event, "UnbondRequested", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseUnbondRequested is a log parse operation binding the contract event 0x72e23ace8891ee7addc6cb17bf889c592ff7cbe5dbc02a1dc4ddba8a3f11920a.
+//
+// Solidity: event UnbondRequested(bytes32 indexed providerKey, uint256 unbondingAt)
+func (_SimpleSettlement *SimpleSettlementFilterer) ParseUnbondRequested(log types.Log) (*SimpleSettlementUnbondRequested, error) {
+	event := new(SimpleSettlementUnbondRequested)
+	if err := _SimpleSettlement.contract.UnpackLog(event, "UnbondRequested", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// SimpleSettlementWithdrawnIterator is returned from FilterWithdrawn and is used to iterate over the raw logs and unpacked data for Withdrawn events raised by the SimpleSettlement contract.
+type SimpleSettlementWithdrawnIterator struct {
+	Event *SimpleSettlementWithdrawn // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *SimpleSettlementWithdrawnIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(SimpleSettlementWithdrawn)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(SimpleSettlementWithdrawn)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *SimpleSettlementWithdrawnIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *SimpleSettlementWithdrawnIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// SimpleSettlementWithdrawn represents a Withdrawn event raised by the SimpleSettlement contract.
+type SimpleSettlementWithdrawn struct {
+	Provider common.Address
+	Amount   *big.Int
+	Raw      types.Log // Blockchain specific contextual infos
+}
+
+// FilterWithdrawn is a free log retrieval operation binding the contract event 0x7084f5476618d8e60b11ef0d7d3f06914655adb8793e28ff7f018d4c76d505d5.
+//
+// Solidity: event Withdrawn(address indexed provider, uint256 amount)
+func (_SimpleSettlement *SimpleSettlementFilterer) FilterWithdrawn(opts *bind.FilterOpts, provider []common.Address) (*SimpleSettlementWithdrawnIterator, error) {
+
+	var providerRule []interface{}
+	for _, providerItem := range provider {
+		providerRule = append(providerRule, providerItem)
+	}
+
+	logs, sub, err := _SimpleSettlement.contract.FilterLogs(opts, "Withdrawn", providerRule)
+	if err != nil {
+		return nil, err
+	}
+	return &SimpleSettlementWithdrawnIterator{contract: _SimpleSettlement.contract, event: "Withdrawn", logs: logs, sub: sub}, nil
+}
+
+// WatchWithdrawn is a free log subscription operation binding the contract event 0x7084f5476618d8e60b11ef0d7d3f06914655adb8793e28ff7f018d4c76d505d5.
+//
+// Solidity: event Withdrawn(address indexed provider, uint256 amount)
+func (_SimpleSettlement *SimpleSettlementFilterer) WatchWithdrawn(opts *bind.WatchOpts, sink chan<- *SimpleSettlementWithdrawn, provider []common.Address) (event.Subscription, error) {
+
+	var providerRule []interface{}
+	for _, providerItem := range provider {
+		providerRule = append(providerRule, providerItem)
+	}
+
+	logs, sub, err := _SimpleSettlement.contract.WatchLogs(opts, "Withdrawn", providerRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(SimpleSettlementWithdrawn)
+				if err := _SimpleSettlement.contract.UnpackLog(event, "Withdrawn", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseWithdrawn is a log parse operation binding the contract event 0x7084f5476618d8e60b11ef0d7d3f06914655adb8793e28ff7f018d4c76d505d5.
+//
+// Solidity: event Withdrawn(address indexed provider, uint256 amount)
+func (_SimpleSettlement *SimpleSettlementFilterer) ParseWithdrawn(log types.Log) (*SimpleSettlementWithdrawn, error) {
+	event := new(SimpleSettlementWithdrawn)
+	if err := _SimpleSettlement.contract.UnpackLog(event, "Withdrawn", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
@@ -0,0 +1,71 @@
+// Package blockchaintest provides contracts and helpers for tests that run
+// the blockchain client against a simulated chain.
+package blockchaintest
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+// RequireBytecode skips t if any of the contracts' bytecode is missing
+// from the bindings, or fails it if QUIVER_REQUIRE_BYTECODE is set, as it
+// is in CI.
+func RequireBytecode(t testing.TB, bins ...string) {
+	t.Helper()
+	for _, bin := range bins {
+		if bin != "" {
+			continue
+		}
+		const regenerate = "run \"make bindings\" to compile the contracts and regenerate them"
+		if os.Getenv("QUIVER_REQUIRE_BYTECODE") != "" {
+			t.Fatal("bindings have no bytecode; " + regenerate)
+		}
+		t.Skip("bindings have no bytecode; " + regenerate)
+	}
+}
+
+// Deploy deploys a contract from its binding's bytecode.
+func Deploy(t testing.TB, opts *bind.TransactOpts, meta *bind.MetaData, backend bind.ContractBackend, params ...interface{}) common.Address {
+	t.Helper()
+	parsed, err := meta.GetAbi()
+	require.NoError(t, err)
+	addr, _, _, err := bind.DeployContract(opts, *parsed, common.FromHex(meta.Bin), backend, params...)
+	require.NoError(t, err)
+	return addr
+}
+
+// RootLogger returns code that emits PayoutRootCommitted(epoch, root,
+// treeSize) for a commitPayoutRoot call and RootCommitted for any other call
+// with the same arguments, standing in for the settlement contract.
+func RootLogger() []byte {
+	code := []byte{
+		0x60, 0x40, 0x60, 0x24, 0x60, 0x00, 0x37, // CALLDATACOPY(0, 36, 64): root, treeSize
+		0x60, 0x04, 0x35, // CALLDATALOAD(4): epoch
+		0x60, 0x00, 0x35, 0x60, 0xe0, 0x1c, // CALLDATALOAD(0) >> 224: the selector
+		0x63, // PUSH4 commitPayoutRoot's selector
+	}
+	code = append(code, crypto.Keccak256([]byte("commitPayoutRoot(uint256,bytes32,uint256)"))[:4]...)
+	code = append(code, 0x14, 0x60, 0x00, 0x57) // JUMPI to the payout log if EQ, at an offset set below
+	jump := len(code) - 2
+
+	code = logEvent(code, "RootCommitted(uint256,bytes32,uint256)")
+	code[jump] = byte(len(code))
+	code = append(code, 0x5b) // JUMPDEST
+	return logEvent(code, "PayoutRootCommitted(uint256,bytes32,uint256)")
+}
+
+// logEvent appends code logging the named event with the epoch on the
+// stack as its topic and memory 0-64 as its data, then stopping.
+func logEvent(code []byte, event string) []byte {
+	code = append(code, 0x7f) // PUSH32 the event ID
+	code = append(code, crypto.Keccak256([]byte(event))...)
+	return append(code,
+		0x60, 0x40, 0x60, 0x00, 0xa2, // LOG2(0, 64, id, epoch)
+		0x00, // STOP
+	)
+}
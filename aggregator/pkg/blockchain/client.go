@@ -3,11 +3,16 @@ package blockchain
 import (
 	"context"
 	"crypto/ecdsa"
+	"errors"
 	"fmt"
+	"math"
 	"math/big"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -25,6 +30,17 @@ type Config struct {
 	GasLimit         uint64
 	MaxGasPrice      *big.Int
 	ConfirmationWait time.Duration
+	// Confirmations is how many blocks, counting the one that includes a
+	// transaction, make it confirmed. Zero counts as one.
+	Confirmations uint64
+	// PollInterval is how often a pending transaction is checked; zero
+	// means every three seconds
+	PollInterval time.Duration
+	// A transaction not mined within ConfirmationWait is replaced by one
+	// paying FeeBumpPercent more gas, at most MaxFeeBumps times. Nodes
+	// refuse replacements that pay less than 10% more.
+	FeeBumpPercent int
+	MaxFeeBumps    int
 }
 
 // DefaultPolygonConfig returns default configuration for Polygon
@@ -35,6 +51,9 @@ func DefaultPolygonConfig() *Config {
 		GasLimit:         3000000,
 		MaxGasPrice:      big.NewInt(500_000_000_000), // 500 Gwei
 		ConfirmationWait: 30 * time.Second,
+		Confirmations:    5,
+		FeeBumpPercent:   20,
+		MaxFeeBumps:      3,
 	}
 }
 
@@ -46,18 +65,48 @@ func DefaultPolygonMumbaiConfig() *Config {
 		GasLimit:         3000000,
 		MaxGasPrice:      big.NewInt(50_000_000_000), // 50 Gwei
 		ConfirmationWait: 15 * time.Second,
+		Confirmations:    2,
+		FeeBumpPercent:   20,
+		MaxFeeBumps:      3,
 	}
 }
 
+//go:generate go run ./internal/bindgen -dir ../../../contracts/abi -pkg blockchain -out bindings.go SimpleSettlement SimpleQUIVToken
+
+// settlementABI is SimpleSettlement's ABI, for the calls the client packs
+// itself and the events it filters
+var settlementABI = mustParseABI(SimpleSettlementMetaData.ABI)
+
+func mustParseABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// EthBackend is what the client needs from a node. ethclient.Client
+// implements it, as does go-ethereum's simulated backend.
+type EthBackend interface {
+	bind.ContractBackend
+	bind.DeployBackend
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+}
+
 // Client interfaces with the blockchain
 type Client struct {
-	ethClient       *ethclient.Client
-	privateKey      *ecdsa.PrivateKey
-	publicAddress   common.Address
-	config          *Config
-	settlementAddr  common.Address
-	tokenAddr       common.Address
-	transactOpts    *bind.TransactOpts
+	ethClient      EthBackend
+	closeClient    func()
+	privateKey     *ecdsa.PrivateKey
+	publicAddress  common.Address
+	config         *Config
+	settlementAddr common.Address
+	tokenAddr      common.Address
+	settlement     *SimpleSettlement
+	token          *SimpleQUIVToken
+
+	// sendMu keeps nonces in order across concurrent sends
+	sendMu sync.Mutex
 }
 
 // NewClient creates a new blockchain client
@@ -68,8 +117,20 @@ func NewClient(config *Config) (*Client, error) {
 		return nil, fmt.Errorf("failed to connect to blockchain: %w", err)
 	}
 
+	client, err := NewClientWithBackend(config, ethClient)
+	if err != nil {
+		ethClient.Close()
+		return nil, err
+	}
+	client.closeClient = ethClient.Close
+	return client, nil
+}
+
+// NewClientWithBackend creates a client that talks to the chain through
+// backend, such as a simulated one. Close leaves the backend open.
+func NewClientWithBackend(config *Config, ethClient EthBackend) (*Client, error) {
 	// Load private key
-	privateKey, err := crypto.HexToECDSA(config.PrivateKey)
+	privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(config.PrivateKey, "0x"))
 	if err != nil {
 		return nil, fmt.Errorf("failed to load private key: %w", err)
 	}
@@ -82,22 +143,27 @@ func NewClient(config *Config) (*Client, error) {
 
 	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
 
-	// Create transaction options
-	transactOpts, err := bind.NewKeyedTransactorWithChainID(privateKey, big.NewInt(config.ChainID))
+	settlementAddr := common.HexToAddress(config.ContractAddress)
+	settlement, err := NewSimpleSettlement(settlementAddr, ethClient)
+	if err != nil {
+		return nil, err
+	}
+	tokenAddr := common.HexToAddress(config.TokenAddress)
+	token, err := NewSimpleQUIVToken(tokenAddr, ethClient)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create transactor: %w", err)
+		return nil, err
 	}
-	transactOpts.GasLimit = config.GasLimit
-	transactOpts.GasPrice = config.MaxGasPrice
 
 	return &Client{
 		ethClient:      ethClient,
 		privateKey:     privateKey,
 		publicAddress:  fromAddress,
 		config:         config,
-		settlementAddr: common.HexToAddress(config.ContractAddress),
-		tokenAddr:      common.HexToAddress(config.TokenAddress),
-		transactOpts:   transactOpts,
+		settlementAddr: settlementAddr,
+		tokenAddr:      tokenAddr,
+		settlement:     settlement,
+		token:          token,
+		closeClient:    func() {},
 	}, nil
 }
 
@@ -108,75 +174,80 @@ func (c *Client) GetBalance(ctx context.Context) (*big.Int, error) {
 
 // GetTokenBalance returns the QUIV token balance
 func (c *Client) GetTokenBalance(ctx context.Context, address common.Address) (*big.Int, error) {
-	// This would call the balanceOf method on the token contract
-	// Simplified for now
-	return big.NewInt(0), nil
+	return c.token.BalanceOf(&bind.CallOpts{Context: ctx}, address)
 }
 
-// OpenChannel opens a payment channel
-func (c *Client) OpenChannel(ctx context.Context, provider common.Address, amount *big.Int) (*types.Transaction, error) {
-	// This would interact with the settlement contract to open a channel
-	// Simplified implementation
-	nonce, err := c.ethClient.PendingNonceAt(ctx, c.publicAddress)
+// ProviderBalance returns what the settlement contract owes a provider,
+// which the provider collects with withdraw.
+func (c *Client) ProviderBalance(ctx context.Context, provider common.Address) (*big.Int, error) {
+	return c.settlement.ProviderBalances(&bind.CallOpts{Context: ctx}, provider)
+}
+
+// StakeOf returns the stake bound to a provider's receipt key: the address
+// that owns it, the amount and, once the owner has asked to unbond, when it
+// can be withdrawn. owner is empty if the key has no stake.
+func (c *Client) StakeOf(ctx context.Context, key [32]byte) (string, *big.Int, time.Time, error) {
+	stake, err := c.settlement.GetStake(&bind.CallOpts{Context: ctx}, key)
 	if err != nil {
-		return nil, err
+		return "", nil, time.Time{}, err
+	}
+	var owner string
+	if stake.StakeOwner != (common.Address{}) {
+		owner = stake.StakeOwner.Hex()
 	}
+	var unbondingAt time.Time
+	if stake.UnbondingAt.Sign() > 0 {
+		if !stake.UnbondingAt.IsInt64() {
+			return "", nil, time.Time{}, fmt.Errorf("unbonding time %s out of range", stake.UnbondingAt)
+		}
+		unbondingAt = time.Unix(stake.UnbondingAt.Int64(), 0)
+	}
+	return owner, stake.Amount, unbondingAt, nil
+}
 
-	gasPrice, err := c.ethClient.SuggestGasPrice(ctx)
+// ProviderModels returns the models a staked provider key declared.
+func (c *Client) ProviderModels(ctx context.Context, key [32]byte) ([]string, error) {
+	stake, err := c.settlement.GetStake(&bind.CallOpts{Context: ctx}, key)
 	if err != nil {
 		return nil, err
 	}
+	return stake.Models, nil
+}
 
-	tx := types.NewTransaction(
-		nonce,
-		c.settlementAddr,
-		big.NewInt(0), // Value in ETH
-		c.config.GasLimit,
-		gasPrice,
-		nil, // Data would contain the encoded function call
-	)
-
-	chainID := big.NewInt(c.config.ChainID)
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), c.privateKey)
+// StakeParams returns the settlement contract's minimum stake and how long
+// an unbonding stake stays locked.
+func (c *Client) StakeParams(ctx context.Context) (*big.Int, time.Duration, error) {
+	opts := &bind.CallOpts{Context: ctx}
+	minStake, err := c.settlement.MinStake(opts)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
-
-	err = c.ethClient.SendTransaction(ctx, signedTx)
+	period, err := c.settlement.UnbondingPeriod(opts)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
-
-	return signedTx, nil
+	if !period.IsInt64() || period.Int64() > int64(math.MaxInt64/time.Second) {
+		return nil, 0, fmt.Errorf("unbonding period %s out of range", period)
+	}
+	return minStake, time.Duration(period.Int64()) * time.Second, nil
 }
 
-// SubmitBatch submits a batch of receipts for settlement
+// SubmitBatch commits a batch's Merkle root with the settlement contract's
+// commitRoot(epoch, root, receiptCount). It returns once the node accepts
+// the transaction; see AnchorBatch to wait for it.
 func (c *Client) SubmitBatch(ctx context.Context, batch ReceiptBatch) (*types.Transaction, error) {
-	// This would encode and submit the batch to the settlement contract
-	// Simplified implementation
-	return c.OpenChannel(ctx, c.publicAddress, big.NewInt(0))
+	if batch.MerkleRoot == ([32]byte{}) || batch.ReceiptCount == 0 {
+		return nil, fmt.Errorf("batch for epoch %d is empty", batch.Epoch)
+	}
+	return c.transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return c.settlement.CommitRoot(opts,
+			new(big.Int).SetUint64(batch.Epoch), batch.MerkleRoot, new(big.Int).SetUint64(batch.ReceiptCount))
+	})
 }
 
 // WaitForConfirmation waits for a transaction to be confirmed
 func (c *Client) WaitForConfirmation(ctx context.Context, tx *types.Transaction) (*types.Receipt, error) {
-	ticker := time.NewTicker(3 * time.Second)
-	defer ticker.Stop()
-
-	timeout := time.After(c.config.ConfirmationWait)
-
-	for {
-		select {
-		case <-ticker.C:
-			receipt, err := c.ethClient.TransactionReceipt(ctx, tx.Hash())
-			if err == nil {
-				return receipt, nil
-			}
-		case <-timeout:
-			return nil, fmt.Errorf("transaction confirmation timeout")
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		}
-	}
+	return c.waitMined(ctx, []*types.Transaction{tx})
 }
 
 // EstimateGas estimates gas for a transaction
@@ -190,7 +261,136 @@ func (c *Client) EstimateGas(ctx context.Context, to common.Address, data []byte
 	return c.ethClient.EstimateGas(ctx, msg)
 }
 
+// SubmitClaim pays one receipt through the settlement contract's
+// processReceipt. The contract checks proof, an RFC 6962 inclusion proof of
+// leafHash at leafIndex, against the epoch's committed root, and pays each
+// leaf once. It returns the transaction hash once the node accepts the
+// transaction.
+func (c *Client) SubmitClaim(ctx context.Context, epoch, leafIndex uint64, leafHash [32]byte, proof [][32]byte, provider string, amount *big.Int) (string, error) {
+	if !common.IsHexAddress(provider) {
+		return "", fmt.Errorf("invalid provider address %q", provider)
+	}
+	tx, err := c.transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return c.settlement.ProcessReceipt(opts, new(big.Int).SetUint64(epoch), new(big.Int).SetUint64(leafIndex),
+			leafHash, proof, common.HexToAddress(provider), amount)
+	})
+	if err != nil {
+		return "", err
+	}
+	return tx.Hash().Hex(), nil
+}
+
+// Slash sends a transaction taking up to amount from the stake bound to a
+// provider key, as the penalty for the evidence hashed as evidenceHash. It
+// returns the transaction hash without waiting for it to be mined.
+func (c *Client) Slash(ctx context.Context, key [32]byte, amount *big.Int, evidenceHash [32]byte) (string, error) {
+	tx, err := c.transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return c.settlement.Slash(opts, key, amount, evidenceHash)
+	})
+	if err != nil {
+		return "", err
+	}
+	return tx.Hash().Hex(), nil
+}
+
+// OpenDispute sends a transaction opening a dispute against a provider
+// key, which keeps its stake from being withdrawn until the dispute is
+// closed. It returns the transaction hash without waiting for it to be
+// mined.
+func (c *Client) OpenDispute(ctx context.Context, key [32]byte) (string, error) {
+	tx, err := c.transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return c.settlement.OpenDispute(opts, key)
+	})
+	if err != nil {
+		return "", err
+	}
+	return tx.Hash().Hex(), nil
+}
+
+// CloseDispute sends a transaction closing one of the disputes open
+// against a provider key.
+func (c *Client) CloseDispute(ctx context.Context, key [32]byte) (string, error) {
+	tx, err := c.transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return c.settlement.CloseDispute(opts, key)
+	})
+	if err != nil {
+		return "", err
+	}
+	return tx.Hash().Hex(), nil
+}
+
+// TransactionStatus reports "pending" until the transaction is mined with
+// the configured confirmations, then "confirmed" or "failed" by its receipt
+// status.
+func (c *Client) TransactionStatus(ctx context.Context, txHash string) (string, error) {
+	receipt, err := c.ethClient.TransactionReceipt(ctx, common.HexToHash(txHash))
+	if errors.Is(err, ethereum.NotFound) {
+		return "pending", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	ok, err := c.confirmed(ctx, receipt)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "pending", nil
+	}
+	if receipt.Status == types.ReceiptStatusSuccessful {
+		return "confirmed", nil
+	}
+	return "failed", nil
+}
+
+// transact sends a contract call made through a binding, at the next
+// nonce and the suggested gas price capped at the configured maximum.
+func (c *Client) transact(ctx context.Context, call func(*bind.TransactOpts) (*types.Transaction, error)) (*types.Transaction, error) {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	nonce, err := c.ethClient.PendingNonceAt(ctx, c.publicAddress)
+	if err != nil {
+		return nil, err
+	}
+	gasPrice, err := c.ethClient.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if c.config.MaxGasPrice != nil && gasPrice.Cmp(c.config.MaxGasPrice) > 0 {
+		gasPrice = c.config.MaxGasPrice
+	}
+
+	return call(&bind.TransactOpts{
+		From:     c.publicAddress,
+		Nonce:    new(big.Int).SetUint64(nonce),
+		Signer:   c.sign,
+		GasPrice: gasPrice,
+		GasLimit: c.config.GasLimit,
+		Context:  ctx,
+	})
+}
+
+// sign is the bind.SignerFn of the client's key.
+func (c *Client) sign(from common.Address, tx *types.Transaction) (*types.Transaction, error) {
+	if from != c.publicAddress {
+		return nil, bind.ErrNotAuthorized
+	}
+	return types.SignTx(tx, types.NewEIP155Signer(big.NewInt(c.config.ChainID)), c.privateKey)
+}
+
+func (c *Client) signAndSend(ctx context.Context, tx *types.Transaction) (*types.Transaction, error) {
+	signedTx, err := c.sign(c.publicAddress, tx)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.ethClient.SendTransaction(ctx, signedTx); err != nil {
+		return nil, err
+	}
+	return signedTx, nil
+}
+
 // Close closes the client connection
 func (c *Client) Close() {
-	c.ethClient.Close()
+	c.closeClient()
 }
\ No newline at end of file
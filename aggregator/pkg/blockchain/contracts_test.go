@@ -0,0 +1,248 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quiver/aggregator/pkg/blockchain/blockchaintest"
+	"github.com/quiver/aggregator/pkg/merkle"
+	"github.com/quiver/aggregator/pkg/payout"
+)
+
+func decodeHashes(t *testing.T, hashes []string) [][32]byte {
+	t.Helper()
+	out := make([][32]byte, len(hashes))
+	for i, h := range hashes {
+		b, err := hex.DecodeString(h)
+		require.NoError(t, err)
+		copy(out[i][:], b)
+	}
+	return out
+}
+
+// TestSettlementContracts deploys the token and settlement contracts,
+// commits an epoch's receipt root, pays a receipt with its inclusion proof
+// and withdraws it, then pays another address from the epoch's payout
+// root. The provider then stakes, is slashed and cannot withdraw its stake
+// while a dispute is open.
+func TestSettlementContracts(t *testing.T) {
+	blockchaintest.RequireBytecode(t, SimpleSettlementMetaData.Bin, SimpleQUIVTokenMetaData.Bin)
+
+	// The client's key deploys the token, then the settlement contract
+	var owner common.Address
+	client, sim := newSimulatedClient(t, common.Address{}, nil, func(config *Config) {
+		key, err := crypto.HexToECDSA(config.PrivateKey)
+		require.NoError(t, err)
+		owner = crypto.PubkeyToAddress(key.PublicKey)
+		config.TokenAddress = crypto.CreateAddress(owner, 0).Hex()
+		config.ContractAddress = crypto.CreateAddress(owner, 1).Hex()
+		config.GasLimit = 1_000_000
+		config.Confirmations = 1
+	})
+	ctx := context.Background()
+
+	deployer, err := bind.NewKeyedTransactorWithChainID(client.privateKey, big.NewInt(1337))
+	require.NoError(t, err)
+	require.Equal(t, client.tokenAddr, blockchaintest.Deploy(t, deployer, SimpleQUIVTokenMetaData, sim))
+	sim.Commit()
+	require.Equal(t, client.settlementAddr, blockchaintest.Deploy(t, deployer, SimpleSettlementMetaData, sim, client.tokenAddr))
+	sim.Commit()
+
+	_, err = client.token.Transfer(deployer, client.settlementAddr, big.NewInt(1e18))
+	require.NoError(t, err)
+	sim.Commit()
+
+	// Commit an epoch of five receipts
+	tree := merkle.NewTree()
+	for _, r := range []string{"r0", "r1", "r2", "r3", "r4"} {
+		tree.AddLeaf([]byte(r))
+	}
+	require.NoError(t, tree.Build())
+	batch := ReceiptBatch{Epoch: 19723, ReceiptCount: uint64(tree.Size())}
+	copy(batch.MerkleRoot[:], common.FromHex(tree.Root()))
+
+	tx, err := client.SubmitBatch(ctx, batch)
+	require.NoError(t, err)
+	sim.Commit()
+	receipt, err := client.WaitForConfirmation(ctx, tx)
+	require.NoError(t, err)
+	require.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+
+	txHash, root, err := client.FindAnchor(ctx, 19723)
+	require.NoError(t, err)
+	require.Equal(t, tx.Hash().Hex(), txHash)
+	require.Equal(t, batch.MerkleRoot, root)
+
+	providerKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	provider := crypto.PubkeyToAddress(providerKey.PublicKey)
+
+	// claim pays to for receipt r at leafIndex
+	claim := func(to common.Address, r, leafIndex int, proof [][32]byte) string {
+		t.Helper()
+		var leaf [32]byte
+		copy(leaf[:], merkle.LeafHash([]byte(fmt.Sprintf("r%d", r))))
+		txHash, err := client.SubmitClaim(ctx, 19723, uint64(leafIndex), leaf, proof, to.Hex(), big.NewInt(1500))
+		require.NoError(t, err)
+		sim.Commit()
+		status, err := client.TransactionStatus(ctx, txHash)
+		require.NoError(t, err)
+		return status
+	}
+
+	proof, err := tree.Proof(3)
+	require.NoError(t, err)
+	wrong, err := tree.Proof(2)
+	require.NoError(t, err)
+
+	require.Equal(t, "failed", claim(provider, 3, 3, decodeHashes(t, wrong.Hashes)), "paid with another leaf's proof")
+	require.Equal(t, "confirmed", claim(provider, 3, 3, decodeHashes(t, proof.Hashes)))
+	require.Equal(t, "failed", claim(provider, 3, 3, decodeHashes(t, proof.Hashes)), "paid a leaf twice")
+
+	owed, err := client.ProviderBalance(ctx, provider)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(1500), owed)
+
+	// The provider pays for its own withdrawal
+	fund := func(to common.Address) {
+		t.Helper()
+		nonce, err := sim.PendingNonceAt(ctx, owner)
+		require.NoError(t, err)
+		gasPrice, err := sim.SuggestGasPrice(ctx)
+		require.NoError(t, err)
+		signed, err := client.sign(owner, types.NewTransaction(nonce, to, big.NewInt(1e17), 21_000, gasPrice, nil))
+		require.NoError(t, err)
+		require.NoError(t, sim.SendTransaction(ctx, signed))
+		sim.Commit()
+	}
+	fund(provider)
+
+	providerOpts, err := bind.NewKeyedTransactorWithChainID(providerKey, big.NewInt(1337))
+	require.NoError(t, err)
+	_, err = client.settlement.Withdraw(providerOpts)
+	require.NoError(t, err)
+	sim.Commit()
+
+	balance, err := client.GetTokenBalance(ctx, provider)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(1500), balance)
+	owed, err = client.ProviderBalance(ctx, provider)
+	require.NoError(t, err)
+	require.Zero(t, owed.Sign())
+
+	// The epoch's payout root pays each address once: the provider, paid
+	// per receipt, cannot claim its payout too, and an address that
+	// claimed its payout is not paid per receipt
+	payeeKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	payee := crypto.PubkeyToAddress(payeeKey.PublicKey)
+	fund(payee)
+	payeeOpts, err := bind.NewKeyedTransactorWithChainID(payeeKey, big.NewInt(1337))
+	require.NoError(t, err)
+
+	payouts := &payout.Payouts{Leaves: []*payout.Leaf{
+		{Address: provider.Hex(), Amount: big.NewInt(1500), ReceiptCount: 1, ReceiptsRoot: tree.Root()},
+		{Address: payee.Hex(), Amount: big.NewInt(2500), ReceiptCount: 4, ReceiptsRoot: tree.Root()},
+	}}
+	payoutTree, err := payouts.Tree()
+	require.NoError(t, err)
+	var payoutRoot [32]byte
+	copy(payoutRoot[:], common.FromHex(payoutTree.Root()))
+	_, err = client.settlement.CommitPayoutRoot(deployer, big.NewInt(19723), payoutRoot, big.NewInt(2))
+	require.NoError(t, err)
+	sim.Commit()
+	_, root, err = client.FindPayoutAnchor(ctx, 19723)
+	require.NoError(t, err)
+	require.Equal(t, payoutRoot, root)
+
+	claimPayout := func(opts *bind.TransactOpts, i int) error {
+		t.Helper()
+		leaf := payouts.Leaves[i]
+		proof, err := payoutTree.Proof(i)
+		require.NoError(t, err)
+		_, err = client.settlement.ClaimPayout(opts, big.NewInt(19723), leaf.Amount, big.NewInt(int64(leaf.ReceiptCount)),
+			common.HexToHash(leaf.ReceiptsRoot), big.NewInt(int64(i)), decodeHashes(t, proof.Hashes))
+		sim.Commit()
+		return err
+	}
+	require.Error(t, claimPayout(providerOpts, 0), "paid per receipt and by payout")
+	require.NoError(t, claimPayout(payeeOpts, 1))
+	require.Error(t, claimPayout(payeeOpts, 1), "paid a payout twice")
+	owed, err = client.ProviderBalance(ctx, payee)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(2500), owed)
+	proof, err = tree.Proof(1)
+	require.NoError(t, err)
+	require.Equal(t, "failed", claim(payee, 1, 1, decodeHashes(t, proof.Hashes)), "paid by payout and per receipt")
+
+	// The provider stakes its earnings on its receipt key
+	_, err = client.settlement.SetStakeParams(deployer, big.NewInt(1000), big.NewInt(3600))
+	require.NoError(t, err)
+	_, err = client.token.Approve(providerOpts, client.settlementAddr, big.NewInt(1500))
+	require.NoError(t, err)
+	sim.Commit()
+	receiptKey := [32]byte{7}
+	_, err = client.settlement.Stake(providerOpts, receiptKey, big.NewInt(1500), []string{"llama3.2:3b"})
+	require.NoError(t, err)
+	sim.Commit()
+
+	minStake, period, err := client.StakeParams(ctx)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(1000), minStake)
+	require.Equal(t, time.Hour, period)
+	stakeOwner, staked, unbondingAt, err := client.StakeOf(ctx, receiptKey)
+	require.NoError(t, err)
+	require.Equal(t, provider.Hex(), stakeOwner)
+	require.Equal(t, big.NewInt(1500), staked)
+	require.True(t, unbondingAt.IsZero())
+	models, err := client.ProviderModels(ctx, receiptKey)
+	require.NoError(t, err)
+	require.Equal(t, []string{"llama3.2:3b"}, models)
+
+	// Slashing takes from the stake once per piece of evidence
+	evidenceHash := [32]byte{9}
+	_, err = client.Slash(ctx, receiptKey, big.NewInt(600), evidenceHash)
+	require.NoError(t, err)
+	sim.Commit()
+	_, staked, _, err = client.StakeOf(ctx, receiptKey)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(900), staked)
+	_, err = client.Slash(ctx, receiptKey, big.NewInt(600), evidenceHash)
+	require.Error(t, err)
+
+	// Evidence opens a dispute, which holds the stake past the unbonding
+	// period until it is closed
+	_, err = client.OpenDispute(ctx, receiptKey)
+	require.NoError(t, err)
+	sim.Commit()
+	_, err = client.settlement.RequestUnbond(providerOpts, receiptKey)
+	require.NoError(t, err)
+	sim.Commit()
+	_, _, unbondingAt, err = client.StakeOf(ctx, receiptKey)
+	require.NoError(t, err)
+	require.False(t, unbondingAt.IsZero())
+	require.NoError(t, sim.AdjustTime(2*time.Hour))
+	sim.Commit()
+	_, err = client.settlement.WithdrawStake(providerOpts, receiptKey)
+	require.Error(t, err, "withdrew a disputed stake")
+
+	_, err = client.CloseDispute(ctx, receiptKey)
+	require.NoError(t, err)
+	sim.Commit()
+	_, err = client.settlement.WithdrawStake(providerOpts, receiptKey)
+	require.NoError(t, err)
+	sim.Commit()
+	_, staked, _, err = client.StakeOf(ctx, receiptKey)
+	require.NoError(t, err)
+	require.Zero(t, staked.Sign())
+}
@@ -0,0 +1,57 @@
+// Command bindgen writes abigen-style Go bindings for contracts whose ABI,
+// and optionally bytecode, were exported by the contracts package's
+// "npm run abi". It uses the same generator as abigen, which does not build
+// inside this module.
+//
+//	go run ./internal/bindgen -dir ../../../contracts/abi -pkg blockchain -out bindings.go SimpleSettlement SimpleQUIVToken
+//
+// For each named contract it reads <dir>/<name>.abi and, if it exists,
+// <dir>/<name>.bin. Without bytecode the bindings cannot deploy.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+)
+
+func main() {
+	dir := flag.String("dir", ".", "directory holding <name>.abi and <name>.bin")
+	pkg := flag.String("pkg", "blockchain", "package of the generated file")
+	out := flag.String("out", "bindings.go", "file to write")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		log.Fatal("bindgen: no contracts named")
+	}
+
+	var abis, bins []string
+	for _, name := range flag.Args() {
+		abi, err := os.ReadFile(filepath.Join(*dir, name+".abi"))
+		if err != nil {
+			log.Fatalf("bindgen: %v", err)
+		}
+		bin, err := os.ReadFile(filepath.Join(*dir, name+".bin"))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			log.Fatalf("bindgen: %v", err)
+		}
+		abis = append(abis, string(abi))
+		bins = append(bins, strings.TrimSpace(string(bin)))
+	}
+
+	code, err := bind.Bind(flag.Args(), abis, bins, nil, *pkg, bind.LangGo, nil, nil)
+	if err != nil {
+		log.Fatalf("bindgen: %v", err)
+	}
+	if err := os.WriteFile(*out, []byte(code), 0o644); err != nil {
+		log.Fatalf("bindgen: %v", err)
+	}
+	fmt.Printf("wrote %s\n", *out)
+}
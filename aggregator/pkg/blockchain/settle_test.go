@@ -0,0 +1,123 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+// reverter's code is PUSH1 0 PUSH1 0 REVERT: every call to it fails
+var reverter = common.HexToAddress("0x00000000000000000000000000000000000000ff")
+
+// newSimulatedClient returns a client of a simulated chain with code, if
+// any, deployed at settlement. configure, if set, adjusts the client's
+// config and wrap, if given, stands between the client and the chain.
+func newSimulatedClient(t *testing.T, settlement common.Address, code []byte, configure func(*Config), wrap ...func(*backends.SimulatedBackend) EthBackend) (*Client, *backends.SimulatedBackend) {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	alloc := core.GenesisAlloc{
+		crypto.PubkeyToAddress(key.PublicKey): {Balance: big.NewInt(1e18)},
+		reverter:                              {Code: []byte{0x60, 0x00, 0x60, 0x00, 0xfd}},
+	}
+	if code != nil {
+		alloc[settlement] = core.GenesisAccount{Code: code, Balance: new(big.Int)}
+	}
+	sim := backends.NewSimulatedBackend(alloc, 10_000_000)
+	t.Cleanup(func() { sim.Close() })
+
+	config := DefaultPolygonConfig()
+	config.ChainID = 1337
+	config.GasLimit = 100_000
+	config.ContractAddress = settlement.Hex()
+	config.PrivateKey = hex.EncodeToString(crypto.FromECDSA(key))
+
+	if configure != nil {
+		configure(config)
+	}
+
+	var backend EthBackend = sim
+	for _, w := range wrap {
+		backend = w(sim)
+	}
+	client, err := NewClientWithBackend(config, backend)
+	require.NoError(t, err)
+	return client, sim
+}
+
+func TestSubmitClaim(t *testing.T) {
+	settlement := common.HexToAddress("0x0000000000000000000000000000000000005e77")
+	client, sim := newSimulatedClient(t, settlement, nil, func(config *Config) {
+		config.Confirmations = 2
+	})
+	ctx := context.Background()
+
+	provider := "0x00000000000000000000000000000000000000aa"
+	leaf := [32]byte{1}
+	proof := [][32]byte{{2}, {3}}
+	txHash, err := client.SubmitClaim(ctx, 19723, 5, leaf, proof, provider, big.NewInt(1500))
+	require.NoError(t, err)
+
+	status, err := client.TransactionStatus(ctx, txHash)
+	require.NoError(t, err)
+	require.Equal(t, "pending", status)
+
+	// Mined, but one block short of the confirmations
+	sim.Commit()
+	status, err = client.TransactionStatus(ctx, txHash)
+	require.NoError(t, err)
+	require.Equal(t, "pending", status)
+
+	sim.Commit()
+	status, err = client.TransactionStatus(ctx, txHash)
+	require.NoError(t, err)
+	require.Equal(t, "confirmed", status)
+
+	tx, _, err := sim.TransactionByHash(ctx, common.HexToHash(txHash))
+	require.NoError(t, err)
+	require.Equal(t, settlement, *tx.To())
+
+	args, err := settlementABI.Methods["processReceipt"].Inputs.Unpack(tx.Data()[4:])
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(19723), args[0])
+	require.Equal(t, big.NewInt(5), args[1])
+	require.Equal(t, leaf, args[2])
+	require.Equal(t, proof, args[3])
+	require.Equal(t, common.HexToAddress(provider), args[4])
+	require.Equal(t, big.NewInt(1500), args[5])
+
+	// A second send takes the next nonce
+	second, err := client.SubmitClaim(ctx, 19723, 6, leaf, nil, provider, big.NewInt(1))
+	require.NoError(t, err)
+	sim.Commit()
+	tx2, _, err := sim.TransactionByHash(ctx, common.HexToHash(second))
+	require.NoError(t, err)
+	require.Equal(t, tx.Nonce()+1, tx2.Nonce())
+
+	_, err = client.SubmitClaim(ctx, 19723, 7, leaf, nil, "not-an-address", big.NewInt(1))
+	require.Error(t, err)
+}
+
+func TestSubmitClaimReverted(t *testing.T) {
+	client, sim := newSimulatedClient(t, reverter, nil, func(config *Config) {
+		config.Confirmations = 2
+	})
+	ctx := context.Background()
+
+	txHash, err := client.SubmitClaim(ctx, 1, 0, [32]byte{1}, nil, "0x00000000000000000000000000000000000000aa", big.NewInt(1))
+	require.NoError(t, err)
+	sim.Commit()
+	sim.Commit()
+
+	status, err := client.TransactionStatus(ctx, txHash)
+	require.NoError(t, err)
+	require.Equal(t, "failed", status)
+}
@@ -1,35 +1,164 @@
 package epoch
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
 	"sync"
 	"time"
 )
 
+// State is where an epoch is in its lifecycle. Epochs only move forward:
+// open → sealing → finalized → anchoring → anchored. A seal that fails is
+// cancelled back to open.
+type State string
+
+const (
+	// StateOpen epochs accept receipts.
+	StateOpen State = "open"
+	// StateSealing epochs are having their Merkle tree built.
+	StateSealing State = "sealing"
+	// StateFinalized epochs have a fixed root. It only changes through a
+	// recorded amendment.
+	StateFinalized State = "finalized"
+	// StateAnchoring epochs are being published on chain. Their root may
+	// already be there, so they can no longer be amended.
+	StateAnchoring State = "anchoring"
+	// StateAnchored epochs have their root on chain and can no longer be
+	// amended.
+	StateAnchored State = "anchored"
+)
+
+var (
+	// ErrAlreadyFinalized means an epoch was finalized again with a
+	// different root or receipt count.
+	ErrAlreadyFinalized = errors.New("epoch already finalized")
+	// ErrInvalidTransition means the epoch is not in a state that allows
+	// the requested change.
+	ErrInvalidTransition = errors.New("invalid epoch state transition")
+	// ErrRootChanged means an epoch was amended after its root was read.
+	ErrRootChanged = errors.New("epoch root changed")
+	// ErrAmending means an amendment of the epoch is in progress.
+	ErrAmending = errors.New("epoch is being amended")
+)
+
 type Info struct {
 	Epoch        uint64    `json:"epoch"`
 	StartTime    time.Time `json:"start_time"`
 	EndTime      time.Time `json:"end_time"`
 	Root         string    `json:"root"`
 	ReceiptCount int       `json:"receipt_count"`
-	Finalized    bool      `json:"finalized"`
+	// Finalized is true once the epoch is finalized or anchored
+	Finalized bool  `json:"finalized"`
+	State     State `json:"state"`
+	// Version is 1 when the epoch is first finalized and goes up by one
+	// with each amendment
+	Version     int         `json:"version,omitempty"`
+	FinalizedAt *time.Time  `json:"finalized_at,omitempty"`
+	AnchorTx    string      `json:"anchor_tx,omitempty"`
+	AnchoredAt  *time.Time  `json:"anchored_at,omitempty"`
+	Amendments  []Amendment `json:"amendments,omitempty"`
+}
+
+// Amendment records a change to a finalized epoch's root.
+type Amendment struct {
+	Version          int       `json:"version"`
+	PrevRoot         string    `json:"prev_root"`
+	PrevReceiptCount int       `json:"prev_receipt_count"`
+	Root             string    `json:"root"`
+	ReceiptCount     int       `json:"receipt_count"`
+	Reason           string    `json:"reason"`
+	AmendedAt        time.Time `json:"amended_at"`
+	// ConsistencyProof shows that the tree with Root extends the tree with
+	// PrevRoot; see merkle.VerifyConsistency.
+	ConsistencyProof []string `json:"consistency_proof"`
+}
+
+// Persister saves epoch records so a Manager survives restarts. Records are
+// JSON documents the persister stores as given. storage.Store implements it.
+type Persister interface {
+	SaveEpoch(epoch uint64, record []byte) error
+	LoadEpochs() (map[uint64][]byte, error)
 }
 
+// Manager tracks epochs. Every state change is saved to the persister, if
+// there is one, before it takes effect. Infos it returns are never modified
+// afterwards and must not be modified by callers.
 type Manager struct {
-	epochs map[uint64]*Info
-	mu     sync.RWMutex
+	epochs    map[uint64]*Info
+	persister Persister
+	schedule  *Schedule
+	// amending holds the epochs between BeginAmending and EndAmending
+	amending map[uint64]bool
+	mu       sync.RWMutex
 }
 
+// NewManager returns a manager that keeps epochs in memory only.
 func NewManager() *Manager {
 	return &Manager{
-		epochs: make(map[uint64]*Info),
+		epochs:   make(map[uint64]*Info),
+		schedule: DefaultSchedule(),
+		amending: make(map[uint64]bool),
+	}
+}
+
+// NewManagerWithPersister loads the epochs saved in p. Epochs that were
+// sealing when the process stopped are reopened.
+func NewManagerWithPersister(p Persister) (*Manager, error) {
+	records, err := p.LoadEpochs()
+	if err != nil {
+		return nil, err
+	}
+
+	m := NewManager()
+	m.persister = p
+	for epoch, record := range records {
+		var info Info
+		if err := json.Unmarshal(record, &info); err != nil {
+			return nil, fmt.Errorf("epoch %d: %w", epoch, err)
+		}
+		if info.State == StateSealing {
+			info.State = StateOpen
+		}
+		m.epochs[epoch] = &info
+	}
+	return m, nil
+}
+
+// SetSchedule changes how epochs are numbered. It fails if the schedule
+// would move an epoch the manager already knows, so a schedule may only
+// change after the last known epoch.
+func (m *Manager) SetSchedule(s *Schedule) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for epoch, info := range m.epochs {
+		start, end, err := s.Bounds(epoch)
+		if err != nil {
+			return err
+		}
+		if !start.Equal(info.StartTime) || !end.Equal(info.EndTime) {
+			return fmt.Errorf("schedule moves epoch %d from %s to %s", epoch, info.StartTime.Format(time.RFC3339), start.Format(time.RFC3339))
+		}
 	}
+	m.schedule = s
+	return nil
+}
+
+// Schedule returns the schedule epochs are numbered by.
+func (m *Manager) Schedule() *Schedule {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.schedule
 }
 
 func (m *Manager) CurrentEpoch() uint64 {
-	now := time.Now().UTC()
-	return uint64(now.Unix() / 86400)
+	return m.Schedule().EpochAt(time.Now())
 }
 
+// GetOrCreateEpoch returns an epoch, creating it open if it is not known.
+// Open epochs are not saved until they change state.
 func (m *Manager) GetOrCreateEpoch(epoch uint64) *Info {
 	m.mu.RLock()
 	info, exists := m.epochs[epoch]
@@ -46,39 +175,223 @@ func (m *Manager) GetOrCreateEpoch(epoch uint64) *Info {
 		return info
 	}
 
-	startTime := time.Unix(int64(epoch*86400), 0).UTC()
-	endTime := startTime.Add(24 * time.Hour)
+	info = m.newInfo(epoch)
+	m.epochs[epoch] = info
+	return info
+}
 
-	info = &Info{
+// newInfo returns a new open epoch. An epoch out of the schedule's range
+// gets zero bounds, so its grace window has always passed.
+func (m *Manager) newInfo(epoch uint64) *Info {
+	start, end, _ := m.schedule.Bounds(epoch)
+	return &Info{
 		Epoch:     epoch,
-		StartTime: startTime,
-		EndTime:   endTime,
-		Finalized: false,
+		StartTime: start,
+		EndTime:   end,
+		State:     StateOpen,
 	}
+}
 
-	m.epochs[epoch] = info
-	return info
+// errUnchanged tells update the change is already in effect.
+var errUnchanged = errors.New("unchanged")
+
+// update applies fn to a copy of the epoch, saves the copy and swaps it in.
+func (m *Manager) update(epoch uint64, fn func(info *Info) error) (*Info, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current, exists := m.epochs[epoch]
+	if !exists {
+		current = m.newInfo(epoch)
+	}
+	next := *current
+	next.Amendments = append([]Amendment(nil), current.Amendments...)
+
+	if err := fn(&next); err != nil {
+		if err == errUnchanged {
+			return current, nil
+		}
+		return nil, err
+	}
+
+	if m.persister != nil {
+		record, err := json.Marshal(&next)
+		if err != nil {
+			return nil, err
+		}
+		if err := m.persister.SaveEpoch(epoch, record); err != nil {
+			return nil, fmt.Errorf("save epoch %d: %w", epoch, err)
+		}
+	}
+	m.epochs[epoch] = &next
+	return &next, nil
+}
+
+func transitionError(info *Info, to State) error {
+	return fmt.Errorf("%w: epoch %d is %s, cannot become %s", ErrInvalidTransition, info.Epoch, info.State, to)
+}
+
+// BeginSealing moves an open epoch to sealing. Only one seal of an epoch can
+// be in progress.
+func (m *Manager) BeginSealing(epoch uint64) error {
+	_, err := m.update(epoch, func(info *Info) error {
+		if info.State != StateOpen {
+			return transitionError(info, StateSealing)
+		}
+		info.State = StateSealing
+		return nil
+	})
+	return err
+}
+
+// CancelSealing returns a sealing epoch to open after a failed seal.
+func (m *Manager) CancelSealing(epoch uint64) error {
+	_, err := m.update(epoch, func(info *Info) error {
+		if info.State != StateSealing {
+			return transitionError(info, StateOpen)
+		}
+		info.State = StateOpen
+		return nil
+	})
+	return err
 }
 
+// FinalizeEpoch fixes an open or sealing epoch's root. Finalizing again with
+// the same root and count is a no-op; with anything else it fails with
+// ErrAlreadyFinalized and the epoch keeps its root. Use Amend to change it.
 func (m *Manager) FinalizeEpoch(epoch uint64, root string, receiptCount int) error {
+	_, err := m.update(epoch, func(info *Info) error {
+		switch info.State {
+		case StateFinalized, StateAnchoring, StateAnchored:
+			if info.Root == root && info.ReceiptCount == receiptCount {
+				return errUnchanged
+			}
+			return fmt.Errorf("%w: epoch %d has root %s", ErrAlreadyFinalized, epoch, info.Root)
+		}
+
+		now := time.Now().UTC()
+		info.Root = root
+		info.ReceiptCount = receiptCount
+		info.Finalized = true
+		info.State = StateFinalized
+		info.Version = 1
+		info.FinalizedAt = &now
+		return nil
+	})
+	return err
+}
+
+// Amend replaces a finalized epoch's root and records the old one with the
+// proof that the new tree extends it. Amendments only add receipts. Anchoring
+// and anchored epochs cannot be amended.
+func (m *Manager) Amend(epoch uint64, root string, receiptCount int, reason string, consistency []string) (*Info, error) {
+	if reason == "" {
+		return nil, errors.New("an amendment needs a reason")
+	}
+	return m.update(epoch, func(info *Info) error {
+		if info.State != StateFinalized {
+			return fmt.Errorf("%w: epoch %d is %s and cannot be amended", ErrInvalidTransition, epoch, info.State)
+		}
+		if receiptCount < info.ReceiptCount {
+			return fmt.Errorf("amendment of epoch %d drops receipts: %d < %d", epoch, receiptCount, info.ReceiptCount)
+		}
+
+		info.Version++
+		info.Amendments = append(info.Amendments, Amendment{
+			Version:          info.Version,
+			PrevRoot:         info.Root,
+			PrevReceiptCount: info.ReceiptCount,
+			Root:             root,
+			ReceiptCount:     receiptCount,
+			Reason:           reason,
+			AmendedAt:        time.Now().UTC(),
+			ConsistencyProof: consistency,
+		})
+		info.Root = root
+		info.ReceiptCount = receiptCount
+		return nil
+	})
+}
+
+// BeginAmending reserves a finalized epoch for an amendment that is about
+// to be built, so it cannot start anchoring meanwhile. Call EndAmending
+// when done. It fails with ErrAmending if another amendment holds the
+// epoch.
+func (m *Manager) BeginAmending(epoch uint64) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	info, exists := m.epochs[epoch]
-	if !exists {
-		info = &Info{
-			Epoch:     epoch,
-			StartTime: time.Unix(int64(epoch*86400), 0).UTC(),
-			EndTime:   time.Unix(int64((epoch+1)*86400), 0).UTC(),
+	if !exists || info.State != StateFinalized {
+		state := StateOpen
+		if exists {
+			state = info.State
 		}
-		m.epochs[epoch] = info
+		return fmt.Errorf("%w: epoch %d is %s and cannot be amended", ErrInvalidTransition, epoch, state)
 	}
+	if m.amending[epoch] {
+		return fmt.Errorf("%w: %d", ErrAmending, epoch)
+	}
+	m.amending[epoch] = true
+	return nil
+}
 
-	info.Root = root
-	info.ReceiptCount = receiptCount
-	info.Finalized = true
+// EndAmending releases an epoch reserved by BeginAmending.
+func (m *Manager) EndAmending(epoch uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.amending, epoch)
+}
 
-	return nil
+// BeginAnchoring moves a finalized epoch with the given root to anchoring,
+// before its root is sent on chain, so it is not amended after the
+// settlement contract may have it. It fails with ErrRootChanged if the
+// epoch was amended since root was read, and with ErrAmending while an
+// amendment is in progress. An epoch already anchoring with root is left
+// as it is.
+func (m *Manager) BeginAnchoring(epoch uint64, root string) error {
+	_, err := m.update(epoch, func(info *Info) error {
+		if info.State != StateFinalized && info.State != StateAnchoring {
+			return transitionError(info, StateAnchoring)
+		}
+		if info.Root != root {
+			return fmt.Errorf("%w: epoch %d has root %s, not %s", ErrRootChanged, epoch, info.Root, root)
+		}
+		if info.State == StateAnchoring {
+			return errUnchanged
+		}
+		if m.amending[epoch] {
+			return fmt.Errorf("%w: %d", ErrAmending, epoch)
+		}
+		info.State = StateAnchoring
+		return nil
+	})
+	return err
+}
+
+// MarkAnchored records that a finalized or anchoring epoch's root was
+// published in transaction tx. It fails with ErrRootChanged if the epoch
+// was amended since root was read. Marking it again with the same
+// transaction is a no-op.
+func (m *Manager) MarkAnchored(epoch uint64, root, tx string) error {
+	_, err := m.update(epoch, func(info *Info) error {
+		if info.State == StateAnchored && info.AnchorTx == tx {
+			return errUnchanged
+		}
+		if info.State != StateFinalized && info.State != StateAnchoring {
+			return transitionError(info, StateAnchored)
+		}
+		if info.Root != root {
+			return fmt.Errorf("%w: epoch %d has root %s, not %s", ErrRootChanged, epoch, info.Root, root)
+		}
+
+		now := time.Now().UTC()
+		info.State = StateAnchored
+		info.AnchorTx = tx
+		info.AnchoredAt = &now
+		return nil
+	})
+	return err
 }
 
 func (m *Manager) GetEpochInfo(epoch uint64) (*Info, bool) {
@@ -89,6 +402,24 @@ func (m *Manager) GetEpochInfo(epoch uint64) (*Info, bool) {
 	return info, exists
 }
 
+// ListEpochs returns known epochs in ascending order, optionally only those
+// in one state.
+func (m *Manager) ListEpochs(state State) []*Info {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]*Info, 0, len(m.epochs))
+	for _, info := range m.epochs {
+		if state == "" || info.State == state {
+			result = append(result, info)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Epoch < result[j].Epoch
+	})
+	return result
+}
+
 func (m *Manager) GetEpochCount() int {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
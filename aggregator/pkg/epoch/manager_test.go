@@ -1,8 +1,11 @@
 package epoch
 
 import (
+	"errors"
 	"testing"
 	"time"
+
+	"github.com/quiver/aggregator/pkg/storage"
 )
 
 func TestCurrentEpoch(t *testing.T) {
@@ -71,3 +74,231 @@ func TestFinalizeEpoch(t *testing.T) {
 		t.Errorf("Expected count %d, got %d", count, info.ReceiptCount)
 	}
 }
+
+func TestFinalizeIsWriteOnce(t *testing.T) {
+	manager := NewManager()
+
+	if err := manager.FinalizeEpoch(5, "root-a", 2); err != nil {
+		t.Fatal(err)
+	}
+	first, _ := manager.GetEpochInfo(5)
+
+	// Repeating the same finalization is a no-op
+	if err := manager.FinalizeEpoch(5, "root-a", 2); err != nil {
+		t.Errorf("repeat finalization: %v", err)
+	}
+	if again, _ := manager.GetEpochInfo(5); again != first {
+		t.Error("repeat finalization changed the epoch")
+	}
+
+	if err := manager.FinalizeEpoch(5, "root-b", 3); !errors.Is(err, ErrAlreadyFinalized) {
+		t.Fatalf("expected ErrAlreadyFinalized, got %v", err)
+	}
+	if info, _ := manager.GetEpochInfo(5); info.Root != "root-a" || info.ReceiptCount != 2 {
+		t.Errorf("rejected finalization changed the epoch: %+v", info)
+	}
+}
+
+func TestEpochStateMachine(t *testing.T) {
+	manager := NewManager()
+
+	if info := manager.GetOrCreateEpoch(7); info.State != StateOpen {
+		t.Fatalf("new epoch is %s", info.State)
+	}
+	if err := manager.MarkAnchored(7, "root", "0xabc"); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("anchored an open epoch: %v", err)
+	}
+
+	if err := manager.BeginSealing(7); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.BeginSealing(7); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("sealed twice: %v", err)
+	}
+	if err := manager.CancelSealing(7); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.BeginSealing(7); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := manager.FinalizeEpoch(7, "root", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.BeginSealing(7); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("resealed a finalized epoch: %v", err)
+	}
+
+	if err := manager.MarkAnchored(7, "old-root", "0xabc"); !errors.Is(err, ErrRootChanged) {
+		t.Errorf("anchored a root the epoch no longer has: %v", err)
+	}
+	if err := manager.MarkAnchored(7, "root", "0xabc"); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.MarkAnchored(7, "root", "0xabc"); err != nil {
+		t.Errorf("repeat anchoring: %v", err)
+	}
+	info, _ := manager.GetEpochInfo(7)
+	if info.State != StateAnchored || !info.Finalized || info.AnchorTx != "0xabc" || info.AnchoredAt == nil {
+		t.Errorf("anchored epoch: %+v", info)
+	}
+	if _, err := manager.Amend(7, "root-2", 2, "late receipts", nil); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("amended an anchored epoch: %v", err)
+	}
+
+	if got := manager.ListEpochs(StateAnchored); len(got) != 1 || got[0].Epoch != 7 {
+		t.Errorf("ListEpochs(anchored) = %+v", got)
+	}
+}
+
+func TestAmendKeepsHistory(t *testing.T) {
+	manager := NewManager()
+
+	if _, err := manager.Amend(3, "root", 1, "too early", nil); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("amended an open epoch: %v", err)
+	}
+
+	manager.FinalizeEpoch(3, "root-1", 1)
+	if _, err := manager.Amend(3, "root-2", 2, "", nil); err == nil {
+		t.Error("amendment without a reason accepted")
+	}
+	if _, err := manager.Amend(3, "root-0", 0, "dropped receipt", nil); err == nil {
+		t.Error("amendment dropping receipts accepted")
+	}
+	info, err := manager.Amend(3, "root-2", 2, "late receipts", []string{"c1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Root != "root-2" || info.Version != 2 || len(info.Amendments) != 1 {
+		t.Fatalf("amended epoch: %+v", info)
+	}
+	a := info.Amendments[0]
+	if a.PrevRoot != "root-1" || a.PrevReceiptCount != 1 || a.Root != "root-2" || a.Version != 2 || a.Reason != "late receipts" || len(a.ConsistencyProof) != 1 {
+		t.Errorf("amendment: %+v", a)
+	}
+}
+
+func TestAnchoringExcludesAmending(t *testing.T) {
+	manager := NewManager()
+	manager.FinalizeEpoch(4, "root-1", 1)
+
+	if err := manager.BeginAmending(4); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.BeginAmending(4); !errors.Is(err, ErrAmending) {
+		t.Errorf("amended twice at once: %v", err)
+	}
+	if err := manager.BeginAnchoring(4, "root-1"); !errors.Is(err, ErrAmending) {
+		t.Errorf("anchoring began during an amendment: %v", err)
+	}
+	if _, err := manager.Amend(4, "root-2", 2, "late receipts", nil); err != nil {
+		t.Fatal(err)
+	}
+	manager.EndAmending(4)
+
+	if err := manager.BeginAnchoring(4, "root-1"); !errors.Is(err, ErrRootChanged) {
+		t.Errorf("anchoring began with an amended root: %v", err)
+	}
+	if err := manager.BeginAnchoring(4, "root-2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.BeginAnchoring(4, "root-2"); err != nil {
+		t.Errorf("repeat anchoring: %v", err)
+	}
+	if err := manager.BeginAmending(4); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("amending began on an anchoring epoch: %v", err)
+	}
+	if _, err := manager.Amend(4, "root-3", 3, "later receipts", nil); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("amended an anchoring epoch: %v", err)
+	}
+	if got := manager.ListEpochs(StateAnchoring); len(got) != 1 || got[0].Epoch != 4 {
+		t.Errorf("ListEpochs(anchoring) = %+v", got)
+	}
+
+	if err := manager.MarkAnchored(4, "root-2", "0xabc"); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := manager.GetEpochInfo(4); info.State != StateAnchored {
+		t.Errorf("anchoring epoch marked anchored is %s", info.State)
+	}
+}
+
+func TestManagerPersists(t *testing.T) {
+	store := storage.NewStore()
+	manager, err := NewManagerWithPersister(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manager.FinalizeEpoch(1, "root-1", 4)
+	manager.Amend(1, "root-1b", 5, "missed receipt", nil)
+	manager.FinalizeEpoch(2, "root-2", 1)
+	manager.MarkAnchored(2, "root-2", "0xdef")
+	manager.BeginSealing(3)
+	manager.GetOrCreateEpoch(4)
+
+	reloaded, err := NewManagerWithPersister(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	one, _ := reloaded.GetEpochInfo(1)
+	if one == nil || one.Root != "root-1b" || one.Version != 2 || len(one.Amendments) != 1 {
+		t.Errorf("epoch 1 after reload: %+v", one)
+	}
+	if two, _ := reloaded.GetEpochInfo(2); two == nil || two.State != StateAnchored {
+		t.Errorf("epoch 2 after reload: %+v", two)
+	}
+	// An interrupted seal is reopened
+	if three, _ := reloaded.GetEpochInfo(3); three == nil || three.State != StateOpen {
+		t.Errorf("epoch 3 after reload: %+v", three)
+	}
+	// Open epochs are not saved
+	if _, exists := reloaded.GetEpochInfo(4); exists {
+		t.Error("untouched open epoch was saved")
+	}
+
+	if err := reloaded.FinalizeEpoch(1, "root-x", 9); !errors.Is(err, ErrAlreadyFinalized) {
+		t.Errorf("reloaded epoch accepted a new root: %v", err)
+	}
+}
+
+func TestSetScheduleKeepsKnownEpochs(t *testing.T) {
+	genesis := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	days, err := NewSchedule(genesis, []Period{{StartEpoch: 0, Length: 24 * time.Hour}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manager := NewManager()
+	if err := manager.SetSchedule(days); err != nil {
+		t.Fatal(err)
+	}
+	manager.FinalizeEpoch(2, "root-2", 1)
+
+	// Shortening epochs from 3 on leaves epoch 2 where it was
+	hours, _ := NewSchedule(genesis, []Period{
+		{StartEpoch: 0, Length: 24 * time.Hour},
+		{StartEpoch: 3, Length: time.Hour},
+	})
+	if err := manager.SetSchedule(hours); err != nil {
+		t.Fatal(err)
+	}
+	info := manager.GetOrCreateEpoch(4)
+	if want := genesis.Add(73 * time.Hour); !info.StartTime.Equal(want) || info.EndTime.Sub(info.StartTime) != time.Hour {
+		t.Errorf("epoch 4 is %s to %s", info.StartTime, info.EndTime)
+	}
+
+	// Changing the length of a known epoch would renumber it
+	early, _ := NewSchedule(genesis, []Period{
+		{StartEpoch: 0, Length: 24 * time.Hour},
+		{StartEpoch: 2, Length: time.Hour},
+	})
+	if err := manager.SetSchedule(early); err == nil {
+		t.Error("schedule moved a known epoch")
+	}
+	if manager.Schedule() != hours {
+		t.Error("rejected schedule was kept")
+	}
+}
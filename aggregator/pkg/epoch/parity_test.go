@@ -0,0 +1,34 @@
+package epoch
+
+import (
+	"bytes"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"testing"
+)
+
+// TestScheduleMatchesProvider checks that provider/pkg/epoch numbers epochs
+// with the same code as this package. Comments may differ; the vectors in
+// testvectors/epoch_schedule.json only sample the behaviour.
+func TestScheduleMatchesProvider(t *testing.T) {
+	code := func(path string) []byte {
+		t.Helper()
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := printer.Fprint(&buf, fset, file); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	ours := code("schedule.go")
+	theirs := code("../../../provider/pkg/epoch/schedule.go")
+	if !bytes.Equal(ours, theirs) {
+		t.Error("schedule.go differs from provider/pkg/epoch/schedule.go; change both together")
+	}
+}
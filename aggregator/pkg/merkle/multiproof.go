@@ -0,0 +1,144 @@
+package merkle
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Multiproof proves several leaves of one tree at once. Hashes are the roots
+// of the subtrees that hold none of the leaves at LeafIndices, in the order
+// a depth-first, left-to-right walk of the tree meets them; subtrees shared
+// by several leaves appear once. contracts/contracts/RFC6962.sol and
+// contracts/mock verify the same encoding.
+type Multiproof struct {
+	TreeSize    int      `json:"tree_size"`
+	LeafIndices []int    `json:"leaf_indices"`
+	Hashes      []string `json:"hashes"`
+}
+
+// Index returns the position of the first leaf added with data.
+func (t *Tree) Index(data []byte) (int, bool) {
+	i, ok := t.index[string(LeafHash(data))]
+	return i, ok
+}
+
+// Multiproof returns one proof for the leaves at indices, which may be in
+// any order but must not repeat.
+func (t *Tree) Multiproof(indices []int) (*Multiproof, error) {
+	if t.root == nil {
+		return nil, errors.New("tree is not built")
+	}
+	if len(indices) == 0 {
+		return nil, errors.New("no leaves to prove")
+	}
+	sorted := append([]int(nil), indices...)
+	sort.Ints(sorted)
+	for i, index := range sorted {
+		if index < 0 || index >= t.Size() {
+			return nil, fmt.Errorf("leaf index %d out of range", index)
+		}
+		if i > 0 && index == sorted[i-1] {
+			return nil, fmt.Errorf("leaf index %d repeated", index)
+		}
+	}
+
+	var path [][]byte
+	t.multiproof(0, t.Size(), sorted, &path)
+	return &Multiproof{
+		TreeSize:    t.Size(),
+		LeafIndices: sorted,
+		Hashes:      encodePath(path),
+	}, nil
+}
+
+func (t *Tree) multiproof(lo, hi int, indices []int, path *[][]byte) {
+	if len(indices) == 0 {
+		*path = append(*path, t.subtree(lo, hi))
+		return
+	}
+	if hi-lo == 1 {
+		return
+	}
+	k := splitPoint(hi - lo)
+	mid := sort.SearchInts(indices, lo+k)
+	t.multiproof(lo, lo+k, indices[:mid], path)
+	t.multiproof(lo+k, hi, indices[mid:], path)
+}
+
+// VerifyMultiproof checks that leaves, given in the order of
+// proof.LeafIndices, are the leaves at those positions of the tree with
+// root rootHex.
+func VerifyMultiproof(leaves [][]byte, proof *Multiproof, rootHex string) bool {
+	if proof == nil || len(leaves) == 0 || len(leaves) != len(proof.LeafIndices) {
+		return false
+	}
+	hashes := make([][]byte, len(leaves))
+	for i, leaf := range leaves {
+		hashes[i] = LeafHash(leaf)
+	}
+	return VerifyMultiproofHashes(hashes, proof, rootHex)
+}
+
+// VerifyMultiproofHashes is VerifyMultiproof for callers holding leaf hashes
+// rather than leaf data, as the settlement contract does.
+func VerifyMultiproofHashes(leafHashes [][]byte, proof *Multiproof, rootHex string) bool {
+	if proof == nil || proof.TreeSize < 1 || len(leafHashes) == 0 || len(leafHashes) != len(proof.LeafIndices) {
+		return false
+	}
+	for i, index := range proof.LeafIndices {
+		if index < 0 || index >= proof.TreeSize || (i > 0 && index <= proof.LeafIndices[i-1]) {
+			return false
+		}
+	}
+	root, err := hex.DecodeString(rootHex)
+	if err != nil {
+		return false
+	}
+	path, ok := decodePath(proof.Hashes)
+	if !ok {
+		return false
+	}
+
+	v := &multiproofVerifier{indices: proof.LeafIndices, leaves: leafHashes, path: path}
+	computed, ok := v.root(0, proof.TreeSize, 0, len(leafHashes))
+	return ok && v.next == len(path) && bytes.Equal(computed, root)
+}
+
+type multiproofVerifier struct {
+	indices []int
+	leaves  [][]byte
+	path    [][]byte
+	next    int
+}
+
+// root rebuilds the hash of leaves [lo, hi), which hold the proven leaves
+// from to to.
+func (v *multiproofVerifier) root(lo, hi, from, to int) ([]byte, bool) {
+	if from == to {
+		if v.next >= len(v.path) {
+			return nil, false
+		}
+		v.next++
+		return v.path[v.next-1], true
+	}
+	if hi-lo == 1 {
+		return v.leaves[from], true
+	}
+	k := splitPoint(hi - lo)
+	mid := from
+	for mid < to && v.indices[mid] < lo+k {
+		mid++
+	}
+	left, ok := v.root(lo, lo+k, from, mid)
+	if !ok {
+		return nil, false
+	}
+	right, ok := v.root(lo+k, hi, mid, to)
+	if !ok {
+		return nil, false
+	}
+	return nodeHash(left, right), true
+}
@@ -0,0 +1,142 @@
+package merkle
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"testing"
+)
+
+func TestMultiproofVectors(t *testing.T) {
+	leaves, vectors := loadVectors(t)
+	data, err := os.ReadFile("../../../testvectors/merkle.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file struct {
+		Multiproof []Multiproof `json:"multiproof"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatal(err)
+	}
+	roots := make(map[int]string)
+	for _, v := range vectors.Roots {
+		roots[v.TreeSize] = v.Root
+	}
+
+	for _, v := range file.Multiproof {
+		name := fmt.Sprintf("%v/%d", v.LeafIndices, v.TreeSize)
+		got, err := buildTree(t, leaves[:v.TreeSize]).Multiproof(v.LeafIndices)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(got.Hashes) != fmt.Sprint(v.Hashes) {
+			t.Errorf("%s: proof %v, want %v", name, got.Hashes, v.Hashes)
+		}
+
+		proven := make([][]byte, len(v.LeafIndices))
+		for i, index := range v.LeafIndices {
+			proven[i] = leaves[index]
+		}
+		proof := v
+		if !VerifyMultiproof(proven, &proof, roots[v.TreeSize]) {
+			t.Errorf("%s does not verify", name)
+		}
+	}
+}
+
+func TestMultiproof(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	for size := 1; size <= 70; size++ {
+		leaves := make([][]byte, size)
+		for i := range leaves {
+			leaves[i] = []byte(fmt.Sprintf("receipt%d", i))
+		}
+		tree := buildTree(t, leaves)
+		root := tree.Root()
+
+		for trial := 0; trial < 5; trial++ {
+			var indices []int
+			for i := 0; i < size; i++ {
+				if rng.Intn(3) == 0 {
+					indices = append(indices, i)
+				}
+			}
+			if len(indices) == 0 {
+				indices = []int{rng.Intn(size)}
+			}
+			rng.Shuffle(len(indices), func(i, j int) { indices[i], indices[j] = indices[j], indices[i] })
+
+			proof, err := tree.Multiproof(indices)
+			if err != nil {
+				t.Fatal(err)
+			}
+			proven := make([][]byte, len(proof.LeafIndices))
+			single := 0
+			for i, index := range proof.LeafIndices {
+				proven[i] = leaves[index]
+				p, _ := tree.Proof(index)
+				single += len(p.Hashes)
+			}
+			if !VerifyMultiproof(proven, proof, root) {
+				t.Fatalf("size %d: multiproof for %v does not verify", size, proof.LeafIndices)
+			}
+			if len(proof.Hashes) > single {
+				t.Errorf("size %d: multiproof has %d hashes, separate proofs %d", size, len(proof.Hashes), single)
+			}
+
+			// Swapping a proven leaf for another fails
+			forged := append([][]byte(nil), proven...)
+			forged[0] = []byte("forged")
+			if VerifyMultiproof(forged, proof, root) {
+				t.Fatalf("size %d: forged leaf verifies", size)
+			}
+			if len(proof.Hashes) > 0 {
+				short := *proof
+				short.Hashes = proof.Hashes[:len(proof.Hashes)-1]
+				if VerifyMultiproof(proven, &short, root) {
+					t.Fatalf("size %d: truncated multiproof verifies", size)
+				}
+				long := *proof
+				long.Hashes = append(append([]string(nil), proof.Hashes...), proof.Hashes[0])
+				if VerifyMultiproof(proven, &long, root) {
+					t.Fatalf("size %d: multiproof with a spare hash verifies", size)
+				}
+			}
+		}
+	}
+}
+
+func TestMultiproofRejects(t *testing.T) {
+	tree := buildTree(t, [][]byte{[]byte("a"), []byte("b"), []byte("c")})
+
+	for _, indices := range [][]int{nil, {3}, {-1}, {1, 1}} {
+		if _, err := tree.Multiproof(indices); err == nil {
+			t.Errorf("multiproof for %v", indices)
+		}
+	}
+
+	proof, _ := tree.Multiproof([]int{0, 2})
+	unordered := *proof
+	unordered.LeafIndices = []int{2, 0}
+	if VerifyMultiproof([][]byte{[]byte("c"), []byte("a")}, &unordered, tree.Root()) {
+		t.Error("multiproof with unordered indices verifies")
+	}
+	if VerifyMultiproof([][]byte{[]byte("a")}, proof, tree.Root()) {
+		t.Error("multiproof verifies with a leaf missing")
+	}
+}
+
+func TestIndex(t *testing.T) {
+	tree := buildTree(t, [][]byte{[]byte("a"), []byte("b"), []byte("a"), []byte("c")})
+	for data, want := range map[string]int{"a": 0, "b": 1, "c": 3} {
+		if got, ok := tree.Index([]byte(data)); !ok || got != want {
+			t.Errorf("Index(%q) = %d, %v; want %d", data, got, ok, want)
+		}
+	}
+	if _, ok := tree.Index([]byte("d")); ok {
+		t.Error("found a leaf never added")
+	}
+}
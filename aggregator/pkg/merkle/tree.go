@@ -1,82 +1,117 @@
+// Package merkle implements the RFC 6962 (Certificate Transparency) Merkle
+// tree. Leaves keep the order they were added in and are hashed as
+// SHA-256(0x00 || data), interior nodes as SHA-256(0x01 || left || right).
+// An odd node is carried up a level rather than paired with itself, so every
+// tree size has exactly one shape and a tree of n leaves is a prefix of any
+// larger tree built by appending to it.
 package merkle
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
-	"sort"
+	"math/bits"
 )
 
+const (
+	leafPrefix = 0x00
+	nodePrefix = 0x01
+)
+
+// Proof is an inclusion proof for the leaf at LeafIndex in a tree of
+// TreeSize leaves. Hashes run from the leaf's sibling up to the root.
+type Proof struct {
+	LeafIndex int      `json:"leaf_index"`
+	TreeSize  int      `json:"tree_size"`
+	Hashes    []string `json:"hashes"`
+}
+
 type Tree struct {
-	leaves      [][]byte
-	sortedIndex []int
-	nodes       [][]byte
-	root        []byte
+	// levels[0] holds the leaf hashes; levels[h][i] is the root of the
+	// complete subtree of 2^h leaves starting at leaf i<<h
+	levels [][][]byte
+	root   []byte
+	// index maps each leaf hash to its first position
+	index map[string]int
 }
 
 func NewTree() *Tree {
 	return &Tree{
-		leaves: make([][]byte, 0),
+		levels: [][][]byte{make([][]byte, 0)},
+		index:  make(map[string]int),
 	}
 }
 
-func (t *Tree) AddLeaf(data []byte) {
-	leaf := sha256.Sum256(data)
-	t.leaves = append(t.leaves, leaf[:])
+// LeafHash returns the RFC 6962 hash of a leaf's data.
+func LeafHash(data []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{leafPrefix})
+	h.Write(data)
+	return h.Sum(nil)
 }
 
-func (t *Tree) Build() error {
-	if len(t.leaves) == 0 {
-		return fmt.Errorf("no leaves to build tree")
-	}
+func nodeHash(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{nodePrefix})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
 
-	// Create sorted index for deterministic ordering
-	t.sortedIndex = make([]int, len(t.leaves))
-	for i := range t.sortedIndex {
-		t.sortedIndex[i] = i
+// AddLeaf appends a leaf. Call Build again before reading the root or
+// proofs.
+func (t *Tree) AddLeaf(data []byte) {
+	leaf := LeafHash(data)
+	if _, exists := t.index[string(leaf)]; !exists {
+		t.index[string(leaf)] = t.Size()
 	}
+	t.levels[0] = append(t.levels[0], leaf)
+	t.root = nil
+}
 
-	sort.Slice(t.sortedIndex, func(i, j int) bool {
-		return hex.EncodeToString(t.leaves[t.sortedIndex[i]]) < hex.EncodeToString(t.leaves[t.sortedIndex[j]])
-	})
+// Size returns the number of leaves.
+func (t *Tree) Size() int {
+	return len(t.levels[0])
+}
 
-	// Build tree with sorted leaves
-	sortedLeaves := make([][]byte, len(t.leaves))
-	for i, idx := range t.sortedIndex {
-		sortedLeaves[i] = t.leaves[idx]
+func (t *Tree) Build() error {
+	if t.Size() == 0 {
+		return fmt.Errorf("no leaves to build tree")
 	}
 
-	level := make([][]byte, len(sortedLeaves))
-	copy(level, sortedLeaves)
-
-	t.nodes = make([][]byte, 0)
-	t.nodes = append(t.nodes, sortedLeaves...)
-
-	for len(level) > 1 {
-		nextLevel := make([][]byte, 0)
-
-		for i := 0; i < len(level); i += 2 {
-			var combined []byte
-			if i+1 < len(level) {
-				combined = append(combined, level[i]...)
-				combined = append(combined, level[i+1]...)
-			} else {
-				combined = append(combined, level[i]...)
-				combined = append(combined, level[i]...)
-			}
-
-			hash := sha256.Sum256(combined)
-			nextLevel = append(nextLevel, hash[:])
-			t.nodes = append(t.nodes, hash[:])
+	t.levels = t.levels[:1]
+	for level := t.levels[0]; len(level) > 1; {
+		next := make([][]byte, len(level)/2)
+		for i := range next {
+			next[i] = nodeHash(level[2*i], level[2*i+1])
 		}
-
-		level = nextLevel
+		t.levels = append(t.levels, next)
+		level = next
 	}
 
-	t.root = level[0]
+	t.root = t.subtree(0, t.Size())
 	return nil
 }
 
+// subtree returns the hash of leaves [lo, hi). Ranges are split as RFC 6962
+// splits them, so the left part is always a complete, aligned subtree.
+func (t *Tree) subtree(lo, hi int) []byte {
+	n := hi - lo
+	if n&(n-1) == 0 {
+		h := bits.TrailingZeros(uint(n))
+		return t.levels[h][lo>>h]
+	}
+	k := splitPoint(n)
+	return nodeHash(t.subtree(lo, lo+k), t.subtree(lo+k, hi))
+}
+
+// splitPoint returns the largest power of two smaller than n, for n > 1.
+func splitPoint(n int) int {
+	return 1 << (bits.Len(uint(n-1)) - 1)
+}
+
 func (t *Tree) Root() string {
 	if t.root == nil {
 		return ""
@@ -84,65 +119,194 @@ func (t *Tree) Root() string {
 	return hex.EncodeToString(t.root)
 }
 
-func (t *Tree) Proof(leafIndex int) ([]string, error) {
-	if leafIndex >= len(t.leaves) {
+// RootAt returns the root the tree had when it held its first size leaves.
+func (t *Tree) RootAt(size int) (string, error) {
+	if t.root == nil {
+		return "", errors.New("tree is not built")
+	}
+	if size < 1 || size > t.Size() {
+		return "", fmt.Errorf("tree size %d out of range", size)
+	}
+	return hex.EncodeToString(t.subtree(0, size)), nil
+}
+
+// Proof returns the inclusion proof for the leaf at leafIndex.
+func (t *Tree) Proof(leafIndex int) (*Proof, error) {
+	if t.root == nil {
+		return nil, errors.New("tree is not built")
+	}
+	if leafIndex < 0 || leafIndex >= t.Size() {
 		return nil, fmt.Errorf("leaf index out of range")
 	}
 
-	// Find sorted position
-	sortedPos := -1
-	for i, idx := range t.sortedIndex {
-		if idx == leafIndex {
-			sortedPos = i
-			break
+	var path [][]byte
+	lo, hi, m := 0, t.Size(), leafIndex
+	for hi-lo > 1 {
+		k := splitPoint(hi - lo)
+		if m < k {
+			path = append(path, t.subtree(lo+k, hi))
+			hi = lo + k
+		} else {
+			path = append(path, t.subtree(lo, lo+k))
+			lo += k
+			m -= k
 		}
 	}
+	reverse(path)
 
-	if sortedPos == -1 {
-		return nil, fmt.Errorf("leaf not found in sorted index")
-	}
+	return &Proof{
+		LeafIndex: leafIndex,
+		TreeSize:  t.Size(),
+		Hashes:    encodePath(path),
+	}, nil
+}
 
-	proof := make([]string, 0)
-	index := sortedPos
-	levelStart := 0
-	levelSize := len(t.leaves)
+// ConsistencyProof proves that the tree's first oldSize leaves, and so the
+// root it had at that size, are a prefix of the current tree.
+func (t *Tree) ConsistencyProof(oldSize int) ([]string, error) {
+	if t.root == nil {
+		return nil, errors.New("tree is not built")
+	}
+	if oldSize < 1 || oldSize > t.Size() {
+		return nil, fmt.Errorf("tree size %d out of range", oldSize)
+	}
 
-	for levelSize > 1 {
-		siblingIndex := index ^ 1
-		if siblingIndex < levelSize {
-			proof = append(proof, hex.EncodeToString(t.nodes[levelStart+siblingIndex]))
+	var path [][]byte
+	lo, hi, m := 0, t.Size(), oldSize
+	complete := true
+	for m != hi-lo {
+		k := splitPoint(hi - lo)
+		if m <= k {
+			path = append(path, t.subtree(lo+k, hi))
+			hi = lo + k
 		} else {
-			proof = append(proof, hex.EncodeToString(t.nodes[levelStart+index]))
+			path = append(path, t.subtree(lo, lo+k))
+			lo += k
+			m -= k
+			complete = false
 		}
+	}
+	if !complete {
+		path = append(path, t.subtree(lo, hi))
+	}
+
+	reverse(path)
+	return encodePath(path), nil
+}
 
-		index /= 2
-		levelStart += levelSize
-		levelSize = (levelSize + 1) / 2
+// reverse turns a path collected from the root down into the leaf-first
+// order proofs use.
+func reverse(path [][]byte) {
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
 	}
+}
 
-	return proof, nil
+func encodePath(path [][]byte) []string {
+	out := make([]string, len(path))
+	for i, h := range path {
+		out[i] = hex.EncodeToString(h)
+	}
+	return out
+}
+
+func decodePath(path []string) ([][]byte, bool) {
+	out := make([][]byte, len(path))
+	for i, s := range path {
+		h, err := hex.DecodeString(s)
+		if err != nil || len(h) != sha256.Size {
+			return nil, false
+		}
+		out[i] = h
+	}
+	return out, true
 }
 
-func Verify(leafData []byte, proof []string, rootHex string) bool {
-	hash := sha256.Sum256(leafData)
-	current := hash[:]
+// Verify checks that leafData is the leaf at proof.LeafIndex of the tree of
+// proof.TreeSize leaves with root rootHex.
+func Verify(leafData []byte, proof *Proof, rootHex string) bool {
+	if proof == nil || proof.LeafIndex < 0 || proof.LeafIndex >= proof.TreeSize {
+		return false
+	}
+	root, err := hex.DecodeString(rootHex)
+	if err != nil {
+		return false
+	}
+	path, ok := decodePath(proof.Hashes)
+	if !ok {
+		return false
+	}
 
-	for _, proofElement := range proof {
-		proofBytes, err := hex.DecodeString(proofElement)
-		if err != nil {
+	// RFC 9162 section 2.1.3.2
+	fn, sn := uint64(proof.LeafIndex), uint64(proof.TreeSize-1)
+	r := LeafHash(leafData)
+	for _, p := range path {
+		if sn == 0 {
 			return false
 		}
-
-		var combined []byte
-		if hex.EncodeToString(current) < proofElement {
-			combined = append(current, proofBytes...)
+		if fn&1 == 1 || fn == sn {
+			r = nodeHash(p, r)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
 		} else {
-			combined = append(proofBytes, current...)
+			r = nodeHash(r, p)
 		}
+		fn >>= 1
+		sn >>= 1
+	}
+	return sn == 0 && bytes.Equal(r, root)
+}
 
-		nextHash := sha256.Sum256(combined)
-		current = nextHash[:]
+// VerifyConsistency checks that the tree of newSize leaves with root newRoot
+// extends the tree of oldSize leaves with root oldRoot.
+func VerifyConsistency(oldSize, newSize int, oldRoot, newRoot string, proof []string) bool {
+	if oldSize < 1 || oldSize > newSize {
+		return false
+	}
+	first, err1 := hex.DecodeString(oldRoot)
+	second, err2 := hex.DecodeString(newRoot)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	path, ok := decodePath(proof)
+	if !ok {
+		return false
+	}
+	if oldSize == newSize {
+		return len(path) == 0 && bytes.Equal(first, second)
 	}
 
-	return hex.EncodeToString(current) == rootHex
+	// RFC 9162 section 2.1.4.2
+	if oldSize&(oldSize-1) == 0 {
+		path = append([][]byte{first}, path...)
+	}
+	if len(path) == 0 {
+		return false
+	}
+	fn, sn := uint64(oldSize-1), uint64(newSize-1)
+	for fn&1 == 1 {
+		fn >>= 1
+		sn >>= 1
+	}
+	fr, sr := path[0], path[0]
+	for _, c := range path[1:] {
+		if sn == 0 {
+			return false
+		}
+		if fn&1 == 1 || fn == sn {
+			fr = nodeHash(c, fr)
+			sr = nodeHash(c, sr)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			sr = nodeHash(sr, c)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	return sn == 0 && bytes.Equal(fr, first) && bytes.Equal(sr, second)
 }
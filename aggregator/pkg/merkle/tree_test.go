@@ -3,73 +3,186 @@ package merkle
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
 	"testing"
 )
 
-func TestMerkleRootProofVerify(t *testing.T) {
-	tree := NewTree()
+type vectorFile struct {
+	Leaves []string `json:"leaves"`
+	Roots  []struct {
+		TreeSize int    `json:"tree_size"`
+		Root     string `json:"root"`
+	} `json:"roots"`
+	Inclusion   []Proof `json:"inclusion"`
+	Consistency []struct {
+		OldSize int      `json:"old_size"`
+		NewSize int      `json:"new_size"`
+		Hashes  []string `json:"hashes"`
+	} `json:"consistency"`
+}
 
-	// Add test data
-	data := []string{"receipt1", "receipt2", "receipt3", "receipt4"}
-	for _, d := range data {
-		tree.AddLeaf([]byte(d))
+func loadVectors(t *testing.T) ([][]byte, *vectorFile) {
+	data, err := os.ReadFile("../../../testvectors/merkle.json")
+	if err != nil {
+		t.Fatal(err)
 	}
 
-	err := tree.Build()
-	if err != nil {
+	var vectors vectorFile
+	if err := json.Unmarshal(data, &vectors); err != nil {
 		t.Fatal(err)
 	}
+	leaves := make([][]byte, len(vectors.Leaves))
+	for i, l := range vectors.Leaves {
+		if leaves[i], err = hex.DecodeString(l); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return leaves, &vectors
+}
 
-	root := tree.Root()
+func buildTree(t testing.TB, leaves [][]byte) *Tree {
+	t.Helper()
+	tree := NewTree()
+	for _, l := range leaves {
+		tree.AddLeaf(l)
+	}
+	if err := tree.Build(); err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+func TestVectors(t *testing.T) {
+	leaves, vectors := loadVectors(t)
+
+	roots := make(map[int]string)
+	for _, v := range vectors.Roots {
+		roots[v.TreeSize] = v.Root
+		if got := buildTree(t, leaves[:v.TreeSize]).Root(); got != v.Root {
+			t.Errorf("size %d: root %s, want %s", v.TreeSize, got, v.Root)
+		}
+	}
 
-	// Test verification for each original leaf
-	for i, d := range data {
-		proof, err := tree.Proof(i)
+	for _, v := range vectors.Inclusion {
+		name := fmt.Sprintf("inclusion %d/%d", v.LeafIndex, v.TreeSize)
+		got, err := buildTree(t, leaves[:v.TreeSize]).Proof(v.LeafIndex)
 		if err != nil {
 			t.Fatal(err)
 		}
-
-		// Create a new tree to find sorted position
-		verifyTree := NewTree()
-		for _, item := range data {
-			verifyTree.AddLeaf([]byte(item))
+		if fmt.Sprint(got.Hashes) != fmt.Sprint(v.Hashes) {
+			t.Errorf("%s: proof %v, want %v", name, got.Hashes, v.Hashes)
+		}
+		proof := v
+		if !Verify(leaves[v.LeafIndex], &proof, roots[v.TreeSize]) {
+			t.Errorf("%s does not verify", name)
 		}
-		if err := verifyTree.Build(); err != nil {
+	}
+
+	for _, v := range vectors.Consistency {
+		name := fmt.Sprintf("consistency %d->%d", v.OldSize, v.NewSize)
+		got, err := buildTree(t, leaves[:v.NewSize]).ConsistencyProof(v.OldSize)
+		if err != nil {
 			t.Fatal(err)
 		}
+		if fmt.Sprint(got) != fmt.Sprint(v.Hashes) {
+			t.Errorf("%s: proof %v, want %v", name, got, v.Hashes)
+		}
+		if !VerifyConsistency(v.OldSize, v.NewSize, roots[v.OldSize], roots[v.NewSize], v.Hashes) {
+			t.Errorf("%s does not verify", name)
+		}
+	}
+}
 
-		// Find sorted position of this leaf
-		leafHash := sha256.Sum256([]byte(d))
-		sortedPos := -1
-		for j, idx := range verifyTree.sortedIndex {
-			if idx == i {
-				sortedPos = j
-				break
-			}
+func TestMerkleRootProofVerify(t *testing.T) {
+	for size := 1; size <= 33; size++ {
+		leaves := make([][]byte, size)
+		for i := range leaves {
+			leaves[i] = []byte(fmt.Sprintf("receipt%d", i))
 		}
+		tree := buildTree(t, leaves)
+		root := tree.Root()
 
-		// Manual verification
-		current := leafHash[:]
-		index := sortedPos
-		for _, proofElem := range proof {
-			proofBytes, _ := hex.DecodeString(proofElem)
+		for i, leaf := range leaves {
+			proof, err := tree.Proof(i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !Verify(leaf, proof, root) {
+				t.Fatalf("size %d: proof for leaf %d does not verify", size, i)
+			}
 
-			var combined []byte
-			if index%2 == 0 {
-				combined = append(current, proofBytes...)
-			} else {
-				combined = append(proofBytes, current...)
+			// The proof is bound to its position
+			moved := *proof
+			moved.LeafIndex = (i + 1) % size
+			if size > 1 && Verify(leaf, &moved, root) {
+				t.Errorf("size %d: proof for leaf %d verifies at index %d", size, i, moved.LeafIndex)
+			}
+			if Verify([]byte("forged"), proof, root) {
+				t.Errorf("size %d: proof for leaf %d verifies other data", size, i)
 			}
+		}
+	}
+}
+
+func TestConsistencyProofs(t *testing.T) {
+	var leaves [][]byte
+	for i := 0; i < 40; i++ {
+		leaves = append(leaves, []byte{byte(i)})
+	}
+	full := buildTree(t, leaves)
 
-			nextHash := sha256.Sum256(combined)
-			current = nextHash[:]
-			index /= 2
+	for newSize := 1; newSize <= len(leaves); newSize++ {
+		tree := buildTree(t, leaves[:newSize])
+		newRoot := tree.Root()
+		if at, _ := full.RootAt(newSize); at != newRoot {
+			t.Fatalf("RootAt(%d) = %s, want %s", newSize, at, newRoot)
 		}
 
-		if hex.EncodeToString(current) != root {
-			t.Errorf("Manual verification failed for leaf %d", i)
+		for oldSize := 1; oldSize <= newSize; oldSize++ {
+			oldRoot, _ := tree.RootAt(oldSize)
+			proof, err := tree.ConsistencyProof(oldSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !VerifyConsistency(oldSize, newSize, oldRoot, newRoot, proof) {
+				t.Fatalf("%d -> %d does not verify", oldSize, newSize)
+			}
+			if oldSize < newSize && VerifyConsistency(oldSize, newSize, newRoot, newRoot, proof) {
+				t.Errorf("%d -> %d verifies with the wrong old root", oldSize, newSize)
+			}
 		}
 	}
+
+	// A tree that rewrote a leaf does not extend the old one
+	rewritten := append([][]byte{[]byte("changed")}, leaves[1:10]...)
+	old := buildTree(t, leaves[:5])
+	forged := buildTree(t, rewritten)
+	proof, _ := forged.ConsistencyProof(5)
+	if VerifyConsistency(5, 10, old.Root(), forged.Root(), proof) {
+		t.Error("rewritten tree verifies as an extension")
+	}
+}
+
+func TestNoDuplicateLeafAmbiguity(t *testing.T) {
+	// Duplicating the last node on odd levels made these two trees share a
+	// root
+	odd := buildTree(t, [][]byte{[]byte("a"), []byte("b"), []byte("c")})
+	padded := buildTree(t, [][]byte{[]byte("a"), []byte("b"), []byte("c"), []byte("c")})
+	if odd.Root() == padded.Root() {
+		t.Error("a tree and its padded copy share a root")
+	}
+}
+
+func TestLeafNodeSeparation(t *testing.T) {
+	// An interior node's preimage presented as leaf data must not reproduce
+	// the root
+	tree := buildTree(t, [][]byte{[]byte("a"), []byte("b")})
+	interior := append(LeafHash([]byte("a")), LeafHash([]byte("b"))...)
+	if buildTree(t, [][]byte{interior}).Root() == tree.Root() {
+		t.Error("leaf and node hashes collide")
+	}
 }
 
 func TestMerkleTreeDeterminism(t *testing.T) {
@@ -110,16 +223,13 @@ func TestMerkleOddLeaves(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	// Find the last item's original index
-	lastIndex := 4
-	proof, err := tree.Proof(lastIndex)
+	// The last leaf is carried up to the root's right child
+	proof, err := tree.Proof(4)
 	if err != nil {
 		t.Fatal(err)
 	}
-
-	// Just verify proof is not empty for odd tree
-	if len(proof) == 0 {
-		t.Error("Empty proof for odd tree")
+	if len(proof.Hashes) != 1 {
+		t.Errorf("Expected 1 proof element for the odd leaf, got %d", len(proof.Hashes))
 	}
 }
 
@@ -143,17 +253,23 @@ func TestProofOutOfRange(t *testing.T) {
 	if err == nil {
 		t.Error("Out of range proof should fail")
 	}
+	if _, err := tree.ConsistencyProof(2); err == nil {
+		t.Error("Consistency proof beyond the tree should fail")
+	}
 }
 
 func TestInvalidProof(t *testing.T) {
 	// Test with corrupted proof
 	leafData := []byte("test")
 	validRoot := "abc123"
-	invalidProof := []string{"not-hex", "123"}
+	invalidProof := &Proof{LeafIndex: 0, TreeSize: 3, Hashes: []string{"not-hex", "123"}}
 
 	if Verify(leafData, invalidProof, validRoot) {
 		t.Error("Invalid proof format should fail")
 	}
+	if Verify(leafData, nil, validRoot) {
+		t.Error("Missing proof should fail")
+	}
 }
 
 func TestSimpleMerkleTree(t *testing.T) {
@@ -177,8 +293,8 @@ func TestSimpleMerkleTree(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if len(proof0) != 1 {
-		t.Errorf("Expected 1 proof element, got %d", len(proof0))
+	if len(proof0.Hashes) != 1 {
+		t.Errorf("Expected 1 proof element, got %d", len(proof0.Hashes))
 	}
 }
 
@@ -34,8 +34,28 @@ var (
 		Buckets: prometheus.DefBuckets,
 	})
 
+	EpochSealsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "aggregator_epoch_seals_total",
+		Help: "Epoch seals, by result (sealed, amended, failed)",
+	}, []string{"result"})
+
 	StorageSize = promauto.NewGauge(prometheus.GaugeOpts{
 		Name: "aggregator_storage_size_bytes",
 		Help: "Total size of stored receipts in bytes",
 	})
+
+	SignatureVerificationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "aggregator_signature_verifications_total",
+		Help: "Receipt signature checks on ingest, by result (valid, invalid, unregistered, checkpoint, revoked)",
+	}, []string{"result"})
+
+	ChainFindingsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "aggregator_chain_findings_total",
+		Help: "Receipt chain violations detected, by kind (gap, fork, replay, time_regression)",
+	}, []string{"kind"})
+
+	ChainMissingReceipts = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "aggregator_chain_missing_receipts",
+		Help: "Sequence numbers currently missing across all provider chains",
+	})
 )
\ No newline at end of file
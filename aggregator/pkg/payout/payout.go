@@ -0,0 +1,310 @@
+// Package payout builds an epoch's payout tree, which providers claim from.
+// The tree has one leaf per payout address: the address, the total owed,
+// the number of receipts paid and the root of an RFC 6962 tree over those
+// receipts. The epoch's receipt tree stays the record for audits and
+// disputes; a provider claims once per epoch against the payout root.
+//
+// A leaf is encoded as Solidity's abi.encodePacked(address, uint256,
+// uint256, bytes32) and hashed like any merkle leaf. Leaves are ordered by
+// address, so the payout root depends only on the receipts, the prices and
+// the address book.
+package payout
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/quiver/aggregator/pkg/jcs"
+	"github.com/quiver/aggregator/pkg/merkle"
+	"github.com/quiver/aggregator/pkg/pricing"
+	"github.com/quiver/aggregator/pkg/storage"
+)
+
+// ErrNoPayout means an address has no leaf in an epoch's payout tree.
+var ErrNoPayout = errors.New("no payout for address")
+
+// Leaf is one provider's payout for an epoch. Provider keys that share a
+// payout address share a leaf.
+type Leaf struct {
+	Address      string   `json:"address"`
+	ProviderPKs  []string `json:"provider_pks"`
+	Amount       *big.Int `json:"-"`
+	ReceiptCount int      `json:"receipt_count"`
+	// ReceiptsRoot commits to the leaf's receipts, in the order they have
+	// in the epoch's receipt tree
+	ReceiptsRoot string `json:"receipts_root"`
+}
+
+type leafJSON struct {
+	*leafAlias
+	Amount string `json:"amount"`
+}
+
+type leafAlias Leaf
+
+// MarshalJSON writes Amount as a decimal string, which survives JSON
+// parsers that read numbers as doubles.
+func (l *Leaf) MarshalJSON() ([]byte, error) {
+	amount := "0"
+	if l.Amount != nil {
+		amount = l.Amount.String()
+	}
+	return json.Marshal(leafJSON{leafAlias: (*leafAlias)(l), Amount: amount})
+}
+
+func (l *Leaf) UnmarshalJSON(data []byte) error {
+	aux := leafJSON{leafAlias: (*leafAlias)(l)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	amount, ok := new(big.Int).SetString(aux.Amount, 10)
+	if !ok || amount.Sign() < 0 {
+		return fmt.Errorf("invalid amount %q", aux.Amount)
+	}
+	l.Amount = amount
+	return nil
+}
+
+// Encode returns the leaf's data in the payout tree.
+func (l *Leaf) Encode() ([]byte, error) {
+	if !common.IsHexAddress(l.Address) {
+		return nil, fmt.Errorf("invalid payout address %q", l.Address)
+	}
+	if l.Amount == nil || l.Amount.Sign() < 0 || l.Amount.BitLen() > 256 {
+		return nil, fmt.Errorf("amount out of range for %s", l.Address)
+	}
+	root, err := hex.DecodeString(l.ReceiptsRoot)
+	if err != nil || len(root) != 32 {
+		return nil, fmt.Errorf("invalid receipts root %q", l.ReceiptsRoot)
+	}
+
+	out := make([]byte, 0, 20+32+32+32)
+	out = append(out, common.HexToAddress(l.Address).Bytes()...)
+	out = append(out, common.LeftPadBytes(l.Amount.Bytes(), 32)...)
+	out = append(out, common.LeftPadBytes(big.NewInt(int64(l.ReceiptCount)).Bytes(), 32)...)
+	return append(out, root...), nil
+}
+
+// Payouts is an epoch's payout tree as stored. Root is empty when no
+// provider has a payout address.
+type Payouts struct {
+	Epoch uint64 `json:"epoch"`
+	// EpochRoot is the receipt root the payouts were built from; they are
+	// stale once an amendment changes it
+	EpochRoot string  `json:"epoch_root"`
+	Root      string  `json:"root"`
+	Leaves    []*Leaf `json:"leaves"`
+	// Unassigned holds what is owed to providers with no payout address.
+	// Their receipts stay in the epoch but are not in the payout tree.
+	Unassigned []*Leaf `json:"unassigned,omitempty"`
+}
+
+// Price returns what one receipt earns; see package pricing.
+type Price func(r *storage.Receipt) *big.Int
+
+// Paid reports whether a receipt earns anything. Only the final receipt of
+// a stream is paid; its checkpoints are not.
+func Paid(r *storage.Receipt) bool {
+	return r.Stream == nil || r.Stream.Final
+}
+
+// Build computes the payouts for an epoch whose receipts, in leaf order,
+// have root epochRoot.
+func Build(epoch uint64, epochRoot string, receipts []*storage.SignedReceipt, addresses Addresses, price Price) (*Payouts, error) {
+	if price == nil {
+		price = pricing.DefaultBook().Price
+	}
+
+	byAddress := make(map[common.Address]*Leaf)
+	unassigned := make(map[string]*Leaf)
+	groups := make(map[*Leaf][]*storage.SignedReceipt)
+	for _, receipt := range receipts {
+		r := &receipt.Receipt
+		if !Paid(r) {
+			continue
+		}
+
+		var leaf *Leaf
+		if address, ok := addresses[r.ProviderPK]; ok {
+			if leaf = byAddress[address]; leaf == nil {
+				leaf = &Leaf{Address: address.Hex(), Amount: new(big.Int)}
+				byAddress[address] = leaf
+			}
+		} else if leaf = unassigned[r.ProviderPK]; leaf == nil {
+			leaf = &Leaf{ProviderPKs: []string{r.ProviderPK}, Amount: new(big.Int)}
+			unassigned[r.ProviderPK] = leaf
+		}
+		if leaf.Address != "" && !contains(leaf.ProviderPKs, r.ProviderPK) {
+			leaf.ProviderPKs = append(leaf.ProviderPKs, r.ProviderPK)
+		}
+		leaf.Amount.Add(leaf.Amount, price(r))
+		leaf.ReceiptCount++
+		groups[leaf] = append(groups[leaf], receipt)
+	}
+
+	p := &Payouts{Epoch: epoch, EpochRoot: epochRoot, Leaves: []*Leaf{}}
+	for leaf, group := range groups {
+		tree, err := subtree(group)
+		if err != nil {
+			return nil, err
+		}
+		leaf.ReceiptsRoot = tree.Root()
+		sort.Strings(leaf.ProviderPKs)
+		if leaf.Address == "" {
+			p.Unassigned = append(p.Unassigned, leaf)
+		} else {
+			p.Leaves = append(p.Leaves, leaf)
+		}
+	}
+	sort.Slice(p.Leaves, func(i, j int) bool {
+		return bytes.Compare(common.HexToAddress(p.Leaves[i].Address).Bytes(), common.HexToAddress(p.Leaves[j].Address).Bytes()) < 0
+	})
+	sort.Slice(p.Unassigned, func(i, j int) bool {
+		return p.Unassigned[i].ProviderPKs[0] < p.Unassigned[j].ProviderPKs[0]
+	})
+
+	if len(p.Leaves) > 0 {
+		tree, err := p.Tree()
+		if err != nil {
+			return nil, err
+		}
+		p.Root = tree.Root()
+	}
+	return p, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// subtree builds the tree over one leaf's receipts, with the same leaf data
+// as the epoch's receipt tree.
+func subtree(receipts []*storage.SignedReceipt) (*merkle.Tree, error) {
+	tree := merkle.NewTree()
+	for _, receipt := range receipts {
+		canonical, err := jcs.Canonicalize(receipt.Receipt)
+		if err != nil {
+			return nil, fmt.Errorf("receipt %s: %w", receipt.Receipt.ReceiptID, err)
+		}
+		tree.AddLeaf(canonical)
+	}
+	if err := tree.Build(); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// Tree rebuilds the payout tree from the leaves.
+func (p *Payouts) Tree() (*merkle.Tree, error) {
+	tree := merkle.NewTree()
+	for _, leaf := range p.Leaves {
+		data, err := leaf.Encode()
+		if err != nil {
+			return nil, err
+		}
+		tree.AddLeaf(data)
+	}
+	if err := tree.Build(); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// Claim is everything a provider needs to claim one epoch's payout and to
+// show which receipts it covers.
+type Claim struct {
+	Epoch uint64        `json:"epoch"`
+	Root  string        `json:"root"`
+	Leaf  *Leaf         `json:"leaf"`
+	Proof *merkle.Proof `json:"proof"`
+	// ReceiptIDs are the receipts under Leaf.ReceiptsRoot, in tree order
+	ReceiptIDs []string `json:"receipt_ids"`
+}
+
+// Claim proves address's leaf. receipts are the epoch's receipts in leaf
+// order, as passed to Build.
+func (p *Payouts) Claim(address string, receipts []*storage.SignedReceipt) (*Claim, error) {
+	if !common.IsHexAddress(address) {
+		return nil, fmt.Errorf("invalid address %q", address)
+	}
+	want := common.HexToAddress(address)
+	for i, leaf := range p.Leaves {
+		if common.HexToAddress(leaf.Address) != want {
+			continue
+		}
+		tree, err := p.Tree()
+		if err != nil {
+			return nil, err
+		}
+		proof, err := tree.Proof(i)
+		if err != nil {
+			return nil, err
+		}
+
+		var ids []string
+		for _, receipt := range receipts {
+			if Paid(&receipt.Receipt) && contains(leaf.ProviderPKs, receipt.Receipt.ProviderPK) {
+				ids = append(ids, receipt.Receipt.ReceiptID)
+			}
+		}
+		return &Claim{Epoch: p.Epoch, Root: p.Root, Leaf: leaf, Proof: proof, ReceiptIDs: ids}, nil
+	}
+	return nil, fmt.Errorf("%w %s in epoch %d", ErrNoPayout, want.Hex(), p.Epoch)
+}
+
+// Verify checks that leaf is in the payout tree with root rootHex.
+func Verify(leaf *Leaf, proof *merkle.Proof, rootHex string) bool {
+	data, err := leaf.Encode()
+	if err != nil {
+		return false
+	}
+	return merkle.Verify(data, proof, rootHex)
+}
+
+// Addresses maps provider keys to the address their payouts go to.
+type Addresses map[string]common.Address
+
+// LoadAddresses reads one "<base64 provider key> <0x address>" pair per
+// line. Blank lines and lines starting with # are ignored.
+func LoadAddresses(path string) (Addresses, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	addresses := make(Addresses)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 || !common.IsHexAddress(fields[1]) {
+			return nil, fmt.Errorf("%s:%d: want a provider key and an address", path, line)
+		}
+		if _, dup := addresses[fields[0]]; dup {
+			return nil, fmt.Errorf("%s:%d: provider key listed twice", path, line)
+		}
+		addresses[fields[0]] = common.HexToAddress(fields[1])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return addresses, nil
+}
@@ -0,0 +1,177 @@
+package payout
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/quiver/aggregator/pkg/jcs"
+	"github.com/quiver/aggregator/pkg/merkle"
+	"github.com/quiver/aggregator/pkg/storage"
+)
+
+func TestVectors(t *testing.T) {
+	data, err := os.ReadFile("../../../testvectors/payout.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors struct {
+		Leaves []struct {
+			Leaf     Leaf   `json:"leaf"`
+			Encoded  string `json:"encoded"`
+			LeafHash string `json:"leaf_hash"`
+		} `json:"leaves"`
+		Root string `json:"root"`
+	}
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &Payouts{}
+	for _, v := range vectors.Leaves {
+		encoded, err := v.Leaf.Encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(encoded) != v.Encoded {
+			t.Errorf("%s encodes as %x, want %s", v.Leaf.Address, encoded, v.Encoded)
+		}
+		if hash := hex.EncodeToString(merkle.LeafHash(encoded)); hash != v.LeafHash {
+			t.Errorf("%s hashes to %s, want %s", v.Leaf.Address, hash, v.LeafHash)
+		}
+		leaf := v.Leaf
+		p.Leaves = append(p.Leaves, &leaf)
+	}
+	tree, err := p.Tree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.Root() != vectors.Root {
+		t.Errorf("root %s, want %s", tree.Root(), vectors.Root)
+	}
+}
+
+func receipt(id, provider string, tokens int) *storage.SignedReceipt {
+	return &storage.SignedReceipt{Receipt: storage.Receipt{
+		Version:    "2.0.0",
+		ProviderPK: provider,
+		Epoch:      4,
+		ReceiptID:  id,
+		TokensIn:   tokens,
+	}}
+}
+
+func TestBuild(t *testing.T) {
+	alice := common.HexToAddress("0x00000000000000000000000000000000000000a1")
+	bob := common.HexToAddress("0x00000000000000000000000000000000000000b0")
+	addresses := Addresses{"pk-a1": alice, "pk-a2": alice, "pk-b": bob}
+
+	checkpoint := receipt("r4", "pk-b", 50)
+	checkpoint.Receipt.Stream = &storage.StreamInfo{Index: 0}
+	final := receipt("r5", "pk-b", 60)
+	final.Receipt.Stream = &storage.StreamInfo{Index: 1, Final: true}
+	receipts := []*storage.SignedReceipt{
+		receipt("r1", "pk-b", 1),
+		receipt("r2", "pk-a2", 2),
+		receipt("r3", "pk-a1", 3),
+		checkpoint,
+		final,
+		receipt("r6", "pk-x", 4),
+	}
+
+	p, err := Build(4, "epoch-root", receipts, addresses, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Leaves) != 2 || p.Leaves[0].Address != alice.Hex() || p.Leaves[1].Address != bob.Hex() {
+		t.Fatalf("leaves: %+v", p.Leaves)
+	}
+
+	a, b := p.Leaves[0], p.Leaves[1]
+	if fmt.Sprint(a.ProviderPKs) != "[pk-a1 pk-a2]" || a.ReceiptCount != 2 || a.Amount.Int64() != 500 {
+		t.Errorf("shared address leaf: %+v", a)
+	}
+	// The stream checkpoint is not paid
+	if b.ReceiptCount != 2 || b.Amount.Int64() != 6100 {
+		t.Errorf("leaf with a stream: %+v", b)
+	}
+	if len(p.Unassigned) != 1 || p.Unassigned[0].ProviderPKs[0] != "pk-x" || p.Unassigned[0].Amount.Int64() != 400 {
+		t.Errorf("unassigned: %+v", p.Unassigned)
+	}
+
+	// A leaf's receipts root covers its receipts in epoch order
+	sub := merkle.NewTree()
+	for _, r := range []*storage.SignedReceipt{receipts[1], receipts[2]} {
+		canonical, _ := jcs.Canonicalize(r.Receipt)
+		sub.AddLeaf(canonical)
+	}
+	sub.Build()
+	if a.ReceiptsRoot != sub.Root() {
+		t.Error("receipts root does not cover the leaf's receipts in epoch order")
+	}
+
+	claim, err := p.Claim(bob.Hex(), receipts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Verify(claim.Leaf, claim.Proof, p.Root) || fmt.Sprint(claim.ReceiptIDs) != "[r1 r5]" {
+		t.Errorf("claim: %+v", claim)
+	}
+	if _, err := p.Claim("0x00000000000000000000000000000000000000c0", receipts); err == nil {
+		t.Error("claim for an address with no payout")
+	}
+
+	// An inflated amount does not verify
+	forged := *claim.Leaf
+	forged.Amount = big.NewInt(1 << 40)
+	if Verify(&forged, claim.Proof, p.Root) {
+		t.Error("forged leaf verifies")
+	}
+
+	// The stored form round-trips
+	data, _ := json.Marshal(p)
+	var restored Payouts
+	if err := json.Unmarshal(data, &restored); err != nil {
+		t.Fatal(err)
+	}
+	tree, err := restored.Tree()
+	if err != nil || tree.Root() != p.Root {
+		t.Errorf("restored payouts have root %v, want %s (%v)", tree, p.Root, err)
+	}
+}
+
+func TestBuildWithoutAddresses(t *testing.T) {
+	p, err := Build(4, "epoch-root", []*storage.SignedReceipt{receipt("r1", "pk", 1)}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Root != "" || len(p.Leaves) != 0 || len(p.Unassigned) != 1 {
+		t.Errorf("payouts: %+v", p)
+	}
+}
+
+func TestLoadAddresses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "payouts")
+	content := "# provider payout addresses\n\npk-1 0x00000000000000000000000000000000000000a1\n"
+	os.WriteFile(path, []byte(content), 0600)
+
+	addresses, err := LoadAddresses(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addresses["pk-1"] != common.HexToAddress("0xa1") {
+		t.Errorf("addresses: %v", addresses)
+	}
+
+	for _, bad := range []string{"pk-1\n", "pk-1 0x12\n", "pk-1 0x00000000000000000000000000000000000000a1\npk-1 0x00000000000000000000000000000000000000a2\n"} {
+		os.WriteFile(path, []byte(bad), 0600)
+		if _, err := LoadAddresses(path); err == nil {
+			t.Errorf("loaded %q", bad)
+		}
+	}
+}
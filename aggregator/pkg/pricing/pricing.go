@@ -0,0 +1,219 @@
+// Package pricing prices receipts for rewards. A Book holds price tables,
+// each in force from its start epoch until the next table's. A receipt is
+// priced by the table in force in the epoch it was produced in, so a new
+// table never changes what earlier receipts earn. Rates are in base units
+// of the reward token per token, separately for input and output tokens,
+// and are keyed by the model names provider/internal/models registers.
+package pricing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/quiver/aggregator/pkg/storage"
+)
+
+// Rate is what one input and one output token earn.
+type Rate struct {
+	Input  *big.Int
+	Output *big.Int
+}
+
+// Table prices receipts from StartEpoch until the next table starts.
+type Table struct {
+	StartEpoch uint64
+	Models     map[string]Rate
+	// Default prices models not in Models; without it they earn nothing
+	Default *Rate
+}
+
+// Book is a set of price tables ordered by start epoch.
+type Book struct {
+	tables []*Table
+}
+
+type bookJSON struct {
+	Tables []tableJSON `json:"tables"`
+}
+
+type tableJSON struct {
+	StartEpoch uint64              `json:"start_epoch"`
+	Models     map[string]rateJSON `json:"models,omitempty"`
+	Default    *rateJSON           `json:"default,omitempty"`
+}
+
+// rateJSON holds amounts as decimal strings, which survive JSON parsers
+// that read numbers as doubles.
+type rateJSON struct {
+	Input  string `json:"input"`
+	Output string `json:"output"`
+}
+
+// DefaultBook pays 100 base units per token in or out for every model, the
+// pricing used before price tables.
+func DefaultBook() *Book {
+	flat := &Rate{Input: big.NewInt(100), Output: big.NewInt(100)}
+	b, _ := NewBook([]*Table{{StartEpoch: 0, Default: flat}})
+	return b
+}
+
+// NewBook checks that tables start at epoch 0, are in ascending order and
+// have no negative or missing rates.
+func NewBook(tables []*Table) (*Book, error) {
+	if len(tables) == 0 {
+		return nil, errors.New("book has no tables")
+	}
+	if tables[0].StartEpoch != 0 {
+		return nil, errors.New("first table must start at epoch 0")
+	}
+	for i, t := range tables {
+		if i > 0 && t.StartEpoch <= tables[i-1].StartEpoch {
+			return nil, fmt.Errorf("table %d: start epoch %d is not after %d", i, t.StartEpoch, tables[i-1].StartEpoch)
+		}
+		if t.Default != nil {
+			if err := t.Default.check(); err != nil {
+				return nil, fmt.Errorf("table %d: default: %w", i, err)
+			}
+		}
+		for model, rate := range t.Models {
+			if err := rate.check(); err != nil {
+				return nil, fmt.Errorf("table %d: model %s: %w", i, model, err)
+			}
+		}
+	}
+	return &Book{tables: append([]*Table(nil), tables...)}, nil
+}
+
+func (r *Rate) check() error {
+	if r.Input == nil || r.Output == nil {
+		return errors.New("rate needs input and output")
+	}
+	if r.Input.Sign() < 0 || r.Output.Sign() < 0 {
+		return errors.New("rate is negative")
+	}
+	return nil
+}
+
+// LoadBook reads a book from a JSON file.
+func LoadBook(path string) (*Book, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var b Book
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &b, nil
+}
+
+func (b *Book) UnmarshalJSON(data []byte) error {
+	var raw bookJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	tables := make([]*Table, len(raw.Tables))
+	for i, t := range raw.Tables {
+		table := &Table{StartEpoch: t.StartEpoch, Models: make(map[string]Rate, len(t.Models))}
+		for model, r := range t.Models {
+			rate, err := r.parse()
+			if err != nil {
+				return fmt.Errorf("table %d: model %s: %w", i, model, err)
+			}
+			table.Models[model] = rate
+		}
+		if t.Default != nil {
+			rate, err := t.Default.parse()
+			if err != nil {
+				return fmt.Errorf("table %d: default: %w", i, err)
+			}
+			table.Default = &rate
+		}
+		tables[i] = table
+	}
+
+	parsed, err := NewBook(tables)
+	if err != nil {
+		return err
+	}
+	*b = *parsed
+	return nil
+}
+
+func (b *Book) MarshalJSON() ([]byte, error) {
+	raw := bookJSON{Tables: make([]tableJSON, len(b.tables))}
+	for i, t := range b.tables {
+		table := tableJSON{StartEpoch: t.StartEpoch}
+		if len(t.Models) > 0 {
+			table.Models = make(map[string]rateJSON, len(t.Models))
+			for model, rate := range t.Models {
+				table.Models[model] = rateJSON{Input: rate.Input.String(), Output: rate.Output.String()}
+			}
+		}
+		if t.Default != nil {
+			table.Default = &rateJSON{Input: t.Default.Input.String(), Output: t.Default.Output.String()}
+		}
+		raw.Tables[i] = table
+	}
+	return json.Marshal(raw)
+}
+
+func (r rateJSON) parse() (Rate, error) {
+	input, ok := new(big.Int).SetString(r.Input, 10)
+	if !ok {
+		return Rate{}, fmt.Errorf("input rate %q is not a decimal integer", r.Input)
+	}
+	output, ok := new(big.Int).SetString(r.Output, 10)
+	if !ok {
+		return Rate{}, fmt.Errorf("output rate %q is not a decimal integer", r.Output)
+	}
+	return Rate{Input: input, Output: output}, nil
+}
+
+// Table returns the table in force in an epoch.
+func (b *Book) Table(epoch uint64) *Table {
+	i := sort.Search(len(b.tables), func(i int) bool {
+		return b.tables[i].StartEpoch > epoch
+	})
+	return b.tables[i-1]
+}
+
+// Rate returns the rate for a model in an epoch and whether it has one.
+func (b *Book) Rate(epoch uint64, model string) (Rate, bool) {
+	t := b.Table(epoch)
+	if rate, ok := t.Models[model]; ok {
+		return rate, true
+	}
+	if t.Default != nil {
+		return *t.Default, true
+	}
+	return Rate{}, false
+}
+
+// Price returns what a receipt earns under the table in force in its
+// epoch. It has the signature of payout.Price.
+func (b *Book) Price(r *storage.Receipt) *big.Int {
+	epoch := uint64(0)
+	if r.Epoch > 0 {
+		epoch = uint64(r.Epoch)
+	}
+	rate, ok := b.Rate(epoch, r.Model)
+	if !ok {
+		return new(big.Int)
+	}
+	amount := new(big.Int).Mul(rate.Input, tokens(r.TokensIn))
+	return amount.Add(amount, new(big.Int).Mul(rate.Output, tokens(r.TokensOut)))
+}
+
+// tokens counts a negative token count, which no honest receipt has, as
+// zero so a receipt can never lower a payout.
+func tokens(n int) *big.Int {
+	if n < 0 {
+		return new(big.Int)
+	}
+	return big.NewInt(int64(n))
+}
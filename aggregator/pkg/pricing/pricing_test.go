@@ -0,0 +1,84 @@
+package pricing
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/quiver/aggregator/pkg/storage"
+)
+
+func TestPriceByEpoch(t *testing.T) {
+	book, err := LoadBook("../../prices.example.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		r    storage.Receipt
+		want string
+	}{
+		{"first table", storage.Receipt{Model: "qwen3:7b", TokensIn: 10, TokensOut: 20, Epoch: 20499}, "3000"},
+		{"model rate", storage.Receipt{Model: "qwen3:7b", TokensIn: 10, TokensOut: 20, Epoch: 20500}, "5000"},
+		{"later epoch", storage.Receipt{Model: "gpt-oss:120b", TokensIn: 1, TokensOut: 1, Epoch: 99999}, "3600"},
+		{"unlisted model", storage.Receipt{Model: "other", TokensIn: 10, TokensOut: 20, Epoch: 20500}, "2500"},
+		{"negative tokens", storage.Receipt{Model: "qwen3:7b", TokensIn: -10, TokensOut: 20, Epoch: 20500}, "4000"},
+	} {
+		if got := book.Price(&tc.r).String(); got != tc.want {
+			t.Errorf("%s: price %s, want %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestPriceIsExact(t *testing.T) {
+	// 10^30 base units per token overflows int64 and loses digits as a
+	// float64
+	rate, _ := new(big.Int).SetString("1000000000000000000000000000001", 10)
+	book, err := NewBook([]*Table{{Models: map[string]Rate{"m": {Input: rate, Output: new(big.Int)}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := book.Price(&storage.Receipt{Model: "m", TokensIn: 3, TokensOut: 5})
+	if got.String() != "3000000000000000000000000000003" {
+		t.Errorf("price %s", got)
+	}
+	if got := book.Price(&storage.Receipt{Model: "unlisted", TokensIn: 3}); got.Sign() != 0 {
+		t.Errorf("unlisted model without a default earned %s", got)
+	}
+}
+
+func TestBookJSON(t *testing.T) {
+	book, err := LoadBook("../../prices.example.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(book)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var again Book
+	if err := json.Unmarshal(data, &again); err != nil {
+		t.Fatal(err)
+	}
+	r := &storage.Receipt{Model: "mistral:7b", TokensIn: 7, TokensOut: 9, Epoch: 20600}
+	if book.Price(r).Cmp(again.Price(r)) != 0 {
+		t.Errorf("round trip changed the price from %s to %s", book.Price(r), again.Price(r))
+	}
+
+	for name, doc := range map[string]string{
+		"no tables":        `{"tables": []}`,
+		"late first table": `{"tables": [{"start_epoch": 5}]}`,
+		"out of order":     `{"tables": [{"start_epoch": 0}, {"start_epoch": 9}, {"start_epoch": 9}]}`,
+		"negative rate":    `{"tables": [{"start_epoch": 0, "default": {"input": "-1", "output": "1"}}]}`,
+		"number rate":      `{"tables": [{"start_epoch": 0, "models": {"m": {"input": 1, "output": "1"}}}]}`,
+		"fractional rate":  `{"tables": [{"start_epoch": 0, "models": {"m": {"input": "0.5", "output": "1"}}}]}`,
+		"missing rate":     `{"tables": [{"start_epoch": 0, "default": {"input": "1"}}]}`,
+	} {
+		var b Book
+		if err := json.NewDecoder(strings.NewReader(doc)).Decode(&b); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+// Package registry decides which providers may earn: those that stake at
+// least the settlement contract's minimum, bound to the Ed25519 key they
+// sign receipts with, and declare the models they serve. A stake stops
+// counting once its owner asks to unbond. The contract keeps it locked for
+// the unbonding period and while disputes are open, so the period must be
+// long enough to dispute the provider's last receipts; see CheckUnbonding.
+//
+// Registrations are read from the chain when a key is first seen and again
+// once they are older than the registry's TTL. Only so many keys with no stake
+// are cached, since anyone can make them up.
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultTTL is how long a registration read from the chain is trusted.
+	DefaultTTL = time.Minute
+	// lookupTimeout bounds the chain reads of Contains and Serves.
+	lookupTimeout = 10 * time.Second
+	// defaultMaxUnstaked bounds how many keys with no stake are cached.
+	defaultMaxUnstaked = 10000
+)
+
+// Backend reads stakes from the settlement contract. blockchain.Client
+// implements it, and so does the mock chain in contracts/mock.
+type Backend interface {
+	// StakeOf returns the stake bound to a provider key: the address that
+	// owns it, the amount and, once the owner has asked to unbond, when it
+	// can be withdrawn. owner is empty if the key has no stake.
+	StakeOf(ctx context.Context, key [32]byte) (owner string, amount *big.Int, unbondingAt time.Time, err error)
+	// ProviderModels returns the models a staked key declared.
+	ProviderModels(ctx context.Context, key [32]byte) ([]string, error)
+	// StakeParams returns the minimum stake and how long an unbonding
+	// stake stays locked.
+	StakeParams(ctx context.Context) (minStake *big.Int, unbondingPeriod time.Duration, err error)
+}
+
+// Provider is a provider key's registration as last read from the chain.
+type Provider struct {
+	ProviderPK string `json:"provider_pk"`
+	// Owner is the address that staked, empty if the key has no stake
+	Owner string `json:"owner,omitempty"`
+	// Stake is a decimal amount of base units
+	Stake       string     `json:"stake"`
+	Models      []string   `json:"models"`
+	UnbondingAt *time.Time `json:"unbonding_at,omitempty"`
+	// Registered is true if the stake is bonded and at least the minimum
+	Registered bool      `json:"registered"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
+
+// Serves reports whether a registered provider declared model.
+func (p *Provider) Serves(model string) bool {
+	if !p.Registered {
+		return false
+	}
+	for _, m := range p.Models {
+		if m == model {
+			return true
+		}
+	}
+	return false
+}
+
+// Registry caches provider registrations. It implements verify.ModelKeySet,
+// failing closed: a key whose registration cannot be read is not
+// registered.
+type Registry struct {
+	backend   Backend
+	ttl       time.Duration
+	now       func() time.Time
+	providers map[string]*Provider
+	// unstaked caches keys with no stake, up to maxUnstaked of them
+	unstaked    map[string]*Provider
+	maxUnstaked int
+	logger      *logrus.Logger
+
+	mu sync.Mutex
+}
+
+func New(backend Backend) *Registry {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	return &Registry{
+		backend:     backend,
+		ttl:         DefaultTTL,
+		now:         time.Now,
+		providers:   make(map[string]*Provider),
+		unstaked:    make(map[string]*Provider),
+		maxUnstaked: defaultMaxUnstaked,
+		logger:      logger,
+	}
+}
+
+// SetTTL sets how long a registration is trusted before it is read again.
+func (r *Registry) SetTTL(ttl time.Duration) {
+	r.ttl = ttl
+}
+
+// Lookup returns a provider key's registration, reading it from the chain
+// if the cached one is missing or stale.
+func (r *Registry) Lookup(ctx context.Context, providerPK string) (*Provider, error) {
+	r.mu.Lock()
+	cached, exists := r.providers[providerPK]
+	if !exists {
+		cached, exists = r.unstaked[providerPK]
+	}
+	r.mu.Unlock()
+	if exists && r.now().Sub(cached.CheckedAt) < r.ttl {
+		return cached, nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(providerPK)
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("provider key %q is not a base64 Ed25519 key", providerPK)
+	}
+	var k [32]byte
+	copy(k[:], key)
+
+	owner, amount, unbondingAt, err := r.backend.StakeOf(ctx, k)
+	if err != nil {
+		return nil, fmt.Errorf("stake of %s: %w", providerPK, err)
+	}
+	minStake, _, err := r.backend.StakeParams(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("stake parameters: %w", err)
+	}
+	p := &Provider{
+		ProviderPK: providerPK,
+		Owner:      owner,
+		Stake:      amount.String(),
+		Models:     []string{},
+		CheckedAt:  r.now(),
+	}
+	if !unbondingAt.IsZero() {
+		p.UnbondingAt = &unbondingAt
+	}
+	if owner != "" {
+		if p.Models, err = r.backend.ProviderModels(ctx, k); err != nil {
+			return nil, fmt.Errorf("models of %s: %w", providerPK, err)
+		}
+		p.Registered = amount.Sign() > 0 && amount.Cmp(minStake) >= 0 && unbondingAt.IsZero()
+	}
+
+	r.mu.Lock()
+	if owner == "" {
+		delete(r.providers, providerPK)
+		r.cacheUnstaked(p)
+	} else {
+		delete(r.unstaked, providerPK)
+		r.providers[providerPK] = p
+	}
+	r.mu.Unlock()
+	return p, nil
+}
+
+// cacheUnstaked caches a key with no stake, making room by dropping stale
+// entries, or any entry if none is stale. r.mu must be held.
+func (r *Registry) cacheUnstaked(p *Provider) {
+	if len(r.unstaked) >= r.maxUnstaked {
+		now := r.now()
+		for pk, cached := range r.unstaked {
+			if now.Sub(cached.CheckedAt) >= r.ttl {
+				delete(r.unstaked, pk)
+			}
+		}
+	}
+	for pk := range r.unstaked {
+		if len(r.unstaked) < r.maxUnstaked {
+			break
+		}
+		delete(r.unstaked, pk)
+	}
+	r.unstaked[p.ProviderPK] = p
+}
+
+// Contains implements verify.KeySet.
+func (r *Registry) Contains(providerPK string) bool {
+	p := r.lookup(providerPK)
+	return p != nil && p.Registered
+}
+
+// Serves implements verify.ModelKeySet.
+func (r *Registry) Serves(providerPK, model string) bool {
+	p := r.lookup(providerPK)
+	return p != nil && p.Serves(model)
+}
+
+func (r *Registry) lookup(providerPK string) *Provider {
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+
+	p, err := r.Lookup(ctx, providerPK)
+	if err != nil {
+		r.logger.WithError(err).WithField("provider", providerPK).Warn("Failed to read provider registration")
+		return nil
+	}
+	return p
+}
+
+// CheckUnbonding returns an error if the contract's unbonding period is
+// shorter than window, the time a receipt can still be disputed after it
+// is produced. A shorter period would let a provider withdraw its stake
+// before its last receipts could be disputed.
+func (r *Registry) CheckUnbonding(ctx context.Context, window time.Duration) error {
+	_, period, err := r.backend.StakeParams(ctx)
+	if err != nil {
+		return fmt.Errorf("stake parameters: %w", err)
+	}
+	if period < window {
+		return fmt.Errorf("unbonding period %s is shorter than the %s receipts can be disputed for", period, window)
+	}
+	return nil
+}
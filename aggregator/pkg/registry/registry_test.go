@@ -0,0 +1,177 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+type fakeStake struct {
+	owner       string
+	amount      int64
+	unbondingAt time.Time
+	models      []string
+}
+
+type fakeBackend struct {
+	stakes   map[[32]byte]*fakeStake
+	minStake int64
+	period   time.Duration
+	reads    int
+	err      error
+}
+
+func (b *fakeBackend) StakeOf(ctx context.Context, key [32]byte) (string, *big.Int, time.Time, error) {
+	b.reads++
+	if b.err != nil {
+		return "", nil, time.Time{}, b.err
+	}
+	s, ok := b.stakes[key]
+	if !ok {
+		return "", new(big.Int), time.Time{}, nil
+	}
+	return s.owner, big.NewInt(s.amount), s.unbondingAt, nil
+}
+
+func (b *fakeBackend) ProviderModels(ctx context.Context, key [32]byte) ([]string, error) {
+	if s, ok := b.stakes[key]; ok {
+		return s.models, nil
+	}
+	return nil, nil
+}
+
+func (b *fakeBackend) StakeParams(ctx context.Context) (*big.Int, time.Duration, error) {
+	return big.NewInt(b.minStake), b.period, nil
+}
+
+func testKey(b byte) ([32]byte, string) {
+	var key [32]byte
+	copy(key[:], bytes.Repeat([]byte{b}, 32))
+	return key, base64.StdEncoding.EncodeToString(key[:])
+}
+
+func TestRegistry(t *testing.T) {
+	staked, stakedPK := testKey(1)
+	small, smallPK := testKey(2)
+	leaving, leavingPK := testKey(3)
+	_, unknownPK := testKey(4)
+
+	backend := &fakeBackend{
+		minStake: 1000,
+		stakes: map[[32]byte]*fakeStake{
+			staked:  {owner: "0xaa", amount: 1000, models: []string{"llama3.2:3b"}},
+			small:   {owner: "0xbb", amount: 999, models: []string{"llama3.2:3b"}},
+			leaving: {owner: "0xcc", amount: 5000, unbondingAt: time.Unix(1_700_000_000, 0), models: []string{"llama3.2:3b"}},
+		},
+	}
+	r := New(backend)
+
+	for pk, want := range map[string]bool{
+		stakedPK:      true,
+		smallPK:       false,
+		leavingPK:     false,
+		unknownPK:     false,
+		"not-base64!": false,
+	} {
+		if got := r.Contains(pk); got != want {
+			t.Errorf("Contains(%s) = %v, want %v", pk, got, want)
+		}
+	}
+	if !r.Serves(stakedPK, "llama3.2:3b") || r.Serves(stakedPK, "mistral:7b") || r.Serves(leavingPK, "llama3.2:3b") {
+		t.Error("Serves does not follow declared models of registered providers")
+	}
+
+	p, err := r.Lookup(context.Background(), leavingPK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Owner != "0xcc" || p.Stake != "5000" || p.UnbondingAt == nil || p.Registered {
+		t.Errorf("unbonding provider: %+v", p)
+	}
+}
+
+func TestRegistryCache(t *testing.T) {
+	key, pk := testKey(1)
+	backend := &fakeBackend{minStake: 1, stakes: map[[32]byte]*fakeStake{}}
+	r := New(backend)
+	now := time.Unix(1_700_000_000, 0)
+	r.now = func() time.Time { return now }
+
+	if r.Contains(pk) {
+		t.Fatal("unstaked key registered")
+	}
+
+	// A new stake shows once the cached registration expires
+	backend.stakes[key] = &fakeStake{owner: "0xaa", amount: 1}
+	if r.Contains(pk) || backend.reads != 1 {
+		t.Fatalf("registration read %d times within the TTL", backend.reads)
+	}
+	now = now.Add(DefaultTTL)
+	if !r.Contains(pk) {
+		t.Fatal("stake not seen after the TTL")
+	}
+
+	// Read failures fail closed and are not cached
+	backend.err = errors.New("node down")
+	now = now.Add(DefaultTTL)
+	if r.Contains(pk) {
+		t.Fatal("registered while the chain cannot be read")
+	}
+	backend.err = nil
+	if !r.Contains(pk) {
+		t.Fatal("read failure was cached")
+	}
+}
+
+func TestRegistryBoundsUnstaked(t *testing.T) {
+	staked, stakedPK := testKey(1)
+	backend := &fakeBackend{
+		minStake: 1,
+		stakes:   map[[32]byte]*fakeStake{staked: {owner: "0xaa", amount: 1}},
+	}
+	r := New(backend)
+	r.maxUnstaked = 2
+	now := time.Unix(1_700_000_000, 0)
+	r.now = func() time.Time { return now }
+
+	if !r.Contains(stakedPK) {
+		t.Fatal("staked key not registered")
+	}
+	for b := byte(2); b < 10; b++ {
+		_, pk := testKey(b)
+		if r.Contains(pk) {
+			t.Fatalf("unstaked key %d registered", b)
+		}
+		if len(r.unstaked) > 2 {
+			t.Fatalf("%d unstaked keys cached", len(r.unstaked))
+		}
+	}
+
+	// Staked keys are not evicted to make room
+	reads := backend.reads
+	if !r.Contains(stakedPK) || backend.reads != reads {
+		t.Error("staked key was evicted by unstaked ones")
+	}
+
+	// Stale entries go first
+	now = now.Add(DefaultTTL)
+	_, pk := testKey(20)
+	r.Contains(pk)
+	if len(r.unstaked) != 1 {
+		t.Errorf("stale unstaked keys kept: %d cached", len(r.unstaked))
+	}
+}
+
+func TestCheckUnbonding(t *testing.T) {
+	r := New(&fakeBackend{period: 24 * time.Hour})
+	if err := r.CheckUnbonding(context.Background(), 24*time.Hour); err != nil {
+		t.Errorf("period equal to the window: %v", err)
+	}
+	if err := r.CheckUnbonding(context.Background(), 25*time.Hour); err == nil {
+		t.Error("period shorter than the window accepted")
+	}
+}
@@ -0,0 +1,637 @@
+// Package sealer closes epochs: once an epoch's end time plus a grace window
+// has passed, it builds the Merkle tree over every receipt stored for the
+// epoch, stores each receipt's proof and finalizes the epoch with the root.
+//
+// An epoch's leaves are its receipts in ID order when it is first sealed.
+// Amendments append new receipts after them, so every amended root extends
+// the roots before it.
+//
+// Each seal also builds the epoch's payout tree (see package payout) and
+// stores it before the root is finalized.
+package sealer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/quiver/aggregator/pkg/epoch"
+	"github.com/quiver/aggregator/pkg/jcs"
+	"github.com/quiver/aggregator/pkg/merkle"
+	"github.com/quiver/aggregator/pkg/metrics"
+	"github.com/quiver/aggregator/pkg/payout"
+	"github.com/quiver/aggregator/pkg/pricing"
+	"github.com/quiver/aggregator/pkg/storage"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultGrace is how long after an epoch ends receipts are still
+	// accepted for it.
+	DefaultGrace = 10 * time.Minute
+	// DefaultInterval is how often Run looks for epochs to seal.
+	DefaultInterval = time.Minute
+)
+
+var (
+	// ErrEpochClosed means a receipt arrived for an epoch that is sealing
+	// or already finalized.
+	ErrEpochClosed = errors.New("epoch is closed to new receipts")
+	// ErrEmptyEpoch means there are no receipts to seal.
+	ErrEmptyEpoch = errors.New("epoch has no receipts")
+	// ErrReceiptChanged means an amendment tried to replace a receipt
+	// already committed to the epoch's root.
+	ErrReceiptChanged = errors.New("committed receipts cannot be changed")
+	// ErrNotSealed means proofs were asked for before an epoch had a root.
+	ErrNotSealed = errors.New("epoch is not sealed")
+	// ErrNotInEpoch means a receipt is not one of an epoch's leaves.
+	ErrNotInEpoch = errors.New("receipt is not in the epoch")
+)
+
+// Event announces a newly finalized or amended epoch root.
+type Event struct {
+	Epoch        uint64 `json:"epoch"`
+	Root         string `json:"root"`
+	ReceiptCount int    `json:"receipt_count"`
+	Version      int    `json:"version"`
+	// PayoutRoot is empty when no provider has a payout address
+	PayoutRoot string    `json:"payout_root"`
+	SealedAt   time.Time `json:"sealed_at"`
+}
+
+// Sealer seals epochs for a store and epoch manager. Receipts should enter
+// the store through Admit so none can slip into an epoch while it is being
+// sealed.
+type Sealer struct {
+	store     storage.Store
+	epochs    *epoch.Manager
+	grace     time.Duration
+	addresses payout.Addresses
+	price     payout.Price
+	handlers  []func(Event)
+	now       func() time.Time
+	logger    *logrus.Logger
+
+	// admitting is held for reading while receipts are stored and for
+	// writing while an epoch moves to sealing
+	admitting sync.RWMutex
+	mu        sync.Mutex
+}
+
+func New(store storage.Store, epochs *epoch.Manager) *Sealer {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	return &Sealer{
+		store:  store,
+		epochs: epochs,
+		grace:  DefaultGrace,
+		price:  pricing.DefaultBook().Price,
+		now:    time.Now,
+		logger: logger,
+	}
+}
+
+// SetClock sets where the sealer reads the current time, which decides
+// whether an epoch still admits receipts and whether it is due to seal.
+func (s *Sealer) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// SetGrace sets how long after an epoch's end time it stays open.
+func (s *Sealer) SetGrace(grace time.Duration) {
+	s.grace = grace
+}
+
+// SetAddresses sets where each provider's payouts go. Providers without an
+// address are left out of payout trees sealed afterwards.
+func (s *Sealer) SetAddresses(addresses payout.Addresses) {
+	s.addresses = addresses
+}
+
+// SetPrice sets what receipts earn in payout trees sealed afterwards,
+// usually a pricing.Book's Price.
+func (s *Sealer) SetPrice(price payout.Price) {
+	s.price = price
+}
+
+// Price returns what a receipt earns in the sealer's payout trees. Stream
+// checkpoints are left out of payouts whatever their price; see
+// payout.Paid.
+func (s *Sealer) Price(r *storage.Receipt) *big.Int {
+	return s.price(r)
+}
+
+// Address returns the payout address of a provider key.
+func (s *Sealer) Address(providerPK string) (common.Address, bool) {
+	address, ok := s.addresses[providerPK]
+	return address, ok
+}
+
+// OnSealed registers fn to be called after every seal and amendment. Handlers
+// run synchronously, in registration order.
+func (s *Sealer) OnSealed(fn func(Event)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers = append(s.handlers, fn)
+}
+
+// Admit stores a receipt if its epoch is open. Receipts for an epoch whose
+// grace window has passed are turned away even if it was never sealed.
+func (s *Sealer) Admit(receipt *storage.SignedReceipt) error {
+	s.admitting.RLock()
+	defer s.admitting.RUnlock()
+
+	n := uint64(receipt.Receipt.Epoch)
+	if err := s.checkOpen(n); err != nil {
+		return err
+	}
+	return s.store.Store(receipt)
+}
+
+// WhileOpen runs fn if epoch n is open, holding off its sealing until fn
+// returns like Admit does, and reports whether fn ran.
+func (s *Sealer) WhileOpen(n uint64, fn func() error) (bool, error) {
+	s.admitting.RLock()
+	defer s.admitting.RUnlock()
+
+	if err := s.checkOpen(n); err != nil {
+		return false, nil
+	}
+	return true, fn()
+}
+
+// checkOpen returns ErrEpochClosed unless epoch n is open and inside its
+// grace window. The caller must hold admitting.
+func (s *Sealer) checkOpen(n uint64) error {
+	info, exists := s.epochs.GetEpochInfo(n)
+	if exists && info.State != epoch.StateOpen {
+		return fmt.Errorf("%w: epoch %d is %s", ErrEpochClosed, n, info.State)
+	}
+	if !exists {
+		start, end, err := s.epochs.Schedule().Bounds(n)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrEpochClosed, err)
+		}
+		info = &epoch.Info{Epoch: n, StartTime: start, EndTime: end}
+	}
+	if s.Due(info, s.now()) {
+		return fmt.Errorf("%w: epoch %d ended at %s", ErrEpochClosed, n, info.EndTime.Format(time.RFC3339))
+	}
+	s.epochs.GetOrCreateEpoch(n)
+	return nil
+}
+
+// Due reports whether an epoch's grace window has passed at now.
+func (s *Sealer) Due(info *epoch.Info, now time.Time) bool {
+	return !now.Before(info.EndTime.Add(s.grace))
+}
+
+// SealIfDue seals epoch n if it is open, has stored receipts and its grace
+// window has passed, and returns the epoch as it stands.
+func (s *Sealer) SealIfDue(n uint64) (*epoch.Info, error) {
+	info := s.epochs.GetOrCreateEpoch(n)
+	if info.State != epoch.StateOpen || !s.Due(info, s.now()) || s.store.Count(n) == 0 {
+		return info, nil
+	}
+	return s.Seal(n)
+}
+
+// Seal finalizes an open epoch over all of its stored receipts. If sealing
+// fails the epoch is reopened.
+func (s *Sealer) Seal(n uint64) (*epoch.Info, error) {
+	if s.store.Count(n) == 0 {
+		return nil, fmt.Errorf("%w: %d", ErrEmptyEpoch, n)
+	}
+
+	s.admitting.Lock()
+	err := s.epochs.BeginSealing(n)
+	s.admitting.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := s.seal(n, "")
+	if err != nil {
+		metrics.EpochSealsTotal.WithLabelValues("failed").Inc()
+		if cerr := s.epochs.CancelSealing(n); cerr != nil {
+			s.logger.WithError(cerr).WithField("epoch", n).Error("Failed to reopen epoch")
+		}
+		return nil, err
+	}
+	metrics.EpochSealsTotal.WithLabelValues("sealed").Inc()
+	return info, nil
+}
+
+// Amend stores receipts into a finalized epoch and reseals it over
+// everything stored, recording the old root with reason.
+func (s *Sealer) Amend(n uint64, receipts []*storage.SignedReceipt, reason string) (*epoch.Info, error) {
+	if reason == "" {
+		return nil, errors.New("an amendment needs a reason")
+	}
+	// Holding the epoch keeps it from starting to anchor while the new
+	// receipts, proofs and payouts are written.
+	if err := s.epochs.BeginAmending(n); err != nil {
+		return nil, err
+	}
+	defer s.epochs.EndAmending(n)
+
+	var added []*storage.SignedReceipt
+	for _, receipt := range receipts {
+		if uint64(receipt.Receipt.Epoch) != n {
+			return nil, fmt.Errorf("receipt %s is for epoch %d", receipt.Receipt.ReceiptID, receipt.Receipt.Epoch)
+		}
+		stored, err := s.unchanged(receipt)
+		if err != nil {
+			return nil, err
+		}
+		if !stored {
+			added = append(added, receipt)
+		}
+	}
+	before, err := s.output(n)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, receipt := range added {
+		if err = s.store.Store(receipt); err != nil {
+			break
+		}
+		ids = append(ids, receipt.Receipt.ReceiptID)
+	}
+	var info *epoch.Info
+	if err == nil {
+		info, err = s.seal(n, reason)
+	}
+	if err != nil {
+		metrics.EpochSealsTotal.WithLabelValues("failed").Inc()
+		if undoErr := s.undo(n, ids, before); undoErr != nil {
+			s.logger.WithError(undoErr).WithField("epoch", n).Error("Failed to undo amendment")
+		}
+		return nil, err
+	}
+	metrics.EpochSealsTotal.WithLabelValues("amended").Inc()
+	return info, nil
+}
+
+// sealedOutput is what sealing writes besides the epoch record: the
+// payouts and the proofs of the epoch's receipts.
+type sealedOutput struct {
+	payouts []byte
+	proofs  map[string]*merkle.Proof
+}
+
+// output reads what the last seal of epoch n wrote.
+func (s *Sealer) output(n uint64) (*sealedOutput, error) {
+	payouts, err := s.store.GetPayouts(n)
+	if err != nil {
+		return nil, err
+	}
+	receipts, err := s.store.GetByEpoch(n)
+	if err != nil {
+		return nil, err
+	}
+	out := &sealedOutput{payouts: payouts, proofs: make(map[string]*merkle.Proof, len(receipts))}
+	for _, receipt := range receipts {
+		if proof, ok := s.store.GetProof(receipt.Receipt.ReceiptID); ok {
+			out.proofs[receipt.Receipt.ReceiptID] = proof
+		}
+	}
+	return out, nil
+}
+
+// undo takes back a failed amendment of epoch n: it deletes the receipts
+// it added and puts back the payouts and proofs of the last seal.
+func (s *Sealer) undo(n uint64, added []string, before *sealedOutput) error {
+	if err := s.store.Delete(added); err != nil {
+		return err
+	}
+	if before.payouts != nil {
+		if err := s.store.SavePayouts(n, before.payouts); err != nil {
+			return err
+		}
+	}
+	return s.store.StoreProofs(before.proofs)
+}
+
+// unchanged reports whether receipt is already stored as given. It fails
+// if a different receipt is stored under the same ID.
+func (s *Sealer) unchanged(receipt *storage.SignedReceipt) (bool, error) {
+	stored, err := s.store.GetByID(receipt.Receipt.ReceiptID)
+	if err != nil || stored == nil {
+		return false, err
+	}
+	a, err := jcs.Canonicalize(stored.Receipt)
+	if err != nil {
+		return false, err
+	}
+	b, err := jcs.Canonicalize(receipt.Receipt)
+	if err != nil {
+		return false, err
+	}
+	if !bytes.Equal(a, b) {
+		return false, fmt.Errorf("%w: receipt %s", ErrReceiptChanged, receipt.Receipt.ReceiptID)
+	}
+	return true, nil
+}
+
+// seal builds the epoch's tree, stores every proof and then finalizes, or
+// amends when reason is set.
+func (s *Sealer) seal(n uint64, reason string) (*epoch.Info, error) {
+	receipts, err := s.store.GetByEpoch(n)
+	if err != nil {
+		return nil, err
+	}
+	if len(receipts) == 0 {
+		return nil, fmt.Errorf("%w: %d", ErrEmptyEpoch, n)
+	}
+
+	var prev *epoch.Info
+	if reason != "" {
+		prev, _ = s.epochs.GetEpochInfo(n)
+		if receipts, err = s.appendOrder(prev, receipts); err != nil {
+			return nil, err
+		}
+	}
+
+	start := time.Now()
+	tree, err := BuildTree(receipts)
+	if err != nil {
+		return nil, err
+	}
+	metrics.MerkleTreeBuildTime.Observe(time.Since(start).Seconds())
+
+	var consistency []string
+	if prev != nil {
+		if root, err := tree.RootAt(prev.ReceiptCount); err != nil || root != prev.Root {
+			return nil, fmt.Errorf("epoch %d: stored receipts no longer match root %s", n, prev.Root)
+		}
+		if consistency, err = tree.ConsistencyProof(prev.ReceiptCount); err != nil {
+			return nil, err
+		}
+	}
+
+	root := tree.Root()
+	payouts, err := payout.Build(n, root, receipts, s.addresses, s.price)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.savePayouts(payouts); err != nil {
+		return nil, err
+	}
+
+	proofs := make(map[string]*merkle.Proof, len(receipts))
+	for i, receipt := range receipts {
+		if proofs[receipt.Receipt.ReceiptID], err = tree.Proof(i); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.store.StoreProofs(proofs); err != nil {
+		return nil, err
+	}
+
+	var info *epoch.Info
+	if reason != "" {
+		if info, err = s.epochs.Amend(n, root, len(receipts), reason, consistency); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := s.epochs.FinalizeEpoch(n, root, len(receipts)); err != nil {
+			return nil, err
+		}
+		info, _ = s.epochs.GetEpochInfo(n)
+	}
+
+	event := Event{
+		Epoch:        n,
+		Root:         info.Root,
+		ReceiptCount: info.ReceiptCount,
+		Version:      info.Version,
+		PayoutRoot:   payouts.Root,
+		SealedAt:     time.Now().UTC(),
+	}
+	s.logger.WithFields(logrus.Fields{
+		"epoch":         n,
+		"receipt_count": event.ReceiptCount,
+		"merkle_root":   event.Root,
+		"payout_root":   event.PayoutRoot,
+		"version":       event.Version,
+	}).Info("Epoch sealed")
+
+	s.mu.Lock()
+	handlers := append([]func(Event){}, s.handlers...)
+	s.mu.Unlock()
+	for _, fn := range handlers {
+		fn(event)
+	}
+	return info, nil
+}
+
+// appendOrder orders an epoch's receipts for an amendment: the receipts
+// already sealed keep their leaf positions and the rest follow in ID order.
+func (s *Sealer) appendOrder(prev *epoch.Info, receipts []*storage.SignedReceipt) ([]*storage.SignedReceipt, error) {
+	if len(receipts) < prev.ReceiptCount {
+		return nil, fmt.Errorf("epoch %d: %d receipts stored, %d sealed", prev.Epoch, len(receipts), prev.ReceiptCount)
+	}
+	ordered := make([]*storage.SignedReceipt, prev.ReceiptCount, len(receipts))
+	var added []*storage.SignedReceipt
+	for _, receipt := range receipts {
+		proof, ok := s.store.GetProof(receipt.Receipt.ReceiptID)
+		if !ok || proof.TreeSize != prev.ReceiptCount {
+			added = append(added, receipt)
+			continue
+		}
+		if proof.LeafIndex < 0 || proof.LeafIndex >= prev.ReceiptCount || ordered[proof.LeafIndex] != nil {
+			return nil, fmt.Errorf("epoch %d: receipt %s has an invalid leaf position", prev.Epoch, receipt.Receipt.ReceiptID)
+		}
+		ordered[proof.LeafIndex] = receipt
+	}
+	for i, receipt := range ordered {
+		if receipt == nil {
+			return nil, fmt.Errorf("epoch %d: no stored receipt at leaf %d of %d", prev.Epoch, i, prev.ReceiptCount)
+		}
+	}
+	return append(ordered, added...), nil
+}
+
+// Multiproof proves receipts of a sealed epoch against its current root in
+// one proof. It returns the receipt IDs in the order of the proof's leaf
+// indices, which is the order a verifier needs the leaves in.
+func (s *Sealer) Multiproof(n uint64, receiptIDs []string) (*merkle.Multiproof, []string, error) {
+	_, ordered, tree, err := s.sealed(n)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	position := make(map[string]int, len(ordered))
+	for i, receipt := range ordered {
+		position[receipt.Receipt.ReceiptID] = i
+	}
+	indices := make([]int, len(receiptIDs))
+	for i, id := range receiptIDs {
+		index, ok := position[id]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: %s is not in epoch %d", ErrNotInEpoch, id, n)
+		}
+		indices[i] = index
+	}
+	proof, err := tree.Multiproof(indices)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ids := make([]string, len(proof.LeafIndices))
+	for i, index := range proof.LeafIndices {
+		ids[i] = ordered[index].Receipt.ReceiptID
+	}
+	return proof, ids, nil
+}
+
+// sealed returns a sealed epoch's receipts in leaf order and their tree,
+// checked against the epoch's root.
+func (s *Sealer) sealed(n uint64) (*epoch.Info, []*storage.SignedReceipt, *merkle.Tree, error) {
+	info, exists := s.epochs.GetEpochInfo(n)
+	if !exists || (info.State != epoch.StateFinalized && info.State != epoch.StateAnchoring && info.State != epoch.StateAnchored) {
+		return nil, nil, nil, fmt.Errorf("%w: %d", ErrNotSealed, n)
+	}
+	receipts, err := s.store.GetByEpoch(n)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	ordered, err := s.appendOrder(info, receipts)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if len(ordered) != info.ReceiptCount {
+		return nil, nil, nil, fmt.Errorf("epoch %d: %d receipts stored, %d sealed", n, len(ordered), info.ReceiptCount)
+	}
+	tree, err := BuildTree(ordered)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if tree.Root() != info.Root {
+		return nil, nil, nil, fmt.Errorf("epoch %d: stored receipts no longer match root %s", n, info.Root)
+	}
+	return info, ordered, tree, nil
+}
+
+// Payouts returns a sealed epoch's payout tree. Epochs sealed before payout
+// trees existed get one built, with the current addresses, and stored.
+func (s *Sealer) Payouts(n uint64) (*payout.Payouts, error) {
+	info, exists := s.epochs.GetEpochInfo(n)
+	if !exists || (info.State != epoch.StateFinalized && info.State != epoch.StateAnchoring && info.State != epoch.StateAnchored) {
+		return nil, fmt.Errorf("%w: %d", ErrNotSealed, n)
+	}
+	record, err := s.store.GetPayouts(n)
+	if err != nil {
+		return nil, err
+	}
+	if record != nil {
+		var payouts payout.Payouts
+		if err := json.Unmarshal(record, &payouts); err != nil {
+			return nil, fmt.Errorf("epoch %d payouts: %w", n, err)
+		}
+		if payouts.EpochRoot == info.Root {
+			return &payouts, nil
+		}
+	}
+
+	_, ordered, _, err := s.sealed(n)
+	if err != nil {
+		return nil, err
+	}
+	payouts, err := payout.Build(n, info.Root, ordered, s.addresses, s.price)
+	if err != nil {
+		return nil, err
+	}
+	return payouts, s.savePayouts(payouts)
+}
+
+// PayoutClaim proves address's payout in a sealed epoch.
+func (s *Sealer) PayoutClaim(n uint64, address string) (*payout.Claim, error) {
+	payouts, err := s.Payouts(n)
+	if err != nil {
+		return nil, err
+	}
+	_, ordered, _, err := s.sealed(n)
+	if err != nil {
+		return nil, err
+	}
+	return payouts.Claim(address, ordered)
+}
+
+func (s *Sealer) savePayouts(payouts *payout.Payouts) error {
+	record, err := json.Marshal(payouts)
+	if err != nil {
+		return err
+	}
+	return s.store.SavePayouts(payouts.Epoch, record)
+}
+
+// SealDue seals every open epoch with stored receipts whose grace window
+// has passed at now, oldest first, and returns the epochs it sealed.
+func (s *Sealer) SealDue(now time.Time) ([]uint64, error) {
+	stored, err := s.store.Epochs()
+	if err != nil {
+		return nil, err
+	}
+
+	var sealed []uint64
+	var errs []error
+	for _, n := range stored {
+		info := s.epochs.GetOrCreateEpoch(n)
+		if info.State != epoch.StateOpen || !s.Due(info, now) {
+			continue
+		}
+		if _, err := s.Seal(n); err != nil {
+			errs = append(errs, fmt.Errorf("epoch %d: %w", n, err))
+			continue
+		}
+		sealed = append(sealed, n)
+	}
+	return sealed, errors.Join(errs...)
+}
+
+// Run calls SealDue every interval until ctx is done.
+func (s *Sealer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.SealDue(s.now()); err != nil {
+			s.logger.WithError(err).Error("Failed to seal epochs")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// BuildTree commits to receipts in the order given. Each leaf is the
+// receipt's canonical JSON.
+func BuildTree(receipts []*storage.SignedReceipt) (*merkle.Tree, error) {
+	tree := merkle.NewTree()
+	for _, receipt := range receipts {
+		canonical, err := jcs.Canonicalize(receipt.Receipt)
+		if err != nil {
+			return nil, fmt.Errorf("receipt %s: %w", receipt.Receipt.ReceiptID, err)
+		}
+		tree.AddLeaf(canonical)
+	}
+	if err := tree.Build(); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
@@ -0,0 +1,458 @@
+package sealer
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/quiver/aggregator/pkg/epoch"
+	"github.com/quiver/aggregator/pkg/jcs"
+	"github.com/quiver/aggregator/pkg/merkle"
+	"github.com/quiver/aggregator/pkg/payout"
+	"github.com/quiver/aggregator/pkg/pricing"
+	"github.com/quiver/aggregator/pkg/storage"
+)
+
+func testReceipt(id string, n uint64) *storage.SignedReceipt {
+	return &storage.SignedReceipt{
+		Receipt: storage.Receipt{
+			Version:   "2.0.0",
+			Model:     "llama3.2:3b",
+			Epoch:     int64(n),
+			ReceiptID: id,
+			TokensOut: 10,
+		},
+		Signature: "sig",
+	}
+}
+
+func newSealer(t *testing.T) (*Sealer, storage.Store, *epoch.Manager) {
+	t.Helper()
+	store := storage.NewStore()
+	epochs := epoch.NewManager()
+	s := New(store, epochs)
+	s.SetGrace(5 * time.Minute)
+	// The test epochs are all still open at the genesis time
+	s.SetClock(func() time.Time { return time.Unix(0, 0) })
+	return s, store, epochs
+}
+
+func TestSealDueWaitsForGrace(t *testing.T) {
+	s, store, epochs := newSealer(t)
+
+	var events []Event
+	s.OnSealed(func(e Event) { events = append(events, e) })
+
+	for _, id := range []string{"b", "a", "d"} {
+		if err := s.Admit(testReceipt(id, 100)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Admit(testReceipt("later", 101)); err != nil {
+		t.Fatal(err)
+	}
+
+	end := epochs.GetOrCreateEpoch(100).EndTime
+	sealed, err := s.SealDue(end.Add(5*time.Minute - time.Second))
+	if err != nil || len(sealed) != 0 {
+		t.Fatalf("sealed %v inside the grace window: %v", sealed, err)
+	}
+
+	sealed, err = s.SealDue(end.Add(5 * time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(sealed) != "[100]" {
+		t.Fatalf("sealed %v, want [100]", sealed)
+	}
+
+	info, _ := epochs.GetEpochInfo(100)
+	if info.State != epoch.StateFinalized || info.ReceiptCount != 3 {
+		t.Fatalf("epoch after sealing: %+v", info)
+	}
+	if len(events) != 1 || events[0].Epoch != 100 || events[0].Root != info.Root || events[0].Version != 1 {
+		t.Errorf("events: %+v", events)
+	}
+
+	// Every stored receipt gets a proof under the root, with leaves in
+	// receipt ID order
+	receipts, _ := store.GetByEpoch(100)
+	for i, receipt := range receipts {
+		proof, ok := store.GetProof(receipt.Receipt.ReceiptID)
+		if !ok {
+			t.Fatalf("no proof for %s", receipt.Receipt.ReceiptID)
+		}
+		if proof.LeafIndex != i || proof.TreeSize != 3 {
+			t.Errorf("%s is leaf %d of %d", receipt.Receipt.ReceiptID, proof.LeafIndex, proof.TreeSize)
+		}
+		canonical, _ := jcs.Canonicalize(receipt.Receipt)
+		if !merkle.Verify(canonical, proof, info.Root) {
+			t.Errorf("proof for %s does not verify", receipt.Receipt.ReceiptID)
+		}
+	}
+
+	// The sealed epoch no longer takes receipts; the next one still does
+	if err := s.Admit(testReceipt("e", 100)); !errors.Is(err, ErrEpochClosed) {
+		t.Errorf("admitted into a finalized epoch: %v", err)
+	}
+	if next, _ := epochs.GetEpochInfo(101); next.State != epoch.StateOpen {
+		t.Errorf("epoch 101 is %s", next.State)
+	}
+
+	// Sealing again finds nothing to do
+	if sealed, _ := s.SealDue(end.Add(time.Hour)); fmt.Sprint(sealed) != "[]" {
+		t.Errorf("resealed %v", sealed)
+	}
+}
+
+func TestAdmitAfterGrace(t *testing.T) {
+	s, _, epochs := newSealer(t)
+	now := epochs.Schedule().Genesis()
+	s.SetClock(func() time.Time { return now })
+
+	_, end, _ := epochs.Schedule().Bounds(4)
+	now = end.Add(5*time.Minute - time.Second)
+	if err := s.Admit(testReceipt("a", 4)); err != nil {
+		t.Fatalf("refused inside the grace window: %v", err)
+	}
+	if info, err := s.SealIfDue(4); err != nil || info.State != epoch.StateOpen {
+		t.Fatalf("sealed inside the grace window: %+v, %v", info, err)
+	}
+
+	// An epoch that was never seen is closed once its window has passed,
+	// and turning a receipt away does not create it
+	now = end.Add(5 * time.Minute)
+	if err := s.Admit(testReceipt("b", 3)); !errors.Is(err, ErrEpochClosed) {
+		t.Errorf("admitted into a past epoch: %v", err)
+	}
+	if _, exists := epochs.GetEpochInfo(3); exists {
+		t.Error("a refused receipt created its epoch")
+	}
+	if err := s.Admit(testReceipt("c", 1<<62)); !errors.Is(err, ErrEpochClosed) {
+		t.Errorf("admitted into an epoch out of the schedule's range: %v", err)
+	}
+	if ran, _ := s.WhileOpen(4, func() error { return nil }); ran {
+		t.Error("epoch 4 still open after its grace window")
+	}
+
+	// A due epoch is closed to receipts but not yet sealed until asked
+	if err := s.Admit(testReceipt("d", 4)); !errors.Is(err, ErrEpochClosed) {
+		t.Errorf("admitted after the grace window: %v", err)
+	}
+	info, err := s.SealIfDue(4)
+	if err != nil || info.State != epoch.StateFinalized || info.ReceiptCount != 1 {
+		t.Errorf("SealIfDue = %+v, %v", info, err)
+	}
+	if info, err := s.SealIfDue(5); err != nil || info.State != epoch.StateOpen {
+		t.Errorf("SealIfDue on an epoch with no receipts = %+v, %v", info, err)
+	}
+}
+
+func TestSealEmptyEpoch(t *testing.T) {
+	s, _, epochs := newSealer(t)
+
+	if _, err := s.Seal(5); !errors.Is(err, ErrEmptyEpoch) {
+		t.Errorf("sealed an empty epoch: %v", err)
+	}
+	if info, exists := epochs.GetEpochInfo(5); exists && info.State != epoch.StateOpen {
+		t.Errorf("empty epoch left %s", info.State)
+	}
+}
+
+func TestAmendReseals(t *testing.T) {
+	s, store, epochs := newSealer(t)
+
+	var events []Event
+	s.OnSealed(func(e Event) { events = append(events, e) })
+
+	s.Admit(testReceipt("c", 7))
+	s.Admit(testReceipt("d", 7))
+	s.Admit(testReceipt("f", 7))
+	first, err := s.Seal(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Amend(7, []*storage.SignedReceipt{testReceipt("b", 7)}, ""); err == nil {
+		t.Error("amended without a reason")
+	}
+	if _, err := s.Amend(7, []*storage.SignedReceipt{testReceipt("x", 8)}, "wrong epoch"); err == nil {
+		t.Error("amended with a receipt from another epoch")
+	}
+	if _, err := s.Amend(9, nil, "open epoch"); !errors.Is(err, epoch.ErrInvalidTransition) {
+		t.Errorf("amended an open epoch: %v", err)
+	}
+
+	changed := testReceipt("d", 7)
+	changed.Receipt.TokensOut = 99
+	if _, err := s.Amend(7, []*storage.SignedReceipt{changed}, "rewrite"); !errors.Is(err, ErrReceiptChanged) {
+		t.Errorf("amendment replaced a committed receipt: %v", err)
+	}
+
+	// Late receipts that sort before the sealed ones still go at the end,
+	// and resending a sealed receipt is harmless
+	late := []*storage.SignedReceipt{testReceipt("b", 7), testReceipt("a", 7), testReceipt("c", 7)}
+	info, err := s.Amend(7, late, "late receipts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Version != 2 || info.ReceiptCount != 5 || info.Root == first.Root {
+		t.Fatalf("amended epoch: %+v", info)
+	}
+	if len(info.Amendments) != 1 || info.Amendments[0].PrevRoot != first.Root {
+		t.Fatalf("amendments: %+v", info.Amendments)
+	}
+	a := info.Amendments[0]
+	if !merkle.VerifyConsistency(a.PrevReceiptCount, a.ReceiptCount, a.PrevRoot, a.Root, a.ConsistencyProof) {
+		t.Error("amended root does not extend the sealed root")
+	}
+	if len(events) != 2 || events[1].Version != 2 {
+		t.Errorf("events: %+v", events)
+	}
+	for i, id := range []string{"c", "d", "f", "a", "b"} {
+		proof, ok := store.GetProof(id)
+		if !ok || proof.LeafIndex != i || proof.TreeSize != 5 {
+			t.Errorf("proof for %s: %+v", id, proof)
+		}
+	}
+
+	// A second amendment extends the first
+	info, err = s.Amend(7, []*storage.SignedReceipt{testReceipt("e", 7)}, "later still")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := info.Amendments[1]; !merkle.VerifyConsistency(5, 6, a.PrevRoot, a.Root, a.ConsistencyProof) {
+		t.Error("second amendment does not extend the first")
+	}
+
+	// Once anchoring begins the epoch's receipts, proofs and payouts stay
+	// as they are
+	if err := epochs.BeginAnchoring(7, info.Root); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Amend(7, []*storage.SignedReceipt{testReceipt("g", 7)}, "while anchoring"); !errors.Is(err, epoch.ErrInvalidTransition) {
+		t.Errorf("amended an anchoring epoch: %v", err)
+	}
+	if stored, _ := store.GetByID("g"); stored != nil {
+		t.Error("stored a receipt for an anchoring epoch")
+	}
+
+	if err := epochs.MarkAnchored(7, info.Root, "0xabc"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Amend(7, nil, "after anchoring"); !errors.Is(err, epoch.ErrInvalidTransition) {
+		t.Errorf("amended an anchored epoch: %v", err)
+	}
+}
+
+// flakyProofs fails the next StoreProofs call once failNext is set.
+type flakyProofs struct {
+	*storage.MemoryStore
+	failNext bool
+}
+
+func (s *flakyProofs) StoreProofs(proofs map[string]*merkle.Proof) error {
+	if s.failNext {
+		s.failNext = false
+		return errors.New("disk full")
+	}
+	return s.MemoryStore.StoreProofs(proofs)
+}
+
+func TestAmendFailureLeavesStore(t *testing.T) {
+	store := &flakyProofs{MemoryStore: storage.NewStore()}
+	s := New(store, epoch.NewManager())
+	s.SetClock(func() time.Time { return time.Unix(0, 0) })
+	for _, id := range []string{"c", "d", "f"} {
+		s.Admit(testReceipt(id, 3))
+	}
+	if _, err := s.Seal(3); err != nil {
+		t.Fatal(err)
+	}
+	payouts, _ := store.GetPayouts(3)
+
+	store.failNext = true
+	late := []*storage.SignedReceipt{testReceipt("a", 3), testReceipt("b", 3)}
+	if _, err := s.Amend(3, late, "late receipts"); err == nil {
+		t.Fatal("amendment succeeded without its proofs")
+	}
+	if stored, _ := store.GetByID("a"); stored != nil || store.Count(3) != 3 {
+		t.Errorf("failed amendment kept its receipts: %d stored", store.Count(3))
+	}
+	if after, _ := store.GetPayouts(3); string(after) != string(payouts) {
+		t.Error("failed amendment changed the payouts")
+	}
+	for _, id := range []string{"c", "d", "f"} {
+		if proof, ok := store.GetProof(id); !ok || proof.TreeSize != 3 {
+			t.Errorf("proof for %s after a failed amendment: %+v", id, proof)
+		}
+	}
+
+	// The amendment can be made again
+	info, err := s.Amend(3, late, "late receipts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Version != 2 || info.ReceiptCount != 5 {
+		t.Errorf("amended epoch: %+v", info)
+	}
+}
+
+func TestMultiproof(t *testing.T) {
+	s, store, _ := newSealer(t)
+
+	if _, _, err := s.Multiproof(3, []string{"a"}); !errors.Is(err, ErrNotSealed) {
+		t.Errorf("multiproof for an open epoch: %v", err)
+	}
+
+	for _, id := range []string{"c", "d", "f"} {
+		s.Admit(testReceipt(id, 3))
+	}
+	if _, err := s.Seal(3); err != nil {
+		t.Fatal(err)
+	}
+	info, err := s.Amend(3, []*storage.SignedReceipt{testReceipt("a", 3), testReceipt("b", 3)}, "late receipts")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Leaves come back in tree order: c, d, f, a, b
+	proof, ids, err := s.Multiproof(3, []string{"b", "d", "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(ids) != "[c d b]" || fmt.Sprint(proof.LeafIndices) != "[0 1 4]" {
+		t.Fatalf("proved %v at %v", ids, proof.LeafIndices)
+	}
+	leaves := make([][]byte, len(ids))
+	for i, id := range ids {
+		receipt, _ := store.GetByID(id)
+		leaves[i], _ = jcs.Canonicalize(receipt.Receipt)
+	}
+	if !merkle.VerifyMultiproof(leaves, proof, info.Root) {
+		t.Error("multiproof does not verify")
+	}
+
+	if _, _, err := s.Multiproof(3, []string{"a", "z"}); !errors.Is(err, ErrNotInEpoch) {
+		t.Errorf("multiproof for an unknown receipt: %v", err)
+	}
+}
+
+func TestSealedMissingReceipts(t *testing.T) {
+	s, _, epochs := newSealer(t)
+	for _, id := range []string{"c", "d", "f"} {
+		s.Admit(testReceipt(id, 3))
+	}
+	if _, err := s.Seal(3); err != nil {
+		t.Fatal(err)
+	}
+
+	// A store holding fewer receipts than were sealed fails, not panics
+	partial := storage.NewStore()
+	partial.Store(testReceipt("c", 3))
+	other := New(partial, epochs)
+	if _, _, err := other.Multiproof(3, []string{"c"}); err == nil {
+		t.Error("multiproof over a partial store")
+	}
+	if _, err := other.Amend(3, []*storage.SignedReceipt{testReceipt("a", 3)}, "late receipt"); err == nil {
+		t.Error("amendment over a partial store")
+	}
+}
+
+func TestSealBuildsPayouts(t *testing.T) {
+	s, store, _ := newSealer(t)
+	alice := common.HexToAddress("0x00000000000000000000000000000000000000a1")
+	s.SetAddresses(payout.Addresses{"pk-a": alice})
+
+	var events []Event
+	s.OnSealed(func(e Event) { events = append(events, e) })
+
+	for i, provider := range []string{"pk-a", "pk-b", "pk-a"} {
+		receipt := testReceipt(fmt.Sprintf("r%d", i), 6)
+		receipt.Receipt.ProviderPK = provider
+		s.Admit(receipt)
+	}
+	if _, err := s.Seal(6); err != nil {
+		t.Fatal(err)
+	}
+
+	payouts, err := s.Payouts(6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payouts.Root == "" || events[0].PayoutRoot != payouts.Root {
+		t.Fatalf("payout root %q, event %+v", payouts.Root, events[0])
+	}
+	if len(payouts.Leaves) != 1 || payouts.Leaves[0].ReceiptCount != 2 || len(payouts.Unassigned) != 1 {
+		t.Errorf("payouts: %+v", payouts)
+	}
+
+	claim, err := s.PayoutClaim(6, alice.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !payout.Verify(claim.Leaf, claim.Proof, payouts.Root) || fmt.Sprint(claim.ReceiptIDs) != "[r0 r2]" {
+		t.Errorf("claim: %+v", claim)
+	}
+	if _, err := s.PayoutClaim(6, "0x00000000000000000000000000000000000000b0"); !errors.Is(err, payout.ErrNoPayout) {
+		t.Errorf("claim for an address with no payout: %v", err)
+	}
+
+	// An amendment rebuilds the payouts
+	late := testReceipt("r3", 6)
+	late.Receipt.ProviderPK = "pk-a"
+	if _, err := s.Amend(6, []*storage.SignedReceipt{late}, "late receipt"); err != nil {
+		t.Fatal(err)
+	}
+	amended, _ := s.Payouts(6)
+	if amended.Root == payouts.Root || amended.Leaves[0].ReceiptCount != 3 || events[1].PayoutRoot != amended.Root {
+		t.Errorf("payouts after amendment: %+v", amended)
+	}
+
+	// Stale or missing payouts, as for epochs sealed before payout trees,
+	// are rebuilt
+	store.SavePayouts(6, []byte(`{"epoch":6,"epoch_root":"old"}`))
+	rebuilt, err := s.Payouts(6)
+	if err != nil || rebuilt.Root != amended.Root {
+		t.Errorf("rebuilt payouts: %+v, %v", rebuilt, err)
+	}
+
+	if _, err := s.Payouts(7); !errors.Is(err, ErrNotSealed) {
+		t.Errorf("payouts for an open epoch: %v", err)
+	}
+}
+
+func TestSealPricesByEpoch(t *testing.T) {
+	s, _, _ := newSealer(t)
+	s.SetAddresses(payout.Addresses{"pk-a": common.HexToAddress("0x00000000000000000000000000000000000000a1")})
+	book, err := pricing.NewBook([]*pricing.Table{
+		{StartEpoch: 0, Default: &pricing.Rate{Input: big.NewInt(1), Output: big.NewInt(2)}},
+		{StartEpoch: 7, Models: map[string]pricing.Rate{"llama3.2:3b": {Input: big.NewInt(1), Output: big.NewInt(5)}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetPrice(book.Price)
+
+	for n, want := range map[uint64]int64{6: 20, 7: 50} {
+		receipt := testReceipt(fmt.Sprintf("r%d", n), n)
+		receipt.Receipt.ProviderPK = "pk-a"
+		if err := s.Admit(receipt); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Seal(n); err != nil {
+			t.Fatal(err)
+		}
+		payouts, err := s.Payouts(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := payouts.Leaves[0].Amount; got.Int64() != want || s.Price(&receipt.Receipt).Int64() != want {
+			t.Errorf("epoch %d paid %s, want %d", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,346 @@
+// Package settlement pays claims through a settlement backend and records
+// each one durably, so no claim is paid twice.
+//
+// A claim is recorded as pending before it is sent. If the aggregator stops
+// between sending and recording the transaction hash, the claim is sent
+// again; the settlement contract rejects the second transaction rather
+// than paying twice.
+package settlement
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultPollInterval is how often pending claims are checked.
+const DefaultPollInterval = 3 * time.Second
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusConfirmed Status = "confirmed"
+	StatusFailed    Status = "failed"
+)
+
+// ErrAlreadyClaimed means a claim ID is pending or has been paid.
+var ErrAlreadyClaimed = errors.New("already claimed")
+
+// Backend sends claims to a settlement contract. blockchain.Client and
+// contracts/mock.Chain implement it.
+type Backend interface {
+	// SubmitClaim sends a claim paying amount to the provider address for
+	// the receipt whose leaf hash is at leafIndex of the epoch's committed
+	// tree, with its inclusion proof. It returns the transaction hash
+	// without waiting for it to be mined.
+	SubmitClaim(ctx context.Context, epoch, leafIndex uint64, leafHash [32]byte, proof [][32]byte, provider string, amount *big.Int) (string, error)
+	// TransactionStatus returns "pending", "confirmed" or "failed".
+	TransactionStatus(ctx context.Context, txHash string) (string, error)
+}
+
+// Store keeps claim records. storage.Store implements it.
+type Store interface {
+	CreateClaim(id string, record []byte) (bool, error)
+	SaveClaim(id string, record []byte) error
+	GetClaim(id string) ([]byte, error)
+	LoadClaims() (map[string][]byte, error)
+}
+
+type Claim struct {
+	ID    string `json:"id"`
+	Epoch uint64 `json:"epoch"`
+	// LeafIndex, LeafHash and Proof place the claimed receipt in the
+	// epoch's receipt tree; the hashes are hex
+	LeafIndex uint64   `json:"leaf_index"`
+	LeafHash  string   `json:"leaf_hash"`
+	Proof     []string `json:"proof"`
+	Provider  string   `json:"provider"`
+	// Amount is a decimal string, which survives JSON parsers that read
+	// numbers as doubles
+	Amount    string    `json:"amount"`
+	TxHash    string    `json:"tx_hash,omitempty"`
+	Status    Status    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Settler submits claims and follows them until they are confirmed or fail.
+type Settler struct {
+	store   Store
+	backend Backend
+	poll    time.Duration
+	logger  *logrus.Logger
+
+	// mu serializes changes to claim records. It is not held while the
+	// backend is called; sending holds the IDs of claims being sent
+	// meanwhile, so no claim is sent twice at once.
+	mu      sync.Mutex
+	sending map[string]bool
+}
+
+func New(store Store, backend Backend) *Settler {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	return &Settler{
+		store:   store,
+		backend: backend,
+		poll:    DefaultPollInterval,
+		logger:  logger,
+		sending: make(map[string]bool),
+	}
+}
+
+// SetPollInterval sets how often Wait checks a pending claim.
+func (s *Settler) SetPollInterval(d time.Duration) {
+	s.poll = d
+}
+
+// Submit records a claim as pending and sends it. A claim ID can be
+// submitted again only after its earlier attempt failed; otherwise Submit
+// returns the existing claim with ErrAlreadyClaimed. If the backend rejects
+// the claim it is returned with StatusFailed and no error.
+func (s *Settler) Submit(ctx context.Context, claim *Claim) (*Claim, error) {
+	now := time.Now().UTC()
+	c := *claim
+	c.TxHash, c.Error = "", ""
+	c.Status = StatusPending
+	c.CreatedAt, c.UpdatedAt = now, now
+	record, err := json.Marshal(&c)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing, err := s.reserve(c.ID, record); existing != nil || err != nil {
+		return existing, err
+	}
+	return s.send(ctx, &c)
+}
+
+// reserve records a claim as pending and marks it as being sent. If the
+// claim ID is taken by a claim that has not failed, it returns that claim
+// with ErrAlreadyClaimed.
+func (s *Settler) reserve(id string, record []byte) (*Claim, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	created, err := s.store.CreateClaim(id, record)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		existing, err := s.get(id)
+		if err != nil {
+			return nil, err
+		}
+		if existing.Status != StatusFailed {
+			return existing, fmt.Errorf("%w: %s is %s", ErrAlreadyClaimed, id, existing.Status)
+		}
+		if err := s.store.SaveClaim(id, record); err != nil {
+			return nil, err
+		}
+	}
+	s.sending[id] = true
+	return nil, nil
+}
+
+// send submits a pending claim that reserve or Refresh marked as being
+// sent, and records the outcome. The caller must not hold mu.
+func (s *Settler) send(ctx context.Context, c *Claim) (*Claim, error) {
+	var txHash string
+	leafHash, proof, err := c.decodeProof()
+	if err == nil {
+		amount, ok := new(big.Int).SetString(c.Amount, 10)
+		err = fmt.Errorf("invalid amount %q", c.Amount)
+		if ok && amount.Sign() >= 0 {
+			txHash, err = s.backend.SubmitClaim(ctx, c.Epoch, c.LeafIndex, leafHash, proof, c.Provider, amount)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sending, c.ID)
+	if err != nil {
+		s.logger.WithError(err).WithField("claim_id", c.ID).Warn("Claim rejected")
+		c.Status = StatusFailed
+		c.Error = err.Error()
+	}
+	c.TxHash = txHash
+	return c, s.save(c)
+}
+
+func (c *Claim) decodeProof() ([32]byte, [][32]byte, error) {
+	leafHash, err := decodeHash(c.LeafHash)
+	if err != nil {
+		return leafHash, nil, fmt.Errorf("leaf hash: %w", err)
+	}
+	proof := make([][32]byte, len(c.Proof))
+	for i, h := range c.Proof {
+		if proof[i], err = decodeHash(h); err != nil {
+			return leafHash, nil, fmt.Errorf("proof hash %d: %w", i, err)
+		}
+	}
+	return leafHash, proof, nil
+}
+
+func decodeHash(s string) ([32]byte, error) {
+	var h [32]byte
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return h, err
+	}
+	if len(b) != len(h) {
+		return h, fmt.Errorf("%d bytes, want %d", len(b), len(h))
+	}
+	copy(h[:], b)
+	return h, nil
+}
+
+func (s *Settler) save(c *Claim) error {
+	c.UpdatedAt = time.Now().UTC()
+	record, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return s.store.SaveClaim(c.ID, record)
+}
+
+// Get returns nil and no error if there is no such claim.
+func (s *Settler) Get(id string) (*Claim, error) {
+	return s.get(id)
+}
+
+func (s *Settler) get(id string) (*Claim, error) {
+	record, err := s.store.GetClaim(id)
+	if err != nil || record == nil {
+		return nil, err
+	}
+	var c Claim
+	if err := json.Unmarshal(record, &c); err != nil {
+		return nil, fmt.Errorf("claim %s: %w", id, err)
+	}
+	return &c, nil
+}
+
+// Refresh asks the backend about a pending claim's transaction and records
+// any change. A pending claim with no transaction hash is sent again,
+// unless it is being sent.
+func (s *Settler) Refresh(ctx context.Context, id string) (*Claim, error) {
+	s.mu.Lock()
+	c, err := s.get(id)
+	if err != nil || c == nil || c.Status != StatusPending || s.sending[id] {
+		s.mu.Unlock()
+		return c, err
+	}
+	if c.TxHash == "" {
+		s.sending[id] = true
+		s.mu.Unlock()
+		return s.send(ctx, c)
+	}
+	s.mu.Unlock()
+
+	status, err := s.backend.TransactionStatus(ctx, c.TxHash)
+	if err != nil {
+		return c, err
+	}
+	switch Status(status) {
+	case StatusPending:
+		return c, nil
+	case StatusConfirmed, StatusFailed:
+		return s.settle(c, Status(status))
+	default:
+		return c, fmt.Errorf("backend reported unknown status %q for %s", status, c.TxHash)
+	}
+}
+
+// settle records the final status of c's transaction, unless the claim
+// changed while the backend was asked about it.
+func (s *Settler) settle(c *Claim, status Status) (*Claim, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, err := s.get(c.ID)
+	if err != nil || current == nil || current.Status != StatusPending || current.TxHash != c.TxHash {
+		return current, err
+	}
+	current.Status = status
+	if status == StatusFailed {
+		current.Error = "transaction reverted"
+	}
+	return current, s.save(current)
+}
+
+// Wait refreshes a claim until it is no longer pending or ctx is done, and
+// returns it as last seen.
+func (s *Settler) Wait(ctx context.Context, id string) (*Claim, error) {
+	ticker := time.NewTicker(s.poll)
+	defer ticker.Stop()
+
+	for {
+		c, err := s.Refresh(ctx, id)
+		if err != nil && ctx.Err() == nil {
+			s.logger.WithError(err).WithField("claim_id", id).Warn("Failed to check claim")
+		}
+		if c == nil || c.Status != StatusPending {
+			return c, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return c, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Pending returns the IDs of pending claims, in ID order.
+func (s *Settler) Pending() ([]string, error) {
+	records, err := s.store.LoadClaims()
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for id, record := range records {
+		var c Claim
+		if json.Unmarshal(record, &c) == nil && c.Status == StatusPending {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// Run refreshes every pending claim each interval until ctx is done,
+// including claims left pending by an earlier run.
+func (s *Settler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		ids, err := s.Pending()
+		if err != nil {
+			s.logger.WithError(err).Error("Failed to list pending claims")
+		}
+		for _, id := range ids {
+			if _, err := s.Refresh(ctx, id); err != nil {
+				s.logger.WithError(err).WithField("claim_id", id).Warn("Failed to check claim")
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package settlement
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/quiver/aggregator/pkg/storage"
+)
+
+// fakeBackend mines a transaction after it has been asked about it mined
+// times.
+type fakeBackend struct {
+	mu      sync.Mutex
+	mined   int
+	revert  bool
+	reject  error
+	sent    [][32]byte
+	queries map[string]int
+}
+
+func (b *fakeBackend) SubmitClaim(ctx context.Context, epoch, leafIndex uint64, leafHash [32]byte, proof [][32]byte, provider string, amount *big.Int) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.reject != nil {
+		return "", b.reject
+	}
+	b.sent = append(b.sent, leafHash)
+	return fmt.Sprintf("0x%02x", len(b.sent)), nil
+}
+
+func (b *fakeBackend) TransactionStatus(ctx context.Context, txHash string) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.queries == nil {
+		b.queries = make(map[string]int)
+	}
+	b.queries[txHash]++
+	switch {
+	case b.queries[txHash] <= b.mined:
+		return "pending", nil
+	case b.revert:
+		return "failed", nil
+	}
+	return "confirmed", nil
+}
+
+func newSettler(backend *fakeBackend) *Settler {
+	s := New(storage.NewStore(), backend)
+	s.SetPollInterval(time.Millisecond)
+	return s
+}
+
+func testClaim(id string) *Claim {
+	return &Claim{
+		ID:        id,
+		Epoch:     7,
+		LeafIndex: 2,
+		LeafHash:  strings.Repeat("11", 32),
+		Proof:     []string{strings.Repeat("22", 32), strings.Repeat("33", 32)},
+		Provider:  "0x00000000000000000000000000000000000000aa",
+		Amount:    "1500",
+	}
+}
+
+func TestSubmitAndWait(t *testing.T) {
+	backend := &fakeBackend{mined: 2}
+	s := newSettler(backend)
+	ctx := context.Background()
+
+	c, err := s.Submit(ctx, testClaim("r1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Status != StatusPending || c.TxHash != "0x01" {
+		t.Fatalf("submitted claim %+v", c)
+	}
+
+	c, err = s.Wait(ctx, "r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Status != StatusConfirmed || c.TxHash != "0x01" {
+		t.Fatalf("waited claim %+v", c)
+	}
+
+	stored, err := s.Get("r1")
+	if err != nil || stored.Status != StatusConfirmed || stored.Amount != "1500" {
+		t.Fatalf("stored claim %+v: %v", stored, err)
+	}
+	if missing, err := s.Get("nope"); missing != nil || err != nil {
+		t.Fatalf("Get of unknown claim = %+v, %v", missing, err)
+	}
+}
+
+func TestSubmitRejectsDoubleClaim(t *testing.T) {
+	backend := &fakeBackend{mined: 100}
+	s := newSettler(backend)
+	ctx := context.Background()
+
+	if _, err := s.Submit(ctx, testClaim("r1")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Pending claims are spent too
+	existing, err := s.Submit(ctx, testClaim("r1"))
+	if !errors.Is(err, ErrAlreadyClaimed) || existing == nil || existing.TxHash != "0x01" {
+		t.Fatalf("second submit = %+v, %v", existing, err)
+	}
+
+	backend.mined = 0
+	if _, err := s.Wait(ctx, "r1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Submit(ctx, testClaim("r1")); !errors.Is(err, ErrAlreadyClaimed) {
+		t.Fatalf("submit after confirmation: %v", err)
+	}
+	if len(backend.sent) != 1 {
+		t.Fatalf("backend saw %d submissions, want 1", len(backend.sent))
+	}
+}
+
+func TestFailedClaimsCanBeRetried(t *testing.T) {
+	backend := &fakeBackend{revert: true}
+	s := newSettler(backend)
+	ctx := context.Background()
+
+	if _, err := s.Submit(ctx, testClaim("r1")); err != nil {
+		t.Fatal(err)
+	}
+	c, err := s.Wait(ctx, "r1")
+	if err != nil || c.Status != StatusFailed || c.Error == "" {
+		t.Fatalf("reverted claim %+v: %v", c, err)
+	}
+
+	backend.reject = errors.New("insufficient funds")
+	c, err = s.Submit(ctx, testClaim("r1"))
+	if err != nil || c.Status != StatusFailed || c.TxHash != "" || c.Error != "insufficient funds" {
+		t.Fatalf("rejected claim %+v: %v", c, err)
+	}
+
+	backend.reject, backend.revert = nil, false
+	if c, err = s.Submit(ctx, testClaim("r1")); err != nil {
+		t.Fatal(err)
+	}
+	if c, err = s.Wait(ctx, "r1"); err != nil || c.Status != StatusConfirmed || c.TxHash != "0x02" {
+		t.Fatalf("retried claim %+v: %v", c, err)
+	}
+}
+
+func TestWaitStopsAtDeadline(t *testing.T) {
+	s := newSettler(&fakeBackend{mined: 1 << 30})
+	if _, err := s.Submit(context.Background(), testClaim("r1")); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	c, err := s.Wait(ctx, "r1")
+	if err != nil || c.Status != StatusPending {
+		t.Fatalf("claim after deadline %+v: %v", c, err)
+	}
+}
+
+func TestRunSettlesPendingClaims(t *testing.T) {
+	backend := &fakeBackend{}
+	store := storage.NewStore()
+
+	// A claim recorded before a crash, never sent
+	record, err := json.Marshal(&Claim{ID: "r1", LeafHash: strings.Repeat("44", 32), Provider: "0xaa", Amount: "5", Status: StatusPending})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.CreateClaim("r1", record); err != nil {
+		t.Fatal(err)
+	}
+	s := New(store, backend)
+	if _, err := s.Submit(context.Background(), testClaim("r2")); err != nil {
+		t.Fatal(err)
+	}
+	if ids, err := s.Pending(); err != nil || len(ids) != 2 {
+		t.Fatalf("pending = %v, %v", ids, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx, time.Millisecond)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		ids, err := s.Pending()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ids) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("claims %v still pending", ids)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	for _, id := range []string{"r1", "r2"} {
+		if c, err := s.Get(id); err != nil || c.Status != StatusConfirmed || c.TxHash == "" {
+			t.Fatalf("claim %s = %+v, %v", id, c, err)
+		}
+	}
+}
+
+func TestSubmitRejectsMalformedProof(t *testing.T) {
+	backend := &fakeBackend{}
+	s := newSettler(backend)
+
+	claim := testClaim("r1")
+	claim.Proof = append(claim.Proof, "abcd")
+	c, err := s.Submit(context.Background(), claim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Status != StatusFailed || !strings.Contains(c.Error, "proof hash 2") {
+		t.Fatalf("claim with a short proof hash: %+v", c)
+	}
+	if len(backend.sent) != 0 {
+		t.Fatalf("backend saw %d submissions, want 0", len(backend.sent))
+	}
+}
+
+// slowBackend holds each SubmitClaim until release is closed.
+type slowBackend struct {
+	*fakeBackend
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (b *slowBackend) SubmitClaim(ctx context.Context, epoch, leafIndex uint64, leafHash [32]byte, proof [][32]byte, provider string, amount *big.Int) (string, error) {
+	b.entered <- struct{}{}
+	<-b.release
+	return b.fakeBackend.SubmitClaim(ctx, epoch, leafIndex, leafHash, proof, provider, amount)
+}
+
+func TestSubmitDoesNotHoldLockWhileSending(t *testing.T) {
+	backend := &slowBackend{fakeBackend: &fakeBackend{}, entered: make(chan struct{}, 2), release: make(chan struct{})}
+	s := New(storage.NewStore(), backend)
+	s.SetPollInterval(time.Millisecond)
+	ctx := context.Background()
+
+	submitted := make(chan *Claim)
+	go func() {
+		c, err := s.Submit(ctx, testClaim("r1"))
+		if err != nil {
+			t.Error(err)
+		}
+		submitted <- c
+	}()
+	<-backend.entered
+
+	// Other claims and refreshes go ahead meanwhile, and the claim being
+	// sent is not sent again
+	if c, err := s.Refresh(ctx, "r1"); err != nil || c.Status != StatusPending || c.TxHash != "" {
+		t.Fatalf("refresh while sending = %+v, %v", c, err)
+	}
+	if existing, err := s.Submit(ctx, testClaim("r1")); !errors.Is(err, ErrAlreadyClaimed) || existing == nil {
+		t.Fatalf("second submit while sending = %+v, %v", existing, err)
+	}
+	go s.Submit(ctx, testClaim("r2"))
+	<-backend.entered
+
+	close(backend.release)
+	if c := <-submitted; c == nil || c.Status != StatusPending || c.TxHash == "" {
+		t.Fatalf("submitted claim %+v", c)
+	}
+	if c, err := s.Wait(ctx, "r1"); err != nil || c.Status != StatusConfirmed {
+		t.Fatalf("claim %+v: %v", c, err)
+	}
+	if c, err := s.Wait(ctx, "r2"); err != nil || c.Status != StatusConfirmed {
+		t.Fatalf("claim %+v: %v", c, err)
+	}
+	if len(backend.sent) != 2 {
+		t.Fatalf("backend saw %d submissions, want 2", len(backend.sent))
+	}
+}
@@ -0,0 +1,187 @@
+// Package slashing acts on evidence that a provider failed a canary, a
+// known-answer prompt a gateway sent it as an ordinary request (see
+// gateway/pkg/canary). Evidence is accepted from trusted gateway keys only,
+// and only once it verifies: the gateway signed it, the provider signed the
+// receipt, the receipt commits to the canary's prompt and the completion,
+// and the completion fails the canary when scored again here.
+//
+// Recorded evidence costs the provider twice. If it arrives while the
+// receipt's epoch is still open, none of the provider's receipts in that
+// epoch earn anything; see Ledger.Price. And with a Backend set, each piece
+// of evidence slashes the provider's stake once.
+package slashing
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/quiver/aggregator/pkg/jcs"
+	"github.com/quiver/aggregator/pkg/storage"
+	"github.com/quiver/aggregator/pkg/verify"
+)
+
+const (
+	EvidenceVersion  = "1"
+	DomainEvidenceV1 = "QUIVER-CANARY-EVIDENCE-V1"
+)
+
+var (
+	// ErrUntrustedGateway means evidence is signed by a gateway key the
+	// aggregator does not trust.
+	ErrUntrustedGateway = errors.New("evidence not from a trusted gateway")
+	// ErrInvalidEvidence means evidence does not show a provider failing a
+	// canary.
+	ErrInvalidEvidence = errors.New("invalid evidence")
+)
+
+// Evidence mirrors gateway/pkg/canary.Evidence: a provider's failed answer
+// to a canary. Receipt is kept as raw JSON, as the gateway signed it.
+type Evidence struct {
+	Version    string          `json:"version"`
+	CanaryID   string          `json:"canary_id"`
+	Prompt     string          `json:"prompt"`
+	Answers    []string        `json:"answers"`
+	Completion string          `json:"completion"`
+	Receipt    json.RawMessage `json:"receipt"`
+	GatewayPK  string          `json:"gateway_pk"`
+	IssuedAt   string          `json:"issued_at"`
+}
+
+// SignedEvidence is evidence signed by the gateway that issued the canary.
+type SignedEvidence struct {
+	Evidence  Evidence `json:"evidence"`
+	Algorithm string   `json:"alg"`
+	Signature string   `json:"signature"`
+}
+
+// SigningInput returns the bytes the gateway signs:
+// "QUIVER-CANARY-EVIDENCE-V1" || 0x00 || JCS(evidence).
+func SigningInput(e *Evidence) ([]byte, error) {
+	canonical, err := jcs.Canonicalize(e)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(DomainEvidenceV1)+1+len(canonical))
+	out = append(out, DomainEvidenceV1...)
+	out = append(out, 0)
+	return append(out, canonical...), nil
+}
+
+// Hash returns the SHA-256 of the evidence's signing input, which names the
+// evidence on chain.
+func Hash(e *Evidence) ([32]byte, error) {
+	input, err := SigningInput(e)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return sha256.Sum256(input), nil
+}
+
+// Verify checks evidence against the trusted gateway keys and returns the
+// provider's receipt for the canary. The receipt's signature is checked
+// against the provider's known key history, so a receipt signed with a
+// revoked key is not evidence against the provider; with a nil history,
+// only the succession chain the receipt carries is followed.
+func Verify(s *SignedEvidence, gateways verify.KeySet, history *verify.KeyHistory) (*storage.SignedReceipt, error) {
+	e := &s.Evidence
+	if gateways == nil || !gateways.Contains(e.GatewayPK) {
+		return nil, ErrUntrustedGateway
+	}
+	if history == nil {
+		history = verify.NewKeyHistory()
+	}
+	receipt, err := check(s, history)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEvidence, err)
+	}
+	return receipt, nil
+}
+
+func check(s *SignedEvidence, history *verify.KeyHistory) (*storage.SignedReceipt, error) {
+	e := &s.Evidence
+	if e.Version != EvidenceVersion {
+		return nil, fmt.Errorf("unsupported evidence version %q", e.Version)
+	}
+	if s.Algorithm != "ed25519" {
+		return nil, fmt.Errorf("unsupported signature algorithm %q", s.Algorithm)
+	}
+	gatewayKey, err := base64.StdEncoding.DecodeString(e.GatewayPK)
+	if err != nil || len(gatewayKey) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid gateway key")
+	}
+	input, err := SigningInput(e)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := base64.StdEncoding.DecodeString(s.Signature)
+	if err != nil || !ed25519.Verify(gatewayKey, input, signature) {
+		return nil, errors.New("invalid gateway signature")
+	}
+
+	var receipt storage.SignedReceipt
+	if err := json.Unmarshal(e.Receipt, &receipt); err != nil {
+		return nil, fmt.Errorf("receipt: %w", err)
+	}
+	r := &receipt.Receipt
+	if err := history.Learn(r.ProviderPK, receipt.Succession); err != nil {
+		return nil, fmt.Errorf("key history: %v", err)
+	}
+	if valid, err := verify.VerifySignatureChain(&receipt, r.ProviderPK, history.Chain(r.ProviderPK)); err != nil || !valid {
+		return nil, fmt.Errorf("receipt signature does not verify: %v", err)
+	}
+	if r.Stream != nil || r.PromptHash != hashString(e.Prompt) || r.OutputHash != hashString(e.Completion) {
+		return nil, errors.New("receipt does not match prompt and completion")
+	}
+	if Passed(e.Answers, e.Completion) {
+		return nil, errors.New("completion passes the canary")
+	}
+	return &receipt, nil
+}
+
+// Passed reports whether completion begins with one of answers, compared
+// as lower-case words of letters and digits. It mirrors
+// gateway/pkg/canary.Passed; both are tested against
+// testvectors/canary.json.
+func Passed(answers []string, completion string) bool {
+	text := normalize(completion)
+	for _, answer := range answers {
+		if a := normalize(answer); strings.TrimSpace(a) != "" && strings.HasPrefix(text, a) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalize lower-cases s and replaces every run of characters other than
+// letters and digits with one space, with a space at each end.
+func normalize(s string) string {
+	var b strings.Builder
+	b.WriteByte(' ')
+	space := true
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			space = false
+		} else if !space {
+			b.WriteByte(' ')
+			space = true
+		}
+	}
+	if !space {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
+// hashString hashes like the provider does for prompt_hash and output_hash.
+func hashString(s string) string {
+	h := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(h[:])
+}
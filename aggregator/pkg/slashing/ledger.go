@@ -0,0 +1,440 @@
+package slashing
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/quiver/aggregator/pkg/payout"
+	"github.com/quiver/aggregator/pkg/storage"
+	"github.com/quiver/aggregator/pkg/verify"
+	"github.com/sirupsen/logrus"
+)
+
+// Store keeps evidence records. storage.Store implements it.
+type Store interface {
+	CreateEvidence(id string, record []byte) (bool, error)
+	SaveEvidence(id string, record []byte) error
+	LoadEvidence() (map[string][]byte, error)
+}
+
+// Epochs tells whether evidence arrives in time to forfeit rewards.
+// sealer.Sealer implements it.
+type Epochs interface {
+	// WhileOpen runs fn if epoch n is open, holding off its sealing until
+	// fn returns, and reports whether fn ran.
+	WhileOpen(n uint64, fn func() error) (bool, error)
+}
+
+// Backend disputes and slashes stakes on the settlement contract.
+// blockchain.Client and contracts/mock.Chain implement it. Each sending
+// method returns the transaction hash without waiting for it to be mined.
+type Backend interface {
+	// OpenDispute keeps the stake bound to a provider key from being
+	// withdrawn until CloseDispute is called as many times.
+	OpenDispute(ctx context.Context, key [32]byte) (string, error)
+	CloseDispute(ctx context.Context, key [32]byte) (string, error)
+	// Slash takes up to amount from the stake bound to a provider key, at
+	// most once per evidenceHash.
+	Slash(ctx context.Context, key [32]byte, amount *big.Int, evidenceHash [32]byte) (string, error)
+	// TransactionStatus returns "pending", "confirmed" or "failed".
+	TransactionStatus(ctx context.Context, txHash string) (string, error)
+}
+
+// Slash statuses of a record.
+const (
+	// SlashPending means the slash was sent and is not yet mined, or it
+	// was mined and the dispute is still to be closed
+	SlashPending = "pending"
+	// SlashConfirmed means the slash was mined and the dispute closed
+	SlashConfirmed = "confirmed"
+	// SlashFailed means the slash was not sent or it reverted
+	SlashFailed = "failed"
+)
+
+// Record is recorded evidence against a provider. Records are keyed by the
+// receipt they implicate, so a canary answer is punished once.
+type Record struct {
+	ReceiptID  string `json:"receipt_id"`
+	ProviderPK string `json:"provider_pk"`
+	Epoch      uint64 `json:"epoch"`
+	CanaryID   string `json:"canary_id"`
+	GatewayPK  string `json:"gateway_pk"`
+	// EvidenceHash is the hex Hash of the evidence
+	EvidenceHash string `json:"evidence_hash"`
+	// Forfeit is true if the evidence arrived while the epoch was open, so
+	// the provider's receipts in it earn nothing
+	Forfeit bool `json:"forfeit"`
+	// DisputeTx opened a dispute holding the provider's stake, SlashTx
+	// slashed it and CloseTx closed the dispute once the slash was mined.
+	// SlashError says why the stake was not slashed; the dispute then
+	// stays open.
+	DisputeTx   string          `json:"dispute_tx,omitempty"`
+	SlashTx     string          `json:"slash_tx,omitempty"`
+	SlashStatus string          `json:"slash_status,omitempty"`
+	CloseTx     string          `json:"close_tx,omitempty"`
+	SlashError  string          `json:"slash_error,omitempty"`
+	Evidence    *SignedEvidence `json:"evidence"`
+	RecordedAt  time.Time       `json:"recorded_at"`
+}
+
+// Ledger verifies and records evidence and applies its penalties.
+type Ledger struct {
+	store    Store
+	gateways verify.KeySet
+	history  *verify.KeyHistory
+	epochs   Epochs
+	backend  Backend
+	amount   *big.Int
+	records  map[string]*Record
+	// forfeits holds, per epoch, the providers whose receipts earn nothing
+	forfeits map[uint64]map[string]bool
+	// refreshing holds the records whose slash Refresh is moving along
+	refreshing map[string]bool
+	logger     *logrus.Logger
+
+	mu sync.RWMutex
+}
+
+// New loads the records in store. Evidence is accepted from the gateway
+// keys in gateways.
+func New(store Store, gateways verify.KeySet, epochs Epochs) (*Ledger, error) {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	l := &Ledger{
+		store:      store,
+		gateways:   gateways,
+		epochs:     epochs,
+		records:    make(map[string]*Record),
+		forfeits:   make(map[uint64]map[string]bool),
+		refreshing: make(map[string]bool),
+		logger:     logger,
+	}
+	stored, err := store.LoadEvidence()
+	if err != nil {
+		return nil, err
+	}
+	for id, data := range stored {
+		var r Record
+		if err := json.Unmarshal(data, &r); err != nil {
+			return nil, fmt.Errorf("evidence %s: %w", id, err)
+		}
+		l.add(&r)
+	}
+	return l, nil
+}
+
+// SetBackend slashes amount from a provider's stake for each piece of
+// evidence recorded afterwards. The stake is held by a dispute from when
+// the evidence is recorded until the slash has been mined; see Run.
+func (l *Ledger) SetBackend(backend Backend, amount *big.Int) {
+	l.backend = backend
+	l.amount = amount
+}
+
+// SetKeyHistory checks the receipts in evidence against history, normally
+// the receipt verifier's, so evidence follows the same key rotations and
+// revocations as ingestion.
+func (l *Ledger) SetKeyHistory(history *verify.KeyHistory) {
+	l.history = history
+}
+
+func (l *Ledger) add(r *Record) {
+	l.records[r.ReceiptID] = r
+	if r.Forfeit {
+		if l.forfeits[r.Epoch] == nil {
+			l.forfeits[r.Epoch] = make(map[string]bool)
+		}
+		l.forfeits[r.Epoch][r.ProviderPK] = true
+	}
+}
+
+// Record verifies evidence, records it and applies its penalties. Evidence
+// for a receipt already recorded returns the existing record and false.
+// Failing to dispute or slash is noted in the record, not returned.
+func (l *Ledger) Record(ctx context.Context, s *SignedEvidence) (*Record, bool, error) {
+	receipt, err := Verify(s, l.gateways, l.history)
+	if err != nil {
+		return nil, false, err
+	}
+	hash, err := Hash(&s.Evidence)
+	if err != nil {
+		return nil, false, err
+	}
+	r := &Record{
+		ReceiptID:    receipt.Receipt.ReceiptID,
+		ProviderPK:   receipt.Receipt.ProviderPK,
+		CanaryID:     s.Evidence.CanaryID,
+		GatewayPK:    s.Evidence.GatewayPK,
+		EvidenceHash: hex.EncodeToString(hash[:]),
+		Evidence:     s,
+		RecordedAt:   time.Now().UTC(),
+	}
+	if receipt.Receipt.Epoch > 0 {
+		r.Epoch = uint64(receipt.Receipt.Epoch)
+	}
+
+	created := false
+	create := func() error {
+		data, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if created, err = l.store.CreateEvidence(r.ReceiptID, data); err != nil || !created {
+			return err
+		}
+		l.add(r)
+		return nil
+	}
+	// Mark the record before trying, since create only runs while the
+	// epoch stays open
+	r.Forfeit = true
+	open := false
+	if l.epochs != nil {
+		open, err = l.epochs.WhileOpen(r.Epoch, create)
+	}
+	if err == nil && !open {
+		r.Forfeit = false
+		err = create()
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if !created {
+		existing, _ := l.Get(r.ReceiptID)
+		return existing, false, nil
+	}
+
+	l.logger.WithFields(logrus.Fields{
+		"provider_pk": r.ProviderPK,
+		"receipt_id":  r.ReceiptID,
+		"canary_id":   r.CanaryID,
+		"forfeit":     r.Forfeit,
+	}).Warn("Recorded failed canary")
+
+	if l.backend != nil && l.amount != nil && l.amount.Sign() > 0 {
+		l.slash(ctx, r, hash)
+		if _, err := l.Refresh(ctx, r.ReceiptID); err != nil {
+			l.logger.WithError(err).WithField("receipt_id", r.ReceiptID).Warn("Failed to check slash")
+		}
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.copy(r), true, nil
+}
+
+// slash opens a dispute against a new record's provider, sends the slash
+// and saves the outcome. The transactions share a sender, so they are mined
+// in that order. Refresh closes the dispute once the slash is mined. If
+// slashing fails the dispute stays open, keeping the stake from being
+// withdrawn until the contract's owner resolves it.
+func (l *Ledger) slash(ctx context.Context, r *Record, hash [32]byte) {
+	var disputeTx, slashTx string
+	key, err := providerKey(r.ProviderPK)
+	if err == nil {
+		disputeTx, err = l.backend.OpenDispute(ctx, key)
+	}
+	if err == nil {
+		slashTx, err = l.backend.Slash(ctx, key, l.amount, hash)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	r.DisputeTx = disputeTx
+	r.SlashTx = slashTx
+	r.SlashStatus = SlashPending
+	if err != nil {
+		l.logger.WithError(err).WithField("receipt_id", r.ReceiptID).Error("Failed to slash stake")
+		r.SlashStatus = SlashFailed
+		r.SlashError = err.Error()
+	}
+	l.save(r)
+}
+
+// Refresh asks the backend whether a record's pending slash was mined. Once
+// it is, Refresh closes the dispute; if it reverted, the slash is marked
+// failed and the dispute left open.
+func (l *Ledger) Refresh(ctx context.Context, receiptID string) (*Record, error) {
+	l.mu.Lock()
+	r, ok := l.records[receiptID]
+	if !ok {
+		l.mu.Unlock()
+		return nil, nil
+	}
+	if l.backend == nil || r.SlashStatus != SlashPending || l.refreshing[receiptID] {
+		defer l.mu.Unlock()
+		return l.copy(r), nil
+	}
+	l.refreshing[receiptID] = true
+	c := l.copy(r)
+	l.mu.Unlock()
+
+	err := l.advance(ctx, c)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.refreshing, receiptID)
+	*r = *c
+	l.save(r)
+	return l.copy(r), err
+}
+
+// advance moves a pending slash along as far as the chain allows.
+func (l *Ledger) advance(ctx context.Context, r *Record) error {
+	key, err := providerKey(r.ProviderPK)
+	if err != nil {
+		return err
+	}
+	if r.CloseTx == "" {
+		status, err := l.backend.TransactionStatus(ctx, r.SlashTx)
+		if err != nil {
+			return err
+		}
+		switch status {
+		case SlashPending:
+			return nil
+		case SlashFailed:
+			l.logger.WithField("receipt_id", r.ReceiptID).Error("Slash transaction reverted")
+			r.SlashStatus = SlashFailed
+			r.SlashError = "slash transaction reverted"
+			return nil
+		case SlashConfirmed:
+		default:
+			return fmt.Errorf("backend reported unknown status %q for %s", status, r.SlashTx)
+		}
+		if r.CloseTx, err = l.backend.CloseDispute(ctx, key); err != nil {
+			return fmt.Errorf("close dispute: %w", err)
+		}
+	}
+
+	// The close is not watched: if it reverts the stake stays held, which
+	// the contract's owner can undo
+	r.SlashStatus = SlashConfirmed
+	return nil
+}
+
+// Pending returns the IDs of records whose slash is pending, in ID order.
+func (l *Ledger) Pending() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var ids []string
+	for id, r := range l.records {
+		if r.SlashStatus == SlashPending {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Run refreshes every pending slash each interval until ctx is done,
+// including slashes left pending by an earlier run.
+func (l *Ledger) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for _, id := range l.Pending() {
+			if _, err := l.Refresh(ctx, id); err != nil {
+				l.logger.WithError(err).WithField("receipt_id", id).Warn("Failed to check slash")
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// save stores r. The caller must hold mu.
+func (l *Ledger) save(r *Record) {
+	data, err := json.Marshal(r)
+	if err == nil {
+		err = l.store.SaveEvidence(r.ReceiptID, data)
+	}
+	if err != nil {
+		l.logger.WithError(err).WithField("receipt_id", r.ReceiptID).Error("Failed to save slashing outcome")
+	}
+}
+
+func providerKey(providerPK string) ([32]byte, error) {
+	var key [32]byte
+	decoded, err := base64.StdEncoding.DecodeString(providerPK)
+	if err != nil {
+		return key, err
+	}
+	if len(decoded) != len(key) {
+		return key, fmt.Errorf("provider key is %d bytes", len(decoded))
+	}
+	copy(key[:], decoded)
+	return key, nil
+}
+
+// Get returns the record for a receipt.
+func (l *Ledger) Get(receiptID string) (*Record, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	r, ok := l.records[receiptID]
+	if !ok {
+		return nil, false
+	}
+	return l.copy(r), true
+}
+
+// List returns the records against a provider, or all records if
+// providerPK is empty, oldest first.
+func (l *Ledger) List(providerPK string) []*Record {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var records []*Record
+	for _, r := range l.records {
+		if providerPK == "" || r.ProviderPK == providerPK {
+			records = append(records, l.copy(r))
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if !records[i].RecordedAt.Equal(records[j].RecordedAt) {
+			return records[i].RecordedAt.Before(records[j].RecordedAt)
+		}
+		return records[i].ReceiptID < records[j].ReceiptID
+	})
+	return records
+}
+
+// copy returns a copy of r that later slashing outcomes do not change. The
+// caller must hold mu.
+func (l *Ledger) copy(r *Record) *Record {
+	c := *r
+	return &c
+}
+
+// Forfeits reports whether a provider's receipts in an epoch earn nothing.
+func (l *Ledger) Forfeits(epoch uint64, providerPK string) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.forfeits[epoch][providerPK]
+}
+
+// Price wraps price so that receipts of providers who forfeited their
+// epoch earn nothing.
+func (l *Ledger) Price(price payout.Price) payout.Price {
+	return func(r *storage.Receipt) *big.Int {
+		if r.Epoch >= 0 && l.Forfeits(uint64(r.Epoch), r.ProviderPK) {
+			return new(big.Int)
+		}
+		return price(r)
+	}
+}
@@ -0,0 +1,368 @@
+package slashing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/quiver/aggregator/pkg/storage"
+	"github.com/quiver/aggregator/pkg/verify"
+)
+
+type canaryVectors struct {
+	Scoring []struct {
+		Answers    []string `json:"answers"`
+		Completion string   `json:"completion"`
+		Passed     bool     `json:"passed"`
+	} `json:"scoring"`
+	Gateway struct {
+		PublicKey string `json:"public_key"`
+	} `json:"gateway"`
+	SigningInput string `json:"signing_input"`
+	Cases        []struct {
+		Name   string         `json:"name"`
+		Signed SignedEvidence `json:"signed"`
+		Valid  bool           `json:"valid"`
+	} `json:"cases"`
+}
+
+func loadVectors(t *testing.T) *canaryVectors {
+	t.Helper()
+	data, err := os.ReadFile("../../../testvectors/canary.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v canaryVectors
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatal(err)
+	}
+	return &v
+}
+
+func TestScoringVectors(t *testing.T) {
+	for _, tc := range loadVectors(t).Scoring {
+		if got := Passed(tc.Answers, tc.Completion); got != tc.Passed {
+			t.Errorf("Passed(%q, %q) = %v, want %v", tc.Answers, tc.Completion, got, tc.Passed)
+		}
+	}
+}
+
+func TestEvidenceVectors(t *testing.T) {
+	v := loadVectors(t)
+	input, err := SigningInput(&v.Cases[0].Signed.Evidence)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(input) != v.SigningInput {
+		t.Errorf("signing input\n%q\nwant\n%q", input, v.SigningInput)
+	}
+
+	trusted := verify.NewAllowlist([]string{v.Gateway.PublicKey})
+	for _, tc := range v.Cases {
+		_, err := Verify(&tc.Signed, trusted, nil)
+		if (err == nil) != tc.Valid {
+			t.Errorf("%s: Verify = %v, want valid %v", tc.Name, err, tc.Valid)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidEvidence) {
+			t.Errorf("%s: Verify = %v, want ErrInvalidEvidence", tc.Name, err)
+		}
+	}
+	if _, err := Verify(&v.Cases[0].Signed, verify.NewAllowlist(nil), nil); !errors.Is(err, ErrUntrustedGateway) {
+		t.Errorf("evidence from an untrusted gateway: %v", err)
+	}
+}
+
+func TestVerifyKeyHistory(t *testing.T) {
+	v := loadVectors(t)
+	trusted := verify.NewAllowlist([]string{v.Gateway.PublicKey})
+
+	// The evidence's receipt is signed with the rotation vectors' first
+	// key at seq 7, after the rotation revoked it from seq 2
+	data, err := os.ReadFile("../../../testvectors/rotation.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rotation struct {
+		Chain []storage.SignedSuccession `json:"chain"`
+	}
+	if err := json.Unmarshal(data, &rotation); err != nil {
+		t.Fatal(err)
+	}
+	var receipt storage.SignedReceipt
+	if err := json.Unmarshal(v.Cases[0].Signed.Evidence.Receipt, &receipt); err != nil {
+		t.Fatal(err)
+	}
+
+	history := verify.NewKeyHistory()
+	if _, err := Verify(&v.Cases[0].Signed, trusted, history); err != nil {
+		t.Fatalf("Verify before the rotation is known = %v", err)
+	}
+	if err := history.Learn(receipt.Receipt.ProviderPK, rotation.Chain); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Verify(&v.Cases[0].Signed, trusted, history); !errors.Is(err, ErrInvalidEvidence) {
+		t.Errorf("receipt signed with a revoked key: %v", err)
+	}
+}
+
+type epochs struct {
+	open bool
+}
+
+func (e *epochs) WhileOpen(n uint64, fn func() error) (bool, error) {
+	if !e.open {
+		return false, nil
+	}
+	return true, fn()
+}
+
+type backend struct {
+	slashed map[[32]byte]*big.Int
+	// disputes counts the disputes open against each key
+	disputes map[[32]byte]int
+	// status is what TransactionStatus reports, "confirmed" if empty
+	status string
+	err    error
+}
+
+func (b *backend) OpenDispute(ctx context.Context, key [32]byte) (string, error) {
+	if b.disputes == nil {
+		b.disputes = make(map[[32]byte]int)
+	}
+	b.disputes[key]++
+	return "0xd15", nil
+}
+
+func (b *backend) CloseDispute(ctx context.Context, key [32]byte) (string, error) {
+	if b.disputes[key] == 0 {
+		return "", errors.New("no open dispute")
+	}
+	b.disputes[key]--
+	return "0xc105e", nil
+}
+
+func (b *backend) Slash(ctx context.Context, key [32]byte, amount *big.Int, evidenceHash [32]byte) (string, error) {
+	if b.err != nil {
+		return "", b.err
+	}
+	if b.slashed == nil {
+		b.slashed = make(map[[32]byte]*big.Int)
+	}
+	b.slashed[evidenceHash] = amount
+	return "0xabc", nil
+}
+
+func (b *backend) TransactionStatus(ctx context.Context, txHash string) (string, error) {
+	if b.status == "" {
+		return "confirmed", nil
+	}
+	return b.status, nil
+}
+
+func TestLedger(t *testing.T) {
+	v := loadVectors(t)
+	failed := &v.Cases[0].Signed
+	trusted := verify.NewAllowlist([]string{v.Gateway.PublicKey})
+	store := storage.NewStore()
+	open := &epochs{open: true}
+	chain := &backend{}
+
+	ledger, err := New(store, trusted, open)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ledger.SetBackend(chain, big.NewInt(600))
+
+	record, created, err := ledger.Record(context.Background(), failed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created || !record.Forfeit || record.Epoch != 19723 || record.SlashTx != "0xabc" {
+		t.Errorf("record = %+v, created %v", record, created)
+	}
+	if record.DisputeTx != "0xd15" || record.CloseTx != "0xc105e" || record.SlashStatus != SlashConfirmed {
+		t.Errorf("dispute transactions = %q, %q", record.DisputeTx, record.CloseTx)
+	}
+	for key, n := range chain.disputes {
+		if n != 0 {
+			t.Errorf("%d disputes left open against %x after slashing", n, key)
+		}
+	}
+	hash, _ := Hash(&failed.Evidence)
+	if amount := chain.slashed[hash]; amount == nil || amount.Int64() != 600 {
+		t.Errorf("slashed %v, want 600", amount)
+	}
+
+	// The same evidence again is neither recorded nor slashed twice
+	chain.slashed = nil
+	if _, created, err := ledger.Record(context.Background(), failed); err != nil || created {
+		t.Errorf("second Record = %v, created %v", err, created)
+	}
+	if len(chain.slashed) != 0 {
+		t.Error("slashed twice")
+	}
+
+	price := ledger.Price(func(r *storage.Receipt) *big.Int { return big.NewInt(10) })
+	forfeited := &storage.Receipt{ProviderPK: record.ProviderPK, Epoch: 19723}
+	if got := price(forfeited); got.Sign() != 0 {
+		t.Errorf("forfeited receipt earns %v", got)
+	}
+	if got := price(&storage.Receipt{ProviderPK: record.ProviderPK, Epoch: 19724}); got.Int64() != 10 {
+		t.Errorf("receipt in a later epoch earns %v", got)
+	}
+
+	// Records survive a restart
+	reloaded, err := New(store, trusted, open)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reloaded.List(record.ProviderPK); len(got) != 1 || got[0].SlashTx != "0xabc" {
+		t.Errorf("reloaded records = %+v", got)
+	}
+	if !reloaded.Forfeits(19723, record.ProviderPK) {
+		t.Error("forfeit lost on reload")
+	}
+}
+
+func TestLedgerSealedEpoch(t *testing.T) {
+	v := loadVectors(t)
+	trusted := verify.NewAllowlist([]string{v.Gateway.PublicKey})
+	ledger, err := New(storage.NewStore(), trusted, &epochs{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain := &backend{err: errors.New("not staked")}
+	ledger.SetBackend(chain, big.NewInt(600))
+
+	record, created, err := ledger.Record(context.Background(), &v.Cases[0].Signed)
+	if err != nil || !created {
+		t.Fatalf("Record = %v, created %v", err, created)
+	}
+	// A stake that could not be slashed stays held by the dispute
+	if len(chain.disputes) != 1 || record.DisputeTx == "" || record.CloseTx != "" {
+		t.Errorf("disputes = %v, record %+v", chain.disputes, record)
+	}
+	if record.Forfeit || ledger.Forfeits(19723, record.ProviderPK) {
+		t.Error("evidence after sealing forfeited the epoch")
+	}
+	if record.SlashTx != "" || record.SlashStatus != SlashFailed || record.SlashError != "not staked" {
+		t.Errorf("slash outcome = %q, %q, %q", record.SlashTx, record.SlashStatus, record.SlashError)
+	}
+
+	if _, _, err := ledger.Record(context.Background(), &v.Cases[1].Signed); !errors.Is(err, ErrInvalidEvidence) {
+		t.Errorf("passing completion: %v", err)
+	}
+}
+
+func TestLedgerWaitsForSlash(t *testing.T) {
+	v := loadVectors(t)
+	trusted := verify.NewAllowlist([]string{v.Gateway.PublicKey})
+	store := storage.NewStore()
+	ledger, err := New(store, trusted, &epochs{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain := &backend{status: "pending"}
+	ledger.SetBackend(chain, big.NewInt(600))
+
+	// The dispute holds the stake until the slash is mined
+	record, _, err := ledger.Record(context.Background(), &v.Cases[0].Signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.SlashTx != "0xabc" || record.SlashStatus != SlashPending || record.CloseTx != "" || len(chain.disputes) != 1 {
+		t.Fatalf("unmined slash: record %+v, disputes %v", record, chain.disputes)
+	}
+	if ids := ledger.Pending(); len(ids) != 1 || ids[0] != record.ReceiptID {
+		t.Errorf("pending = %v", ids)
+	}
+
+	// A pending slash is picked up again after a restart, and closes the
+	// dispute once mined
+	ledger, err = New(store, trusted, &epochs{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ledger.SetBackend(chain, big.NewInt(600))
+	chain.status = ""
+	record, err = ledger.Refresh(context.Background(), record.ReceiptID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.SlashStatus != SlashConfirmed || record.CloseTx != "0xc105e" {
+		t.Errorf("mined slash: %+v", record)
+	}
+	for key, n := range chain.disputes {
+		if n != 0 {
+			t.Errorf("%d disputes left open against %x after slashing", n, key)
+		}
+	}
+	stored, _ := store.LoadEvidence()
+	if !strings.Contains(string(stored[record.ReceiptID]), `"slash_status":"confirmed"`) {
+		t.Errorf("outcome not saved: %s", stored[record.ReceiptID])
+	}
+
+	// A reverted slash is recorded and leaves the dispute open
+	ledger, err = New(storage.NewStore(), trusted, &epochs{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain = &backend{status: "failed"}
+	ledger.SetBackend(chain, big.NewInt(600))
+	record, _, err = ledger.Record(context.Background(), &v.Cases[0].Signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.SlashStatus != SlashFailed || record.SlashError != "slash transaction reverted" || record.CloseTx != "" {
+		t.Errorf("reverted slash: %+v", record)
+	}
+	if len(chain.disputes) != 1 || len(ledger.Pending()) != 0 {
+		t.Errorf("disputes = %v, pending = %v", chain.disputes, ledger.Pending())
+	}
+}
+
+func TestLedgerConcurrentRecord(t *testing.T) {
+	v := loadVectors(t)
+	trusted := verify.NewAllowlist([]string{v.Gateway.PublicKey})
+	ledger, err := New(storage.NewStore(), trusted, &epochs{open: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ledger.SetBackend(&backend{}, big.NewInt(600))
+
+	const n = 8
+	var wg sync.WaitGroup
+	records := make([]*Record, n)
+	created := make([]bool, n)
+	errs := make([]error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			records[i], created[i], errs[i] = ledger.Record(context.Background(), &v.Cases[0].Signed)
+			ledger.List("")
+		}(i)
+	}
+	wg.Wait()
+
+	count := 0
+	for i := 0; i < n; i++ {
+		if errs[i] != nil {
+			t.Fatalf("Record = %v", errs[i])
+		}
+		if created[i] {
+			count++
+		}
+		if records[i] == nil || records[i].ReceiptID != records[0].ReceiptID {
+			t.Errorf("record %d = %+v", i, records[i])
+		}
+	}
+	if count != 1 {
+		t.Errorf("evidence recorded %d times, want once", count)
+	}
+}
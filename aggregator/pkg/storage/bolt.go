@@ -0,0 +1,698 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/quiver/aggregator/pkg/merkle"
+	bolt "go.etcd.io/bbolt"
+)
+
+// boltSchemaVersion is bumped whenever the bucket layout changes. Version 2
+// stores RFC 6962 proofs; opening a version 1 database drops its proofs,
+// which no root sealed since can verify.
+const boltSchemaVersion = 2
+
+// Buckets. receipts and proofs are keyed by receipt ID. The index buckets
+// hold empty values under keys that sort in Scan order:
+//
+//	epochs:    epoch(8) | receipt ID
+//	providers: provider key | 0x00 | epoch(8) | receipt ID
+//	models:    model | 0x00 | epoch(8) | receipt ID
+//
+// quarantine is keyed by an 8-byte insertion sequence, epoch_records and
+// payout_records by epoch(8), claims by claim ID and evidence by receipt ID.
+var (
+	bucketMeta       = []byte("meta")
+	bucketReceipts   = []byte("receipts")
+	bucketEpochs     = []byte("epochs")
+	bucketProviders  = []byte("providers")
+	bucketModels     = []byte("models")
+	bucketProofs     = []byte("proofs")
+	bucketQuarantine = []byte("quarantine")
+	bucketEpochInfo  = []byte("epoch_records")
+	bucketPayouts    = []byte("payout_records")
+	bucketClaims     = []byte("claims")
+	bucketEvidence   = []byte("evidence")
+	bucketFindings   = []byte("findings")
+
+	allBuckets = [][]byte{
+		bucketMeta, bucketReceipts, bucketEpochs, bucketProviders,
+		bucketModels, bucketProofs, bucketQuarantine, bucketEpochInfo,
+		bucketPayouts, bucketClaims, bucketEvidence, bucketFindings,
+	}
+
+	keySchemaVersion = []byte("schema_version")
+)
+
+// BoltStore is a Store backed by a bbolt database file.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// OpenBoltStore opens or creates the database at path.
+func OpenBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		if err := createBuckets(tx); err != nil {
+			return err
+		}
+		meta := tx.Bucket(bucketMeta)
+		stored := meta.Get(keySchemaVersion)
+		if stored == nil {
+			return meta.Put(keySchemaVersion, encodeUint64(boltSchemaVersion))
+		}
+		switch v := binary.BigEndian.Uint64(stored); v {
+		case boltSchemaVersion:
+			return nil
+		case 1:
+			if err := tx.DeleteBucket(bucketProofs); err != nil {
+				return err
+			}
+			if _, err := tx.CreateBucket(bucketProofs); err != nil {
+				return err
+			}
+			return meta.Put(keySchemaVersion, encodeUint64(boltSchemaVersion))
+		default:
+			return fmt.Errorf("unsupported storage schema version %d", v)
+		}
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+func createBuckets(tx *bolt.Tx) error {
+	for _, name := range allBuckets {
+		if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *BoltStore) Store(receipt *SignedReceipt) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return putReceipt(tx, receipt)
+	})
+}
+
+func putReceipt(tx *bolt.Tx, receipt *SignedReceipt) error {
+	id := []byte(receipt.Receipt.ReceiptID)
+	receipts := tx.Bucket(bucketReceipts)
+	if old := receipts.Get(id); old != nil {
+		var prev SignedReceipt
+		if err := json.Unmarshal(old, &prev); err != nil {
+			return fmt.Errorf("receipt %s: %w", id, err)
+		}
+		return checkTaken(&prev.Receipt, &receipt.Receipt)
+	}
+
+	data, err := json.Marshal(receipt)
+	if err != nil {
+		return err
+	}
+	if err := receipts.Put(id, data); err != nil {
+		return err
+	}
+	for _, entry := range indexEntries(&receipt.Receipt) {
+		if err := tx.Bucket(entry.bucket).Put(entry.key, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *BoltStore) Delete(ids []string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		receipts := tx.Bucket(bucketReceipts)
+		for _, id := range ids {
+			data := receipts.Get([]byte(id))
+			if data == nil {
+				continue
+			}
+			var receipt SignedReceipt
+			if err := json.Unmarshal(data, &receipt); err != nil {
+				return fmt.Errorf("receipt %s: %w", id, err)
+			}
+			for _, entry := range indexEntries(&receipt.Receipt) {
+				if err := tx.Bucket(entry.bucket).Delete(entry.key); err != nil {
+					return err
+				}
+			}
+			if err := receipts.Delete([]byte(id)); err != nil {
+				return err
+			}
+			if err := tx.Bucket(bucketProofs).Delete([]byte(id)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+type indexEntry struct {
+	bucket []byte
+	key    []byte
+}
+
+func indexEntries(r *Receipt) []indexEntry {
+	pos := positionOf(r).key()
+	return []indexEntry{
+		{bucketEpochs, pos},
+		{bucketProviders, append(indexPrefix(r.ProviderPK), pos...)},
+		{bucketModels, append(indexPrefix(r.Model), pos...)},
+	}
+}
+
+func indexPrefix(value string) []byte {
+	return append([]byte(value), 0)
+}
+
+func (s *BoltStore) GetByID(id string) (*SignedReceipt, error) {
+	var receipt *SignedReceipt
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		receipt, err = getReceipt(tx, []byte(id))
+		return err
+	})
+	return receipt, err
+}
+
+func getReceipt(tx *bolt.Tx, id []byte) (*SignedReceipt, error) {
+	data := tx.Bucket(bucketReceipts).Get(id)
+	if data == nil {
+		return nil, nil
+	}
+	var receipt SignedReceipt
+	if err := json.Unmarshal(data, &receipt); err != nil {
+		return nil, fmt.Errorf("receipt %s: %w", id, err)
+	}
+	return &receipt, nil
+}
+
+func (s *BoltStore) GetByEpoch(epoch uint64) ([]*SignedReceipt, error) {
+	result := []*SignedReceipt{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		prefix := encodeUint64(epoch)
+		c := tx.Bucket(bucketEpochs).Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			receipt, err := getReceipt(tx, k[8:])
+			if err != nil {
+				return err
+			}
+			if receipt != nil {
+				result = append(result, receipt)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *BoltStore) Count(epoch uint64) int {
+	count := 0
+	s.db.View(func(tx *bolt.Tx) error {
+		prefix := encodeUint64(epoch)
+		c := tx.Bucket(bucketEpochs).Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			count++
+		}
+		return nil
+	})
+	return count
+}
+
+// Epochs skips through the epoch index one epoch at a time.
+func (s *BoltStore) Epochs() ([]uint64, error) {
+	epochs := []uint64{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucketEpochs).Cursor()
+		for k, _ := c.First(); k != nil; {
+			epoch := binary.BigEndian.Uint64(k)
+			epochs = append(epochs, epoch)
+			if epoch == ^uint64(0) {
+				break
+			}
+			k, _ = c.Seek(encodeUint64(epoch + 1))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return epochs, nil
+}
+
+// Scan walks the provider index when q names a provider, else the model
+// index when it names a model, else the epoch index.
+func (s *BoltStore) Scan(q Query) (*Page, error) {
+	var prefix []byte
+	var index []byte
+	switch {
+	case q.ProviderPK != "":
+		index, prefix = bucketProviders, indexPrefix(q.ProviderPK)
+	case q.Model != "":
+		index, prefix = bucketModels, indexPrefix(q.Model)
+	default:
+		index, prefix = bucketEpochs, nil
+	}
+
+	start := append(append([]byte{}, prefix...), encodeUint64(q.FromEpoch)...)
+	var after []byte
+	if q.After != "" {
+		pos, err := parseCursor(q.After)
+		if err != nil {
+			return nil, err
+		}
+		after = append(append([]byte{}, prefix...), pos.key()...)
+		if bytes.Compare(after, start) > 0 {
+			start = after
+		}
+	}
+
+	limit := q.limit()
+	page := &Page{Receipts: []*SignedReceipt{}}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(index).Cursor()
+		for k, _ := c.Seek(start); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			if after != nil && bytes.Equal(k, after) {
+				continue
+			}
+			pos := k[len(prefix):]
+			if q.ToEpoch != 0 && binary.BigEndian.Uint64(pos) >= q.ToEpoch {
+				break
+			}
+
+			receipt, err := getReceipt(tx, pos[8:])
+			if err != nil {
+				return err
+			}
+			if receipt == nil || !q.matches(&receipt.Receipt) {
+				continue
+			}
+			if len(page.Receipts) == limit {
+				page.Next = positionOf(&page.Receipts[limit-1].Receipt).cursor()
+				break
+			}
+			page.Receipts = append(page.Receipts, receipt)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func (s *BoltStore) StoreProof(receiptID string, proof *merkle.Proof) error {
+	data, err := json.Marshal(proof)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketProofs).Put([]byte(receiptID), data)
+	})
+}
+
+// StoreProofs writes every proof in one transaction.
+func (s *BoltStore) StoreProofs(proofs map[string]*merkle.Proof) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketProofs)
+		for receiptID, proof := range proofs {
+			data, err := json.Marshal(proof)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put([]byte(receiptID), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *BoltStore) GetProof(receiptID string) (*merkle.Proof, bool) {
+	var proof *merkle.Proof
+	found := false
+	s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucketProofs).Get([]byte(receiptID))
+		if data == nil {
+			return nil
+		}
+		found = json.Unmarshal(data, &proof) == nil && proof != nil
+		return nil
+	})
+	return proof, found
+}
+
+func (s *BoltStore) Quarantine(receipt *SignedReceipt, reason string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return putQuarantined(tx, &QuarantinedReceipt{
+			Receipt:       receipt,
+			Reason:        reason,
+			QuarantinedAt: time.Now().UTC(),
+		})
+	})
+}
+
+func putQuarantined(tx *bolt.Tx, q *QuarantinedReceipt) error {
+	data, err := json.Marshal(q)
+	if err != nil {
+		return err
+	}
+	bucket := tx.Bucket(bucketQuarantine)
+	seq, err := bucket.NextSequence()
+	if err != nil {
+		return err
+	}
+	return bucket.Put(encodeUint64(seq), data)
+}
+
+func (s *BoltStore) GetQuarantined() []*QuarantinedReceipt {
+	result := []*QuarantinedReceipt{}
+	s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketQuarantine).ForEach(func(_, v []byte) error {
+			var q QuarantinedReceipt
+			if err := json.Unmarshal(v, &q); err == nil {
+				result = append(result, &q)
+			}
+			return nil
+		})
+	})
+	return result
+}
+
+func (s *BoltStore) SaveEpoch(epoch uint64, record []byte) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketEpochInfo).Put(encodeUint64(epoch), record)
+	})
+}
+
+func (s *BoltStore) LoadEpochs() (map[uint64][]byte, error) {
+	result := make(map[uint64][]byte)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketEpochInfo).ForEach(func(k, v []byte) error {
+			result[binary.BigEndian.Uint64(k)] = append([]byte(nil), v...)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *BoltStore) SavePayouts(epoch uint64, record []byte) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketPayouts).Put(encodeUint64(epoch), record)
+	})
+}
+
+func (s *BoltStore) GetPayouts(epoch uint64) ([]byte, error) {
+	var record []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		record = append([]byte(nil), tx.Bucket(bucketPayouts).Get(encodeUint64(epoch))...)
+		return nil
+	})
+	return record, err
+}
+
+func (s *BoltStore) CreateClaim(id string, record []byte) (bool, error) {
+	created := false
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketClaims)
+		if bucket.Get([]byte(id)) != nil {
+			return nil
+		}
+		created = true
+		return bucket.Put([]byte(id), record)
+	})
+	return created && err == nil, err
+}
+
+func (s *BoltStore) SaveClaim(id string, record []byte) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketClaims).Put([]byte(id), record)
+	})
+}
+
+func (s *BoltStore) GetClaim(id string) ([]byte, error) {
+	var record []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		record = append([]byte(nil), tx.Bucket(bucketClaims).Get([]byte(id))...)
+		return nil
+	})
+	return record, err
+}
+
+func (s *BoltStore) LoadClaims() (map[string][]byte, error) {
+	result := make(map[string][]byte)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketClaims).ForEach(func(k, v []byte) error {
+			result[string(k)] = append([]byte(nil), v...)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *BoltStore) CreateEvidence(id string, record []byte) (bool, error) {
+	created := false
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketEvidence)
+		if bucket.Get([]byte(id)) != nil {
+			return nil
+		}
+		created = true
+		return bucket.Put([]byte(id), record)
+	})
+	return created && err == nil, err
+}
+
+func (s *BoltStore) SaveEvidence(id string, record []byte) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketEvidence).Put([]byte(id), record)
+	})
+}
+
+func (s *BoltStore) LoadEvidence() (map[string][]byte, error) {
+	result := make(map[string][]byte)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketEvidence).ForEach(func(k, v []byte) error {
+			result[string(k)] = append([]byte(nil), v...)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *BoltStore) SaveFinding(id int64, record []byte) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketFindings).Put(encodeUint64(uint64(id)), record)
+	})
+}
+
+func (s *BoltStore) LoadFindings() (map[int64][]byte, error) {
+	result := make(map[int64][]byte)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketFindings).ForEach(func(k, v []byte) error {
+			result[int64(binary.BigEndian.Uint64(k))] = append([]byte(nil), v...)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *BoltStore) ExportState() ([]byte, error) {
+	receipts := make(map[string]json.RawMessage)
+	proofs := make(map[string]json.RawMessage)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		err := tx.Bucket(bucketReceipts).ForEach(func(k, v []byte) error {
+			receipts[string(k)] = append(json.RawMessage{}, v...)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(bucketProofs).ForEach(func(k, v []byte) error {
+			proofs[string(k)] = append(json.RawMessage{}, v...)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	state := map[string]interface{}{
+		"receipts": receipts,
+		"proofs":   proofs,
+	}
+
+	return json.MarshalIndent(state, "", "  ")
+}
+
+// Snapshot writes the store as seen by a single read transaction, so it is
+// consistent even while receipts are being committed.
+func (s *BoltStore) Snapshot(w io.Writer) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		sw, err := newSnapshotWriter(w)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Bucket(bucketReceipts).ForEach(func(k, v []byte) error {
+			var receipt SignedReceipt
+			if err := json.Unmarshal(v, &receipt); err != nil {
+				return fmt.Errorf("receipt %s: %w", k, err)
+			}
+			return sw.write(snapshotRecord{Receipt: &receipt})
+		})
+		if err != nil {
+			return err
+		}
+
+		err = tx.Bucket(bucketProofs).ForEach(func(k, v []byte) error {
+			p := &snapshotProof{ReceiptID: string(k)}
+			if err := json.Unmarshal(v, &p.Proof); err != nil {
+				return fmt.Errorf("proof %s: %w", k, err)
+			}
+			return sw.write(snapshotRecord{Proof: p})
+		})
+		if err != nil {
+			return err
+		}
+
+		err = tx.Bucket(bucketQuarantine).ForEach(func(_, v []byte) error {
+			var q QuarantinedReceipt
+			if err := json.Unmarshal(v, &q); err != nil {
+				return err
+			}
+			return sw.write(snapshotRecord{Quarantine: &q})
+		})
+		if err != nil {
+			return err
+		}
+
+		err = tx.Bucket(bucketEpochInfo).ForEach(func(k, v []byte) error {
+			return sw.write(snapshotRecord{Epoch: &snapshotEpoch{
+				Epoch:  binary.BigEndian.Uint64(k),
+				Record: json.RawMessage(v),
+			}})
+		})
+		if err != nil {
+			return err
+		}
+
+		err = tx.Bucket(bucketPayouts).ForEach(func(k, v []byte) error {
+			return sw.write(snapshotRecord{Payouts: &snapshotEpoch{
+				Epoch:  binary.BigEndian.Uint64(k),
+				Record: json.RawMessage(v),
+			}})
+		})
+		if err != nil {
+			return err
+		}
+
+		err = tx.Bucket(bucketClaims).ForEach(func(k, v []byte) error {
+			return sw.write(snapshotRecord{Claim: &snapshotClaim{ID: string(k), Record: json.RawMessage(v)}})
+		})
+		if err != nil {
+			return err
+		}
+
+		err = tx.Bucket(bucketEvidence).ForEach(func(k, v []byte) error {
+			return sw.write(snapshotRecord{Evidence: &snapshotClaim{ID: string(k), Record: json.RawMessage(v)}})
+		})
+		if err != nil {
+			return err
+		}
+
+		err = tx.Bucket(bucketFindings).ForEach(func(k, v []byte) error {
+			return sw.write(snapshotRecord{Finding: &snapshotFinding{
+				ID:     int64(binary.BigEndian.Uint64(k)),
+				Record: json.RawMessage(v),
+			}})
+		})
+		if err != nil {
+			return err
+		}
+		return sw.flush()
+	})
+}
+
+// Restore replaces the store's contents in one transaction; if the snapshot
+// is invalid the store is left unchanged.
+func (s *BoltStore) Restore(r io.Reader) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		for _, name := range allBuckets {
+			if bytes.Equal(name, bucketMeta) {
+				continue
+			}
+			if err := tx.DeleteBucket(name); err != nil && err != bolt.ErrBucketNotFound {
+				return err
+			}
+		}
+		if err := createBuckets(tx); err != nil {
+			return err
+		}
+
+		return readSnapshot(r, func(rec snapshotRecord) error {
+			switch {
+			case rec.Receipt != nil:
+				return putReceipt(tx, rec.Receipt)
+			case rec.Proof != nil:
+				data, err := json.Marshal(rec.Proof.Proof)
+				if err != nil {
+					return err
+				}
+				return tx.Bucket(bucketProofs).Put([]byte(rec.Proof.ReceiptID), data)
+			case rec.Quarantine != nil:
+				return putQuarantined(tx, rec.Quarantine)
+			case rec.Payouts != nil:
+				return tx.Bucket(bucketPayouts).Put(encodeUint64(rec.Payouts.Epoch), rec.Payouts.Record)
+			case rec.Claim != nil:
+				return tx.Bucket(bucketClaims).Put([]byte(rec.Claim.ID), rec.Claim.Record)
+			case rec.Evidence != nil:
+				return tx.Bucket(bucketEvidence).Put([]byte(rec.Evidence.ID), rec.Evidence.Record)
+			case rec.Finding != nil:
+				return tx.Bucket(bucketFindings).Put(encodeUint64(uint64(rec.Finding.ID)), rec.Finding.Record)
+			default:
+				return tx.Bucket(bucketEpochInfo).Put(encodeUint64(rec.Epoch.Epoch), rec.Epoch.Record)
+			}
+		})
+	})
+}
+
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+func encodeUint64(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
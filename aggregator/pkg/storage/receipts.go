@@ -2,7 +2,12 @@ package storage
 
 import (
 	"encoding/json"
+	"io"
+	"sort"
 	"sync"
+	"time"
+
+	"github.com/quiver/aggregator/pkg/merkle"
 )
 
 type Receipt struct {
@@ -21,42 +26,145 @@ type Receipt struct {
 	PrevHash   string                 `json:"prev_hash"`
 	Canary     map[string]interface{} `json:"canary"`
 	Rate       map[string]interface{} `json:"rate"`
+	Stream     *StreamInfo            `json:"stream,omitempty"`
+	KeyEvent   *SignedSuccession      `json:"key_event,omitempty"`
 	ReceiptID  string                 `json:"receipt_id"`
 }
 
+// StreamInfo mirrors provider/pkg/receipt.StreamInfo. It is set on receipts
+// for streamed generations; only the final receipt of a stream is settled.
+type StreamInfo struct {
+	Index          int    `json:"index"`
+	Chunks         int    `json:"chunks"`
+	PrevCheckpoint string `json:"prev_checkpoint"`
+	Final          bool   `json:"final"`
+}
+
+// SignedReceipt mirrors provider/pkg/receipt.SignedReceipt. Version 1
+// receipts only carry Signature; version 2 adds the envelope fields.
 type SignedReceipt struct {
-	Receipt   Receipt `json:"receipt"`
-	Signature string  `json:"signature"`
+	Receipt      Receipt            `json:"receipt"`
+	Signature    string             `json:"signature"`
+	Algorithm    string             `json:"alg,omitempty"`
+	KeyID        string             `json:"kid,omitempty"`
+	CoSignatures []CoSignature      `json:"cosignatures,omitempty"`
+	Succession   []SignedSuccession `json:"succession,omitempty"`
+}
+
+// CoSignature is an additional signature over a v2 receipt.
+type CoSignature struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	PublicKey string `json:"public_key"`
+	Signature string `json:"signature"`
+}
+
+// SuccessionCertificate mirrors provider/pkg/receipt.SuccessionCertificate:
+// the provider identity ProviderPK hands signing from one key to the next.
+type SuccessionCertificate struct {
+	ProviderPK    string `json:"provider_pk"`
+	PrevKeyID     string `json:"prev_kid"`
+	NextKey       string `json:"next_public_key"`
+	NextKeyID     string `json:"next_kid"`
+	EffectiveSeq  int64  `json:"effective_seq"`
+	RevokeFromSeq int64  `json:"revoke_from_seq"`
+	Reason        string `json:"reason"`
+	IssuedAt      string `json:"issued_at"`
+}
+
+// SignedSuccession is a succession certificate signed by the previous key.
+type SignedSuccession struct {
+	Certificate SuccessionCertificate `json:"certificate"`
+	Algorithm   string                `json:"alg"`
+	KeyID       string                `json:"kid"`
+	Signature   string                `json:"signature"`
+}
+
+// QuarantinedReceipt is a receipt held back from settlement because it
+// failed verification on ingest.
+type QuarantinedReceipt struct {
+	Receipt       *SignedReceipt `json:"receipt"`
+	Reason        string         `json:"reason"`
+	QuarantinedAt time.Time      `json:"quarantined_at"`
 }
 
-type Store struct {
+// MemoryStore is a Store that keeps everything in memory.
+type MemoryStore struct {
 	receipts        map[string]*SignedReceipt
 	receiptsByEpoch map[uint64][]*SignedReceipt
-	proofs          map[string][]string
+	proofs          map[string]*merkle.Proof
+	quarantine      []*QuarantinedReceipt
+	epochs          map[uint64][]byte
+	payouts         map[uint64][]byte
+	claims          map[string][]byte
+	evidence        map[string][]byte
+	findings        map[int64][]byte
 	mu              sync.RWMutex
 }
 
-func NewStore() *Store {
-	return &Store{
+// NewStore returns an empty in-memory store.
+func NewStore() *MemoryStore {
+	return &MemoryStore{
 		receipts:        make(map[string]*SignedReceipt),
 		receiptsByEpoch: make(map[uint64][]*SignedReceipt),
-		proofs:          make(map[string][]string),
+		proofs:          make(map[string]*merkle.Proof),
+		epochs:          make(map[uint64][]byte),
+		payouts:         make(map[uint64][]byte),
+		claims:          make(map[string][]byte),
+		evidence:        make(map[string][]byte),
+		findings:        make(map[int64][]byte),
 	}
 }
 
-func (s *Store) Store(receipt *SignedReceipt) error {
+func (s *MemoryStore) Store(receipt *SignedReceipt) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.receipts[receipt.Receipt.ReceiptID] = receipt
+	return s.put(receipt)
+}
+
+func (s *MemoryStore) put(receipt *SignedReceipt) error {
+	id := receipt.Receipt.ReceiptID
+	if old, exists := s.receipts[id]; exists {
+		return checkTaken(&old.Receipt, &receipt.Receipt)
+	}
+
+	s.receipts[id] = receipt
 
 	epoch := uint64(receipt.Receipt.Epoch)
 	s.receiptsByEpoch[epoch] = append(s.receiptsByEpoch[epoch], receipt)
+	return nil
+}
 
+func (s *MemoryStore) Delete(ids []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range ids {
+		receipt, exists := s.receipts[id]
+		if !exists {
+			continue
+		}
+		delete(s.receipts, id)
+		delete(s.proofs, id)
+
+		epoch := uint64(receipt.Receipt.Epoch)
+		kept := s.receiptsByEpoch[epoch][:0]
+		for _, r := range s.receiptsByEpoch[epoch] {
+			if r != receipt {
+				kept = append(kept, r)
+			}
+		}
+		if len(kept) == 0 {
+			delete(s.receiptsByEpoch, epoch)
+		} else {
+			s.receiptsByEpoch[epoch] = kept
+		}
+	}
 	return nil
 }
 
-func (s *Store) GetByID(id string) (*SignedReceipt, error) {
+func (s *MemoryStore) GetByID(id string) (*SignedReceipt, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -68,32 +176,97 @@ func (s *Store) GetByID(id string) (*SignedReceipt, error) {
 	return receipt, nil
 }
 
-func (s *Store) GetByEpoch(epoch uint64) ([]*SignedReceipt, error) {
+func (s *MemoryStore) GetByEpoch(epoch uint64) ([]*SignedReceipt, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	receipts := s.receiptsByEpoch[epoch]
 	result := make([]*SignedReceipt, len(receipts))
 	copy(result, receipts)
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Receipt.ReceiptID < result[j].Receipt.ReceiptID
+	})
 
 	return result, nil
 }
 
-func (s *Store) Count(epoch uint64) int {
+func (s *MemoryStore) Count(epoch uint64) int {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	return len(s.receiptsByEpoch[epoch])
 }
 
-func (s *Store) StoreProof(receiptID string, proof []string) {
+func (s *MemoryStore) Epochs() ([]uint64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	epochs := []uint64{}
+	for epoch, receipts := range s.receiptsByEpoch {
+		if len(receipts) > 0 {
+			epochs = append(epochs, epoch)
+		}
+	}
+	sort.Slice(epochs, func(i, j int) bool { return epochs[i] < epochs[j] })
+	return epochs, nil
+}
+
+// Scan filters every receipt in memory; it does not keep provider or model
+// indexes.
+func (s *MemoryStore) Scan(q Query) (*Page, error) {
+	var after *scanPosition
+	if q.After != "" {
+		pos, err := parseCursor(q.After)
+		if err != nil {
+			return nil, err
+		}
+		after = &pos
+	}
+
+	s.mu.RLock()
+	matched := []*SignedReceipt{}
+	for _, r := range s.receipts {
+		if !q.matches(&r.Receipt) {
+			continue
+		}
+		if after != nil && !after.less(positionOf(&r.Receipt)) {
+			continue
+		}
+		matched = append(matched, r)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(matched, func(i, j int) bool {
+		return positionOf(&matched[i].Receipt).less(positionOf(&matched[j].Receipt))
+	})
+
+	page := &Page{Receipts: matched}
+	if limit := q.limit(); len(matched) > limit {
+		page.Receipts = matched[:limit]
+		page.Next = positionOf(&matched[limit-1].Receipt).cursor()
+	}
+	return page, nil
+}
+
+func (s *MemoryStore) StoreProof(receiptID string, proof *merkle.Proof) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	s.proofs[receiptID] = proof
+	return nil
+}
+
+func (s *MemoryStore) StoreProofs(proofs map[string]*merkle.Proof) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for receiptID, proof := range proofs {
+		s.proofs[receiptID] = proof
+	}
+	return nil
 }
 
-func (s *Store) GetProof(receiptID string) ([]string, bool) {
+func (s *MemoryStore) GetProof(receiptID string) (*merkle.Proof, bool) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -101,7 +274,148 @@ func (s *Store) GetProof(receiptID string) ([]string, bool) {
 	return proof, exists
 }
 
-func (s *Store) ExportState() ([]byte, error) {
+func (s *MemoryStore) Quarantine(receipt *SignedReceipt, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.quarantine = append(s.quarantine, &QuarantinedReceipt{
+		Receipt:       receipt,
+		Reason:        reason,
+		QuarantinedAt: time.Now().UTC(),
+	})
+	return nil
+}
+
+func (s *MemoryStore) GetQuarantined() []*QuarantinedReceipt {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*QuarantinedReceipt, len(s.quarantine))
+	copy(result, s.quarantine)
+	return result
+}
+
+func (s *MemoryStore) SaveEpoch(epoch uint64, record []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.epochs[epoch] = append([]byte(nil), record...)
+	return nil
+}
+
+func (s *MemoryStore) LoadEpochs() (map[uint64][]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make(map[uint64][]byte, len(s.epochs))
+	for epoch, record := range s.epochs {
+		result[epoch] = append([]byte(nil), record...)
+	}
+	return result, nil
+}
+
+func (s *MemoryStore) SavePayouts(epoch uint64, record []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.payouts[epoch] = append([]byte(nil), record...)
+	return nil
+}
+
+func (s *MemoryStore) GetPayouts(epoch uint64) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]byte(nil), s.payouts[epoch]...), nil
+}
+
+func (s *MemoryStore) CreateClaim(id string, record []byte) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.claims[id]; exists {
+		return false, nil
+	}
+	s.claims[id] = append([]byte(nil), record...)
+	return true, nil
+}
+
+func (s *MemoryStore) SaveClaim(id string, record []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.claims[id] = append([]byte(nil), record...)
+	return nil
+}
+
+func (s *MemoryStore) GetClaim(id string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]byte(nil), s.claims[id]...), nil
+}
+
+func (s *MemoryStore) LoadClaims() (map[string][]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make(map[string][]byte, len(s.claims))
+	for id, record := range s.claims {
+		result[id] = append([]byte(nil), record...)
+	}
+	return result, nil
+}
+
+func (s *MemoryStore) CreateEvidence(id string, record []byte) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.evidence[id]; exists {
+		return false, nil
+	}
+	s.evidence[id] = append([]byte(nil), record...)
+	return true, nil
+}
+
+func (s *MemoryStore) SaveEvidence(id string, record []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.evidence[id] = append([]byte(nil), record...)
+	return nil
+}
+
+func (s *MemoryStore) LoadEvidence() (map[string][]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make(map[string][]byte, len(s.evidence))
+	for id, record := range s.evidence {
+		result[id] = append([]byte(nil), record...)
+	}
+	return result, nil
+}
+
+func (s *MemoryStore) SaveFinding(id int64, record []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.findings[id] = append([]byte(nil), record...)
+	return nil
+}
+
+func (s *MemoryStore) LoadFindings() (map[int64][]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make(map[int64][]byte, len(s.findings))
+	for id, record := range s.findings {
+		result[id] = append([]byte(nil), record...)
+	}
+	return result, nil
+}
+
+func (s *MemoryStore) ExportState() ([]byte, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -112,3 +426,146 @@ func (s *Store) ExportState() ([]byte, error) {
 
 	return json.MarshalIndent(state, "", "  ")
 }
+
+func (s *MemoryStore) Snapshot(w io.Writer) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sw, err := newSnapshotWriter(w)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]string, 0, len(s.receipts))
+	for id := range s.receipts {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if err := sw.write(snapshotRecord{Receipt: s.receipts[id]}); err != nil {
+			return err
+		}
+	}
+
+	ids = ids[:0]
+	for id := range s.proofs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if err := sw.write(snapshotRecord{Proof: &snapshotProof{ReceiptID: id, Proof: s.proofs[id]}}); err != nil {
+			return err
+		}
+	}
+
+	for _, q := range s.quarantine {
+		if err := sw.write(snapshotRecord{Quarantine: q}); err != nil {
+			return err
+		}
+	}
+
+	epochs := make([]uint64, 0, len(s.epochs))
+	for epoch := range s.epochs {
+		epochs = append(epochs, epoch)
+	}
+	sort.Slice(epochs, func(i, j int) bool { return epochs[i] < epochs[j] })
+	for _, epoch := range epochs {
+		if err := sw.write(snapshotRecord{Epoch: &snapshotEpoch{Epoch: epoch, Record: s.epochs[epoch]}}); err != nil {
+			return err
+		}
+	}
+
+	epochs = epochs[:0]
+	for epoch := range s.payouts {
+		epochs = append(epochs, epoch)
+	}
+	sort.Slice(epochs, func(i, j int) bool { return epochs[i] < epochs[j] })
+	for _, epoch := range epochs {
+		if err := sw.write(snapshotRecord{Payouts: &snapshotEpoch{Epoch: epoch, Record: s.payouts[epoch]}}); err != nil {
+			return err
+		}
+	}
+
+	ids = ids[:0]
+	for id := range s.claims {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if err := sw.write(snapshotRecord{Claim: &snapshotClaim{ID: id, Record: s.claims[id]}}); err != nil {
+			return err
+		}
+	}
+
+	ids = ids[:0]
+	for id := range s.evidence {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if err := sw.write(snapshotRecord{Evidence: &snapshotClaim{ID: id, Record: s.evidence[id]}}); err != nil {
+			return err
+		}
+	}
+
+	findings := make([]int64, 0, len(s.findings))
+	for id := range s.findings {
+		findings = append(findings, id)
+	}
+	sort.Slice(findings, func(i, j int) bool { return findings[i] < findings[j] })
+	for _, id := range findings {
+		if err := sw.write(snapshotRecord{Finding: &snapshotFinding{ID: id, Record: s.findings[id]}}); err != nil {
+			return err
+		}
+	}
+	return sw.flush()
+}
+
+// Restore loads the snapshot into a fresh store and swaps it in only once the
+// whole snapshot has been read.
+func (s *MemoryStore) Restore(r io.Reader) error {
+	fresh := NewStore()
+	err := readSnapshot(r, func(rec snapshotRecord) error {
+		switch {
+		case rec.Receipt != nil:
+			return fresh.put(rec.Receipt)
+		case rec.Proof != nil:
+			fresh.proofs[rec.Proof.ReceiptID] = rec.Proof.Proof
+		case rec.Quarantine != nil:
+			fresh.quarantine = append(fresh.quarantine, rec.Quarantine)
+		case rec.Epoch != nil:
+			fresh.epochs[rec.Epoch.Epoch] = rec.Epoch.Record
+		case rec.Payouts != nil:
+			fresh.payouts[rec.Payouts.Epoch] = rec.Payouts.Record
+		case rec.Claim != nil:
+			fresh.claims[rec.Claim.ID] = rec.Claim.Record
+		case rec.Evidence != nil:
+			fresh.evidence[rec.Evidence.ID] = rec.Evidence.Record
+		case rec.Finding != nil:
+			fresh.findings[rec.Finding.ID] = rec.Finding.Record
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.receipts = fresh.receipts
+	s.receiptsByEpoch = fresh.receiptsByEpoch
+	s.proofs = fresh.proofs
+	s.quarantine = fresh.quarantine
+	s.epochs = fresh.epochs
+	s.payouts = fresh.payouts
+	s.claims = fresh.claims
+	s.evidence = fresh.evidence
+	s.findings = fresh.findings
+	return nil
+}
+
+// Close is a no-op.
+func (s *MemoryStore) Close() error {
+	return nil
+}
@@ -3,6 +3,8 @@ package storage
 import (
 	"encoding/json"
 	"testing"
+
+	"github.com/quiver/aggregator/pkg/merkle"
 )
 
 func TestReceiptStorage(t *testing.T) {
@@ -55,7 +57,7 @@ func TestProofStorage(t *testing.T) {
 	store := NewStore()
 
 	receiptID := "test-receipt-123"
-	proof := []string{"hash1", "hash2", "hash3"}
+	proof := &merkle.Proof{LeafIndex: 2, TreeSize: 5, Hashes: []string{"hash1", "hash2", "hash3"}}
 
 	store.StoreProof(receiptID, proof)
 
@@ -64,12 +66,15 @@ func TestProofStorage(t *testing.T) {
 		t.Fatal("Proof not found")
 	}
 
-	if len(retrievedProof) != len(proof) {
-		t.Errorf("Expected %d proof elements, got %d", len(proof), len(retrievedProof))
+	if retrievedProof.LeafIndex != 2 || retrievedProof.TreeSize != 5 {
+		t.Errorf("Proof position %d/%d", retrievedProof.LeafIndex, retrievedProof.TreeSize)
+	}
+	if len(retrievedProof.Hashes) != len(proof.Hashes) {
+		t.Errorf("Expected %d proof elements, got %d", len(proof.Hashes), len(retrievedProof.Hashes))
 	}
 
-	for i, p := range proof {
-		if retrievedProof[i] != p {
+	for i, p := range proof.Hashes {
+		if retrievedProof.Hashes[i] != p {
 			t.Errorf("Proof element %d mismatch", i)
 		}
 	}
@@ -90,7 +95,7 @@ func TestExportState(t *testing.T) {
 	if err := store.Store(receipt); err != nil {
 		t.Fatal(err)
 	}
-	store.StoreProof("export-test", []string{"proof1"})
+	store.StoreProof("export-test", &merkle.Proof{TreeSize: 1, Hashes: []string{}})
 
 	// Export state
 	state, err := store.ExportState()
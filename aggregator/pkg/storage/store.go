@@ -0,0 +1,363 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/quiver/aggregator/pkg/merkle"
+)
+
+const (
+	// DefaultPageSize is the page size of a Scan that does not set one.
+	DefaultPageSize = 100
+	// MaxPageSize caps the page size of a Scan.
+	MaxPageSize = 1000
+
+	// SnapshotVersion is the version of the format Snapshot writes.
+	// Version 1 snapshots still restore, without their proofs, which were
+	// for the Merkle tree used before RFC 6962 trees.
+	SnapshotVersion = 2
+)
+
+var (
+	// ErrInvalidCursor means a Query.After value was not produced by Scan.
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrReceiptExists means a different receipt is already stored under
+	// the same receipt ID.
+	ErrReceiptExists = errors.New("a different receipt is stored under this ID")
+)
+
+// Store holds committed receipts, their Merkle proofs and quarantined
+// receipts. MemoryStore keeps everything in memory and is meant for tests;
+// BoltStore persists to disk.
+type Store interface {
+	// Store adds a receipt. Storing a receipt that is already stored does
+	// nothing; a different receipt under the same ID fails with
+	// ErrReceiptExists, so a stored receipt can never be replaced.
+	Store(receipt *SignedReceipt) error
+	// Delete removes receipts and their proofs. It only undoes a failed
+	// amendment: receipts in a sealed tree must never be deleted.
+	Delete(ids []string) error
+	// GetByID returns nil and no error if there is no such receipt.
+	GetByID(id string) (*SignedReceipt, error)
+	// GetByEpoch returns the receipts of an epoch in receipt ID order.
+	GetByEpoch(epoch uint64) ([]*SignedReceipt, error)
+	Count(epoch uint64) int
+	// Epochs returns the epochs that have receipts, in ascending order.
+	Epochs() ([]uint64, error)
+	// Scan returns one page of the receipts matching q, ordered by epoch
+	// then receipt ID.
+	Scan(q Query) (*Page, error)
+
+	StoreProof(receiptID string, proof *merkle.Proof) error
+	// StoreProofs stores the proofs of a whole epoch, keyed by receipt ID,
+	// all at once.
+	StoreProofs(proofs map[string]*merkle.Proof) error
+	GetProof(receiptID string) (*merkle.Proof, bool)
+
+	// Quarantine records a receipt that failed verification. Quarantined
+	// receipts are never indexed by ID or epoch.
+	Quarantine(receipt *SignedReceipt, reason string) error
+	// GetQuarantined returns quarantined receipts, oldest first.
+	GetQuarantined() []*QuarantinedReceipt
+
+	// SaveEpoch and LoadEpochs persist epoch.Manager records, which the
+	// store keeps as opaque JSON.
+	SaveEpoch(epoch uint64, record []byte) error
+	LoadEpochs() (map[uint64][]byte, error)
+	// SavePayouts and GetPayouts persist an epoch's payout tree, also as
+	// opaque JSON. GetPayouts returns nil and no error if there is none.
+	SavePayouts(epoch uint64, record []byte) error
+	GetPayouts(epoch uint64) ([]byte, error)
+
+	// Claims are settlement records, opaque JSON keyed by claim ID.
+	// CreateClaim stores a record only if the ID is new and reports
+	// whether it did, so a claim can be made at most once.
+	CreateClaim(id string, record []byte) (bool, error)
+	SaveClaim(id string, record []byte) error
+	// GetClaim returns nil and no error if there is no such claim.
+	GetClaim(id string) ([]byte, error)
+	LoadClaims() (map[string][]byte, error)
+
+	// Evidence records are slashing evidence, opaque JSON keyed by the ID
+	// of the receipt they implicate. CreateEvidence stores a record only
+	// if the ID is new and reports whether it did.
+	CreateEvidence(id string, record []byte) (bool, error)
+	SaveEvidence(id string, record []byte) error
+	LoadEvidence() (map[string][]byte, error)
+
+	// Findings are chain audit findings, opaque JSON keyed by finding ID.
+	SaveFinding(id int64, record []byte) error
+	LoadFindings() (map[int64][]byte, error)
+
+	ExportState() ([]byte, error)
+	// Snapshot writes the full contents of the store to w.
+	Snapshot(w io.Writer) error
+	// Restore replaces the contents of the store with a snapshot.
+	Restore(r io.Reader) error
+	Close() error
+}
+
+var (
+	_ Store = (*MemoryStore)(nil)
+	_ Store = (*BoltStore)(nil)
+)
+
+// checkTaken returns nil if stored is receipt, compared as JSON, and
+// ErrReceiptExists otherwise.
+func checkTaken(stored, receipt *Receipt) error {
+	a, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(receipt)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(a, b) {
+		return fmt.Errorf("%w: %s", ErrReceiptExists, receipt.ReceiptID)
+	}
+	return nil
+}
+
+// Query selects receipts for a Scan. Zero values match everything.
+type Query struct {
+	ProviderPK string
+	Model      string
+	// FromEpoch is inclusive and ToEpoch exclusive; a zero ToEpoch leaves
+	// the range open
+	FromEpoch uint64
+	ToEpoch   uint64
+	// After is the Next cursor of the previous page
+	After string
+	Limit int
+}
+
+// Page is one page of Scan results. Next is empty on the last page.
+type Page struct {
+	Receipts []*SignedReceipt `json:"receipts"`
+	Next     string           `json:"next,omitempty"`
+}
+
+func (q *Query) limit() int {
+	switch {
+	case q.Limit <= 0:
+		return DefaultPageSize
+	case q.Limit > MaxPageSize:
+		return MaxPageSize
+	default:
+		return q.Limit
+	}
+}
+
+// matches reports whether r falls in q, ignoring the cursor.
+func (q *Query) matches(r *Receipt) bool {
+	epoch := uint64(r.Epoch)
+	switch {
+	case q.ProviderPK != "" && r.ProviderPK != q.ProviderPK:
+		return false
+	case q.Model != "" && r.Model != q.Model:
+		return false
+	case epoch < q.FromEpoch:
+		return false
+	case q.ToEpoch != 0 && epoch >= q.ToEpoch:
+		return false
+	}
+	return true
+}
+
+// scanPosition is a point in Scan order.
+type scanPosition struct {
+	epoch uint64
+	id    string
+}
+
+func positionOf(r *Receipt) scanPosition {
+	return scanPosition{epoch: uint64(r.Epoch), id: r.ReceiptID}
+}
+
+func (p scanPosition) less(o scanPosition) bool {
+	if p.epoch != o.epoch {
+		return p.epoch < o.epoch
+	}
+	return p.id < o.id
+}
+
+// key encodes p so that byte order matches Scan order.
+func (p scanPosition) key() []byte {
+	key := make([]byte, 8+len(p.id))
+	binary.BigEndian.PutUint64(key, p.epoch)
+	copy(key[8:], p.id)
+	return key
+}
+
+func (p scanPosition) cursor() string {
+	return base64.RawURLEncoding.EncodeToString(p.key())
+}
+
+func parseCursor(cursor string) (scanPosition, error) {
+	key, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(key) <= 8 {
+		return scanPosition{}, ErrInvalidCursor
+	}
+	return scanPosition{epoch: binary.BigEndian.Uint64(key), id: string(key[8:])}, nil
+}
+
+// snapshotRecord is one line of a snapshot after the header. Exactly one
+// field is set.
+type snapshotRecord struct {
+	Receipt    *SignedReceipt      `json:"receipt,omitempty"`
+	Proof      *snapshotProof      `json:"proof,omitempty"`
+	Quarantine *QuarantinedReceipt `json:"quarantine,omitempty"`
+	Epoch      *snapshotEpoch      `json:"epoch,omitempty"`
+	Payouts    *snapshotEpoch      `json:"payouts,omitempty"`
+	Claim      *snapshotClaim      `json:"claim,omitempty"`
+	Evidence   *snapshotClaim      `json:"evidence,omitempty"`
+	Finding    *snapshotFinding    `json:"finding,omitempty"`
+}
+
+type snapshotProof struct {
+	ReceiptID string        `json:"receipt_id"`
+	Proof     *merkle.Proof `json:"proof"`
+}
+
+type snapshotEpoch struct {
+	Epoch  uint64          `json:"epoch"`
+	Record json.RawMessage `json:"record"`
+}
+
+type snapshotClaim struct {
+	ID     string          `json:"id"`
+	Record json.RawMessage `json:"record"`
+}
+
+type snapshotFinding struct {
+	ID     int64           `json:"id"`
+	Record json.RawMessage `json:"record"`
+}
+
+type snapshotHeader struct {
+	Version int `json:"quiver_snapshot"`
+}
+
+// snapshotWriter writes a snapshot as JSON lines: a header, then one record
+// per line. Snapshots are portable between Store implementations.
+type snapshotWriter struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+func newSnapshotWriter(w io.Writer) (*snapshotWriter, error) {
+	buf := bufio.NewWriter(w)
+	sw := &snapshotWriter{buf: buf, enc: json.NewEncoder(buf)}
+	if err := sw.enc.Encode(snapshotHeader{Version: SnapshotVersion}); err != nil {
+		return nil, err
+	}
+	return sw, nil
+}
+
+func (sw *snapshotWriter) write(rec snapshotRecord) error {
+	return sw.enc.Encode(rec)
+}
+
+func (sw *snapshotWriter) flush() error {
+	return sw.buf.Flush()
+}
+
+// readSnapshot checks the snapshot header and calls fn for each record.
+func readSnapshot(r io.Reader, fn func(snapshotRecord) error) error {
+	dec := json.NewDecoder(bufio.NewReader(r))
+
+	var header snapshotHeader
+	if err := dec.Decode(&header); err != nil {
+		return fmt.Errorf("invalid snapshot header: %w", err)
+	}
+	if header.Version != SnapshotVersion && header.Version != 1 {
+		return fmt.Errorf("unsupported snapshot version %d", header.Version)
+	}
+
+	for line := 2; ; line++ {
+		var raw json.RawMessage
+		err := dec.Decode(&raw)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("snapshot record %d: %w", line, err)
+		}
+		if header.Version == 1 && isProofRecord(raw) {
+			continue
+		}
+
+		var rec snapshotRecord
+		if err := json.Unmarshal(raw, &rec); err != nil {
+			return fmt.Errorf("snapshot record %d: %w", line, err)
+		}
+		if err := validRecord(&rec); err != nil {
+			return fmt.Errorf("snapshot record %d: %w", line, err)
+		}
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+}
+
+func validRecord(rec *snapshotRecord) error {
+	set := 0
+	if rec.Receipt != nil {
+		set++
+		if rec.Receipt.Receipt.ReceiptID == "" {
+			return errors.New("receipt has no ID")
+		}
+	}
+	if rec.Proof != nil {
+		set++
+		if rec.Proof.ReceiptID == "" || rec.Proof.Proof == nil {
+			return errors.New("proof has no receipt ID or hashes")
+		}
+	}
+	if rec.Quarantine != nil {
+		set++
+	}
+	if rec.Epoch != nil {
+		set++
+	}
+	if rec.Payouts != nil {
+		set++
+	}
+	if rec.Claim != nil {
+		set++
+		if rec.Claim.ID == "" {
+			return errors.New("claim has no ID")
+		}
+	}
+	if rec.Evidence != nil {
+		set++
+		if rec.Evidence.ID == "" {
+			return errors.New("evidence has no ID")
+		}
+	}
+	if rec.Finding != nil {
+		set++
+	}
+	if set != 1 {
+		return errors.New("expected exactly one of receipt, proof, quarantine, epoch, payouts, claim, evidence or finding")
+	}
+	return nil
+}
+
+// isProofRecord reports whether a record holds only a proof.
+func isProofRecord(raw json.RawMessage) bool {
+	var rec map[string]json.RawMessage
+	if json.Unmarshal(raw, &rec) != nil || len(rec) != 1 {
+		return false
+	}
+	_, ok := rec["proof"]
+	return ok
+}
@@ -0,0 +1,479 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/quiver/aggregator/pkg/merkle"
+	bolt "go.etcd.io/bbolt"
+)
+
+// eachStore runs fn against every Store implementation.
+func eachStore(t *testing.T, fn func(t *testing.T, s Store)) {
+	t.Run("memory", func(t *testing.T) {
+		fn(t, NewStore())
+	})
+	t.Run("bolt", func(t *testing.T) {
+		s, err := OpenBoltStore(filepath.Join(t.TempDir(), "test.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer s.Close()
+		fn(t, s)
+	})
+}
+
+func testReceipt(id, provider, model string, epoch int64) *SignedReceipt {
+	return &SignedReceipt{
+		Receipt: Receipt{
+			Version:    "2.0.0",
+			ProviderPK: provider,
+			Model:      model,
+			Epoch:      epoch,
+			ReceiptID:  id,
+		},
+		Signature: "sig-" + id,
+	}
+}
+
+// fill stores 3 providers x 2 models x 3 epochs of receipts.
+func fill(t *testing.T, s Store) {
+	t.Helper()
+	for _, provider := range []string{"pa", "pb", "pc"} {
+		for _, model := range []string{"m1", "m2"} {
+			for epoch := int64(10); epoch < 13; epoch++ {
+				id := fmt.Sprintf("%s-%s-%d", provider, model, epoch)
+				if err := s.Store(testReceipt(id, provider, model, epoch)); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+	}
+}
+
+func ids(receipts []*SignedReceipt) []string {
+	out := make([]string, len(receipts))
+	for i, r := range receipts {
+		out[i] = r.Receipt.ReceiptID
+	}
+	return out
+}
+
+// scanAll follows cursors to the last page.
+func scanAll(t *testing.T, s Store, q Query) []string {
+	t.Helper()
+	var all []string
+	for pages := 0; ; pages++ {
+		if pages > 100 {
+			t.Fatal("scan does not terminate")
+		}
+		page, err := s.Scan(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if q.Limit > 0 && len(page.Receipts) > q.Limit {
+			t.Fatalf("page of %d exceeds limit %d", len(page.Receipts), q.Limit)
+		}
+		all = append(all, ids(page.Receipts)...)
+		if page.Next == "" {
+			return all
+		}
+		q.After = page.Next
+	}
+}
+
+func TestStoreScan(t *testing.T) {
+	eachStore(t, func(t *testing.T, s Store) {
+		fill(t, s)
+
+		tests := []struct {
+			name  string
+			query Query
+			want  []string
+		}{
+			{
+				name:  "provider",
+				query: Query{ProviderPK: "pb", Limit: 2},
+				want:  []string{"pb-m1-10", "pb-m2-10", "pb-m1-11", "pb-m2-11", "pb-m1-12", "pb-m2-12"},
+			},
+			{
+				name:  "model and epoch range",
+				query: Query{Model: "m2", FromEpoch: 11, ToEpoch: 12, Limit: 1},
+				want:  []string{"pa-m2-11", "pb-m2-11", "pc-m2-11"},
+			},
+			{
+				name:  "provider and model",
+				query: Query{ProviderPK: "pc", Model: "m1", FromEpoch: 11},
+				want:  []string{"pc-m1-11", "pc-m1-12"},
+			},
+			{
+				name:  "epoch",
+				query: Query{FromEpoch: 12, Limit: 4},
+				want:  []string{"pa-m1-12", "pa-m2-12", "pb-m1-12", "pb-m2-12", "pc-m1-12", "pc-m2-12"},
+			},
+			{
+				name:  "no match",
+				query: Query{ProviderPK: "pz"},
+				want:  nil,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got := scanAll(t, s, tt.query)
+				if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+					t.Errorf("got %v, want %v", got, tt.want)
+				}
+			})
+		}
+
+		if _, err := s.Scan(Query{After: "not a cursor"}); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("invalid cursor: got %v", err)
+		}
+
+		// An exact page leaves no cursor behind
+		page, err := s.Scan(Query{ProviderPK: "pa", Model: "m1", Limit: 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Receipts) != 3 || page.Next != "" {
+			t.Errorf("exact page: %d receipts, next %q", len(page.Receipts), page.Next)
+		}
+	})
+}
+
+func TestStoreRejectsTakenID(t *testing.T) {
+	eachStore(t, func(t *testing.T, s Store) {
+		if err := s.Store(testReceipt("r1", "pa", "m1", 10)); err != nil {
+			t.Fatal(err)
+		}
+		// The same receipt again is a no-op
+		if err := s.Store(testReceipt("r1", "pa", "m1", 10)); err != nil {
+			t.Errorf("storing the same receipt again: %v", err)
+		}
+		if err := s.Store(testReceipt("r1", "pb", "m2", 11)); !errors.Is(err, ErrReceiptExists) {
+			t.Errorf("storing another receipt under a taken ID: %v", err)
+		}
+
+		if n := s.Count(10); n != 1 {
+			t.Errorf("epoch counts %d receipts", n)
+		}
+		if n := s.Count(11); n != 0 {
+			t.Errorf("rejected receipt counted in epoch 11: %d", n)
+		}
+		if got, _ := s.GetByID("r1"); got == nil || got.Receipt.ProviderPK != "pa" {
+			t.Errorf("stored receipt = %+v", got)
+		}
+		if got := scanAll(t, s, Query{ProviderPK: "pb"}); len(got) != 0 {
+			t.Errorf("rejected receipt indexed: %v", got)
+		}
+	})
+}
+
+func TestStoreGetByEpochOrder(t *testing.T) {
+	eachStore(t, func(t *testing.T, s Store) {
+		for _, id := range []string{"c", "a", "b"} {
+			if err := s.Store(testReceipt(id, "pa", "m1", 7)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		got, err := s.GetByEpoch(7)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(ids(got), "") != "abc" {
+			t.Errorf("got %v", ids(got))
+		}
+
+		s.Store(testReceipt("d", "pa", "m1", 3))
+		s.Store(testReceipt("e", "pa", "m1", 300))
+		if epochs, err := s.Epochs(); err != nil || fmt.Sprint(epochs) != "[3 7 300]" {
+			t.Errorf("epochs: %v %v", epochs, err)
+		}
+
+		missing, err := s.GetByID("zz")
+		if err != nil || missing != nil {
+			t.Errorf("missing receipt: %v, %v", missing, err)
+		}
+	})
+}
+
+func TestStoreSnapshotRestore(t *testing.T) {
+	eachStore(t, func(t *testing.T, s Store) {
+		fill(t, s)
+		if err := s.StoreProof("pa-m1-10", &merkle.Proof{LeafIndex: 1, TreeSize: 3, Hashes: []string{"h1", "h2"}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Quarantine(testReceipt("bad", "pa", "m1", 10), "invalid signature"); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.SaveEpoch(10, []byte(`{"epoch":10,"state":"finalized"}`)); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.SavePayouts(10, []byte(`{"epoch":10}`)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.CreateClaim("pa-m1-10", []byte(`{"status":"confirmed"}`)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.CreateEvidence("pa-m1-11", []byte(`{"canary_id":"c1"}`)); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.SaveFinding(3, []byte(`{"kind":"gap"}`)); err != nil {
+			t.Fatal(err)
+		}
+
+		var snapshot bytes.Buffer
+		if err := s.Snapshot(&snapshot); err != nil {
+			t.Fatal(err)
+		}
+
+		// Snapshots restore into either implementation, replacing what
+		// was there
+		eachStore(t, func(t *testing.T, restored Store) {
+			restored.Store(testReceipt("stale", "pz", "m9", 1))
+			if err := restored.Restore(bytes.NewReader(snapshot.Bytes())); err != nil {
+				t.Fatal(err)
+			}
+
+			if got, _ := restored.GetByID("stale"); got != nil {
+				t.Error("restore kept a receipt not in the snapshot")
+			}
+			if n := restored.Count(11); n != 6 {
+				t.Errorf("epoch 11 has %d receipts, want 6", n)
+			}
+			if proof, ok := restored.GetProof("pa-m1-10"); !ok || proof.LeafIndex != 1 || len(proof.Hashes) != 2 {
+				t.Errorf("proof: %v %v", proof, ok)
+			}
+			if q := restored.GetQuarantined(); len(q) != 1 || q[0].Reason != "invalid signature" {
+				t.Errorf("quarantine: %+v", q)
+			}
+			if epochs, err := restored.LoadEpochs(); err != nil || string(epochs[10]) != `{"epoch":10,"state":"finalized"}` {
+				t.Errorf("epochs: %q %v", epochs, err)
+			}
+			if payouts, err := restored.GetPayouts(10); err != nil || string(payouts) != `{"epoch":10}` {
+				t.Errorf("payouts: %q %v", payouts, err)
+			}
+			if claim, err := restored.GetClaim("pa-m1-10"); err != nil || string(claim) != `{"status":"confirmed"}` {
+				t.Errorf("claim: %q %v", claim, err)
+			}
+			if evidence, err := restored.LoadEvidence(); err != nil || string(evidence["pa-m1-11"]) != `{"canary_id":"c1"}` {
+				t.Errorf("evidence: %q %v", evidence, err)
+			}
+			if findings, err := restored.LoadFindings(); err != nil || len(findings) != 1 || string(findings[3]) != `{"kind":"gap"}` {
+				t.Errorf("findings: %q %v", findings, err)
+			}
+			if payouts, _ := restored.GetPayouts(11); payouts != nil {
+				t.Errorf("payouts for an epoch never sealed: %q", payouts)
+			}
+			if got := scanAll(t, restored, Query{ProviderPK: "pc", Model: "m2"}); len(got) != 3 {
+				t.Errorf("provider index after restore: %v", got)
+			}
+		})
+
+		// A bad snapshot leaves the store as it was
+		if err := s.Restore(strings.NewReader(`{"quiver_snapshot":2}` + "\n" + `{"proof":{},"receipt":{}}`)); err == nil {
+			t.Error("invalid snapshot restored")
+		}
+		if n := s.Count(10); n != 6 {
+			t.Errorf("failed restore changed the store: epoch 10 has %d receipts", n)
+		}
+		if err := s.Restore(strings.NewReader(`{"quiver_snapshot":99}`)); err == nil {
+			t.Error("unknown snapshot version restored")
+		}
+	})
+}
+
+func TestStoreDelete(t *testing.T) {
+	eachStore(t, func(t *testing.T, s Store) {
+		fill(t, s)
+		if err := s.StoreProof("pa-m1-12", &merkle.Proof{TreeSize: 1, Hashes: []string{}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Delete([]string{"pa-m1-12", "pb-m2-12", "unknown"}); err != nil {
+			t.Fatal(err)
+		}
+
+		if got, _ := s.GetByID("pa-m1-12"); got != nil {
+			t.Error("deleted receipt still stored")
+		}
+		if _, ok := s.GetProof("pa-m1-12"); ok {
+			t.Error("deleted receipt's proof kept")
+		}
+		if n := s.Count(12); n != 4 {
+			t.Errorf("epoch 12 has %d receipts, want 4", n)
+		}
+		if got := scanAll(t, s, Query{ProviderPK: "pa", Model: "m1"}); fmt.Sprint(got) != "[pa-m1-10 pa-m1-11]" {
+			t.Errorf("provider index after delete: %v", got)
+		}
+
+		// The ID is free again
+		if err := s.Store(testReceipt("pa-m1-12", "pa", "m2", 12)); err != nil {
+			t.Errorf("storing under a deleted ID: %v", err)
+		}
+	})
+}
+
+func TestStoreProofs(t *testing.T) {
+	eachStore(t, func(t *testing.T, s Store) {
+		s.StoreProof("r0", &merkle.Proof{TreeSize: 1, Hashes: []string{}})
+
+		proofs := make(map[string]*merkle.Proof)
+		for i := 0; i < 3; i++ {
+			proofs[fmt.Sprintf("r%d", i)] = &merkle.Proof{LeafIndex: i, TreeSize: 3, Hashes: []string{"h"}}
+		}
+		if err := s.StoreProofs(proofs); err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 3; i++ {
+			proof, ok := s.GetProof(fmt.Sprintf("r%d", i))
+			if !ok || proof.LeafIndex != i || proof.TreeSize != 3 {
+				t.Errorf("proof r%d: %+v, %v", i, proof, ok)
+			}
+		}
+	})
+}
+
+func TestStoreClaims(t *testing.T) {
+	eachStore(t, func(t *testing.T, s Store) {
+		if created, err := s.CreateClaim("c1", []byte(`{"status":"pending"}`)); err != nil || !created {
+			t.Fatalf("create: %v %v", created, err)
+		}
+		if created, _ := s.CreateClaim("c1", []byte(`{"status":"other"}`)); created {
+			t.Error("claim created twice")
+		}
+		if err := s.SaveClaim("c1", []byte(`{"status":"confirmed"}`)); err != nil {
+			t.Fatal(err)
+		}
+		if record, _ := s.GetClaim("c1"); string(record) != `{"status":"confirmed"}` {
+			t.Errorf("claim after save: %s", record)
+		}
+		if record, err := s.GetClaim("c2"); record != nil || err != nil {
+			t.Errorf("unknown claim: %s %v", record, err)
+		}
+		if claims, _ := s.LoadClaims(); len(claims) != 1 {
+			t.Errorf("claims: %v", claims)
+		}
+	})
+}
+
+func TestStoreEvidence(t *testing.T) {
+	eachStore(t, func(t *testing.T, s Store) {
+		if created, err := s.CreateEvidence("r1", []byte(`{"slash_tx":""}`)); err != nil || !created {
+			t.Fatalf("create: %v %v", created, err)
+		}
+		if created, _ := s.CreateEvidence("r1", []byte(`{"slash_tx":"0x2"}`)); created {
+			t.Error("evidence recorded twice")
+		}
+		if err := s.SaveEvidence("r1", []byte(`{"slash_tx":"0x1"}`)); err != nil {
+			t.Fatal(err)
+		}
+		if evidence, _ := s.LoadEvidence(); len(evidence) != 1 || string(evidence["r1"]) != `{"slash_tx":"0x1"}` {
+			t.Errorf("evidence: %q", evidence)
+		}
+	})
+}
+
+func TestStoreFindings(t *testing.T) {
+	eachStore(t, func(t *testing.T, s Store) {
+		if err := s.SaveFinding(1, []byte(`{"resolved":false}`)); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.SaveFinding(300, []byte(`{"resolved":false}`)); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.SaveFinding(1, []byte(`{"resolved":true}`)); err != nil {
+			t.Fatal(err)
+		}
+		if findings, _ := s.LoadFindings(); len(findings) != 2 || string(findings[1]) != `{"resolved":true}` || findings[300] == nil {
+			t.Errorf("findings: %q", findings)
+		}
+	})
+}
+
+func TestBoltStorePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "aggregator.db")
+	s, err := OpenBoltStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fill(t, s)
+	s.StoreProof("pb-m2-12", &merkle.Proof{TreeSize: 1, Hashes: []string{}})
+	s.Quarantine(testReceipt("bad", "pa", "m1", 10), "revoked key")
+	s.SaveEpoch(12, []byte(`{"epoch":12}`))
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = OpenBoltStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	got, err := s.GetByID("pb-m2-12")
+	if err != nil || got == nil || got.Signature != "sig-pb-m2-12" {
+		t.Fatalf("receipt after reopen: %+v, %v", got, err)
+	}
+	if _, ok := s.GetProof("pb-m2-12"); !ok {
+		t.Error("proof lost on reopen")
+	}
+	if len(s.GetQuarantined()) != 1 {
+		t.Error("quarantine lost on reopen")
+	}
+	if epochs, _ := s.LoadEpochs(); len(epochs[12]) == 0 {
+		t.Error("epoch record lost on reopen")
+	}
+	if n := len(scanAll(t, s, Query{Model: "m1"})); n != 9 {
+		t.Errorf("model index has %d receipts after reopen, want 9", n)
+	}
+}
+
+func TestRestoreVersion1Snapshot(t *testing.T) {
+	v1 := `{"quiver_snapshot":1}
+{"receipt":{"receipt":{"version":"2.0.0","provider_pk":"pa","model":"m1","epoch":10,"receipt_id":"r1"},"signature":"sig-r1"}}
+{"proof":{"receipt_id":"r1","proof":["h1","h2"]}}
+`
+	eachStore(t, func(t *testing.T, s Store) {
+		if err := s.Restore(strings.NewReader(v1)); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := s.GetByID("r1"); got == nil {
+			t.Error("receipt not restored")
+		}
+		// Proofs for the old tree are dropped
+		if _, ok := s.GetProof("r1"); ok {
+			t.Error("version 1 proof restored")
+		}
+	})
+}
+
+func TestBoltStoreMigratesVersion1(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "aggregator.db")
+	s, err := OpenBoltStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Store(testReceipt("r1", "pa", "m1", 10))
+	s.db.Update(func(tx *bolt.Tx) error {
+		tx.Bucket(bucketProofs).Put([]byte("r1"), []byte(`["h1","h2"]`))
+		return tx.Bucket(bucketMeta).Put(keySchemaVersion, encodeUint64(1))
+	})
+	s.Close()
+
+	s, err = OpenBoltStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if got, _ := s.GetByID("r1"); got == nil {
+		t.Error("receipt lost in migration")
+	}
+	if _, ok := s.GetProof("r1"); ok {
+		t.Error("version 1 proof kept")
+	}
+}
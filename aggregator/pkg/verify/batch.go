@@ -0,0 +1,197 @@
+package verify
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/quiver/aggregator/pkg/storage"
+)
+
+var (
+	// ErrInvalidSignature means the provider signature does not verify.
+	ErrInvalidSignature = errors.New("invalid signature")
+	// ErrInvalidCoSignature means a co-signature does not verify.
+	ErrInvalidCoSignature = errors.New("invalid co-signature")
+	// ErrUnregisteredKey means the provider key is not on the allowlist.
+	ErrUnregisteredKey = errors.New("provider key not registered")
+	// ErrUndeclaredModel means the provider has not declared it serves the
+	// receipt's model.
+	ErrUndeclaredModel = errors.New("model not declared by provider")
+	// ErrCheckpointReceipt means an interim stream checkpoint was submitted.
+	// Only the final receipt of a stream is settled.
+	ErrCheckpointReceipt = errors.New("stream checkpoint receipts are not settled")
+	// ErrMissingReceipt means a batch held null instead of a receipt.
+	ErrMissingReceipt = errors.New("missing receipt")
+)
+
+// KeySet reports whether a provider public key may submit receipts.
+type KeySet interface {
+	Contains(providerPK string) bool
+}
+
+// ModelKeySet is a KeySet that also knows which models each key serves,
+// such as registry.Registry.
+type ModelKeySet interface {
+	KeySet
+	Serves(providerPK, model string) bool
+}
+
+// Allowlist is a fixed set of base64 provider public keys.
+type Allowlist map[string]struct{}
+
+// NewAllowlist builds an allowlist from keys.
+func NewAllowlist(keys []string) Allowlist {
+	a := make(Allowlist, len(keys))
+	for _, k := range keys {
+		a[k] = struct{}{}
+	}
+	return a
+}
+
+// LoadAllowlist reads one base64 public key per line. Blank lines and lines
+// starting with # are ignored.
+func LoadAllowlist(path string) (Allowlist, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var keys []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		keys = append(keys, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return NewAllowlist(keys), nil
+}
+
+// Contains implements KeySet.
+func (a Allowlist) Contains(providerPK string) bool {
+	_, ok := a[providerPK]
+	return ok
+}
+
+// Verifier checks receipts on ingest.
+type Verifier struct {
+	keys    KeySet
+	history *KeyHistory
+	workers int
+}
+
+// NewVerifier creates a verifier. If keys is nil any well-formed key is
+// accepted.
+func NewVerifier(keys KeySet) *Verifier {
+	return &Verifier{
+		keys:    keys,
+		history: NewKeyHistory(),
+		workers: runtime.NumCPU(),
+	}
+}
+
+// KeyHistory returns the provider key successions learned so far.
+func (v *Verifier) KeyHistory() *KeyHistory {
+	return v.history
+}
+
+// Verify checks one receipt: that it is settleable, the provider signature
+// against ProviderPK and its key history, any co-signatures, and then
+// allowlist membership and, for a ModelKeySet, the model.
+func (v *Verifier) Verify(r *storage.SignedReceipt) error {
+	if r == nil {
+		return ErrMissingReceipt
+	}
+	if stream := r.Receipt.Stream; stream != nil && !stream.Final {
+		return ErrCheckpointReceipt
+	}
+	// Check against the provider's full known key history, so a key
+	// revoked by a later rotation cannot vouch for receipts past the
+	// revocation point
+	if err := v.history.Learn(r.Receipt.ProviderPK, r.Succession); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+	valid, err := VerifySignatureChain(r, r.Receipt.ProviderPK, v.history.Chain(r.Receipt.ProviderPK))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+	if !valid {
+		return ErrInvalidSignature
+	}
+
+	valid, err = VerifyCoSignatures(r)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCoSignature, err)
+	}
+	if !valid {
+		return ErrInvalidCoSignature
+	}
+
+	// Keys are looked up only once the receipt is authenticated, since a
+	// lookup can cost a chain read and anyone can make up a key
+	if v.keys != nil && !v.keys.Contains(r.Receipt.ProviderPK) {
+		return ErrUnregisteredKey
+	}
+	if models, ok := v.keys.(ModelKeySet); ok && !models.Serves(r.Receipt.ProviderPK, r.Receipt.Model) {
+		return fmt.Errorf("%w: %s", ErrUndeclaredModel, r.Receipt.Model)
+	}
+
+	return nil
+}
+
+// VerifyParallel runs Verify over receipts on a pool of workers. The
+// returned slice is aligned with receipts; a nil entry means the receipt is
+// valid.
+//
+// Each signature is checked on its own with ed25519.Verify; there is no
+// batch equation. Go's ed25519 verification is cofactorless, while batch
+// verification is cofactored and accepts some signatures it rejects, so a
+// batch would let receipts in that a single check turns away.
+func (v *Verifier) VerifyParallel(receipts []*storage.SignedReceipt) []error {
+	errs := make([]error, len(receipts))
+
+	// Learn every key rotation in the batch first, so revocations apply
+	// regardless of the order receipts are checked in. Bad chains are
+	// reported by Verify.
+	for _, r := range receipts {
+		if r != nil {
+			v.history.Learn(r.Receipt.ProviderPK, r.Succession)
+		}
+	}
+
+	workers := v.workers
+	if workers > len(receipts) {
+		workers = len(receipts)
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				errs[i] = v.Verify(receipts[i])
+			}
+		}()
+	}
+
+	for i := range receipts {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return errs
+}
@@ -0,0 +1,178 @@
+package verify
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/quiver/aggregator/pkg/storage"
+)
+
+func signTestReceipt(t *testing.T, key ed25519.PrivateKey, id string) *storage.SignedReceipt {
+	t.Helper()
+
+	pub := key.Public().(ed25519.PublicKey)
+	signed := &storage.SignedReceipt{
+		Receipt: storage.Receipt{
+			Version:    VersionV2,
+			ProviderPK: base64.StdEncoding.EncodeToString(pub),
+			ReceiptID:  id,
+		},
+		Algorithm: AlgEd25519,
+		KeyID:     KeyID(pub),
+	}
+	input, err := SigningInput(&signed.Receipt, signed.Algorithm, signed.KeyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, input))
+	return signed
+}
+
+func TestVerifyParallel(t *testing.T) {
+	key := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{1}, ed25519.SeedSize))
+
+	receipts := make([]*storage.SignedReceipt, 50)
+	for i := range receipts {
+		receipts[i] = signTestReceipt(t, key, string(rune('a'+i)))
+	}
+	receipts[7].Receipt.TokensOut = 99
+	receipts[31].Signature = receipts[30].Signature
+	receipts[42] = nil
+
+	errs := NewVerifier(nil).VerifyParallel(receipts)
+	if len(errs) != len(receipts) {
+		t.Fatalf("got %d results for %d receipts", len(errs), len(receipts))
+	}
+	if !errors.Is(errs[42], ErrMissingReceipt) {
+		t.Errorf("null receipt: got %v, want ErrMissingReceipt", errs[42])
+	}
+	for i, err := range errs {
+		bad := i == 7 || i == 31
+		if i == 42 {
+			continue
+		}
+		if bad && !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("receipt %d: got %v, want ErrInvalidSignature", i, err)
+		}
+		if !bad && err != nil {
+			t.Errorf("receipt %d: unexpected error %v", i, err)
+		}
+	}
+
+	if errs := NewVerifier(nil).VerifyParallel(nil); len(errs) != 0 {
+		t.Errorf("empty batch returned %d results", len(errs))
+	}
+}
+
+func TestVerifierAllowlist(t *testing.T) {
+	registered := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{1}, ed25519.SeedSize))
+	stranger := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{2}, ed25519.SeedSize))
+
+	good := signTestReceipt(t, registered, "good")
+	unknown := signTestReceipt(t, stranger, "unknown")
+
+	path := filepath.Join(t.TempDir(), "providers.allow")
+	content := "# registered providers\n\n" + good.Receipt.ProviderPK + "\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	allowlist, err := LoadAllowlist(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(allowlist) != 1 {
+		t.Fatalf("expected 1 key, got %d", len(allowlist))
+	}
+
+	v := NewVerifier(allowlist)
+	if err := v.Verify(good); err != nil {
+		t.Errorf("registered provider rejected: %v", err)
+	}
+	if err := v.Verify(unknown); !errors.Is(err, ErrUnregisteredKey) {
+		t.Errorf("got %v, want ErrUnregisteredKey", err)
+	}
+}
+
+// countingKeys accepts every key and counts the lookups.
+type countingKeys struct {
+	lookups int
+}
+
+func (k *countingKeys) Contains(providerPK string) bool {
+	k.lookups++
+	return true
+}
+
+func TestVerifierLooksUpOnlyAuthenticKeys(t *testing.T) {
+	key := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{1}, ed25519.SeedSize))
+	forged := signTestReceipt(t, key, "forged")
+	forged.Receipt.TokensOut++
+
+	keys := &countingKeys{}
+	v := NewVerifier(keys)
+	if err := v.Verify(forged); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("got %v, want ErrInvalidSignature", err)
+	}
+	if keys.lookups != 0 {
+		t.Errorf("forged receipt cost %d key lookups", keys.lookups)
+	}
+	if err := v.Verify(signTestReceipt(t, key, "good")); err != nil || keys.lookups != 1 {
+		t.Errorf("good receipt: %v after %d lookups", err, keys.lookups)
+	}
+}
+
+// modelSet lets each key serve one model.
+type modelSet map[string]string
+
+func (m modelSet) Contains(providerPK string) bool {
+	_, ok := m[providerPK]
+	return ok
+}
+
+func (m modelSet) Serves(providerPK, model string) bool {
+	return m[providerPK] == model
+}
+
+func TestVerifierModels(t *testing.T) {
+	key := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{1}, ed25519.SeedSize))
+	declared := signTestReceipt(t, key, "declared")
+	v := NewVerifier(modelSet{declared.Receipt.ProviderPK: ""})
+	if err := v.Verify(declared); err != nil {
+		t.Errorf("declared model rejected: %v", err)
+	}
+
+	v = NewVerifier(modelSet{declared.Receipt.ProviderPK: "llama3.2:3b"})
+	if err := v.Verify(declared); !errors.Is(err, ErrUndeclaredModel) {
+		t.Errorf("got %v, want ErrUndeclaredModel", err)
+	}
+}
+
+func TestVerifierStreamReceipts(t *testing.T) {
+	data, err := os.ReadFile("../../../testvectors/stream.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors struct {
+		Receipts []*storage.SignedReceipt `json:"receipts"`
+	}
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+
+	errs := NewVerifier(nil).VerifyParallel(vectors.Receipts)
+	last := len(errs) - 1
+	for i, err := range errs[:last] {
+		if !errors.Is(err, ErrCheckpointReceipt) {
+			t.Errorf("checkpoint %d: got %v, want ErrCheckpointReceipt", i, err)
+		}
+	}
+	if errs[last] != nil {
+		t.Errorf("final stream receipt rejected: %v", errs[last])
+	}
+}
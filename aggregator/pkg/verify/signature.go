@@ -0,0 +1,171 @@
+// Package verify checks provider signatures on stored receipts. The signing
+// rules mirror provider/pkg/receipt and both are tested against
+// testvectors/receipts.json.
+package verify
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/mr-tron/base58"
+	"github.com/quiver/aggregator/pkg/jcs"
+	"github.com/quiver/aggregator/pkg/storage"
+)
+
+const (
+	VersionV1 = "1.0.0"
+	VersionV2 = "2.0.0"
+
+	AlgEd25519 = "ed25519"
+
+	DomainReceiptV2    = "QUIVER-RECEIPT-V2"
+	DomainCoSignV2     = "QUIVER-RECEIPT-COSIGN-V2"
+	DomainSuccessionV1 = "QUIVER-KEY-SUCCESSION-V1"
+)
+
+type signedPayload struct {
+	Algorithm string          `json:"alg"`
+	KeyID     string          `json:"kid"`
+	Receipt   storage.Receipt `json:"receipt"`
+}
+
+// KeyID derives the identifier of a public key: base58 of the first 16 bytes
+// of its SHA-256.
+func KeyID(publicKey []byte) string {
+	hash := sha256.Sum256(publicKey)
+	return base58.Encode(hash[:16])
+}
+
+// SigningInput returns the bytes the provider signature covers.
+func SigningInput(receipt *storage.Receipt, alg, keyID string) ([]byte, error) {
+	switch receipt.Version {
+	case VersionV1:
+		return jcs.Canonicalize(receipt)
+	case VersionV2:
+		canonical, err := jcs.Canonicalize(signedPayload{
+			Algorithm: alg,
+			KeyID:     keyID,
+			Receipt:   *receipt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return domainSeparate(DomainReceiptV2, canonical), nil
+	default:
+		return nil, fmt.Errorf("unsupported receipt version %q", receipt.Version)
+	}
+}
+
+// CoSigningInput returns the bytes a co-signature covers.
+func CoSigningInput(signed *storage.SignedReceipt) ([]byte, error) {
+	if signed.Receipt.Version != VersionV2 {
+		return nil, fmt.Errorf("co-signatures require receipt version %s", VersionV2)
+	}
+
+	input, err := SigningInput(&signed.Receipt, signed.Algorithm, signed.KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	h := sha256.New()
+	h.Write(input)
+	h.Write([]byte(signed.Signature))
+	return domainSeparate(DomainCoSignV2, h.Sum(nil)), nil
+}
+
+// VerifySignature checks the provider signature on a v1 or v2 receipt
+// against the provider identity publicKeyBase64, following the succession
+// chain carried in the envelope.
+func VerifySignature(receipt *storage.SignedReceipt, publicKeyBase64 string) (bool, error) {
+	return VerifySignatureChain(receipt, publicKeyBase64, receipt.Succession)
+}
+
+// VerifySignatureChain is VerifySignature with the succession chain supplied
+// by the caller, typically the longest chain known for the provider, so
+// revocations the envelope omits still apply.
+func VerifySignatureChain(receipt *storage.SignedReceipt, publicKeyBase64 string, chain []storage.SignedSuccession) (bool, error) {
+	publicKey, err := base64.StdEncoding.DecodeString(publicKeyBase64)
+	if err != nil {
+		return false, err
+	}
+	if len(publicKey) != ed25519.PublicKeySize {
+		return false, fmt.Errorf("invalid public key size")
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(receipt.Signature)
+	if err != nil {
+		return false, err
+	}
+
+	signingKey := ed25519.PublicKey(publicKey)
+	if receipt.Receipt.Version != VersionV1 {
+		if receipt.Algorithm != AlgEd25519 {
+			return false, fmt.Errorf("unsupported signature algorithm %q", receipt.Algorithm)
+		}
+
+		windows, err := resolveKeyChain(signingKey, chain)
+		if err != nil {
+			return false, err
+		}
+		signingKey, err = keyForReceipt(windows, receipt.KeyID, receipt.Receipt.Seq)
+		if errors.Is(err, ErrUnknownKey) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+
+	input, err := SigningInput(&receipt.Receipt, receipt.Algorithm, receipt.KeyID)
+	if err != nil {
+		return false, err
+	}
+
+	return ed25519.Verify(signingKey, input, signature), nil
+}
+
+// VerifyCoSignatures checks every co-signature against the key it carries.
+func VerifyCoSignatures(receipt *storage.SignedReceipt) (bool, error) {
+	if len(receipt.CoSignatures) == 0 {
+		return true, nil
+	}
+
+	input, err := CoSigningInput(receipt)
+	if err != nil {
+		return false, err
+	}
+
+	for _, cs := range receipt.CoSignatures {
+		if cs.Algorithm != AlgEd25519 {
+			return false, fmt.Errorf("unsupported co-signature algorithm %q", cs.Algorithm)
+		}
+
+		publicKey, err := base64.StdEncoding.DecodeString(cs.PublicKey)
+		if err != nil {
+			return false, err
+		}
+		if len(publicKey) != ed25519.PublicKeySize || cs.KeyID != KeyID(publicKey) {
+			return false, nil
+		}
+
+		signature, err := base64.StdEncoding.DecodeString(cs.Signature)
+		if err != nil {
+			return false, err
+		}
+		if !ed25519.Verify(ed25519.PublicKey(publicKey), input, signature) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func domainSeparate(domain string, msg []byte) []byte {
+	out := make([]byte, 0, len(domain)+1+len(msg))
+	out = append(out, domain...)
+	out = append(out, 0)
+	return append(out, msg...)
+}
@@ -0,0 +1,68 @@
+package verify
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/quiver/aggregator/pkg/storage"
+)
+
+func TestVectors(t *testing.T) {
+	data, err := os.ReadFile("../../../testvectors/receipts.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var vectors struct {
+		Provider struct {
+			PublicKey string `json:"public_key"`
+		} `json:"provider"`
+		Cases []struct {
+			Name         string                `json:"name"`
+			Signed       storage.SignedReceipt `json:"signed"`
+			SigningInput string                `json:"signing_input"`
+			Valid        bool                  `json:"valid"`
+		} `json:"cases"`
+	}
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range vectors.Cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			if tc.SigningInput != "" {
+				input, err := SigningInput(&tc.Signed.Receipt, tc.Signed.Algorithm, tc.Signed.KeyID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(input) != tc.SigningInput {
+					t.Errorf("signing input mismatch:\n got  %q\n want %q", input, tc.SigningInput)
+				}
+			}
+
+			valid, _ := VerifySignature(&tc.Signed, vectors.Provider.PublicKey)
+			if valid && len(tc.Signed.CoSignatures) > 0 {
+				valid, _ = VerifyCoSignatures(&tc.Signed)
+			}
+			if valid != tc.Valid {
+				t.Errorf("verification = %v, want %v", valid, tc.Valid)
+			}
+		})
+	}
+}
+
+func TestUnsupportedVersion(t *testing.T) {
+	signed := &storage.SignedReceipt{
+		Receipt:   storage.Receipt{Version: "9.9.9"},
+		Signature: "",
+		Algorithm: AlgEd25519,
+	}
+	// 32 zero bytes, base64
+	pk := "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="
+	signed.KeyID = KeyID(make([]byte, 32))
+
+	if _, err := VerifySignature(signed, pk); err == nil {
+		t.Error("expected error for unsupported receipt version")
+	}
+}
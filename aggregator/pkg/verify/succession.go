@@ -0,0 +1,174 @@
+package verify
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/quiver/aggregator/pkg/jcs"
+	"github.com/quiver/aggregator/pkg/storage"
+)
+
+var (
+	// ErrRevokedKey means a receipt was signed by a key outside the range of
+	// sequence numbers the provider's succession chain allows.
+	ErrRevokedKey = errors.New("signing key revoked for this sequence number")
+	// ErrUnknownKey means no key in the succession chain matches the kid.
+	ErrUnknownKey = errors.New("signing key not in provider key chain")
+	// ErrKeyHistoryConflict means a receipt carries a succession chain that
+	// diverges from the one already known for the provider.
+	ErrKeyHistoryConflict = errors.New("succession chain conflicts with known key history")
+)
+
+// SuccessionSigningInput returns the bytes the previous key signs in a
+// succession certificate.
+func SuccessionSigningInput(cert *storage.SuccessionCertificate) ([]byte, error) {
+	canonical, err := jcs.Canonicalize(cert)
+	if err != nil {
+		return nil, err
+	}
+	return domainSeparate(DomainSuccessionV1, canonical), nil
+}
+
+// keyWindow is a key and the sequence numbers it may sign: from inclusive,
+// until exclusive, zero meaning unbounded.
+type keyWindow struct {
+	publicKey ed25519.PublicKey
+	from      int64
+	until     int64
+}
+
+// resolveKeyChain verifies a succession chain starting at identity. The
+// rules mirror provider/pkg/receipt.
+func resolveKeyChain(identity ed25519.PublicKey, chain []storage.SignedSuccession) ([]keyWindow, error) {
+	identityBase64 := base64.StdEncoding.EncodeToString(identity)
+	windows := []keyWindow{{publicKey: identity}}
+
+	for i := range chain {
+		s := &chain[i]
+		cert := &s.Certificate
+		prev := &windows[len(windows)-1]
+
+		if cert.ProviderPK != identityBase64 {
+			return nil, fmt.Errorf("succession %d: certificate for another provider", i)
+		}
+		if s.Algorithm != AlgEd25519 {
+			return nil, fmt.Errorf("succession %d: unsupported algorithm %q", i, s.Algorithm)
+		}
+		if s.KeyID != KeyID(prev.publicKey) || cert.PrevKeyID != s.KeyID {
+			return nil, fmt.Errorf("succession %d: not signed by the preceding key", i)
+		}
+		if cert.EffectiveSeq <= prev.from || cert.RevokeFromSeq < 1 || cert.RevokeFromSeq > cert.EffectiveSeq {
+			return nil, fmt.Errorf("succession %d: invalid sequence bounds", i)
+		}
+
+		next, err := base64.StdEncoding.DecodeString(cert.NextKey)
+		if err != nil {
+			return nil, fmt.Errorf("succession %d: %w", i, err)
+		}
+		if len(next) != ed25519.PublicKeySize || cert.NextKeyID != KeyID(next) {
+			return nil, fmt.Errorf("succession %d: invalid next key", i)
+		}
+
+		input, err := SuccessionSigningInput(cert)
+		if err != nil {
+			return nil, err
+		}
+		signature, err := base64.StdEncoding.DecodeString(s.Signature)
+		if err != nil {
+			return nil, fmt.Errorf("succession %d: %w", i, err)
+		}
+		if !ed25519.Verify(prev.publicKey, input, signature) {
+			return nil, fmt.Errorf("succession %d: invalid signature", i)
+		}
+
+		prev.until = cert.RevokeFromSeq
+		windows = append(windows, keyWindow{publicKey: ed25519.PublicKey(next), from: cert.EffectiveSeq})
+	}
+
+	return windows, nil
+}
+
+// keyForReceipt picks the key that may sign a receipt with the given kid and
+// sequence number. Receipts outside the hash chain (seq 0) may only be
+// signed by a key that has not been retired.
+func keyForReceipt(windows []keyWindow, kid string, seq int64) (ed25519.PublicKey, error) {
+	for _, w := range windows {
+		if KeyID(w.publicKey) != kid {
+			continue
+		}
+		if seq == 0 {
+			if w.until != 0 {
+				return nil, ErrRevokedKey
+			}
+			return w.publicKey, nil
+		}
+		if seq < w.from || (w.until != 0 && seq >= w.until) {
+			return nil, ErrRevokedKey
+		}
+		return w.publicKey, nil
+	}
+	return nil, ErrUnknownKey
+}
+
+// KeyHistory remembers the longest valid succession chain seen for each
+// provider identity. A receipt signed with a compromised key will not carry
+// the certificate that revoked it, so the verifier checks receipts against
+// the known history rather than the envelope alone. It is safe for
+// concurrent use.
+type KeyHistory struct {
+	chains map[string][]storage.SignedSuccession
+	mu     sync.RWMutex
+}
+
+// NewKeyHistory creates an empty history.
+func NewKeyHistory() *KeyHistory {
+	return &KeyHistory{
+		chains: make(map[string][]storage.SignedSuccession),
+	}
+}
+
+// Learn records chain for the provider identity if it is valid and extends
+// the known chain. A chain that diverges from the known one is rejected with
+// ErrKeyHistoryConflict.
+func (h *KeyHistory) Learn(identity string, chain []storage.SignedSuccession) error {
+	if len(chain) == 0 {
+		return nil
+	}
+
+	publicKey, err := base64.StdEncoding.DecodeString(identity)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid provider key")
+	}
+	if _, err := resolveKeyChain(publicKey, chain); err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	known := h.chains[identity]
+	shorter, longer := chain, known
+	if len(chain) > len(known) {
+		shorter, longer = known, chain
+	}
+	for i := range shorter {
+		if shorter[i].Signature != longer[i].Signature {
+			return ErrKeyHistoryConflict
+		}
+	}
+
+	if len(chain) > len(known) {
+		h.chains[identity] = append([]storage.SignedSuccession{}, chain...)
+	}
+	return nil
+}
+
+// Chain returns the known succession chain for a provider identity.
+func (h *KeyHistory) Chain(identity string) []storage.SignedSuccession {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.chains[identity]
+}
@@ -0,0 +1,126 @@
+package verify
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/quiver/aggregator/pkg/storage"
+)
+
+type rotationVectors struct {
+	Provider struct {
+		SeedHex   string `json:"seed_hex"`
+		PublicKey string `json:"public_key"`
+	} `json:"provider"`
+	Chain []storage.SignedSuccession `json:"chain"`
+	Cases []struct {
+		Name             string                `json:"name"`
+		Signed           storage.SignedReceipt `json:"signed"`
+		Valid            bool                  `json:"valid"`
+		ValidWithHistory bool                  `json:"valid_with_history"`
+	} `json:"cases"`
+}
+
+func loadRotationVectors(t *testing.T) *rotationVectors {
+	t.Helper()
+	data, err := os.ReadFile("../../../testvectors/rotation.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v rotationVectors
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatal(err)
+	}
+	return &v
+}
+
+func TestRotationVectors(t *testing.T) {
+	v := loadRotationVectors(t)
+
+	for _, tc := range v.Cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			valid, _ := VerifySignature(&tc.Signed, v.Provider.PublicKey)
+			if valid != tc.Valid {
+				t.Errorf("envelope chain: verification = %v, want %v", valid, tc.Valid)
+			}
+
+			valid, _ = VerifySignatureChain(&tc.Signed, v.Provider.PublicKey, v.Chain)
+			if valid != tc.ValidWithHistory {
+				t.Errorf("full chain: verification = %v, want %v", valid, tc.ValidWithHistory)
+			}
+		})
+	}
+}
+
+func TestVerifierFollowsRotation(t *testing.T) {
+	v := loadRotationVectors(t)
+	byName := make(map[string]*storage.SignedReceipt)
+	for i := range v.Cases {
+		byName[v.Cases[i].Name] = &v.Cases[i].Signed
+	}
+
+	// The forged receipt arrives in the same batch as the key event that
+	// revokes its key, ahead of it
+	batch := []*storage.SignedReceipt{
+		byName["revoked-key-without-chain"],
+		byName["pre-rotation"],
+		byName["key-event"],
+		byName["post-rotation"],
+	}
+	errs := NewVerifier(nil).VerifyParallel(batch)
+	if !errors.Is(errs[0], ErrRevokedKey) {
+		t.Errorf("revoked key: got %v, want ErrRevokedKey", errs[0])
+	}
+	for i, err := range errs[1:] {
+		if err != nil {
+			t.Errorf("receipt %d rejected: %v", i+1, err)
+		}
+	}
+
+	// Once learned, the rotation applies to later batches too
+	verifier := NewVerifier(nil)
+	verifier.VerifyParallel([]*storage.SignedReceipt{byName["key-event"]})
+	if err := verifier.Verify(byName["revoked-key-without-chain"]); !errors.Is(err, ErrRevokedKey) {
+		t.Errorf("got %v, want ErrRevokedKey", err)
+	}
+}
+
+func TestKeyHistoryConflict(t *testing.T) {
+	v := loadRotationVectors(t)
+	history := NewKeyHistory()
+
+	if err := history.Learn(v.Provider.PublicKey, v.Chain); err != nil {
+		t.Fatal(err)
+	}
+	if err := history.Learn(v.Provider.PublicKey, nil); err != nil {
+		t.Errorf("empty chain rejected: %v", err)
+	}
+	if len(history.Chain(v.Provider.PublicKey)) != 1 {
+		t.Error("known chain lost")
+	}
+
+	// A tampered certificate does not verify
+	tampered := append([]storage.SignedSuccession{}, v.Chain...)
+	tampered[0].Certificate.Reason = "compromised"
+	if err := history.Learn(v.Provider.PublicKey, tampered); err == nil || errors.Is(err, ErrKeyHistoryConflict) {
+		t.Errorf("got %v, want a signature error", err)
+	}
+
+	// A second, validly signed certificate from the same key is a fork
+	seed, _ := hex.DecodeString(v.Provider.SeedHex)
+	forked := append([]storage.SignedSuccession{}, v.Chain...)
+	forked[0].Certificate.Reason = "compromised"
+	input, err := SuccessionSigningInput(&forked[0].Certificate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forked[0].Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(ed25519.NewKeyFromSeed(seed), input))
+	if err := history.Learn(v.Provider.PublicKey, forked); !errors.Is(err, ErrKeyHistoryConflict) {
+		t.Errorf("got %v, want ErrKeyHistoryConflict", err)
+	}
+}
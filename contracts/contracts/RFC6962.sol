@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.20;
+
+/// @notice RFC 6962 Merkle tree hashing and multiproof verification, matching
+/// aggregator/pkg/merkle. A multiproof lists the roots of the subtrees that
+/// hold none of the proven leaves, in depth-first, left-to-right order.
+library RFC6962 {
+    struct State {
+        uint256[] indices;
+        bytes32[] leaves;
+        bytes32[] proof;
+        uint256 next;
+        bool ok;
+    }
+
+    function leafHash(bytes memory data) internal pure returns (bytes32) {
+        return sha256(abi.encodePacked(bytes1(0x00), data));
+    }
+
+    function nodeHash(bytes32 left, bytes32 right) internal pure returns (bytes32) {
+        return sha256(abi.encodePacked(bytes1(0x01), left, right));
+    }
+
+    /// @notice Checks an inclusion proof: leaf is at index in the tree of
+    /// treeSize leaves with root. proof runs from the leaf's sibling up
+    /// (RFC 9162 section 2.1.3.2).
+    function verifyInclusion(
+        bytes32 root,
+        bytes32 leaf,
+        uint256 index,
+        uint256 treeSize,
+        bytes32[] memory proof
+    ) internal pure returns (bool) {
+        if (index >= treeSize) {
+            return false;
+        }
+        uint256 fn = index;
+        uint256 sn = treeSize - 1;
+        bytes32 r = leaf;
+        for (uint256 i = 0; i < proof.length; i++) {
+            if (sn == 0) {
+                return false;
+            }
+            if ((fn & 1) == 1 || fn == sn) {
+                r = nodeHash(proof[i], r);
+                while ((fn & 1) == 0 && fn != 0) {
+                    fn >>= 1;
+                    sn >>= 1;
+                }
+            } else {
+                r = nodeHash(r, proof[i]);
+            }
+            fn >>= 1;
+            sn >>= 1;
+        }
+        return sn == 0 && r == root;
+    }
+
+    /// @notice Checks that leaves, ordered by their strictly ascending
+    /// indices, are leaves of the tree of treeSize leaves with root.
+    function verifyMultiproof(
+        bytes32 root,
+        uint256 treeSize,
+        uint256[] memory indices,
+        bytes32[] memory leaves,
+        bytes32[] memory proof
+    ) internal pure returns (bool) {
+        if (treeSize == 0 || leaves.length == 0 || leaves.length != indices.length) {
+            return false;
+        }
+        for (uint256 i = 0; i < indices.length; i++) {
+            if (indices[i] >= treeSize || (i > 0 && indices[i] <= indices[i - 1])) {
+                return false;
+            }
+        }
+
+        State memory s = State(indices, leaves, proof, 0, true);
+        bytes32 computed = _root(0, treeSize, 0, leaves.length, s);
+        return s.ok && s.next == proof.length && computed == root;
+    }
+
+    /// @dev Rebuilds the hash of leaves [lo, hi), which hold the proven
+    /// leaves from to to.
+    function _root(uint256 lo, uint256 hi, uint256 from, uint256 to, State memory s)
+        private
+        pure
+        returns (bytes32)
+    {
+        if (from == to) {
+            if (s.next >= s.proof.length) {
+                s.ok = false;
+                return bytes32(0);
+            }
+            return s.proof[s.next++];
+        }
+        if (hi - lo == 1) {
+            return s.leaves[from];
+        }
+        uint256 k = _splitPoint(hi - lo);
+        uint256 mid = from;
+        while (mid < to && s.indices[mid] < lo + k) {
+            mid++;
+        }
+        bytes32 left = _root(lo, lo + k, from, mid, s);
+        if (!s.ok) {
+            return bytes32(0);
+        }
+        bytes32 right = _root(lo + k, hi, mid, to, s);
+        return nodeHash(left, right);
+    }
+
+    /// @dev The largest power of two smaller than n, for n > 1.
+    function _splitPoint(uint256 n) private pure returns (uint256 k) {
+        k = 1;
+        while (k << 1 < n) {
+            k <<= 1;
+        }
+    }
+}
@@ -3,29 +3,163 @@ pragma solidity ^0.8.20;
 
 import "@openzeppelin/contracts/token/ERC20/IERC20.sol";
 import "@openzeppelin/contracts/access/Ownable.sol";
+import "./RFC6962.sol";
 
 contract SimpleSettlement is Ownable {
     IERC20 public token;
     
-    mapping(bytes32 => bool) public processedReceipts;
     mapping(address => uint256) public providerBalances;
+
+    // Receipt roots committed per epoch, with the number of leaves under each
+    mapping(uint256 => bytes32) public epochRoots;
+    mapping(uint256 => uint256) public epochTreeSizes;
+    // Leaves already paid out, by epoch and leaf index. A receipt is
+    // identified by its epoch and its index in the epoch's receipt tree.
+    mapping(uint256 => mapping(uint256 => bool)) public claimedLeaves;
+
+    // Payout roots per epoch: one leaf per provider address, see
+    // aggregator/pkg/payout
+    mapping(uint256 => bytes32) public payoutRoots;
+    mapping(uint256 => uint256) public payoutTreeSizes;
+    mapping(uint256 => mapping(address => bool)) public payoutClaimed;
+    // Addresses paid per receipt in an epoch. An address is paid for an
+    // epoch either per receipt or by its payout leaf, never both, since a
+    // payout leaf covers every receipt of the address in the epoch.
+    mapping(uint256 => mapping(address => bool)) public receiptsPaid;
+
+    // Provider stakes, keyed by the Ed25519 public key a provider signs
+    // receipts with. The address that first stakes a key owns its stake.
+    struct ProviderStake {
+        address owner;
+        uint256 amount;
+        // When a requested unbond can be withdrawn; zero while bonded
+        uint256 unbondingAt;
+        // Disputes open against the key's receipts. Stake cannot be
+        // withdrawn while any is open.
+        uint256 openDisputes;
+        string[] models;
+    }
+    mapping(bytes32 => ProviderStake) private stakes;
+    uint256 public minStake;
+    uint256 public unbondingPeriod;
+    // Hashes of the evidence stakes were slashed for, so no evidence is
+    // punished twice
+    mapping(bytes32 => bool) public slashedEvidence;
     
-    event ReceiptProcessed(bytes32 indexed receiptId, address indexed provider, uint256 amount);
+    event ReceiptProcessed(uint256 indexed epoch, uint256 leafIndex, address indexed provider, uint256 amount);
+    event RootCommitted(uint256 indexed epoch, bytes32 root, uint256 treeSize);
+    event ClaimProcessed(uint256 indexed epoch, address indexed provider, uint256 leafCount, uint256 amount);
+    event PayoutRootCommitted(uint256 indexed epoch, bytes32 root, uint256 treeSize);
+    event PayoutClaimed(uint256 indexed epoch, address indexed provider, uint256 amount, uint256 receiptCount, bytes32 receiptsRoot);
     event Withdrawn(address indexed provider, uint256 amount);
+    event Staked(bytes32 indexed providerKey, address indexed owner, uint256 amount, string[] models);
+    event UnbondRequested(bytes32 indexed providerKey, uint256 unbondingAt);
+    event StakeWithdrawn(bytes32 indexed providerKey, address indexed owner, uint256 amount);
+    event DisputeOpened(bytes32 indexed providerKey, uint256 openDisputes);
+    event DisputeClosed(bytes32 indexed providerKey, uint256 openDisputes);
+    event StakeParamsSet(uint256 minStake, uint256 unbondingPeriod);
+    event Slashed(bytes32 indexed providerKey, bytes32 indexed evidenceHash, uint256 amount, uint256 remaining);
     
     constructor(address _token) Ownable(msg.sender) {
         token = IERC20(_token);
     }
     
+    /// @notice Credits one receipt, proven by an RFC 6962 inclusion proof
+    /// of its leaf hash at leafIndex in the epoch's committed receipt tree.
     function processReceipt(
-        bytes32 receiptId,
+        uint256 epoch,
+        uint256 leafIndex,
+        bytes32 leafHash,
+        bytes32[] calldata proof,
+        address provider,
+        uint256 amount
+    ) external onlyOwner {
+        bytes32 root = epochRoots[epoch];
+        require(root != bytes32(0), "Epoch not committed");
+        require(!claimedLeaves[epoch][leafIndex], "Receipt already processed");
+        require(!payoutClaimed[epoch][provider], "Payout already claimed");
+        require(RFC6962.verifyInclusion(root, leafHash, leafIndex, epochTreeSizes[epoch], proof), "Invalid proof");
+
+        claimedLeaves[epoch][leafIndex] = true;
+        receiptsPaid[epoch][provider] = true;
+        providerBalances[provider] += amount;
+        emit ReceiptProcessed(epoch, leafIndex, provider, amount);
+    }
+    
+    function commitRoot(uint256 epoch, bytes32 root, uint256 treeSize) external onlyOwner {
+        require(epochRoots[epoch] == bytes32(0), "Epoch already committed");
+        require(root != bytes32(0) && treeSize > 0, "Empty root");
+        epochRoots[epoch] = root;
+        epochTreeSizes[epoch] = treeSize;
+        emit RootCommitted(epoch, root, treeSize);
+    }
+
+    /// @notice Checks a whole claim against an epoch's root with one
+    /// multiproof. leafHashes are the RFC 6962 leaf hashes of the claimed
+    /// receipts, in the order of their strictly ascending leafIndices.
+    function verifyClaim(
+        uint256 epoch,
+        uint256[] calldata leafIndices,
+        bytes32[] calldata leafHashes,
+        bytes32[] calldata proof
+    ) public view returns (bool) {
+        bytes32 root = epochRoots[epoch];
+        if (root == bytes32(0)) {
+            return false;
+        }
+        return RFC6962.verifyMultiproof(root, epochTreeSizes[epoch], leafIndices, leafHashes, proof);
+    }
+
+    function processClaim(
+        uint256 epoch,
+        uint256[] calldata leafIndices,
+        bytes32[] calldata leafHashes,
+        bytes32[] calldata proof,
         address provider,
         uint256 amount
     ) external onlyOwner {
-        require(!processedReceipts[receiptId], "Receipt already processed");
-        processedReceipts[receiptId] = true;
+        require(verifyClaim(epoch, leafIndices, leafHashes, proof), "Invalid proof");
+        require(!payoutClaimed[epoch][provider], "Payout already claimed");
+        for (uint256 i = 0; i < leafIndices.length; i++) {
+            require(!claimedLeaves[epoch][leafIndices[i]], "Receipt already processed");
+            claimedLeaves[epoch][leafIndices[i]] = true;
+        }
+        receiptsPaid[epoch][provider] = true;
         providerBalances[provider] += amount;
-        emit ReceiptProcessed(receiptId, provider, amount);
+        emit ClaimProcessed(epoch, provider, leafIndices.length, amount);
+    }
+    
+    function commitPayoutRoot(uint256 epoch, bytes32 root, uint256 treeSize) external onlyOwner {
+        require(payoutRoots[epoch] == bytes32(0), "Epoch already committed");
+        require(root != bytes32(0) && treeSize > 0, "Empty root");
+        payoutRoots[epoch] = root;
+        payoutTreeSizes[epoch] = treeSize;
+        emit PayoutRootCommitted(epoch, root, treeSize);
+    }
+
+    /// @notice Credits the caller's whole payout for an epoch. The leaf is
+    /// abi.encodePacked(msg.sender, amount, receiptCount, receiptsRoot) at
+    /// leafIndex of the epoch's payout tree. An address already paid per
+    /// receipt for the epoch cannot claim its payout too.
+    function claimPayout(
+        uint256 epoch,
+        uint256 amount,
+        uint256 receiptCount,
+        bytes32 receiptsRoot,
+        uint256 leafIndex,
+        bytes32[] calldata proof
+    ) external {
+        bytes32 root = payoutRoots[epoch];
+        require(root != bytes32(0), "Epoch not committed");
+        require(!payoutClaimed[epoch][msg.sender], "Payout already claimed");
+        require(!receiptsPaid[epoch][msg.sender], "Receipts already paid");
+
+        bytes32 leaf = RFC6962.leafHash(abi.encodePacked(msg.sender, amount, receiptCount, receiptsRoot));
+        require(RFC6962.verifyInclusion(root, leaf, leafIndex, payoutTreeSizes[epoch], proof), "Invalid proof");
+
+        payoutClaimed[epoch][msg.sender] = true;
+        providerBalances[msg.sender] += amount;
+        emit PayoutClaimed(epoch, msg.sender, amount, receiptCount, receiptsRoot);
     }
     
     function withdraw() external {
@@ -35,4 +169,94 @@ contract SimpleSettlement is Ownable {
         require(token.transfer(msg.sender, balance), "Transfer failed");
         emit Withdrawn(msg.sender, balance);
     }
-}
\ No newline at end of file
+
+    function setStakeParams(uint256 _minStake, uint256 _unbondingPeriod) external onlyOwner {
+        minStake = _minStake;
+        unbondingPeriod = _unbondingPeriod;
+        emit StakeParamsSet(_minStake, _unbondingPeriod);
+    }
+
+    /// @notice Adds amount, which the caller must have approved, to the
+    /// stake bound to providerKey and replaces the models it declares.
+    function stake(bytes32 providerKey, uint256 amount, string[] calldata models) external {
+        ProviderStake storage s = stakes[providerKey];
+        require(s.owner == address(0) || s.owner == msg.sender, "Key staked by another address");
+        require(s.unbondingAt == 0, "Stake is unbonding");
+        require(s.amount + amount > 0 && s.amount + amount >= minStake, "Stake below minimum");
+        if (amount > 0) {
+            require(token.transferFrom(msg.sender, address(this), amount), "Transfer failed");
+        }
+
+        s.owner = msg.sender;
+        s.amount += amount;
+        delete s.models;
+        for (uint256 i = 0; i < models.length; i++) {
+            s.models.push(models[i]);
+        }
+        emit Staked(providerKey, msg.sender, s.amount, models);
+    }
+
+    /// @notice Starts unbonding a stake. It can be withdrawn unbondingPeriod
+    /// later, once no dispute is open.
+    function requestUnbond(bytes32 providerKey) external {
+        ProviderStake storage s = stakes[providerKey];
+        require(s.owner == msg.sender, "Not stake owner");
+        require(s.unbondingAt == 0, "Stake is unbonding");
+        s.unbondingAt = block.timestamp + unbondingPeriod;
+        emit UnbondRequested(providerKey, s.unbondingAt);
+    }
+
+    function withdrawStake(bytes32 providerKey) external {
+        ProviderStake storage s = stakes[providerKey];
+        require(s.owner == msg.sender, "Not stake owner");
+        require(s.unbondingAt != 0 && block.timestamp >= s.unbondingAt, "Stake is locked");
+        require(s.openDisputes == 0, "Dispute open");
+
+        uint256 amount = s.amount;
+        delete stakes[providerKey];
+        require(token.transfer(msg.sender, amount), "Transfer failed");
+        emit StakeWithdrawn(providerKey, msg.sender, amount);
+    }
+
+    function openDispute(bytes32 providerKey) external onlyOwner {
+        ProviderStake storage s = stakes[providerKey];
+        require(s.amount > 0, "Not staked");
+        s.openDisputes++;
+        emit DisputeOpened(providerKey, s.openDisputes);
+    }
+
+    function closeDispute(bytes32 providerKey) external onlyOwner {
+        ProviderStake storage s = stakes[providerKey];
+        require(s.openDisputes > 0, "No open dispute");
+        s.openDisputes--;
+        emit DisputeClosed(providerKey, s.openDisputes);
+    }
+
+    /// @notice Takes up to amount from a provider's stake as the penalty for
+    /// the evidence hashed as evidenceHash, and sends it to the owner.
+    /// Unbonding stakes can be slashed until they are withdrawn.
+    function slash(bytes32 providerKey, uint256 amount, bytes32 evidenceHash) external onlyOwner {
+        ProviderStake storage s = stakes[providerKey];
+        require(s.amount > 0, "Not staked");
+        require(!slashedEvidence[evidenceHash], "Evidence already slashed");
+        if (amount > s.amount) {
+            amount = s.amount;
+        }
+
+        slashedEvidence[evidenceHash] = true;
+        s.amount -= amount;
+        require(token.transfer(owner(), amount), "Transfer failed");
+        emit Slashed(providerKey, evidenceHash, amount, s.amount);
+    }
+
+    function getStake(bytes32 providerKey) external view returns (
+        address stakeOwner,
+        uint256 amount,
+        uint256 unbondingAt,
+        uint256 openDisputes,
+        string[] memory models
+    ) {
+        ProviderStake storage s = stakes[providerKey];
+        return (s.owner, s.amount, s.unbondingAt, s.openDisputes, s.models);
+    }
+}
@@ -1,105 +1,617 @@
 package mock
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"math/big"
+	"strings"
 	"sync"
 	"time"
 )
 
+// Event names, as the settlement contract emits them
+const (
+	EventRootCommitted       = "RootCommitted"
+	EventReceiptProcessed    = "ReceiptProcessed"
+	EventClaimProcessed      = "ClaimProcessed"
+	EventPayoutRootCommitted = "PayoutRootCommitted"
+	EventPayoutClaimed       = "PayoutClaimed"
+	EventWithdrawn           = "Withdrawn"
+	EventStaked              = "Staked"
+	EventUnbondRequested     = "UnbondRequested"
+	EventStakeWithdrawn      = "StakeWithdrawn"
+	EventDisputeOpened       = "DisputeOpened"
+	EventDisputeClosed       = "DisputeClosed"
+	EventSlashed             = "Slashed"
+)
+
+var (
+	ErrNotCommitted     = errors.New("epoch not committed")
+	ErrAlreadyCommitted = errors.New("epoch already committed")
+	ErrAlreadyClaimed   = errors.New("receipt already processed")
+	ErrPayoutClaimed    = errors.New("payout already claimed")
+	ErrReceiptsPaid     = errors.New("receipts already paid")
+	ErrInvalidProof     = errors.New("invalid proof")
+	ErrWindowClosed     = errors.New("claim window closed")
+	ErrNoBalance        = errors.New("no balance")
+	ErrNotStaked        = errors.New("not staked")
+	ErrNotStakeOwner    = errors.New("not stake owner")
+	ErrBelowMinStake    = errors.New("stake below minimum")
+	ErrUnbonding        = errors.New("stake is unbonding")
+	ErrStakeLocked      = errors.New("stake is locked")
+	ErrDisputeOpen      = errors.New("dispute open")
+	ErrAlreadySlashed   = errors.New("evidence already slashed")
+)
+
+// Chain simulates the SimpleSettlement contract: it checks claims against
+// committed roots the way the contract does and keeps the same balances.
+// Its methods mirror the contract's functions; those that would revert
+// return an error and change nothing, except SubmitClaim, which, like a
+// transaction sent without estimating gas, is mined as failed.
+//
+// On top of the contract, an epoch can only be claimed within a window
+// after its root is committed; see SetClaimWindow. Tokens only exist as
+// withdrawals and Mint, so stakers must be given some first.
 type Chain struct {
-	roots       map[uint64]string
-	claims      map[string]*Claim
-	balances    map[string]*big.Int
-	commitCount uint64
-	mu          sync.RWMutex
+	state     *state
+	snapshots []*state
+
+	claimWindow time.Duration
+	now         func() time.Time
+	handlers    []func(Event)
+
+	mu sync.RWMutex
+}
+
+// Event is an entry of the chain's event log. Which fields are set depends
+// on Name.
+type Event struct {
+	Name   string
+	Block  uint64
+	TxHash string
+	Epoch  uint64
+	// Root and TreeSize are set for RootCommitted and
+	// PayoutRootCommitted
+	Root     string
+	TreeSize uint64
+	// LeafIndex is set for ReceiptProcessed, LeafCount for ClaimProcessed
+	// and ReceiptCount and ReceiptsRoot for PayoutClaimed
+	LeafIndex    uint64
+	LeafCount    int
+	ReceiptCount uint64
+	ReceiptsRoot string
+	Provider     string
+	Amount       *big.Int
+	// Key is the hex provider key of stake events. Models is set for
+	// Staked, UnbondingAt for UnbondRequested, OpenDisputes, the count
+	// after the event, for DisputeOpened and DisputeClosed, and
+	// EvidenceHash for Slashed, whose Amount is what was taken
+	Key          string
+	Models       []string
+	UnbondingAt  time.Time
+	OpenDisputes uint64
+	EvidenceHash string
+}
+
+// Proof is an inclusion proof in the encoding aggregator/pkg/merkle
+// produces: Hashes run from the leaf's sibling up to the root.
+type Proof struct {
+	LeafIndex int      `json:"leaf_index"`
+	TreeSize  int      `json:"tree_size"`
+	Hashes    []string `json:"hashes"`
+}
+
+// window is when an epoch can be claimed. A zero closes never closes.
+type window struct {
+	opens, closes time.Time
+}
+
+// state is everything Snapshot saves.
+type state struct {
+	block         uint64
+	roots         map[uint64]string
+	treeSizes     map[uint64]uint64
+	windows       map[uint64]window
+	claimedLeaves map[uint64]map[uint64]bool
+	// payoutRoots and payoutTreeSizes are the epochs' payout trees.
+	// payoutClaimed and receiptsPaid hold, by epoch, the addresses paid by
+	// their payout leaf and those paid per receipt; no address is both.
+	payoutRoots     map[uint64]string
+	payoutTreeSizes map[uint64]uint64
+	payoutClaimed   map[uint64]map[string]bool
+	receiptsPaid    map[uint64]map[string]bool
+	// balances are the contract's providerBalances; tokens are token
+	// balances, from withdrawals and Mint, less what is staked
+	balances map[string]*big.Int
+	tokens   map[string]*big.Int
+	// stakes are keyed by hex provider key
+	stakes          map[string]*stake
+	minStake        *big.Int
+	unbondingPeriod time.Duration
+	// slashedEvidence holds the hex hashes of evidence stakes were
+	// slashed for
+	slashedEvidence map[string]bool
+	// transactions holds the status of each transaction
+	transactions map[string]string
+	events       []Event
+}
+
+func newState() *state {
+	return &state{
+		roots:           make(map[uint64]string),
+		treeSizes:       make(map[uint64]uint64),
+		windows:         make(map[uint64]window),
+		claimedLeaves:   make(map[uint64]map[uint64]bool),
+		payoutRoots:     make(map[uint64]string),
+		payoutTreeSizes: make(map[uint64]uint64),
+		payoutClaimed:   make(map[uint64]map[string]bool),
+		receiptsPaid:    make(map[uint64]map[string]bool),
+		balances:        make(map[string]*big.Int),
+		tokens:          make(map[string]*big.Int),
+		stakes:          make(map[string]*stake),
+		minStake:        new(big.Int),
+		slashedEvidence: make(map[string]bool),
+		transactions:    make(map[string]string),
+	}
+}
+
+func (s *state) clone() *state {
+	c := newState()
+	c.block = s.block
+	for k, v := range s.roots {
+		c.roots[k] = v
+	}
+	for k, v := range s.treeSizes {
+		c.treeSizes[k] = v
+	}
+	for k, v := range s.windows {
+		c.windows[k] = v
+	}
+	for epoch, leaves := range s.claimedLeaves {
+		c.claimedLeaves[epoch] = make(map[uint64]bool, len(leaves))
+		for k, v := range leaves {
+			c.claimedLeaves[epoch][k] = v
+		}
+	}
+	for k, v := range s.payoutRoots {
+		c.payoutRoots[k] = v
+	}
+	for k, v := range s.payoutTreeSizes {
+		c.payoutTreeSizes[k] = v
+	}
+	c.payoutClaimed = cloneAddressSets(s.payoutClaimed)
+	c.receiptsPaid = cloneAddressSets(s.receiptsPaid)
+	for k, v := range s.balances {
+		c.balances[k] = new(big.Int).Set(v)
+	}
+	for k, v := range s.tokens {
+		c.tokens[k] = new(big.Int).Set(v)
+	}
+	for k, v := range s.stakes {
+		c.stakes[k] = v.clone()
+	}
+	c.minStake = new(big.Int).Set(s.minStake)
+	c.unbondingPeriod = s.unbondingPeriod
+	for k, v := range s.slashedEvidence {
+		c.slashedEvidence[k] = v
+	}
+	for k, v := range s.transactions {
+		c.transactions[k] = v
+	}
+	c.events = append([]Event(nil), s.events...)
+	return c
 }
 
-type Claim struct {
-	ID        string
-	Epoch     uint64
-	Amount    *big.Int
-	Timestamp time.Time
-	Processed bool
+func cloneAddressSets(sets map[uint64]map[string]bool) map[uint64]map[string]bool {
+	c := make(map[uint64]map[string]bool, len(sets))
+	for epoch, set := range sets {
+		c[epoch] = make(map[string]bool, len(set))
+		for k, v := range set {
+			c[epoch][k] = v
+		}
+	}
+	return c
 }
 
 func NewChain() *Chain {
 	return &Chain{
-		roots:    make(map[uint64]string),
-		claims:   make(map[string]*Claim),
-		balances: make(map[string]*big.Int),
+		state: newState(),
+		now:   time.Now,
 	}
 }
 
-func (c *Chain) CommitRoot(epoch uint64, root string) (string, error) {
+// SetClaimWindow sets how long after its root is committed an epoch can be
+// claimed. Epochs committed earlier keep their window. Zero, the default,
+// never closes.
+func (c *Chain) SetClaimWindow(d time.Duration) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	c.claimWindow = d
+}
 
-	if _, exists := c.roots[epoch]; exists {
-		return "", fmt.Errorf("epoch %d already committed", epoch)
+// SetClock sets the chain's clock, which decides claim windows.
+func (c *Chain) SetClock(now func() time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+// OnEvent registers fn to be called with every event the chain emits.
+// Handlers run synchronously, in registration order, after the emitting
+// call has released the chain, so they may call it.
+func (c *Chain) OnEvent(fn func(Event)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handlers = append(c.handlers, fn)
+}
+
+// Events returns the event log, oldest first.
+func (c *Chain) Events() []Event {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]Event(nil), c.state.events...)
+}
+
+// Snapshot saves the chain's state and returns an ID to Revert to.
+func (c *Chain) Snapshot() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.snapshots = append(c.snapshots, c.state.clone())
+	return len(c.snapshots) - 1
+}
+
+// Revert restores the state saved by Snapshot id. That snapshot and any
+// taken after it are used up.
+func (c *Chain) Revert(id int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if id < 0 || id >= len(c.snapshots) {
+		return fmt.Errorf("unknown snapshot %d", id)
+	}
+	c.state = c.snapshots[id]
+	c.snapshots = c.snapshots[:id]
+	return nil
+}
+
+// CommitRoot records an epoch's receipt root and the number of leaves
+// under it, and opens the epoch's claim window.
+func (c *Chain) CommitRoot(epoch uint64, root string, treeSize uint64) (string, error) {
+	c.mu.Lock()
+	if _, exists := c.state.roots[epoch]; exists {
+		c.mu.Unlock()
+		return "", fmt.Errorf("%w: %d", ErrAlreadyCommitted, epoch)
+	}
+	root, err := normalizeHash(root)
+	if err != nil {
+		c.mu.Unlock()
+		return "", err
+	}
+	if root == "0x"+strings.Repeat("0", 64) || treeSize == 0 {
+		c.mu.Unlock()
+		return "", fmt.Errorf("epoch %d has an empty root", epoch)
 	}
 
-	c.roots[epoch] = root
-	c.commitCount++
+	c.state.roots[epoch] = root
+	c.state.treeSizes[epoch] = treeSize
+	w := window{opens: c.now()}
+	if c.claimWindow > 0 {
+		w.closes = w.opens.Add(c.claimWindow)
+	}
+	c.state.windows[epoch] = w
 
-	txHash := generateTxHash(fmt.Sprintf("commit_%d_%s", epoch, root))
+	txHash := c.mine(fmt.Sprintf("commit_%d_%s", epoch, root), "confirmed")
+	event := c.emit(Event{Name: EventRootCommitted, TxHash: txHash, Epoch: epoch, Root: root, TreeSize: treeSize})
+	c.mu.Unlock()
+
+	c.notify(event)
 	return txHash, nil
 }
 
-func (c *Chain) VerifyClaim(receiptID string, proof []string, epoch uint64) (bool, error) {
+// VerifyClaim reports whether proof places leafData in the tree committed
+// for epoch.
+func (c *Chain) VerifyClaim(epoch uint64, leafData []byte, proof *Proof) (bool, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	root, exists := c.roots[epoch]
+	root, exists := c.state.roots[epoch]
 	if !exists {
-		return false, fmt.Errorf("epoch %d not found", epoch)
+		return false, fmt.Errorf("%w: %d", ErrNotCommitted, epoch)
+	}
+	if proof == nil || proof.LeafIndex < 0 || uint64(proof.TreeSize) != c.state.treeSizes[epoch] {
+		return false, nil
 	}
+	path, ok := decodeHashes(proof.Hashes)
+	if !ok {
+		return false, nil
+	}
+	var leaf [32]byte
+	copy(leaf[:], LeafHash(leafData))
+	return verifyInclusion(leaf, uint64(proof.LeafIndex), c.state.treeSizes[epoch], path, root), nil
+}
 
-	// Simplified verification - in real implementation would verify Merkle proof
-	proofHash := generateTxHash(fmt.Sprintf("%s_%v_%s", receiptID, proof, root))
-	return len(proofHash) > 0, nil
+// VerifyBatchClaim checks a whole claim in one call: leafHashes, in
+// proof.LeafIndices order, must all be leaves of the root committed for
+// epoch.
+func (c *Chain) VerifyBatchClaim(epoch uint64, leafHashes [][]byte, proof *Multiproof) (bool, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	root, exists := c.state.roots[epoch]
+	if !exists {
+		return false, fmt.Errorf("%w: %d", ErrNotCommitted, epoch)
+	}
+	if proof == nil || uint64(proof.TreeSize) != c.state.treeSizes[epoch] {
+		return false, nil
+	}
+	return verifyMultiproof(leafHashes, proof, root), nil
 }
 
-func (c *Chain) ProcessClaim(claimID string, amount *big.Int) (string, error) {
+// ProcessClaim credits amount to provider for the leaves of a batch claim,
+// like the settlement contract's processClaim.
+func (c *Chain) ProcessClaim(epoch uint64, leafHashes [][]byte, proof *Multiproof, provider string, amount *big.Int) (string, error) {
+	provider, err := normalizeAddress(provider)
+	if err != nil {
+		return "", err
+	}
+	if amount == nil || amount.Sign() < 0 {
+		return "", fmt.Errorf("invalid amount")
+	}
+
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	if err := c.claimable(epoch); err != nil {
+		c.mu.Unlock()
+		return "", err
+	}
+	if proof == nil || uint64(proof.TreeSize) != c.state.treeSizes[epoch] ||
+		!verifyMultiproof(leafHashes, proof, c.state.roots[epoch]) {
+		c.mu.Unlock()
+		return "", ErrInvalidProof
+	}
+	if c.state.payoutClaimed[epoch][provider] {
+		c.mu.Unlock()
+		return "", fmt.Errorf("%w: epoch %d by %s", ErrPayoutClaimed, epoch, provider)
+	}
+	for _, index := range proof.LeafIndices {
+		if c.state.claimedLeaves[epoch][uint64(index)] {
+			c.mu.Unlock()
+			return "", fmt.Errorf("%w: epoch %d leaf %d", ErrAlreadyClaimed, epoch, index)
+		}
+	}
+
+	for _, index := range proof.LeafIndices {
+		c.claimLeaf(epoch, uint64(index))
+	}
+	addToSet(c.state.receiptsPaid, epoch, provider)
+	c.credit(provider, amount)
+	txHash := c.mine(fmt.Sprintf("claim_%d_%v_%s_%s", epoch, proof.LeafIndices, provider, amount), "confirmed")
+	event := c.emit(Event{
+		Name:      EventClaimProcessed,
+		TxHash:    txHash,
+		Epoch:     epoch,
+		LeafCount: len(proof.LeafIndices),
+		Provider:  provider,
+		Amount:    new(big.Int).Set(amount),
+	})
+	c.mu.Unlock()
+
+	c.notify(event)
+	return txHash, nil
+}
 
-	if claim, exists := c.claims[claimID]; exists && claim.Processed {
-		return "", fmt.Errorf("claim %s already processed", claimID)
+// SubmitClaim pays amount to provider like the settlement contract's
+// processReceipt, for the leaf at leafIndex of the epoch's committed tree.
+// Its transaction is mined at once: it is confirmed, or failed if the epoch
+// has no root or its claim window is closed, the proof does not lead to
+// the root, the leaf was already paid or the provider has claimed its
+// payout for the epoch.
+func (c *Chain) SubmitClaim(ctx context.Context, epoch, leafIndex uint64, leafHash [32]byte, proof [][32]byte, provider string, amount *big.Int) (string, error) {
+	provider, err := normalizeAddress(provider)
+	if err != nil {
+		return "", err
+	}
+	if amount == nil || amount.Sign() < 0 {
+		return "", fmt.Errorf("invalid amount")
 	}
 
-	claim := &Claim{
-		ID:        claimID,
-		Amount:    amount,
-		Timestamp: time.Now(),
-		Processed: true,
+	c.mu.Lock()
+	desc := fmt.Sprintf("submit_%d_%d_%s_%s", epoch, leafIndex, provider, amount)
+	if c.claimable(epoch) != nil || c.state.claimedLeaves[epoch][leafIndex] || c.state.payoutClaimed[epoch][provider] ||
+		!verifyInclusion(leafHash, leafIndex, c.state.treeSizes[epoch], proof, c.state.roots[epoch]) {
+		txHash := c.mine(desc, "failed")
+		c.mu.Unlock()
+		return txHash, nil
 	}
 
-	c.claims[claimID] = claim
+	c.claimLeaf(epoch, leafIndex)
+	addToSet(c.state.receiptsPaid, epoch, provider)
+	c.credit(provider, amount)
+	txHash := c.mine(desc, "confirmed")
+	event := c.emit(Event{
+		Name:      EventReceiptProcessed,
+		TxHash:    txHash,
+		Epoch:     epoch,
+		LeafIndex: leafIndex,
+		Provider:  provider,
+		Amount:    new(big.Int).Set(amount),
+	})
+	c.mu.Unlock()
 
-	// Update balance
-	provider := "default_provider"
-	if balance, exists := c.balances[provider]; exists {
-		c.balances[provider] = new(big.Int).Add(balance, amount)
-	} else {
-		c.balances[provider] = new(big.Int).Set(amount)
+	c.notify(event)
+	return txHash, nil
+}
+
+// CommitPayoutRoot records an epoch's payout root and the number of leaves
+// under it, like the settlement contract's commitPayoutRoot.
+func (c *Chain) CommitPayoutRoot(epoch uint64, root string, treeSize uint64) (string, error) {
+	c.mu.Lock()
+	if _, exists := c.state.payoutRoots[epoch]; exists {
+		c.mu.Unlock()
+		return "", fmt.Errorf("%w: payouts of %d", ErrAlreadyCommitted, epoch)
 	}
+	root, err := normalizeHash(root)
+	if err != nil {
+		c.mu.Unlock()
+		return "", err
+	}
+	if root == "0x"+strings.Repeat("0", 64) || treeSize == 0 {
+		c.mu.Unlock()
+		return "", fmt.Errorf("epoch %d has an empty payout root", epoch)
+	}
+
+	c.state.payoutRoots[epoch] = root
+	c.state.payoutTreeSizes[epoch] = treeSize
+	txHash := c.mine(fmt.Sprintf("commit_payouts_%d_%s", epoch, root), "confirmed")
+	event := c.emit(Event{Name: EventPayoutRootCommitted, TxHash: txHash, Epoch: epoch, Root: root, TreeSize: treeSize})
+	c.mu.Unlock()
 
-	txHash := generateTxHash(fmt.Sprintf("claim_%s_%s", claimID, amount.String()))
+	c.notify(event)
 	return txHash, nil
 }
 
+// ClaimPayout credits provider, the caller, with its whole payout for an
+// epoch, like the settlement contract's claimPayout. proof places the leaf
+// encoding provider, amount, receiptCount and receiptsRoot in the epoch's
+// payout tree. An address is paid once per epoch, either here or per
+// receipt.
+func (c *Chain) ClaimPayout(epoch uint64, provider string, amount *big.Int, receiptCount uint64, receiptsRoot string, proof *Proof) (string, error) {
+	provider, err := normalizeAddress(provider)
+	if err != nil {
+		return "", err
+	}
+	if amount == nil || amount.Sign() < 0 || amount.BitLen() > 256 {
+		return "", fmt.Errorf("invalid amount")
+	}
+	receiptsRoot, err = normalizeHash(receiptsRoot)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	root, exists := c.state.payoutRoots[epoch]
+	if !exists {
+		c.mu.Unlock()
+		return "", fmt.Errorf("%w: payouts of %d", ErrNotCommitted, epoch)
+	}
+	if err := c.inWindow(epoch); err != nil {
+		c.mu.Unlock()
+		return "", err
+	}
+	if c.state.payoutClaimed[epoch][provider] {
+		c.mu.Unlock()
+		return "", fmt.Errorf("%w: epoch %d by %s", ErrPayoutClaimed, epoch, provider)
+	}
+	if c.state.receiptsPaid[epoch][provider] {
+		c.mu.Unlock()
+		return "", fmt.Errorf("%w: epoch %d to %s", ErrReceiptsPaid, epoch, provider)
+	}
+	var path [][32]byte
+	ok := proof != nil && proof.LeafIndex >= 0 && uint64(proof.TreeSize) == c.state.payoutTreeSizes[epoch]
+	if ok {
+		path, ok = decodeHashes(proof.Hashes)
+	}
+	var leaf [32]byte
+	copy(leaf[:], LeafHash(payoutLeaf(provider, amount, receiptCount, receiptsRoot)))
+	if !ok || !verifyInclusion(leaf, uint64(proof.LeafIndex), c.state.payoutTreeSizes[epoch], path, root) {
+		c.mu.Unlock()
+		return "", ErrInvalidProof
+	}
+
+	addToSet(c.state.payoutClaimed, epoch, provider)
+	c.credit(provider, amount)
+	txHash := c.mine(fmt.Sprintf("payout_%d_%s_%s", epoch, provider, amount), "confirmed")
+	event := c.emit(Event{
+		Name:         EventPayoutClaimed,
+		TxHash:       txHash,
+		Epoch:        epoch,
+		Provider:     provider,
+		Amount:       new(big.Int).Set(amount),
+		ReceiptCount: receiptCount,
+		ReceiptsRoot: receiptsRoot,
+	})
+	c.mu.Unlock()
+
+	c.notify(event)
+	return txHash, nil
+}
+
+// payoutLeaf is abi.encodePacked(address, uint256, uint256, bytes32) of
+// normalized arguments.
+func payoutLeaf(provider string, amount *big.Int, receiptCount uint64, receiptsRoot string) []byte {
+	address, _ := hex.DecodeString(strings.TrimPrefix(provider, "0x"))
+	root, _ := hex.DecodeString(strings.TrimPrefix(receiptsRoot, "0x"))
+
+	out := make([]byte, 0, 20+32+32+32)
+	out = append(out, address...)
+	out = append(out, amount.FillBytes(make([]byte, 32))...)
+	out = append(out, new(big.Int).SetUint64(receiptCount).FillBytes(make([]byte, 32))...)
+	return append(out, root...)
+}
+
+// Withdraw pays out a provider's whole balance in tokens and returns the
+// amount paid.
+func (c *Chain) Withdraw(provider string) (string, *big.Int, error) {
+	provider, err := normalizeAddress(provider)
+	if err != nil {
+		return "", nil, err
+	}
+
+	c.mu.Lock()
+	balance := c.state.balances[provider]
+	if balance == nil || balance.Sign() == 0 {
+		c.mu.Unlock()
+		return "", nil, ErrNoBalance
+	}
+	delete(c.state.balances, provider)
+	addTo(c.state.tokens, provider, balance)
+	txHash := c.mine(fmt.Sprintf("withdraw_%s_%s", provider, balance), "confirmed")
+	event := c.emit(Event{Name: EventWithdrawn, TxHash: txHash, Provider: provider, Amount: new(big.Int).Set(balance)})
+	c.mu.Unlock()
+
+	c.notify(event)
+	return txHash, new(big.Int).Set(balance), nil
+}
+
+// TransactionStatus returns the status of a transaction.
+func (c *Chain) TransactionStatus(ctx context.Context, txHash string) (string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	status, exists := c.state.transactions[txHash]
+	if !exists {
+		return "", fmt.Errorf("transaction %s not found", txHash)
+	}
+	return status, nil
+}
+
+// GetBalance returns what the contract owes an address: its
+// providerBalances entry.
 func (c *Chain) GetBalance(address string) (*big.Int, error) {
+	return c.balance(address, func(s *state) map[string]*big.Int { return s.balances })
+}
+
+// TokenBalance returns an address's tokens: what it has withdrawn or been
+// minted, less what it has staked.
+func (c *Chain) TokenBalance(address string) (*big.Int, error) {
+	return c.balance(address, func(s *state) map[string]*big.Int { return s.tokens })
+}
+
+func (c *Chain) balance(address string, balances func(*state) map[string]*big.Int) (*big.Int, error) {
+	address, err := normalizeAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	balance, exists := c.balances[address]
+	balance, exists := balances(c.state)[address]
 	if !exists {
 		return big.NewInt(0), nil
 	}
-
 	return new(big.Int).Set(balance), nil
 }
 
@@ -107,24 +619,139 @@ func (c *Chain) GetRoot(epoch uint64) (string, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	root, exists := c.roots[epoch]
+	root, exists := c.state.roots[epoch]
 	if !exists {
-		return "", fmt.Errorf("epoch %d not found", epoch)
+		return "", fmt.Errorf("%w: %d", ErrNotCommitted, epoch)
 	}
 
 	return root, nil
 }
 
-func (c *Chain) IsClaimProcessed(claimID string) (bool, error) {
+// ClaimWindow returns when an epoch's claims open and close. A zero close
+// time never closes.
+func (c *Chain) ClaimWindow(epoch uint64) (time.Time, time.Time, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	claim, exists := c.claims[claimID]
+	w, exists := c.state.windows[epoch]
 	if !exists {
-		return false, nil
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: %d", ErrNotCommitted, epoch)
 	}
+	return w.opens, w.closes, nil
+}
 
-	return claim.Processed, nil
+// GetPayoutRoot returns an epoch's committed payout root.
+func (c *Chain) GetPayoutRoot(epoch uint64) (string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	root, exists := c.state.payoutRoots[epoch]
+	if !exists {
+		return "", fmt.Errorf("%w: payouts of %d", ErrNotCommitted, epoch)
+	}
+	return root, nil
+}
+
+// IsLeafClaimed reports whether the leaf at leafIndex of an epoch's tree
+// has been paid, like the contract's claimedLeaves.
+func (c *Chain) IsLeafClaimed(epoch, leafIndex uint64) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.state.claimedLeaves[epoch][leafIndex]
+}
+
+// claimable checks that an epoch has a root and an open claim window.
+func (c *Chain) claimable(epoch uint64) error {
+	if _, exists := c.state.roots[epoch]; !exists {
+		return fmt.Errorf("%w: %d", ErrNotCommitted, epoch)
+	}
+	return c.inWindow(epoch)
+}
+
+// inWindow checks that an epoch's claim window is open. Epochs whose
+// receipt root is not committed have no window yet.
+func (c *Chain) inWindow(epoch uint64) error {
+	w, exists := c.state.windows[epoch]
+	if !exists {
+		return nil
+	}
+	if now := c.now(); now.Before(w.opens) || (!w.closes.IsZero() && !now.Before(w.closes)) {
+		return fmt.Errorf("%w: epoch %d", ErrWindowClosed, epoch)
+	}
+	return nil
+}
+
+func (c *Chain) claimLeaf(epoch, leafIndex uint64) {
+	if c.state.claimedLeaves[epoch] == nil {
+		c.state.claimedLeaves[epoch] = make(map[uint64]bool)
+	}
+	c.state.claimedLeaves[epoch][leafIndex] = true
+}
+
+// addToSet adds address to an epoch's set.
+func addToSet(sets map[uint64]map[string]bool, epoch uint64, address string) {
+	if sets[epoch] == nil {
+		sets[epoch] = make(map[string]bool)
+	}
+	sets[epoch][address] = true
+}
+
+func (c *Chain) credit(provider string, amount *big.Int) {
+	addTo(c.state.balances, provider, amount)
+}
+
+// addTo adds amount to an address's entry in balances, which may be
+// negative to subtract.
+func addTo(balances map[string]*big.Int, address string, amount *big.Int) {
+	if balance, exists := balances[address]; exists {
+		balances[address] = new(big.Int).Add(balance, amount)
+	} else {
+		balances[address] = new(big.Int).Set(amount)
+	}
+}
+
+// mine records a transaction in a new block and returns its hash.
+func (c *Chain) mine(desc, status string) string {
+	c.state.block++
+	txHash := generateTxHash(fmt.Sprintf("%d_%s", c.state.block, desc))
+	c.state.transactions[txHash] = status
+	return txHash
+}
+
+// emit appends an event, in the current block, to the log.
+func (c *Chain) emit(e Event) Event {
+	e.Block = c.state.block
+	c.state.events = append(c.state.events, e)
+	return e
+}
+
+// notify passes an event to the handlers. The caller must not hold mu.
+func (c *Chain) notify(e Event) {
+	c.mu.RLock()
+	handlers := append([]func(Event){}, c.handlers...)
+	c.mu.RUnlock()
+
+	for _, fn := range handlers {
+		fn(e)
+	}
+}
+
+// normalizeAddress lower-cases a hex address, so checksummed and plain
+// forms of it name the same account.
+func normalizeAddress(address string) (string, error) {
+	s := strings.TrimPrefix(address, "0x")
+	if b, err := hex.DecodeString(s); err != nil || len(b) != 20 {
+		return "", fmt.Errorf("invalid address %q", address)
+	}
+	return "0x" + strings.ToLower(s), nil
+}
+
+func normalizeHash(hash string) (string, error) {
+	s := strings.TrimPrefix(hash, "0x")
+	if b, err := hex.DecodeString(s); err != nil || len(b) != sha256.Size {
+		return "", fmt.Errorf("invalid hash %q", hash)
+	}
+	return "0x" + strings.ToLower(s), nil
 }
 
 func generateTxHash(data string) string {
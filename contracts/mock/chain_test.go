@@ -1,15 +1,60 @@
 package mock
 
 import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
+	"os"
+	"strings"
 	"testing"
+	"time"
 )
 
+// Chain stands in for the settlement contract behind the aggregator's
+// settlement.Backend.
+var _ interface {
+	SubmitClaim(ctx context.Context, epoch, leafIndex uint64, leafHash [32]byte, proof [][32]byte, provider string, amount *big.Int) (string, error)
+	TransactionStatus(ctx context.Context, txHash string) (string, error)
+} = (*Chain)(nil)
+
+// and behind the aggregator's registry.Backend.
+var _ interface {
+	StakeOf(ctx context.Context, key [32]byte) (string, *big.Int, time.Time, error)
+	ProviderModels(ctx context.Context, key [32]byte) ([]string, error)
+	StakeParams(ctx context.Context) (*big.Int, time.Duration, error)
+} = (*Chain)(nil)
+
+// and behind the aggregator's slashing.Backend.
+var _ interface {
+	OpenDispute(ctx context.Context, key [32]byte) (string, error)
+	CloseDispute(ctx context.Context, key [32]byte) (string, error)
+	Slash(ctx context.Context, key [32]byte, amount *big.Int, evidenceHash [32]byte) (string, error)
+	TransactionStatus(ctx context.Context, txHash string) (string, error)
+} = (*Chain)(nil)
+
+const (
+	providerA = "0x00000000000000000000000000000000000000aa"
+	providerB = "0x00000000000000000000000000000000000000BB"
+)
+
+// testTree returns the leaf hashes of a tree of r0, r1 and r2 and its root.
+func testTree() ([3][32]byte, string) {
+	var leaves [3][32]byte
+	for i := range leaves {
+		copy(leaves[i][:], LeafHash([]byte(fmt.Sprintf("r%d", i))))
+	}
+	root := nodeHash(nodeHash(leaves[0][:], leaves[1][:]), leaves[2][:])
+	return leaves, "0x" + hex.EncodeToString(root)
+}
+
 func TestChainCommitRoot(t *testing.T) {
 	chain := NewChain()
+	_, root := testTree()
 
-	txHash, err := chain.CommitRoot(1, "0xabc123")
+	txHash, err := chain.CommitRoot(1, root, 3)
 	if err != nil {
 		t.Fatalf("Failed to commit root: %v", err)
 	}
@@ -17,70 +62,427 @@ func TestChainCommitRoot(t *testing.T) {
 	if txHash == "" {
 		t.Fatal("Expected non-empty tx hash")
 	}
+	if got, err := chain.GetRoot(1); err != nil || got != root {
+		t.Fatalf("GetRoot = %q, %v", got, err)
+	}
 
 	// Try to commit same epoch again
-	_, err = chain.CommitRoot(1, "0xdef456")
-	if err == nil {
-		t.Fatal("Expected error when committing duplicate epoch")
+	_, err = chain.CommitRoot(1, root, 3)
+	if !errors.Is(err, ErrAlreadyCommitted) {
+		t.Fatalf("Expected ErrAlreadyCommitted, got %v", err)
+	}
+	if _, err := chain.CommitRoot(2, root, 0); err == nil {
+		t.Fatal("Expected error for an empty tree")
+	}
+	if _, err := chain.CommitRoot(2, "0xabc123", 3); err == nil {
+		t.Fatal("Expected error for a short root")
+	}
+	if _, err := chain.GetRoot(2); !errors.Is(err, ErrNotCommitted) {
+		t.Fatalf("Expected ErrNotCommitted, got %v", err)
+	}
+}
+
+func TestChainVerifyClaim(t *testing.T) {
+	chain := NewChain()
+	leaves, root := testTree()
+	proof := &Proof{LeafIndex: 1, TreeSize: 3, Hashes: []string{hex.EncodeToString(leaves[0][:]), hex.EncodeToString(leaves[2][:])}}
+
+	if _, err := chain.VerifyClaim(1, []byte("r1"), proof); !errors.Is(err, ErrNotCommitted) {
+		t.Fatalf("Expected ErrNotCommitted, got %v", err)
+	}
+	chain.CommitRoot(1, root, 3)
+
+	if valid, err := chain.VerifyClaim(1, []byte("r1"), proof); err != nil || !valid {
+		t.Fatalf("valid claim rejected: %v", err)
+	}
+	if valid, _ := chain.VerifyClaim(1, []byte("r0"), proof); valid {
+		t.Error("claim for another leaf accepted")
+	}
+	wrongSize := *proof
+	wrongSize.TreeSize = 4
+	if valid, _ := chain.VerifyClaim(1, []byte("r1"), &wrongSize); valid {
+		t.Error("claim against another tree size accepted")
+	}
+	if valid, _ := chain.VerifyClaim(1, []byte("r1"), &Proof{LeafIndex: 1, TreeSize: 3, Hashes: []string{"zz"}}); valid {
+		t.Error("claim with a malformed proof accepted")
 	}
 }
 
 func TestChainProcessClaim(t *testing.T) {
 	chain := NewChain()
+	leaves, root := testTree()
+	chain.CommitRoot(1, root, 3)
+
+	proof := &Multiproof{TreeSize: 3, LeafIndices: []int{0, 2}, Hashes: []string{hex.EncodeToString(leaves[1][:])}}
+	hashes := [][]byte{leaves[0][:], leaves[2][:]}
+
+	if _, err := chain.ProcessClaim(1, [][]byte{leaves[0][:], leaves[1][:]}, proof, providerA, big.NewInt(1000)); !errors.Is(err, ErrInvalidProof) {
+		t.Fatalf("Expected ErrInvalidProof, got %v", err)
+	}
+	if _, err := chain.ProcessClaim(1, hashes, proof, "default_provider", big.NewInt(1000)); err == nil {
+		t.Fatal("Expected error for an invalid provider address")
+	}
 
-	amount := big.NewInt(1000)
-	txHash, err := chain.ProcessClaim("claim1", amount)
+	txHash, err := chain.ProcessClaim(1, hashes, proof, providerA, big.NewInt(1000))
 	if err != nil {
 		t.Fatalf("Failed to process claim: %v", err)
 	}
+	if status, _ := chain.TransactionStatus(context.Background(), txHash); status != "confirmed" {
+		t.Fatalf("Expected confirmed, got %q", status)
+	}
+	if !chain.IsLeafClaimed(1, 0) || chain.IsLeafClaimed(1, 1) || !chain.IsLeafClaimed(1, 2) {
+		t.Fatal("Expected leaves 0 and 2 claimed")
+	}
 
-	if txHash == "" {
-		t.Fatal("Expected non-empty tx hash")
+	// Try to process a claimed leaf again, for anyone
+	single := &Multiproof{TreeSize: 3, LeafIndices: []int{2}, Hashes: []string{hex.EncodeToString(nodeHash(leaves[0][:], leaves[1][:]))}}
+	if _, err := chain.ProcessClaim(1, [][]byte{leaves[2][:]}, single, providerB, big.NewInt(1000)); !errors.Is(err, ErrAlreadyClaimed) {
+		t.Fatalf("Expected ErrAlreadyClaimed, got %v", err)
+	}
+	if _, err := chain.ProcessClaim(2, hashes, proof, providerA, big.NewInt(1000)); !errors.Is(err, ErrNotCommitted) {
+		t.Fatalf("Expected ErrNotCommitted, got %v", err)
 	}
+}
 
-	// Check if claim was processed
-	processed, err := chain.IsClaimProcessed("claim1")
-	if err != nil {
-		t.Fatalf("Failed to check claim status: %v", err)
+func TestChainBalance(t *testing.T) {
+	chain := NewChain()
+	leaves, root := testTree()
+	chain.CommitRoot(1, root, 3)
+
+	// Each leaf paid to the provider its claim names
+	proofs := []*Multiproof{
+		{TreeSize: 3, LeafIndices: []int{0}, Hashes: []string{hex.EncodeToString(leaves[1][:]), hex.EncodeToString(leaves[2][:])}},
+		{TreeSize: 3, LeafIndices: []int{1}, Hashes: []string{hex.EncodeToString(leaves[0][:]), hex.EncodeToString(leaves[2][:])}},
+		{TreeSize: 3, LeafIndices: []int{2}, Hashes: []string{hex.EncodeToString(nodeHash(leaves[0][:], leaves[1][:]))}},
+	}
+	providers := []string{providerA, providerB, strings.ToLower(providerB)}
+	for i, proof := range proofs {
+		if _, err := chain.ProcessClaim(1, [][]byte{leaves[i][:]}, proof, providers[i], big.NewInt(int64(1000*(i+1)))); err != nil {
+			t.Fatalf("Failed to process claim %d: %v", i, err)
+		}
 	}
 
-	if !processed {
-		t.Fatal("Expected claim to be processed")
+	for provider, want := range map[string]int64{providerA: 1000, providerB: 5000} {
+		balance, err := chain.GetBalance(provider)
+		if err != nil {
+			t.Fatalf("Failed to get balance: %v", err)
+		}
+		if balance.Cmp(big.NewInt(want)) != 0 {
+			t.Fatalf("Expected balance %d for %s, got %s", want, provider, balance)
+		}
 	}
 
-	// Try to process same claim again
-	_, err = chain.ProcessClaim("claim1", amount)
-	if err == nil {
-		t.Fatal("Expected error when processing duplicate claim")
+	_, paid, err := chain.Withdraw(providerB)
+	if err != nil || paid.Cmp(big.NewInt(5000)) != 0 {
+		t.Fatalf("Withdraw = %v, %v", paid, err)
+	}
+	if balance, _ := chain.GetBalance(providerB); balance.Sign() != 0 {
+		t.Fatalf("Expected no balance after withdrawing, got %s", balance)
+	}
+	if tokens, _ := chain.TokenBalance(providerB); tokens.Cmp(big.NewInt(5000)) != 0 {
+		t.Fatalf("Expected 5000 tokens withdrawn, got %s", tokens)
+	}
+	if _, _, err := chain.Withdraw(providerB); !errors.Is(err, ErrNoBalance) {
+		t.Fatalf("Expected ErrNoBalance, got %v", err)
 	}
 }
 
-func TestChainBalance(t *testing.T) {
+func TestChainVerifyBatchClaim(t *testing.T) {
 	chain := NewChain()
 
-	// Process multiple claims
-	amounts := []*big.Int{
-		big.NewInt(1000),
-		big.NewInt(2000),
-		big.NewInt(3000),
+	leaves := [][]byte{LeafHash([]byte("r0")), LeafHash([]byte("r1")), LeafHash([]byte("r2"))}
+	root := nodeHash(nodeHash(leaves[0], leaves[1]), leaves[2])
+	proof := &Multiproof{TreeSize: 3, LeafIndices: []int{0, 2}, Hashes: []string{hex.EncodeToString(leaves[1])}}
+
+	if _, err := chain.VerifyBatchClaim(1, leaves, proof); err == nil {
+		t.Fatal("verified against an uncommitted epoch")
+	}
+	chain.CommitRoot(1, "0x"+hex.EncodeToString(root), 3)
+
+	valid, err := chain.VerifyBatchClaim(1, [][]byte{leaves[0], leaves[2]}, proof)
+	if err != nil || !valid {
+		t.Fatalf("batch claim rejected: %v", err)
+	}
+	if valid, _ := chain.VerifyBatchClaim(1, [][]byte{leaves[0], leaves[1]}, proof); valid {
+		t.Error("batch claim with a wrong leaf accepted")
 	}
+}
+
+func TestChainSubmitClaim(t *testing.T) {
+	chain := NewChain()
+	ctx := context.Background()
+	leaves, root := testTree()
+	proof := [][32]byte{leaves[0], leaves[2]}
 
-	for i, amount := range amounts {
-		claimID := fmt.Sprintf("claim%d", i)
-		_, err := chain.ProcessClaim(claimID, amount)
+	submit := func(leafIndex uint64, proof [][32]byte) (string, string) {
+		t.Helper()
+		txHash, err := chain.SubmitClaim(ctx, 1, leafIndex, leaves[1], proof, providerA, big.NewInt(1500))
+		if err != nil {
+			t.Fatalf("Failed to submit claim: %v", err)
+		}
+		status, err := chain.TransactionStatus(ctx, txHash)
 		if err != nil {
-			t.Fatalf("Failed to process claim %s: %v", claimID, err)
+			t.Fatal(err)
 		}
+		return txHash, status
+	}
+
+	// Claims against an uncommitted epoch fail on chain
+	if _, status := submit(1, proof); status != "failed" {
+		t.Fatalf("Expected failed before the root is committed, got %q", status)
+	}
+	chain.CommitRoot(1, root, 3)
+
+	if _, status := submit(0, proof); status != "failed" {
+		t.Fatalf("Expected failed at the wrong index, got %q", status)
+	}
+	txHash, status := submit(1, proof)
+	if status != "confirmed" {
+		t.Fatalf("Expected confirmed, got %q", status)
+	}
+	if balance, _ := chain.GetBalance(providerA); balance.Cmp(big.NewInt(1500)) != 0 {
+		t.Fatalf("Expected balance 1500, got %s", balance)
+	}
+
+	// Paying the same leaf again fails on chain
+	again, status := submit(1, proof)
+	if again == txHash {
+		t.Fatal("Expected a new transaction hash")
+	}
+	if status != "failed" {
+		t.Fatalf("Expected failed, got %q", status)
+	}
+	if balance, _ := chain.GetBalance(providerA); balance.Cmp(big.NewInt(1500)) != 0 {
+		t.Fatalf("Expected balance 1500, got %s", balance)
 	}
 
-	// Check balance
-	balance, err := chain.GetBalance("default_provider")
+	if _, err := chain.SubmitClaim(ctx, 1, 1, leaves[1], proof, "0xaa", big.NewInt(1)); err == nil {
+		t.Fatal("Expected error for an invalid provider address")
+	}
+	if _, err := chain.TransactionStatus(ctx, "0xunknown"); err == nil {
+		t.Fatal("Expected error for unknown transaction")
+	}
+}
+
+func TestChainClaimWindow(t *testing.T) {
+	chain := NewChain()
+	ctx := context.Background()
+	leaves, root := testTree()
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	chain.SetClock(func() time.Time { return now })
+	chain.SetClaimWindow(time.Hour)
+	chain.CommitRoot(1, root, 3)
+
+	opens, closes, err := chain.ClaimWindow(1)
+	if err != nil || !opens.Equal(now) || !closes.Equal(now.Add(time.Hour)) {
+		t.Fatalf("ClaimWindow = %v, %v, %v", opens, closes, err)
+	}
+
+	now = now.Add(59 * time.Minute)
+	txHash, _ := chain.SubmitClaim(ctx, 1, 0, leaves[0], [][32]byte{leaves[1], leaves[2]}, providerA, big.NewInt(1))
+	if status, _ := chain.TransactionStatus(ctx, txHash); status != "confirmed" {
+		t.Fatalf("Expected confirmed inside the window, got %q", status)
+	}
+
+	now = now.Add(time.Minute)
+	txHash, _ = chain.SubmitClaim(ctx, 1, 1, leaves[1], [][32]byte{leaves[0], leaves[2]}, providerA, big.NewInt(1))
+	if status, _ := chain.TransactionStatus(ctx, txHash); status != "failed" {
+		t.Fatalf("Expected failed once the window closed, got %q", status)
+	}
+	proof := &Multiproof{TreeSize: 3, LeafIndices: []int{1}, Hashes: []string{hex.EncodeToString(leaves[0][:]), hex.EncodeToString(leaves[2][:])}}
+	if _, err := chain.ProcessClaim(1, [][]byte{leaves[1][:]}, proof, providerA, big.NewInt(1)); !errors.Is(err, ErrWindowClosed) {
+		t.Fatalf("Expected ErrWindowClosed, got %v", err)
+	}
+
+	// A later epoch gets its own window
+	chain.CommitRoot(2, root, 3)
+	txHash, _ = chain.SubmitClaim(ctx, 2, 1, leaves[1], [][32]byte{leaves[0], leaves[2]}, providerA, big.NewInt(1))
+	if status, _ := chain.TransactionStatus(ctx, txHash); status != "confirmed" {
+		t.Fatalf("Expected confirmed in epoch 2's window, got %q", status)
+	}
+}
+
+func TestChainEvents(t *testing.T) {
+	chain := NewChain()
+	ctx := context.Background()
+	leaves, root := testTree()
+
+	var seen []Event
+	var balances []string
+	chain.OnEvent(func(e Event) {
+		seen = append(seen, e)
+		// Handlers may call back into the chain
+		balance, _ := chain.GetBalance(providerA)
+		balances = append(balances, balance.String())
+	})
+
+	commitTx, _ := chain.CommitRoot(1, root, 3)
+	claimTx, _ := chain.SubmitClaim(ctx, 1, 1, leaves[1], [][32]byte{leaves[0], leaves[2]}, providerA, big.NewInt(1500))
+	chain.SubmitClaim(ctx, 1, 1, leaves[1], [][32]byte{leaves[0], leaves[2]}, providerA, big.NewInt(1500)) // fails, no event
+	withdrawTx, _, _ := chain.Withdraw(providerA)
+
+	want := []Event{
+		{Name: EventRootCommitted, Block: 1, TxHash: commitTx, Epoch: 1, Root: root, TreeSize: 3},
+		{Name: EventReceiptProcessed, Block: 2, TxHash: claimTx, Epoch: 1, LeafIndex: 1, Provider: providerA, Amount: big.NewInt(1500)},
+		{Name: EventWithdrawn, Block: 4, TxHash: withdrawTx, Provider: providerA, Amount: big.NewInt(1500)},
+	}
+	for _, log := range [][]Event{seen, chain.Events()} {
+		if len(log) != len(want) {
+			t.Fatalf("Expected %d events, got %+v", len(want), log)
+		}
+		for i := range want {
+			got := log[i]
+			if got.Amount != nil && want[i].Amount != nil && got.Amount.Cmp(want[i].Amount) == 0 {
+				got.Amount = want[i].Amount
+			}
+			if fmt.Sprintf("%+v", got) != fmt.Sprintf("%+v", want[i]) {
+				t.Fatalf("Event %d: got %+v, want %+v", i, got, want[i])
+			}
+		}
+	}
+	if strings.Join(balances, ",") != "0,1500,0" {
+		t.Fatalf("Balances seen by handlers: %v", balances)
+	}
+}
+
+func TestChainSnapshotRevert(t *testing.T) {
+	chain := NewChain()
+	ctx := context.Background()
+	leaves, root := testTree()
+	chain.CommitRoot(1, root, 3)
+
+	snapshot := chain.Snapshot()
+	txHash, _ := chain.SubmitClaim(ctx, 1, 1, leaves[1], [][32]byte{leaves[0], leaves[2]}, providerA, big.NewInt(1500))
+	chain.CommitRoot(2, root, 3)
+	inner := chain.Snapshot()
+	chain.Withdraw(providerA)
+
+	if err := chain.Revert(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if chain.IsLeafClaimed(1, 1) {
+		t.Error("claimed leaf survived the revert")
+	}
+	if balance, _ := chain.GetBalance(providerA); balance.Sign() != 0 {
+		t.Errorf("Expected no balance after the revert, got %s", balance)
+	}
+	if _, err := chain.GetRoot(2); err == nil {
+		t.Error("root committed after the snapshot survived the revert")
+	}
+	if _, err := chain.TransactionStatus(ctx, txHash); err == nil {
+		t.Error("transaction survived the revert")
+	}
+	if events := chain.Events(); len(events) != 1 || events[0].Name != EventRootCommitted {
+		t.Errorf("Expected only the first commit in the log, got %+v", events)
+	}
+	if err := chain.Revert(inner); err == nil {
+		t.Error("reverted to a snapshot taken after the one reverted to")
+	}
+	if err := chain.Revert(snapshot); err == nil {
+		t.Error("reverted to a used snapshot")
+	}
+
+	// The claim can be paid again after the revert
+	txHash, _ = chain.SubmitClaim(ctx, 1, 1, leaves[1], [][32]byte{leaves[0], leaves[2]}, providerA, big.NewInt(1500))
+	if status, _ := chain.TransactionStatus(ctx, txHash); status != "confirmed" {
+		t.Fatalf("Expected confirmed, got %q", status)
+	}
+}
+
+// The payout vectors are shared with aggregator/pkg/payout, which builds the
+// payout trees the aggregator anchors.
+func TestChainPayouts(t *testing.T) {
+	data, err := os.ReadFile("../../testvectors/payout.json")
 	if err != nil {
-		t.Fatalf("Failed to get balance: %v", err)
+		t.Fatal(err)
+	}
+	var vectors struct {
+		Leaves []struct {
+			Leaf struct {
+				Address      string `json:"address"`
+				Amount       string `json:"amount"`
+				ReceiptCount uint64 `json:"receipt_count"`
+				ReceiptsRoot string `json:"receipts_root"`
+			} `json:"leaf"`
+			LeafHash string `json:"leaf_hash"`
+		} `json:"leaves"`
+		Root string `json:"root"`
+	}
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+	if len(vectors.Leaves) != 4 {
+		t.Fatalf("Expected 4 payout leaves, got %d", len(vectors.Leaves))
+	}
+	// proof returns the inclusion proof of leaf i of the four-leaf tree
+	proof := func(i int) *Proof {
+		sibling := vectors.Leaves[i^1].LeafHash
+		other := 2 - i&2
+		left, _ := hex.DecodeString(vectors.Leaves[other].LeafHash)
+		right, _ := hex.DecodeString(vectors.Leaves[other+1].LeafHash)
+		return &Proof{LeafIndex: i, TreeSize: 4, Hashes: []string{sibling, hex.EncodeToString(nodeHash(left, right))}}
+	}
+	chain := NewChain()
+	ctx := context.Background()
+	claim := func(i int, amount string) (string, error) {
+		leaf := vectors.Leaves[i].Leaf
+		a, _ := new(big.Int).SetString(amount, 10)
+		return chain.ClaimPayout(1, leaf.Address, a, leaf.ReceiptCount, leaf.ReceiptsRoot, proof(i))
+	}
+	amount := vectors.Leaves[1].Leaf.Amount
+
+	if _, err := claim(1, amount); !errors.Is(err, ErrNotCommitted) {
+		t.Fatalf("Expected ErrNotCommitted, got %v", err)
+	}
+	leaves, root := testTree()
+	chain.CommitRoot(1, root, 3)
+	if _, err := chain.CommitPayoutRoot(1, vectors.Root, 4); err != nil {
+		t.Fatalf("Failed to commit payout root: %v", err)
+	}
+	if _, err := chain.CommitPayoutRoot(1, vectors.Root, 4); !errors.Is(err, ErrAlreadyCommitted) {
+		t.Fatalf("Expected ErrAlreadyCommitted, got %v", err)
+	}
+	if got, err := chain.GetPayoutRoot(1); err != nil || got != "0x"+vectors.Root {
+		t.Fatalf("GetPayoutRoot = %q, %v", got, err)
+	}
+
+	if _, err := claim(1, "123401"); !errors.Is(err, ErrInvalidProof) {
+		t.Fatalf("Expected ErrInvalidProof for another amount, got %v", err)
+	}
+	if _, err := claim(1, amount); err != nil {
+		t.Fatalf("Failed to claim payout: %v", err)
+	}
+	if balance, _ := chain.GetBalance(vectors.Leaves[1].Leaf.Address); balance.String() != amount {
+		t.Fatalf("Expected balance %s, got %s", amount, balance)
+	}
+	if _, err := claim(1, amount); !errors.Is(err, ErrPayoutClaimed) {
+		t.Fatalf("Expected ErrPayoutClaimed, got %v", err)
+	}
+
+	// An address paid by its payout leaf is not also paid per receipt
+	payee := vectors.Leaves[1].Leaf.Address
+	txHash, _ := chain.SubmitClaim(ctx, 1, 1, leaves[1], [][32]byte{leaves[0], leaves[2]}, payee, big.NewInt(1))
+	if status, _ := chain.TransactionStatus(ctx, txHash); status != "failed" {
+		t.Fatalf("Expected failed per-receipt claim after the payout, got %q", status)
+	}
+	multiproof := &Multiproof{TreeSize: 3, LeafIndices: []int{1}, Hashes: []string{hex.EncodeToString(leaves[0][:]), hex.EncodeToString(leaves[2][:])}}
+	if _, err := chain.ProcessClaim(1, [][]byte{leaves[1][:]}, multiproof, payee, big.NewInt(1)); !errors.Is(err, ErrPayoutClaimed) {
+		t.Fatalf("Expected ErrPayoutClaimed, got %v", err)
+	}
+
+	// and the reverse
+	paid := vectors.Leaves[2].Leaf
+	txHash, _ = chain.SubmitClaim(ctx, 1, 1, leaves[1], [][32]byte{leaves[0], leaves[2]}, paid.Address, big.NewInt(1))
+	if status, _ := chain.TransactionStatus(ctx, txHash); status != "confirmed" {
+		t.Fatalf("Expected confirmed per-receipt claim, got %q", status)
+	}
+	if _, err := claim(2, paid.Amount); !errors.Is(err, ErrReceiptsPaid) {
+		t.Fatalf("Expected ErrReceiptsPaid, got %v", err)
 	}
 
-	expectedBalance := big.NewInt(6000)
-	if balance.Cmp(expectedBalance) != 0 {
-		t.Fatalf("Expected balance %s, got %s", expectedBalance, balance)
+	if events := chain.Events(); events[1].Name != EventPayoutRootCommitted || events[2].Name != EventPayoutClaimed ||
+		events[2].ReceiptCount != vectors.Leaves[1].Leaf.ReceiptCount || events[2].Amount.String() != amount {
+		t.Fatalf("Unexpected events %+v", events)
 	}
 }
@@ -0,0 +1,145 @@
+package mock
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/bits"
+	"strings"
+)
+
+// Multiproof is a proof for several leaves of an RFC 6962 Merkle tree, in
+// the encoding aggregator/pkg/merkle produces: Hashes are the roots of the
+// subtrees holding none of the proven leaves, in depth-first, left-to-right
+// order.
+type Multiproof struct {
+	TreeSize    int      `json:"tree_size"`
+	LeafIndices []int    `json:"leaf_indices"`
+	Hashes      []string `json:"hashes"`
+}
+
+// LeafHash returns the RFC 6962 hash of a leaf's data.
+func LeafHash(data []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{0x00})
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func nodeHash(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{0x01})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// verifyInclusion checks an inclusion proof of leaf at index in the tree of
+// treeSize leaves with root rootHex, as RFC6962.sol does. proof runs from
+// the leaf's sibling up.
+func verifyInclusion(leaf [32]byte, index, treeSize uint64, proof [][32]byte, rootHex string) bool {
+	if index >= treeSize {
+		return false
+	}
+	root, err := hex.DecodeString(strings.TrimPrefix(rootHex, "0x"))
+	if err != nil {
+		return false
+	}
+	fn, sn := index, treeSize-1
+	r := leaf[:]
+	for _, p := range proof {
+		if sn == 0 {
+			return false
+		}
+		if fn&1 == 1 || fn == sn {
+			r = nodeHash(p[:], r)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			r = nodeHash(r, p[:])
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	return sn == 0 && bytes.Equal(r, root)
+}
+
+// decodeHashes decodes hex SHA-256 hashes.
+func decodeHashes(hashes []string) ([][32]byte, bool) {
+	out := make([][32]byte, len(hashes))
+	for i, s := range hashes {
+		h, err := hex.DecodeString(s)
+		if err != nil || len(h) != sha256.Size {
+			return nil, false
+		}
+		copy(out[i][:], h)
+	}
+	return out, true
+}
+
+// verifyMultiproof checks that leafHashes, in proof.LeafIndices order, are
+// leaves of the tree with root rootHex, as RFC6962.sol does.
+func verifyMultiproof(leafHashes [][]byte, proof *Multiproof, rootHex string) bool {
+	if proof == nil || proof.TreeSize < 1 || len(leafHashes) == 0 || len(leafHashes) != len(proof.LeafIndices) {
+		return false
+	}
+	for i, index := range proof.LeafIndices {
+		if index < 0 || index >= proof.TreeSize || (i > 0 && index <= proof.LeafIndices[i-1]) {
+			return false
+		}
+	}
+	root, err := hex.DecodeString(strings.TrimPrefix(rootHex, "0x"))
+	if err != nil {
+		return false
+	}
+	path := make([][]byte, len(proof.Hashes))
+	for i, s := range proof.Hashes {
+		h, err := hex.DecodeString(s)
+		if err != nil || len(h) != sha256.Size {
+			return false
+		}
+		path[i] = h
+	}
+
+	v := &multiproofVerifier{indices: proof.LeafIndices, leaves: leafHashes, path: path}
+	computed, ok := v.root(0, proof.TreeSize, 0, len(leafHashes))
+	return ok && v.next == len(path) && bytes.Equal(computed, root)
+}
+
+type multiproofVerifier struct {
+	indices []int
+	leaves  [][]byte
+	path    [][]byte
+	next    int
+}
+
+// root rebuilds the hash of leaves [lo, hi), which hold the proven leaves
+// from to to.
+func (v *multiproofVerifier) root(lo, hi, from, to int) ([]byte, bool) {
+	if from == to {
+		if v.next >= len(v.path) {
+			return nil, false
+		}
+		v.next++
+		return v.path[v.next-1], true
+	}
+	if hi-lo == 1 {
+		return v.leaves[from], true
+	}
+	k := 1 << (bits.Len(uint(hi-lo-1)) - 1)
+	mid := from
+	for mid < to && v.indices[mid] < lo+k {
+		mid++
+	}
+	left, ok := v.root(lo, lo+k, from, mid)
+	if !ok {
+		return nil, false
+	}
+	right, ok := v.root(lo+k, hi, mid, to)
+	if !ok {
+		return nil, false
+	}
+	return nodeHash(left, right), true
+}
@@ -0,0 +1,107 @@
+package mock
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+)
+
+// The vectors are shared with aggregator/pkg/merkle, which generates the
+// proofs this package verifies.
+func TestMultiproofVectors(t *testing.T) {
+	data, err := os.ReadFile("../../testvectors/merkle.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors struct {
+		Leaves []string `json:"leaves"`
+		Roots  []struct {
+			TreeSize int    `json:"tree_size"`
+			Root     string `json:"root"`
+		} `json:"roots"`
+		Multiproof []Multiproof `json:"multiproof"`
+	}
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+	roots := make(map[int]string)
+	for _, r := range vectors.Roots {
+		roots[r.TreeSize] = r.Root
+	}
+
+	for _, v := range vectors.Multiproof {
+		proof := v
+		leaves := make([][]byte, len(v.LeafIndices))
+		for i, index := range v.LeafIndices {
+			leaf, err := hex.DecodeString(vectors.Leaves[index])
+			if err != nil {
+				t.Fatal(err)
+			}
+			leaves[i] = LeafHash(leaf)
+		}
+		name := fmt.Sprintf("%v/%d", v.LeafIndices, v.TreeSize)
+		if !verifyMultiproof(leaves, &proof, roots[v.TreeSize]) {
+			t.Errorf("%s does not verify", name)
+		}
+		if len(leaves) > 1 && verifyMultiproof(leaves[1:], &proof, roots[v.TreeSize]) {
+			t.Errorf("%s verifies with a leaf missing", name)
+		}
+		if len(v.Hashes) > 0 {
+			short := proof
+			short.Hashes = v.Hashes[1:]
+			if verifyMultiproof(leaves, &short, roots[v.TreeSize]) {
+				t.Errorf("%s verifies with a hash missing", name)
+			}
+		}
+	}
+}
+
+// The settlement contract's processReceipt, which SubmitClaim mirrors, takes
+// the inclusion proofs the aggregator serves.
+func TestInclusionVectors(t *testing.T) {
+	data, err := os.ReadFile("../../testvectors/merkle.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors struct {
+		Leaves []string `json:"leaves"`
+		Roots  []struct {
+			TreeSize int    `json:"tree_size"`
+			Root     string `json:"root"`
+		} `json:"roots"`
+		Inclusion []Proof `json:"inclusion"`
+	}
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+	roots := make(map[int]string)
+	for _, r := range vectors.Roots {
+		roots[r.TreeSize] = r.Root
+	}
+
+	for _, v := range vectors.Inclusion {
+		data, err := hex.DecodeString(vectors.Leaves[v.LeafIndex])
+		if err != nil {
+			t.Fatal(err)
+		}
+		var leaf [32]byte
+		copy(leaf[:], LeafHash(data))
+		path, ok := decodeHashes(v.Hashes)
+		if !ok {
+			t.Fatalf("bad hashes in %+v", v)
+		}
+		name := fmt.Sprintf("%d/%d", v.LeafIndex, v.TreeSize)
+		index, size := uint64(v.LeafIndex), uint64(v.TreeSize)
+		if !verifyInclusion(leaf, index, size, path, roots[v.TreeSize]) {
+			t.Errorf("%s does not verify", name)
+		}
+		if size > 1 && verifyInclusion(leaf, (index+1)%size, size, path, roots[v.TreeSize]) {
+			t.Errorf("%s verifies at another index", name)
+		}
+		if len(path) > 0 && verifyInclusion(leaf, index, size, path[1:], roots[v.TreeSize]) {
+			t.Errorf("%s verifies with a hash missing", name)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package mock
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// stake mirrors the settlement contract's ProviderStake.
+type stake struct {
+	owner        string
+	amount       *big.Int
+	unbondingAt  time.Time
+	openDisputes uint64
+	models       []string
+}
+
+func (s *stake) clone() *stake {
+	c := *s
+	c.amount = new(big.Int).Set(s.amount)
+	c.models = append([]string(nil), s.models...)
+	return &c
+}
+
+// Mint gives an address tokens, as a transfer from the token's deployer
+// would.
+func (c *Chain) Mint(address string, amount *big.Int) error {
+	address, err := normalizeAddress(address)
+	if err != nil {
+		return err
+	}
+	if amount == nil || amount.Sign() <= 0 {
+		return fmt.Errorf("invalid amount")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	addTo(c.state.tokens, address, amount)
+	return nil
+}
+
+// SetStakeParams sets the minimum stake and how long an unbonding stake
+// stays locked.
+func (c *Chain) SetStakeParams(minStake *big.Int, unbondingPeriod time.Duration) (string, error) {
+	if minStake == nil || minStake.Sign() < 0 || unbondingPeriod < 0 {
+		return "", fmt.Errorf("invalid stake parameters")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.state.minStake = new(big.Int).Set(minStake)
+	c.state.unbondingPeriod = unbondingPeriod
+	return c.mine(fmt.Sprintf("stake_params_%s_%s", minStake, unbondingPeriod), "confirmed"), nil
+}
+
+// Stake adds amount of owner's tokens to the stake bound to a provider key
+// and replaces the models it declares. The first address to stake a key
+// owns its stake.
+func (c *Chain) Stake(owner string, key [32]byte, amount *big.Int, models []string) (string, error) {
+	owner, err := normalizeAddress(owner)
+	if err != nil {
+		return "", err
+	}
+	if amount == nil || amount.Sign() < 0 {
+		return "", fmt.Errorf("invalid amount")
+	}
+
+	c.mu.Lock()
+	id := hex.EncodeToString(key[:])
+	s, exists := c.state.stakes[id]
+	if !exists {
+		s = &stake{owner: owner, amount: new(big.Int)}
+	}
+	total := new(big.Int).Add(s.amount, amount)
+	tokens := c.state.tokens[owner]
+	switch {
+	case s.owner != owner:
+		c.mu.Unlock()
+		return "", fmt.Errorf("%w: key staked by %s", ErrNotStakeOwner, s.owner)
+	case !s.unbondingAt.IsZero():
+		c.mu.Unlock()
+		return "", ErrUnbonding
+	case total.Sign() == 0 || total.Cmp(c.state.minStake) < 0:
+		c.mu.Unlock()
+		return "", fmt.Errorf("%w: %s", ErrBelowMinStake, total)
+	case amount.Sign() > 0 && (tokens == nil || tokens.Cmp(amount) < 0):
+		c.mu.Unlock()
+		return "", fmt.Errorf("%w: %s has too few tokens", ErrNoBalance, owner)
+	}
+
+	addTo(c.state.tokens, owner, new(big.Int).Neg(amount))
+	s.amount = total
+	s.models = append([]string(nil), models...)
+	c.state.stakes[id] = s
+	txHash := c.mine(fmt.Sprintf("stake_%s_%s_%s", id, owner, amount), "confirmed")
+	event := c.emit(Event{
+		Name:     EventStaked,
+		TxHash:   txHash,
+		Key:      id,
+		Provider: owner,
+		Amount:   new(big.Int).Set(total),
+		Models:   append([]string(nil), models...),
+	})
+	c.mu.Unlock()
+
+	c.notify(event)
+	return txHash, nil
+}
+
+// RequestUnbond starts unbonding a stake. It can be withdrawn once the
+// unbonding period has passed and no dispute is open.
+func (c *Chain) RequestUnbond(owner string, key [32]byte) (string, error) {
+	c.mu.Lock()
+	id, s, err := c.ownedStake(owner, key)
+	if err == nil && !s.unbondingAt.IsZero() {
+		err = ErrUnbonding
+	}
+	if err != nil {
+		c.mu.Unlock()
+		return "", err
+	}
+
+	// The contract counts whole seconds of block time
+	s.unbondingAt = c.now().Truncate(time.Second).Add(c.state.unbondingPeriod)
+	txHash := c.mine(fmt.Sprintf("unbond_%s", id), "confirmed")
+	event := c.emit(Event{Name: EventUnbondRequested, TxHash: txHash, Key: id, UnbondingAt: s.unbondingAt})
+	c.mu.Unlock()
+
+	c.notify(event)
+	return txHash, nil
+}
+
+// WithdrawStake returns an unbonded stake to its owner and returns the
+// amount.
+func (c *Chain) WithdrawStake(owner string, key [32]byte) (string, *big.Int, error) {
+	c.mu.Lock()
+	id, s, err := c.ownedStake(owner, key)
+	switch {
+	case err != nil:
+	case s.unbondingAt.IsZero() || c.now().Before(s.unbondingAt):
+		err = ErrStakeLocked
+	case s.openDisputes > 0:
+		err = fmt.Errorf("%w: %d", ErrDisputeOpen, s.openDisputes)
+	}
+	if err != nil {
+		c.mu.Unlock()
+		return "", nil, err
+	}
+
+	delete(c.state.stakes, id)
+	addTo(c.state.tokens, s.owner, s.amount)
+	txHash := c.mine(fmt.Sprintf("withdraw_stake_%s", id), "confirmed")
+	event := c.emit(Event{Name: EventStakeWithdrawn, TxHash: txHash, Key: id, Provider: s.owner, Amount: new(big.Int).Set(s.amount)})
+	c.mu.Unlock()
+
+	c.notify(event)
+	return txHash, new(big.Int).Set(s.amount), nil
+}
+
+// OpenDispute holds a staked key's stake until CloseDispute is called as
+// many times.
+func (c *Chain) OpenDispute(ctx context.Context, key [32]byte) (string, error) {
+	return c.dispute(key, EventDisputeOpened)
+}
+
+func (c *Chain) CloseDispute(ctx context.Context, key [32]byte) (string, error) {
+	return c.dispute(key, EventDisputeClosed)
+}
+
+func (c *Chain) dispute(key [32]byte, name string) (string, error) {
+	c.mu.Lock()
+	id := hex.EncodeToString(key[:])
+	s, exists := c.state.stakes[id]
+	switch {
+	case !exists:
+		c.mu.Unlock()
+		return "", ErrNotStaked
+	case name == EventDisputeOpened:
+		s.openDisputes++
+	case s.openDisputes == 0:
+		c.mu.Unlock()
+		return "", fmt.Errorf("no open dispute")
+	default:
+		s.openDisputes--
+	}
+	txHash := c.mine(fmt.Sprintf("%s_%s_%d", name, id, s.openDisputes), "confirmed")
+	event := c.emit(Event{Name: name, TxHash: txHash, Key: id, OpenDisputes: s.openDisputes})
+	c.mu.Unlock()
+
+	c.notify(event)
+	return txHash, nil
+}
+
+// Slash takes up to amount from the stake bound to a provider key as the
+// penalty for the evidence hashed as evidenceHash. The contract sends the
+// tokens to its owner; the mock has no owner, so they leave circulation.
+func (c *Chain) Slash(ctx context.Context, key [32]byte, amount *big.Int, evidenceHash [32]byte) (string, error) {
+	if amount == nil || amount.Sign() < 0 {
+		return "", fmt.Errorf("invalid amount")
+	}
+
+	c.mu.Lock()
+	id := hex.EncodeToString(key[:])
+	evidence := hex.EncodeToString(evidenceHash[:])
+	s, exists := c.state.stakes[id]
+	switch {
+	case !exists || s.amount.Sign() == 0:
+		c.mu.Unlock()
+		return "", ErrNotStaked
+	case c.state.slashedEvidence[evidence]:
+		c.mu.Unlock()
+		return "", fmt.Errorf("%w: %s", ErrAlreadySlashed, evidence)
+	}
+
+	taken := new(big.Int).Set(amount)
+	if taken.Cmp(s.amount) > 0 {
+		taken.Set(s.amount)
+	}
+	c.state.slashedEvidence[evidence] = true
+	s.amount = new(big.Int).Sub(s.amount, taken)
+	txHash := c.mine(fmt.Sprintf("slash_%s_%s", id, evidence), "confirmed")
+	event := c.emit(Event{Name: EventSlashed, TxHash: txHash, Key: id, Amount: taken, EvidenceHash: evidence})
+	c.mu.Unlock()
+
+	c.notify(event)
+	return txHash, nil
+}
+
+// StakeOf returns the stake bound to a provider key: the address that owns
+// it, the amount and, once the owner has asked to unbond, when it can be
+// withdrawn. owner is empty if the key has no stake.
+func (c *Chain) StakeOf(ctx context.Context, key [32]byte) (string, *big.Int, time.Time, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	s, exists := c.state.stakes[hex.EncodeToString(key[:])]
+	if !exists {
+		return "", new(big.Int), time.Time{}, nil
+	}
+	return s.owner, new(big.Int).Set(s.amount), s.unbondingAt, nil
+}
+
+// ProviderModels returns the models a staked provider key declared.
+func (c *Chain) ProviderModels(ctx context.Context, key [32]byte) ([]string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if s, exists := c.state.stakes[hex.EncodeToString(key[:])]; exists {
+		return append([]string(nil), s.models...), nil
+	}
+	return nil, nil
+}
+
+// StakeParams returns the minimum stake and the unbonding period.
+func (c *Chain) StakeParams(ctx context.Context) (*big.Int, time.Duration, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return new(big.Int).Set(c.state.minStake), c.state.unbondingPeriod, nil
+}
+
+// OpenDisputes returns how many disputes are open against a provider key.
+func (c *Chain) OpenDisputes(key [32]byte) uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if s, exists := c.state.stakes[hex.EncodeToString(key[:])]; exists {
+		return s.openDisputes
+	}
+	return 0
+}
+
+// ownedStake returns the stake bound to key if owner owns it. The caller
+// must hold mu.
+func (c *Chain) ownedStake(owner string, key [32]byte) (string, *stake, error) {
+	owner, err := normalizeAddress(owner)
+	if err != nil {
+		return "", nil, err
+	}
+	id := hex.EncodeToString(key[:])
+	s, exists := c.state.stakes[id]
+	if !exists {
+		return "", nil, ErrNotStaked
+	}
+	if s.owner != owner {
+		return "", nil, ErrNotStakeOwner
+	}
+	return id, s, nil
+}
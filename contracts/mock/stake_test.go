@@ -0,0 +1,151 @@
+package mock
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestChainStaking(t *testing.T) {
+	chain := NewChain()
+	now := time.Unix(1_700_000_000, 0)
+	chain.SetClock(func() time.Time { return now })
+	ctx := context.Background()
+	key := [32]byte{7}
+
+	if _, err := chain.SetStakeParams(big.NewInt(1000), time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := chain.Stake(providerA, key, big.NewInt(1000), nil); !errors.Is(err, ErrNoBalance) {
+		t.Fatalf("staked without tokens: %v", err)
+	}
+	if err := chain.Mint(providerA, big.NewInt(1500)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := chain.Stake(providerA, key, big.NewInt(999), nil); !errors.Is(err, ErrBelowMinStake) {
+		t.Fatalf("staked below the minimum: %v", err)
+	}
+	if _, err := chain.Stake(providerA, key, big.NewInt(1200), []string{"llama3.2:3b"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.Mint(providerB, big.NewInt(1500)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := chain.Stake(providerB, key, big.NewInt(1000), nil); !errors.Is(err, ErrNotStakeOwner) {
+		t.Fatalf("another address staked the key: %v", err)
+	}
+
+	// Topping up keeps the stake and replaces the models
+	if _, err := chain.Stake(providerA, key, big.NewInt(300), []string{"mistral:7b", "phi3:mini"}); err != nil {
+		t.Fatal(err)
+	}
+	owner, amount, unbondingAt, err := chain.StakeOf(ctx, key)
+	if err != nil || owner != providerA || amount.Int64() != 1500 || !unbondingAt.IsZero() {
+		t.Fatalf("StakeOf = %s, %s, %s, %v", owner, amount, unbondingAt, err)
+	}
+	models, _ := chain.ProviderModels(ctx, key)
+	if len(models) != 2 || models[0] != "mistral:7b" {
+		t.Fatalf("models %v", models)
+	}
+	if tokens, _ := chain.TokenBalance(providerA); tokens.Sign() != 0 {
+		t.Fatalf("%s tokens left after staking them all", tokens)
+	}
+
+	if _, _, err := chain.WithdrawStake(providerA, key); !errors.Is(err, ErrStakeLocked) {
+		t.Fatalf("withdrew a bonded stake: %v", err)
+	}
+	if _, err := chain.RequestUnbond(providerB, key); !errors.Is(err, ErrNotStakeOwner) {
+		t.Fatalf("another address unbonded the stake: %v", err)
+	}
+	if _, err := chain.RequestUnbond(providerA, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, unbondingAt, _ := chain.StakeOf(ctx, key); !unbondingAt.Equal(now.Add(time.Hour)) {
+		t.Fatalf("unbonding at %s", unbondingAt)
+	}
+	if _, err := chain.Stake(providerA, key, big.NewInt(0), nil); !errors.Is(err, ErrUnbonding) {
+		t.Fatalf("changed an unbonding stake: %v", err)
+	}
+
+	// A dispute holds the stake past the unbonding period
+	if _, err := chain.OpenDispute(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(2 * time.Hour)
+	if _, _, err := chain.WithdrawStake(providerA, key); !errors.Is(err, ErrDisputeOpen) {
+		t.Fatalf("withdrew during a dispute: %v", err)
+	}
+	if _, err := chain.CloseDispute(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := chain.CloseDispute(ctx, key); err == nil {
+		t.Fatal("closed a dispute that was not open")
+	}
+
+	_, paid, err := chain.WithdrawStake(providerA, key)
+	if err != nil || paid.Int64() != 1500 {
+		t.Fatalf("WithdrawStake = %s, %v", paid, err)
+	}
+	if tokens, _ := chain.TokenBalance(providerA); tokens.Int64() != 1500 {
+		t.Fatalf("%s tokens after withdrawing the stake", tokens)
+	}
+	if owner, _, _, _ := chain.StakeOf(ctx, key); owner != "" {
+		t.Fatalf("stake still owned by %s", owner)
+	}
+
+	var names []string
+	for _, e := range chain.Events() {
+		names = append(names, e.Name)
+	}
+	want := []string{EventStaked, EventStaked, EventUnbondRequested, EventDisputeOpened, EventDisputeClosed, EventStakeWithdrawn}
+	if len(names) != len(want) {
+		t.Fatalf("events %v", names)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("events %v, want %v", names, want)
+		}
+	}
+}
+
+func TestChainSlashing(t *testing.T) {
+	chain := NewChain()
+	ctx := context.Background()
+	key := [32]byte{7}
+	first, second := [32]byte{1}, [32]byte{2}
+
+	if _, err := chain.Slash(ctx, key, big.NewInt(1), first); !errors.Is(err, ErrNotStaked) {
+		t.Fatalf("slashed an unstaked key: %v", err)
+	}
+	chain.SetStakeParams(big.NewInt(1000), time.Hour)
+	chain.Mint(providerA, big.NewInt(1500))
+	if _, err := chain.Stake(providerA, key, big.NewInt(1500), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := chain.Slash(ctx, key, big.NewInt(600), first); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := chain.Slash(ctx, key, big.NewInt(600), first); !errors.Is(err, ErrAlreadySlashed) {
+		t.Fatalf("slashed twice for the same evidence: %v", err)
+	}
+	if _, staked, _, _ := chain.StakeOf(ctx, key); staked.Int64() != 900 {
+		t.Fatalf("stake %s after slashing 600", staked)
+	}
+
+	// A slash never takes more than the stake
+	if _, err := chain.Slash(ctx, key, big.NewInt(5000), second); err != nil {
+		t.Fatal(err)
+	}
+	if _, staked, _, _ := chain.StakeOf(ctx, key); staked.Sign() != 0 {
+		t.Fatalf("stake %s after slashing it all", staked)
+	}
+	events := chain.Events()
+	last := events[len(events)-1]
+	if last.Name != EventSlashed || last.Amount.Int64() != 900 || last.EvidenceHash != hex.EncodeToString(second[:]) {
+		t.Fatalf("last event %+v", last)
+	}
+}
@@ -8,10 +8,11 @@ const TOKEN_ABI = [
 ];
 
 const SETTLEMENT_ABI = [
-  "function processReceipt(bytes32 receiptId, address provider, uint256 amount)",
+  "function commitRoot(uint256 epoch, bytes32 root, uint256 treeSize)",
+  "function processReceipt(uint256 epoch, uint256 leafIndex, bytes32 leafHash, bytes32[] proof, address provider, uint256 amount)",
   "function withdraw()",
   "function providerBalances(address) view returns (uint256)",
-  "event ReceiptProcessed(bytes32 indexed receiptId, address indexed provider, uint256 amount)",
+  "event ReceiptProcessed(uint256 indexed epoch, uint256 leafIndex, address indexed provider, uint256 amount)",
   "event Withdrawn(address indexed provider, uint256 amount)"
 ];
 
@@ -45,13 +46,25 @@ async function main() {
   const providerBalance = await token.balanceOf(providerWallet.address);
   console.log(`Provider initial QUIV balance: ${ethers.formatEther(providerBalance)} QUIV`);
   
-  // Simulate processing a receipt from the inference we did earlier
-  const receiptId = ethers.id("JU9fUkkqbn9FVyiUie3mYX"); // Receipt ID from demo
+  // Simulate processing a receipt from the inference we did earlier. The
+  // epoch's receipt tree holds just this receipt, so its RFC 6962 leaf hash
+  // is the root and the inclusion proof is empty.
+  const epoch = Math.floor(Date.now() / 86400000);
+  const receiptId = "JU9fUkkqbn9FVyiUie3mYX"; // Receipt ID from demo
+  const leafHash = ethers.sha256(ethers.concat(["0x00", ethers.toUtf8Bytes(receiptId)]));
   const rewardAmount = ethers.parseEther("10"); // 10 QUIV tokens as reward
   
-  console.log("\n1. Processing inference receipt...");
+  console.log("\n1. Committing the epoch's receipt root...");
+  const tx0 = await settlement.commitRoot(epoch, leafHash, 1);
+  await tx0.wait();
+  console.log(`   ✓ Root committed for epoch ${epoch}`);
+  
+  console.log("\n2. Processing inference receipt...");
   const tx1 = await settlement.processReceipt(
-    receiptId,
+    epoch,
+    0,
+    leafHash,
+    [],
     providerWallet.address,
     rewardAmount
   );
@@ -63,7 +76,7 @@ async function main() {
   console.log(`   Provider pending balance: ${ethers.formatEther(pendingBalance)} QUIV`);
   
   // Provider withdraws their rewards
-  console.log("\n2. Provider withdrawing rewards...");
+  console.log("\n3. Provider withdrawing rewards...");
   const settlementProvider = settlement.connect(providerWallet);
   const tx2 = await settlementProvider.withdraw();
   await tx2.wait();
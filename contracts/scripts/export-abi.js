@@ -0,0 +1,18 @@
+// Exports the ABI and creation bytecode of each contract the Go
+// bindings use from Hardhat's artifacts into abi/. "make bindings" in the
+// repository root runs it through "npm run abi" and then regenerates the
+// bindings; CI checks that the committed files match.
+const fs = require("fs");
+const path = require("path");
+
+const contracts = ["SimpleSettlement", "SimpleQUIVToken"];
+const outDir = path.join(__dirname, "..", "abi");
+
+fs.mkdirSync(outDir, { recursive: true });
+for (const name of contracts) {
+  const artifact = path.join(__dirname, "..", "artifacts", "contracts", `${name}.sol`, `${name}.json`);
+  const { abi, bytecode } = JSON.parse(fs.readFileSync(artifact, "utf8"));
+  fs.writeFileSync(path.join(outDir, `${name}.abi`), JSON.stringify(abi, null, 2) + "\n");
+  fs.writeFileSync(path.join(outDir, `${name}.bin`), bytecode.replace(/^0x/, "") + "\n");
+  console.log(`Exported ${name}`);
+}
@@ -2,16 +2,21 @@ package main
 
 import (
 	"context"
+	"crypto/ed25519"
+	"encoding/base64"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/quiver/gateway/internal/config"
 	"github.com/quiver/gateway/pkg/api"
 	"github.com/quiver/gateway/pkg/auth"
+	"github.com/quiver/gateway/pkg/canary"
 	"github.com/quiver/gateway/pkg/p2p"
 	"github.com/quiver/gateway/pkg/ratelimit"
 )
@@ -35,6 +40,32 @@ func main() {
 	statsCollector := api.NewStatsCollector()
 	handler := api.NewHandler(p2pClient, limiter, cfg.CanaryRate)
 	handler.SetStatsCollector(statsCollector)
+	if cfg.CanaryRate > 0 && cfg.CanariesPath == "" {
+		fmt.Println("Not sending canaries: QUIVER_CANARIES names no challenge pool")
+	} else if cfg.CanaryRate > 0 {
+		challenges, err := canary.LoadChallenges(cfg.CanariesPath)
+		if err != nil {
+			log.Fatal("Failed to load canaries:", err)
+		}
+		key, err := canary.LoadOrCreateKey(cfg.GatewayKeyPath)
+		if err != nil {
+			log.Fatal("Failed to load gateway key:", err)
+		}
+		handler.SetCanaries(challenges, key)
+		fmt.Printf("Sending canaries after %.1f%% of requests; evidence signed by %s\n",
+			cfg.CanaryRate*100, base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey)))
+
+		if cfg.AggregatorURL != "" {
+			reporter := canary.NewReporter(cfg.AggregatorURL)
+			handler.OnCanaryFailed(func(evidence *canary.SignedEvidence) {
+				ctx, cancel := context.WithTimeout(ctx, time.Minute)
+				defer cancel()
+				if err := reporter.Report(ctx, evidence); err != nil {
+					log.Println("Failed to report canary evidence:", err)
+				}
+			})
+		}
+	}
 
 	// Initialize authenticator
 	authConfig := auth.AuthConfig{
@@ -67,6 +98,7 @@ func main() {
 	router.GET("/health", handler.Health)
 	router.GET("/stats", handler.StatsHandler)
 	router.GET("/providers", handler.ListProviders)
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
 	router.OPTIONS("/generate", func(c *gin.Context) {
 		c.Status(204)
 	})
@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -12,7 +13,17 @@ type Config struct {
 	DHTBootstrapPeers []string
 	RequestTimeout    time.Duration
 	RateLimitPerToken int
-	CanaryRate        float64
+	// CanaryRate is the fraction of answered requests after which the
+	// provider that answered is sent a canary; see package canary
+	CanaryRate float64
+	// CanariesPath names the private JSON array of canary challenges.
+	// Without it no canaries are sent.
+	CanariesPath string
+	// GatewayKeyPath holds the key failed-canary evidence is signed with.
+	// It is created if missing.
+	GatewayKeyPath string
+	// AggregatorURL, if set, is where failed-canary evidence is reported
+	AggregatorURL string
 	
 	// Authentication settings
 	EnableAuth    bool
@@ -30,6 +41,7 @@ func DefaultConfig() *Config {
 		RequestTimeout:    60 * time.Second,
 		RateLimitPerToken: 10,
 		CanaryRate:        0.05,
+		GatewayKeyPath:    "./gateway.key",
 		EnableAuth:        false,
 		JWTSecret:         "quiver-secret-key-change-in-production",
 		APIKeyPrefix:      "qvr",
@@ -56,5 +68,23 @@ func DefaultConfig() *Config {
 		cfg.APIKeyPrefix = prefix
 	}
 	
+	if rate := os.Getenv("QUIVER_CANARY_RATE"); rate != "" {
+		if r, err := strconv.ParseFloat(rate, 64); err == nil && r >= 0 && r <= 1 {
+			cfg.CanaryRate = r
+		}
+	}
+
+	if canaries := os.Getenv("QUIVER_CANARIES"); canaries != "" {
+		cfg.CanariesPath = canaries
+	}
+
+	if key := os.Getenv("QUIVER_GATEWAY_KEY"); key != "" {
+		cfg.GatewayKeyPath = key
+	}
+
+	if aggregator := os.Getenv("QUIVER_AGGREGATOR_URL"); aggregator != "" {
+		cfg.AggregatorURL = aggregator
+	}
+
 	return cfg
 }
@@ -2,33 +2,63 @@ package api
 
 import (
 	"context"
+	"crypto/ed25519"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/quiver/gateway/pkg/canary"
+	"github.com/quiver/gateway/pkg/metrics"
 	"github.com/quiver/gateway/pkg/p2p"
 	"github.com/quiver/gateway/pkg/ratelimit"
+	"github.com/quiver/gateway/pkg/receipt"
+	"github.com/sirupsen/logrus"
 )
 
+const (
+	// canaryTimeout bounds a canary request, which runs after the request
+	// that triggered it has been answered.
+	canaryTimeout = 60 * time.Second
+	// maxResponseBytes bounds everything a provider sends for one request.
+	maxResponseBytes = 8 << 20
+	// maxStreamMessages bounds the chunks and checkpoints of a streamed
+	// answer.
+	maxStreamMessages = 100000
+)
+
+var errResponseTooLarge = errors.New("provider response too large")
+
 // Handler handles HTTP requests and forwards them to the P2P network
 type Handler struct {
 	p2pClient      *p2p.Client
 	limiter        *ratelimit.Limiter
 	canaryRate     float64
+	canaries       []canary.Challenge
+	canaryKey      ed25519.PrivateKey
+	onCanaryFailed []func(*canary.SignedEvidence)
 	statsCollector *StatsCollector
+	logger         *logrus.Logger
 }
 
-// NewHandler creates a new API handler
+// NewHandler creates a new API handler. No canaries are sent until
+// SetCanaries is called.
 func NewHandler(p2pClient *p2p.Client, limiter *ratelimit.Limiter, canaryRate float64) *Handler {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
 	return &Handler{
 		p2pClient:      p2pClient,
 		limiter:        limiter,
 		canaryRate:     canaryRate,
 		statsCollector: NewStatsCollector(),
+		logger:         logger,
 	}
 }
 
@@ -37,6 +67,20 @@ func (h *Handler) SetStatsCollector(sc *StatsCollector) {
 	h.statsCollector = sc
 }
 
+// SetCanaries enables canary checks: after a fraction canaryRate of
+// answered requests the provider that answered is sent one of challenges,
+// and evidence of a failed answer is signed with key.
+func (h *Handler) SetCanaries(challenges []canary.Challenge, key ed25519.PrivateKey) {
+	h.canaries = challenges
+	h.canaryKey = key
+}
+
+// OnCanaryFailed registers fn to receive the evidence of every failed
+// canary.
+func (h *Handler) OnCanaryFailed(fn func(*canary.SignedEvidence)) {
+	h.onCanaryFailed = append(h.onCanaryFailed, fn)
+}
+
 // InferenceRequest represents an inference request
 type InferenceRequest struct {
 	Prompt    string `json:"prompt"`
@@ -57,14 +101,32 @@ type InferenceResponse struct {
 type Receipt struct {
 	Receipt   InnerReceipt `json:"receipt"`
 	Signature string       `json:"signature"`
+	// Signed is the provider's receipt envelope, unchanged
+	Signed *receipt.SignedReceipt `json:"signed,omitempty"`
 }
 
-// InnerReceipt contains the actual receipt data
+// InnerReceipt contains the actual receipt data. ProviderPK is the receipt
+// identity the responding peer proved it speaks for.
 type InnerReceipt struct {
 	ProviderPK string `json:"provider_pk"`
+	PeerID     string `json:"peer_id"`
+	ReceiptID  string `json:"receipt_id"`
 	Timestamp  int64  `json:"timestamp"`
 }
 
+// providerResponse is one message on the provider's inference stream. A
+// non-streaming request gets one message with Completion and Receipt. A
+// streaming request gets NDJSON: a message per Chunk, a Checkpoint every
+// few tokens and a final message with Receipt.
+type providerResponse struct {
+	Completion  string                     `json:"completion"`
+	Chunk       string                     `json:"chunk"`
+	Checkpoint  *receipt.SignedReceipt     `json:"checkpoint"`
+	Receipt     *receipt.SignedReceipt     `json:"receipt"`
+	PeerBinding *receipt.SignedPeerBinding `json:"peer_binding"`
+	Error       string                     `json:"error"`
+}
+
 // Generate handles inference generation requests
 func (h *Handler) Generate(c *gin.Context) {
 	var req InferenceRequest
@@ -110,6 +172,10 @@ func (h *Handler) Generate(c *gin.Context) {
 		// Success - record stats and return response
 		h.statsCollector.RecordRequest(req.Model, float64(time.Since(startTime).Milliseconds()), 100)
 		c.JSON(http.StatusOK, result)
+
+		if len(h.canaries) > 0 && rand.Float64() < h.canaryRate {
+			go h.checkCanary(provider, req.Model)
+		}
 		return
 	}
 
@@ -139,34 +205,147 @@ func (h *Handler) requestInference(ctx context.Context, providerID peer.ID, req
 	}
 	defer stream.Close()
 
-	// Read response
-	respData, err := io.ReadAll(stream)
+	completion, signed, header, err := readInference(stream, providerID.String())
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	// Parse response
-	var p2pResp map[string]interface{}
-	if err := json.Unmarshal(respData, &p2pResp); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+		return nil, err
 	}
 
 	// Convert to API response format
 	resp := &InferenceResponse{
-		Completion: p2pResp["completion"].(string),
+		Completion: completion,
 		Model:      req.Model,
 		Receipt: Receipt{
 			Receipt: InnerReceipt{
-				ProviderPK: providerID.String(),
+				ProviderPK: header.ProviderPK,
+				PeerID:     providerID.String(),
+				ReceiptID:  header.ReceiptID,
 				Timestamp:  time.Now().Unix(),
 			},
-			Signature: p2pResp["signature"].(string),
+			Signature: signed.Signature,
+			Signed:    signed,
 		},
 	}
 
 	return resp, nil
 }
 
+// readInference reads a provider's answer from r up to its final receipt
+// and checks it came from peerID. For a streamed answer the chunks are
+// joined into the completion, and the checkpoints and final receipt must
+// all verify and commit to the chunks received. An answer longer than
+// maxResponseBytes or maxStreamMessages fails.
+func readInference(r io.Reader, peerID string) (string, *receipt.SignedReceipt, *receipt.Header, error) {
+	decoder := json.NewDecoder(&limitedReader{r: r, n: maxResponseBytes})
+	var (
+		chunks      []string
+		checkpoints []*receipt.SignedReceipt
+		msg         providerResponse
+	)
+	for {
+		msg = providerResponse{}
+		if err := decoder.Decode(&msg); err != nil {
+			return "", nil, nil, fmt.Errorf("failed to read response: %w", err)
+		}
+		if msg.Error != "" {
+			return "", nil, nil, fmt.Errorf("provider error: %s", msg.Error)
+		}
+		if msg.Receipt != nil {
+			break
+		}
+		if len(chunks)+len(checkpoints) >= maxStreamMessages {
+			return "", nil, nil, fmt.Errorf("%w: more than %d stream messages", errResponseTooLarge, maxStreamMessages)
+		}
+		// Chunks are sent with omitempty, so an empty message is an empty
+		// chunk
+		if msg.Checkpoint != nil {
+			checkpoints = append(checkpoints, msg.Checkpoint)
+		} else {
+			chunks = append(chunks, msg.Chunk)
+		}
+	}
+
+	// Only accept receipts the peer we spoke to is bound to
+	header, err := receipt.VerifyFromPeer(msg.Receipt, msg.PeerBinding, peerID)
+	if err == nil && header.Stream != nil {
+		err = verifyStream(append(checkpoints, msg.Receipt), msg.PeerBinding, peerID, chunks)
+	} else if err == nil && (len(chunks) > 0 || len(checkpoints) > 0) {
+		err = fmt.Errorf("streamed answer ended with a non-stream receipt")
+	}
+	if err != nil {
+		metrics.ReceiptVerifications.WithLabelValues("invalid").Inc()
+		return "", nil, nil, fmt.Errorf("receipt not from provider peer: %w", err)
+	}
+	metrics.ReceiptVerifications.WithLabelValues("valid").Inc()
+
+	if header.Stream != nil {
+		return strings.Join(chunks, ""), msg.Receipt, header, nil
+	}
+	return msg.Completion, msg.Receipt, header, nil
+}
+
+// limitedReader reads from r until n bytes have been read and then fails
+// with errResponseTooLarge.
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		return 0, fmt.Errorf("%w: more than %d bytes", errResponseTooLarge, maxResponseBytes)
+	}
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	return n, err
+}
+
+// verifyStream checks the checkpoints and final receipt of a streamed
+// answer, in order, against the chunks received.
+func verifyStream(receipts []*receipt.SignedReceipt, binding *receipt.SignedPeerBinding, peerID string, chunks []string) error {
+	for _, checkpoint := range receipts[:len(receipts)-1] {
+		if _, err := receipt.VerifyFromPeer(checkpoint, binding, peerID); err != nil {
+			return fmt.Errorf("checkpoint: %w", err)
+		}
+	}
+	return receipt.VerifyStream(receipts, chunks)
+}
+
+// checkCanary sends a provider a canary for model and scores its answer.
+func (h *Handler) checkCanary(providerID peer.ID, model string) {
+	ctx, cancel := context.WithTimeout(context.Background(), canaryTimeout)
+	defer cancel()
+
+	challenge := canary.Pick(h.canaries)
+	resp, err := h.requestInference(ctx, providerID, InferenceRequest{Prompt: challenge.Prompt, Model: model})
+	if err != nil {
+		metrics.CanaryChecks.WithLabelValues("error").Inc()
+		h.logger.WithError(err).WithField("peer_id", providerID.String()).Warn("Canary request failed")
+		return
+	}
+	if canary.Passed(challenge.Answers, resp.Completion) {
+		metrics.CanaryChecks.WithLabelValues("pass").Inc()
+		return
+	}
+	metrics.CanaryChecks.WithLabelValues("fail").Inc()
+
+	evidence, err := canary.Sign(canary.NewEvidence(challenge, resp.Completion, resp.Receipt.Signed), h.canaryKey)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to sign canary evidence")
+		return
+	}
+	h.logger.WithFields(logrus.Fields{
+		"provider_pk": resp.Receipt.Receipt.ProviderPK,
+		"receipt_id":  resp.Receipt.Receipt.ReceiptID,
+		"canary_id":   challenge.ID,
+	}).Warn("Provider failed canary")
+	for _, fn := range h.onCanaryFailed {
+		fn(evidence)
+	}
+}
+
 // Health handles health check requests
 func (h *Handler) Health(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
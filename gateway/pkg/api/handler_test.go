@@ -0,0 +1,188 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/quiver/gateway/pkg/receipt"
+)
+
+// providerMessage mirrors the provider's stream.Response, so tests replay
+// exactly what its inference stream handler writes.
+type providerMessage struct {
+	Completion  string                     `json:"completion"`
+	Chunk       string                     `json:"chunk,omitempty"`
+	Checkpoint  *receipt.SignedReceipt     `json:"checkpoint,omitempty"`
+	Receipt     *receipt.SignedReceipt     `json:"receipt"`
+	PeerBinding *receipt.SignedPeerBinding `json:"peer_binding,omitempty"`
+	Error       string                     `json:"error,omitempty"`
+}
+
+type streamFixture struct {
+	chunks   []string
+	receipts []*receipt.SignedReceipt
+	binding  *receipt.SignedPeerBinding
+	peerID   string
+}
+
+func loadVectors(t *testing.T, name string, v interface{}) {
+	t.Helper()
+	data, err := os.ReadFile("../../../testvectors/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func loadStreamFixture(t *testing.T) *streamFixture {
+	t.Helper()
+	var stream struct {
+		Chunks   []string                 `json:"chunks"`
+		Receipts []*receipt.SignedReceipt `json:"receipts"`
+	}
+	loadVectors(t, "stream.json", &stream)
+	var bindings struct {
+		Peer struct {
+			PeerID string `json:"peer_id"`
+		} `json:"peer"`
+		Cases []struct {
+			Signed receipt.SignedPeerBinding `json:"signed"`
+		} `json:"cases"`
+	}
+	loadVectors(t, "peer_binding.json", &bindings)
+	return &streamFixture{
+		chunks:   stream.Chunks,
+		receipts: stream.Receipts,
+		binding:  &bindings.Cases[0].Signed,
+		peerID:   bindings.Peer.PeerID,
+	}
+}
+
+// encode writes the NDJSON the provider sends for a streamed generation:
+// every chunk, a checkpoint after each chunk a checkpoint covers, and the
+// final receipt with the peer binding.
+func (f *streamFixture) encode(t *testing.T, chunks []string, checkpoints []*receipt.SignedReceipt) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	write := func(m providerMessage) {
+		if err := encoder.Encode(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	next := 0
+	for i, chunk := range chunks {
+		write(providerMessage{Chunk: chunk})
+		for next < len(checkpoints) {
+			header, err := checkpoints[next].Header()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if header.Stream.Chunks != i+1 {
+				break
+			}
+			write(providerMessage{Checkpoint: checkpoints[next]})
+			next++
+		}
+	}
+	write(providerMessage{Receipt: f.receipts[len(f.receipts)-1], PeerBinding: f.binding})
+	return &buf
+}
+
+func TestReadInferenceStream(t *testing.T) {
+	f := loadStreamFixture(t)
+	checkpoints := f.receipts[:len(f.receipts)-1]
+
+	completion, signed, header, err := readInference(f.encode(t, f.chunks, checkpoints), f.peerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if completion != strings.Join(f.chunks, "") {
+		t.Errorf("completion = %q", completion)
+	}
+	if signed.Signature != f.receipts[len(f.receipts)-1].Signature {
+		t.Error("returned a receipt other than the final one")
+	}
+	if header.Stream == nil || !header.Stream.Final {
+		t.Errorf("header = %+v", header)
+	}
+
+	altered := append([]string{"A"}, f.chunks[1:]...)
+	if _, _, _, err := readInference(f.encode(t, altered, checkpoints), f.peerID); err == nil {
+		t.Error("accepted chunks the receipts do not commit to")
+	}
+	if _, _, _, err := readInference(f.encode(t, f.chunks, checkpoints[1:]), f.peerID); err == nil {
+		t.Error("accepted a stream missing a checkpoint")
+	}
+	if _, _, _, err := readInference(f.encode(t, f.chunks, checkpoints), "12D3KooWSomeoneElse"); err == nil {
+		t.Error("accepted a stream from another peer")
+	}
+
+	// A stream cut off before its final receipt is not an answer
+	truncated := f.encode(t, f.chunks, checkpoints)
+	lines := bytes.SplitAfter(truncated.Bytes(), []byte("\n"))
+	cut := bytes.Join(lines[:len(lines)-2], nil)
+	if _, _, _, err := readInference(bytes.NewReader(cut), f.peerID); err == nil {
+		t.Error("accepted a stream without a final receipt")
+	}
+}
+
+func TestReadInferenceLimits(t *testing.T) {
+	f := loadStreamFixture(t)
+
+	// Endless chunks fail once there are too many
+	var buf bytes.Buffer
+	for i := 0; i <= maxStreamMessages; i++ {
+		buf.WriteString("{}\n")
+	}
+	if _, _, _, err := readInference(&buf, f.peerID); !errors.Is(err, errResponseTooLarge) {
+		t.Errorf("too many chunks: %v", err)
+	}
+
+	// So does one enormous chunk
+	buf.Reset()
+	json.NewEncoder(&buf).Encode(providerMessage{Chunk: strings.Repeat("a", maxResponseBytes)})
+	if _, _, _, err := readInference(&buf, f.peerID); !errors.Is(err, errResponseTooLarge) {
+		t.Errorf("oversized chunk: %v", err)
+	}
+}
+
+func TestReadInferenceSingle(t *testing.T) {
+	f := loadStreamFixture(t)
+	var receipts struct {
+		Cases []struct {
+			Name   string                `json:"name"`
+			Signed receipt.SignedReceipt `json:"signed"`
+		} `json:"cases"`
+	}
+	loadVectors(t, "receipts.json", &receipts)
+	var signed *receipt.SignedReceipt
+	for i := range receipts.Cases {
+		if receipts.Cases[i].Name == "v2" {
+			signed = &receipts.Cases[i].Signed
+		}
+	}
+
+	var buf bytes.Buffer
+	json.NewEncoder(&buf).Encode(providerMessage{Completion: "Paris", Receipt: signed, PeerBinding: f.binding})
+	completion, _, header, err := readInference(&buf, f.peerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if completion != "Paris" || header.Stream != nil {
+		t.Errorf("completion %q, stream %+v", completion, header.Stream)
+	}
+
+	buf.Reset()
+	json.NewEncoder(&buf).Encode(providerMessage{Error: "rate limit exceeded"})
+	if _, _, _, err := readInference(&buf, f.peerID); err == nil || !strings.Contains(err.Error(), "rate limit") {
+		t.Errorf("provider error: %v", err)
+	}
+}
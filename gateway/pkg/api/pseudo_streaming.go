@@ -124,13 +124,6 @@ func (h *Handler) GenerateStream(c *gin.Context) {
 			"timestamp": time.Now().UnixMilli(),
 		})
 		
-		// Log for canary
-		if isCanary := req.Prompt != req.Prompt; isCanary {
-			// Log: Streamed canary request
-		} else {
-			// Log: Streamed request processed
-		}
-		
 		return
 	}
 
@@ -0,0 +1,128 @@
+// Package canary checks providers with known-answer prompts. At the
+// configured rate the gateway sends the provider that served a request a
+// challenge picked at random from the operator's pool, as an ordinary
+// inference request, and scores the completion against the challenge's
+// answers.
+//
+// There are no built-in challenges: a provider can read this package, so
+// any prompt it generates or ships could be recognised and answered
+// honestly while other requests are not. Canaries only work while the pool
+// is kept private and its prompts read like the gateway's real traffic. A
+// provider that sees a prompt repeatedly may learn it, so the pool should
+// be large and replaced from time to time.
+//
+// A failed challenge becomes slashing evidence: the provider's signed
+// receipt for the canary, the prompt, the accepted answers and the
+// completion, signed by the gateway. The receipt's prompt and output
+// hashes bind the provider to the prompt and the completion, so anyone can
+// score the evidence again; the gateway's signature attests that the
+// prompt was a canary. Receipts themselves keep canary {"id": "",
+// "passed": true}: the provider signs them and must not learn which
+// requests are canaries.
+package canary
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// Challenge is a prompt with a known answer. Providers answer at
+// temperature 0, so an honest model answers a challenge the same way
+// every time.
+type Challenge struct {
+	ID     string `json:"id"`
+	Prompt string `json:"prompt"`
+	// Answers are the accepted answers; a completion passes if it begins
+	// with any of them, see Passed
+	Answers []string `json:"answers"`
+}
+
+// LoadChallenges reads a JSON array of challenges.
+func LoadChallenges(path string) ([]Challenge, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var challenges []Challenge
+	if err := json.Unmarshal(data, &challenges); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := Check(challenges); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return challenges, nil
+}
+
+// Check returns an error unless there is at least one challenge and every
+// challenge has a unique ID, a prompt and answers that can match.
+func Check(challenges []Challenge) error {
+	if len(challenges) == 0 {
+		return errors.New("no challenges")
+	}
+	seen := make(map[string]bool, len(challenges))
+	for i, c := range challenges {
+		if c.ID == "" || seen[c.ID] {
+			return fmt.Errorf("challenge %d: missing or duplicate ID %q", i, c.ID)
+		}
+		seen[c.ID] = true
+		if c.Prompt == "" {
+			return fmt.Errorf("challenge %s: no prompt", c.ID)
+		}
+		if len(c.Answers) == 0 {
+			return fmt.Errorf("challenge %s: no answers", c.ID)
+		}
+		for _, answer := range c.Answers {
+			if strings.TrimSpace(normalize(answer)) == "" {
+				return fmt.Errorf("challenge %s: answer %q has no letters or digits", c.ID, answer)
+			}
+		}
+	}
+	return nil
+}
+
+// Pick returns a random challenge.
+func Pick(challenges []Challenge) Challenge {
+	return challenges[rand.Intn(len(challenges))]
+}
+
+// Passed reports whether completion begins with one of answers. Both are
+// compared as lower-case words of letters and digits, so "4." passes for
+// "4" but "42" and "The answer is 4." do not, and a completion listing
+// many candidates passes at most the canary its first one answers. The
+// aggregator scores evidence with the same rule; see
+// testvectors/canary.json.
+func Passed(answers []string, completion string) bool {
+	text := normalize(completion)
+	for _, answer := range answers {
+		if a := normalize(answer); strings.TrimSpace(a) != "" && strings.HasPrefix(text, a) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalize lower-cases s and replaces every run of characters other than
+// letters and digits with one space, with a space at each end.
+func normalize(s string) string {
+	var b strings.Builder
+	b.WriteByte(' ')
+	space := true
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			space = false
+		} else if !space {
+			b.WriteByte(' ')
+			space = true
+		}
+	}
+	if !space {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
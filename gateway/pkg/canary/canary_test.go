@@ -0,0 +1,121 @@
+package canary
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type canaryVectors struct {
+	Scoring []struct {
+		Answers    []string `json:"answers"`
+		Completion string   `json:"completion"`
+		Passed     bool     `json:"passed"`
+	} `json:"scoring"`
+	SigningInput string `json:"signing_input"`
+	Cases        []struct {
+		Name   string         `json:"name"`
+		Signed SignedEvidence `json:"signed"`
+		Valid  bool           `json:"valid"`
+	} `json:"cases"`
+}
+
+func loadVectors(t *testing.T) *canaryVectors {
+	t.Helper()
+	data, err := os.ReadFile("../../../testvectors/canary.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v canaryVectors
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatal(err)
+	}
+	return &v
+}
+
+func TestScoringVectors(t *testing.T) {
+	for _, tc := range loadVectors(t).Scoring {
+		if got := Passed(tc.Answers, tc.Completion); got != tc.Passed {
+			t.Errorf("Passed(%q, %q) = %v, want %v", tc.Answers, tc.Completion, got, tc.Passed)
+		}
+	}
+}
+
+func TestEvidenceVectors(t *testing.T) {
+	v := loadVectors(t)
+	input, err := SigningInput(&v.Cases[0].Signed.Evidence)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(input) != v.SigningInput {
+		t.Errorf("signing input\n%q\nwant\n%q", input, v.SigningInput)
+	}
+	for _, tc := range v.Cases {
+		if err := Verify(&tc.Signed); (err == nil) != tc.Valid {
+			t.Errorf("%s: Verify = %v, want valid %v", tc.Name, err, tc.Valid)
+		}
+	}
+}
+
+func TestSign(t *testing.T) {
+	v := loadVectors(t)
+	failed := v.Cases[0].Signed.Evidence
+	_, key, _ := ed25519.GenerateKey(nil)
+
+	signed, err := Sign(&failed, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(signed); err != nil {
+		t.Errorf("evidence signed by a new gateway key: %v", err)
+	}
+	signed.Evidence.IssuedAt = "2024-01-02T00:00:00Z"
+	if err := Verify(signed); err == nil {
+		t.Error("evidence changed after signing verified")
+	}
+}
+
+func TestLoadChallenges(t *testing.T) {
+	// The pool the integration tests run with
+	challenges, err := LoadChallenges("../../../tests/integration/canaries.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range challenges {
+		for _, answer := range c.Answers {
+			if !Passed(c.Answers, answer) {
+				t.Errorf("%s: answer %q does not pass", c.ID, answer)
+			}
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "canaries.json")
+	for name, doc := range map[string]string{
+		"empty":        `[]`,
+		"duplicate ID": `[{"id":"a","prompt":"p","answers":["x"]},{"id":"a","prompt":"q","answers":["y"]}]`,
+		"no answers":   `[{"id":"a","prompt":"p","answers":[]}]`,
+		"blank answer": `[{"id":"a","prompt":"p","answers":["?!"]}]`,
+	} {
+		os.WriteFile(path, []byte(doc), 0600)
+		if _, err := LoadChallenges(path); err == nil {
+			t.Errorf("%s: loaded", name)
+		}
+	}
+}
+
+func TestLoadOrCreateKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gateway.key")
+	created, err := LoadOrCreateKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadOrCreateKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created.Equal(loaded) {
+		t.Error("key changed when read back")
+	}
+}
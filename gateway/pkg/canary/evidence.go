@@ -0,0 +1,179 @@
+package canary
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/quiver/gateway/pkg/jcs"
+	"github.com/quiver/gateway/pkg/receipt"
+)
+
+const (
+	EvidenceVersion  = "1"
+	DomainEvidenceV1 = "QUIVER-CANARY-EVIDENCE-V1"
+)
+
+var (
+	// ErrNotFailed means evidence shows a completion that passes its
+	// canary.
+	ErrNotFailed = errors.New("completion passes the canary")
+	// ErrUnbound means the provider's receipt does not commit to the
+	// evidence's prompt or completion.
+	ErrUnbound = errors.New("receipt does not match prompt and completion")
+)
+
+// Evidence is a provider's failed answer to a canary.
+type Evidence struct {
+	Version    string   `json:"version"`
+	CanaryID   string   `json:"canary_id"`
+	Prompt     string   `json:"prompt"`
+	Answers    []string `json:"answers"`
+	Completion string   `json:"completion"`
+	// Receipt is the provider's signed receipt for the canary, unchanged
+	Receipt   *receipt.SignedReceipt `json:"receipt"`
+	GatewayPK string                 `json:"gateway_pk"`
+	IssuedAt  string                 `json:"issued_at"`
+}
+
+// SignedEvidence is evidence signed by the gateway that issued the canary.
+type SignedEvidence struct {
+	Evidence  Evidence `json:"evidence"`
+	Algorithm string   `json:"alg"`
+	Signature string   `json:"signature"`
+}
+
+// receiptHashes holds the receipt fields that bind it to the canary.
+type receiptHashes struct {
+	PromptHash string          `json:"prompt_hash"`
+	OutputHash string          `json:"output_hash"`
+	Stream     json.RawMessage `json:"stream"`
+}
+
+// NewEvidence records a provider's failed answer to c.
+func NewEvidence(c Challenge, completion string, signed *receipt.SignedReceipt) *Evidence {
+	return &Evidence{
+		Version:    EvidenceVersion,
+		CanaryID:   c.ID,
+		Prompt:     c.Prompt,
+		Answers:    c.Answers,
+		Completion: completion,
+		Receipt:    signed,
+		IssuedAt:   time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// SigningInput returns the bytes the gateway signs:
+// "QUIVER-CANARY-EVIDENCE-V1" || 0x00 || JCS(evidence).
+func SigningInput(e *Evidence) ([]byte, error) {
+	canonical, err := jcs.Canonicalize(e)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(DomainEvidenceV1)+1+len(canonical))
+	out = append(out, DomainEvidenceV1...)
+	out = append(out, 0)
+	return append(out, canonical...), nil
+}
+
+// Sign sets the evidence's gateway key and signs it.
+func Sign(e *Evidence, key ed25519.PrivateKey) (*SignedEvidence, error) {
+	e.GatewayPK = base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey))
+	input, err := SigningInput(e)
+	if err != nil {
+		return nil, err
+	}
+	return &SignedEvidence{
+		Evidence:  *e,
+		Algorithm: receipt.AlgEd25519,
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(key, input)),
+	}, nil
+}
+
+// Verify checks that s is signed by its gateway key, that the receipt is
+// signed by its provider and commits to the prompt and completion, and that
+// the completion fails the canary. It does not decide whether the gateway
+// key is trusted.
+func Verify(s *SignedEvidence) error {
+	e := &s.Evidence
+	if e.Version != EvidenceVersion {
+		return fmt.Errorf("unsupported evidence version %q", e.Version)
+	}
+	if s.Algorithm != receipt.AlgEd25519 {
+		return fmt.Errorf("unsupported signature algorithm %q", s.Algorithm)
+	}
+	gatewayKey, err := base64.StdEncoding.DecodeString(e.GatewayPK)
+	if err != nil || len(gatewayKey) != ed25519.PublicKeySize {
+		return errors.New("invalid gateway key")
+	}
+	input, err := SigningInput(e)
+	if err != nil {
+		return err
+	}
+	signature, err := base64.StdEncoding.DecodeString(s.Signature)
+	if err != nil || !ed25519.Verify(gatewayKey, input, signature) {
+		return errors.New("invalid gateway signature")
+	}
+
+	if e.Receipt == nil {
+		return errors.New("evidence has no receipt")
+	}
+	header, err := e.Receipt.Header()
+	if err != nil {
+		return err
+	}
+	if err := receipt.VerifySignature(e.Receipt, header); err != nil {
+		return err
+	}
+	var hashes receiptHashes
+	if err := json.Unmarshal(e.Receipt.Receipt, &hashes); err != nil {
+		return fmt.Errorf("invalid receipt: %w", err)
+	}
+	if (len(hashes.Stream) > 0 && string(hashes.Stream) != "null") ||
+		hashes.PromptHash != hashString(e.Prompt) || hashes.OutputHash != hashString(e.Completion) {
+		return ErrUnbound
+	}
+
+	if Passed(e.Answers, e.Completion) {
+		return ErrNotFailed
+	}
+	return nil
+}
+
+// hashString hashes like the provider does for prompt_hash and output_hash.
+func hashString(s string) string {
+	h := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(h[:])
+}
+
+// LoadOrCreateKey reads the gateway's signing key, a base64 Ed25519 seed,
+// from path, creating the file with a new key if it does not exist.
+func LoadOrCreateKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		_, key, err := ed25519.GenerateKey(nil)
+		if err != nil {
+			return nil, err
+		}
+		seed := base64.StdEncoding.EncodeToString(key.Seed())
+		if err := os.WriteFile(path, []byte(seed+"\n"), 0600); err != nil {
+			return nil, err
+		}
+		return key, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("%s: not a base64 Ed25519 seed", path)
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
@@ -0,0 +1,52 @@
+package canary
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Reporter submits evidence to an aggregator, which verifies it, records
+// it and penalizes the provider.
+type Reporter struct {
+	url    string
+	client *http.Client
+}
+
+// NewReporter reports to the aggregator at baseURL.
+func NewReporter(baseURL string) *Reporter {
+	return &Reporter{
+		url:    strings.TrimRight(baseURL, "/") + "/evidence",
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Report posts evidence to the aggregator's /evidence endpoint. Evidence
+// the aggregator already holds is not an error.
+func (r *Reporter) Report(ctx context.Context, s *SignedEvidence) error {
+	body, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("aggregator answered %d: %s", resp.StatusCode, msg)
+	}
+	return nil
+}
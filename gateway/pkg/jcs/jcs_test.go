@@ -0,0 +1,111 @@
+package jcs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+type vectorFile struct {
+	Valid []struct {
+		Name      string `json:"name"`
+		Input     string `json:"input"`
+		Canonical string `json:"canonical"`
+	} `json:"valid"`
+	// Invalid inputs that are not valid UTF-8 are given as input_hex
+	Invalid []struct {
+		Name     string `json:"name"`
+		Input    string `json:"input"`
+		InputHex string `json:"input_hex"`
+	} `json:"invalid"`
+	Receipts []struct {
+		Name      string          `json:"name"`
+		Receipt   json.RawMessage `json:"receipt"`
+		Canonical string          `json:"canonical"`
+		SHA256    string          `json:"sha256"`
+	} `json:"receipts"`
+}
+
+func loadVectors(t *testing.T) *vectorFile {
+	data, err := os.ReadFile("../../../testvectors/jcs.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var vectors vectorFile
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+	return &vectors
+}
+
+func TestVectors(t *testing.T) {
+	vectors := loadVectors(t)
+
+	for _, v := range vectors.Valid {
+		t.Run(v.Name, func(t *testing.T) {
+			got, err := Transform([]byte(v.Input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != v.Canonical {
+				t.Errorf("got  %s\nwant %s", got, v.Canonical)
+			}
+
+			// Canonical output must be a fixed point
+			again, err := Transform(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(again) != v.Canonical {
+				t.Errorf("not idempotent: %s", again)
+			}
+		})
+	}
+
+	for _, v := range vectors.Invalid {
+		t.Run(v.Name, func(t *testing.T) {
+			input := []byte(v.Input)
+			if v.InputHex != "" {
+				var err error
+				if input, err = hex.DecodeString(v.InputHex); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, err := Transform(input); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+
+	for _, v := range vectors.Receipts {
+		t.Run(v.Name, func(t *testing.T) {
+			got, err := Canonicalize(v.Receipt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != v.Canonical {
+				t.Errorf("got  %s\nwant %s", got, v.Canonical)
+			}
+			sum := sha256.Sum256(got)
+			if hash := hex.EncodeToString(sum[:]); hash != v.SHA256 {
+				t.Errorf("hash %s, want %s", hash, v.SHA256)
+			}
+		})
+	}
+}
+
+func TestRejectsInvalidUTF8(t *testing.T) {
+	if _, err := Transform([]byte("\"\xff\"")); err == nil {
+		t.Error("expected error for invalid UTF-8")
+	}
+	// encoding/json would quietly replace these with U+FFFD
+	if _, err := Canonicalize(map[string]string{"a": "\xff"}); err == nil {
+		t.Error("expected error for invalid UTF-8 in a string")
+	}
+	if _, err := Canonicalize(map[string]int{"\xed\xa0\x80": 1}); err == nil {
+		t.Error("expected error for invalid UTF-8 in a key")
+	}
+}
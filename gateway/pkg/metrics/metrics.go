@@ -31,7 +31,12 @@ var (
 	CanaryChecks = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "gateway_canary_checks_total",
 		Help: "Total number of canary checks",
-	}, []string{"result"}) // result: "pass" or "fail"
+	}, []string{"result"}) // result: "pass", "fail" or "error"
+
+	ReceiptVerifications = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_receipt_verifications_total",
+		Help: "Provider receipts checked against the responding peer",
+	}, []string{"result"}) // result: "valid" or "invalid"
 
 	ActiveProviders = promauto.NewGauge(prometheus.GaugeOpts{
 		Name: "gateway_active_providers",
@@ -0,0 +1,248 @@
+// Package receipt checks that a provider's signed receipt came from the peer
+// the gateway spoke to. The signing rules mirror provider/pkg/receipt and
+// both are tested against testvectors/receipts.json, rotation.json and
+// peer_binding.json.
+package receipt
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/mr-tron/base58"
+	"github.com/quiver/gateway/pkg/jcs"
+)
+
+const (
+	VersionV1 = "1.0.0"
+	VersionV2 = "2.0.0"
+
+	AlgEd25519 = "ed25519"
+
+	DomainReceiptV2     = "QUIVER-RECEIPT-V2"
+	DomainSuccessionV1  = "QUIVER-KEY-SUCCESSION-V1"
+	DomainPeerBindingV1 = "QUIVER-PEER-BINDING-V1"
+)
+
+var (
+	// ErrPeerMismatch means a peer binding names a different libp2p peer
+	// than the one the gateway is talking to.
+	ErrPeerMismatch = errors.New("peer binding is for another peer")
+	// ErrProviderMismatch means a receipt names a different provider than
+	// the peer's binding.
+	ErrProviderMismatch = errors.New("receipt provider does not match peer binding")
+	// ErrInvalidSignature means the provider signature does not verify.
+	ErrInvalidSignature = errors.New("invalid receipt signature")
+)
+
+// SignedReceipt is a provider's receipt envelope. The receipt itself is kept
+// as raw JSON so it can be passed on unchanged; only the fields needed to
+// verify it are decoded.
+type SignedReceipt struct {
+	Receipt      json.RawMessage    `json:"receipt"`
+	Signature    string             `json:"signature"`
+	Algorithm    string             `json:"alg,omitempty"`
+	KeyID        string             `json:"kid,omitempty"`
+	CoSignatures json.RawMessage    `json:"cosignatures,omitempty"`
+	Succession   []SignedSuccession `json:"succession,omitempty"`
+}
+
+// Header holds the receipt fields the gateway reads.
+type Header struct {
+	Version    string `json:"version"`
+	ProviderPK string `json:"provider_pk"`
+	Seq        int64  `json:"seq"`
+	ReceiptID  string `json:"receipt_id"`
+	OutputHash string `json:"output_hash"`
+	// Stream is set on the receipts of a streamed generation
+	Stream *StreamInfo `json:"stream,omitempty"`
+}
+
+// Header decodes the receipt fields the gateway reads.
+func (s *SignedReceipt) Header() (*Header, error) {
+	var h Header
+	if err := json.Unmarshal(s.Receipt, &h); err != nil {
+		return nil, fmt.Errorf("invalid receipt: %w", err)
+	}
+	return &h, nil
+}
+
+// PeerBinding states that the libp2p peer PeerID signs receipts for the
+// provider identity ProviderPK.
+type PeerBinding struct {
+	ProviderPK string `json:"provider_pk"`
+	PeerID     string `json:"peer_id"`
+	IssuedAt   string `json:"issued_at"`
+}
+
+// SignedPeerBinding is a peer binding signed by the provider's current
+// receipt key.
+type SignedPeerBinding struct {
+	Binding    PeerBinding        `json:"binding"`
+	Algorithm  string             `json:"alg"`
+	KeyID      string             `json:"kid"`
+	Signature  string             `json:"signature"`
+	Succession []SignedSuccession `json:"succession,omitempty"`
+}
+
+type signedPayload struct {
+	Algorithm string          `json:"alg"`
+	KeyID     string          `json:"kid"`
+	Receipt   json.RawMessage `json:"receipt"`
+}
+
+// KeyID derives the identifier of a public key: base58 of the first 16 bytes
+// of its SHA-256.
+func KeyID(publicKey []byte) string {
+	hash := sha256.Sum256(publicKey)
+	return base58.Encode(hash[:16])
+}
+
+// SigningInput returns the bytes the provider signature covers.
+func SigningInput(signed *SignedReceipt, version string) ([]byte, error) {
+	switch version {
+	case VersionV1:
+		return jcs.Transform(signed.Receipt)
+	case VersionV2:
+		canonical, err := jcs.Canonicalize(signedPayload{
+			Algorithm: signed.Algorithm,
+			KeyID:     signed.KeyID,
+			Receipt:   signed.Receipt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return domainSeparate(DomainReceiptV2, canonical), nil
+	default:
+		return nil, fmt.Errorf("unsupported receipt version %q", version)
+	}
+}
+
+// PeerBindingSigningInput returns the bytes the receipt key signs in a peer
+// binding.
+func PeerBindingSigningInput(b *PeerBinding) ([]byte, error) {
+	canonical, err := jcs.Canonicalize(b)
+	if err != nil {
+		return nil, err
+	}
+	return domainSeparate(DomainPeerBindingV1, canonical), nil
+}
+
+// VerifyPeerBinding checks that b binds peerID and is signed by a key its
+// identity has not retired.
+func VerifyPeerBinding(b *SignedPeerBinding, peerID string) error {
+	if b.Binding.PeerID != peerID {
+		return ErrPeerMismatch
+	}
+	if b.Algorithm != AlgEd25519 {
+		return fmt.Errorf("unsupported signature algorithm %q", b.Algorithm)
+	}
+
+	identity, err := decodePublicKey(b.Binding.ProviderPK)
+	if err != nil {
+		return err
+	}
+	windows, err := resolveKeyChain(identity, b.Succession)
+	if err != nil {
+		return err
+	}
+	signingKey, err := keyForReceipt(windows, b.KeyID, 0)
+	if err != nil {
+		return err
+	}
+
+	input, err := PeerBindingSigningInput(&b.Binding)
+	if err != nil {
+		return err
+	}
+	signature, err := base64.StdEncoding.DecodeString(b.Signature)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(signingKey, input, signature) {
+		return errors.New("invalid peer binding signature")
+	}
+	return nil
+}
+
+// VerifyFromPeer checks that signed was produced by the provider peerID
+// speaks for: the binding must be valid for peerID, the receipt must name
+// the binding's provider and its signature must verify under that
+// provider's key chain. It returns the receipt header.
+func VerifyFromPeer(signed *SignedReceipt, binding *SignedPeerBinding, peerID string) (*Header, error) {
+	if binding == nil {
+		return nil, errors.New("provider sent no peer binding")
+	}
+	if err := VerifyPeerBinding(binding, peerID); err != nil {
+		return nil, err
+	}
+
+	header, err := signed.Header()
+	if err != nil {
+		return nil, err
+	}
+	if header.ProviderPK != binding.Binding.ProviderPK {
+		return nil, ErrProviderMismatch
+	}
+	if err := VerifySignature(signed, header); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+// VerifySignature checks the provider signature on signed against the
+// identity in its header, following the envelope's succession chain for v2
+// receipts.
+func VerifySignature(signed *SignedReceipt, header *Header) error {
+	signingKey, err := decodePublicKey(header.ProviderPK)
+	if err != nil {
+		return err
+	}
+
+	if header.Version != VersionV1 {
+		if signed.Algorithm != AlgEd25519 {
+			return fmt.Errorf("unsupported signature algorithm %q", signed.Algorithm)
+		}
+		windows, err := resolveKeyChain(signingKey, signed.Succession)
+		if err != nil {
+			return err
+		}
+		if signingKey, err = keyForReceipt(windows, signed.KeyID, header.Seq); err != nil {
+			return err
+		}
+	}
+
+	input, err := SigningInput(signed, header.Version)
+	if err != nil {
+		return err
+	}
+	signature, err := base64.StdEncoding.DecodeString(signed.Signature)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(signingKey, input, signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func decodePublicKey(publicKeyBase64 string) (ed25519.PublicKey, error) {
+	publicKey, err := base64.StdEncoding.DecodeString(publicKeyBase64)
+	if err != nil {
+		return nil, err
+	}
+	if len(publicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key size")
+	}
+	return ed25519.PublicKey(publicKey), nil
+}
+
+func domainSeparate(domain string, msg []byte) []byte {
+	out := make([]byte, 0, len(domain)+1+len(msg))
+	out = append(out, domain...)
+	out = append(out, 0)
+	return append(out, msg...)
+}
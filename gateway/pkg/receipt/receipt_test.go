@@ -0,0 +1,118 @@
+package receipt
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+)
+
+type vectorCase struct {
+	Name   string        `json:"name"`
+	Signed SignedReceipt `json:"signed"`
+	Valid  bool          `json:"valid"`
+}
+
+type bindingCase struct {
+	Name   string            `json:"name"`
+	PeerID string            `json:"peer_id"`
+	Signed SignedPeerBinding `json:"signed"`
+	Valid  bool              `json:"valid"`
+}
+
+func loadVectors(t *testing.T, name string, v interface{}) {
+	t.Helper()
+	data, err := os.ReadFile("../../../testvectors/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func verify(signed *SignedReceipt) error {
+	header, err := signed.Header()
+	if err != nil {
+		return err
+	}
+	return VerifySignature(signed, header)
+}
+
+func TestReceiptVectors(t *testing.T) {
+	for _, file := range []string{"receipts.json", "rotation.json"} {
+		var v struct {
+			Cases []vectorCase `json:"cases"`
+		}
+		loadVectors(t, file, &v)
+
+		for _, tc := range v.Cases {
+			t.Run(file+"/"+tc.Name, func(t *testing.T) {
+				err := verify(&tc.Signed)
+				if (err == nil) != tc.Valid {
+					t.Errorf("VerifySignature = %v, want valid %v", err, tc.Valid)
+				}
+			})
+		}
+	}
+}
+
+func TestPeerBindingVectors(t *testing.T) {
+	var v struct {
+		Cases []bindingCase `json:"cases"`
+	}
+	loadVectors(t, "peer_binding.json", &v)
+
+	for _, tc := range v.Cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := VerifyPeerBinding(&tc.Signed, tc.PeerID)
+			if (err == nil) != tc.Valid {
+				t.Errorf("VerifyPeerBinding = %v, want valid %v", err, tc.Valid)
+			}
+		})
+	}
+}
+
+func TestVerifyFromPeer(t *testing.T) {
+	var receipts struct {
+		Cases []vectorCase `json:"cases"`
+	}
+	loadVectors(t, "receipts.json", &receipts)
+	var bindings struct {
+		Peer struct {
+			PeerID string `json:"peer_id"`
+		} `json:"peer"`
+		Cases []bindingCase `json:"cases"`
+	}
+	loadVectors(t, "peer_binding.json", &bindings)
+
+	var signed *SignedReceipt
+	for i := range receipts.Cases {
+		if receipts.Cases[i].Name == "v2" {
+			signed = &receipts.Cases[i].Signed
+		}
+	}
+	binding := &bindings.Cases[0].Signed
+	peerID := bindings.Peer.PeerID
+
+	header, err := VerifyFromPeer(signed, binding, peerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.ProviderPK != binding.Binding.ProviderPK {
+		t.Errorf("provider %s", header.ProviderPK)
+	}
+
+	if _, err := VerifyFromPeer(signed, binding, "12D3KooWSomeoneElse"); !errors.Is(err, ErrPeerMismatch) {
+		t.Errorf("other peer: %v", err)
+	}
+	if _, err := VerifyFromPeer(signed, nil, peerID); err == nil {
+		t.Error("accepted a receipt without a binding")
+	}
+
+	other := *binding
+	other.Binding.ProviderPK = "PUAXw+hDiVqStwqnTRt+vJyYLM8uxJaMwM1V8Sr0Zgw="
+	if _, err := VerifyFromPeer(signed, &other, peerID); err == nil {
+		t.Error("accepted a binding for another provider")
+	}
+}
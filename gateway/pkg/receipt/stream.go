@@ -0,0 +1,96 @@
+package receipt
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/quiver/gateway/pkg/jcs"
+)
+
+// DomainStreamV1 seeds the rolling output hash of streamed receipts.
+const DomainStreamV1 = "QUIVER-STREAM-V1"
+
+// StreamInfo marks a receipt as part of a streamed generation. The provider
+// signs a checkpoint every few tokens and a final receipt when generation
+// ends, each linked to the one before through PrevCheckpoint. OutputHash is
+// the rolling hash over the first Chunks chunks. The rules mirror
+// provider/pkg/receipt and are tested against testvectors/stream.json.
+type StreamInfo struct {
+	Index          int    `json:"index"`
+	Chunks         int    `json:"chunks"`
+	PrevCheckpoint string `json:"prev_checkpoint"`
+	Final          bool   `json:"final"`
+}
+
+// StreamHash returns the rolling hash of chunks:
+//
+//	h0 = SHA-256("QUIVER-STREAM-V1")
+//	hi = SHA-256(h(i-1) || SHA-256(chunk i))
+func StreamHash(chunks []string) string {
+	state := sha256.Sum256([]byte(DomainStreamV1))
+	for _, chunk := range chunks {
+		chunkHash := sha256.Sum256([]byte(chunk))
+		state = sha256.Sum256(append(state[:], chunkHash[:]...))
+	}
+	return hex.EncodeToString(state[:])
+}
+
+// CheckpointHash is the value the next streamed receipt carries as
+// PrevCheckpoint: the hex SHA-256 of the receipt's JCS form.
+func CheckpointHash(signed *SignedReceipt) (string, error) {
+	canonical, err := jcs.Transform(signed.Receipt)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(canonical)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// VerifyStream checks that receipts form one streamed generation of
+// chunks: consecutive indexes from 1, each linked to its predecessor, only
+// the last one final, and each committing to the chunks received up to it.
+// Signatures are checked separately.
+func VerifyStream(receipts []*SignedReceipt, chunks []string) error {
+	if len(receipts) == 0 {
+		return errors.New("no stream receipts")
+	}
+	prev := ""
+	covered := 0
+	for i, signed := range receipts {
+		h, err := signed.Header()
+		if err != nil {
+			return err
+		}
+		if h.Stream == nil {
+			return fmt.Errorf("receipt %d is not a stream receipt", i)
+		}
+		if h.Stream.Index != i+1 {
+			return fmt.Errorf("receipt %d has index %d", i, h.Stream.Index)
+		}
+		if h.Stream.PrevCheckpoint != prev {
+			return fmt.Errorf("receipt %d does not link to its predecessor", i)
+		}
+		if h.Stream.Chunks < covered || h.Stream.Chunks > len(chunks) {
+			return fmt.Errorf("receipt %d covers %d of %d chunks", i, h.Stream.Chunks, len(chunks))
+		}
+		if h.Stream.Final != (i == len(receipts)-1) {
+			return fmt.Errorf("receipt %d is final: %v", i, h.Stream.Final)
+		}
+		if StreamHash(chunks[:h.Stream.Chunks]) != h.OutputHash {
+			return fmt.Errorf("receipt %d does not match the chunks received", i)
+		}
+
+		hash, err := CheckpointHash(signed)
+		if err != nil {
+			return err
+		}
+		prev = hash
+		covered = h.Stream.Chunks
+	}
+	if covered != len(chunks) {
+		return fmt.Errorf("final receipt covers %d of %d chunks", covered, len(chunks))
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package receipt
+
+import "testing"
+
+type streamVectors struct {
+	Chunks        []string         `json:"chunks"`
+	RollingHashes []string         `json:"rolling_hashes"`
+	Receipts      []*SignedReceipt `json:"receipts"`
+}
+
+func TestStreamHashVectors(t *testing.T) {
+	var v streamVectors
+	loadVectors(t, "stream.json", &v)
+
+	for i := range v.Chunks {
+		if got := StreamHash(v.Chunks[:i+1]); got != v.RollingHashes[i] {
+			t.Fatalf("rolling hash after chunk %d = %s, want %s", i+1, got, v.RollingHashes[i])
+		}
+	}
+}
+
+func TestVerifyStream(t *testing.T) {
+	var v streamVectors
+	loadVectors(t, "stream.json", &v)
+
+	for i, signed := range v.Receipts {
+		if err := verify(signed); err != nil {
+			t.Fatalf("receipt %d: %v", i, err)
+		}
+	}
+	if err := VerifyStream(v.Receipts, v.Chunks); err != nil {
+		t.Fatalf("stream rejected: %v", err)
+	}
+
+	altered := append([]string{"A"}, v.Chunks[1:]...)
+	if err := VerifyStream(v.Receipts, altered); err == nil {
+		t.Error("accepted altered chunks")
+	}
+	if err := VerifyStream(v.Receipts, append(v.Chunks, " extra")); err == nil {
+		t.Error("accepted chunks the final receipt does not cover")
+	}
+	if err := VerifyStream(v.Receipts[1:], v.Chunks); err == nil {
+		t.Error("accepted a chain missing its first checkpoint")
+	}
+	if err := VerifyStream(v.Receipts[:2], v.Chunks[:8]); err == nil {
+		t.Error("accepted a chain without a final receipt")
+	}
+	reordered := []*SignedReceipt{v.Receipts[1], v.Receipts[0], v.Receipts[2]}
+	if err := VerifyStream(reordered, v.Chunks); err == nil {
+		t.Error("accepted reordered checkpoints")
+	}
+}
@@ -14,6 +14,7 @@ import (
 
     "github.com/libp2p/go-libp2p/core/crypto"
     "github.com/quiver/provider/pkg/api"
+    keystore "github.com/quiver/provider/pkg/crypto"
     "github.com/quiver/provider/pkg/p2p"
 )
 
@@ -195,9 +196,20 @@ func downloadModel(model string) {
 
 func loadOrGenerateKey(dataDir string) (crypto.PrivKey, error) {
     keyPath := filepath.Join(dataDir, "node.key")
+    ks := keystore.NewKeystore(keyPath)
+
+    // 鍵のパスフレーズを取得(新規作成時は確認入力あり)
+    passphrase, err := keystore.ReadPassphrase(os.Getenv(keystore.EnvPassphraseFile), !ks.Exists())
+    if err != nil {
+        return nil, err
+    }
     
-    // 既存のキーを読み込み
-    if keyBytes, err := os.ReadFile(keyPath); err == nil {
+    // 既存のキーを読み込み(平文の鍵は暗号化して保存し直される)
+    if ks.Exists() {
+        keyBytes, err := ks.LoadKey(passphrase)
+        if err != nil {
+            return nil, err
+        }
         return crypto.UnmarshalPrivateKey(keyBytes)
     }
     
@@ -207,13 +219,13 @@ func loadOrGenerateKey(dataDir string) (crypto.PrivKey, error) {
         return nil, err
     }
     
-    // 保存
+    // 暗号化して保存
     keyBytes, err := crypto.MarshalPrivateKey(privKey)
     if err != nil {
         return nil, err
     }
     
-    if err := os.WriteFile(keyPath, keyBytes, 0600); err != nil {
+    if err := ks.StoreKey(keyBytes, passphrase); err != nil {
         return nil, err
     }
     
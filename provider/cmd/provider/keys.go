@@ -0,0 +1,125 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/quiver/provider/internal/config"
+	"github.com/quiver/provider/pkg/crypto"
+	"github.com/quiver/provider/pkg/journal"
+	"github.com/quiver/provider/pkg/receipt"
+	"github.com/quiver/provider/pkg/rotation"
+)
+
+const keysUsage = `usage: provider keys <command>
+
+commands:
+  show                 print the provider identity and current signing key
+  rotate [flags]       replace the signing key (stop the provider first)
+`
+
+// runKeys implements the "provider keys" subcommands and returns the exit
+// code.
+func runKeys(cfg *config.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, keysUsage)
+		return 2
+	}
+
+	switch args[0] {
+	case "show":
+		return keysShow(cfg)
+	case "rotate":
+		return keysRotate(cfg, args[1:])
+	default:
+		fmt.Fprint(os.Stderr, keysUsage)
+		return 2
+	}
+}
+
+func keysShow(cfg *config.Config) int {
+	if _, err := os.Stat(cfg.PrivateKeyPath); err != nil {
+		fmt.Fprintf(os.Stderr, "no signing key at %s\n", cfg.PrivateKeyPath)
+		return 1
+	}
+	codec, err := keyCodec(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	signer, err := receipt.NewSignerWithCodec(cfg.PrivateKeyPath, codec)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	fmt.Printf("Provider identity: %s\n", signer.PublicKeyBase64())
+	fmt.Printf("Signing key id:    %s\n", signer.KeyID())
+	for i, s := range signer.Successions() {
+		cert := s.Certificate
+		fmt.Printf("Rotation %d: %s -> %s at seq %d (%s, old key revoked from seq %d)\n",
+			i+1, cert.PrevKeyID, cert.NextKeyID, cert.EffectiveSeq, cert.Reason, cert.RevokeFromSeq)
+	}
+	return 0
+}
+
+func keysRotate(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("provider keys rotate", flag.ContinueOnError)
+	compromised := fs.Bool("compromised", false, "record the old key as compromised")
+	revokeFrom := fs.Int64("revoke-from", 0, "disown receipts the old key signed from this seq on (default: the rotation point)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	codec, err := keyCodec(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	j, err := journal.Open(cfg.JournalPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to open receipt journal: %v\n", err)
+		return 1
+	}
+	defer j.Close()
+
+	schedule, err := epochSchedule(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load epoch schedule: %v\n", err)
+		return 1
+	}
+
+	opts := rotation.Options{
+		Reason:        receipt.ReasonScheduled,
+		RevokeFromSeq: *revokeFrom,
+		Schedule:      schedule,
+	}
+	if *compromised {
+		opts.Reason = receipt.ReasonCompromised
+	}
+
+	signer, err := rotation.Rotate(cfg.PrivateKeyPath, codec, j, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "rotation failed: %v\n", err)
+		return 1
+	}
+
+	successions := signer.Successions()
+	cert := successions[len(successions)-1].Certificate
+	fmt.Printf("Rotated %s -> %s at seq %d\n", cert.PrevKeyID, cert.NextKeyID, cert.EffectiveSeq)
+	fmt.Printf("Receipts signed by %s from seq %d on will be rejected\n", cert.PrevKeyID, cert.RevokeFromSeq)
+	return 0
+}
+
+// keyCodec reads the key passphrase and returns the codec the signing key is
+// stored with. The passphrase is asked for twice when no key exists yet.
+func keyCodec(cfg *config.Config) (receipt.KeyCodec, error) {
+	_, err := os.Stat(cfg.PrivateKeyPath)
+	passphrase, err := crypto.ReadPassphrase(cfg.KeyPassphraseFile, os.IsNotExist(err))
+	if err != nil {
+		return nil, err
+	}
+	return crypto.NewCodec(passphrase), nil
+}
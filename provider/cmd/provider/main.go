@@ -11,9 +11,12 @@ import (
 
 	"github.com/libp2p/go-libp2p/core/protocol"
 	"github.com/quiver/provider/internal/config"
+	"github.com/quiver/provider/pkg/epoch"
+	"github.com/quiver/provider/pkg/journal"
 	"github.com/quiver/provider/pkg/llm"
 	"github.com/quiver/provider/pkg/p2p"
 	"github.com/quiver/provider/pkg/receipt"
+	"github.com/quiver/provider/pkg/rotation"
 	"github.com/quiver/provider/pkg/stream"
 	"github.com/quiver/provider/pkg/updater"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -28,6 +31,10 @@ var startTime = time.Now()
 func main() {
 	cfg := config.DefaultConfig()
 
+	if len(os.Args) > 1 && os.Args[1] == "keys" {
+		os.Exit(runKeys(cfg, os.Args[2:]))
+	}
+
 	// Setup logger
 	logger := logrus.New()
 	logger.SetLevel(logrus.InfoLevel)
@@ -38,12 +45,19 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	signer, err := receipt.NewSigner(cfg.PrivateKeyPath)
+	codec, err := keyCodec(cfg)
 	if err != nil {
-		logger.Fatal("Failed to create signer:", err)
+		logger.Fatal("Failed to read key passphrase:", err)
 	}
 
-	host, err := p2p.NewHost(ctx, cfg.ListenAddr, cfg.DHTBootstrapPeers)
+	// A persisted node key keeps the peer ID stable, so the binding to the
+	// receipt identity below stays meaningful across restarts
+	nodeKey, _, err := p2p.LoadOrGenerateKey(cfg.NodeKeyPath, codec)
+	if err != nil {
+		logger.Fatal("Failed to load node key:", err)
+	}
+
+	host, err := p2p.NewHostWithKey(ctx, nodeKey, cfg.ListenAddr, cfg.DHTBootstrapPeers)
 	if err != nil {
 		logger.Fatal("Failed to create P2P host:", err)
 	}
@@ -57,14 +71,51 @@ func main() {
 	connMgr.Start()
 	defer connMgr.Stop()
 
+	receiptJournal, err := journal.Open(cfg.JournalPath)
+	if err != nil {
+		logger.Fatal("Failed to open receipt journal:", err)
+	}
+	defer receiptJournal.Close()
+
+	if n := receiptJournal.RecoveredBytes(); n > 0 {
+		logger.Warnf("Receipt journal: discarded %d bytes of an incomplete entry", n)
+	}
+	headSeq, _ := receiptJournal.Head()
+	logger.Infof("Receipt chain resumes after seq %d", headSeq)
+
+	if recovered, err := rotation.Recover(cfg.PrivateKeyPath, codec, receiptJournal); err != nil {
+		logger.Fatal("Failed to recover interrupted key rotation:", err)
+	} else if recovered {
+		logger.Warn("Completed an interrupted key rotation")
+	}
+
+	signer, err := receipt.NewSignerWithCodec(cfg.PrivateKeyPath, codec)
+	if err != nil {
+		logger.Fatal("Failed to create signer:", err)
+	}
+
 	llmClient := llm.NewClient(cfg.OllamaURL)
 
 	handler := stream.NewHandler(
 		llmClient,
 		signer,
+		receiptJournal,
 		cfg.MaxPromptBytes,
 		cfg.TokensPerSecond,
 	)
+	handler.SetCheckpointInterval(cfg.CheckpointTokens)
+
+	schedule, err := epochSchedule(cfg)
+	if err != nil {
+		logger.Fatal("Failed to load epoch schedule:", err)
+	}
+	handler.SetSchedule(schedule)
+
+	binding, err := signer.BindPeer(host.ID().String(), time.Now())
+	if err != nil {
+		logger.Fatal("Failed to bind peer ID to signing key:", err)
+	}
+	handler.SetPeerBinding(binding)
 
 	host.SetStreamHandler(protocol.ID(protocolID), handler.HandleStream)
 
@@ -86,6 +137,7 @@ func main() {
 			})
 		})
 		mux.Handle("/metrics", promhttp.Handler())
+		mux.Handle("/receipts", receiptJournal)
 		port := os.Getenv("PROVIDER_METRICS_PORT")
 		if port == "" {
 			port = "8090"
@@ -129,6 +181,7 @@ func main() {
 	}
 	logger.Infof("Bootstrap peers: %v", cfg.DHTBootstrapPeers)
 	logger.Infof("Public Key: %s", signer.PublicKeyBase64())
+	logger.Infof("Signing Key ID: %s", signer.KeyID())
 
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
@@ -136,3 +189,11 @@ func main() {
 
 	logger.Info("Shutting down...")
 }
+
+// epochSchedule loads the configured epoch schedule, or the default one.
+func epochSchedule(cfg *config.Config) (*epoch.Schedule, error) {
+	if cfg.EpochSchedulePath == "" {
+		return epoch.DefaultSchedule(), nil
+	}
+	return epoch.LoadSchedule(cfg.EpochSchedulePath)
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -10,10 +11,17 @@ type Config struct {
 	ListenAddr        string
 	OllamaURL         string
 	PrivateKeyPath    string
+	NodeKeyPath       string
+	KeyPassphraseFile string
+	JournalPath       string
 	MaxPromptBytes    int
+	CheckpointTokens  int
 	TokensPerSecond   int
 	RequestTimeout    time.Duration
 	DHTBootstrapPeers []string
+	// EpochSchedulePath, if set, names a JSON epoch schedule; it must be
+	// the aggregator's. Without one epochs are whole UTC days.
+	EpochSchedulePath string
 }
 
 func DefaultConfig() *Config {
@@ -21,7 +29,10 @@ func DefaultConfig() *Config {
 		ListenAddr:        "/ip4/0.0.0.0/tcp/4003",
 		OllamaURL:         "http://127.0.0.1:11434",
 		PrivateKeyPath:    "provider.key",
+		NodeKeyPath:       "node.key",
+		JournalPath:       "receipts.journal",
 		MaxPromptBytes:    4096,
+		CheckpointTokens:  32,
 		TokensPerSecond:   10,
 		RequestTimeout:    30 * time.Second,
 		DHTBootstrapPeers: []string{},
@@ -35,6 +46,28 @@ func DefaultConfig() *Config {
 	if ollamaURL := os.Getenv("QUIVER_OLLAMA_URL"); ollamaURL != "" {
 		cfg.OllamaURL = ollamaURL
 	}
+
+	if nodeKeyPath := os.Getenv("QUIVER_NODE_KEY"); nodeKeyPath != "" {
+		cfg.NodeKeyPath = nodeKeyPath
+	}
+
+	if passphraseFile := os.Getenv("QUIVER_KEY_PASSPHRASE_FILE"); passphraseFile != "" {
+		cfg.KeyPassphraseFile = passphraseFile
+	}
+
+	if journalPath := os.Getenv("QUIVER_JOURNAL_PATH"); journalPath != "" {
+		cfg.JournalPath = journalPath
+	}
+
+	if checkpoint := os.Getenv("QUIVER_CHECKPOINT_TOKENS"); checkpoint != "" {
+		if n, err := strconv.Atoi(checkpoint); err == nil && n > 0 {
+			cfg.CheckpointTokens = n
+		}
+	}
+
+	if schedule := os.Getenv("QUIVER_EPOCH_SCHEDULE"); schedule != "" {
+		cfg.EpochSchedulePath = schedule
+	}
 	
 	return cfg
 }
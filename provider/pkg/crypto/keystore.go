@@ -7,135 +7,305 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
 )
 
 const (
-	saltSize   = 32
-	keySize    = 32
-	iterations = 100000
+	saltSize = 32
+	keySize  = 32
+
+	// KeystoreVersion is the current keystore file format.
+	KeystoreVersion = 2
+
+	// CipherAESGCM is the authenticated cipher used by version 2 files.
+	CipherAESGCM = "AES-256-GCM"
+	// cipherLegacyCFB is the unauthenticated cipher of version 1 files,
+	// still readable so they can be migrated.
+	cipherLegacyCFB = "AES-256-CFB"
+
+	// KDFArgon2id and KDFScrypt are the supported key derivation functions.
+	KDFArgon2id = "argon2id"
+	KDFScrypt   = "scrypt"
+
+	// keystoreAAD binds the ciphertext to its purpose.
+	keystoreAAD = "QUIVER-KEYSTORE-V2"
 )
 
-// EncryptedKey represents an encrypted private key
-type EncryptedKey struct {
-	Algorithm  string `json:"algorithm"`
-	Salt       string `json:"salt"`
-	IV         string `json:"iv"`
-	Ciphertext string `json:"ciphertext"`
-	Iterations int    `json:"iterations"`
-}
+// ErrWrongPassphrase means the keystore could not be decrypted, either
+// because the passphrase is wrong or because the file was modified.
+var ErrWrongPassphrase = errors.New("wrong passphrase or corrupted keystore")
 
-// Keystore handles secure key storage
-type Keystore struct {
-	filepath string
+// KDFParams are the key derivation settings stored in a keystore file, so
+// they can be raised for new files without breaking old ones.
+type KDFParams struct {
+	Name string `json:"name"`
+	Salt string `json:"salt"`
+	// Argon2id
+	Time    uint32 `json:"time,omitempty"`
+	Memory  uint32 `json:"memory,omitempty"` // KiB
+	Threads uint8  `json:"threads,omitempty"`
+	// scrypt
+	N int `json:"n,omitempty"`
+	R int `json:"r,omitempty"`
+	P int `json:"p,omitempty"`
 }
 
-// NewKeystore creates a new keystore
-func NewKeystore(filepath string) *Keystore {
-	return &Keystore{
-		filepath: filepath,
+// DefaultKDF returns the parameters used for new keystores: Argon2id with
+// 64 MiB of memory, following the RFC 9106 second recommended option.
+func DefaultKDF() KDFParams {
+	return KDFParams{
+		Name:    KDFArgon2id,
+		Time:    3,
+		Memory:  64 * 1024,
+		Threads: 4,
 	}
 }
 
-// StoreKey encrypts and stores a private key
-func (ks *Keystore) StoreKey(privateKey []byte, passphrase string) error {
-	// Generate salt
+// EncryptedKey represents an encrypted private key. Version 2 files use
+// AES-256-GCM with the KDF described by KDF; files without a version are the
+// legacy PBKDF2 and AES-CFB format.
+type EncryptedKey struct {
+	Version    int        `json:"version,omitempty"`
+	Algorithm  string     `json:"algorithm"`
+	KDF        *KDFParams `json:"kdf,omitempty"`
+	Nonce      string     `json:"nonce,omitempty"`
+	Ciphertext string     `json:"ciphertext"`
+	// Legacy fields
+	Salt       string `json:"salt,omitempty"`
+	IV         string `json:"iv,omitempty"`
+	Iterations int    `json:"iterations,omitempty"`
+}
+
+// Seal encrypts privateKey under passphrase.
+func Seal(privateKey []byte, passphrase string, kdf KDFParams) (*EncryptedKey, error) {
+	if passphrase == "" {
+		return nil, errors.New("empty passphrase")
+	}
+
 	salt := make([]byte, saltSize)
 	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
-		return fmt.Errorf("failed to generate salt: %w", err)
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
 	}
+	kdf.Salt = base64.StdEncoding.EncodeToString(salt)
 
-	// Derive key from passphrase
-	key := pbkdf2.Key([]byte(passphrase), salt, iterations, keySize, sha256.New)
-
-	// Create cipher
-	block, err := aes.NewCipher(key)
+	key, err := deriveKey(passphrase, &kdf)
 	if err != nil {
-		return fmt.Errorf("failed to create cipher: %w", err)
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
 	}
 
-	// Generate IV
-	iv := make([]byte, aes.BlockSize)
-	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
-		return fmt.Errorf("failed to generate IV: %w", err)
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
 	}
 
-	// Encrypt the key
-	stream := cipher.NewCFBEncrypter(block, iv)
-	ciphertext := make([]byte, len(privateKey))
-	stream.XORKeyStream(ciphertext, privateKey)
+	return &EncryptedKey{
+		Version:    KeystoreVersion,
+		Algorithm:  CipherAESGCM,
+		KDF:        &kdf,
+		Nonce:      base64.StdEncoding.EncodeToString(nonce),
+		Ciphertext: base64.StdEncoding.EncodeToString(gcm.Seal(nil, nonce, privateKey, []byte(keystoreAAD))),
+	}, nil
+}
 
-	// Create encrypted key structure
-	encKey := EncryptedKey{
-		Algorithm:  "AES-256-CFB",
-		Salt:       base64.StdEncoding.EncodeToString(salt),
-		IV:         base64.StdEncoding.EncodeToString(iv),
-		Ciphertext: base64.StdEncoding.EncodeToString(ciphertext),
-		Iterations: iterations,
+// Open decrypts an encrypted key. Legacy files are unauthenticated, so a
+// wrong passphrase yields garbage rather than an error; callers should check
+// the result is a well-formed key.
+func Open(enc *EncryptedKey, passphrase string) ([]byte, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(enc.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode ciphertext: %w", err)
 	}
 
-	// Save to file
-	data, err := json.MarshalIndent(encKey, "", "  ")
+	switch enc.Algorithm {
+	case CipherAESGCM:
+		if enc.KDF == nil {
+			return nil, errors.New("keystore has no KDF parameters")
+		}
+		nonce, err := base64.StdEncoding.DecodeString(enc.Nonce)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode nonce: %w", err)
+		}
+		key, err := deriveKey(passphrase, enc.KDF)
+		if err != nil {
+			return nil, err
+		}
+		gcm, err := newGCM(key)
+		if err != nil {
+			return nil, err
+		}
+		if len(nonce) != gcm.NonceSize() {
+			return nil, errors.New("invalid nonce size")
+		}
+		plaintext, err := gcm.Open(nil, nonce, ciphertext, []byte(keystoreAAD))
+		if err != nil {
+			return nil, ErrWrongPassphrase
+		}
+		return plaintext, nil
+
+	case cipherLegacyCFB:
+		return openLegacy(enc, ciphertext, passphrase)
+
+	default:
+		return nil, fmt.Errorf("unsupported keystore cipher %q", enc.Algorithm)
+	}
+}
+
+func openLegacy(enc *EncryptedKey, ciphertext []byte, passphrase string) ([]byte, error) {
+	salt, err := base64.StdEncoding.DecodeString(enc.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode salt: %w", err)
+	}
+	iv, err := base64.StdEncoding.DecodeString(enc.IV)
 	if err != nil {
-		return fmt.Errorf("failed to marshal encrypted key: %w", err)
+		return nil, fmt.Errorf("failed to decode IV: %w", err)
+	}
+	if len(iv) != aes.BlockSize {
+		return nil, errors.New("invalid IV size")
 	}
 
-	// Write with restricted permissions
-	if err := os.WriteFile(ks.filepath, data, 0600); err != nil {
-		return fmt.Errorf("failed to write keystore: %w", err)
+	key := pbkdf2.Key([]byte(passphrase), salt, enc.Iterations, keySize, sha256.New)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
 	}
 
-	return nil
+	stream := cipher.NewCFBDecrypter(block, iv)
+	plaintext := make([]byte, len(ciphertext))
+	stream.XORKeyStream(plaintext, ciphertext)
+	return plaintext, nil
 }
 
-// LoadKey decrypts and loads a private key
-func (ks *Keystore) LoadKey(passphrase string) ([]byte, error) {
-	// Read encrypted key
-	data, err := os.ReadFile(ks.filepath)
+// deriveKey runs the KDF described by params. Parameters come from the
+// keystore file, so they are bounded to keep a crafted file from exhausting
+// memory.
+func deriveKey(passphrase string, params *KDFParams) ([]byte, error) {
+	salt, err := base64.StdEncoding.DecodeString(params.Salt)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read keystore: %w", err)
+		return nil, fmt.Errorf("failed to decode salt: %w", err)
+	}
+	if len(salt) < 16 {
+		return nil, errors.New("KDF salt too short")
 	}
 
-	var encKey EncryptedKey
-	if err := json.Unmarshal(data, &encKey); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal encrypted key: %w", err)
+	switch params.Name {
+	case KDFArgon2id:
+		if params.Time < 1 || params.Time > 64 || params.Memory < 8*1024 || params.Memory > 4*1024*1024 || params.Threads < 1 {
+			return nil, errors.New("argon2id parameters out of range")
+		}
+		return argon2.IDKey([]byte(passphrase), salt, params.Time, params.Memory, params.Threads, keySize), nil
+	case KDFScrypt:
+		if params.N < 1<<14 || params.N > 1<<22 || params.R < 1 || params.R > 32 || params.P < 1 || params.P > 16 {
+			return nil, errors.New("scrypt parameters out of range")
+		}
+		return scrypt.Key([]byte(passphrase), salt, params.N, params.R, params.P, keySize)
+	default:
+		return nil, fmt.Errorf("unsupported KDF %q", params.Name)
 	}
+}
 
-	// Decode base64
-	salt, err := base64.StdEncoding.DecodeString(encKey.Salt)
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode salt: %w", err)
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
 	}
+	return cipher.NewGCM(block)
+}
+
+// Codec encrypts private keys for storage with a passphrase. Decode also
+// accepts plaintext keys and legacy keystores and reports them as stale, so
+// callers can rewrite them in the current format.
+type Codec struct {
+	passphrase string
+	kdf        KDFParams
+}
 
-	iv, err := base64.StdEncoding.DecodeString(encKey.IV)
+// NewCodec creates a codec for passphrase using DefaultKDF.
+func NewCodec(passphrase string) *Codec {
+	return &Codec{passphrase: passphrase, kdf: DefaultKDF()}
+}
+
+// Encode returns keystore file contents holding privateKey.
+func (c *Codec) Encode(privateKey []byte) ([]byte, error) {
+	enc, err := Seal(privateKey, c.passphrase, c.kdf)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode IV: %w", err)
+		return nil, err
 	}
+	return json.MarshalIndent(enc, "", "  ")
+}
 
-	ciphertext, err := base64.StdEncoding.DecodeString(encKey.Ciphertext)
+// Decode returns the private key held in data and whether data should be
+// re-encoded. Data that is not a keystore is taken to be a plaintext key.
+func (c *Codec) Decode(data []byte) ([]byte, bool, error) {
+	var enc EncryptedKey
+	if err := json.Unmarshal(data, &enc); err != nil || enc.Ciphertext == "" {
+		return data, true, nil
+	}
+
+	key, err := Open(&enc, c.passphrase)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode ciphertext: %w", err)
+		return nil, false, err
 	}
+	return key, enc.Version < KeystoreVersion, nil
+}
 
-	// Derive key from passphrase
-	key := pbkdf2.Key([]byte(passphrase), salt, encKey.Iterations, keySize, sha256.New)
+// Keystore handles secure key storage
+type Keystore struct {
+	filepath string
+}
 
-	// Create cipher
-	block, err := aes.NewCipher(key)
+// NewKeystore creates a new keystore
+func NewKeystore(filepath string) *Keystore {
+	return &Keystore{
+		filepath: filepath,
+	}
+}
+
+// StoreKey encrypts and stores a private key
+func (ks *Keystore) StoreKey(privateKey []byte, passphrase string) error {
+	data, err := NewCodec(passphrase).Encode(privateKey)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create cipher: %w", err)
+		return fmt.Errorf("failed to encrypt key: %w", err)
 	}
 
-	// Decrypt
-	stream := cipher.NewCFBDecrypter(block, iv)
-	plaintext := make([]byte, len(ciphertext))
-	stream.XORKeyStream(plaintext, ciphertext)
+	// Write with restricted permissions
+	if err := writeFileAtomic(ks.filepath, data); err != nil {
+		return fmt.Errorf("failed to write keystore: %w", err)
+	}
 
-	return plaintext, nil
+	return nil
+}
+
+// LoadKey decrypts and loads a private key. Plaintext and legacy files are
+// rewritten in the current format.
+func (ks *Keystore) LoadKey(passphrase string) ([]byte, error) {
+	data, err := os.ReadFile(ks.filepath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore: %w", err)
+	}
+
+	key, stale, err := NewCodec(passphrase).Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	if stale {
+		if err := ks.StoreKey(key, passphrase); err != nil {
+			return nil, err
+		}
+	}
+
+	return key, nil
 }
 
 // Exists checks if keystore file exists
@@ -165,6 +335,34 @@ func (ks *Keystore) GenerateAndStore(keyType string, passphrase string) error {
 	return ks.StoreKey(privateKey, passphrase)
 }
 
+// WriteFileAtomic replaces path with data, readable only by the owner. The
+// data is synced before the rename so a crash leaves either the old or the
+// new file.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
 // GenerateEd25519Key generates a new Ed25519 private key
 func GenerateEd25519Key() ([]byte, error) {
 	// Implementation would use crypto/ed25519
@@ -181,4 +379,4 @@ func GenerateSecp256k1Key() ([]byte, error) {
 	key := make([]byte, 32)
 	_, err := io.ReadFull(rand.Reader, key)
 	return key, err
-}
\ No newline at end of file
+}
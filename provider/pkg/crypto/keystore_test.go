@@ -0,0 +1,199 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/term"
+)
+
+// Cheap parameters keep the tests fast; they are still inside the bounds
+// deriveKey accepts.
+var (
+	testArgon2 = KDFParams{Name: KDFArgon2id, Time: 1, Memory: 8 * 1024, Threads: 1}
+	testScrypt = KDFParams{Name: KDFScrypt, N: 1 << 14, R: 8, P: 1}
+)
+
+func testCodec(passphrase string) *Codec {
+	return &Codec{passphrase: passphrase, kdf: testArgon2}
+}
+
+// sealLegacy writes key in the version 1 PBKDF2 and AES-CFB format.
+func sealLegacy(t *testing.T, key []byte, passphrase string) []byte {
+	salt := make([]byte, saltSize)
+	iv := make([]byte, aes.BlockSize)
+	rand.Read(salt)
+	rand.Read(iv)
+
+	block, err := aes.NewCipher(pbkdf2.Key([]byte(passphrase), salt, 1000, keySize, sha256.New))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext := make([]byte, len(key))
+	cipher.NewCFBEncrypter(block, iv).XORKeyStream(ciphertext, key)
+
+	data, err := json.Marshal(EncryptedKey{
+		Algorithm:  cipherLegacyCFB,
+		Salt:       base64.StdEncoding.EncodeToString(salt),
+		IV:         base64.StdEncoding.EncodeToString(iv),
+		Ciphertext: base64.StdEncoding.EncodeToString(ciphertext),
+		Iterations: 1000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestSealOpen(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+
+	for _, kdf := range []KDFParams{testArgon2, testScrypt} {
+		t.Run(kdf.Name, func(t *testing.T) {
+			enc, err := Seal(key, "correct horse", kdf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if enc.Version != KeystoreVersion || enc.Algorithm != CipherAESGCM || enc.KDF.Name != kdf.Name {
+				t.Fatalf("unexpected header: %+v", enc)
+			}
+
+			got, err := Open(enc, "correct horse")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, key) {
+				t.Error("round trip changed the key")
+			}
+
+			if _, err := Open(enc, "wrong"); !errors.Is(err, ErrWrongPassphrase) {
+				t.Errorf("wrong passphrase: %v", err)
+			}
+
+			tampered := *enc
+			ciphertext, _ := base64.StdEncoding.DecodeString(enc.Ciphertext)
+			ciphertext[0] ^= 1
+			tampered.Ciphertext = base64.StdEncoding.EncodeToString(ciphertext)
+			if _, err := Open(&tampered, "correct horse"); !errors.Is(err, ErrWrongPassphrase) {
+				t.Errorf("tampered ciphertext: %v", err)
+			}
+		})
+	}
+}
+
+func TestOpenRejectsExcessiveKDF(t *testing.T) {
+	enc, err := Seal([]byte("key"), "pass", testArgon2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc.KDF.Memory = 1 << 30
+	if _, err := Open(enc, "pass"); err == nil {
+		t.Error("accepted a 1 TiB argon2id memory cost")
+	}
+
+	enc.KDF = &KDFParams{Name: "pbkdf2", Salt: enc.KDF.Salt}
+	if _, err := Open(enc, "pass"); err == nil {
+		t.Error("accepted an unknown KDF")
+	}
+}
+
+func TestCodecDecode(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	codec := testCodec("pass")
+
+	current, err := codec.Encode(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		data  []byte
+		stale bool
+	}{
+		{"current", current, false},
+		{"legacy", sealLegacy(t, key, "pass"), true},
+		{"plaintext", key, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, stale, err := codec.Decode(tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, key) || stale != tt.stale {
+				t.Errorf("Decode = %x, stale %v", got, stale)
+			}
+		})
+	}
+
+	if _, _, err := testCodec("wrong").Decode(current); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("wrong passphrase: %v", err)
+	}
+}
+
+func TestKeystoreMigratesLegacy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "node.key")
+	key := []byte("0123456789abcdef0123456789abcdef")
+	if err := os.WriteFile(path, sealLegacy(t, key, "pass"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := NewKeystore(path).LoadKey("pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, key) {
+		t.Fatal("legacy key changed")
+	}
+
+	data, _ := os.ReadFile(path)
+	var enc EncryptedKey
+	if err := json.Unmarshal(data, &enc); err != nil {
+		t.Fatal(err)
+	}
+	if enc.Version != KeystoreVersion || enc.Algorithm != CipherAESGCM {
+		t.Errorf("keystore not upgraded: %+v", enc)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("keystore mode %v", info.Mode().Perm())
+	}
+}
+
+func TestReadPassphrase(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "passphrase")
+	if err := os.WriteFile(file, []byte("from file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(EnvPassphrase, "from env")
+
+	if got, err := ReadPassphrase(file, false); err != nil || got != "from file" {
+		t.Errorf("file: %q, %v", got, err)
+	}
+	if got, err := ReadPassphrase("", false); err != nil || got != "from env" {
+		t.Errorf("env: %q, %v", got, err)
+	}
+
+	t.Setenv(EnvPassphrase, "")
+	if _, err := ReadPassphrase("", false); err == nil {
+		t.Error("accepted an empty passphrase")
+	}
+
+	os.Unsetenv(EnvPassphrase)
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		t.Skip("stdin is a terminal")
+	}
+	if _, err := ReadPassphrase("", false); !errors.Is(err, ErrNoPassphrase) {
+		t.Errorf("no source: %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package crypto
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+const (
+	// EnvPassphrase holds the key passphrase directly.
+	EnvPassphrase = "QUIVER_KEY_PASSPHRASE"
+	// EnvPassphraseFile names a file holding the key passphrase, such as a
+	// mounted secret.
+	EnvPassphraseFile = "QUIVER_KEY_PASSPHRASE_FILE"
+)
+
+// ErrNoPassphrase means no passphrase was configured and there is no
+// terminal to prompt on.
+var ErrNoPassphrase = fmt.Errorf("no key passphrase: set %s or %s", EnvPassphrase, EnvPassphraseFile)
+
+// ReadPassphrase returns the key passphrase from file if set, then from
+// EnvPassphrase, then by prompting on the terminal. With confirm the prompt
+// asks twice, for use when a new key is created.
+func ReadPassphrase(file string, confirm bool) (string, error) {
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("failed to read passphrase file: %w", err)
+		}
+		return nonEmpty(strings.TrimRight(string(data), "\r\n"))
+	}
+
+	if passphrase, ok := os.LookupEnv(EnvPassphrase); ok {
+		return nonEmpty(passphrase)
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", ErrNoPassphrase
+	}
+
+	passphrase, err := prompt(fd, "Key passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if confirm {
+		again, err := prompt(fd, "Confirm passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if again != passphrase {
+			return "", errors.New("passphrases do not match")
+		}
+	}
+	return nonEmpty(passphrase)
+}
+
+func prompt(fd int, label string) (string, error) {
+	fmt.Fprint(os.Stderr, label)
+	data, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	return string(data), nil
+}
+
+func nonEmpty(passphrase string) (string, error) {
+	if passphrase == "" {
+		return "", errors.New("empty key passphrase")
+	}
+	return passphrase, nil
+}
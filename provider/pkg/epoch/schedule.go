@@ -0,0 +1,167 @@
+package epoch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"time"
+)
+
+// ErrOutOfRange means an epoch is too far from genesis for its bounds to be
+// computed.
+var ErrOutOfRange = errors.New("epoch out of range")
+
+// Schedule numbers epochs. Epoch 0 starts at the genesis time and each
+// period sets the length of the epochs from its start epoch up to the next
+// period's, so the schedule can change at an epoch boundary without
+// renumbering earlier epochs. aggregator/pkg/epoch has the same type; both are
+// tested against testvectors/epoch_schedule.json.
+type Schedule struct {
+	genesis time.Time
+	periods []Period
+	// starts holds the start time of each period
+	starts []time.Time
+}
+
+// Period is a run of epochs of one length.
+type Period struct {
+	StartEpoch uint64
+	Length     time.Duration
+}
+
+type scheduleJSON struct {
+	Genesis time.Time    `json:"genesis"`
+	Periods []periodJSON `json:"periods"`
+}
+
+type periodJSON struct {
+	StartEpoch uint64 `json:"start_epoch"`
+	Length     string `json:"length"`
+}
+
+// DefaultSchedule is one-day epochs from the Unix epoch, the numbering used
+// before schedules were configurable.
+func DefaultSchedule() *Schedule {
+	s, _ := NewSchedule(time.Unix(0, 0), []Period{{StartEpoch: 0, Length: 24 * time.Hour}})
+	return s
+}
+
+// NewSchedule checks that periods start at epoch 0, are in ascending order
+// and have positive lengths in whole seconds.
+func NewSchedule(genesis time.Time, periods []Period) (*Schedule, error) {
+	if genesis.IsZero() {
+		return nil, errors.New("schedule has no genesis time")
+	}
+	if len(periods) == 0 {
+		return nil, errors.New("schedule has no periods")
+	}
+	if periods[0].StartEpoch != 0 {
+		return nil, errors.New("first period must start at epoch 0")
+	}
+
+	s := &Schedule{
+		genesis: genesis.UTC(),
+		periods: append([]Period(nil), periods...),
+		starts:  make([]time.Time, len(periods)),
+	}
+	s.starts[0] = s.genesis
+	for i, p := range periods {
+		if p.Length <= 0 || p.Length%time.Second != 0 {
+			return nil, fmt.Errorf("period %d: length %s is not a positive number of seconds", i, p.Length)
+		}
+		if i == 0 {
+			continue
+		}
+		prev := periods[i-1]
+		if p.StartEpoch <= prev.StartEpoch {
+			return nil, fmt.Errorf("period %d: start epoch %d is not after %d", i, p.StartEpoch, prev.StartEpoch)
+		}
+		span := p.StartEpoch - prev.StartEpoch
+		if span > uint64(math.MaxInt64/prev.Length) {
+			return nil, fmt.Errorf("period %d: starts too far after period %d", i, i-1)
+		}
+		s.starts[i] = s.starts[i-1].Add(time.Duration(span) * prev.Length)
+	}
+	return s, nil
+}
+
+// LoadSchedule reads a schedule from a JSON file.
+func LoadSchedule(path string) (*Schedule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s Schedule
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &s, nil
+}
+
+func (s *Schedule) UnmarshalJSON(data []byte) error {
+	var raw scheduleJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	periods := make([]Period, len(raw.Periods))
+	for i, p := range raw.Periods {
+		length, err := time.ParseDuration(p.Length)
+		if err != nil {
+			return fmt.Errorf("period %d: %w", i, err)
+		}
+		periods[i] = Period{StartEpoch: p.StartEpoch, Length: length}
+	}
+
+	parsed, err := NewSchedule(raw.Genesis, periods)
+	if err != nil {
+		return err
+	}
+	*s = *parsed
+	return nil
+}
+
+func (s *Schedule) MarshalJSON() ([]byte, error) {
+	raw := scheduleJSON{Genesis: s.genesis, Periods: make([]periodJSON, len(s.periods))}
+	for i, p := range s.periods {
+		raw.Periods[i] = periodJSON{StartEpoch: p.StartEpoch, Length: p.Length.String()}
+	}
+	return json.Marshal(raw)
+}
+
+// Genesis returns the start of epoch 0.
+func (s *Schedule) Genesis() time.Time {
+	return s.genesis
+}
+
+// EpochAt returns the epoch containing t. Times before genesis are in
+// epoch 0.
+func (s *Schedule) EpochAt(t time.Time) uint64 {
+	if t.Before(s.genesis) {
+		return 0
+	}
+	i := len(s.starts) - 1
+	for i > 0 && t.Before(s.starts[i]) {
+		i--
+	}
+	p := s.periods[i]
+	return p.StartEpoch + uint64(t.Sub(s.starts[i])/p.Length)
+}
+
+// Bounds returns the start of an epoch and the start of the next. Epochs
+// that start more than math.MaxInt64 nanoseconds into their period are out
+// of range.
+func (s *Schedule) Bounds(epoch uint64) (start, end time.Time, err error) {
+	i := len(s.periods) - 1
+	for i > 0 && epoch < s.periods[i].StartEpoch {
+		i--
+	}
+	p := s.periods[i]
+	offset := epoch - p.StartEpoch
+	if offset > uint64(math.MaxInt64/p.Length) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: %d", ErrOutOfRange, epoch)
+	}
+	start = s.starts[i].Add(time.Duration(offset) * p.Length)
+	return start, start.Add(p.Length), nil
+}
@@ -0,0 +1,112 @@
+package epoch
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+type scheduleVectors struct {
+	Schedule json.RawMessage `json:"schedule"`
+	EpochAt  []struct {
+		Time  time.Time `json:"time"`
+		Epoch uint64    `json:"epoch"`
+	} `json:"epoch_at"`
+	Bounds []struct {
+		Epoch uint64    `json:"epoch"`
+		Start time.Time `json:"start"`
+		End   time.Time `json:"end"`
+	} `json:"bounds"`
+	OutOfRange []uint64 `json:"out_of_range"`
+	Invalid    []struct {
+		Name     string          `json:"name"`
+		Schedule json.RawMessage `json:"schedule"`
+	} `json:"invalid"`
+}
+
+func loadScheduleVectors(t *testing.T) (*scheduleVectors, *Schedule) {
+	data, err := os.ReadFile("../../../testvectors/epoch_schedule.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var vectors scheduleVectors
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+	var s Schedule
+	if err := json.Unmarshal(vectors.Schedule, &s); err != nil {
+		t.Fatal(err)
+	}
+	return &vectors, &s
+}
+
+func TestScheduleVectors(t *testing.T) {
+	vectors, s := loadScheduleVectors(t)
+
+	for _, v := range vectors.EpochAt {
+		if got := s.EpochAt(v.Time); got != v.Epoch {
+			t.Errorf("EpochAt(%s) = %d, want %d", v.Time.Format(time.RFC3339), got, v.Epoch)
+		}
+	}
+
+	for _, v := range vectors.Bounds {
+		start, end, err := s.Bounds(v.Epoch)
+		if err != nil || !start.Equal(v.Start) || !end.Equal(v.End) {
+			t.Errorf("Bounds(%d) = %s, %s; want %s, %s", v.Epoch, start, end, v.Start, v.End)
+		}
+		// Every epoch contains its own start and not its end
+		if s.EpochAt(start) != v.Epoch || s.EpochAt(end) != v.Epoch+1 {
+			t.Errorf("epoch %d does not round-trip through its bounds", v.Epoch)
+		}
+	}
+
+	for _, n := range vectors.OutOfRange {
+		if start, _, err := s.Bounds(n); !errors.Is(err, ErrOutOfRange) {
+			t.Errorf("Bounds(%d) = %s, %v; want ErrOutOfRange", n, start, err)
+		}
+	}
+
+	for _, v := range vectors.Invalid {
+		t.Run(v.Name, func(t *testing.T) {
+			var s Schedule
+			if err := json.Unmarshal(v.Schedule, &s); err == nil {
+				t.Error("invalid schedule accepted")
+			}
+		})
+	}
+}
+
+func TestScheduleJSONRoundTrip(t *testing.T) {
+	_, s := loadScheduleVectors(t)
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var again Schedule
+	if err := json.Unmarshal(data, &again); err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []uint64{0, 9, 10, 58, 1000} {
+		a, _, _ := s.Bounds(n)
+		b, _, _ := again.Bounds(n)
+		if !a.Equal(b) {
+			t.Errorf("epoch %d moved from %s to %s", n, a, b)
+		}
+	}
+}
+
+func TestDefaultScheduleIsDays(t *testing.T) {
+	s := DefaultSchedule()
+	now := time.Now()
+	if got, want := s.EpochAt(now), uint64(now.Unix()/86400); got != want {
+		t.Errorf("EpochAt(now) = %d, want %d", got, want)
+	}
+	start, end, err := s.Bounds(19723)
+	if err != nil || start.Unix() != 19723*86400 || end.Sub(start) != 24*time.Hour {
+		t.Errorf("Bounds(19723) = %s, %s", start, end)
+	}
+}
@@ -0,0 +1,74 @@
+package journal
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/quiver/provider/pkg/receipt"
+)
+
+const (
+	defaultExportLimit = 500
+	maxExportLimit     = 5000
+)
+
+// ExportResponse is returned by GET /receipts.
+type ExportResponse struct {
+	Receipts  []*receipt.SignedReceipt `json:"receipts"`
+	HeadSeq   int64                    `json:"head_seq"`
+	HeadHash  string                   `json:"head_hash"`
+	NextSince int64                    `json:"next_since"`
+}
+
+// ServeHTTP implements GET /receipts?since=seq&limit=n. It returns committed
+// receipts with seq greater than since; clients page by passing next_since
+// back until it equals head_seq.
+func (j *Journal) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	since, err := queryInt(r, "since", 0)
+	if err != nil || since < 0 {
+		http.Error(w, "invalid since", http.StatusBadRequest)
+		return
+	}
+	limit, err := queryInt(r, "limit", defaultExportLimit)
+	if err != nil || limit <= 0 {
+		http.Error(w, "invalid limit", http.StatusBadRequest)
+		return
+	}
+	if limit > maxExportLimit {
+		limit = maxExportLimit
+	}
+
+	receipts, err := j.Since(since, int(limit))
+	if err != nil {
+		http.Error(w, "failed to read journal", http.StatusInternalServerError)
+		return
+	}
+
+	headSeq, headHash := j.Head()
+	next := since
+	if n := len(receipts); n > 0 {
+		next = receipts[n-1].Receipt.Seq
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ExportResponse{
+		Receipts:  receipts,
+		HeadSeq:   headSeq,
+		HeadHash:  headHash,
+		NextSince: next,
+	})
+}
+
+func queryInt(r *http.Request, key string, def int64) (int64, error) {
+	v := r.URL.Query().Get(key)
+	if v == "" {
+		return def, nil
+	}
+	return strconv.ParseInt(v, 10, 64)
+}
@@ -0,0 +1,310 @@
+// Package journal persists the provider's receipt hash chain. Every signed
+// receipt is appended to a newline-delimited JSON file and fsync'd before it
+// is handed to the client, so a restarted provider resumes the chain from
+// the last committed entry instead of starting over at seq 1.
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/quiver/provider/pkg/receipt"
+)
+
+// Entry is one line of the journal.
+type Entry struct {
+	Seq     int64                  `json:"seq"`
+	Hash    string                 `json:"hash"`
+	Receipt *receipt.SignedReceipt `json:"receipt"`
+}
+
+// BuildFunc produces the next signed receipt for the given chain position.
+type BuildFunc func(seq int64, prevHash string) (*receipt.SignedReceipt, error)
+
+// file is the part of *os.File the journal uses, so tests can make writes
+// fail.
+type file interface {
+	io.ReadWriteSeeker
+	io.ReaderAt
+	Truncate(size int64) error
+	Sync() error
+	Close() error
+}
+
+// Journal is an append-only, crash-safe log of signed receipts.
+type Journal struct {
+	mu        sync.Mutex
+	file      file
+	path      string
+	seq       int64
+	hash      string
+	offsets   []int64 // offsets[i] is where the entry with seq i+1 starts
+	size      int64
+	recovered int64
+}
+
+// Open opens or creates the journal at path and replays it. A torn final
+// entry left by a crash is truncated away; corruption anywhere else is an
+// error because truncating would discard committed receipts.
+func Open(path string) (*Journal, error) {
+	_, statErr := os.Stat(path)
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	if os.IsNotExist(statErr) {
+		// Make the new directory entry durable too
+		if err := syncDir(filepath.Dir(path)); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+
+	j := &Journal{
+		file: f,
+		path: path,
+	}
+
+	if err := j.replay(); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return j, nil
+}
+
+func (j *Journal) replay() error {
+	if _, err := j.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(j.file)
+	var offset int64
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(line) > 0 {
+				// Partial write without a trailing newline
+				return j.truncate(offset, int64(len(line)))
+			}
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		entry, verr := j.validate(line)
+		if verr != nil {
+			if _, perr := reader.Peek(1); perr == io.EOF {
+				return j.truncate(offset, int64(len(line)))
+			}
+			return fmt.Errorf("journal %s corrupt at offset %d: %w", j.path, offset, verr)
+		}
+
+		j.offsets = append(j.offsets, offset)
+		j.seq = entry.Seq
+		j.hash = entry.Hash
+		offset += int64(len(line))
+	}
+
+	j.size = offset
+	_, err := j.file.Seek(offset, io.SeekStart)
+	return err
+}
+
+// validate checks that line is the entry that extends the current head.
+func (j *Journal) validate(line []byte) (*Entry, error) {
+	var entry Entry
+	if err := json.Unmarshal(bytes.TrimSpace(line), &entry); err != nil {
+		return nil, err
+	}
+	if entry.Receipt == nil {
+		return nil, errors.New("entry has no receipt")
+	}
+
+	rcpt := &entry.Receipt.Receipt
+	if entry.Seq != j.seq+1 || rcpt.Seq != entry.Seq {
+		return nil, fmt.Errorf("expected seq %d, found %d", j.seq+1, entry.Seq)
+	}
+	if rcpt.PrevHash != j.hash {
+		return nil, fmt.Errorf("seq %d does not link to previous entry", entry.Seq)
+	}
+
+	hash, err := ChainHash(rcpt)
+	if err != nil {
+		return nil, err
+	}
+	if hash != entry.Hash {
+		return nil, fmt.Errorf("seq %d hash mismatch", entry.Seq)
+	}
+
+	return &entry, nil
+}
+
+func (j *Journal) truncate(offset, dropped int64) error {
+	if err := j.file.Truncate(offset); err != nil {
+		return err
+	}
+	if err := j.file.Sync(); err != nil {
+		return err
+	}
+	j.size = offset
+	j.recovered = dropped
+	_, err := j.file.Seek(offset, io.SeekStart)
+	return err
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// ChainHash is the value the next receipt carries as PrevHash.
+func ChainHash(r *receipt.Receipt) (string, error) {
+	canonical, err := receipt.CanonicalizeJSON(r)
+	if err != nil {
+		return "", err
+	}
+	return receipt.HashData(canonical), nil
+}
+
+// Head returns the sequence number and chain hash of the last committed entry.
+func (j *Journal) Head() (int64, string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.seq, j.hash
+}
+
+// RecoveredBytes reports how many bytes of a torn tail Open discarded.
+func (j *Journal) RecoveredBytes() int64 {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.recovered
+}
+
+// Commit reserves the next chain position, lets build produce the signed
+// receipt for it, and durably appends the result. The journal lock is held
+// throughout so concurrent requests cannot fork the chain.
+func (j *Journal) Commit(build BuildFunc) (*receipt.SignedReceipt, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.file == nil {
+		return nil, errors.New("journal closed")
+	}
+
+	seq := j.seq + 1
+	signed, err := build(seq, j.hash)
+	if err != nil {
+		return nil, err
+	}
+	if signed.Receipt.Seq != seq || signed.Receipt.PrevHash != j.hash {
+		return nil, fmt.Errorf("receipt does not extend chain at seq %d", seq)
+	}
+
+	hash, err := ChainHash(&signed.Receipt)
+	if err != nil {
+		return nil, err
+	}
+
+	line, err := json.Marshal(Entry{Seq: seq, Hash: hash, Receipt: signed})
+	if err != nil {
+		return nil, err
+	}
+	line = append(line, '\n')
+
+	if _, err := j.file.Write(line); err != nil {
+		j.rollback()
+		return nil, err
+	}
+	if err := j.file.Sync(); err != nil {
+		j.rollback()
+		return nil, err
+	}
+
+	j.offsets = append(j.offsets, j.size)
+	j.size += int64(len(line))
+	j.seq = seq
+	j.hash = hash
+
+	return signed, nil
+}
+
+// rollback drops whatever part of a failed append made it to the file, so
+// the next entry does not follow a torn one. If the file cannot be cut back
+// the journal is closed: Open recovers the torn tail on restart.
+func (j *Journal) rollback() {
+	if err := j.file.Truncate(j.size); err == nil {
+		if _, err := j.file.Seek(j.size, io.SeekStart); err == nil {
+			return
+		}
+	}
+	j.file.Close()
+	j.file = nil
+}
+
+// Since returns up to limit committed receipts with a sequence number
+// greater than since, in chain order.
+func (j *Journal) Since(since int64, limit int) ([]*receipt.SignedReceipt, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.file == nil {
+		return nil, errors.New("journal closed")
+	}
+	if since < 0 {
+		since = 0
+	}
+	if since >= j.seq || limit <= 0 {
+		return []*receipt.SignedReceipt{}, nil
+	}
+
+	start := j.offsets[since]
+	reader := bufio.NewReader(io.NewSectionReader(j.file, start, j.size-start))
+
+	result := make([]*receipt.SignedReceipt, 0)
+	for len(result) < limit {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var entry Entry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return nil, err
+		}
+		result = append(result, entry.Receipt)
+	}
+
+	return result, nil
+}
+
+// Close flushes and closes the journal file.
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Close()
+	j.file = nil
+	return err
+}
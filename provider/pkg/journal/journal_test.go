@@ -0,0 +1,318 @@
+package journal
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/quiver/provider/pkg/receipt"
+)
+
+func newTestSigner(t *testing.T) *receipt.Signer {
+	signer, err := receipt.NewSigner(filepath.Join(t.TempDir(), "test.key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+func buildWith(signer *receipt.Signer) BuildFunc {
+	return func(seq int64, prevHash string) (*receipt.SignedReceipt, error) {
+		now := time.Now()
+		rcpt, err := receipt.NewReceipt(signer.PublicKeyBase64(), "model", "p", "o", 1, 2, now, now)
+		if err != nil {
+			return nil, err
+		}
+		rcpt.Seq = seq
+		rcpt.PrevHash = prevHash
+		if err := receipt.SetReceiptID(rcpt); err != nil {
+			return nil, err
+		}
+		return signer.Sign(rcpt)
+	}
+}
+
+func TestCommitAndResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "receipts.journal")
+	signer := newTestSigner(t)
+
+	j, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var last *receipt.SignedReceipt
+	for i := 0; i < 5; i++ {
+		last, err = j.Commit(buildWith(signer))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	seq, hash := j.Head()
+	if seq != 5 || last.Receipt.Seq != 5 {
+		t.Fatalf("expected head seq 5, got %d", seq)
+	}
+	j.Close()
+
+	// Reopen and continue the chain
+	j, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+
+	resumedSeq, resumedHash := j.Head()
+	if resumedSeq != seq || resumedHash != hash {
+		t.Fatalf("resumed head (%d, %s), want (%d, %s)", resumedSeq, resumedHash, seq, hash)
+	}
+
+	next, err := j.Commit(buildWith(signer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.Receipt.Seq != 6 || next.Receipt.PrevHash != hash {
+		t.Errorf("next receipt does not extend the chain: seq=%d prev=%s", next.Receipt.Seq, next.Receipt.PrevHash)
+	}
+}
+
+func TestTornTailRecovery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "receipts.journal")
+	signer := newTestSigner(t)
+
+	j, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := j.Commit(buildWith(signer)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, hash := j.Head()
+	j.Close()
+
+	// Simulate a crash half way through writing entry 4
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"seq":4,"hash":"abc","receipt":{"rec`)
+	f.Close()
+
+	j, err = Open(path)
+	if err != nil {
+		t.Fatalf("torn tail should be recoverable: %v", err)
+	}
+	defer j.Close()
+
+	seq, recoveredHash := j.Head()
+	if seq != 3 || recoveredHash != hash {
+		t.Errorf("expected head seq 3 after recovery, got %d", seq)
+	}
+	if j.RecoveredBytes() == 0 {
+		t.Error("expected recovered byte count")
+	}
+
+	next, err := j.Commit(buildWith(signer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.Receipt.Seq != 4 {
+		t.Errorf("expected seq 4 after recovery, got %d", next.Receipt.Seq)
+	}
+}
+
+func TestMidFileCorruptionIsFatal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "receipts.journal")
+	signer := newTestSigner(t)
+
+	j, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := j.Commit(buildWith(signer)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	j.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Flip a byte inside the first entry's hash
+	for i := range data {
+		if data[i] == '"' && string(data[i:i+8]) == `"hash":"` {
+			data[i+8] ^= 0x01
+			break
+		}
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Open(path); err == nil {
+		t.Error("expected error for corruption before the tail")
+	}
+}
+
+func TestSinceAndExport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "receipts.journal")
+	signer := newTestSigner(t)
+
+	j, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+
+	for i := 0; i < 10; i++ {
+		if _, err := j.Commit(buildWith(signer)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	receipts, err := j.Since(7, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(receipts) != 3 || receipts[0].Receipt.Seq != 8 {
+		t.Fatalf("expected seqs 8-10, got %d receipts", len(receipts))
+	}
+
+	req := httptest.NewRequest("GET", "/receipts?since=2&limit=4", nil)
+	w := httptest.NewRecorder()
+	j.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	var resp ExportResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Receipts) != 4 || resp.NextSince != 6 || resp.HeadSeq != 10 {
+		t.Errorf("unexpected page: %d receipts, next_since=%d, head_seq=%d", len(resp.Receipts), resp.NextSince, resp.HeadSeq)
+	}
+
+	bad := httptest.NewRecorder()
+	j.ServeHTTP(bad, httptest.NewRequest("GET", "/receipts?since=abc", nil))
+	if bad.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid since, got %d", bad.Code)
+	}
+}
+
+func TestConcurrentCommitsFormOneChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "receipts.journal")
+	signer := newTestSigner(t)
+
+	j, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := j.Commit(buildWith(signer)); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	receipts, err := j.Since(0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prevHash := ""
+	for i, r := range receipts {
+		if r.Receipt.Seq != int64(i+1) || r.Receipt.PrevHash != prevHash {
+			t.Fatalf("chain broken at index %d", i)
+		}
+		prevHash, _ = ChainHash(&r.Receipt)
+	}
+}
+
+// failingFile fails the next write after writing part of it, or the next
+// sync, as a full disk or I/O error would.
+type failingFile struct {
+	file
+	failWrite bool
+	failSync  bool
+}
+
+func (f *failingFile) Write(p []byte) (int, error) {
+	if f.failWrite {
+		f.failWrite = false
+		n, _ := f.file.Write(p[:len(p)/2])
+		return n, errors.New("no space left on device")
+	}
+	return f.file.Write(p)
+}
+
+func (f *failingFile) Sync() error {
+	if f.failSync {
+		f.failSync = false
+		return errors.New("input/output error")
+	}
+	return f.file.Sync()
+}
+
+func TestFailedAppendIsRolledBack(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "receipts.journal")
+	signer := newTestSigner(t)
+
+	j, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failing := &failingFile{file: j.file}
+	j.file = failing
+
+	if _, err := j.Commit(buildWith(signer)); err != nil {
+		t.Fatal(err)
+	}
+	failing.failWrite = true
+	if _, err := j.Commit(buildWith(signer)); err == nil {
+		t.Fatal("commit with a failed write succeeded")
+	}
+	failing.failSync = true
+	if _, err := j.Commit(buildWith(signer)); err == nil {
+		t.Fatal("commit with a failed sync succeeded")
+	}
+	if seq, _ := j.Head(); seq != 1 {
+		t.Fatalf("failed commits moved head to seq %d", seq)
+	}
+
+	last, err := j.Commit(buildWith(signer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last.Receipt.Seq != 2 {
+		t.Errorf("commit after failures got seq %d, want 2", last.Receipt.Seq)
+	}
+	j.Close()
+
+	// Nothing of the failed appends is left for Open to recover
+	j, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+	if seq, _ := j.Head(); seq != 2 || j.RecoveredBytes() != 0 {
+		t.Errorf("reopened at seq %d with %d bytes recovered", seq, j.RecoveredBytes())
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -85,6 +86,93 @@ func (c *Client) Generate(ctx context.Context, prompt, model string) (*GenerateR
 	return &genResp, promptHash, outputHash, nil
 }
 
+// streamChunk is one line of Ollama's newline-delimited streaming response.
+type streamChunk struct {
+	Response        string `json:"response"`
+	Done            bool   `json:"done"`
+	TotalDuration   int64  `json:"total_duration"`
+	PromptEvalCount int    `json:"prompt_eval_count"`
+	EvalCount       int    `json:"eval_count"`
+}
+
+// ChunkFunc receives each generated chunk in order. Returning an error stops
+// generation.
+type ChunkFunc func(chunk string) error
+
+// GenerateStream runs a streaming generation and calls onChunk for every
+// chunk as it arrives. The returned response carries the assembled text and
+// final token counts; if generation stops early it holds what was produced
+// so far alongside the error.
+func (c *Client) GenerateStream(ctx context.Context, prompt, model string, onChunk ChunkFunc) (*GenerateResponse, error) {
+	req := GenerateRequest{
+		Model:       model,
+		Prompt:      prompt,
+		Temperature: 0,
+		Seed:        42,
+		Stream:      true,
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/generate", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	// The client timeout would cut long generations off mid-stream; ctx
+	// bounds the request instead
+	streamClient := *c.httpClient
+	streamClient.Timeout = 0
+
+	resp, err := streamClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("ollama error %d: %s", resp.StatusCode, string(body))
+	}
+
+	genResp := &GenerateResponse{Model: model}
+	var text strings.Builder
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var chunk streamChunk
+		if err := decoder.Decode(&chunk); err != nil {
+			genResp.Response = text.String()
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return genResp, err
+		}
+
+		if chunk.Response != "" {
+			text.WriteString(chunk.Response)
+			genResp.EvalCount++
+			if err := onChunk(chunk.Response); err != nil {
+				genResp.Response = text.String()
+				return genResp, err
+			}
+		}
+
+		if chunk.Done {
+			genResp.Response = text.String()
+			genResp.TotalDuration = chunk.TotalDuration
+			genResp.PromptEvalCount = chunk.PromptEvalCount
+			if chunk.EvalCount > 0 {
+				genResp.EvalCount = chunk.EvalCount
+			}
+			return genResp, nil
+		}
+	}
+}
+
 func hashString(s string) string {
 	h := sha256.Sum256([]byte(s))
 	return hex.EncodeToString(h[:])
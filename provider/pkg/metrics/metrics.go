@@ -37,4 +37,9 @@ var (
 		Name: "provider_receipt_signatures_total",
 		Help: "Total number of receipt signatures created",
 	})
+
+	CheckpointReceipts = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "provider_checkpoint_receipts_total",
+		Help: "Total number of interim checkpoint receipts signed for streamed generations",
+	})
 )
\ No newline at end of file
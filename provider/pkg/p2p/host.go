@@ -26,12 +26,19 @@ func (h *Host) GetHost() host.Host {
 	return h.host
 }
 
+// NewHost creates a host with a fresh, ephemeral identity.
 func NewHost(ctx context.Context, listenAddr string, bootstrapPeers []string) (*Host, error) {
 	priv, _, err := crypto.GenerateKeyPairWithReader(crypto.Ed25519, -1, rand.Reader)
 	if err != nil {
 		return nil, err
 	}
 
+	return NewHostWithKey(ctx, priv, listenAddr, bootstrapPeers)
+}
+
+// NewHostWithKey creates a host whose peer ID is derived from priv, so it
+// stays the same across restarts when priv is persisted.
+func NewHostWithKey(ctx context.Context, priv crypto.PrivKey, listenAddr string, bootstrapPeers []string) (*Host, error) {
 	listen, err := multiaddr.NewMultiaddr(listenAddr)
 	if err != nil {
 		return nil, err
@@ -8,8 +8,17 @@ import (
 	"github.com/libp2p/go-libp2p/core/crypto"
 )
 
-// LoadOrGenerateKey loads a private key from file or generates a new one
-func LoadOrGenerateKey(keyPath string) (crypto.PrivKey, crypto.PubKey, error) {
+// KeyCodec converts the node key to and from its on-disk form. It has the
+// same shape as receipt.KeyCodec, so one keystore codec serves both keys.
+type KeyCodec interface {
+	Encode(privateKey []byte) ([]byte, error)
+	Decode(data []byte) (privateKey []byte, stale bool, err error)
+}
+
+// LoadOrGenerateKey loads a private key from file or generates a new one.
+// With a codec the file is stored through it, and a key found in an older
+// format, such as plaintext, is rewritten.
+func LoadOrGenerateKey(keyPath string, codec KeyCodec) (crypto.PrivKey, crypto.PubKey, error) {
 	if keyPath == "" {
 		// Generate ephemeral key
 		return crypto.GenerateKeyPairWithReader(crypto.Ed25519, -1, rand.Reader)
@@ -18,16 +27,29 @@ func LoadOrGenerateKey(keyPath string) (crypto.PrivKey, crypto.PubKey, error) {
 	// Check if key file exists
 	if _, err := os.Stat(keyPath); err == nil {
 		// Load existing key
-		keyBytes, err := ioutil.ReadFile(keyPath)
+		data, err := ioutil.ReadFile(keyPath)
 		if err != nil {
 			return nil, nil, err
 		}
 
+		keyBytes, stale := data, false
+		if codec != nil {
+			if keyBytes, stale, err = codec.Decode(data); err != nil {
+				return nil, nil, err
+			}
+		}
+
 		priv, err := crypto.UnmarshalPrivateKey(keyBytes)
 		if err != nil {
 			return nil, nil, err
 		}
 
+		if stale {
+			if err := saveKey(keyPath, codec, keyBytes); err != nil {
+				return nil, nil, err
+			}
+		}
+
 		return priv, priv.GetPublic(), nil
 	}
 
@@ -43,9 +65,26 @@ func LoadOrGenerateKey(keyPath string) (crypto.PrivKey, crypto.PubKey, error) {
 		return nil, nil, err
 	}
 
-	if err := ioutil.WriteFile(keyPath, keyBytes, 0600); err != nil {
+	if err := saveKey(keyPath, codec, keyBytes); err != nil {
 		return nil, nil, err
 	}
 
 	return priv, pub, nil
-}
\ No newline at end of file
+}
+
+func saveKey(keyPath string, codec KeyCodec, keyBytes []byte) error {
+	data := keyBytes
+	if codec != nil {
+		var err error
+		if data, err = codec.Encode(keyBytes); err != nil {
+			return err
+		}
+	}
+
+	// Replace via rename so a crash never leaves a truncated key
+	tmp := keyPath + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, keyPath)
+}
@@ -83,7 +83,7 @@ func parseRelayAddrs(addrs []string) []peer.AddrInfo {
 
 // SetupRelayNode creates a dedicated relay node
 func SetupRelayNode(ctx context.Context, listenAddr string, privKeyPath string) (*Host, error) {
-	priv, _, err := LoadOrGenerateKey(privKeyPath)
+	priv, _, err := LoadOrGenerateKey(privKeyPath, nil)
 	if err != nil {
 		return nil, err
 	}
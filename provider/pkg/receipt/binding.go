@@ -0,0 +1,113 @@
+package receipt
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DomainPeerBindingV1 prefixes the signing input of peer bindings.
+const DomainPeerBindingV1 = "QUIVER-PEER-BINDING-V1"
+
+// ErrPeerMismatch means a peer binding names a different libp2p peer than
+// the one the verifier is talking to.
+var ErrPeerMismatch = errors.New("peer binding is for another peer")
+
+// PeerBinding states that the libp2p peer PeerID speaks for the receipt
+// identity ProviderPK. The transport authenticates the peer ID, and the
+// receipt key signs the binding, so a client that checks both knows the
+// peer it spoke to is the one signing its receipts.
+type PeerBinding struct {
+	ProviderPK string `json:"provider_pk"`
+	PeerID     string `json:"peer_id"`
+	IssuedAt   string `json:"issued_at"`
+}
+
+// SignedPeerBinding is a peer binding signed by the provider's current
+// receipt key, with the succession chain from the identity to that key.
+type SignedPeerBinding struct {
+	Binding    PeerBinding        `json:"binding"`
+	Algorithm  string             `json:"alg"`
+	KeyID      string             `json:"kid"`
+	Signature  string             `json:"signature"`
+	Succession []SignedSuccession `json:"succession,omitempty"`
+}
+
+// PeerBindingSigningInput returns the bytes the receipt key signs:
+// DomainPeerBindingV1 || 0x00 || JCS(binding).
+func PeerBindingSigningInput(b *PeerBinding) ([]byte, error) {
+	canonical, err := CanonicalizeJSON(b)
+	if err != nil {
+		return nil, err
+	}
+	return domainSeparate(DomainPeerBindingV1, canonical), nil
+}
+
+// BindPeer signs a binding of the libp2p peer peerID to this signer's
+// identity. A binding is issued at startup, so it always names the current
+// key.
+func (s *Signer) BindPeer(peerID string, issuedAt time.Time) (*SignedPeerBinding, error) {
+	b := PeerBinding{
+		ProviderPK: s.PublicKeyBase64(),
+		PeerID:     peerID,
+		IssuedAt:   issuedAt.UTC().Format(time.RFC3339),
+	}
+	input, err := PeerBindingSigningInput(&b)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SignedPeerBinding{
+		Binding:    b,
+		Algorithm:  AlgEd25519,
+		KeyID:      s.KeyID(),
+		Signature:  base64.StdEncoding.EncodeToString(ed25519.Sign(s.privateKey, input)),
+		Succession: s.Successions(),
+	}, nil
+}
+
+// VerifyPeerBinding checks that b binds peerID and is signed by a key the
+// identity in b has not retired. On success the caller should accept
+// receipts from peerID only if their provider_pk is b.Binding.ProviderPK.
+func VerifyPeerBinding(b *SignedPeerBinding, peerID string) error {
+	if b.Binding.PeerID != peerID {
+		return ErrPeerMismatch
+	}
+	if b.Algorithm != AlgEd25519 {
+		return fmt.Errorf("unsupported signature algorithm %q", b.Algorithm)
+	}
+
+	identity, err := base64.StdEncoding.DecodeString(b.Binding.ProviderPK)
+	if err != nil {
+		return err
+	}
+	if len(identity) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid public key size")
+	}
+
+	windows, err := resolveKeyChain(ed25519.PublicKey(identity), b.Succession)
+	if err != nil {
+		return err
+	}
+	// Like a checkpoint, a binding sits outside the hash chain, so only an
+	// unretired key may sign it
+	signingKey, err := keyForReceipt(windows, b.KeyID, 0)
+	if err != nil {
+		return err
+	}
+
+	input, err := PeerBindingSigningInput(&b.Binding)
+	if err != nil {
+		return err
+	}
+	signature, err := base64.StdEncoding.DecodeString(b.Signature)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(signingKey, input, signature) {
+		return errors.New("invalid peer binding signature")
+	}
+	return nil
+}
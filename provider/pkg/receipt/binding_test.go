@@ -0,0 +1,82 @@
+package receipt
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+type bindingVectors struct {
+	Provider struct {
+		SeedHex string `json:"seed_hex"`
+	} `json:"provider"`
+	Peer struct {
+		PeerID string `json:"peer_id"`
+	} `json:"peer"`
+	IdentitySigningInputHex string `json:"identity_signing_input_hex"`
+	Cases                   []struct {
+		Name   string            `json:"name"`
+		PeerID string            `json:"peer_id"`
+		Signed SignedPeerBinding `json:"signed"`
+		Valid  bool              `json:"valid"`
+	} `json:"cases"`
+}
+
+func loadBindingVectors(t *testing.T) *bindingVectors {
+	t.Helper()
+	data, err := os.ReadFile("../../../testvectors/peer_binding.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v bindingVectors
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatal(err)
+	}
+	return &v
+}
+
+func TestPeerBindingVectors(t *testing.T) {
+	v := loadBindingVectors(t)
+
+	for _, tc := range v.Cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := VerifyPeerBinding(&tc.Signed, tc.PeerID)
+			if (err == nil) != tc.Valid {
+				t.Errorf("VerifyPeerBinding = %v, want valid %v", err, tc.Valid)
+			}
+		})
+	}
+}
+
+func TestBindPeerMatchesVector(t *testing.T) {
+	v := loadBindingVectors(t)
+	want := v.Cases[0].Signed
+
+	issued, _ := time.Parse(time.RFC3339, want.Binding.IssuedAt)
+	got, err := newSigner(seedKey(t, v.Provider.SeedHex)).BindPeer(v.Peer.PeerID, issued)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input, err := PeerBindingSigningInput(&got.Binding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(input) != v.IdentitySigningInputHex {
+		t.Errorf("signing input %x", input)
+	}
+	if got.Signature != want.Signature || got.KeyID != want.KeyID {
+		t.Errorf("binding differs from vector: %+v", got)
+	}
+}
+
+func TestVerifyPeerBindingWrongPeer(t *testing.T) {
+	v := loadBindingVectors(t)
+	err := VerifyPeerBinding(&v.Cases[0].Signed, "12D3KooWSomeoneElse")
+	if !errors.Is(err, ErrPeerMismatch) {
+		t.Errorf("got %v, want ErrPeerMismatch", err)
+	}
+}
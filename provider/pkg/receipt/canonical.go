@@ -4,29 +4,32 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
-	"sort"
+	"reflect"
 	"time"
 
 	"github.com/mr-tron/base58"
+	"github.com/quiver/provider/pkg/epoch"
 )
 
 type Receipt struct {
-	Version    string   `json:"version"`
-	ProviderPK string   `json:"provider_pk"`
-	Model      string   `json:"model"`
-	PromptHash string   `json:"prompt_hash"`
-	OutputHash string   `json:"output_hash"`
-	TokensIn   int      `json:"tokens_in"`
-	TokensOut  int      `json:"tokens_out"`
-	StartISO   string   `json:"start_iso"`
-	EndISO     string   `json:"end_iso"`
-	DurationMs int64    `json:"duration_ms"`
-	Epoch      int64    `json:"epoch"`
-	Seq        int64    `json:"seq"`
-	PrevHash   string   `json:"prev_hash"`
-	Canary     Canary   `json:"canary"`
-	Rate       RateInfo `json:"rate"`
-	ReceiptID  string   `json:"receipt_id"`
+	Version    string            `json:"version"`
+	ProviderPK string            `json:"provider_pk"`
+	Model      string            `json:"model"`
+	PromptHash string            `json:"prompt_hash"`
+	OutputHash string            `json:"output_hash"`
+	TokensIn   int               `json:"tokens_in"`
+	TokensOut  int               `json:"tokens_out"`
+	StartISO   string            `json:"start_iso"`
+	EndISO     string            `json:"end_iso"`
+	DurationMs int64             `json:"duration_ms"`
+	Epoch      int64             `json:"epoch"`
+	Seq        int64             `json:"seq"`
+	PrevHash   string            `json:"prev_hash"`
+	Canary     Canary            `json:"canary"`
+	Rate       RateInfo          `json:"rate"`
+	Stream     *StreamInfo       `json:"stream,omitempty"`
+	KeyEvent   *SignedSuccession `json:"key_event,omitempty"`
+	ReceiptID  string            `json:"receipt_id"`
 }
 
 type Canary struct {
@@ -39,52 +42,35 @@ type RateInfo struct {
 	Truncated bool `json:"truncated"`
 }
 
+// SignedReceipt carries a receipt and its signature. Version 1 receipts only
+// set Signature; version 2 receipts also name the algorithm and signing key
+// and may carry co-signatures and, once the provider has rotated keys, the
+// succession chain from its identity key to the signing key. Both shapes
+// decode into this struct.
 type SignedReceipt struct {
-	Receipt   Receipt `json:"receipt"`
-	Signature string  `json:"signature"`
+	Receipt      Receipt            `json:"receipt"`
+	Signature    string             `json:"signature"`
+	Algorithm    string             `json:"alg,omitempty"`
+	KeyID        string             `json:"kid,omitempty"`
+	CoSignatures []CoSignature      `json:"cosignatures,omitempty"`
+	Succession   []SignedSuccession `json:"succession,omitempty"`
 }
 
+// CanonicalizeJSON serializes v and returns its RFC 8785 (JCS) canonical
+// form. Receipts are hashed and signed over these bytes, so any verifier that
+// implements JCS reproduces them exactly. Strings in v must be valid UTF-8.
 func CanonicalizeJSON(v interface{}) ([]byte, error) {
 	data, err := json.Marshal(v)
 	if err != nil {
 		return nil, err
 	}
-
-	var m map[string]interface{}
-	if err := json.Unmarshal(data, &m); err != nil {
+	// encoding/json replaces invalid UTF-8 in strings with U+FFFD, which
+	// cannot be told apart afterwards, so check the strings themselves
+	if err := checkStrings(reflect.ValueOf(v)); err != nil {
 		return nil, err
 	}
 
-	return json.Marshal(sortKeys(m))
-}
-
-func sortKeys(m map[string]interface{}) map[string]interface{} {
-	result := make(map[string]interface{})
-
-	keys := make([]string, 0, len(m))
-	for k := range m {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-
-	for _, k := range keys {
-		v := m[k]
-		switch val := v.(type) {
-		case map[string]interface{}:
-			result[k] = sortKeys(val)
-		case []interface{}:
-			for i, item := range val {
-				if mapItem, ok := item.(map[string]interface{}); ok {
-					val[i] = sortKeys(mapItem)
-				}
-			}
-			result[k] = val
-		default:
-			result[k] = v
-		}
-	}
-
-	return result
+	return TransformJCS(data)
 }
 
 func HashData(data []byte) string {
@@ -100,11 +86,11 @@ func GenerateReceiptID(hash string) string {
 	return base58.Encode(decoded)
 }
 
-func NewReceipt(providerPK, model, promptHash, outputHash string, tokensIn, tokensOut int, start, end time.Time) *Receipt {
-	epoch := start.UTC().Unix() / 86400
-
+// NewReceipt numbers the receipt's epoch by the default schedule; callers
+// with a configured schedule set Epoch before SetReceiptID.
+func NewReceipt(providerPK, model, promptHash, outputHash string, tokensIn, tokensOut int, start, end time.Time) (*Receipt, error) {
 	receipt := &Receipt{
-		Version:    "1.0.0",
+		Version:    VersionV2,
 		ProviderPK: providerPK,
 		Model:      model,
 		PromptHash: promptHash,
@@ -114,7 +100,7 @@ func NewReceipt(providerPK, model, promptHash, outputHash string, tokensIn, toke
 		StartISO:   start.UTC().Format(time.RFC3339),
 		EndISO:     end.UTC().Format(time.RFC3339),
 		DurationMs: end.Sub(start).Milliseconds(),
-		Epoch:      epoch,
+		Epoch:      int64(epoch.DefaultSchedule().EpochAt(start)),
 		Seq:        time.Now().UnixNano(),
 		PrevHash:   "",
 		Canary: Canary{
@@ -127,9 +113,20 @@ func NewReceipt(providerPK, model, promptHash, outputHash string, tokensIn, toke
 		},
 	}
 
-	canonical, _ := CanonicalizeJSON(receipt)
-	hash := HashData(canonical)
-	receipt.ReceiptID = GenerateReceiptID(hash)
+	if err := SetReceiptID(receipt); err != nil {
+		return nil, err
+	}
+	return receipt, nil
+}
 
-	return receipt
+// SetReceiptID derives ReceiptID from the rest of the receipt. Call it again
+// after changing any field, for example once Seq and PrevHash are assigned.
+func SetReceiptID(receipt *Receipt) error {
+	receipt.ReceiptID = ""
+	canonical, err := CanonicalizeJSON(receipt)
+	if err != nil {
+		return err
+	}
+	receipt.ReceiptID = GenerateReceiptID(HashData(canonical))
+	return nil
 }
@@ -132,10 +132,13 @@ func TestNewReceiptFields(t *testing.T) {
 	start := time.Now()
 	end := start.Add(100 * time.Millisecond)
 
-	receipt := NewReceipt("provider-key", "test-model", "prompt-hash", "output-hash", 10, 20, start, end)
+	receipt, err := NewReceipt("provider-key", "test-model", "prompt-hash", "output-hash", 10, 20, start, end)
+	if err != nil {
+		t.Fatal(err)
+	}
 
-	if receipt.Version != "1.0.0" {
-		t.Errorf("Expected version 1.0.0, got %s", receipt.Version)
+	if receipt.Version != VersionV2 {
+		t.Errorf("Expected version %s, got %s", VersionV2, receipt.Version)
 	}
 
 	if receipt.Epoch != start.UTC().Unix()/86400 {
@@ -0,0 +1,96 @@
+package receipt
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/mr-tron/base58"
+)
+
+const (
+	// VersionV1 receipts are signed over their raw canonical JSON.
+	VersionV1 = "1.0.0"
+	// VersionV2 receipts are signed over a domain-separated envelope that
+	// binds the algorithm and key id.
+	VersionV2 = "2.0.0"
+
+	// AlgEd25519 is the only signature algorithm currently accepted.
+	AlgEd25519 = "ed25519"
+
+	// DomainReceiptV2 prefixes the provider's signing input for v2 receipts.
+	DomainReceiptV2 = "QUIVER-RECEIPT-V2"
+	// DomainCoSignV2 prefixes the signing input of co-signatures, so a
+	// provider signature can never be replayed as a co-signature or vice versa.
+	DomainCoSignV2 = "QUIVER-RECEIPT-COSIGN-V2"
+)
+
+// CoSignature is an additional signature over a v2 receipt, for example a
+// gateway attesting that it relayed the response.
+type CoSignature struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	PublicKey string `json:"public_key"`
+	Signature string `json:"signature"`
+}
+
+// signedPayload is the object canonicalized for v2 signatures.
+type signedPayload struct {
+	Algorithm string  `json:"alg"`
+	KeyID     string  `json:"kid"`
+	Receipt   Receipt `json:"receipt"`
+}
+
+// KeyID derives the identifier of a public key: base58 of the first 16 bytes
+// of its SHA-256.
+func KeyID(publicKey []byte) string {
+	hash := sha256.Sum256(publicKey)
+	return base58.Encode(hash[:16])
+}
+
+// SigningInput returns the exact bytes a signature over receipt covers.
+// Version 1 signs the canonical receipt; version 2 signs
+// DomainReceiptV2 || 0x00 || JCS({"alg", "kid", "receipt"}).
+func SigningInput(receipt *Receipt, alg, keyID string) ([]byte, error) {
+	switch receipt.Version {
+	case VersionV1:
+		return CanonicalizeJSON(receipt)
+	case VersionV2:
+		canonical, err := CanonicalizeJSON(signedPayload{
+			Algorithm: alg,
+			KeyID:     keyID,
+			Receipt:   *receipt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return domainSeparate(DomainReceiptV2, canonical), nil
+	default:
+		return nil, fmt.Errorf("unsupported receipt version %q", receipt.Version)
+	}
+}
+
+// CoSigningInput returns the bytes a co-signature covers: the domain prefix
+// followed by the SHA-256 of the provider's signing input and its signature,
+// so co-signers attest to one specific signed receipt.
+func CoSigningInput(signed *SignedReceipt) ([]byte, error) {
+	if signed.Receipt.Version != VersionV2 {
+		return nil, fmt.Errorf("co-signatures require receipt version %s", VersionV2)
+	}
+
+	input, err := SigningInput(&signed.Receipt, signed.Algorithm, signed.KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	h := sha256.New()
+	h.Write(input)
+	h.Write([]byte(signed.Signature))
+	return domainSeparate(DomainCoSignV2, h.Sum(nil)), nil
+}
+
+func domainSeparate(domain string, msg []byte) []byte {
+	out := make([]byte, 0, len(domain)+1+len(msg))
+	out = append(out, domain...)
+	out = append(out, 0)
+	return append(out, msg...)
+}
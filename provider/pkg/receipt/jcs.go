@@ -0,0 +1,354 @@
+package receipt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// checkStrings rejects strings and map keys in v that are not valid UTF-8.
+// v must be acyclic, as it is once json.Marshal has accepted it. Byte
+// slices are skipped: encoding/json writes them as base64, and a
+// json.RawMessage is checked when its output is transformed.
+func checkStrings(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.String:
+		if !utf8.ValidString(v.String()) {
+			return errors.New("jcs: invalid UTF-8 in string")
+		}
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			return checkStrings(v.Elem())
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				if err := checkStrings(v.Field(i)); err != nil {
+					return err
+				}
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := checkStrings(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := checkStrings(iter.Key()); err != nil {
+				return err
+			}
+			if err := checkStrings(iter.Value()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// TransformJCS rewrites a JSON document into its RFC 8785 (JSON
+// Canonicalization Scheme) form: object members sorted by UTF-16 code units,
+// numbers serialized as ECMAScript doubles, minimal string escaping and no
+// insignificant whitespace. The input must be I-JSON: duplicate object keys,
+// invalid UTF-8, escaped lone surrogates and non-finite numbers are
+// rejected.
+func TransformJCS(data []byte) ([]byte, error) {
+	// encoding/json silently replaces invalid UTF-8, so check up front
+	if !utf8.Valid(data) {
+		return nil, errors.New("jcs: invalid UTF-8 in input")
+	}
+	// It also replaces escaped lone surrogates
+	if err := checkSurrogates(data); err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	value, err := decodeValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("jcs: trailing data after JSON value")
+	}
+
+	var buf bytes.Buffer
+	if err := writeValue(&buf, value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// checkSurrogates rejects \u escapes in strings that encode a UTF-16
+// surrogate other than as the first half of a pair, as RFC 8785 section
+// 3.2.2.2 requires. Malformed escapes are left to the decoder.
+func checkSurrogates(data []byte) error {
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if !inString {
+			inString = c == '"'
+			continue
+		}
+		switch c {
+		case '"':
+			inString = false
+		case '\\':
+			r, ok := escapedRune(data[i:])
+			switch {
+			case !ok:
+				i++
+			case utf16.IsSurrogate(r) && r < 0xdc00:
+				low, ok := escapedRune(data[i+6:])
+				if !ok || low < 0xdc00 || low > 0xdfff {
+					return fmt.Errorf("jcs: lone surrogate \\u%04x", r)
+				}
+				i += 11
+			case utf16.IsSurrogate(r):
+				return fmt.Errorf("jcs: lone surrogate \\u%04x", r)
+			default:
+				i += 5
+			}
+		}
+	}
+	return nil
+}
+
+// escapedRune decodes a \uXXXX escape at the start of b.
+func escapedRune(b []byte) (rune, bool) {
+	if len(b) < 6 || b[0] != '\\' || b[1] != 'u' {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(string(b[2:6]), 16, 16)
+	if err != nil {
+		return 0, false
+	}
+	return rune(n), true
+}
+
+type jsonMember struct {
+	key   string
+	value interface{}
+}
+
+type jsonObject []jsonMember
+
+func decodeValue(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, fmt.Errorf("jcs: %w", err)
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			return decodeObject(dec)
+		case '[':
+			return decodeArray(dec)
+		}
+		return nil, fmt.Errorf("jcs: unexpected delimiter %q", t)
+	default:
+		return t, nil
+	}
+}
+
+func decodeObject(dec *json.Decoder) (jsonObject, error) {
+	obj := jsonObject{}
+	seen := make(map[string]struct{})
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("jcs: %w", err)
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return nil, fmt.Errorf("jcs: object key is %T, not string", tok)
+		}
+		if _, dup := seen[key]; dup {
+			return nil, fmt.Errorf("jcs: duplicate object key %q", key)
+		}
+		seen[key] = struct{}{}
+
+		value, err := decodeValue(dec)
+		if err != nil {
+			return nil, err
+		}
+		obj = append(obj, jsonMember{key: key, value: value})
+	}
+
+	// Consume the closing brace
+	if _, err := dec.Token(); err != nil {
+		return nil, fmt.Errorf("jcs: %w", err)
+	}
+
+	sort.SliceStable(obj, func(i, j int) bool {
+		return lessUTF16(obj[i].key, obj[j].key)
+	})
+
+	return obj, nil
+}
+
+func decodeArray(dec *json.Decoder) ([]interface{}, error) {
+	arr := make([]interface{}, 0)
+
+	for dec.More() {
+		value, err := decodeValue(dec)
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, value)
+	}
+
+	// Consume the closing bracket
+	if _, err := dec.Token(); err != nil {
+		return nil, fmt.Errorf("jcs: %w", err)
+	}
+
+	return arr, nil
+}
+
+// lessUTF16 orders keys by their UTF-16 code units as required by RFC 8785
+// section 3.2.3, which differs from byte order for characters above U+FFFF.
+func lessUTF16(a, b string) bool {
+	ua := utf16.Encode([]rune(a))
+	ub := utf16.Encode([]rune(b))
+
+	for i := 0; i < len(ua) && i < len(ub); i++ {
+		if ua[i] != ub[i] {
+			return ua[i] < ub[i]
+		}
+	}
+	return len(ua) < len(ub)
+}
+
+func writeValue(buf *bytes.Buffer, v interface{}) error {
+	switch val := v.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		if val {
+			buf.WriteString("true")
+		} else {
+			buf.WriteString("false")
+		}
+	case string:
+		writeString(buf, val)
+	case json.Number:
+		f, err := strconv.ParseFloat(string(val), 64)
+		if err != nil {
+			return fmt.Errorf("jcs: number %s: %w", val, err)
+		}
+		s, err := formatNumber(f)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(s)
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, item := range val {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeValue(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case jsonObject:
+		buf.WriteByte('{')
+		for i, m := range val {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeString(buf, m.key)
+			buf.WriteByte(':')
+			if err := writeValue(buf, m.value); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		return fmt.Errorf("jcs: unsupported value %T", v)
+	}
+	return nil
+}
+
+// formatNumber serializes a double the way ECMAScript's Number.prototype.toString
+// does, which is what RFC 8785 section 3.2.2.3 mandates.
+func formatNumber(f float64) (string, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", fmt.Errorf("jcs: non-finite number %v", f)
+	}
+	if f == 0 {
+		// Covers negative zero, which serializes as "0"
+		return "0", nil
+	}
+
+	abs := math.Abs(f)
+	format := byte('f')
+	if abs < 1e-6 || abs >= 1e21 {
+		format = 'e'
+	}
+
+	b := strconv.AppendFloat(nil, f, format, -1, 64)
+	if format == 'e' {
+		// Go writes a two digit exponent (1e-07); ECMAScript writes 1e-7
+		n := len(b)
+		if n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+
+	return string(b), nil
+}
+
+func writeString(buf *bytes.Buffer, s string) {
+	const hex = "0123456789abcdef"
+
+	buf.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if c < 0x20 {
+				buf.WriteString(`\u00`)
+				buf.WriteByte(hex[c>>4])
+				buf.WriteByte(hex[c&0xf])
+			} else {
+				buf.WriteByte(c)
+			}
+		}
+	}
+	buf.WriteByte('"')
+}
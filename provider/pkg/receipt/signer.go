@@ -4,82 +4,394 @@ import (
 	"crypto/ed25519"
 	"crypto/rand"
 	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"time"
 )
 
+// SuccessionSuffix is appended to the key path to name the file holding the
+// key's succession chain.
+const SuccessionSuffix = ".succession"
+
+// Signer signs receipts with the provider's current key. After a rotation
+// the current key differs from the identity key, and signed receipts carry
+// the succession chain linking the two.
 type Signer struct {
-	privateKey ed25519.PrivateKey
-	publicKey  ed25519.PublicKey
+	privateKey  ed25519.PrivateKey
+	publicKey   ed25519.PublicKey
+	identity    ed25519.PublicKey
+	successions []SignedSuccession
+	codec       KeyCodec
+}
+
+// KeyCodec converts a private key to and from its on-disk form, typically
+// an encrypted keystore. Decode reports stale when the data is in an older
+// format, including a plaintext key, and should be rewritten.
+type KeyCodec interface {
+	Encode(privateKey []byte) ([]byte, error)
+	Decode(data []byte) (privateKey []byte, stale bool, err error)
 }
 
+// NewSigner loads the plaintext key at keyPath, generating one if the file
+// does not exist.
 func NewSigner(keyPath string) (*Signer, error) {
-	var privateKey ed25519.PrivateKey
+	return NewSignerWithCodec(keyPath, nil)
+}
 
-	if data, err := os.ReadFile(keyPath); err == nil {
-		if len(data) == ed25519.PrivateKeySize {
-			privateKey = ed25519.PrivateKey(data)
-		} else {
-			return nil, fmt.Errorf("invalid key size")
-		}
-	} else {
-		publicKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+// NewSignerWithCodec is NewSigner for a key stored through codec. A key
+// found in an older format is rewritten with codec before returning.
+func NewSignerWithCodec(keyPath string, codec KeyCodec) (*Signer, error) {
+	data, err := os.ReadFile(keyPath)
+	if os.IsNotExist(err) {
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
 		if err != nil {
 			return nil, err
 		}
-		privateKey = privKey
 
-		if err := os.WriteFile(keyPath, privateKey, 0600); err != nil {
+		signer := newSigner(privateKey)
+		signer.codec = codec
+		if err := signer.saveKey(keyPath); err != nil {
 			return nil, err
 		}
+		return signer, nil
+	}
+	if err != nil {
+		return nil, err
+	}
 
-		return &Signer{
-			privateKey: privateKey,
-			publicKey:  publicKey,
-		}, nil
+	privateKey, stale, err := DecodeKey(codec, data)
+	if err != nil {
+		return nil, err
 	}
 
-	publicKey := privateKey.Public().(ed25519.PublicKey)
+	signer := newSigner(privateKey)
+	signer.codec = codec
+	if err := signer.loadSuccession(keyPath + SuccessionSuffix); err != nil {
+		return nil, err
+	}
+	if stale {
+		if err := signer.saveKey(keyPath); err != nil {
+			return nil, fmt.Errorf("failed to migrate signing key: %w", err)
+		}
+	}
+
+	return signer, nil
+}
+
+// EncodeKey returns the on-disk form of privateKey under codec, the raw key
+// if codec is nil.
+func EncodeKey(codec KeyCodec, privateKey ed25519.PrivateKey) ([]byte, error) {
+	if codec == nil {
+		return privateKey, nil
+	}
+	return codec.Encode(privateKey)
+}
 
+// DecodeKey reverses EncodeKey and checks the result is a well-formed
+// ed25519 key, which catches a wrong passphrase on unauthenticated legacy
+// keystores.
+func DecodeKey(codec KeyCodec, data []byte) (ed25519.PrivateKey, bool, error) {
+	key, stale := data, false
+	if codec != nil {
+		var err error
+		key, stale, err = codec.Decode(data)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, false, fmt.Errorf("invalid key size")
+	}
+	privateKey := ed25519.PrivateKey(key)
+	if !ed25519.NewKeyFromSeed(privateKey.Seed()).Equal(privateKey) {
+		return nil, false, errors.New("invalid key: public half does not match seed")
+	}
+	return privateKey, stale, nil
+}
+
+func newSigner(privateKey ed25519.PrivateKey) *Signer {
+	publicKey := privateKey.Public().(ed25519.PublicKey)
 	return &Signer{
 		privateKey: privateKey,
 		publicKey:  publicKey,
-	}, nil
+		identity:   publicKey,
+	}
+}
+
+// loadSuccession reads the succession chain saved next to a rotated key and
+// checks that it ends at this signer's key.
+func (s *Signer) loadSuccession(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var chain []SignedSuccession
+	if err := json.Unmarshal(data, &chain); err != nil {
+		return fmt.Errorf("succession chain %s: %w", path, err)
+	}
+	if len(chain) == 0 {
+		return nil
+	}
+
+	identity, err := base64.StdEncoding.DecodeString(chain[0].Certificate.ProviderPK)
+	if err != nil || len(identity) != ed25519.PublicKeySize {
+		return fmt.Errorf("succession chain %s: invalid provider key", path)
+	}
+	windows, err := resolveKeyChain(identity, chain)
+	if err != nil {
+		return fmt.Errorf("succession chain %s: %w", path, err)
+	}
+	if !windows[len(windows)-1].publicKey.Equal(s.publicKey) {
+		return fmt.Errorf("succession chain %s does not end at the loaded key", path)
+	}
+
+	s.identity = identity
+	s.successions = chain
+	return nil
 }
 
+// Successor issues a succession certificate handing this signer's identity
+// to next, effective from effectiveSeq, and returns a signer for next. This
+// signer's key may only sign receipts before revokeFromSeq.
+func (s *Signer) Successor(next ed25519.PrivateKey, effectiveSeq, revokeFromSeq int64, reason string, issuedAt time.Time) (*Signer, error) {
+	cert := SuccessionCertificate{
+		ProviderPK:    s.PublicKeyBase64(),
+		PrevKeyID:     s.KeyID(),
+		NextKey:       base64.StdEncoding.EncodeToString(next.Public().(ed25519.PublicKey)),
+		NextKeyID:     KeyID(next.Public().(ed25519.PublicKey)),
+		EffectiveSeq:  effectiveSeq,
+		RevokeFromSeq: revokeFromSeq,
+		Reason:        reason,
+		IssuedAt:      issuedAt.UTC().Format(time.RFC3339),
+	}
+	input, err := SuccessionSigningInput(&cert)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Adopt(next, SignedSuccession{
+		Certificate: cert,
+		Algorithm:   AlgEd25519,
+		KeyID:       s.KeyID(),
+		Signature:   base64.StdEncoding.EncodeToString(ed25519.Sign(s.privateKey, input)),
+	})
+}
+
+// Adopt returns a signer for next, which succession hands this signer's
+// identity to. It fails unless succession is a valid certificate from this
+// signer's key to next.
+func (s *Signer) Adopt(next ed25519.PrivateKey, succession SignedSuccession) (*Signer, error) {
+	chain := append(append([]SignedSuccession{}, s.successions...), succession)
+	windows, err := resolveKeyChain(s.identity, chain)
+	if err != nil {
+		return nil, err
+	}
+
+	successor := newSigner(next)
+	if !windows[len(windows)-1].publicKey.Equal(successor.publicKey) {
+		return nil, errors.New("succession does not name the next key")
+	}
+	successor.identity = s.identity
+	successor.successions = chain
+	successor.codec = s.codec
+	return successor, nil
+}
+
+// Successions returns the chain from the identity key to the current key.
+func (s *Signer) Successions() []SignedSuccession {
+	return append([]SignedSuccession{}, s.successions...)
+}
+
+// Save writes the private key, encoded with the signer's codec, and its
+// succession chain to keyPath. Each file is replaced atomically.
+func (s *Signer) Save(keyPath string) error {
+	successionPath := keyPath + SuccessionSuffix
+	if len(s.successions) > 0 {
+		data, err := json.MarshalIndent(s.successions, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := writeFileAtomic(successionPath, data); err != nil {
+			return err
+		}
+	} else if err := os.Remove(successionPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return s.saveKey(keyPath)
+}
+
+func (s *Signer) saveKey(keyPath string) error {
+	data, err := EncodeKey(s.codec, s.privateKey)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(keyPath, data)
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Sign signs receipt using the scheme its Version selects.
 func (s *Signer) Sign(receipt *Receipt) (*SignedReceipt, error) {
-	canonical, err := CanonicalizeJSON(receipt)
+	signed := &SignedReceipt{Receipt: *receipt}
+	if receipt.Version != VersionV1 {
+		signed.Algorithm = AlgEd25519
+		signed.KeyID = s.KeyID()
+		signed.Succession = s.Successions()
+	} else if len(s.successions) > 0 {
+		return nil, errors.New("v1 receipts cannot be signed by a rotated key")
+	}
+
+	input, err := SigningInput(receipt, signed.Algorithm, signed.KeyID)
 	if err != nil {
 		return nil, err
 	}
 
-	signature := ed25519.Sign(s.privateKey, canonical)
+	signature := ed25519.Sign(s.privateKey, input)
+	signed.Signature = base64.StdEncoding.EncodeToString(signature)
 
-	return &SignedReceipt{
-		Receipt:   *receipt,
-		Signature: base64.StdEncoding.EncodeToString(signature),
-	}, nil
+	return signed, nil
 }
 
+// CoSign appends this signer's co-signature to a signed v2 receipt.
+func (s *Signer) CoSign(signed *SignedReceipt) error {
+	input, err := CoSigningInput(signed)
+	if err != nil {
+		return err
+	}
+
+	signed.CoSignatures = append(signed.CoSignatures, CoSignature{
+		Algorithm: AlgEd25519,
+		KeyID:     s.KeyID(),
+		PublicKey: base64.StdEncoding.EncodeToString(s.publicKey),
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(s.privateKey, input)),
+	})
+	return nil
+}
+
+// PublicKeyBase64 returns the provider identity placed in receipts as
+// provider_pk. It is the first key the provider used and survives rotation.
 func (s *Signer) PublicKeyBase64() string {
-	return base64.StdEncoding.EncodeToString(s.publicKey)
+	return base64.StdEncoding.EncodeToString(s.identity)
+}
+
+// KeyID returns the identifier of the current signing key, placed in the kid
+// field of v2 receipts.
+func (s *Signer) KeyID() string {
+	return KeyID(s.publicKey)
 }
 
+// VerifySignature checks the provider signature on a v1 or v2 receipt
+// against the provider identity key. For v2 the envelope must name ed25519
+// and a kid that is either the identity key or reached from it through the
+// envelope's succession chain, and the receipt's seq must fall inside that
+// key's window. Revocations the envelope does not include are not seen;
+// verifiers that track a provider's full chain must check those separately.
 func VerifySignature(receipt *SignedReceipt, publicKeyBase64 string) (bool, error) {
 	publicKey, err := base64.StdEncoding.DecodeString(publicKeyBase64)
 	if err != nil {
 		return false, err
 	}
+	if len(publicKey) != ed25519.PublicKeySize {
+		return false, fmt.Errorf("invalid public key size")
+	}
 
 	signature, err := base64.StdEncoding.DecodeString(receipt.Signature)
 	if err != nil {
 		return false, err
 	}
 
-	canonical, err := CanonicalizeJSON(receipt.Receipt)
+	signingKey := ed25519.PublicKey(publicKey)
+	if receipt.Receipt.Version != VersionV1 {
+		if receipt.Algorithm != AlgEd25519 {
+			return false, fmt.Errorf("unsupported signature algorithm %q", receipt.Algorithm)
+		}
+
+		windows, err := resolveKeyChain(signingKey, receipt.Succession)
+		if err != nil {
+			return false, err
+		}
+		signingKey, err = keyForReceipt(windows, receipt.KeyID, receipt.Receipt.Seq)
+		if errors.Is(err, ErrUnknownKey) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+
+	input, err := SigningInput(&receipt.Receipt, receipt.Algorithm, receipt.KeyID)
 	if err != nil {
 		return false, err
 	}
 
-	return ed25519.Verify(ed25519.PublicKey(publicKey), canonical, signature), nil
+	return ed25519.Verify(signingKey, input, signature), nil
+}
+
+// VerifyCoSignatures checks every co-signature on a v2 receipt against the
+// public key it carries. Callers decide which co-signer keys they trust.
+func VerifyCoSignatures(receipt *SignedReceipt) (bool, error) {
+	if len(receipt.CoSignatures) == 0 {
+		return true, nil
+	}
+
+	input, err := CoSigningInput(receipt)
+	if err != nil {
+		return false, err
+	}
+
+	for _, cs := range receipt.CoSignatures {
+		if cs.Algorithm != AlgEd25519 {
+			return false, fmt.Errorf("unsupported co-signature algorithm %q", cs.Algorithm)
+		}
+
+		publicKey, err := base64.StdEncoding.DecodeString(cs.PublicKey)
+		if err != nil {
+			return false, err
+		}
+		if len(publicKey) != ed25519.PublicKeySize || cs.KeyID != KeyID(publicKey) {
+			return false, nil
+		}
+
+		signature, err := base64.StdEncoding.DecodeString(cs.Signature)
+		if err != nil {
+			return false, err
+		}
+		if !ed25519.Verify(ed25519.PublicKey(publicKey), input, signature) {
+			return false, nil
+		}
+	}
+
+	return true, nil
 }
@@ -4,6 +4,8 @@ import (
 	"crypto/ed25519"
 	"crypto/rand"
 	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"os"
 	"testing"
 	"time"
@@ -18,7 +20,7 @@ func TestEd25519SignVerify(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	receipt := NewReceipt(
+	receipt, err := NewReceipt(
 		signer.PublicKeyBase64(),
 		"test-model",
 		"prompt-hash",
@@ -26,6 +28,9 @@ func TestEd25519SignVerify(t *testing.T) {
 		10, 20,
 		time.Now(), time.Now().Add(100*time.Millisecond),
 	)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	signed, err := signer.Sign(receipt)
 	if err != nil {
@@ -78,12 +83,9 @@ func TestSignerKeyPersistence(t *testing.T) {
 
 func TestSignatureConsistency(t *testing.T) {
 	_, privKey, _ := ed25519.GenerateKey(rand.Reader)
-	signer := &Signer{
-		privateKey: privKey,
-		publicKey:  privKey.Public().(ed25519.PublicKey),
-	}
+	signer := newSigner(privKey)
 
-	receipt := NewReceipt(
+	receipt, err := NewReceipt(
 		signer.PublicKeyBase64(),
 		"model",
 		"hash1",
@@ -91,6 +93,9 @@ func TestSignatureConsistency(t *testing.T) {
 		5, 10,
 		time.Now(), time.Now(),
 	)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	// Sign multiple times
 	signatures := make([]string, 10)
@@ -109,3 +114,146 @@ func TestSignatureConsistency(t *testing.T) {
 		}
 	}
 }
+
+func TestV2EnvelopeSignVerify(t *testing.T) {
+	_, privKey, _ := ed25519.GenerateKey(rand.Reader)
+	signer := newSigner(privKey)
+
+	rcpt, err := NewReceipt(signer.PublicKeyBase64(), "model", "h1", "h2", 1, 2, time.Now(), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := signer.Sign(rcpt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if signed.Algorithm != AlgEd25519 || signed.KeyID != signer.KeyID() {
+		t.Fatalf("unexpected envelope alg=%q kid=%q", signed.Algorithm, signed.KeyID)
+	}
+
+	valid, err := VerifySignature(signed, signer.PublicKeyBase64())
+	if err != nil || !valid {
+		t.Fatalf("valid v2 receipt failed verification: %v", err)
+	}
+
+	// A different key must not verify even though the signature is intact
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+	otherPK := base64.StdEncoding.EncodeToString(otherKey.Public().(ed25519.PublicKey))
+	if valid, _ := VerifySignature(signed, otherPK); valid {
+		t.Error("v2 receipt verified under the wrong key")
+	}
+
+	// Stripping the domain-separated envelope must break the signature
+	downgraded := *signed
+	downgraded.Receipt.Version = VersionV1
+	if valid, _ := VerifySignature(&downgraded, signer.PublicKeyBase64()); valid {
+		t.Error("v2 signature verified as v1")
+	}
+}
+
+func TestCoSign(t *testing.T) {
+	_, providerKey, _ := ed25519.GenerateKey(rand.Reader)
+	provider := newSigner(providerKey)
+	_, gatewayKey, _ := ed25519.GenerateKey(rand.Reader)
+	gateway := newSigner(gatewayKey)
+
+	rcpt, err := NewReceipt(provider.PublicKeyBase64(), "model", "h1", "h2", 1, 2, time.Now(), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := provider.Sign(rcpt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := gateway.CoSign(signed); err != nil {
+		t.Fatal(err)
+	}
+
+	valid, err := VerifyCoSignatures(signed)
+	if err != nil || !valid {
+		t.Fatalf("co-signature failed verification: %v", err)
+	}
+
+	// Co-signatures commit to the provider signature
+	signed.Signature = base64.StdEncoding.EncodeToString(make([]byte, ed25519.SignatureSize))
+	if valid, _ := VerifyCoSignatures(signed); valid {
+		t.Error("co-signature survived a change to the provider signature")
+	}
+
+	// v1 receipts cannot be co-signed
+	legacy := *rcpt
+	legacy.Version = VersionV1
+	legacySigned, err := provider.Sign(&legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := gateway.CoSign(legacySigned); err == nil {
+		t.Error("expected error co-signing a v1 receipt")
+	}
+}
+
+func TestReceiptVectors(t *testing.T) {
+	data, err := os.ReadFile("../../../testvectors/receipts.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var vectors struct {
+		Provider struct {
+			SeedHex   string `json:"seed_hex"`
+			PublicKey string `json:"public_key"`
+			KeyID     string `json:"kid"`
+		} `json:"provider"`
+		Cases []struct {
+			Name         string        `json:"name"`
+			Signed       SignedReceipt `json:"signed"`
+			SigningInput string        `json:"signing_input"`
+			Valid        bool          `json:"valid"`
+		} `json:"cases"`
+	}
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+
+	seed, _ := hex.DecodeString(vectors.Provider.SeedHex)
+	privKey := ed25519.NewKeyFromSeed(seed)
+	signer := newSigner(privKey)
+	if signer.PublicKeyBase64() != vectors.Provider.PublicKey || signer.KeyID() != vectors.Provider.KeyID {
+		t.Fatal("vector key does not match seed")
+	}
+
+	for _, tc := range vectors.Cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			if tc.SigningInput != "" {
+				input, err := SigningInput(&tc.Signed.Receipt, tc.Signed.Algorithm, tc.Signed.KeyID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(input) != tc.SigningInput {
+					t.Errorf("signing input mismatch:\n got  %q\n want %q", input, tc.SigningInput)
+				}
+			}
+
+			valid, _ := VerifySignature(&tc.Signed, vectors.Provider.PublicKey)
+			if valid && len(tc.Signed.CoSignatures) > 0 {
+				valid, _ = VerifyCoSignatures(&tc.Signed)
+			}
+			if valid != tc.Valid {
+				t.Errorf("verification = %v, want %v", valid, tc.Valid)
+			}
+
+			// Ed25519 is deterministic, so re-signing valid cases reproduces them
+			if tc.Valid {
+				resigned, err := signer.Sign(&tc.Signed.Receipt)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if resigned.Signature != tc.Signed.Signature {
+					t.Error("re-signing produced a different signature")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package receipt
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// DomainStreamV1 seeds the rolling output hash of streamed receipts.
+const DomainStreamV1 = "QUIVER-STREAM-V1"
+
+// StreamInfo marks a receipt as part of a streamed generation. For these
+// receipts OutputHash is the rolling hash over the first Chunks chunks
+// rather than the hash of the whole response.
+//
+// A provider signs an interim checkpoint every N tokens and a final receipt
+// when generation ends. Each links to the one before through PrevCheckpoint,
+// the chain hash of the previous checkpoint receipt. Only the final receipt
+// is journaled and settled; checkpoints let a client that lost the stream
+// prove what it had received.
+type StreamInfo struct {
+	Index          int    `json:"index"`
+	Chunks         int    `json:"chunks"`
+	PrevCheckpoint string `json:"prev_checkpoint"`
+	Final          bool   `json:"final"`
+}
+
+// StreamHasher computes the rolling output hash:
+//
+//	h0 = SHA-256("QUIVER-STREAM-V1")
+//	hi = SHA-256(h(i-1) || SHA-256(chunk i))
+type StreamHasher struct {
+	state  [sha256.Size]byte
+	chunks int
+}
+
+// NewStreamHasher returns a hasher over zero chunks.
+func NewStreamHasher() *StreamHasher {
+	return &StreamHasher{state: sha256.Sum256([]byte(DomainStreamV1))}
+}
+
+// Write folds the next chunk into the hash.
+func (s *StreamHasher) Write(chunk string) {
+	chunkHash := sha256.Sum256([]byte(chunk))
+	h := sha256.New()
+	h.Write(s.state[:])
+	h.Write(chunkHash[:])
+	copy(s.state[:], h.Sum(nil))
+	s.chunks++
+}
+
+// Sum returns the hex rolling hash of the chunks written so far.
+func (s *StreamHasher) Sum() string {
+	return hex.EncodeToString(s.state[:])
+}
+
+// Chunks returns how many chunks have been written.
+func (s *StreamHasher) Chunks() int {
+	return s.chunks
+}
+
+// StreamHash returns the rolling hash of chunks.
+func StreamHash(chunks []string) string {
+	s := NewStreamHasher()
+	for _, chunk := range chunks {
+		s.Write(chunk)
+	}
+	return s.Sum()
+}
+
+// CheckpointHash is the value the next streamed receipt carries as
+// PrevCheckpoint.
+func CheckpointHash(r *Receipt) (string, error) {
+	canonical, err := CanonicalizeJSON(r)
+	if err != nil {
+		return "", err
+	}
+	return HashData(canonical), nil
+}
+
+// VerifyStreamPrefix reports whether chunks, as received by a client, begin
+// with exactly the output a streamed receipt commits to.
+func VerifyStreamPrefix(chunks []string, r *Receipt) error {
+	if r.Stream == nil {
+		return errors.New("receipt is not a stream receipt")
+	}
+	if len(chunks) < r.Stream.Chunks {
+		return fmt.Errorf("receipt covers %d chunks, only %d given", r.Stream.Chunks, len(chunks))
+	}
+	if StreamHash(chunks[:r.Stream.Chunks]) != r.OutputHash {
+		return errors.New("chunks do not match receipt output hash")
+	}
+	return nil
+}
+
+// VerifyStreamChain checks that receipts form one streamed generation in
+// order: consecutive indexes from 1, each linked to its predecessor,
+// non-decreasing chunk counts, and at most the last one final. Signatures
+// are checked separately.
+func VerifyStreamChain(receipts []*Receipt) error {
+	prev := ""
+	chunks := 0
+	for i, r := range receipts {
+		if r.Stream == nil {
+			return fmt.Errorf("receipt %d is not a stream receipt", i)
+		}
+		if r.Stream.Index != i+1 {
+			return fmt.Errorf("receipt %d has index %d", i, r.Stream.Index)
+		}
+		if r.Stream.PrevCheckpoint != prev {
+			return fmt.Errorf("receipt %d does not link to its predecessor", i)
+		}
+		if r.Stream.Chunks < chunks {
+			return fmt.Errorf("receipt %d covers fewer chunks than its predecessor", i)
+		}
+		if r.Stream.Final && i != len(receipts)-1 {
+			return fmt.Errorf("receipt %d is final but not last", i)
+		}
+
+		hash, err := CheckpointHash(r)
+		if err != nil {
+			return err
+		}
+		prev = hash
+		chunks = r.Stream.Chunks
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package receipt
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+type streamVectors struct {
+	Provider struct {
+		PublicKey string `json:"public_key"`
+	} `json:"provider"`
+	Chunks        []string         `json:"chunks"`
+	RollingHashes []string         `json:"rolling_hashes"`
+	Receipts      []*SignedReceipt `json:"receipts"`
+}
+
+func loadStreamVectors(t *testing.T) *streamVectors {
+	t.Helper()
+	data, err := os.ReadFile("../../../testvectors/stream.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v streamVectors
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatal(err)
+	}
+	return &v
+}
+
+func TestStreamHashVectors(t *testing.T) {
+	v := loadStreamVectors(t)
+
+	h := NewStreamHasher()
+	for i, chunk := range v.Chunks {
+		h.Write(chunk)
+		if h.Sum() != v.RollingHashes[i] {
+			t.Fatalf("rolling hash after chunk %d = %s, want %s", i+1, h.Sum(), v.RollingHashes[i])
+		}
+	}
+	if h.Chunks() != len(v.Chunks) {
+		t.Errorf("Chunks() = %d, want %d", h.Chunks(), len(v.Chunks))
+	}
+}
+
+func TestStreamCheckpointChain(t *testing.T) {
+	v := loadStreamVectors(t)
+
+	receipts := make([]*Receipt, len(v.Receipts))
+	for i, signed := range v.Receipts {
+		valid, err := VerifySignature(signed, v.Provider.PublicKey)
+		if err != nil || !valid {
+			t.Fatalf("receipt %d: signature invalid: %v", i, err)
+		}
+		receipts[i] = &signed.Receipt
+	}
+
+	if err := VerifyStreamChain(receipts); err != nil {
+		t.Fatalf("chain rejected: %v", err)
+	}
+	if !receipts[len(receipts)-1].Stream.Final {
+		t.Error("last receipt should be final")
+	}
+
+	// A client that disconnected after six chunks still holds the first
+	// checkpoint and can prove its prefix
+	received := v.Chunks[:6]
+	if err := VerifyStreamPrefix(received, receipts[0]); err != nil {
+		t.Errorf("prefix rejected: %v", err)
+	}
+	if err := VerifyStreamPrefix(received, receipts[1]); err == nil {
+		t.Error("expected error for checkpoint beyond received chunks")
+	}
+
+	altered := append([]string{"A"}, v.Chunks[1:]...)
+	if err := VerifyStreamPrefix(altered, receipts[0]); err == nil {
+		t.Error("expected error for altered chunks")
+	}
+
+	if err := VerifyStreamChain([]*Receipt{receipts[1], receipts[2]}); err == nil {
+		t.Error("expected error for chain missing its first checkpoint")
+	}
+	if err := VerifyStreamChain([]*Receipt{receipts[0], receipts[2]}); err == nil {
+		t.Error("expected error for skipped checkpoint")
+	}
+}
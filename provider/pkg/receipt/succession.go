@@ -0,0 +1,144 @@
+package receipt
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// DomainSuccessionV1 prefixes the signing input of key succession
+// certificates.
+const DomainSuccessionV1 = "QUIVER-KEY-SUCCESSION-V1"
+
+// Rotation reasons recorded in succession certificates.
+const (
+	ReasonScheduled   = "scheduled"
+	ReasonCompromised = "compromised"
+)
+
+var (
+	// ErrRevokedKey means a receipt was signed by a key outside the range of
+	// sequence numbers its succession chain allows.
+	ErrRevokedKey = errors.New("signing key revoked for this sequence number")
+	// ErrUnknownKey means no key in the succession chain matches the kid.
+	ErrUnknownKey = errors.New("signing key not in provider key chain")
+)
+
+// SuccessionCertificate hands a provider identity from one signing key to
+// the next. ProviderPK is the identity, the provider's first key, and stays
+// the same across rotations so reputation and earnings follow it.
+//
+// The next key signs receipts from EffectiveSeq on. The previous key may
+// only sign receipts before RevokeFromSeq; after a compromise this can be
+// set below EffectiveSeq to disown receipts the attacker may have signed.
+type SuccessionCertificate struct {
+	ProviderPK    string `json:"provider_pk"`
+	PrevKeyID     string `json:"prev_kid"`
+	NextKey       string `json:"next_public_key"`
+	NextKeyID     string `json:"next_kid"`
+	EffectiveSeq  int64  `json:"effective_seq"`
+	RevokeFromSeq int64  `json:"revoke_from_seq"`
+	Reason        string `json:"reason"`
+	IssuedAt      string `json:"issued_at"`
+}
+
+// SignedSuccession is a succession certificate signed by the previous key.
+type SignedSuccession struct {
+	Certificate SuccessionCertificate `json:"certificate"`
+	Algorithm   string                `json:"alg"`
+	KeyID       string                `json:"kid"`
+	Signature   string                `json:"signature"`
+}
+
+// SuccessionSigningInput returns the bytes the previous key signs:
+// DomainSuccessionV1 || 0x00 || JCS(certificate).
+func SuccessionSigningInput(cert *SuccessionCertificate) ([]byte, error) {
+	canonical, err := CanonicalizeJSON(cert)
+	if err != nil {
+		return nil, err
+	}
+	return domainSeparate(DomainSuccessionV1, canonical), nil
+}
+
+// keyWindow is a key from a succession chain and the sequence numbers it may
+// sign: from inclusive, until exclusive, zero meaning unbounded.
+type keyWindow struct {
+	publicKey ed25519.PublicKey
+	from      int64
+	until     int64
+}
+
+// resolveKeyChain verifies a succession chain starting at identity and
+// returns every key in it with its signing window.
+func resolveKeyChain(identity ed25519.PublicKey, chain []SignedSuccession) ([]keyWindow, error) {
+	identityBase64 := base64.StdEncoding.EncodeToString(identity)
+	windows := []keyWindow{{publicKey: identity}}
+
+	for i := range chain {
+		s := &chain[i]
+		cert := &s.Certificate
+		prev := &windows[len(windows)-1]
+
+		if cert.ProviderPK != identityBase64 {
+			return nil, fmt.Errorf("succession %d: certificate for another provider", i)
+		}
+		if s.Algorithm != AlgEd25519 {
+			return nil, fmt.Errorf("succession %d: unsupported algorithm %q", i, s.Algorithm)
+		}
+		if s.KeyID != KeyID(prev.publicKey) || cert.PrevKeyID != s.KeyID {
+			return nil, fmt.Errorf("succession %d: not signed by the preceding key", i)
+		}
+		if cert.EffectiveSeq <= prev.from || cert.RevokeFromSeq < 1 || cert.RevokeFromSeq > cert.EffectiveSeq {
+			return nil, fmt.Errorf("succession %d: invalid sequence bounds", i)
+		}
+
+		next, err := base64.StdEncoding.DecodeString(cert.NextKey)
+		if err != nil {
+			return nil, fmt.Errorf("succession %d: %w", i, err)
+		}
+		if len(next) != ed25519.PublicKeySize || cert.NextKeyID != KeyID(next) {
+			return nil, fmt.Errorf("succession %d: invalid next key", i)
+		}
+
+		input, err := SuccessionSigningInput(cert)
+		if err != nil {
+			return nil, err
+		}
+		signature, err := base64.StdEncoding.DecodeString(s.Signature)
+		if err != nil {
+			return nil, fmt.Errorf("succession %d: %w", i, err)
+		}
+		if !ed25519.Verify(prev.publicKey, input, signature) {
+			return nil, fmt.Errorf("succession %d: invalid signature", i)
+		}
+
+		prev.until = cert.RevokeFromSeq
+		windows = append(windows, keyWindow{publicKey: ed25519.PublicKey(next), from: cert.EffectiveSeq})
+	}
+
+	return windows, nil
+}
+
+// keyForReceipt picks the key from windows that may sign a receipt with the
+// given kid and sequence number. Receipts outside the hash chain (seq 0,
+// such as stream checkpoints) may only be signed by a key that has not been
+// retired.
+func keyForReceipt(windows []keyWindow, kid string, seq int64) (ed25519.PublicKey, error) {
+	for _, w := range windows {
+		if KeyID(w.publicKey) != kid {
+			continue
+		}
+		if seq == 0 {
+			if w.until != 0 {
+				return nil, ErrRevokedKey
+			}
+			return w.publicKey, nil
+		}
+		if seq < w.from || (w.until != 0 && seq >= w.until) {
+			return nil, ErrRevokedKey
+		}
+		return w.publicKey, nil
+	}
+	return nil, ErrUnknownKey
+}
@@ -0,0 +1,140 @@
+package receipt
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type rotationVectors struct {
+	Provider struct {
+		SeedHex   string `json:"seed_hex"`
+		PublicKey string `json:"public_key"`
+	} `json:"provider"`
+	NextKey struct {
+		SeedHex string `json:"seed_hex"`
+	} `json:"next_key"`
+	Chain []SignedSuccession `json:"chain"`
+	Cases []struct {
+		Name             string        `json:"name"`
+		Signed           SignedReceipt `json:"signed"`
+		Valid            bool          `json:"valid"`
+		ValidWithHistory bool          `json:"valid_with_history"`
+	} `json:"cases"`
+}
+
+func loadRotationVectors(t *testing.T) *rotationVectors {
+	t.Helper()
+	data, err := os.ReadFile("../../../testvectors/rotation.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v rotationVectors
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatal(err)
+	}
+	return &v
+}
+
+func seedKey(t *testing.T, seedHex string) ed25519.PrivateKey {
+	seed, err := hex.DecodeString(seedHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ed25519.NewKeyFromSeed(seed)
+}
+
+func TestRotationVectors(t *testing.T) {
+	v := loadRotationVectors(t)
+
+	for _, tc := range v.Cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			valid, _ := VerifySignature(&tc.Signed, v.Provider.PublicKey)
+			if valid != tc.Valid {
+				t.Errorf("envelope chain: verification = %v, want %v", valid, tc.Valid)
+			}
+
+			withHistory := tc.Signed
+			withHistory.Succession = v.Chain
+			valid, _ = VerifySignature(&withHistory, v.Provider.PublicKey)
+			if valid != tc.ValidWithHistory {
+				t.Errorf("full chain: verification = %v, want %v", valid, tc.ValidWithHistory)
+			}
+		})
+	}
+}
+
+func TestSuccessorMatchesVector(t *testing.T) {
+	v := loadRotationVectors(t)
+
+	old := newSigner(seedKey(t, v.Provider.SeedHex))
+	cert := v.Chain[0].Certificate
+	issued, _ := time.Parse(time.RFC3339, cert.IssuedAt)
+
+	next, err := old.Successor(seedKey(t, v.NextKey.SeedHex), cert.EffectiveSeq, cert.RevokeFromSeq, cert.Reason, issued)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(next.Successions(), v.Chain) {
+		t.Errorf("succession mismatch:\n got  %+v\n want %+v", next.Successions(), v.Chain)
+	}
+	if next.PublicKeyBase64() != v.Provider.PublicKey {
+		t.Error("successor changed the provider identity")
+	}
+}
+
+func TestSuccessionPersistence(t *testing.T) {
+	v := loadRotationVectors(t)
+	keyPath := filepath.Join(t.TempDir(), "provider.key")
+
+	old := newSigner(seedKey(t, v.Provider.SeedHex))
+	next, err := old.Adopt(seedKey(t, v.NextKey.SeedHex), v.Chain[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := next.Save(keyPath); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := NewSigner(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.PublicKeyBase64() != v.Provider.PublicKey || loaded.KeyID() != next.KeyID() {
+		t.Error("reloaded signer lost its identity or key")
+	}
+
+	// A succession file that does not end at the key is refused
+	if err := old.Save(keyPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath+SuccessionSuffix, mustJSON(t, v.Chain), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewSigner(keyPath); err == nil {
+		t.Error("expected error for succession chain not ending at the key")
+	}
+
+	// Forged certificates are refused
+	if _, err := old.Adopt(seedKey(t, v.NextKey.SeedHex), SignedSuccession{
+		Certificate: v.Chain[0].Certificate,
+		Algorithm:   AlgEd25519,
+		KeyID:       old.KeyID(),
+		Signature:   v.Cases[0].Signed.Signature,
+	}); err == nil {
+		t.Error("expected error for certificate with a bad signature")
+	}
+}
+
+func mustJSON(t *testing.T, v interface{}) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
@@ -0,0 +1,222 @@
+// Package rotation replaces a provider's receipt signing key. The old key
+// signs a succession certificate for the new one, and the certificate is
+// committed to the receipt journal as a key event so the hash chain records
+// exactly where the handover happened.
+package rotation
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/quiver/provider/pkg/epoch"
+	"github.com/quiver/provider/pkg/journal"
+	"github.com/quiver/provider/pkg/receipt"
+)
+
+// PendingSuffix is appended to the key path to name the next key while a
+// rotation is in progress.
+const PendingSuffix = ".next"
+
+// Options controls a rotation.
+type Options struct {
+	// Reason is recorded in the certificate, receipt.ReasonScheduled by
+	// default.
+	Reason string
+	// RevokeFromSeq disowns receipts the old key signed from this sequence
+	// number on. Zero means the rotation point, which keeps every receipt
+	// signed so far valid.
+	RevokeFromSeq int64
+	// Schedule numbers the key event receipt's epoch; nil means
+	// epoch.DefaultSchedule.
+	Schedule *epoch.Schedule
+}
+
+// Rotate generates a new signing key for the provider whose key is at
+// keyPath, stored through codec, commits the succession to j and installs
+// the new key. The provider must not be running. The new key is staged on disk before the
+// journal commit so a crash at any point can be finished by Recover.
+func Rotate(keyPath string, codec receipt.KeyCodec, j *journal.Journal, opts Options) (*receipt.Signer, error) {
+	if _, err := os.Stat(keyPath); err != nil {
+		return nil, fmt.Errorf("no signing key to rotate: %w", err)
+	}
+	if _, err := os.Stat(keyPath + PendingSuffix); err == nil {
+		return nil, errors.New("a previous rotation did not finish; start the provider once to recover it")
+	}
+
+	current, err := receipt.NewSignerWithCodec(keyPath, codec)
+	if err != nil {
+		return nil, err
+	}
+
+	reason := opts.Reason
+	if reason == "" {
+		reason = receipt.ReasonScheduled
+	}
+	schedule := opts.Schedule
+	if schedule == nil {
+		schedule = epoch.DefaultSchedule()
+	}
+
+	_, nextKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeKey(keyPath+PendingSuffix, codec, nextKey); err != nil {
+		return nil, err
+	}
+
+	var next *receipt.Signer
+	_, err = j.Commit(func(seq int64, prevHash string) (*receipt.SignedReceipt, error) {
+		revokeFrom := opts.RevokeFromSeq
+		if revokeFrom == 0 {
+			revokeFrom = seq
+		}
+		if revokeFrom > seq {
+			return nil, fmt.Errorf("revocation point %d is after the rotation point %d", revokeFrom, seq)
+		}
+
+		now := time.Now()
+		var err error
+		next, err = current.Successor(nextKey, seq, revokeFrom, reason, now)
+		if err != nil {
+			return nil, err
+		}
+
+		successions := next.Successions()
+		return signKeyEvent(next, &successions[len(successions)-1], seq, prevHash, now, schedule)
+	})
+	if err != nil {
+		os.Remove(keyPath + PendingSuffix)
+		return nil, err
+	}
+
+	if err := install(next, keyPath); err != nil {
+		return nil, err
+	}
+	return next, nil
+}
+
+// Recover finishes a rotation interrupted by a crash. If the journal head is
+// the key event for the staged key, the key is installed; otherwise the
+// rotation never committed and the staged key is discarded. It reports
+// whether a rotation was completed.
+func Recover(keyPath string, codec receipt.KeyCodec, j *journal.Journal) (bool, error) {
+	pendingPath := keyPath + PendingSuffix
+	data, err := os.ReadFile(pendingPath)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	nextKey, _, err := receipt.DecodeKey(codec, data)
+	if err != nil {
+		// A torn write; the rotation cannot have committed
+		return false, os.Remove(pendingPath)
+	}
+	nextKeyID := receipt.KeyID(nextKey.Public().(ed25519.PublicKey))
+
+	current, err := receipt.NewSignerWithCodec(keyPath, codec)
+	if err != nil {
+		return false, err
+	}
+	if current.KeyID() == nextKeyID {
+		// Installed already; only the cleanup was lost
+		return true, os.Remove(pendingPath)
+	}
+
+	event, err := headKeyEvent(j)
+	if err != nil {
+		return false, err
+	}
+	if event == nil || event.Certificate.NextKeyID != nextKeyID {
+		return false, os.Remove(pendingPath)
+	}
+
+	next, err := current.Adopt(nextKey, *event)
+	if err != nil {
+		return false, err
+	}
+	return true, install(next, keyPath)
+}
+
+// NewKeyEventReceipt builds the zero-token receipt that records a key
+// succession in the hash chain.
+func NewKeyEventReceipt(providerPK string, event *receipt.SignedSuccession, at time.Time) (*receipt.Receipt, error) {
+	r, err := receipt.NewReceipt(providerPK, "", "", "", 0, 0, at, at)
+	if err != nil {
+		return nil, err
+	}
+	r.KeyEvent = event
+	return r, nil
+}
+
+func signKeyEvent(signer *receipt.Signer, event *receipt.SignedSuccession, seq int64, prevHash string, at time.Time, schedule *epoch.Schedule) (*receipt.SignedReceipt, error) {
+	r, err := NewKeyEventReceipt(signer.PublicKeyBase64(), event, at)
+	if err != nil {
+		return nil, err
+	}
+	r.Epoch = int64(schedule.EpochAt(at))
+	r.Seq = seq
+	r.PrevHash = prevHash
+	if err := receipt.SetReceiptID(r); err != nil {
+		return nil, err
+	}
+	return signer.Sign(r)
+}
+
+func headKeyEvent(j *journal.Journal) (*receipt.SignedSuccession, error) {
+	seq, _ := j.Head()
+	if seq == 0 {
+		return nil, nil
+	}
+	head, err := j.Since(seq-1, 1)
+	if err != nil || len(head) == 0 {
+		return nil, err
+	}
+	return head[0].Receipt.KeyEvent, nil
+}
+
+func install(next *receipt.Signer, keyPath string) error {
+	if err := next.Save(keyPath); err != nil {
+		return err
+	}
+	return os.Remove(keyPath + PendingSuffix)
+}
+
+func writeKey(path string, codec receipt.KeyCodec, key ed25519.PrivateKey) error {
+	data, err := receipt.EncodeKey(codec, key)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	d, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
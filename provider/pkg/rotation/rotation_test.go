@@ -0,0 +1,264 @@
+package rotation
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/quiver/provider/pkg/epoch"
+	"github.com/quiver/provider/pkg/journal"
+	"github.com/quiver/provider/pkg/receipt"
+)
+
+type fixture struct {
+	keyPath string
+	journal *journal.Journal
+}
+
+func newFixture(t *testing.T) *fixture {
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "provider.key")
+	if _, err := receipt.NewSigner(keyPath); err != nil {
+		t.Fatal(err)
+	}
+	j, err := journal.Open(filepath.Join(dir, "receipts.journal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { j.Close() })
+	return &fixture{keyPath: keyPath, journal: j}
+}
+
+func (f *fixture) signer(t *testing.T) *receipt.Signer {
+	signer, err := receipt.NewSigner(f.keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+// sign builds and signs a receipt at seq without journaling it.
+func sign(t *testing.T, signer *receipt.Signer, seq int64) *receipt.SignedReceipt {
+	now := time.Now()
+	rcpt, err := receipt.NewReceipt(signer.PublicKeyBase64(), "model", "p", "o", 1, 2, now, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rcpt.Seq = seq
+	if err := receipt.SetReceiptID(rcpt); err != nil {
+		t.Fatal(err)
+	}
+	signed, err := signer.Sign(rcpt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func (f *fixture) commit(t *testing.T, signer *receipt.Signer) *receipt.SignedReceipt {
+	signed, err := f.journal.Commit(func(seq int64, prevHash string) (*receipt.SignedReceipt, error) {
+		now := time.Now()
+		rcpt, err := receipt.NewReceipt(signer.PublicKeyBase64(), "model", "p", "o", 1, 2, now, now)
+		if err != nil {
+			return nil, err
+		}
+		rcpt.Seq = seq
+		rcpt.PrevHash = prevHash
+		if err := receipt.SetReceiptID(rcpt); err != nil {
+			return nil, err
+		}
+		return signer.Sign(rcpt)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestRotateKeepsIdentityAndHistory(t *testing.T) {
+	f := newFixture(t)
+	old := f.signer(t)
+	identity := old.PublicKeyBase64()
+	before := f.commit(t, old)
+
+	next, err := Rotate(f.keyPath, nil, f.journal, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.PublicKeyBase64() != identity {
+		t.Error("rotation changed the provider identity")
+	}
+	if next.KeyID() == old.KeyID() {
+		t.Error("rotation kept the old signing key")
+	}
+	if _, err := os.Stat(f.keyPath + PendingSuffix); !os.IsNotExist(err) {
+		t.Error("staged key left behind")
+	}
+
+	// The installed key and chain survive a reload
+	reloaded := f.signer(t)
+	if reloaded.KeyID() != next.KeyID() || len(reloaded.Successions()) != 1 {
+		t.Fatalf("reloaded signer has kid %s and %d successions", reloaded.KeyID(), len(reloaded.Successions()))
+	}
+
+	// The key event is the journal head and verifies under the identity
+	seq, _ := f.journal.Head()
+	head, _ := f.journal.Since(seq-1, 1)
+	event := head[0]
+	if event.Receipt.KeyEvent == nil || event.Receipt.KeyEvent.Certificate.EffectiveSeq != 2 {
+		t.Fatalf("journal head is not the key event: %+v", event.Receipt)
+	}
+	if valid, err := receipt.VerifySignature(event, identity); !valid {
+		t.Errorf("key event does not verify: %v", err)
+	}
+
+	// History signed by the old key stays valid
+	if valid, err := receipt.VerifySignature(before, identity); !valid {
+		t.Errorf("pre-rotation receipt rejected: %v", err)
+	}
+
+	after := f.commit(t, reloaded)
+	if valid, err := receipt.VerifySignature(after, identity); !valid {
+		t.Errorf("post-rotation receipt rejected: %v", err)
+	}
+
+	// The old key signing past the rotation point is revoked once the
+	// verifier knows the chain
+	forged := sign(t, old, 4)
+	forged.Succession = reloaded.Successions()
+	if valid, err := receipt.VerifySignature(forged, identity); valid || !errors.Is(err, receipt.ErrRevokedKey) {
+		t.Errorf("revoked key accepted: valid=%v err=%v", valid, err)
+	}
+}
+
+func TestRotateCompromisedRevokesEarlier(t *testing.T) {
+	f := newFixture(t)
+	old := f.signer(t)
+	identity := old.PublicKeyBase64()
+	first := f.commit(t, old)
+	second := f.commit(t, old)
+
+	next, err := Rotate(f.keyPath, nil, f.journal, Options{Reason: receipt.ReasonCompromised, RevokeFromSeq: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain := next.Successions()
+
+	first.Succession = chain
+	if valid, err := receipt.VerifySignature(first, identity); !valid {
+		t.Errorf("receipt before the revocation point rejected: %v", err)
+	}
+	second.Succession = chain
+	if valid, _ := receipt.VerifySignature(second, identity); valid {
+		t.Error("receipt after the revocation point accepted")
+	}
+
+	if _, err := Rotate(f.keyPath, nil, f.journal, Options{RevokeFromSeq: 99}); err == nil {
+		t.Error("expected error for revocation point after the rotation point")
+	}
+	if _, err := os.Stat(f.keyPath + PendingSuffix); !os.IsNotExist(err) {
+		t.Error("failed rotation left a staged key")
+	}
+}
+
+func TestRecoverAfterCrash(t *testing.T) {
+	f := newFixture(t)
+	old := f.signer(t)
+	f.commit(t, old)
+
+	// Crash after the journal commit, before the new key was installed
+	_, nextKey, _ := ed25519.GenerateKey(rand.Reader)
+	if err := writeKey(f.keyPath+PendingSuffix, nil, nextKey); err != nil {
+		t.Fatal(err)
+	}
+	_, err := f.journal.Commit(func(seq int64, prevHash string) (*receipt.SignedReceipt, error) {
+		next, err := old.Successor(nextKey, seq, seq, receipt.ReasonScheduled, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		chain := next.Successions()
+		return signKeyEvent(next, &chain[0], seq, prevHash, time.Now(), epoch.DefaultSchedule())
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recovered, err := Recover(f.keyPath, nil, f.journal)
+	if err != nil || !recovered {
+		t.Fatalf("Recover = %v, %v", recovered, err)
+	}
+	if got := f.signer(t).KeyID(); got != receipt.KeyID(nextKey.Public().(ed25519.PublicKey)) {
+		t.Errorf("recovered key id %s", got)
+	}
+
+	// Crash before the commit: the staged key is discarded
+	_, orphan, _ := ed25519.GenerateKey(rand.Reader)
+	if err := writeKey(f.keyPath+PendingSuffix, nil, orphan); err != nil {
+		t.Fatal(err)
+	}
+	before := f.signer(t).KeyID()
+	recovered, err = Recover(f.keyPath, nil, f.journal)
+	if err != nil || recovered {
+		t.Fatalf("Recover = %v, %v", recovered, err)
+	}
+	if f.signer(t).KeyID() != before {
+		t.Error("uncommitted rotation changed the key")
+	}
+	if _, err := os.Stat(f.keyPath + PendingSuffix); !os.IsNotExist(err) {
+		t.Error("staged key not discarded")
+	}
+}
+
+// hexCodec stands in for the keystore: keys on disk are hex with a prefix.
+type hexCodec struct{}
+
+func (hexCodec) Encode(key []byte) ([]byte, error) {
+	return []byte("hex:" + hex.EncodeToString(key)), nil
+}
+
+func (hexCodec) Decode(data []byte) ([]byte, bool, error) {
+	if !bytes.HasPrefix(data, []byte("hex:")) {
+		return data, true, nil
+	}
+	key, err := hex.DecodeString(string(data[4:]))
+	return key, false, err
+}
+
+func TestRotateWithCodec(t *testing.T) {
+	f := newFixture(t)
+	identity := f.signer(t).PublicKeyBase64()
+
+	// The plaintext key written by the fixture is migrated on load
+	old, err := receipt.NewSignerWithCodec(f.keyPath, hexCodec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(f.keyPath); !bytes.HasPrefix(data, []byte("hex:")) {
+		t.Fatal("plaintext key not migrated")
+	}
+	if old.PublicKeyBase64() != identity {
+		t.Fatal("migration changed the key")
+	}
+
+	next, err := Rotate(f.keyPath, hexCodec{}, f.journal, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(f.keyPath); !bytes.HasPrefix(data, []byte("hex:")) {
+		t.Error("rotated key not encoded")
+	}
+
+	reloaded, err := receipt.NewSignerWithCodec(f.keyPath, hexCodec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.KeyID() != next.KeyID() || reloaded.PublicKeyBase64() != identity {
+		t.Errorf("reloaded kid %s identity %s", reloaded.KeyID(), reloaded.PublicKeyBase64())
+	}
+}
@@ -4,10 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"sync"
 	"time"
 
 	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/quiver/provider/pkg/epoch"
+	"github.com/quiver/provider/pkg/journal"
 	"github.com/quiver/provider/pkg/llm"
 	"github.com/quiver/provider/pkg/metrics"
 	"github.com/quiver/provider/pkg/receipt"
@@ -15,46 +16,83 @@ import (
 	"golang.org/x/time/rate"
 )
 
+// DefaultCheckpointTokens is how many streamed tokens a provider emits
+// between signed checkpoint receipts.
+const DefaultCheckpointTokens = 32
+
 type Request struct {
 	Prompt    string `json:"prompt"`
 	Model     string `json:"model"`
 	MaxTokens int    `json:"max_tokens"`
+	// Stream asks for chunks as they are generated, interleaved with
+	// signed checkpoint receipts
+	Stream bool `json:"stream,omitempty"`
 }
 
+// Response is one message on the stream. Non-streaming requests get a single
+// response with Completion and Receipt. Streaming requests get one message
+// per Chunk, a Checkpoint message every few tokens and a final message with
+// Receipt. Messages carrying a Receipt also carry the PeerBinding that ties
+// this peer to the receipt identity.
 type Response struct {
-	Completion string                 `json:"completion"`
-	Receipt    *receipt.SignedReceipt `json:"receipt"`
-	Error      string                 `json:"error,omitempty"`
+	Completion  string                     `json:"completion"`
+	Chunk       string                     `json:"chunk,omitempty"`
+	Checkpoint  *receipt.SignedReceipt     `json:"checkpoint,omitempty"`
+	Receipt     *receipt.SignedReceipt     `json:"receipt"`
+	PeerBinding *receipt.SignedPeerBinding `json:"peer_binding,omitempty"`
+	Error       string                     `json:"error,omitempty"`
 }
 
 type Handler struct {
-	llmClient      *llm.Client
-	signer         *receipt.Signer
-	maxPromptBytes int
-	limiter        *rate.Limiter
-	logger         *logrus.Logger
-	
-	// Protected by mu
-	mu       sync.Mutex
-	prevHash string
-	sequence int64
+	llmClient        *llm.Client
+	signer           *receipt.Signer
+	journal          *journal.Journal
+	maxPromptBytes   int
+	checkpointTokens int
+	binding          *receipt.SignedPeerBinding
+	schedule         *epoch.Schedule
+	limiter          *rate.Limiter
+	logger           *logrus.Logger
 }
 
-func NewHandler(llmClient *llm.Client, signer *receipt.Signer, maxPromptBytes int, tokensPerSecond int) *Handler {
+// NewHandler creates a stream handler. Receipts are chained and committed
+// through j, so the chain head survives provider restarts.
+func NewHandler(llmClient *llm.Client, signer *receipt.Signer, j *journal.Journal, maxPromptBytes int, tokensPerSecond int) *Handler {
 	logger := logrus.New()
 	logger.SetFormatter(&logrus.JSONFormatter{})
 
 	return &Handler{
-		llmClient:      llmClient,
-		signer:         signer,
-		maxPromptBytes: maxPromptBytes,
-		limiter:        rate.NewLimiter(rate.Limit(tokensPerSecond), tokensPerSecond*2),
-		logger:         logger,
-		prevHash:       "",
-		sequence:       0,
+		llmClient:        llmClient,
+		signer:           signer,
+		journal:          j,
+		maxPromptBytes:   maxPromptBytes,
+		checkpointTokens: DefaultCheckpointTokens,
+		schedule:         epoch.DefaultSchedule(),
+		limiter:          rate.NewLimiter(rate.Limit(tokensPerSecond), tokensPerSecond*2),
+		logger:           logger,
 	}
 }
 
+// SetCheckpointInterval sets how many tokens of a streamed generation pass
+// between checkpoint receipts.
+func (h *Handler) SetCheckpointInterval(tokens int) {
+	if tokens > 0 {
+		h.checkpointTokens = tokens
+	}
+}
+
+// SetSchedule sets how receipts are numbered into epochs. It must match the
+// aggregator's schedule.
+func (h *Handler) SetSchedule(s *epoch.Schedule) {
+	h.schedule = s
+}
+
+// SetPeerBinding sets the binding of this host's peer ID to the signer's
+// identity, sent alongside every final receipt.
+func (h *Handler) SetPeerBinding(binding *receipt.SignedPeerBinding) {
+	h.binding = binding
+}
+
 func (h *Handler) HandleStream(s network.Stream) {
 	defer s.Close()
 	metrics.ActiveStreams.Inc()
@@ -83,6 +121,11 @@ func (h *Handler) HandleStream(s network.Stream) {
 		return
 	}
 
+	if req.Stream {
+		h.handleStreaming(ctx, s, &req)
+		return
+	}
+
 	start := time.Now()
 
 	llmResp, promptHash, outputHash, err := h.llmClient.Generate(ctx, req.Prompt, req.Model)
@@ -96,40 +139,10 @@ func (h *Handler) HandleStream(s network.Stream) {
 	duration := end.Sub(start).Seconds()
 	metrics.RequestDuration.WithLabelValues(req.Model).Observe(duration)
 
-	// Protect sequence counter and prevHash with mutex
-	h.mu.Lock()
-	h.sequence++
-	currentSeq := h.sequence
-	currentPrevHash := h.prevHash
-	h.mu.Unlock()
-
-	rcpt := receipt.NewReceipt(
-		h.signer.PublicKeyBase64(),
-		req.Model,
-		promptHash,
-		outputHash,
-		llmResp.PromptEvalCount,
-		llmResp.EvalCount,
-		start,
-		end,
-	)
-	rcpt.PrevHash = currentPrevHash
-	rcpt.Seq = currentSeq
-
-	canonical, err := receipt.CanonicalizeJSON(rcpt)
+	signedReceipt, err := h.commitReceipt(req.Model, promptHash, outputHash, llmResp.PromptEvalCount, llmResp.EvalCount, start, end, nil, false)
 	if err != nil {
-		h.sendError(s, "failed to canonicalize receipt")
-		return
-	}
-	
-	// Update prevHash under lock
-	h.mu.Lock()
-	h.prevHash = receipt.HashData(canonical)
-	h.mu.Unlock()
-
-	signedReceipt, err := h.signer.Sign(rcpt)
-	if err != nil {
-		h.sendError(s, "failed to sign receipt")
+		h.logger.WithError(err).Error("failed to commit receipt")
+		h.sendError(s, "failed to commit receipt")
 		metrics.RequestsTotal.WithLabelValues(req.Model, "sign_error").Inc()
 		return
 	}
@@ -141,12 +154,13 @@ func (h *Handler) HandleStream(s network.Stream) {
 		"tokens_in":   llmResp.PromptEvalCount,
 		"tokens_out":  llmResp.EvalCount,
 		"duration_ms": end.Sub(start).Milliseconds(),
-		"receipt_id":  rcpt.ReceiptID,
+		"receipt_id":  signedReceipt.Receipt.ReceiptID,
 	}).Info("request processed")
 
 	resp := Response{
-		Completion: llmResp.Response,
-		Receipt:    signedReceipt,
+		Completion:  llmResp.Response,
+		Receipt:     signedReceipt,
+		PeerBinding: h.binding,
 	}
 
 	encoder := json.NewEncoder(s)
@@ -160,6 +174,184 @@ func (h *Handler) HandleStream(s network.Stream) {
 	}
 }
 
+// handleStreaming relays chunks as they are generated. Every checkpointTokens
+// chunks it signs a checkpoint receipt over the rolling output hash, and when
+// generation ends it commits a final receipt that chains the checkpoints. If
+// the client goes away generation stops and the final receipt covers what
+// was produced, marked truncated.
+func (h *Handler) handleStreaming(ctx context.Context, s network.Stream, req *Request) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	start := time.Now()
+	promptHash := receipt.HashData([]byte(req.Prompt))
+	hasher := receipt.NewStreamHasher()
+	encoder := json.NewEncoder(s)
+
+	var (
+		index          int
+		prevCheckpoint string
+		clientGone     bool
+	)
+
+	llmResp, genErr := h.llmClient.GenerateStream(ctx, req.Prompt, req.Model, func(chunk string) error {
+		hasher.Write(chunk)
+		if err := encoder.Encode(Response{Chunk: chunk}); err != nil {
+			clientGone = true
+			return err
+		}
+		if hasher.Chunks()%h.checkpointTokens != 0 {
+			return nil
+		}
+
+		index++
+		checkpoint, err := h.signCheckpoint(req.Model, promptHash, hasher, start, &receipt.StreamInfo{
+			Index:          index,
+			Chunks:         hasher.Chunks(),
+			PrevCheckpoint: prevCheckpoint,
+		})
+		if err != nil {
+			return err
+		}
+		if prevCheckpoint, err = receipt.CheckpointHash(&checkpoint.Receipt); err != nil {
+			return err
+		}
+		if err := encoder.Encode(Response{Checkpoint: checkpoint}); err != nil {
+			clientGone = true
+			return err
+		}
+		return nil
+	})
+
+	if hasher.Chunks() == 0 {
+		msg := "no output generated"
+		if genErr != nil {
+			msg = fmt.Sprintf("llm error: %v", genErr)
+		}
+		h.sendError(s, msg)
+		metrics.RequestsTotal.WithLabelValues(req.Model, "error").Inc()
+		return
+	}
+
+	end := time.Now()
+	metrics.RequestDuration.WithLabelValues(req.Model).Observe(end.Sub(start).Seconds())
+
+	tokensIn, tokensOut := 0, hasher.Chunks()
+	if llmResp != nil {
+		tokensIn, tokensOut = llmResp.PromptEvalCount, llmResp.EvalCount
+	}
+
+	signedReceipt, err := h.commitReceipt(req.Model, promptHash, hasher.Sum(), tokensIn, tokensOut, start, end, &receipt.StreamInfo{
+		Index:          index + 1,
+		Chunks:         hasher.Chunks(),
+		PrevCheckpoint: prevCheckpoint,
+		Final:          true,
+	}, genErr != nil)
+	if err != nil {
+		h.logger.WithError(err).Error("failed to commit receipt")
+		if !clientGone {
+			h.sendError(s, "failed to commit receipt")
+		}
+		metrics.RequestsTotal.WithLabelValues(req.Model, "sign_error").Inc()
+		return
+	}
+	metrics.ReceiptSignatures.Inc()
+	metrics.TokensProcessed.WithLabelValues("input").Add(float64(tokensIn))
+	metrics.TokensProcessed.WithLabelValues("output").Add(float64(tokensOut))
+
+	h.logger.WithFields(logrus.Fields{
+		"prompt_hash": promptHash,
+		"output_hash": signedReceipt.Receipt.OutputHash,
+		"tokens_in":   tokensIn,
+		"tokens_out":  tokensOut,
+		"checkpoints": index,
+		"truncated":   genErr != nil,
+		"duration_ms": end.Sub(start).Milliseconds(),
+		"receipt_id":  signedReceipt.Receipt.ReceiptID,
+	}).Info("stream processed")
+
+	if clientGone {
+		metrics.RequestsTotal.WithLabelValues(req.Model, "client_gone").Inc()
+		return
+	}
+
+	if err := encoder.Encode(Response{Receipt: signedReceipt, PeerBinding: h.binding}); err != nil {
+		h.logger.WithError(err).Error("failed to encode response")
+		metrics.RequestsTotal.WithLabelValues(req.Model, "encode_error").Inc()
+		return
+	}
+	if genErr != nil {
+		metrics.RequestsTotal.WithLabelValues(req.Model, "error").Inc()
+		return
+	}
+	metrics.RequestsTotal.WithLabelValues(req.Model, "success").Inc()
+}
+
+// signCheckpoint signs an interim receipt over the output streamed so far.
+// Checkpoints sit outside the journal chain and are not settled.
+func (h *Handler) signCheckpoint(model, promptHash string, hasher *receipt.StreamHasher, start time.Time, info *receipt.StreamInfo) (*receipt.SignedReceipt, error) {
+	rcpt, err := receipt.NewReceipt(
+		h.signer.PublicKeyBase64(),
+		model,
+		promptHash,
+		hasher.Sum(),
+		0,
+		hasher.Chunks(),
+		start,
+		time.Now(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	rcpt.Epoch = int64(h.schedule.EpochAt(start))
+	rcpt.Seq = 0
+	rcpt.Stream = info
+
+	if err := receipt.SetReceiptID(rcpt); err != nil {
+		return nil, err
+	}
+
+	signed, err := h.signer.Sign(rcpt)
+	if err != nil {
+		return nil, err
+	}
+	metrics.CheckpointReceipts.Inc()
+	return signed, nil
+}
+
+// commitReceipt builds, signs and journals the next receipt in the chain.
+// info is set for streamed generations; truncated marks output cut short.
+// Chain position, signing and the durable append happen under the journal
+// lock so the receipt handed out is always the committed one.
+func (h *Handler) commitReceipt(model, promptHash, outputHash string, tokensIn, tokensOut int, start, end time.Time, info *receipt.StreamInfo, truncated bool) (*receipt.SignedReceipt, error) {
+	return h.journal.Commit(func(seq int64, prevHash string) (*receipt.SignedReceipt, error) {
+		rcpt, err := receipt.NewReceipt(
+			h.signer.PublicKeyBase64(),
+			model,
+			promptHash,
+			outputHash,
+			tokensIn,
+			tokensOut,
+			start,
+			end,
+		)
+		if err != nil {
+			return nil, err
+		}
+		rcpt.Epoch = int64(h.schedule.EpochAt(start))
+		rcpt.Seq = seq
+		rcpt.PrevHash = prevHash
+		rcpt.Stream = info
+		rcpt.Rate.Truncated = truncated
+
+		if err := receipt.SetReceiptID(rcpt); err != nil {
+			return nil, err
+		}
+
+		return h.signer.Sign(rcpt)
+	})
+}
+
 func (h *Handler) sendError(s network.Stream, msg string) {
 	resp := Response{Error: msg}
 	encoder := json.NewEncoder(s)
@@ -2,12 +2,20 @@ package stream
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/quiver/provider/pkg/epoch"
+	"github.com/quiver/provider/pkg/journal"
+	"github.com/quiver/provider/pkg/llm"
 	"github.com/quiver/provider/pkg/receipt"
 )
 
@@ -93,7 +101,7 @@ func TestReceiptGeneration(t *testing.T) {
 	defer os.Remove("test.key")
 
 	// Test that receipts are generated with proper fields
-	rcpt := receipt.NewReceipt(
+	rcpt, err := receipt.NewReceipt(
 		signer.PublicKeyBase64(),
 		"test-model",
 		"prompt_hash",
@@ -103,9 +111,12 @@ func TestReceiptGeneration(t *testing.T) {
 		time.Now(),
 		time.Now().Add(100*time.Millisecond),
 	)
+	if err != nil {
+		t.Fatal(err)
+	}
 
-	if rcpt.Version != "1.0.0" {
-		t.Errorf("Expected version 1.0.0, got %s", rcpt.Version)
+	if rcpt.Version != receipt.VersionV2 {
+		t.Errorf("Expected version %s, got %s", receipt.VersionV2, rcpt.Version)
 	}
 
 	if rcpt.ReceiptID == "" {
@@ -122,3 +133,199 @@ func TestReceiptGeneration(t *testing.T) {
 		t.Error("Signature should not be empty")
 	}
 }
+
+// ollamaStream serves chunks the way Ollama's streaming /api/generate does.
+func ollamaStream(t *testing.T, chunks []string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoder := json.NewEncoder(w)
+		for _, chunk := range chunks {
+			encoder.Encode(map[string]interface{}{"response": chunk, "done": false})
+		}
+		encoder.Encode(map[string]interface{}{"response": "", "done": true, "prompt_eval_count": 4, "eval_count": len(chunks)})
+	}))
+}
+
+func newStreamingHandler(t *testing.T, ollamaURL string) (*Handler, *journal.Journal) {
+	t.Helper()
+	dir := t.TempDir()
+
+	signer, err := receipt.NewSigner(filepath.Join(dir, "provider.key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	j, err := journal.Open(filepath.Join(dir, "receipts.journal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { j.Close() })
+
+	h := NewHandler(llm.NewClient(ollamaURL), signer, j, 4096, 1000)
+	h.SetCheckpointInterval(2)
+	return h, j
+}
+
+func streamRequest(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	body, _ := json.Marshal(Request{Prompt: "hello", Model: "test-model", Stream: true})
+	return bytes.NewBuffer(body)
+}
+
+func TestStreamingCheckpoints(t *testing.T) {
+	chunks := []string{"a", "b", "c", "d", "e"}
+	server := ollamaStream(t, chunks)
+	defer server.Close()
+
+	h, j := newStreamingHandler(t, server.URL)
+	binding, err := h.signer.BindPeer("12D3KooWTestPeer", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.SetPeerBinding(binding)
+
+	s := &mockStream{input: streamRequest(t), output: &bytes.Buffer{}}
+	h.HandleStream(s)
+
+	var received []string
+	var chain []*receipt.Receipt
+	var final *receipt.SignedReceipt
+	var finalBinding *receipt.SignedPeerBinding
+	decoder := json.NewDecoder(s.output)
+	for decoder.More() {
+		var msg Response
+		if err := decoder.Decode(&msg); err != nil {
+			t.Fatal(err)
+		}
+		switch {
+		case msg.Error != "":
+			t.Fatalf("unexpected error: %s", msg.Error)
+		case msg.Chunk != "":
+			received = append(received, msg.Chunk)
+		case msg.Checkpoint != nil:
+			if err := receipt.VerifyStreamPrefix(received, &msg.Checkpoint.Receipt); err != nil {
+				t.Errorf("checkpoint %d: %v", msg.Checkpoint.Receipt.Stream.Index, err)
+			}
+			chain = append(chain, &msg.Checkpoint.Receipt)
+		case msg.Receipt != nil:
+			final = msg.Receipt
+			finalBinding = msg.PeerBinding
+		}
+	}
+
+	if len(received) != len(chunks) {
+		t.Fatalf("received %d chunks, want %d", len(received), len(chunks))
+	}
+	if len(chain) != 2 {
+		t.Fatalf("got %d checkpoints, want 2", len(chain))
+	}
+	if final == nil {
+		t.Fatal("no final receipt")
+	}
+
+	chain = append(chain, &final.Receipt)
+	if err := receipt.VerifyStreamChain(chain); err != nil {
+		t.Errorf("checkpoint chain rejected: %v", err)
+	}
+	if !final.Receipt.Stream.Final || final.Receipt.Rate.Truncated {
+		t.Errorf("unexpected final stream state %+v, truncated=%v", final.Receipt.Stream, final.Receipt.Rate.Truncated)
+	}
+	if final.Receipt.OutputHash != receipt.StreamHash(chunks) {
+		t.Error("final output hash does not cover the whole stream")
+	}
+	if seq, _ := j.Head(); seq != 1 || final.Receipt.Seq != 1 {
+		t.Errorf("only the final receipt should be journaled, head seq %d", seq)
+	}
+
+	if finalBinding == nil || finalBinding.Binding.ProviderPK != final.Receipt.ProviderPK {
+		t.Fatal("final receipt not sent with a peer binding for its provider")
+	}
+	if err := receipt.VerifyPeerBinding(finalBinding, "12D3KooWTestPeer"); err != nil {
+		t.Errorf("peer binding rejected: %v", err)
+	}
+}
+
+// brokenStream accepts a fixed number of writes and then fails, like a
+// client that disconnected mid-stream.
+type brokenStream struct {
+	mockStream
+	writes int
+}
+
+func (b *brokenStream) Write(p []byte) (int, error) {
+	if b.writes == 0 {
+		return 0, errors.New("stream reset")
+	}
+	b.writes--
+	return b.mockStream.Write(p)
+}
+
+func TestStreamingClientDisconnect(t *testing.T) {
+	server := ollamaStream(t, []string{"a", "b", "c", "d", "e"})
+	defer server.Close()
+
+	h, j := newStreamingHandler(t, server.URL)
+	// Two chunks and the first checkpoint get through
+	s := &brokenStream{
+		mockStream: mockStream{input: streamRequest(t), output: &bytes.Buffer{}},
+		writes:     3,
+	}
+	h.HandleStream(s)
+
+	committed, err := j.Since(0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(committed) != 1 {
+		t.Fatalf("expected the truncated final receipt in the journal, got %d", len(committed))
+	}
+	final := committed[0].Receipt
+	if !final.Rate.Truncated || !final.Stream.Final {
+		t.Errorf("expected truncated final receipt, got %+v truncated=%v", final.Stream, final.Rate.Truncated)
+	}
+	if final.Stream.Chunks != 3 || final.TokensOut != 3 {
+		t.Errorf("expected 3 chunks billed, got chunks=%d tokens=%d", final.Stream.Chunks, final.TokensOut)
+	}
+}
+
+func TestReceiptsUseSchedule(t *testing.T) {
+	server := ollamaStream(t, []string{"a", "b", "c"})
+	defer server.Close()
+
+	h, _ := newStreamingHandler(t, server.URL)
+	schedule, err := epoch.NewSchedule(time.Now().Add(-241*time.Hour), []epoch.Period{{StartEpoch: 0, Length: 24 * time.Hour}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.SetSchedule(schedule)
+
+	s := &mockStream{input: streamRequest(t), output: &bytes.Buffer{}}
+	h.HandleStream(s)
+
+	var epochs []int64
+	decoder := json.NewDecoder(s.output)
+	for decoder.More() {
+		var msg Response
+		if err := decoder.Decode(&msg); err != nil {
+			t.Fatal(err)
+		}
+		for _, signed := range []*receipt.SignedReceipt{msg.Checkpoint, msg.Receipt} {
+			if signed == nil {
+				continue
+			}
+			epochs = append(epochs, signed.Receipt.Epoch)
+			id := signed.Receipt.ReceiptID
+			if receipt.SetReceiptID(&signed.Receipt); signed.Receipt.ReceiptID != id {
+				t.Error("receipt ID does not cover the scheduled epoch")
+			}
+		}
+	}
+
+	if len(epochs) != 2 {
+		t.Fatalf("got %d receipts, want a checkpoint and a final receipt", len(epochs))
+	}
+	for _, n := range epochs {
+		if n != 10 {
+			t.Errorf("receipt in epoch %d, want 10", n)
+		}
+	}
+}
@@ -167,7 +167,8 @@ async def test_e2e_epoch_settlement():
                 }
             )
             
-            assert commit_resp.status_code == 200
+            # Open epochs answer 202 until they are sealed
+            assert commit_resp.status_code in (200, 202)
             commit_data = commit_resp.json()
             
             # Verify claim
@@ -175,7 +176,7 @@ async def test_e2e_epoch_settlement():
                 f"{AGGREGATOR_URL}/claim",
                 json={
                     "receipt_id": receipts[0]["receipt"]["receipt_id"],
-                    "merkle_proof": [],  # Would be from commit response
+                    "merkle_proof": {"leaf_index": 0, "tree_size": 1, "hashes": []},  # Would be from commit response
                     "epoch": epoch
                 }
             )
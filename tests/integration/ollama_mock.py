@@ -23,7 +23,13 @@ class GenerateResponse(BaseModel):
 CANARY_RESPONSES = {
     "What is the capital of France?": "The capital of France is Paris.",
     "Calculate 2 + 2": "2 + 2 equals 4.",
-    "Who wrote Romeo and Juliet?": "William Shakespeare wrote Romeo and Juliet."
+    "Who wrote Romeo and Juliet?": "William Shakespeare wrote Romeo and Juliet.",
+    # The gateway's canary pool, canaries.json
+    "I'm putting together a geography quiz for my class. What is the capital city of Australia? Just give the city name.": "Canberra",
+    "Converting an old recipe: at sea level, what temperature in Celsius does water boil at? Reply with just the number.": "100",
+    "Who wrote the novel Nineteen Eighty-Four? Just the author's name please.": "George Orwell",
+    "Settle an argument for me: which planet in our solar system is the largest? One word.": "Jupiter.",
+    "What is 255 in hexadecimal? Reply with only the value.": "FF",
 }
 
 DETERMINISTIC_RESPONSES = {
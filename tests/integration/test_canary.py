@@ -2,29 +2,26 @@ import pytest
 import httpx
 import asyncio
 import os
-import json
+import re
 
 GATEWAY_URL = os.getenv("GATEWAY_URL", "http://localhost:8080")
 
-CANARY_PROMPTS = [
-    "What is the capital of France?",
-    "Calculate 2 + 2",
-    "Who wrote Romeo and Juliet?"
-]
+CANARY_METRIC = re.compile(r'^gateway_canary_checks_total\{result="(\w+)"\} (\S+)$', re.M)
 
-EXPECTED_ANSWERS = {
-    "What is the capital of France?": "Paris",
-    "Calculate 2 + 2": "4",
-    "Who wrote Romeo and Juliet?": "William Shakespeare"
-}
+async def canary_checks(client):
+    """Returns the gateway's canary check counts by result"""
+    resp = await client.get(f"{GATEWAY_URL}/metrics")
+    assert resp.status_code == 200
+    return {result: float(n) for result, n in CANARY_METRIC.findall(resp.text)}
 
 @pytest.mark.asyncio
 async def test_canary_execution():
-    async with httpx.AsyncClient() as client:
-        canary_count = 0
+    async with httpx.AsyncClient(timeout=30.0) as client:
+        before = await canary_checks(client)
         total_requests = 100
-        
-        # Make many requests to trigger canaries
+
+        # Canaries follow ordinary requests as separate requests, so the
+        # answers clients see are never replaced
         for i in range(total_requests):
             resp = await client.post(
                 f"{GATEWAY_URL}/generate",
@@ -34,74 +31,38 @@ async def test_canary_execution():
                     "token": "canary-test"
                 }
             )
-            
             if resp.status_code == 200:
-                data = resp.json()
-                receipt = data.get("receipt", {})
-                canary = receipt.get("canary", {})
-                
-                if canary.get("id"):
-                    canary_count += 1
-                    
-                    # Verify canary was properly hidden
-                    assert data["completion"] == "Canary response hidden"
-        
-        # Should have ~5% canaries
-        canary_rate = canary_count / total_requests
-        assert 0.02 <= canary_rate <= 0.08, f"Canary rate {canary_rate} outside expected range"
+                assert resp.json()["completion"].startswith("Deterministic response")
+
+        # Canaries run in the background; give them time to finish
+        await asyncio.sleep(5)
+        after = await canary_checks(client)
+        checks = sum(after.values()) - sum(before.values())
+
+        # About 5% of requests are followed by a canary
+        assert 1 <= checks <= 20, f"{checks} canaries after {total_requests} requests"
 
 @pytest.mark.asyncio
 async def test_canary_validation():
     async with httpx.AsyncClient(timeout=30.0) as client:
-        passed_count = 0
-        failed_count = 0
-        
-        # Force canaries by using known prompts
-        for _ in range(20):
-            for prompt in CANARY_PROMPTS:
-                # Make request that should trigger canary
-                resp = await client.post(
-                    f"{GATEWAY_URL}/generate",
-                    json={
-                        "prompt": prompt,
-                        "model": "llama2",
-                        "token": "canary-validate"
-                    }
-                )
-                
-                if resp.status_code == 200:
-                    data = resp.json()
-                    receipt = data.get("receipt", {})
-                    canary = receipt.get("canary", {})
-                    
-                    if canary.get("passed") is not None:
-                        if canary["passed"]:
-                            passed_count += 1
-                        else:
-                            failed_count += 1
-                
-                await asyncio.sleep(0.1)  # Rate limit
-        
-        # Canary pass rate should be very high
-        if passed_count + failed_count > 0:
-            pass_rate = passed_count / (passed_count + failed_count)
-            assert pass_rate >= 0.99, f"Canary pass rate {pass_rate} below 99%"
+        before = await canary_checks(client)
 
-@pytest.mark.asyncio
-async def test_canary_slashing_stub():
-    """Test that failed canaries log slashing intent"""
-    async with httpx.AsyncClient() as client:
-        # This would need access to provider logs in real test
-        # For now, just verify the canary mechanism works
-        
-        resp = await client.post(
-            f"{GATEWAY_URL}/generate",
-            json={
-                "prompt": "Test for slashing",
-                "model": "llama2",
-                "token": "slashing-test"
-            }
-        )
-        
-        assert resp.status_code == 200
-        # In real implementation, would check logs for slashing messages
\ No newline at end of file
+        for i in range(100):
+            await client.post(
+                f"{GATEWAY_URL}/generate",
+                json={
+                    "prompt": f"Validation prompt {i}",
+                    "model": "llama2",
+                    "token": "canary-validate"
+                }
+            )
+            await asyncio.sleep(0.1)  # Rate limit
+
+        await asyncio.sleep(5)
+        after = await canary_checks(client)
+        passed = after.get("pass", 0) - before.get("pass", 0)
+        failed = after.get("fail", 0) - before.get("fail", 0)
+
+        # The mock Ollama answers every canary correctly
+        assert failed == 0, f"{failed} canaries failed against an honest provider"
+        assert passed > 0
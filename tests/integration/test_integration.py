@@ -53,9 +53,11 @@ async def test_provider_gateway_aggregator_flow():
                 "receipts": [{"receipt": r, "signature": "test-sig"} for r in receipts]
             }
         )
-        assert commit_resp.status_code == 200
+        # The epoch is still open, so the receipts are stored but not
+        # sealed until its grace window has passed
+        assert commit_resp.status_code == 202
         commit_data = commit_resp.json()
-        assert "merkle_root" in commit_data
+        assert commit_data["state"] == "open"
         assert commit_data["receipt_count"] == len(receipts)
 
 @pytest.mark.asyncio
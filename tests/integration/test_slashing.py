@@ -1,81 +1,64 @@
 import pytest
 import httpx
-import asyncio
 import os
-import json
 
 GATEWAY_URL = os.getenv("GATEWAY_URL", "http://localhost:8080")
 
+async def evidence(aggregator_client, **params):
+    """Returns the aggregator's recorded canary evidence, skipping the test
+    if the aggregator does not accept evidence"""
+    resp = await aggregator_client.get("/evidence", params=params)
+    if resp.status_code == 503:
+        pytest.skip("aggregator has no QUIVER_GATEWAY_KEYS")
+    assert resp.status_code == 200
+    return resp.json()["evidence"]
+
 @pytest.mark.asyncio
-async def test_canary_fail_slashing_log(gateway_client, caplog):
-    """Test that failed canaries produce slashing intent logs"""
-    
-    # Force canary by using known prompt
-    canary_prompt = "What is the capital of France?"
-    
-    # Make request that should trigger canary
-    response = await gateway_client.post(
-        "/generate",
-        json={
-            "prompt": canary_prompt,
-            "model": "llama2",
-            "token": "slashing-test"
-        }
-    )
-    
-    assert response.status_code == 200
-    data = response.json()
-    
-    # Check if canary was triggered
-    receipt = data.get("receipt", {})
-    canary = receipt.get("canary", {})
-    
-    if canary.get("id"):
-        # Check canary result
-        if not canary.get("passed", True):
-            # Failed canary detected
-            print(f"SLASHING STUB: Provider {receipt.get('provider_pk')} failed canary check")
-            print(f"  Canary ID: {canary.get('id')}")
-            print(f"  Receipt ID: {receipt.get('receipt_id')}")
-            print(f"  Epoch: {receipt.get('epoch')}")
-            
-            # In production, this would:
-            # 1. Record the failure in persistent storage
-            # 2. Accumulate evidence for on-chain slashing
-            # 3. Potentially pause the provider
-            # 4. Notify monitoring systems
-            
-            assert "Canary response hidden" in data["completion"]
+async def test_honest_provider_not_slashed(gateway_client, aggregator_client):
+    """An honest provider answers its canaries and has no evidence against it"""
+    provider = None
+    for i in range(50):
+        response = await gateway_client.post(
+            "/generate",
+            json={
+                "prompt": f"Slashing prompt {i}",
+                "model": "llama2",
+                "token": "slashing-test"
+            }
+        )
+        assert response.status_code == 200
+
+        receipt = response.json().get("receipt", {})
+        # Providers cannot tell canaries apart, so receipts never mark one
+        assert not receipt.get("canary", {}).get("id")
+        provider = receipt.get("provider_pk") or provider
+
+    if provider:
+        assert await evidence(aggregator_client, provider=provider) == []
 
 @pytest.mark.asyncio
-async def test_slashing_accumulation():
-    """Test slashing evidence accumulation"""
-    
-    slashing_events = []
-    
-    class SlashingCollector:
-        def record_failure(self, provider_id, receipt_id, reason):
-            slashing_events.append({
-                "provider_id": provider_id,
-                "receipt_id": receipt_id,
-                "reason": reason,
-                "timestamp": asyncio.get_event_loop().time()
-            })
-            
-        def should_slash(self, provider_id):
-            # Slash after 3 failures
-            failures = [e for e in slashing_events if e["provider_id"] == provider_id]
-            return len(failures) >= 3
-    
-    collector = SlashingCollector()
-    
-    # Simulate failures
-    for i in range(5):
-        collector.record_failure("provider-1", f"receipt-{i}", "canary_failed")
-    
-    assert collector.should_slash("provider-1")
-    assert not collector.should_slash("provider-2")
-    
-    # Verify events were recorded
-    assert len(slashing_events) == 5
-    assert all(e["reason"] == "canary_failed" for e in slashing_events)
\ No newline at end of file
+async def test_evidence_rejected(aggregator_client):
+    """Evidence must be signed by a trusted gateway and hold up"""
+    await evidence(aggregator_client)
+
+    resp = await aggregator_client.post("/evidence", content=b"{", headers={"Content-Type": "application/json"})
+    assert resp.status_code == 400
+
+    forged = {
+        "evidence": {
+            "version": "1",
+            "canary_id": "capital-france",
+            "prompt": "What is the capital of France? Answer in one word.",
+            "answers": ["Paris"],
+            "completion": "Lyon.",
+            "receipt": {},
+            "gateway_pk": "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=",
+            "issued_at": "2024-01-01T00:00:05Z"
+        },
+        "alg": "ed25519",
+        "signature": ""
+    }
+    resp = await aggregator_client.post("/evidence", json=forged)
+    assert resp.status_code == 403
+
+    assert await evidence(aggregator_client, epoch="0") == []